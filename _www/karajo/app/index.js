@@ -1,6 +1,10 @@
 // SPDX-FileCopyrightText: 2022 M. Shulhan <ms@kilabit.info>
 // SPDX-License-Identifier: GPL-3.0-or-later
 
+// The interval, in milliseconds, to fetch the changes of jobs.
+const CHANGES_INTERVAL = 5000;
+
+let _changes = { epoch: 0, revision: 0 };
 let _env = {};
 let _httpJobs = {};
 let _jobs = {};
@@ -14,6 +18,8 @@ async function main() {
   werr.innerHTML = "";
 
   doRefresh();
+
+  setInterval(doRefreshChanges, CHANGES_INTERVAL);
 }
 
 async function doRefresh() {
@@ -26,10 +32,54 @@ async function doRefresh() {
 
   _env = res.data;
   setTitle();
+  renderReadOnly();
   document.getElementById("version").innerText = _env.version;
 
   renderJobs(_env.jobs);
   renderHttpJobs(_env.http_jobs);
+
+  if (_env.peers != null) {
+    doRefreshPeers();
+  }
+}
+
+// doRefreshChanges fetch and render only the jobs whose state changed since
+// the last revision.
+async function doRefreshChanges() {
+  let fres = await fetch(`/karajo/api/changes?since=${_changes.revision}`);
+  let res = await fres.json();
+  if (res.code != 200) {
+    document.getElementById("err").innerHTML = res.message;
+    return;
+  }
+
+  let changes = res.data;
+  if (_changes.epoch != 0 && _changes.epoch != changes.epoch) {
+    // The server has been restarted, reload the whole environment.
+    _changes = { epoch: changes.epoch, revision: 0 };
+    doRefresh();
+    return;
+  }
+  _changes.epoch = changes.epoch;
+  _changes.revision = changes.revision;
+
+  if (changes.jobs != null) {
+    renderJobs(changes.jobs);
+  }
+  if (changes.http_jobs != null) {
+    renderHttpJobs(changes.http_jobs);
+  }
+}
+
+// doRefreshPeers fetch and render the jobs status from all peers.
+async function doRefreshPeers() {
+  let fres = await fetch("/karajo/api/federation");
+  let res = await fres.json();
+  if (res.code != 200) {
+    document.getElementById("err").innerHTML = res.message;
+    return;
+  }
+  renderPeers(res.data);
 }
 
 function setTitle() {
@@ -37,6 +87,46 @@ function setTitle() {
   document.getElementById("title").innerHTML = _env.name;
 }
 
+function renderReadOnly() {
+  let el = document.getElementById("read_only");
+  let elToggle = document.getElementById("read_only_toggle");
+  if (_env.read_only) {
+    el.style.display = "block";
+    elToggle.innerText = "Disable read-only";
+  } else {
+    el.style.display = "none";
+    elToggle.innerText = "Enable read-only";
+  }
+}
+
+async function envReadOnlyToggle() {
+  let secret = document.getElementById("_secret").value;
+  let epoch = parseInt(new Date().valueOf() / 1000);
+  let body = `_karajo_epoch=${epoch}&read_only=${!_env.read_only}`;
+
+  let hash = CryptoJS.HmacSHA256(body, secret);
+  let sign = hash.toString(CryptoJS.enc.Hex);
+
+  let fres = await fetch("/karajo/api/environment/read_only", {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/x-www-form-urlencoded;charset=UTF-8",
+      "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
+    },
+    body: body,
+  });
+
+  let res = await fres.json();
+  if (res.code !== 200) {
+    console.error(res.message);
+    return;
+  }
+
+  _env.read_only = res.data;
+  renderReadOnly();
+}
+
 function runTimer() {
   let elTimer = document.getElementById("timer");
 
@@ -45,6 +135,63 @@ function runTimer() {
   }, 1000);
 }
 
+// csrfToken return the CSRF token from cookie, that must be sent on
+// state-changing request.
+function csrfToken() {
+  for (let c of document.cookie.split(";")) {
+    let [name, value] = c.trim().split("=");
+    if (name === "karajo_csrf") {
+      return value;
+    }
+  }
+  return "";
+}
+
+async function doLogout() {
+  let fres = await fetch("/karajo/api/auth/logout", {
+    method: "POST",
+    headers: {
+      "x-karajo-csrf": csrfToken(),
+    },
+  });
+
+  let res = await fres.json();
+  if (res.code !== 200) {
+    console.error(res.message);
+    return;
+  }
+
+  window.location = "/karajo/";
+}
+
+async function jobApprove(id) {
+  let secret = document.getElementById("_secret").value;
+  let epoch = parseInt(new Date().valueOf() / 1000);
+  let body = `_karajo_epoch=${epoch}&id=${id}`;
+
+  let hash = CryptoJS.HmacSHA256(body, secret);
+  let sign = hash.toString(CryptoJS.enc.Hex);
+
+  let fres = await fetch("/karajo/api/job_exec/approve", {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/x-www-form-urlencoded;charset=UTF-8",
+      "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
+    },
+    body: body,
+  });
+
+  let res = await fres.json();
+  if (res.code !== 200) {
+    console.error(res.message);
+    return;
+  }
+
+  let job = res.data;
+  renderJobs([job]);
+}
+
 async function jobCancel(id) {
   let secret = document.getElementById("_secret").value;
   let epoch = parseInt(new Date().valueOf() / 1000);
@@ -58,6 +205,7 @@ async function jobCancel(id) {
     headers: {
       "Content-Type": "application/x-www-form-urlencoded;charset=UTF-8",
       "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
     },
     body: body,
   });
@@ -93,11 +241,23 @@ async function jobRunNow(jobID, jobPath) {
   let req = {
     _karajo_epoch: epoch,
   };
+  if (job.run_params) {
+    req.params = {};
+    for (const name of job.run_params) {
+      let value = prompt(`Parameter "${name}":`);
+      if (value === null) {
+        return;
+      }
+      req.params[name] = value;
+    }
+  }
   let body = JSON.stringify(req);
 
   let hash = CryptoJS.HmacSHA256(body, secret);
   let sign = hash.toString(CryptoJS.enc.Hex);
-  let headers = {};
+  let headers = {
+    "x-karajo-csrf": csrfToken(),
+  };
 
   switch (job.auth_kind) {
     case "github":
@@ -109,14 +269,18 @@ async function jobRunNow(jobID, jobPath) {
     // TODO: CryptoJS does not support ed25519 so we cannot support sourcehut auth right now.
   }
 
-  let fres = await fetch(`/karajo/api/job_exec/run${jobPath}`, {
+  let runPath = `/karajo/api/job_exec/run${jobPath}`;
+  if (job.namespace) {
+    runPath = `/karajo/api/ns/${job.namespace}/job_exec/run${jobPath}`;
+  }
+  let fres = await fetch(runPath, {
     method: "POST",
     headers: headers,
     body: body,
   });
 
   let res = await fres.json();
-  if (res.code !== 200) {
+  if (res.code !== 200 && res.code !== 202) {
     console.error(res.message);
     return;
   }
@@ -126,9 +290,17 @@ async function jobRunNow(jobID, jobPath) {
 }
 
 async function jobPause(id) {
+  let duration = promptPauseDuration();
+  if (duration === null) {
+    return;
+  }
+
   let secret = document.getElementById("_secret").value;
   let epoch = parseInt(new Date().valueOf() / 1000);
   let body = `_karajo_epoch=${epoch}&id=${id}`;
+  if (duration) {
+    body += `&duration=${encodeURIComponent(duration)}`;
+  }
 
   let hash = CryptoJS.HmacSHA256(body, secret);
   let sign = hash.toString(CryptoJS.enc.Hex);
@@ -138,6 +310,7 @@ async function jobPause(id) {
     headers: {
       "Content-Type": "application/x-www-form-urlencoded;charset=UTF-8",
       "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
     },
     body: body,
   });
@@ -152,6 +325,22 @@ async function jobPause(id) {
   renderJobs([job]);
 }
 
+// promptPauseDuration ask the duration to pause the job, for example
+// "1h30m".
+// It return empty string to pause the job until resumed manually, or null
+// if the user cancel it.
+function promptPauseDuration() {
+  let duration = prompt(
+    "Pause for duration, for example 1h30m.\n" +
+      "Leave it empty to pause until resumed.",
+    "",
+  );
+  if (duration === null) {
+    return null;
+  }
+  return duration.trim();
+}
+
 async function jobResume(id) {
   let secret = document.getElementById("_secret").value;
   let epoch = parseInt(new Date().valueOf() / 1000);
@@ -165,6 +354,39 @@ async function jobResume(id) {
     headers: {
       "Content-Type": "application/x-www-form-urlencoded;charset=UTF-8",
       "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
+    },
+    body: body,
+  });
+
+  let res = await fres.json();
+  if (res.code !== 200) {
+    console.error(res.message);
+    return;
+  }
+
+  let job = res.data;
+  renderJobs([job]);
+}
+
+// jobSetDisabled disable or enable the JobExec.
+// The disabled job is not run, even after the server restarted, until it
+// enabled again.
+async function jobSetDisabled(id, disabled) {
+  let secret = document.getElementById("_secret").value;
+  let epoch = parseInt(new Date().valueOf() / 1000);
+  let body = `_karajo_epoch=${epoch}&id=${id}`;
+  let path = disabled ? "disable" : "enable";
+
+  let hash = CryptoJS.HmacSHA256(body, secret);
+  let sign = hash.toString(CryptoJS.enc.Hex);
+
+  let fres = await fetch(`/karajo/api/job_exec/${path}`, {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/x-www-form-urlencoded;charset=UTF-8",
+      "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
     },
     body: body,
   });
@@ -194,9 +416,17 @@ async function jobHttpInfo(jobID) {
 }
 
 async function jobHttpPause(id) {
+  let duration = promptPauseDuration();
+  if (duration === null) {
+    return;
+  }
+
   let secret = document.getElementById("_secret").value;
   let epoch = parseInt(new Date().valueOf() / 1000);
   let q = `_karajo_epoch=${epoch}&id=${id}`;
+  if (duration) {
+    q += `&duration=${encodeURIComponent(duration)}`;
+  }
 
   let hash = CryptoJS.HmacSHA256(q, secret);
   let sign = hash.toString(CryptoJS.enc.Hex);
@@ -205,6 +435,37 @@ async function jobHttpPause(id) {
     method: "POST",
     headers: {
       "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
+    },
+  });
+  let res = await fres.json();
+  if (res.code !== 200) {
+    console.error(res.message);
+    return;
+  }
+
+  let job = _httpJobs[id];
+  delete job.disabled;
+  delete job.paused_until;
+  job = Object.assign(job, res.data);
+  renderJobHttp(job);
+}
+
+// jobHttpSetDisabled disable or enable the JobHttp.
+async function jobHttpSetDisabled(id, disabled) {
+  let secret = document.getElementById("_secret").value;
+  let epoch = parseInt(new Date().valueOf() / 1000);
+  let q = `_karajo_epoch=${epoch}&id=${id}`;
+  let path = disabled ? "disable" : "enable";
+
+  let hash = CryptoJS.HmacSHA256(q, secret);
+  let sign = hash.toString(CryptoJS.enc.Hex);
+
+  let fres = await fetch(`/karajo/api/job_http/${path}?` + q, {
+    method: "POST",
+    headers: {
+      "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
     },
   });
   let res = await fres.json();
@@ -214,6 +475,7 @@ async function jobHttpPause(id) {
   }
 
   let job = _httpJobs[id];
+  delete job.disabled;
   job = Object.assign(job, res.data);
   renderJobHttp(job);
 }
@@ -230,6 +492,7 @@ async function jobHttpResume(id) {
     method: "POST",
     headers: {
       "x-karajo-sign": sign,
+      "x-karajo-csrf": csrfToken(),
     },
   });
   let res = await fres.json();
@@ -239,6 +502,8 @@ async function jobHttpResume(id) {
   }
 
   let job = _httpJobs[id];
+  delete job.disabled;
+  delete job.paused_until;
   job = Object.assign(job, res.data);
   renderJobHttp(job);
 }
@@ -265,6 +530,19 @@ function renderJobAttributes(job) {
     <div>Last run: ${job.last_run}</div>
   `;
 
+  if (job.disabled) {
+    out += `<div>Disabled: true</div>`;
+  }
+  if (job.owner) {
+    out += `<div>Owner: ${job.owner}</div>`;
+  }
+  if (job.allowed_users) {
+    out += `<div>Allowed users: ${job.allowed_users.join(", ")}</div>`;
+  }
+  if (job.paused_until) {
+    out += `<div>Paused until: ${job.paused_until}</div>`;
+  }
+
   if (job.schedule) {
     out += `
       <div>Schedule: ${job.schedule}</div>
@@ -288,6 +566,20 @@ function renderJobAttributes(job) {
     out += `</div>`;
   }
 
+  if (job.steps) {
+    out += `
+      <br/>
+      <div class="job_commands">steps:
+    `;
+    job.steps.forEach(function (step, idx, list) {
+      out += `<div> ${step.name}:</div>`;
+      step.commands.forEach(function (cmd, x) {
+        out += `<div> &nbsp; ${x}: <tt>${cmd}</tt> </div>`;
+      });
+    });
+    out += `</div>`;
+  }
+
   out += `
     <br/>
     <div>Log:
@@ -298,19 +590,43 @@ function renderJobAttributes(job) {
   }
 
   job.logs.forEach(function (log, idx, list) {
+    let exit = "";
+    let title = log.status;
+    if (log.exit) {
+      exit = log.exit.signal ? log.exit.signal : log.exit.code;
+      exit = ` (${exit})`;
+      title = `${log.status}: command ${log.exit.command} exit with code ${log.exit.code}`;
+      if (log.exit.signal) {
+        title += `, signal ${log.exit.signal}`;
+      }
+    }
+    if (log.steps) {
+      log.steps.forEach(function (step) {
+        title += `\n${step.name}: ${step.status}`;
+      });
+    }
     out += `<a
       href="/karajo/job_exec/log/?id=${job.id}&counter=${log.counter}"
       target="_blank"
       class="job-log ${log.status}"
+      title="${title}"
     >
-        #${log.counter}
+        #${log.counter}${exit}
     </a>`;
   });
 
   out += `&nbsp;<button onclick="jobRunNow('${job.id}', '${job.path}')">Run now</button>`;
+  if (job.approval) {
+    out += `&nbsp;<button onclick="jobApprove('${job.id}')">Approve</button>`;
+  }
   out += `&nbsp;<button onclick="jobCancel('${job.id}')">Cancel</button>`;
   out += `&nbsp;<button onclick="jobPause('${job.id}')">Pause</button>`;
   out += `&nbsp;<button onclick="jobResume('${job.id}')">Resume</button>`;
+  if (job.disabled) {
+    out += `&nbsp;<button onclick="jobSetDisabled('${job.id}', false)">Enable</button>`;
+  } else {
+    out += `&nbsp;<button onclick="jobSetDisabled('${job.id}', true)">Disable</button>`;
+  }
 
   out += "</div>";
 
@@ -323,6 +639,23 @@ function renderJobStatusRight(job) {
   let now = new Date();
   let nextRun = new Date(job.next_run);
 
+  if (job.approval && new Date(job.approval.expired_at) > now) {
+    let requester = job.approval.requester || "webhook";
+    elNextRun.innerText = `Waiting approval, requested by ${requester}`;
+    return;
+  }
+
+  if (job.disabled) {
+    elNextRun.innerText = "Disabled";
+    return;
+  }
+
+  if (job.status === "paused" && job.paused_until) {
+    let pausedUntil = new Date(job.paused_until);
+    elNextRun.innerText = `Paused until ${pausedUntil.toUTCString()}`;
+    return;
+  }
+
   if (nextRun <= 0) {
     let lastRun = new Date(job.last_run);
     if (lastRun > 0) {
@@ -430,6 +763,19 @@ function renderJobHttpAttrs(job) {
     <div>Status: ${job.status || ""}</div>
   `;
 
+  if (job.disabled) {
+    out += `<div>Disabled: true</div>`;
+  }
+  if (job.owner) {
+    out += `<div>Owner: ${job.owner}</div>`;
+  }
+  if (job.allowed_users) {
+    out += `<div>Allowed users: ${job.allowed_users.join(", ")}</div>`;
+  }
+  if (job.paused_until) {
+    out += `<div>Paused until: ${job.paused_until}</div>`;
+  }
+
   out += `
     <br/>
     <div>Log:
@@ -456,6 +802,11 @@ function renderJobHttpAttrs(job) {
   } else {
     out += `<button onclick="jobHttpPause('${job.id}')">Pause</button>`;
   }
+  if (job.disabled) {
+    out += `&nbsp;<button onclick="jobHttpSetDisabled('${job.id}', false)">Enable</button>`;
+  } else {
+    out += `&nbsp;<button onclick="jobHttpSetDisabled('${job.id}', true)">Disable</button>`;
+  }
 
   out += `</div>`;
   el.innerHTML = out;
@@ -464,6 +815,17 @@ function renderJobHttpAttrs(job) {
 function renderJobHttpNextRun(job) {
   let elNextRun = document.getElementById(job._idStatusRight);
 
+  if (job.disabled) {
+    elNextRun.innerText = "Disabled";
+    return;
+  }
+
+  if (job.status === "paused" && job.paused_until) {
+    let pausedUntil = new Date(job.paused_until);
+    elNextRun.innerText = `Paused until ${pausedUntil.toUTCString()}`;
+    return;
+  }
+
   let now = new Date();
   let nextRun = new Date(job.next_run);
 
@@ -532,3 +894,73 @@ function renderHttpJobs(httpJobs) {
     renderJobHttp(httpJob);
   }
 }
+
+// renderPeers render the jobs from each peer as read-only list.
+// The values from peer are not trusted, so they are set as text instead
+// of HTML.
+function renderPeers(listPeer) {
+  let elPeers = document.getElementById("peers");
+  elPeers.replaceChildren();
+
+  listPeer.forEach(function (peer) {
+    let elPeer = document.createElement("div");
+    elPeer.className = "peer";
+
+    let elLink = document.createElement("a");
+    if (/^https?:\/\//.test(peer.url)) {
+      elLink.setAttribute("href", `${peer.url}/karajo/`);
+    }
+    elLink.setAttribute("target", "_blank");
+    elLink.textContent = peer.name;
+
+    let elLastFetch = document.createElement("span");
+    elLastFetch.className = "status_right";
+    elLastFetch.textContent = `Last fetch ${new Date(peer.last_fetch).toUTCString()}`;
+
+    let elTitle = document.createElement("h4");
+    elTitle.append(elLink, " ", elLastFetch);
+    elPeer.append(elTitle);
+
+    if (peer.error) {
+      let elErr = document.createElement("div");
+      elErr.className = "name failed";
+      elErr.textContent = peer.error;
+      elPeer.append(elErr);
+      elPeers.append(elPeer);
+      return;
+    }
+
+    let env = peer.env;
+    for (let name in env.jobs) {
+      let job = env.jobs[name];
+      elPeer.append(renderPeerJob(job, ""));
+    }
+    for (let name in env.http_jobs) {
+      let job = env.http_jobs[name];
+      elPeer.append(renderPeerJob(job, "HTTP"));
+    }
+    elPeers.append(elPeer);
+  });
+
+  document.getElementById("peers_section").style.display = "block";
+}
+
+// renderPeerJob return the element of single job from peer.
+function renderPeerJob(job, kind) {
+  let elJob = document.createElement("div");
+  elJob.classList.add("name");
+  if (/^[a-z]+$/.test(job.status)) {
+    elJob.classList.add(job.status);
+  }
+  elJob.append(`${kind} ${job.name}`);
+
+  let elLastRun = document.createElement("span");
+  elLastRun.className = "status_right";
+  let t = new Date(job.last_run);
+  if (t > 0) {
+    elLastRun.textContent = `Last run ${t.toUTCString()}`;
+  }
+  elJob.append(elLastRun);
+
+  return elJob;
+}
@@ -0,0 +1,176 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+const apiSchedule = "/karajo/api/schedule";
+const hourMs = 3600 * 1000;
+const dayMs = 24 * hourMs;
+
+async function main() {
+  setRange(-dayMs, dayMs);
+  await doRefresh();
+}
+
+// setRange set the input "from" and "to" relative to the current time.
+function setRange(before, after) {
+  let now = Date.now();
+  document.getElementById("from").value = toInputValue(new Date(now + before));
+  document.getElementById("to").value = toInputValue(new Date(now + after));
+}
+
+async function doPreset(before, after) {
+  setRange(before, after);
+  await doRefresh();
+}
+
+async function doRefresh() {
+  let elErr = document.getElementById("err");
+  elErr.innerText = "";
+
+  let from = fromInputValue(document.getElementById("from").value);
+  let to = fromInputValue(document.getElementById("to").value);
+  let params = new URLSearchParams({
+    from: from.toISOString().replace(/\.\d+Z$/, "Z"),
+    to: to.toISOString().replace(/\.\d+Z$/, "Z"),
+  });
+
+  let fres = await fetch(`${apiSchedule}?${params}`);
+  let res = await fres.json();
+  if (res.code != 200) {
+    elErr.innerText = res.message;
+    return;
+  }
+
+  renderTimeline(res.data);
+  renderCalendar(res.data);
+}
+
+// renderTimeline render each job as a row, with the past runs as bar and
+// the next runs as tick, relative to the range "from" and "to".
+function renderTimeline(sch) {
+  let from = new Date(sch.from).getTime();
+  let to = new Date(sch.to).getTime();
+  let span = to - from;
+  let now = Date.now();
+
+  let pos = function (t) {
+    let p = ((t - from) / span) * 100;
+    return Math.min(Math.max(p, 0), 100);
+  };
+
+  let out = `
+    <div class="row axis">
+      <div class="label"></div>
+      <div class="track">
+        <span style="left: 0%">${new Date(from).toUTCString()}</span>
+        <span style="right: 0%">${new Date(to).toUTCString()}</span>
+      </div>
+    </div>
+  `;
+
+  sch.jobs.forEach(function (job) {
+    out += `
+      <div class="row">
+        <div class="label" title="${job.kind}">${job.name}</div>
+        <div class="track">
+    `;
+
+    if (now >= from && now <= to) {
+      out += `<div class="now" style="left: ${pos(now)}%"></div>`;
+    }
+
+    job.runs.forEach(function (run) {
+      let begin = new Date(run.begin).getTime();
+      let end = run.end ? new Date(run.end).getTime() : now;
+      let left = pos(begin);
+      let width = Math.max(pos(end) - left, 0.2);
+      out += `<a
+        class="run ${run.status}"
+        style="left: ${left}%; width: ${width}%"
+        href="${logURL(job, run.counter)}"
+        target="_blank"
+        title="#${run.counter} ${run.status} ${new Date(begin).toUTCString()}"
+      ></a>`;
+    });
+
+    job.next_runs.forEach(function (next) {
+      let t = new Date(next).getTime();
+      out += `<div
+        class="next"
+        style="left: ${pos(t)}%"
+        title="Next run ${new Date(t).toUTCString()}"
+      ></div>`;
+    });
+
+    out += "</div></div>";
+  });
+
+  document.getElementById("timeline").innerHTML = out;
+}
+
+// renderCalendar render each day in the range as a cell, listing the past
+// runs and the number of upcoming runs on that day.
+function renderCalendar(sch) {
+  let days = {};
+  let dayKey = function (t) {
+    return new Date(t).toISOString().substring(0, 10);
+  };
+
+  let from = new Date(sch.from);
+  let to = new Date(sch.to);
+  let day = Date.UTC(from.getUTCFullYear(), from.getUTCMonth(), from.getUTCDate());
+  for (; day <= to.getTime(); day += dayMs) {
+    days[dayKey(day)] = { runs: [], nnext: 0 };
+  }
+
+  sch.jobs.forEach(function (job) {
+    job.runs.forEach(function (run) {
+      let d = days[dayKey(run.begin)];
+      if (d) {
+        d.runs.push({ job: job, run: run });
+      }
+    });
+    job.next_runs.forEach(function (next) {
+      let d = days[dayKey(next)];
+      if (d) {
+        d.nnext++;
+      }
+    });
+  });
+
+  let out = "";
+  for (let key in days) {
+    let d = days[key];
+    out += `<div class="day"><div class="date">${key}</div>`;
+    d.runs.forEach(function (item) {
+      out += `<a
+        class="dot ${item.run.status}"
+        href="${logURL(item.job, item.run.counter)}"
+        target="_blank"
+        title="${item.job.name} #${item.run.counter} ${item.run.status}"
+      ></a>`;
+    });
+    if (d.nnext > 0) {
+      out += `<div class="upcoming">${d.nnext} upcoming</div>`;
+    }
+    out += "</div>";
+  }
+
+  document.getElementById("calendar").innerHTML = out;
+}
+
+// logURL return the URL of log page for the job run.
+function logURL(job, counter) {
+  let kind = job.kind == "job" ? "job_exec" : job.kind;
+  return `/karajo/${kind}/log/?id=${job.id}&counter=${counter}`;
+}
+
+// toInputValue convert Date into the value of input datetime-local, in UTC.
+function toInputValue(date) {
+  return date.toISOString().substring(0, 16);
+}
+
+// fromInputValue convert the value of input datetime-local, in UTC, into
+// Date.
+function fromInputValue(v) {
+  return new Date(v + ":00Z");
+}
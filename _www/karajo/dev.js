@@ -0,0 +1,15 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// This script is injected into each WUI page only in development mode.
+// It reload the page when the files in "_www" changes or the server
+// restarted.
+(function () {
+  const apiDevReload = new URL("api/dev/reload", document.currentScript.src);
+  const es = new EventSource(apiDevReload);
+
+  es.addEventListener("reload", () => {
+    es.close();
+    window.location.reload();
+  });
+})();
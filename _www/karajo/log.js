@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// LogViewer display the job log from Server-Sent Events stream in
+// apiStream, and follow the new lines until the job finished.
+class LogViewer {
+  constructor(apiStream) {
+    this.apiStream = apiStream;
+    this.isFollow = true;
+    this.isPaused = false;
+    this.pending = [];
+
+    let params = new URLSearchParams(window.location.search);
+    document.title = `${params.get("id")} #${params.get("counter")}`;
+    document.getElementById("title").innerText = document.title;
+
+    this.elLog = document.getElementById("log");
+    this.elSteps = document.getElementById("steps");
+    this.steps = {};
+    this.elStatus = document.getElementById("status");
+    this.elFollow = document.getElementById("follow");
+    this.elPause = document.getElementById("pause");
+
+    this.elFollow.checked = this.isFollow;
+    this.elFollow.onchange = () => {
+      this.isFollow = this.elFollow.checked;
+      this.scroll();
+    };
+    this.elPause.onclick = () => {
+      this.togglePause();
+    };
+
+    // Stop following when user scroll up, and start following again
+    // when user scroll to the bottom.
+    window.onscroll = () => {
+      let atBottom =
+        window.innerHeight + window.scrollY >=
+        document.body.scrollHeight - 5;
+      if (this.isFollow != atBottom) {
+        this.isFollow = atBottom;
+        this.elFollow.checked = atBottom;
+      }
+    };
+  }
+
+  open() {
+    this.setStatus("connecting");
+
+    this.es = new EventSource(this.apiStream + window.location.search);
+    this.es.onopen = () => {
+      this.setStatus("running");
+    };
+    this.es.addEventListener("stdout", (ev) => {
+      this.append(ev.data, false);
+    });
+    this.es.addEventListener("stderr", (ev) => {
+      this.append(ev.data, true);
+    });
+    this.es.addEventListener("exit", (ev) => {
+      this.exit = ev.data;
+    });
+    this.es.addEventListener("end", (ev) => {
+      this.es.close();
+      this.setStatus(ev.data, this.exit);
+    });
+    this.es.addEventListener("error", (ev) => {
+      if (ev.data) {
+        this.es.close();
+        this.setStatus("failed", ev.data);
+        return;
+      }
+      // Connection lost, the EventSource will reconnect and
+      // continue from the last line using Last-Event-ID.
+      this.setStatus("reconnecting");
+    });
+  }
+
+  append(text, isStderr) {
+    let elLine = document.createElement("div");
+    elLine.innerText = text;
+    if (isStderr) {
+      elLine.className = "stderr";
+    }
+    if (text.endsWith("=== BEGIN") || text.endsWith("=== DONE")) {
+      elLine.className += " marker";
+    }
+    this.setStep(text);
+
+    if (this.isPaused) {
+      this.pending.push(elLine);
+      this.elPause.innerText = `Resume (${this.pending.length})`;
+      return;
+    }
+    this.elLog.appendChild(elLine);
+    this.scroll();
+  }
+
+  togglePause() {
+    this.isPaused = !this.isPaused;
+    if (this.isPaused) {
+      this.elPause.innerText = "Resume";
+      return;
+    }
+    this.pending.forEach((elLine) => {
+      this.elLog.appendChild(elLine);
+    });
+    this.pending = [];
+    this.elPause.innerText = "Pause";
+    this.scroll();
+  }
+
+  scroll() {
+    if (this.isFollow) {
+      window.scrollTo(0, document.body.scrollHeight);
+    }
+  }
+
+  // setStep render the step in the pipeline if the text is the status of
+  // step, "--- STEP: <name> <status> [<duration>]".
+  setStep(text) {
+    let match = text.match(/--- STEP: (\S+) (\S+)(?: (\S+))?$/);
+    if (!match || !this.elSteps) {
+      return;
+    }
+    let [, name, status, duration] = match;
+    let elStep = this.steps[name];
+    if (!elStep) {
+      elStep = document.createElement("span");
+      this.steps[name] = elStep;
+      this.elSteps.appendChild(elStep);
+    }
+    elStep.className = `step status ${status}`;
+    elStep.innerText = duration ? `${name} (${duration})` : name;
+    elStep.title = status;
+  }
+
+  setStatus(status, msg) {
+    this.elStatus.className = `status ${status}`;
+    this.elStatus.innerText = msg ? `${status}: ${msg}` : status;
+  }
+}
@@ -0,0 +1,44 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// The theme selected by user is stored in the local storage, and override
+// the default theme from server, which is set in attribute "data-theme"
+// of "html" element.
+const themeKey = "karajo.theme";
+const themeList = ["auto", "light", "dark"];
+
+function themeInit() {
+  let theme = localStorage.getItem(themeKey);
+  if (!themeList.includes(theme)) {
+    theme = document.documentElement.dataset.theme;
+  }
+  if (!themeList.includes(theme)) {
+    theme = "auto";
+  }
+  document.documentElement.dataset.theme = theme;
+
+  document.addEventListener("DOMContentLoaded", themeRenderToggle);
+}
+
+// themeToggle switch the theme to the next one in the list and store it in
+// the local storage.
+function themeToggle() {
+  let theme = document.documentElement.dataset.theme;
+  let idx = (themeList.indexOf(theme) + 1) % themeList.length;
+
+  theme = themeList[idx];
+  document.documentElement.dataset.theme = theme;
+  localStorage.setItem(themeKey, theme);
+
+  themeRenderToggle();
+}
+
+function themeRenderToggle() {
+  let el = document.getElementById("theme_toggle");
+  if (el) {
+    el.innerText = `Theme: ${document.documentElement.dataset.theme}`;
+  }
+}
+
+// Apply the theme as soon as possible to prevent flashing.
+themeInit();
@@ -0,0 +1,286 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defAgentLogInterval the interval for agent to send the output of
+// commands to the server.
+const defAgentLogInterval = time.Second
+
+// defAgentRetryDelay the delay before the agent poll the server again
+// after failure.
+const defAgentRetryDelay = 5 * time.Second
+
+// AgentOptions define the options for running [Agent].
+type AgentOptions struct {
+	// Name of agent.
+	// The JobExec with the same Target as Name are executed by this
+	// agent.
+	Name string
+
+	// DirWork the base working directory.
+	// Each job is executed inside the sub directory with the job ID as
+	// name.
+	// This field is optional, default to "/var/lib/karajo/agent".
+	DirWork string
+
+	ClientOptions
+}
+
+// Agent execute the Commands of JobExec on behalf of karajo server, the
+// controller.
+//
+// The Agent connect outbound to the server, poll the JobExec that target
+// its name, execute the Commands locally, and stream the output back to
+// the server.
+// The scheduling, the log, and the notification of the job are handled by
+// the server.
+type Agent struct {
+	cl    *Client
+	stopq chan struct{}
+	opts  AgentOptions
+}
+
+// NewAgent create new agent.
+func NewAgent(opts AgentOptions) (agent *Agent, err error) {
+	opts.Name = strings.TrimSpace(opts.Name)
+	if len(opts.Name) == 0 {
+		return nil, errors.New(`NewAgent: empty name`)
+	}
+	if len(opts.DirWork) == 0 {
+		opts.DirWork = filepath.Join(defDirBase, `var`, `lib`, defEnvName, `agent`)
+	}
+
+	// The poll request is hold by the server until new task is
+	// available, make sure the client does not timed out before.
+	if opts.Timeout <= defAgentPollWait {
+		opts.Timeout = defAgentPollWait + 10*time.Second
+	}
+	if opts.RequestTimeout > 0 && opts.RequestTimeout <= defAgentPollWait {
+		opts.RequestTimeout = defAgentPollWait + 10*time.Second
+	}
+
+	agent = &Agent{
+		stopq: make(chan struct{}, 1),
+		opts:  opts,
+	}
+
+	agent.cl, err = NewClient(opts.ClientOptions)
+	if err != nil {
+		return nil, fmt.Errorf(`NewAgent: %w`, err)
+	}
+	return agent, nil
+}
+
+// Start polling and executing the task until Stop is called.
+func (agent *Agent) Start() {
+	mlog.Outf(`agent: %s: polling %s`, agent.opts.Name, agent.opts.ServerURL)
+
+	var err error
+	for {
+		select {
+		case <-agent.stopq:
+			return
+		default:
+		}
+
+		err = agent.runOnce()
+		if err != nil {
+			mlog.Errf(`agent: %s: %s`, agent.opts.Name, err)
+
+			select {
+			case <-agent.stopq:
+				return
+			case <-time.After(defAgentRetryDelay):
+			}
+		}
+	}
+}
+
+// Stop the agent.
+// The Start return after the current poll request or the running task
+// is completed.
+func (agent *Agent) Stop() {
+	select {
+	case agent.stopq <- struct{}{}:
+	default:
+	}
+}
+
+// runOnce poll the task once and execute it, if any.
+func (agent *Agent) runOnce() (err error) {
+	var task *AgentTask
+
+	task, err = agent.cl.AgentPoll(agent.opts.Name)
+	if err != nil {
+		return err
+	}
+	if task == nil {
+		return nil
+	}
+
+	mlog.Outf(`agent: %s: executing %s`, agent.opts.Name, task.ID)
+
+	var (
+		ctx, cancel = context.WithCancel(context.Background())
+		alog        = &agentLog{
+			cl:     agent.cl,
+			id:     task.ID,
+			cancel: cancel,
+		}
+	)
+	defer cancel()
+
+	err = agent.execute(ctx, task, alog)
+	if ctx.Err() != nil {
+		// The task has been canceled by server.
+		mlog.Outf(`agent: %s: %s canceled`, agent.opts.Name, task.ID)
+		return nil
+	}
+
+	var errMsg string
+	if err != nil {
+		errMsg = err.Error()
+	}
+	return agent.cl.AgentDone(task.ID, errMsg)
+}
+
+// execute the Commands in the task and send the output to alog.
+func (agent *Agent) execute(ctx context.Context, task *AgentTask, alog *agentLog) (err error) {
+	var dirWork = filepath.Join(agent.opts.DirWork, task.JobID)
+
+	err = os.MkdirAll(dirWork, 0700)
+	if err != nil {
+		return err
+	}
+
+	var (
+		donec = make(chan struct{})
+		wg    sync.WaitGroup
+	)
+	wg.Add(1)
+	go func() {
+		alog.flushPeriodically(donec)
+		wg.Done()
+	}()
+
+	var (
+		env = append(os.Environ(),
+			fmt.Sprintf(`%s=%d`, jobEnvCounter, task.Counter))
+
+		execCmd       *exec.Cmd
+		cmd           string
+		x             int
+		isIgnoreError bool
+	)
+	env = append(env, task.Params...)
+
+	for x, cmd = range task.Commands {
+		fmt.Fprintf(alog, "\n--- Execute %2d: %s\n", x, cmd)
+
+		cmd, isIgnoreError = parseCommand(cmd, task.ContinueOnError)
+
+		execCmd = exec.CommandContext(ctx, `/bin/sh`, `-c`, cmd)
+		execCmd.Dir = dirWork
+		execCmd.Env = env
+		execCmd.Stdout = alog
+		execCmd.Stderr = alog
+		execCmd.WaitDelay = defJobExecWaitDelay
+
+		err = execCmd.Run()
+		if err != nil {
+			if isIgnoreError && ctx.Err() == nil {
+				fmt.Fprintf(alog, "--- Execute %2d: error ignored: %s\n", x, err)
+				err = nil
+				continue
+			}
+			break
+		}
+	}
+
+	close(donec)
+	wg.Wait()
+	alog.flush()
+
+	return err
+}
+
+// agentLog buffer the output of commands and send it to the server
+// periodically.
+type agentLog struct {
+	cl *Client
+
+	// cancel the commands when the server response that the task has
+	// been canceled.
+	cancel context.CancelFunc
+
+	id string
+
+	buf bytes.Buffer
+	mtx sync.Mutex
+}
+
+// Write the output of commands into buffer.
+func (alog *agentLog) Write(b []byte) (n int, err error) {
+	alog.mtx.Lock()
+	n, err = alog.buf.Write(b)
+	alog.mtx.Unlock()
+	return n, err
+}
+
+// flushPeriodically send the buffered output to the server until donec
+// is closed.
+func (alog *agentLog) flushPeriodically(donec chan struct{}) {
+	var ticker = time.NewTicker(defAgentLogInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			alog.flush()
+		case <-donec:
+			return
+		}
+	}
+}
+
+// flush send the buffered output to the server.
+func (alog *agentLog) flush() {
+	alog.mtx.Lock()
+	var output = bytes.Clone(alog.buf.Bytes())
+	alog.buf.Reset()
+	alog.mtx.Unlock()
+
+	if len(output) == 0 {
+		return
+	}
+
+	var err = alog.cl.AgentLog(alog.id, output)
+	if err == nil {
+		return
+	}
+
+	var res *libhttp.EndpointResponse
+	if errors.As(err, &res) && res.Code == http.StatusGone {
+		alog.cancel()
+		return
+	}
+	mlog.Errf(`agent: %s: %s`, alog.id, err)
+}
@@ -0,0 +1,215 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// defAgentPollWait the maximum time the agent poll request wait for new
+// task before returning empty response.
+const defAgentPollWait = 30 * time.Second
+
+// defAgentQueueSize the maximum number of task waiting to be polled by
+// each agent.
+const defAgentQueueSize = 64
+
+// AgentTask define the commands of JobExec to be executed by agent.
+type AgentTask struct {
+	// ID the unique identifier of task, generated from the JobLog name.
+	ID string `json:"id"`
+
+	// JobID the ID of JobExec.
+	JobID string `json:"job_id"`
+
+	// Commands list of command to be executed by agent.
+	Commands []string `json:"commands"`
+
+	// ContinueOnError if its true, all of the Commands are allowed to
+	// fail.
+	ContinueOnError bool `json:"continue_on_error,omitempty"`
+
+	// Counter the current job counter, passed as KARAJO_JOB_COUNTER to
+	// the commands.
+	Counter int64 `json:"counter"`
+
+	// Params list of "KARAJO_PARAM_<NAME>=<value>" from the request that
+	// trigger the job, passed as environment variables to the commands.
+	Params []string `json:"params,omitempty"`
+}
+
+// agentTask the state of AgentTask in the controller.
+type agentTask struct {
+	ctx  context.Context
+	jlog *JobLog
+
+	// done receive the result of task from agent.
+	done chan error
+
+	AgentTask
+}
+
+// agentHub dispatch the JobExec with Target to the agent and collect the
+// output and the result back.
+//
+// Each agent, identified by its name, have its own queue.
+// The agent poll the queue using HTTP API, execute the commands in its
+// host, and send the output and the result back to the controller.
+type agentHub struct {
+	// queues contains the task waiting to be polled, indexed by the
+	// agent name.
+	queues map[string]chan *agentTask
+
+	// tasks contains the task that has been dispatched and not done
+	// yet, indexed by task ID.
+	tasks map[string]*agentTask
+
+	mtx sync.Mutex
+}
+
+func newAgentHub() (hub *agentHub) {
+	hub = &agentHub{
+		queues: make(map[string]chan *agentTask),
+		tasks:  make(map[string]*agentTask),
+	}
+	return hub
+}
+
+// queue return the task queue for agent name, create it if its not
+// exist.
+func (hub *agentHub) queue(name string) (q chan *agentTask) {
+	hub.mtx.Lock()
+	q = hub.queues[name]
+	if q == nil {
+		q = make(chan *agentTask, defAgentQueueSize)
+		hub.queues[name] = q
+	}
+	hub.mtx.Unlock()
+	return q
+}
+
+// dispatch the Commands of JobExec, with its run parameters, into the
+// agent queue and wait until the agent report the result or the ctx is
+// canceled.
+func (hub *agentHub) dispatch(ctx context.Context, job *JobExec, jlog *JobLog, params []string) (err error) {
+	var task = &agentTask{
+		ctx:  ctx,
+		jlog: jlog,
+		done: make(chan error, 1),
+		AgentTask: AgentTask{
+			ID:              jlog.Name,
+			JobID:           job.ID,
+			Commands:        job.Commands,
+			ContinueOnError: job.ContinueOnError,
+			Counter:         jlog.Counter,
+			Params:          params,
+		},
+	}
+
+	hub.mtx.Lock()
+	hub.tasks[task.ID] = task
+	hub.mtx.Unlock()
+
+	defer func() {
+		hub.mtx.Lock()
+		delete(hub.tasks, task.ID)
+		hub.mtx.Unlock()
+	}()
+
+	fmt.Fprintf(jlog, "--- Dispatch to agent %q\n", job.Target)
+
+	select {
+	case hub.queue(job.Target) <- task:
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return fmt.Errorf(`agent %q: queue is full`, job.Target)
+	}
+
+	select {
+	case err = <-task.done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// poll wait for new task for agent name until ctx is done or the wait
+// duration is passed.
+// The task that has been canceled before its polled is skipped.
+func (hub *agentHub) poll(ctx context.Context, name string, wait time.Duration) (task *AgentTask) {
+	var (
+		q     = hub.queue(name)
+		timer = time.NewTimer(wait)
+		t     *agentTask
+	)
+	defer timer.Stop()
+
+	for {
+		select {
+		case t = <-q:
+			if t.ctx.Err() != nil {
+				continue
+			}
+			return &t.AgentTask
+		case <-ctx.Done():
+			return nil
+		case <-timer.C:
+			return nil
+		}
+	}
+}
+
+// get the running task by its ID.
+// It will return errJobCanceled if the task is not exist or has been
+// canceled, to signal the agent to stop executing the commands.
+func (hub *agentHub) get(id string) (task *agentTask, err error) {
+	hub.mtx.Lock()
+	task = hub.tasks[id]
+	hub.mtx.Unlock()
+
+	if task == nil || task.ctx.Err() != nil {
+		return nil, &errJobCanceled
+	}
+	return task, nil
+}
+
+// log write the output of task from agent into the JobLog.
+func (hub *agentHub) log(id string, output []byte) (err error) {
+	var task *agentTask
+
+	task, err = hub.get(id)
+	if err != nil {
+		return err
+	}
+	_, _ = task.jlog.Write(output)
+	return nil
+}
+
+// finish mark the task as done by agent.
+// The errMsg is the error from executing the commands, empty if all of
+// the commands run successfully.
+func (hub *agentHub) finish(id, errMsg string) (err error) {
+	var task *agentTask
+
+	task, err = hub.get(id)
+	if err != nil {
+		return err
+	}
+
+	var errTask error
+	if len(errMsg) != 0 {
+		errTask = errors.New(errMsg)
+	}
+	select {
+	case task.done <- errTask:
+	default:
+		// The task has been finished before.
+	}
+	return nil
+}
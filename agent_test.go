@@ -0,0 +1,173 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestAgent_runOnce(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddressAdmin: `127.0.0.1:0`,
+			DirBase:            t.TempDir(),
+			Secret:             `s3cret`,
+			ExecJobs: map[string]*JobExec{
+				`remote`: &JobExec{
+					Target: `a1`,
+					Commands: []string{
+						`echo hello from $KARAJO_JOB_COUNTER`,
+						`pwd`,
+					},
+				},
+				`fail`: &JobExec{
+					Target: `a1`,
+					Commands: []string{
+						`exit 3`,
+						`echo not executed`,
+					},
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	var srv = httptest.NewServer(k.HTTPd.Server.Handler)
+	t.Cleanup(srv.Close)
+
+	var (
+		dirWork   = t.TempDir()
+		agent     *Agent
+		agentOpts = AgentOptions{
+			Name:    `a1`,
+			DirWork: dirWork,
+			ClientOptions: ClientOptions{
+				ClientOptions: libhttp.ClientOptions{
+					ServerURL: srv.URL,
+				},
+				Secret: env.Secret,
+			},
+		}
+	)
+
+	agent, err = NewAgent(agentOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		jobID     string
+		expError  string
+		expOutput []string
+	}
+
+	var cases = []testCase{{
+		jobID: `remote`,
+		expOutput: []string{
+			`--- Dispatch to agent "a1"`,
+			`hello from 1`,
+			dirWork + `/remote`,
+			`=== DONE`,
+		},
+	}, {
+		jobID:    `fail`,
+		expError: `exit status 3`,
+	}}
+
+	var (
+		c      testCase
+		job    *JobExec
+		jlog   *JobLog
+		errRun error
+		resc   chan struct{}
+	)
+	for _, c = range cases {
+		job = env.ExecJobs[c.jobID]
+		resc = make(chan struct{})
+
+		go func() {
+			jlog, errRun = job.execute(nil)
+			close(resc)
+		}()
+
+		err = agent.runOnce()
+		if err != nil {
+			t.Fatal(err)
+		}
+		<-resc
+
+		var content, _ = jlog.read()
+
+		if len(c.expError) != 0 {
+			test.Assert(t, c.jobID+`: error`, c.expError, errRun.Error())
+			test.Assert(t, c.jobID+`: not executed`, false,
+				strings.Contains(string(content), `not executed`))
+			continue
+		}
+		test.Assert(t, c.jobID+`: error`, nil, errRun)
+
+		var (
+			got = string(content)
+			exp string
+		)
+		for _, exp = range c.expOutput {
+			test.Assert(t, c.jobID+`: `+exp, true, strings.Contains(got, exp))
+		}
+	}
+}
+
+func TestAgentHub_cancel(t *testing.T) {
+	var (
+		hub = newAgentHub()
+		job = &JobExec{
+			JobBase: JobBase{
+				ID: `remote`,
+			},
+			Target: `a1`,
+		}
+		jlog = &JobLog{
+			Name: `remote.1`,
+		}
+		ctx, cancel = context.WithCancel(context.Background())
+		errc        = make(chan error, 1)
+	)
+
+	go func() {
+		errc <- hub.dispatch(ctx, job, jlog, nil)
+	}()
+
+	var task = hub.poll(context.Background(), `a1`, time.Second)
+	test.Assert(t, `poll`, `remote.1`, task.ID)
+
+	var err = hub.log(task.ID, []byte("output\n"))
+	test.Assert(t, `log`, nil, err)
+
+	cancel()
+	test.Assert(t, `dispatch`, context.Canceled, <-errc)
+
+	// The agent receive canceled error on the next log.
+	err = hub.log(task.ID, []byte("output\n"))
+	test.Assert(t, `log after canceled`, &errJobCanceled, err)
+
+	// Empty poll.
+	task = hub.poll(context.Background(), `a1`, 10*time.Millisecond)
+	test.Assert(t, `empty poll`, (*AgentTask)(nil), task)
+}
@@ -0,0 +1,187 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	defAuthMaxAttempts     = 5
+	defAuthLockoutDuration = 15 * time.Minute
+
+	// defAuthMaxEntries the maximum number of IP address and user name
+	// recorded in authLimiter.
+	defAuthMaxEntries = 10000
+)
+
+// authAttempt contains the number of failed login from the same IP
+// address or for the same user name.
+type authAttempt struct {
+	// lastFail the time of the last failed login.
+	lastFail time.Time
+
+	// lockedUntil the time until the login is not allowed.
+	lockedUntil time.Time
+
+	// count the number of consecutive failed login since lastFail.
+	count int
+}
+
+// authLimiter limit the number of failed login per IP address and per
+// user name.
+// Once the number of failed login reach maxAttempts, the login from the
+// same IP address or for the same user name is locked for
+// lockoutDuration.
+type authLimiter struct {
+	// attempts contains the failed login indexed by "ip:<address>" or
+	// "user:<name>".
+	attempts map[string]*authAttempt
+
+	maxAttempts     int
+	maxEntries      int
+	lockoutDuration time.Duration
+
+	sync.Mutex
+}
+
+// newAuthLimiter create new authLimiter.
+// If maxAttempts or lockoutDuration is less or equal to zero, it will be
+// set to its default value.
+func newAuthLimiter(maxAttempts int, lockoutDuration time.Duration) (authl *authLimiter) {
+	if maxAttempts <= 0 {
+		maxAttempts = defAuthMaxAttempts
+	}
+	if lockoutDuration <= 0 {
+		lockoutDuration = defAuthLockoutDuration
+	}
+	authl = &authLimiter{
+		attempts:        make(map[string]*authAttempt),
+		maxAttempts:     maxAttempts,
+		maxEntries:      defAuthMaxEntries,
+		lockoutDuration: lockoutDuration,
+	}
+	return authl
+}
+
+// lockedUntil return the time until the login from ip or for user name is
+// allowed again.
+// It will return zero time if both of them is not locked.
+func (authl *authLimiter) lockedUntil(ip, name string) (until time.Time) {
+	var now = timeNow()
+
+	authl.Lock()
+	defer authl.Unlock()
+
+	var (
+		key string
+		att *authAttempt
+	)
+	for _, key = range authKeys(ip, name) {
+		att = authl.attempts[key]
+		if att == nil || !att.lockedUntil.After(now) {
+			continue
+		}
+		if att.lockedUntil.After(until) {
+			until = att.lockedUntil
+		}
+	}
+	return until
+}
+
+// fail record the failed login from ip for user name.
+// It return true if the ip or user name become locked after this
+// attempt.
+func (authl *authLimiter) fail(ip, name string) (isLocked bool) {
+	var now = timeNow()
+
+	authl.Lock()
+	defer authl.Unlock()
+
+	authl.prune(now)
+
+	var (
+		key string
+		att *authAttempt
+	)
+	for _, key = range authKeys(ip, name) {
+		att = authl.attempts[key]
+		if att == nil {
+			if len(authl.attempts) >= authl.maxEntries {
+				authl.evictOldest()
+			}
+			att = &authAttempt{}
+			authl.attempts[key] = att
+		}
+		att.count++
+		att.lastFail = now
+		if att.count >= authl.maxAttempts {
+			att.lockedUntil = now.Add(authl.lockoutDuration)
+			att.count = 0
+			isLocked = true
+		}
+	}
+	return isLocked
+}
+
+// succeed reset the failed login from ip and for user name.
+func (authl *authLimiter) succeed(ip, name string) {
+	authl.Lock()
+	var key string
+	for _, key = range authKeys(ip, name) {
+		delete(authl.attempts, key)
+	}
+	authl.Unlock()
+}
+
+// prune remove the attempts that has not been locked and has no failed
+// login in the last lockoutDuration.
+// The caller must hold the lock.
+func (authl *authLimiter) prune(now time.Time) {
+	var (
+		key string
+		att *authAttempt
+	)
+	for key, att = range authl.attempts {
+		if att.lockedUntil.After(now) {
+			continue
+		}
+		if now.Sub(att.lastFail) < authl.lockoutDuration {
+			continue
+		}
+		delete(authl.attempts, key)
+	}
+}
+
+// evictOldest remove the attempt with the oldest failed login, so the
+// number of attempts does not grow without limit.
+// The caller must hold the lock.
+func (authl *authLimiter) evictOldest() {
+	var (
+		oldestKey string
+		oldest    time.Time
+
+		key string
+		att *authAttempt
+	)
+	for key, att = range authl.attempts {
+		if len(oldestKey) == 0 || att.lastFail.Before(oldest) {
+			oldestKey = key
+			oldest = att.lastFail
+		}
+	}
+	delete(authl.attempts, oldestKey)
+}
+
+// authKeys return the keys of attempts for ip and user name.
+func authKeys(ip, name string) (keys []string) {
+	if len(ip) != 0 {
+		keys = append(keys, `ip:`+ip)
+	}
+	if len(name) != 0 {
+		keys = append(keys, `user:`+name)
+	}
+	return keys
+}
@@ -0,0 +1,77 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"sort"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestAuthLimiter(t *testing.T) {
+	var (
+		authl = newAuthLimiter(3, time.Minute)
+		now   = timeNow()
+		until = now.Add(time.Minute)
+	)
+
+	test.Assert(t, `fail 1`, false, authl.fail(`10.0.0.1`, `alice`))
+	test.Assert(t, `fail 2`, false, authl.fail(`10.0.0.1`, `bob`))
+	test.Assert(t, `not locked`, time.Time{}, authl.lockedUntil(`10.0.0.1`, `alice`))
+
+	// The third failure from the same IP lock the IP, but not the
+	// user name.
+	test.Assert(t, `fail 3`, true, authl.fail(`10.0.0.1`, `carol`))
+	test.Assert(t, `IP locked`, until, authl.lockedUntil(`10.0.0.1`, `dave`))
+	test.Assert(t, `user not locked`, time.Time{}, authl.lockedUntil(`10.0.0.2`, `carol`))
+
+	// Failures for the same user from different IPs lock the user.
+	authl.fail(`10.0.0.2`, `alice`)
+	authl.fail(`10.0.0.3`, `alice`)
+	test.Assert(t, `user locked`, until, authl.lockedUntil(`10.0.0.3`, `alice`))
+
+	// Successful login reset the failures.
+	authl.fail(`10.0.0.4`, `erin`)
+	authl.succeed(`10.0.0.4`, `erin`)
+	var _, ok = authl.attempts[`user:erin`]
+	test.Assert(t, `succeed`, false, ok)
+
+	// Expired lock is not locked anymore.
+	authl.attempts[`ip:10.0.0.1`].lockedUntil = now
+	authl.attempts[`user:alice`].lockedUntil = now
+	test.Assert(t, `expired`, time.Time{}, authl.lockedUntil(`10.0.0.1`, `alice`))
+}
+
+func TestAuthLimiter_maxEntries(t *testing.T) {
+	var (
+		authl = newAuthLimiter(3, time.Minute)
+		now   = timeNow()
+	)
+
+	authl.maxEntries = 3
+
+	authl.fail(`10.0.0.1`, `alice`)
+	authl.attempts[`ip:10.0.0.1`].lastFail = now.Add(-2 * time.Second)
+	authl.attempts[`user:alice`].lastFail = now.Add(-time.Second)
+
+	authl.fail(``, `bob`)
+
+	// The oldest attempts are removed when the limit is reached.
+	authl.fail(``, `carol`)
+	authl.fail(``, `dave`)
+
+	var (
+		got []string
+		key string
+	)
+	for key = range authl.attempts {
+		got = append(got, key)
+	}
+	sort.Strings(got)
+
+	var exp = []string{`user:bob`, `user:carol`, `user:dave`}
+	test.Assert(t, `attempts`, exp, got)
+}
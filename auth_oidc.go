@@ -0,0 +1,661 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+)
+
+const (
+	defOIDCGroupsClaim   = `groups`
+	defOIDCPendingTTL    = 10 * time.Minute
+	defOIDCMaxPending    = 1000
+	defOIDCTimeout       = 10 * time.Second
+	defOIDCUsernameClaim = `preferred_username`
+
+	// oidcLeeway the allowed clock skew when validating the ID token
+	// time.
+	oidcLeeway = time.Minute
+)
+
+// defOIDCScopes the default scopes requested to provider.
+var defOIDCScopes = []string{`openid`, `profile`, `email`}
+
+// AuthOIDC define the OpenID Connect provider for login into WUI.
+// It is defined in section "[auth \"oidc\"]".
+type AuthOIDC struct {
+	client *http.Client
+
+	// provider contains the provider metadata from discovery.
+	provider *oidcProvider
+
+	// keys contains the provider public keys, indexed by its key ID.
+	keys map[string]*rsa.PublicKey
+
+	// pending contains the login request that has been redirected to
+	// provider, indexed by its state.
+	// The number of pending login is limited by defOIDCMaxPending.
+	pending map[string]*oidcPending
+
+	clientSecret *secretValue
+
+	// Issuer the URL of OpenID provider, for example
+	// "https://accounts.google.com".
+	// The provider metadata is fetched from
+	// "$issuer/.well-known/openid-configuration".
+	Issuer string `ini:"::issuer" json:"-"`
+
+	// ClientID the client identifier registered in provider.
+	ClientID string `ini:"::client_id" json:"-"`
+
+	// ClientSecret the client secret registered in provider.
+	// The secret can be read from external source using
+	// "<scheme>:<path>" format, see [Env.RegisterSecretProvider].
+	ClientSecret string `ini:"::client_secret" json:"-"`
+
+	// RedirectURL the URL of karajo callback registered in provider,
+	// for example
+	// "https://karajo.example.com/karajo/api/auth/oidc/callback".
+	RedirectURL string `ini:"::redirect_url" json:"-"`
+
+	// UsernameClaim the ID token claim used as user name.
+	// If the claim is empty, the "email" and then "sub" claim is used.
+	// This field is optional, default to "preferred_username".
+	UsernameClaim string `ini:"::username_claim" json:"-"`
+
+	// GroupsClaim the ID token claim that contains list of user's
+	// groups.
+	// This field is optional, default to "groups".
+	GroupsClaim string `ini:"::groups_claim" json:"-"`
+
+	// Scopes list of scope requested to provider.
+	// This field is optional, default to "openid", "profile", and
+	// "email".
+	Scopes []string `ini:"::scope" json:"-"`
+
+	// AllowedGroups list of groups that can login.
+	// At least one of AllowedGroups or AllowedUsers must be set.
+	AllowedGroups []string `ini:"::allowed_groups" json:"-"`
+
+	// AllowedUsers list of user name that can login, matched against
+	// the user name from UsernameClaim.
+	// At least one of AllowedGroups or AllowedUsers must be set.
+	AllowedUsers []string `ini:"::allowed_users" json:"-"`
+
+	mtx sync.Mutex
+}
+
+// oidcProvider the metadata of OpenID provider.
+type oidcProvider struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// oidcPending the login request that wait for callback from provider.
+type oidcPending struct {
+	expiredAt time.Time
+	nonce     string
+}
+
+// oidcJWK the JSON Web Key of provider.
+type oidcJWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// oidcJWTHeader the header of ID token.
+type oidcJWTHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// init validate and set the default values.
+func (oidc *AuthOIDC) init(env *Env) (err error) {
+	var logp = `auth "oidc"`
+
+	oidc.Issuer = strings.TrimRight(strings.TrimSpace(expandEnv(oidc.Issuer)), `/`)
+	if len(oidc.Issuer) == 0 {
+		return fmt.Errorf(`%s: empty issuer`, logp)
+	}
+	oidc.ClientID = expandEnv(oidc.ClientID)
+	if len(oidc.ClientID) == 0 {
+		return fmt.Errorf(`%s: empty client_id`, logp)
+	}
+	oidc.RedirectURL = expandEnv(oidc.RedirectURL)
+	if len(oidc.RedirectURL) == 0 {
+		return fmt.Errorf(`%s: empty redirect_url`, logp)
+	}
+	oidc.clientSecret = env.newSecretValue(expandEnv(oidc.ClientSecret))
+
+	if len(oidc.UsernameClaim) == 0 {
+		oidc.UsernameClaim = defOIDCUsernameClaim
+	}
+	if len(oidc.GroupsClaim) == 0 {
+		oidc.GroupsClaim = defOIDCGroupsClaim
+	}
+	if len(oidc.Scopes) == 0 {
+		oidc.Scopes = defOIDCScopes
+	}
+	if len(oidc.AllowedGroups) == 0 && len(oidc.AllowedUsers) == 0 {
+		return fmt.Errorf(`%s: empty allowed_groups and allowed_users`, logp)
+	}
+
+	oidc.client = &http.Client{
+		Timeout: defOIDCTimeout,
+	}
+	oidc.keys = make(map[string]*rsa.PublicKey)
+	oidc.pending = make(map[string]*oidcPending)
+
+	return nil
+}
+
+// authURL create new pending login and return the URL of provider
+// authorization endpoint where user should be redirected, and the state
+// that should be bound to the user browser.
+func (oidc *AuthOIDC) authURL() (authURL, state string, err error) {
+	var provider *oidcProvider
+
+	provider, err = oidc.discover()
+	if err != nil {
+		return ``, ``, fmt.Errorf(`authURL: %w`, err)
+	}
+
+	var (
+		now     = timeNow()
+		pending = &oidcPending{
+			nonce:     string(ascii.Random([]byte(ascii.LettersNumber), 32)),
+			expiredAt: now.Add(defOIDCPendingTTL),
+		}
+	)
+
+	state = string(ascii.Random([]byte(ascii.LettersNumber), 32))
+
+	oidc.mtx.Lock()
+	oidc.prunePending(now)
+	if len(oidc.pending) >= defOIDCMaxPending {
+		oidc.evictOldestPending()
+	}
+	oidc.pending[state] = pending
+	oidc.mtx.Unlock()
+
+	var q = url.Values{}
+	q.Set(`response_type`, `code`)
+	q.Set(`client_id`, oidc.ClientID)
+	q.Set(`redirect_uri`, oidc.RedirectURL)
+	q.Set(`scope`, strings.Join(oidc.Scopes, ` `))
+	q.Set(`state`, state)
+	q.Set(`nonce`, pending.nonce)
+
+	var sep = `?`
+	if strings.Contains(provider.AuthorizationEndpoint, `?`) {
+		sep = `&`
+	}
+	authURL = provider.AuthorizationEndpoint + sep + q.Encode()
+	return authURL, state, nil
+}
+
+// callback exchange the authorization code with ID token, verify it, and
+// return the user.
+// The state from provider must equal to the browserState, the state
+// stored in user browser cookie during login.
+func (oidc *AuthOIDC) callback(state, browserState, code string) (user *User, err error) {
+	var logp = `callback`
+
+	if len(state) == 0 ||
+		subtle.ConstantTimeCompare([]byte(state), []byte(browserState)) != 1 {
+		return nil, fmt.Errorf(`%s: state does not match the browser`, logp)
+	}
+
+	oidc.mtx.Lock()
+	var pending = oidc.pending[state]
+	delete(oidc.pending, state)
+	oidc.mtx.Unlock()
+
+	if pending == nil || !pending.expiredAt.After(timeNow()) {
+		return nil, fmt.Errorf(`%s: unknown or expired state`, logp)
+	}
+	if len(code) == 0 {
+		return nil, fmt.Errorf(`%s: empty code`, logp)
+	}
+
+	var rawToken string
+
+	rawToken, err = oidc.exchange(code)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var claims map[string]any
+
+	claims, err = oidc.verify(rawToken, pending.nonce)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	user, err = oidc.newUser(claims)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return user, nil
+}
+
+// newUser create the user from ID token claims, after checking the user
+// groups.
+func (oidc *AuthOIDC) newUser(claims map[string]any) (user *User, err error) {
+	var name string
+
+	name, _ = claims[oidc.UsernameClaim].(string)
+	if len(name) == 0 {
+		name, _ = claims[`email`].(string)
+	}
+	if len(name) == 0 {
+		name, _ = claims[`sub`].(string)
+	}
+	if len(name) == 0 {
+		return nil, fmt.Errorf(`empty user name`)
+	}
+
+	var (
+		listClaim, _ = claims[oidc.GroupsClaim].([]any)
+
+		groups []string
+		v      any
+		group  string
+	)
+	for _, v = range listClaim {
+		group, _ = v.(string)
+		if len(group) != 0 {
+			groups = append(groups, group)
+		}
+	}
+	if !oidc.isAllowed(name, groups) {
+		return nil, fmt.Errorf(`user %q is not allowed`, name)
+	}
+
+	user = &User{
+		Name:   name,
+		groups: groups,
+		isOIDC: true,
+	}
+	return user, nil
+}
+
+// isAllowed return true if the user name is in AllowedUsers or one of the
+// groups is in AllowedGroups.
+func (oidc *AuthOIDC) isAllowed(name string, groups []string) bool {
+	if slices.Contains(oidc.AllowedUsers, name) {
+		return true
+	}
+	var group string
+	for _, group = range groups {
+		if slices.Contains(oidc.AllowedGroups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// discover fetch and cache the provider metadata.
+func (oidc *AuthOIDC) discover() (provider *oidcProvider, err error) {
+	oidc.mtx.Lock()
+	provider = oidc.provider
+	oidc.mtx.Unlock()
+	if provider != nil {
+		return provider, nil
+	}
+
+	var logp = `discover`
+
+	provider = &oidcProvider{}
+
+	err = oidc.getJSON(oidc.Issuer+`/.well-known/openid-configuration`, provider)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if provider.Issuer != oidc.Issuer {
+		return nil, fmt.Errorf(`%s: issuer mismatch, got %q`, logp, provider.Issuer)
+	}
+
+	oidc.mtx.Lock()
+	oidc.provider = provider
+	oidc.mtx.Unlock()
+
+	return provider, nil
+}
+
+// exchange the authorization code with ID token in provider token
+// endpoint.
+func (oidc *AuthOIDC) exchange(code string) (idToken string, err error) {
+	var (
+		logp = `exchange`
+
+		provider *oidcProvider
+		secret   []byte
+	)
+
+	provider, err = oidc.discover()
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	secret, err = oidc.clientSecret.get()
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var form = url.Values{}
+	form.Set(`grant_type`, `authorization_code`)
+	form.Set(`code`, code)
+	form.Set(`redirect_uri`, oidc.RedirectURL)
+
+	var req *http.Request
+
+	req, err = http.NewRequest(http.MethodPost, provider.TokenEndpoint,
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	req.Header.Set(`Content-Type`, `application/x-www-form-urlencoded`)
+	req.SetBasicAuth(url.QueryEscape(oidc.ClientID), url.QueryEscape(string(secret)))
+
+	var httpRes *http.Response
+
+	httpRes, err = oidc.client.Do(req)
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	defer httpRes.Body.Close()
+
+	var tokenRes struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+
+	err = json.NewDecoder(httpRes.Body).Decode(&tokenRes)
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %s: %w`, logp, httpRes.Status, err)
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return ``, fmt.Errorf(`%s: %s: %s %s`, logp, httpRes.Status,
+			tokenRes.Error, tokenRes.ErrorDescription)
+	}
+	if len(tokenRes.IDToken) == 0 {
+		return ``, fmt.Errorf(`%s: empty id_token`, logp)
+	}
+	return tokenRes.IDToken, nil
+}
+
+// verify the ID token signature and its claims, and return the claims.
+// Only the RS256 signature algorithm is supported.
+func (oidc *AuthOIDC) verify(rawToken, nonce string) (claims map[string]any, err error) {
+	var (
+		logp  = `verify`
+		parts = strings.Split(rawToken, `.`)
+	)
+	if len(parts) != 3 {
+		return nil, fmt.Errorf(`%s: malformed token`, logp)
+	}
+
+	var (
+		header oidcJWTHeader
+		raw    []byte
+	)
+
+	raw, err = base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, fmt.Errorf(`%s: header: %w`, logp, err)
+	}
+	err = json.Unmarshal(raw, &header)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: header: %w`, logp, err)
+	}
+	if header.Alg != `RS256` {
+		return nil, fmt.Errorf(`%s: unsupported algorithm %q`, logp, header.Alg)
+	}
+
+	var pubKey *rsa.PublicKey
+
+	pubKey, err = oidc.publicKey(header.Kid)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var sig []byte
+
+	sig, err = base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf(`%s: signature: %w`, logp, err)
+	}
+
+	var digest = sha256.Sum256([]byte(parts[0] + `.` + parts[1]))
+
+	err = rsa.VerifyPKCS1v15(pubKey, crypto.SHA256, digest[:], sig)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	raw, err = base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, fmt.Errorf(`%s: payload: %w`, logp, err)
+	}
+	err = json.Unmarshal(raw, &claims)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: payload: %w`, logp, err)
+	}
+
+	err = oidc.verifyClaims(claims, nonce)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return claims, nil
+}
+
+// verifyClaims check the issuer, audience, authorized party, expiration
+// time, not before time, issued at time, and nonce of ID token.
+func (oidc *AuthOIDC) verifyClaims(claims map[string]any, nonce string) (err error) {
+	var iss, _ = claims[`iss`].(string)
+	if iss != oidc.Issuer {
+		return fmt.Errorf(`invalid issuer %q`, iss)
+	}
+
+	var (
+		isAudValid bool
+		isMultiAud bool
+	)
+	switch aud := claims[`aud`].(type) {
+	case string:
+		isAudValid = aud == oidc.ClientID
+	case []any:
+		isAudValid = slices.Contains(aud, any(oidc.ClientID))
+		isMultiAud = len(aud) > 1
+	}
+	if !isAudValid {
+		return fmt.Errorf(`invalid audience`)
+	}
+
+	// If the token has multiple audiences, the azp must be present
+	// and equal to our client ID.
+	var azp, hasAzp = claims[`azp`].(string)
+	if (isMultiAud || hasAzp) && azp != oidc.ClientID {
+		return fmt.Errorf(`invalid authorized party %q`, azp)
+	}
+
+	var (
+		now    = timeNow()
+		exp, _ = claims[`exp`].(float64)
+	)
+	if !time.Unix(int64(exp), 0).Add(oidcLeeway).After(now) {
+		return fmt.Errorf(`token expired`)
+	}
+
+	var nbf, hasNbf = claims[`nbf`].(float64)
+	if hasNbf && time.Unix(int64(nbf), 0).After(now.Add(oidcLeeway)) {
+		return fmt.Errorf(`token not yet valid`)
+	}
+
+	var iat, _ = claims[`iat`].(float64)
+	if iat == 0 {
+		return fmt.Errorf(`empty issued at time`)
+	}
+	if time.Unix(int64(iat), 0).After(now.Add(oidcLeeway)) {
+		return fmt.Errorf(`token issued in the future`)
+	}
+
+	var gotNonce, _ = claims[`nonce`].(string)
+	if gotNonce != nonce {
+		return fmt.Errorf(`invalid nonce`)
+	}
+	return nil
+}
+
+// publicKey return the provider public key by its ID.
+// If the key is not found, the keys is fetched again from provider, in
+// case the provider rotate its keys.
+func (oidc *AuthOIDC) publicKey(kid string) (pubKey *rsa.PublicKey, err error) {
+	oidc.mtx.Lock()
+	pubKey = oidc.keys[kid]
+	oidc.mtx.Unlock()
+	if pubKey != nil {
+		return pubKey, nil
+	}
+
+	var (
+		logp = `publicKey`
+
+		provider *oidcProvider
+	)
+
+	provider, err = oidc.discover()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var jwks struct {
+		Keys []oidcJWK `json:"keys"`
+	}
+
+	err = oidc.getJSON(provider.JWKSURI, &jwks)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		keys = make(map[string]*rsa.PublicKey, len(jwks.Keys))
+		jwk  oidcJWK
+		key  *rsa.PublicKey
+	)
+	for _, jwk = range jwks.Keys {
+		if jwk.Kty != `RSA` {
+			continue
+		}
+		key, err = jwk.rsaPublicKey()
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+		keys[jwk.Kid] = key
+	}
+
+	oidc.mtx.Lock()
+	oidc.keys = keys
+	oidc.mtx.Unlock()
+
+	pubKey = keys[kid]
+	if pubKey == nil {
+		return nil, fmt.Errorf(`%s: unknown key ID %q`, logp, kid)
+	}
+	return pubKey, nil
+}
+
+// getJSON fetch the JSON document from URL into v.
+func (oidc *AuthOIDC) getJSON(docURL string, v any) (err error) {
+	var httpRes *http.Response
+
+	httpRes, err = oidc.client.Get(docURL)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode != http.StatusOK {
+		return fmt.Errorf(`%s: %s`, docURL, httpRes.Status)
+	}
+
+	err = json.NewDecoder(httpRes.Body).Decode(v)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, docURL, err)
+	}
+	return nil
+}
+
+// prunePending remove the expired pending login.
+// The caller must hold the lock.
+func (oidc *AuthOIDC) prunePending(now time.Time) {
+	var (
+		state   string
+		pending *oidcPending
+	)
+	for state, pending = range oidc.pending {
+		if !pending.expiredAt.After(now) {
+			delete(oidc.pending, state)
+		}
+	}
+}
+
+// evictOldestPending remove the pending login that will expire first.
+// The caller must hold the lock.
+func (oidc *AuthOIDC) evictOldestPending() {
+	var (
+		oldestState string
+		oldestAt    time.Time
+		state       string
+		pending     *oidcPending
+	)
+	for state, pending = range oidc.pending {
+		if len(oldestState) == 0 || pending.expiredAt.Before(oldestAt) {
+			oldestState = state
+			oldestAt = pending.expiredAt
+		}
+	}
+	delete(oidc.pending, oldestState)
+}
+
+// rsaPublicKey convert the JWK into RSA public key.
+func (jwk oidcJWK) rsaPublicKey() (pubKey *rsa.PublicKey, err error) {
+	var n, e []byte
+
+	n, err = base64.RawURLEncoding.DecodeString(jwk.N)
+	if err != nil {
+		return nil, fmt.Errorf(`key %q: %w`, jwk.Kid, err)
+	}
+	e, err = base64.RawURLEncoding.DecodeString(jwk.E)
+	if err != nil {
+		return nil, fmt.Errorf(`key %q: %w`, jwk.Kid, err)
+	}
+
+	pubKey = &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}
+	return pubKey, nil
+}
@@ -0,0 +1,391 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+// testOIDCProvider mock the OpenID Connect provider.
+type testOIDCProvider struct {
+	srv    *httptest.Server
+	key    *rsa.PrivateKey
+	claims map[string]any
+}
+
+func newTestOIDCProvider(t *testing.T) (p *testOIDCProvider) {
+	var err error
+
+	p = &testOIDCProvider{}
+
+	p.key, err = rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mux = http.NewServeMux()
+
+	mux.HandleFunc(`/.well-known/openid-configuration`, func(w http.ResponseWriter, _ *http.Request) {
+		_ = json.NewEncoder(w).Encode(oidcProvider{
+			Issuer:                p.srv.URL,
+			AuthorizationEndpoint: p.srv.URL + `/authorize`,
+			TokenEndpoint:         p.srv.URL + `/token`,
+			JWKSURI:               p.srv.URL + `/jwks`,
+		})
+	})
+	mux.HandleFunc(`/jwks`, func(w http.ResponseWriter, _ *http.Request) {
+		var jwk = oidcJWK{
+			Kty: `RSA`,
+			Kid: `key1`,
+			N:   base64.RawURLEncoding.EncodeToString(p.key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(p.key.E)).Bytes()),
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]oidcJWK{`keys`: {jwk}})
+	})
+	mux.HandleFunc(`/token`, func(w http.ResponseWriter, req *http.Request) {
+		var id, secret, _ = req.BasicAuth()
+		if id != `karajo` || secret != `s3cret` || req.FormValue(`code`) != `valid` {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error":"invalid_grant"}`))
+			return
+		}
+		_ = json.NewEncoder(w).Encode(map[string]string{
+			`id_token`: p.sign(t, p.claims),
+		})
+	})
+
+	p.srv = httptest.NewServer(mux)
+	t.Cleanup(p.srv.Close)
+
+	return p
+}
+
+func (p *testOIDCProvider) sign(t *testing.T, claims map[string]any) string {
+	var (
+		header     = `{"alg":"RS256","kid":"key1"}`
+		payload, _ = json.Marshal(claims)
+		input      = base64.RawURLEncoding.EncodeToString([]byte(header)) + `.` +
+			base64.RawURLEncoding.EncodeToString(payload)
+		digest = sha256.Sum256([]byte(input))
+	)
+
+	var sig, err = rsa.SignPKCS1v15(rand.Reader, p.key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + `.` + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestAuthOIDC(t *testing.T) {
+	var (
+		provider = newTestOIDCProvider(t)
+		env      = &Env{}
+		oidc     = &AuthOIDC{
+			Issuer:        provider.srv.URL + `/`,
+			ClientID:      `karajo`,
+			ClientSecret:  `s3cret`,
+			RedirectURL:   `http://127.0.0.1:31937/karajo/api/auth/oidc/callback`,
+			AllowedGroups: []string{`ops`},
+			AllowedUsers:  []string{`bob`},
+		}
+		k = &Karajo{
+			env: env,
+			sm:  newSessionManager(),
+		}
+		err error
+	)
+
+	err = oidc.init(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.AuthOIDC = oidc
+
+	type testCase struct {
+		claims   map[string]any
+		desc     string
+		code     string
+		expUser  string
+		expError string
+	}
+
+	var (
+		iat   = float64(timeNow().Unix())
+		exp   = float64(timeNow().Unix() + 300)
+		cases = []testCase{{
+			desc: `with invalid code`,
+			code: `invalid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+			},
+			expError: `callback: exchange: 400 Bad Request: invalid_grant `,
+		}, {
+			desc: `with invalid audience`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: `other`,
+				`exp`: exp,
+				`iat`: iat,
+			},
+			expError: `callback: verify: invalid audience`,
+		}, {
+			desc: `with expired token`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: []string{`karajo`},
+				`exp`: float64(timeNow().Unix() - 3600),
+				`iat`: iat,
+			},
+			expError: `callback: verify: token expired`,
+		}, {
+			desc: `with multiple audiences without azp`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: []string{`karajo`, `other`},
+				`exp`: exp,
+				`iat`: iat,
+			},
+			expError: `callback: verify: invalid authorized party ""`,
+		}, {
+			desc: `with invalid azp`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: `karajo`,
+				`azp`: `other`,
+				`exp`: exp,
+				`iat`: iat,
+			},
+			expError: `callback: verify: invalid authorized party "other"`,
+		}, {
+			desc: `with nbf in the future`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: `karajo`,
+				`exp`: exp,
+				`nbf`: float64(timeNow().Unix() + 120),
+				`iat`: iat,
+			},
+			expError: `callback: verify: token not yet valid`,
+		}, {
+			desc: `without iat`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: `karajo`,
+				`exp`: exp,
+			},
+			expError: `callback: verify: empty issued at time`,
+		}, {
+			desc: `with iat in the future`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`: provider.srv.URL,
+				`aud`: `karajo`,
+				`exp`: exp,
+				`iat`: float64(timeNow().Unix() + 120),
+			},
+			expError: `callback: verify: token issued in the future`,
+		}, {
+			desc: `with user not in allowed groups`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`:                provider.srv.URL,
+				`aud`:                `karajo`,
+				`exp`:                exp,
+				`iat`:                iat,
+				`preferred_username`: `john`,
+				`groups`:             []string{`dev`},
+			},
+			expError: `callback: user "john" is not allowed`,
+		}, {
+			desc: `with user in allowed users`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`:                provider.srv.URL,
+				`aud`:                `karajo`,
+				`exp`:                exp,
+				`iat`:                iat,
+				`preferred_username`: `bob`,
+			},
+			expUser: `bob`,
+		}, {
+			desc: `with valid token`,
+			code: `valid`,
+			claims: map[string]any{
+				`iss`:    provider.srv.URL,
+				`aud`:    []string{`karajo`, `other`},
+				`azp`:    `karajo`,
+				`exp`:    exp,
+				`iat`:    iat,
+				`email`:  `jane@example.com`,
+				`groups`: []string{`dev`, `ops`},
+			},
+			expUser: `jane@example.com`,
+		}}
+
+		c       testCase
+		authURL string
+		urlAuth *url.URL
+		rec     *httptest.ResponseRecorder
+		epr     *libhttp.EndpointRequest
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiAuthOIDCLogin, nil),
+		}
+		_, err = k.apiAuthOIDCLogin(epr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, c.desc+`: login status`, http.StatusFound, rec.Code)
+
+		authURL = rec.Header().Get(`Location`)
+		urlAuth, err = url.Parse(authURL)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var q = urlAuth.Query()
+		test.Assert(t, c.desc+`: client_id`, `karajo`, q.Get(`client_id`))
+		test.Assert(t, c.desc+`: scope`, `openid profile email`, q.Get(`scope`))
+
+		c.claims[`nonce`] = q.Get(`nonce`)
+		provider.claims = c.claims
+
+		var (
+			state  = q.Get(`state`)
+			cookie = rec.Result().Cookies()[0]
+			user   *User
+		)
+		test.Assert(t, c.desc+`: cookie name`, cookieNameOIDC, cookie.Name)
+		test.Assert(t, c.desc+`: cookie value`, state, cookie.Value)
+		test.Assert(t, c.desc+`: cookie HttpOnly`, true, cookie.HttpOnly)
+
+		user, err = oidc.callback(state, cookie.Value, c.code)
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, c.expUser, user.Name)
+		test.Assert(t, c.desc+`: isOIDC`, true, user.isOIDC)
+
+		// The state can only be used once.
+		_, err = oidc.callback(state, state, c.code)
+		test.Assert(t, c.desc+`: reuse state`, `callback: unknown or expired state`, err.Error())
+	}
+}
+
+func TestAuthOIDC_callback_browserState(t *testing.T) {
+	var (
+		provider = newTestOIDCProvider(t)
+		oidc     = &AuthOIDC{
+			Issuer:        provider.srv.URL,
+			ClientID:      `karajo`,
+			RedirectURL:   `http://127.0.0.1:31937/karajo/api/auth/oidc/callback`,
+			AllowedGroups: []string{`ops`},
+		}
+
+		err error
+	)
+
+	err = oidc.init(&Env{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var state string
+
+	_, state, err = oidc.authURL()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var expError = `callback: state does not match the browser`
+
+	_, err = oidc.callback(state, ``, `valid`)
+	test.Assert(t, `without cookie`, expError, err.Error())
+
+	_, err = oidc.callback(state, `other`, `valid`)
+	test.Assert(t, `with other cookie`, expError, err.Error())
+
+	_, err = oidc.callback(``, ``, `valid`)
+	test.Assert(t, `with empty state`, expError, err.Error())
+
+	// The mismatch state should not consume the pending login.
+	test.Assert(t, `pending kept`, 1, len(oidc.pending))
+}
+
+func TestAuthOIDC_authURL_maxPending(t *testing.T) {
+	var (
+		provider = newTestOIDCProvider(t)
+		oidc     = &AuthOIDC{
+			Issuer:        provider.srv.URL,
+			ClientID:      `karajo`,
+			RedirectURL:   `http://127.0.0.1:31937/karajo/api/auth/oidc/callback`,
+			AllowedGroups: []string{`ops`},
+		}
+
+		err error
+	)
+
+	err = oidc.init(&Env{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		firstState string
+		x          int
+	)
+	for x = 0; x <= defOIDCMaxPending; x++ {
+		var state string
+
+		_, state, err = oidc.authURL()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if x == 0 {
+			firstState = state
+			// Make the first login the oldest.
+			oidc.pending[state].expiredAt = oidc.pending[state].expiredAt.Add(-time.Second)
+		}
+	}
+	test.Assert(t, `len(pending)`, defOIDCMaxPending, len(oidc.pending))
+
+	var nilPending *oidcPending
+	test.Assert(t, `oldest evicted`, nilPending, oidc.pending[firstState])
+}
+
+func TestAuthOIDC_init(t *testing.T) {
+	var oidc = &AuthOIDC{
+		Issuer:      `https://sso.example.com`,
+		ClientID:    `karajo`,
+		RedirectURL: `http://127.0.0.1:31937/karajo/api/auth/oidc/callback`,
+	}
+
+	var err = oidc.init(&Env{})
+	test.Assert(t, `without allowed list`,
+		`auth "oidc": empty allowed_groups and allowed_users`, err.Error())
+}
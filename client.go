@@ -4,217 +4,615 @@
 package karajo
 
 import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"net/http/cookiejar"
 	"net/url"
+	"os"
 	"path"
 	"strconv"
+	"strings"
+	"time"
 
 	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
 )
 
 // Client HTTP client for Karajo server.
+//
+// Each method has the variant with suffix "Context" that accept the
+// context, to cancel the request or to set its deadline.
+// The method without context use [context.Background].
+//
+// The error response from server can be checked using [errors.Is] with
+// the exported errors, for example [ErrJobPaused].
 type Client struct {
 	*libhttp.Client
 	opts ClientOptions
 }
 
 // NewClient create new HTTP client.
-func NewClient(opts ClientOptions) (cl *Client) {
+// It return an error if the CAFile in options cannot be loaded.
+func NewClient(opts ClientOptions) (cl *Client, err error) {
+	var logp = `NewClient`
+
+	opts.init()
+
 	cl = &Client{
 		opts:   opts,
 		Client: libhttp.NewClient(opts.ClientOptions),
 	}
-	return cl
+
+	cl.Client.Client.Timeout = opts.RequestTimeout
+
+	// The cookie jar keep the session from Login.
+	// The cookiejar.New never return an error.
+	cl.Client.Jar, _ = cookiejar.New(nil)
+
+	if len(opts.CAFile) != 0 {
+		var rootCAs *x509.CertPool
+
+		rootCAs, err = loadCertPool(opts.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+
+		var transport = cl.Client.Transport.(*http.Transport)
+		if transport.TLSClientConfig == nil {
+			transport.TLSClientConfig = &tls.Config{
+				MinVersion: tls.VersionTLS12,
+			}
+		}
+		transport.TLSClientConfig.RootCAs = rootCAs
+	}
+
+	return cl, nil
+}
+
+// Login authenticate the client using the user name, password, and
+// optional TOTP code.
+// On success, the session is stored in the client and used on the
+// subsequent requests.
+func (cl *Client) Login(name, password, totp string) (err error) {
+	return cl.LoginContext(context.Background(), name, password, totp)
+}
+
+// LoginContext authenticate the client with context.
+// See [Client.Login] for details.
+func (cl *Client) LoginContext(ctx context.Context, name, password, totp string) (err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameName, name)
+	params.Set(paramNamePassword, password)
+	if len(totp) != 0 {
+		params.Set(paramNameTOTP, totp)
+	}
+
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeForm,
+		Path:   apiAuthLogin,
+		Params: params,
+	}
+
+	return cl.doJSON(ctx, `Login`, clientReq, nil)
+}
+
+// Logout remove the session from server and client.
+func (cl *Client) Logout() (err error) {
+	return cl.LogoutContext(context.Background())
+}
+
+// LogoutContext remove the session from server and client with context.
+func (cl *Client) LogoutContext(ctx context.Context) (err error) {
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Path:   apiAuthLogout,
+	}
+
+	return cl.doJSON(ctx, `Logout`, clientReq, nil)
 }
 
 // Env get the server environment.
 func (cl *Client) Env() (env *Env, err error) {
-	var (
-		logp      = `Env`
-		clientReq = libhttp.ClientRequest{
-			Path: apiEnv,
-		}
-		clientResp *libhttp.ClientResponse
-	)
+	return cl.EnvContext(context.Background())
+}
 
-	clientResp, err = cl.Client.Get(clientReq)
-	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+// EnvContext get the server environment with context.
+func (cl *Client) EnvContext(ctx context.Context) (env *Env, err error) {
+	var clientReq = libhttp.ClientRequest{
+		Path: apiEnv,
 	}
 
 	env = &Env{}
-	var res = &libhttp.EndpointResponse{
-		Data: env,
+
+	err = cl.doJSON(ctx, `Env`, clientReq, env)
+	if err != nil {
+		return nil, err
 	}
-	err = json.Unmarshal(clientResp.Body, res)
+	return env, nil
+}
+
+// EnvExport get the effective configuration in the format "ini" or "json".
+func (cl *Client) EnvExport(format string) (content []byte, err error) {
+	return cl.EnvExportContext(context.Background(), format)
+}
+
+// EnvExportContext get the effective configuration with context.
+// See [Client.EnvExport] for details.
+func (cl *Client) EnvExportContext(ctx context.Context, format string) (content []byte, err error) {
+	var params = url.Values{}
+
+	if len(format) != 0 {
+		params.Set(paramNameFormat, format)
+	}
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiEnvExport,
+		Params: params,
+	}
+
+	content, err = cl.doRaw(ctx, `EnvExport`, clientReq)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
-	if res.Code != 200 {
-		res.Data = nil
-		return nil, res
+	return content, nil
+}
+
+// EnvReadOnly enable or disable the read-only mode of server.
+// It return the current read-only mode.
+func (cl *Client) EnvReadOnly(readOnly bool) (isReadOnly bool, err error) {
+	return cl.EnvReadOnlyContext(context.Background(), readOnly)
+}
+
+// EnvReadOnlyContext enable or disable the read-only mode with context.
+// See [Client.EnvReadOnly] for details.
+func (cl *Client) EnvReadOnlyContext(ctx context.Context, readOnly bool) (isReadOnly bool, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameReadOnly, strconv.FormatBool(readOnly))
+
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeForm,
+		Path:   apiEnvReadOnly,
+		Header: cl.sign(params),
+		Params: params,
 	}
-	return env, nil
+
+	err = cl.doJSON(ctx, `EnvReadOnly`, clientReq, &isReadOnly)
+	if err != nil {
+		return false, err
+	}
+	return isReadOnly, nil
 }
 
-// JobExecCancel cancel the running JobExec by its ID.
-func (cl *Client) JobExecCancel(id string) (job *JobExec, err error) {
-	var (
-		logp   = `JobExecCancel`
-		now    = timeNow().Unix()
-		params = url.Values{}
-		header = http.Header{}
-	)
+// Backup write the backup of karajo state, including the job logs that
+// modified in the last logMaxAge, as gzip compressed tar into w.
+// If logMaxAge is zero, the server use its default value.
+func (cl *Client) Backup(w io.Writer, logMaxAge time.Duration) (err error) {
+	return cl.BackupContext(context.Background(), w, logMaxAge)
+}
 
-	params.Set(paramNameKarajoEpoch, strconv.FormatInt(now, 10))
-	params.Set(paramNameID, id)
+// BackupContext write the backup of karajo state with context.
+// See [Client.Backup] for details.
+func (cl *Client) BackupContext(ctx context.Context, w io.Writer, logMaxAge time.Duration) (err error) {
+	var params = url.Values{}
 
-	var body = params.Encode()
-	var sign = Sign([]byte(body), []byte(cl.opts.Secret))
+	if logMaxAge > 0 {
+		params.Set(paramNameLogMaxAge, logMaxAge.String())
+	}
 
-	header.Set(HeaderNameXKarajoSign, sign)
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiBackup,
+		Header: cl.sign(params),
+		Params: params,
+	}
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobExecCancel,
-			Header: header,
-			Params: params,
-		}
-		clientResp *libhttp.ClientResponse
-	)
+	return cl.doDownload(ctx, `Backup`, clientReq, w)
+}
+
+// Changes get the jobs whose state changed after the revision since.
+// Use the Revision in the returned changes as since on the next call.
+// If the Epoch changes, the karajo server has been restarted and the
+// client should get the whole environment using [Client.Env].
+func (cl *Client) Changes(since int64) (changes *EnvChanges, err error) {
+	return cl.ChangesContext(context.Background(), since)
+}
+
+// ChangesContext get the jobs whose state changed with context.
+// See [Client.Changes] for details.
+func (cl *Client) ChangesContext(ctx context.Context, since int64) (changes *EnvChanges, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameSince, strconv.FormatInt(since, 10))
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiChanges,
+		Params: params,
+	}
+
+	changes = &EnvChanges{}
 
-	clientResp, err = cl.Client.PostForm(clientReq)
+	err = cl.doJSON(ctx, `Changes`, clientReq, changes)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
+	return changes, nil
+}
 
-	job = &JobExec{}
-	var res = &libhttp.EndpointResponse{
-		Data: job,
+// Federation get the environment of each peer.
+func (cl *Client) Federation() (peers []*PeerStatus, err error) {
+	return cl.FederationContext(context.Background())
+}
+
+// FederationContext get the environment of each peer with context.
+func (cl *Client) FederationContext(ctx context.Context) (peers []*PeerStatus, err error) {
+	var clientReq = libhttp.ClientRequest{
+		Path: apiFederation,
 	}
 
-	err = json.Unmarshal(clientResp.Body, &res)
+	err = cl.doJSON(ctx, `Federation`, clientReq, &peers)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
-	if res.Code != http.StatusOK {
-		res.Data = nil
-		return nil, res
+	return peers, nil
+}
+
+// NamespaceJobExec get the list of JobExec in the namespace name,
+// indexed by the job name.
+func (cl *Client) NamespaceJobExec(name string) (jobs map[string]*JobExec, err error) {
+	return cl.NamespaceJobExecContext(context.Background(), name)
+}
+
+// NamespaceJobExecContext get the list of JobExec in the namespace with
+// context.
+// See [Client.NamespaceJobExec] for details.
+func (cl *Client) NamespaceJobExecContext(ctx context.Context, name string) (jobs map[string]*JobExec, err error) {
+	var clientReq = libhttp.ClientRequest{
+		Path: path.Join(apiNamespace, url.PathEscape(name), `job_exec`),
+	}
+
+	err = cl.doJSON(ctx, `NamespaceJobExec`, clientReq, &jobs)
+	if err != nil {
+		return nil, err
+	}
+	return jobs, nil
+}
+
+// Schedule get the past runs and the next runs of all jobs between from
+// and to.
+// If from or to is zero, the server use its default value.
+func (cl *Client) Schedule(from, to time.Time) (sched *Schedule, err error) {
+	return cl.ScheduleContext(context.Background(), from, to)
+}
+
+// ScheduleContext get the schedule of all jobs with context.
+// See [Client.Schedule] for details.
+func (cl *Client) ScheduleContext(ctx context.Context, from, to time.Time) (sched *Schedule, err error) {
+	sched, err = cl.schedule(ctx, `Schedule`, apiSchedule, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+// SchedulePreview get the next runs of all jobs between from and to,
+// ignoring the job states.
+// If from or to is zero, the server use its default value.
+func (cl *Client) SchedulePreview(from, to time.Time) (sched *Schedule, err error) {
+	return cl.SchedulePreviewContext(context.Background(), from, to)
+}
+
+// SchedulePreviewContext get the next runs of all jobs with context.
+// See [Client.SchedulePreview] for details.
+func (cl *Client) SchedulePreviewContext(ctx context.Context, from, to time.Time) (sched *Schedule, err error) {
+	sched, err = cl.schedule(ctx, `SchedulePreview`, apiSchedulePreview, from, to)
+	if err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+// JobExecArtifacts get the list of artifacts from the JobExec log by its
+// ID and counter.
+func (cl *Client) JobExecArtifacts(id string, counter int64) (list []JobArtifact, err error) {
+	return cl.JobExecArtifactsContext(context.Background(), id, counter)
+}
+
+// JobExecArtifactsContext get the list of artifacts with context.
+// See [Client.JobExecArtifacts] for details.
+func (cl *Client) JobExecArtifactsContext(ctx context.Context, id string, counter int64) (list []JobArtifact, err error) {
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobExecArtifact,
+		Params: logParams(id, counter),
+	}
+
+	err = cl.doJSON(ctx, `JobExecArtifacts`, clientReq, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// JobExecArtifactDownload write the content of artifact name from the
+// JobExec log into w.
+func (cl *Client) JobExecArtifactDownload(w io.Writer, id string, counter int64, name string) (err error) {
+	return cl.JobExecArtifactDownloadContext(context.Background(), w, id, counter, name)
+}
+
+// JobExecArtifactDownloadContext write the content of artifact with
+// context.
+// See [Client.JobExecArtifactDownload] for details.
+func (cl *Client) JobExecArtifactDownloadContext(
+	ctx context.Context, w io.Writer, id string, counter int64, name string,
+) (err error) {
+	var params = logParams(id, counter)
+
+	params.Set(paramNameName, name)
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobExecArtifactDownload,
+		Params: params,
 	}
 
+	return cl.doDownload(ctx, `JobExecArtifactDownload`, clientReq, w)
+}
+
+// JobExecApprove approve the run of JobExec, by its ID, that wait for
+// approval.
+// The client must be logged in using [Client.Login], as different user
+// from the one that trigger the run.
+func (cl *Client) JobExecApprove(id string) (job *JobExec, err error) {
+	return cl.JobExecApproveContext(context.Background(), id)
+}
+
+// JobExecApproveContext approve the run of JobExec with context.
+func (cl *Client) JobExecApproveContext(ctx context.Context, id string) (job *JobExec, err error) {
+	job = &JobExec{}
+
+	err = cl.postSigned(ctx, `JobExecApprove`, apiJobExecApprove, id, job)
+	if err != nil {
+		return nil, err
+	}
 	return job, nil
 }
 
-// JobExecPause pause the JobExec by its ID.
-func (cl *Client) JobExecPause(id string) (job *JobExec, err error) {
-	var (
-		logp   = `JobExecPause`
-		now    = timeNow().Unix()
-		params = url.Values{}
-		header = http.Header{}
+// JobExecCancel cancel the running JobExec by its ID.
+func (cl *Client) JobExecCancel(id string) (job *JobExec, err error) {
+	return cl.JobExecCancelContext(context.Background(), id)
+}
 
-		body string
-		sign string
-	)
+// JobExecCancelContext cancel the running JobExec with context.
+func (cl *Client) JobExecCancelContext(ctx context.Context, id string) (job *JobExec, err error) {
+	job = &JobExec{}
 
-	params.Set(paramNameKarajoEpoch, strconv.FormatInt(now, 10))
-	params.Set(paramNameID, id)
+	err = cl.postSigned(ctx, `JobExecCancel`, apiJobExecCancel, id, job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
 
-	body = params.Encode()
+// JobExecDisable disable the JobExec by its ID.
+// The job is not run, even after the server restarted, until it
+// enabled again.
+func (cl *Client) JobExecDisable(id string) (job *JobExec, err error) {
+	return cl.JobExecDisableContext(context.Background(), id)
+}
 
-	sign = Sign([]byte(body), []byte(cl.opts.Secret))
-	header.Set(HeaderNameXKarajoSign, sign)
+// JobExecDisableContext disable the JobExec with context.
+func (cl *Client) JobExecDisableContext(ctx context.Context, id string) (job *JobExec, err error) {
+	job = &JobExec{}
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobExecPause,
-			Header: header,
-			Params: params,
-		}
-		clientResp *libhttp.ClientResponse
-	)
+	err = cl.postSigned(ctx, `JobExecDisable`, apiJobExecDisable, id, job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// JobExecDryRun get the information on how the JobExec will be executed
+// on the next run, without running it.
+func (cl *Client) JobExecDryRun(id string) (dry *JobExecDryRun, err error) {
+	return cl.JobExecDryRunContext(context.Background(), id)
+}
 
-	clientResp, err = cl.Client.PostForm(clientReq)
+// JobExecDryRunContext dry-run the JobExec with context.
+// See [Client.JobExecDryRun] for details.
+func (cl *Client) JobExecDryRunContext(ctx context.Context, id string) (dry *JobExecDryRun, err error) {
+	dry = &JobExecDryRun{}
+
+	err = cl.postSigned(ctx, `JobExecDryRun`, apiJobExecDryRun, id, dry)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
+	return dry, nil
+}
+
+// JobExecEnable enable the disabled JobExec by its ID.
+func (cl *Client) JobExecEnable(id string) (job *JobExec, err error) {
+	return cl.JobExecEnableContext(context.Background(), id)
+}
 
+// JobExecEnableContext enable the JobExec with context.
+func (cl *Client) JobExecEnableContext(ctx context.Context, id string) (job *JobExec, err error) {
 	job = &JobExec{}
-	var res = &libhttp.EndpointResponse{
-		Data: job,
+
+	err = cl.postSigned(ctx, `JobExecEnable`, apiJobExecEnable, id, job)
+	if err != nil {
+		return nil, err
 	}
+	return job, nil
+}
+
+// JobExecPause pause the JobExec by its ID.
+func (cl *Client) JobExecPause(id string) (job *JobExec, err error) {
+	return cl.JobExecPauseContext(context.Background(), id)
+}
+
+// JobExecPauseContext pause the JobExec with context.
+func (cl *Client) JobExecPauseContext(ctx context.Context, id string) (job *JobExec, err error) {
+	job = &JobExec{}
 
-	err = json.Unmarshal(clientResp.Body, &res)
+	err = cl.postSigned(ctx, `JobExecPause`, apiJobExecPause, id, job)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
-	if res.Code != http.StatusOK {
-		res.Data = nil
-		return nil, res
+	return job, nil
+}
+
+// JobExecPauseUntil pause the JobExec by its ID, and resume it
+// automatically at until.
+func (cl *Client) JobExecPauseUntil(id string, until time.Time) (job *JobExec, err error) {
+	return cl.JobExecPauseUntilContext(context.Background(), id, until)
+}
+
+// JobExecPauseUntilContext pause the JobExec until the specific time
+// with context.
+// See [Client.JobExecPauseUntil] for details.
+func (cl *Client) JobExecPauseUntilContext(
+	ctx context.Context, id string, until time.Time,
+) (job *JobExec, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+	params.Set(paramNameUntil, until.UTC().Format(time.RFC3339))
+
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeForm,
+		Path:   apiJobExecPause,
+		Header: cl.sign(params),
+		Params: params,
 	}
 
+	job = &JobExec{}
+
+	err = cl.doJSON(ctx, `JobExecPauseUntil`, clientReq, job)
+	if err != nil {
+		return nil, err
+	}
 	return job, nil
 }
 
 // JobExecResume resume the JobExec execution by its ID.
 func (cl *Client) JobExecResume(id string) (job *JobExec, err error) {
-	var (
-		logp   = `JobExecResume`
-		now    = timeNow().Unix()
-		params = url.Values{}
-		header = http.Header{}
-	)
+	return cl.JobExecResumeContext(context.Background(), id)
+}
 
-	params.Set(paramNameKarajoEpoch, strconv.FormatInt(now, 10))
-	params.Set(paramNameID, id)
+// JobExecResumeContext resume the JobExec with context.
+func (cl *Client) JobExecResumeContext(ctx context.Context, id string) (job *JobExec, err error) {
+	job = &JobExec{}
 
-	var body = params.Encode()
-	var sign = Sign([]byte(body), []byte(cl.opts.Secret))
-	header.Set(HeaderNameXKarajoSign, sign)
+	err = cl.postSigned(ctx, `JobExecResume`, apiJobExecResume, id, job)
+	if err != nil {
+		return nil, err
+	}
+	return job, nil
+}
+
+// JobExecSecrets list the secret status of all JobExec.
+func (cl *Client) JobExecSecrets() (list []JobExecSecret, err error) {
+	return cl.JobExecSecretsContext(context.Background())
+}
 
+// JobExecSecretsContext list the secret status of all JobExec with
+// context.
+func (cl *Client) JobExecSecretsContext(ctx context.Context) (list []JobExecSecret, err error) {
 	var (
+		params    = url.Values{}
 		clientReq = libhttp.ClientRequest{
-			Path:   apiJobExecResume,
-			Header: header,
+			Type:   libhttp.RequestTypeQuery,
+			Path:   apiJobExecSecret,
+			Header: cl.sign(params),
 			Params: params,
 		}
-		clientResp *libhttp.ClientResponse
 	)
 
-	clientResp, err = cl.Client.PostForm(clientReq)
+	err = cl.doJSON(ctx, `JobExecSecrets`, clientReq, &list)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
+	return list, nil
+}
 
-	job = &JobExec{}
-	var res = &libhttp.EndpointResponse{
-		Data: job,
-	}
+// JobExecSecretRotate generate new secret for the JobExec by its ID.
+// The previous secret is still valid for the duration of grace.
+// If grace is zero, the server default to 24 hours.
+func (cl *Client) JobExecSecretRotate(id string, grace time.Duration) (rot *JobExecSecretRotate, err error) {
+	return cl.JobExecSecretRotateContext(context.Background(), id, grace)
+}
 
-	err = json.Unmarshal(clientResp.Body, &res)
-	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+// JobExecSecretRotateContext generate new secret for the JobExec with
+// context.
+// See [Client.JobExecSecretRotate] for details.
+func (cl *Client) JobExecSecretRotateContext(
+	ctx context.Context, id string, grace time.Duration,
+) (rot *JobExecSecretRotate, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+	if grace > 0 {
+		params.Set(paramNameGracePeriod, grace.String())
 	}
-	if res.Code != http.StatusOK {
-		res.Data = nil
-		return nil, res
+
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeForm,
+		Path:   apiJobExecSecretRotate,
+		Header: cl.sign(params),
+		Params: params,
 	}
 
-	return job, nil
+	rot = &JobExecSecretRotate{}
+
+	err = cl.doJSON(ctx, `JobExecSecretRotate`, clientReq, rot)
+	if err != nil {
+		return nil, err
+	}
+	return rot, nil
 }
 
 // JobExecRun trigger the JobExec by its path.
 func (cl *Client) JobExecRun(jobPath string) (job *JobExec, err error) {
+	return cl.JobExecRunContext(context.Background(), jobPath)
+}
+
+// JobExecRunContext trigger the JobExec by its path with context.
+func (cl *Client) JobExecRunContext(ctx context.Context, jobPath string) (job *JobExec, err error) {
+	return cl.JobExecRunParamsContext(ctx, jobPath, nil)
+}
+
+// JobExecRunParams trigger the JobExec by its path with parameters.
+// Each parameter must be listed in the "run_param" of job, and passed to
+// the commands as environment variable KARAJO_PARAM_<NAME>.
+func (cl *Client) JobExecRunParams(jobPath string, params map[string]string) (job *JobExec, err error) {
+	return cl.JobExecRunParamsContext(context.Background(), jobPath, params)
+}
+
+// JobExecRunParamsContext trigger the JobExec by its path with parameters
+// and context.
+func (cl *Client) JobExecRunParamsContext(ctx context.Context, jobPath string, params map[string]string) (job *JobExec, err error) {
 	var (
-		logp       = `JobExec`
-		timeNow    = timeNow()
-		apiJobPath = path.Join(apiJobExecRun, jobPath)
-		header     = http.Header{}
+		logp   = `JobExecRun`
+		header = http.Header{}
 
 		req = JobHTTPRequest{
-			Epoch: timeNow.Unix(),
+			Epoch:  timeNow().Unix(),
+			Nonce:  newNonce(),
+			Params: params,
 		}
 
 		reqBody []byte
@@ -230,14 +628,16 @@ func (cl *Client) JobExecRun(jobPath string) (job *JobExec, err error) {
 
 	var (
 		clientReq = libhttp.ClientRequest{
-			Path:   apiJobPath,
+			Method: libhttp.RequestMethodPost,
+			Type:   libhttp.RequestTypeJSON,
+			Path:   path.Join(apiJobExecRun, jobPath),
 			Header: header,
 			Params: &req,
 		}
 		clientResp *libhttp.ClientResponse
 	)
 
-	clientResp, err = cl.Client.PostJSON(clientReq)
+	clientResp, err = cl.doRequest(ctx, clientReq)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
@@ -260,195 +660,789 @@ func (cl *Client) JobExecRun(jobPath string) (job *JobExec, err error) {
 
 // JobExecLog get the JobExec log by its ID and counter.
 func (cl *Client) JobExecLog(jobID string, counter int) (joblog *JobLog, err error) {
-	var (
-		logp   = `JobExecLog`
-		params = url.Values{}
-	)
+	return cl.JobExecLogContext(context.Background(), jobID, counter)
+}
 
-	params.Set(paramNameID, jobID)
-	params.Set(paramNameCounter, strconv.Itoa(counter))
+// JobExecLogContext get the JobExec log with context.
+func (cl *Client) JobExecLogContext(ctx context.Context, jobID string, counter int) (joblog *JobLog, err error) {
+	joblog, err = cl.jobLog(ctx, `JobExecLog`, apiJobExecLog, jobID, int64(counter))
+	if err != nil {
+		return nil, err
+	}
+	return joblog, nil
+}
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobExecLog,
-			Params: params,
-		}
-		clientResp *libhttp.ClientResponse
-	)
+// JobExecLogRaw get the content of JobExec log by its ID and counter as
+// plain text.
+func (cl *Client) JobExecLogRaw(jobID string, counter int64) (content []byte, err error) {
+	return cl.JobExecLogRawContext(context.Background(), jobID, counter)
+}
 
-	clientResp, err = cl.Client.Get(clientReq)
-	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+// JobExecLogRawContext get the content of JobExec log with context.
+// See [Client.JobExecLogRaw] for details.
+func (cl *Client) JobExecLogRawContext(ctx context.Context, jobID string, counter int64) (content []byte, err error) {
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobExecLogRaw,
+		Params: logParams(jobID, counter),
 	}
 
-	var res = &libhttp.EndpointResponse{
-		Data: &joblog,
+	content, err = cl.doRaw(ctx, `JobExecLogRaw`, clientReq)
+	if err != nil {
+		return nil, err
 	}
-	err = json.Unmarshal(clientResp.Body, res)
+	return content, nil
+}
+
+// JobExecLogDiff compare the JobExec log with counter a and b, and return
+// the difference in unified diff format.
+// The timestamp on each line is removed before compared.
+// It return empty content if both logs have the same lines.
+func (cl *Client) JobExecLogDiff(jobID string, a, b int64) (content []byte, err error) {
+	return cl.JobExecLogDiffContext(context.Background(), jobID, a, b)
+}
+
+// JobExecLogDiffContext compare the JobExec logs with context.
+// See [Client.JobExecLogDiff] for details.
+func (cl *Client) JobExecLogDiffContext(ctx context.Context, jobID string, a, b int64) (content []byte, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, jobID)
+	params.Set(paramNameA, strconv.FormatInt(a, 10))
+	params.Set(paramNameB, strconv.FormatInt(b, 10))
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobExecLogDiff,
+		Params: params,
+	}
+
+	content, err = cl.doRaw(ctx, `JobExecLogDiff`, clientReq)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
+	}
+	return content, nil
+}
+
+// JobExecLogSearch search the text query in the JobExec logs.
+// The jobID and status are optional, to limit the search to specific job
+// and/or log status.
+func (cl *Client) JobExecLogSearch(query, jobID, status string) (list []LogSearchResult, err error) {
+	return cl.JobExecLogSearchContext(context.Background(), query, jobID, status)
+}
+
+// JobExecLogSearchContext search the JobExec logs with context.
+// See [Client.JobExecLogSearch] for details.
+func (cl *Client) JobExecLogSearchContext(
+	ctx context.Context, query, jobID, status string,
+) (list []LogSearchResult, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameQuery, query)
+	if len(jobID) != 0 {
+		params.Set(paramNameID, jobID)
 	}
-	if res.Code == 200 {
-		return joblog, nil
+	if len(status) != 0 {
+		params.Set(paramNameStatus, status)
 	}
-	res.Data = nil
-	return nil, res
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobExecLogSearch,
+		Params: params,
+	}
+
+	err = cl.doJSON(ctx, `JobExecLogSearch`, clientReq, &list)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// JobExecNext get the next n runs of JobExec by its ID.
+// If n is zero, the server use its default value.
+func (cl *Client) JobExecNext(id string, n int) (jnr *JobNextRuns, err error) {
+	return cl.JobExecNextContext(context.Background(), id, n)
+}
+
+// JobExecNextContext get the next runs of JobExec with context.
+// See [Client.JobExecNext] for details.
+func (cl *Client) JobExecNextContext(ctx context.Context, id string, n int) (jnr *JobNextRuns, err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+	if n > 0 {
+		params.Set(paramNameN, strconv.Itoa(n))
+	}
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobExecNext,
+		Params: params,
+	}
+
+	err = cl.doJSON(ctx, `JobExecNext`, clientReq, &jnr)
+	if err != nil {
+		return nil, err
+	}
+	return jnr, nil
+}
+
+// JobExecLogTail stream the JobExec log by its ID and counter, starting
+// from offset, until the job finished or the ctx canceled.
+// See [Client.tailLog] for the handle and its events.
+func (cl *Client) JobExecLogTail(
+	ctx context.Context, jobID string, counter, offset int64,
+	handle func(ev JobLogEvent) error,
+) (err error) {
+	return cl.tailLog(ctx, `JobExecLogTail`, apiJobExecLogSSE, jobID, counter, offset, handle)
 }
 
 // JobHTTP get JobHTTP detail by its ID.
 func (cl *Client) JobHTTP(id string) (httpJob *JobHTTP, err error) {
-	var (
-		logp   = `JobHTTP`
-		params = url.Values{}
-	)
+	return cl.JobHTTPContext(context.Background(), id)
+}
 
-	params.Set(`id`, id)
+// JobHTTPContext get JobHTTP detail with context.
+func (cl *Client) JobHTTPContext(ctx context.Context, id string) (httpJob *JobHTTP, err error) {
+	var params = url.Values{}
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobHTTP,
-			Params: params,
-		}
-		clientResp *libhttp.ClientResponse
-	)
+	params.Set(paramNameID, id)
 
-	clientResp, err = cl.Client.Get(clientReq)
-	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobHTTP,
+		Params: params,
 	}
 
 	httpJob = &JobHTTP{}
-	var res = &libhttp.EndpointResponse{
-		Data: httpJob,
+
+	err = cl.doJSON(ctx, `JobHTTP`, clientReq, httpJob)
+	if err != nil {
+		return nil, err
 	}
-	err = json.Unmarshal(clientResp.Body, res)
+	return httpJob, nil
+}
+
+// JobHTTPDisable disable the HTTP job by its ID.
+// The job is not run, even after the server restarted, until it
+// enabled again.
+func (cl *Client) JobHTTPDisable(id string) (jobHTTP *JobHTTP, err error) {
+	return cl.JobHTTPDisableContext(context.Background(), id)
+}
+
+// JobHTTPDisableContext disable the HTTP job with context.
+func (cl *Client) JobHTTPDisableContext(ctx context.Context, id string) (jobHTTP *JobHTTP, err error) {
+	jobHTTP = &JobHTTP{}
+
+	err = cl.postSignedQuery(ctx, `JobHTTPDisable`, apiJobHTTPDisable, id, jobHTTP)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
-	if res.Code != 200 {
-		res.Data = nil
-		return nil, res
+	return jobHTTP, nil
+}
+
+// JobHTTPEnable enable the disabled HTTP job by its ID.
+func (cl *Client) JobHTTPEnable(id string) (jobHTTP *JobHTTP, err error) {
+	return cl.JobHTTPEnableContext(context.Background(), id)
+}
+
+// JobHTTPEnableContext enable the HTTP job with context.
+func (cl *Client) JobHTTPEnableContext(ctx context.Context, id string) (jobHTTP *JobHTTP, err error) {
+	jobHTTP = &JobHTTP{}
+
+	err = cl.postSignedQuery(ctx, `JobHTTPEnable`, apiJobHTTPEnable, id, jobHTTP)
+	if err != nil {
+		return nil, err
 	}
-	return httpJob, nil
+	return jobHTTP, nil
 }
 
 // JobHTTPLog get the job logs by its ID.
 func (cl *Client) JobHTTPLog(id string, counter int) (jlog *JobLog, err error) {
-	var (
-		logp   = `JobHTTPLog`
-		params = url.Values{}
-	)
+	return cl.JobHTTPLogContext(context.Background(), id, counter)
+}
+
+// JobHTTPLogContext get the JobHTTP log with context.
+func (cl *Client) JobHTTPLogContext(ctx context.Context, id string, counter int) (jlog *JobLog, err error) {
+	jlog, err = cl.jobLog(ctx, `JobHTTPLog`, apiJobHTTPLog, id, int64(counter))
+	if err != nil {
+		return nil, err
+	}
+	return jlog, nil
+}
+
+// JobHTTPLogTail stream the JobHTTP log by its ID and counter.
+// See [Client.JobExecLogTail] for details.
+func (cl *Client) JobHTTPLogTail(
+	ctx context.Context, id string, counter, offset int64,
+	handle func(ev JobLogEvent) error,
+) (err error) {
+	return cl.tailLog(ctx, `JobHTTPLogTail`, apiJobHTTPLogSSE, id, counter, offset, handle)
+}
+
+// JobHTTPPause pause the HTTP job by its ID.
+func (cl *Client) JobHTTPPause(id string) (jobHTTP *JobHTTP, err error) {
+	return cl.JobHTTPPauseContext(context.Background(), id)
+}
+
+// JobHTTPPauseContext pause the HTTP job with context.
+func (cl *Client) JobHTTPPauseContext(ctx context.Context, id string) (jobHTTP *JobHTTP, err error) {
+	jobHTTP = &JobHTTP{}
+
+	err = cl.postSignedQuery(ctx, `JobHTTPPause`, apiJobHTTPPause, id, jobHTTP)
+	if err != nil {
+		return nil, err
+	}
+	return jobHTTP, nil
+}
+
+// JobHTTPResume resume the HTTP job by its ID.
+func (cl *Client) JobHTTPResume(id string) (jobHTTP *JobHTTP, err error) {
+	return cl.JobHTTPResumeContext(context.Background(), id)
+}
+
+// JobHTTPResumeContext resume the HTTP job with context.
+func (cl *Client) JobHTTPResumeContext(ctx context.Context, id string) (jobHTTP *JobHTTP, err error) {
+	jobHTTP = &JobHTTP{}
+
+	err = cl.postSignedQuery(ctx, `JobHTTPResume`, apiJobHTTPResume, id, jobHTTP)
+	if err != nil {
+		return nil, err
+	}
+	return jobHTTP, nil
+}
+
+// JobSQL get JobSQL detail by its ID.
+func (cl *Client) JobSQL(id string) (sqlJob *JobSQL, err error) {
+	return cl.JobSQLContext(context.Background(), id)
+}
+
+// JobSQLContext get JobSQL detail with context.
+func (cl *Client) JobSQLContext(ctx context.Context, id string) (sqlJob *JobSQL, err error) {
+	var params = url.Values{}
 
 	params.Set(paramNameID, id)
-	params.Set(paramNameCounter, strconv.Itoa(counter))
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobHTTPLog,
-			Params: params,
-		}
-		clientResp *libhttp.ClientResponse
-	)
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiJobSQL,
+		Params: params,
+	}
+
+	sqlJob = &JobSQL{}
 
-	clientResp, err = cl.Client.Get(clientReq)
+	err = cl.doJSON(ctx, `JobSQL`, clientReq, sqlJob)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
+	return sqlJob, nil
+}
 
-	var res = &libhttp.EndpointResponse{
-		Data: &jlog,
+// JobSQLDisable disable the JobSQL by its ID.
+// The job is not run, even after the server restarted, until it
+// enabled again.
+func (cl *Client) JobSQLDisable(id string) (sqlJob *JobSQL, err error) {
+	return cl.JobSQLDisableContext(context.Background(), id)
+}
+
+// JobSQLDisableContext disable the JobSQL with context.
+func (cl *Client) JobSQLDisableContext(ctx context.Context, id string) (sqlJob *JobSQL, err error) {
+	sqlJob = &JobSQL{}
+
+	err = cl.postSignedQuery(ctx, `JobSQLDisable`, apiJobSQLDisable, id, sqlJob)
+	if err != nil {
+		return nil, err
 	}
-	err = json.Unmarshal(clientResp.Body, res)
+	return sqlJob, nil
+}
+
+// JobSQLEnable enable the disabled JobSQL by its ID.
+func (cl *Client) JobSQLEnable(id string) (sqlJob *JobSQL, err error) {
+	return cl.JobSQLEnableContext(context.Background(), id)
+}
+
+// JobSQLEnableContext enable the JobSQL with context.
+func (cl *Client) JobSQLEnableContext(ctx context.Context, id string) (sqlJob *JobSQL, err error) {
+	sqlJob = &JobSQL{}
+
+	err = cl.postSignedQuery(ctx, `JobSQLEnable`, apiJobSQLEnable, id, sqlJob)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
-	if res.Code == 200 {
-		return jlog, nil
+	return sqlJob, nil
+}
+
+// JobSQLLog get the JobSQL log by its ID and counter.
+func (cl *Client) JobSQLLog(id string, counter int64) (jlog *JobLog, err error) {
+	return cl.JobSQLLogContext(context.Background(), id, counter)
+}
+
+// JobSQLLogContext get the JobSQL log with context.
+func (cl *Client) JobSQLLogContext(ctx context.Context, id string, counter int64) (jlog *JobLog, err error) {
+	jlog, err = cl.jobLog(ctx, `JobSQLLog`, apiJobSQLLog, id, counter)
+	if err != nil {
+		return nil, err
 	}
-	res.Data = nil
-	return nil, res
+	return jlog, nil
 }
 
-// JobHTTPPause pause the HTTP job by its ID.
-func (cl *Client) JobHTTPPause(id string) (jobHTTP *JobHTTP, err error) {
-	var (
-		logp   = `JobHTTPPause`
-		params = url.Values{}
-		header = http.Header{}
-	)
+// JobSQLLogTail stream the JobSQL log by its ID and counter.
+// See [Client.JobExecLogTail] for details.
+func (cl *Client) JobSQLLogTail(
+	ctx context.Context, id string, counter, offset int64,
+	handle func(ev JobLogEvent) error,
+) (err error) {
+	return cl.tailLog(ctx, `JobSQLLogTail`, apiJobSQLLogSSE, id, counter, offset, handle)
+}
+
+// JobSQLPause pause the JobSQL by its ID.
+func (cl *Client) JobSQLPause(id string) (sqlJob *JobSQL, err error) {
+	return cl.JobSQLPauseContext(context.Background(), id)
+}
+
+// JobSQLPauseContext pause the JobSQL with context.
+func (cl *Client) JobSQLPauseContext(ctx context.Context, id string) (sqlJob *JobSQL, err error) {
+	sqlJob = &JobSQL{}
+
+	err = cl.postSignedQuery(ctx, `JobSQLPause`, apiJobSQLPause, id, sqlJob)
+	if err != nil {
+		return nil, err
+	}
+	return sqlJob, nil
+}
+
+// JobSQLResume resume the JobSQL by its ID.
+func (cl *Client) JobSQLResume(id string) (sqlJob *JobSQL, err error) {
+	return cl.JobSQLResumeContext(context.Background(), id)
+}
+
+// JobSQLResumeContext resume the JobSQL with context.
+func (cl *Client) JobSQLResumeContext(ctx context.Context, id string) (sqlJob *JobSQL, err error) {
+	sqlJob = &JobSQL{}
+
+	err = cl.postSignedQuery(ctx, `JobSQLResume`, apiJobSQLResume, id, sqlJob)
+	if err != nil {
+		return nil, err
+	}
+	return sqlJob, nil
+}
+
+// AgentPoll wait for the task of JobExec that target the agent name.
+// It will return nil task if there is no task after the server wait
+// duration passed.
+func (cl *Client) AgentPoll(name string) (task *AgentTask, err error) {
+	return cl.AgentPollContext(context.Background(), name)
+}
+
+// AgentPollContext wait for the task of agent with context.
+// See [Client.AgentPoll] for details.
+func (cl *Client) AgentPollContext(ctx context.Context, name string) (task *AgentTask, err error) {
+	var params = url.Values{}
 
-	params.Set(`id`, id)
+	params.Set(paramNameName, name)
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiAgentPoll,
+		Header: cl.sign(params),
+		Params: params,
+	}
+
+	err = cl.doJSON(ctx, `AgentPoll`, clientReq, &task)
+	if err != nil {
+		return nil, err
+	}
+	return task, nil
+}
+
+// AgentLog send the output of task to the server.
+func (cl *Client) AgentLog(id string, output []byte) (err error) {
+	return cl.AgentLogContext(context.Background(), id, output)
+}
+
+// AgentLogContext send the output of task to the server with context.
+func (cl *Client) AgentLogContext(ctx context.Context, id string, output []byte) (err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+	params.Set(paramNameOutput, string(output))
+
+	return cl.agentPost(ctx, `AgentLog`, apiAgentLog, params)
+}
+
+// AgentDone report the result of task to the server.
+// The errMsg is the error from executing the commands, or empty if all
+// commands run successfully.
+func (cl *Client) AgentDone(id, errMsg string) (err error) {
+	return cl.AgentDoneContext(context.Background(), id, errMsg)
+}
+
+// AgentDoneContext report the result of task to the server with context.
+// See [Client.AgentDone] for details.
+func (cl *Client) AgentDoneContext(ctx context.Context, id, errMsg string) (err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+	params.Set(paramNameError, errMsg)
+
+	return cl.agentPost(ctx, `AgentDone`, apiAgentDone, params)
+}
+
+// agentPost send the signed form params to the agent API.
+func (cl *Client) agentPost(ctx context.Context, logp, apiPath string, params url.Values) (err error) {
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeForm,
+		Path:   apiPath,
+		Header: cl.sign(params),
+		Params: params,
+	}
+
+	return cl.doJSON(ctx, logp, clientReq, nil)
+}
+
+// postSigned send the signed form with job ID to the apiPath and decode
+// the response data into data.
+func (cl *Client) postSigned(ctx context.Context, logp, apiPath, id string, data any) (err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeForm,
+		Path:   apiPath,
+		Header: cl.sign(params),
+		Params: params,
+	}
+
+	return cl.doJSON(ctx, logp, clientReq, data)
+}
+
+// postSignedQuery send the POST request with signed job ID in the query
+// to the apiPath and decode the response data into data.
+func (cl *Client) postSignedQuery(ctx context.Context, logp, apiPath, id string, data any) (err error) {
+	var params = url.Values{}
+
+	params.Set(paramNameID, id)
+
+	var clientReq = libhttp.ClientRequest{
+		Method: libhttp.RequestMethodPost,
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiPath,
+		Header: cl.sign(params),
+		Params: params,
+	}
+
+	return cl.doJSON(ctx, logp, clientReq, data)
+}
+
+// jobLog get the job log from apiPath by job ID and counter.
+func (cl *Client) jobLog(ctx context.Context, logp, apiPath, id string, counter int64) (jlog *JobLog, err error) {
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiPath,
+		Params: logParams(id, counter),
+	}
+
+	err = cl.doJSON(ctx, logp, clientReq, &jlog)
+	if err != nil {
+		return nil, err
+	}
+	return jlog, nil
+}
+
+// schedule get the schedule from apiPath between from and to.
+func (cl *Client) schedule(ctx context.Context, logp, apiPath string, from, to time.Time) (sched *Schedule, err error) {
+	var params = url.Values{}
+
+	if !from.IsZero() {
+		params.Set(paramNameFrom, from.Format(time.RFC3339))
+	}
+	if !to.IsZero() {
+		params.Set(paramNameTo, to.Format(time.RFC3339))
+	}
+
+	var clientReq = libhttp.ClientRequest{
+		Type:   libhttp.RequestTypeQuery,
+		Path:   apiPath,
+		Params: params,
+	}
+
+	sched = &Schedule{}
+
+	err = cl.doJSON(ctx, logp, clientReq, sched)
+	if err != nil {
+		return nil, err
+	}
+	return sched, nil
+}
+
+// sign set the current epoch and random nonce into params and return the
+// header that contains the signature of encoded params.
+func (cl *Client) sign(params url.Values) (header http.Header) {
+	params.Set(paramNameKarajoEpoch, strconv.FormatInt(timeNow().Unix(), 10))
+	params.Set(paramNameKarajoNonce, newNonce())
 
 	var sign = Sign([]byte(params.Encode()), []byte(cl.opts.Secret))
+
+	header = http.Header{}
 	header.Set(HeaderNameXKarajoSign, sign)
+	return header
+}
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobHTTPPause,
-			Header: header,
-			Params: params,
+// newRequest create the HTTP request from clientReq with context.
+// If the client has been logged in, the request other than GET contains
+// the CSRF token from the session.
+func (cl *Client) newRequest(ctx context.Context, clientReq libhttp.ClientRequest) (httpReq *http.Request, err error) {
+	if len(clientReq.Method) == 0 {
+		clientReq.Method = libhttp.RequestMethodGet
+	}
+
+	httpReq, err = cl.Client.GenerateHTTPRequest(clientReq)
+	if err != nil {
+		return nil, err
+	}
+	httpReq = httpReq.WithContext(ctx)
+
+	if clientReq.Method == libhttp.RequestMethodGet {
+		return httpReq, nil
+	}
+
+	var cookie *http.Cookie
+	for _, cookie = range cl.Client.Jar.Cookies(httpReq.URL) {
+		if cookie.Name == cookieNameCSRF {
+			httpReq.Header.Set(HeaderNameXKarajoCSRF, cookie.Value)
+			break
 		}
-		clientResp *libhttp.ClientResponse
-	)
+	}
+	return httpReq, nil
+}
 
-	clientResp, err = cl.Client.Post(clientReq)
+// doRequest send the clientReq with context and return the response with
+// uncompressed body.
+func (cl *Client) doRequest(ctx context.Context, clientReq libhttp.ClientRequest) (clientResp *libhttp.ClientResponse, err error) {
+	var httpReq *http.Request
+
+	httpReq, err = cl.newRequest(ctx, clientReq)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
 
-	var res = &libhttp.EndpointResponse{
-		Data: &jobHTTP,
+	err = cl.retry(httpReq, func(req *http.Request) (code int, err error) {
+		clientResp, err = cl.Client.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		return clientResp.HTTPResponse.StatusCode, nil
+	})
+	if err != nil {
+		return nil, err
 	}
+	return clientResp, nil
+}
 
-	err = json.Unmarshal(clientResp.Body, res)
+// send the clientReq using httpc and return the response with its body
+// open, if the response status is 200.
+// Otherwise the body is read and returned as errBody.
+func (cl *Client) send(
+	ctx context.Context, httpc *http.Client, clientReq libhttp.ClientRequest,
+) (httpRes *http.Response, errBody []byte, err error) {
+	var httpReq *http.Request
+
+	httpReq, err = cl.newRequest(ctx, clientReq)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, nil, err
 	}
-	if res.Code != 200 {
-		res.Data = nil
-		return nil, res
+
+	err = cl.retry(httpReq, func(req *http.Request) (code int, err error) {
+		httpRes, err = httpc.Do(req)
+		if err != nil {
+			return 0, err
+		}
+		if httpRes.StatusCode != http.StatusOK {
+			errBody, _ = io.ReadAll(httpRes.Body)
+			_ = httpRes.Body.Close()
+		}
+		return httpRes.StatusCode, nil
+	})
+	if err != nil {
+		return nil, nil, err
 	}
-	return jobHTTP, nil
+	return httpRes, errBody, nil
 }
 
-// JobHTTPResume resume the HTTP job by its ID.
-func (cl *Client) JobHTTPResume(id string) (jobHTTP *JobHTTP, err error) {
+// retry call the do function with httpReq until its success, the
+// request context is done, or the number of retries reach the MaxRetries
+// in options.
+// The delay between retry start from RetryDelay in options and doubled on
+// each retry.
+func (cl *Client) retry(httpReq *http.Request, do func(req *http.Request) (int, error)) (err error) {
 	var (
-		logp   = `JobHTTPResume`
-		params = url.Values{}
-		header = http.Header{}
+		ctx   = httpReq.Context()
+		delay = cl.opts.RetryDelay
+		req   = httpReq
+
+		timer *time.Timer
+		code  int
+		x     int
 	)
+	for x = 0; ; x++ {
+		if x > 0 {
+			req = httpReq.Clone(ctx)
+			if httpReq.GetBody != nil {
+				req.Body, err = httpReq.GetBody()
+				if err != nil {
+					return err
+				}
+			}
+		}
 
-	params.Set(`id`, id)
+		code, err = do(req)
+		if x >= cl.opts.MaxRetries || !isRetryable(req.Method, code, err) {
+			return err
+		}
 
-	var sign = Sign([]byte(params.Encode()), []byte(cl.opts.Secret))
-	header.Set(HeaderNameXKarajoSign, sign)
+		timer = time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			if err == nil {
+				err = ctx.Err()
+			}
+			return err
+		case <-timer.C:
+		}
 
-	var (
-		clientReq = libhttp.ClientRequest{
-			Path:   apiJobHTTPResume,
-			Header: header,
-			Params: params,
+		delay *= 2
+		if delay > maxClientRetryDelay {
+			delay = maxClientRetryDelay
 		}
-		clientResp *libhttp.ClientResponse
-	)
+	}
+}
 
-	clientResp, err = cl.Client.Post(clientReq)
+// doJSON send the clientReq and decode the data in JSON response into
+// data.
+// If the response code is not 200, it return the response as error.
+func (cl *Client) doJSON(ctx context.Context, logp string, clientReq libhttp.ClientRequest, data any) (err error) {
+	var clientResp *libhttp.ClientResponse
+
+	clientResp, err = cl.doRequest(ctx, clientReq)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
 	var res = &libhttp.EndpointResponse{
-		Data: &jobHTTP,
+		Data: data,
 	}
-
 	err = json.Unmarshal(clientResp.Body, res)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		return fmt.Errorf(`%s: %w`, logp, err)
 	}
-	if res.Code != 200 {
+	if res.Code != http.StatusOK {
 		res.Data = nil
-		return nil, res
+		return res
 	}
-	return jobHTTP, nil
+	return nil
+}
+
+// doRaw send the clientReq and return the response body as is.
+func (cl *Client) doRaw(ctx context.Context, logp string, clientReq libhttp.ClientRequest) (body []byte, err error) {
+	var clientResp *libhttp.ClientResponse
+
+	clientResp, err = cl.doRequest(ctx, clientReq)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if clientResp.HTTPResponse.StatusCode != http.StatusOK {
+		return nil, responseError(clientResp.HTTPResponse.StatusCode, clientResp.Body)
+	}
+	return clientResp.Body, nil
+}
+
+// doDownload send the clientReq and write the response body into w.
+func (cl *Client) doDownload(ctx context.Context, logp string, clientReq libhttp.ClientRequest, w io.Writer) (err error) {
+	var (
+		httpRes *http.Response
+		errBody []byte
+	)
+
+	httpRes, errBody, err = cl.send(ctx, cl.Client.Client, clientReq)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return responseError(httpRes.StatusCode, errBody)
+	}
+	defer httpRes.Body.Close()
+
+	_, err = io.Copy(w, httpRes.Body)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return nil
+}
+
+// isRetryable return true if the request with method can be retried
+// based on the response code or error.
+func isRetryable(method string, code int, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		if errors.As(err, &opErr) && opErr.Op == `dial` {
+			// The request is not sent yet.
+			return true
+		}
+		return method == http.MethodGet
+	}
+	switch code {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return method == http.MethodGet && code >= http.StatusInternalServerError
+}
+
+// loadCertPool load the system certificate authorities and the one in
+// the PEM file.
+func loadCertPool(file string) (pool *x509.CertPool, err error) {
+	var pem []byte
+
+	pem, err = os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pool, err = x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf(`%s: no certificate found`, file)
+	}
+	return pool, nil
+}
+
+// responseError return the error from non-JSON API response.
+// The API return the JSON response on error, otherwise the body is used as
+// the error message.
+func responseError(code int, body []byte) (res *libhttp.EndpointResponse) {
+	res = &libhttp.EndpointResponse{}
+
+	var err = json.Unmarshal(body, res)
+	if err != nil || res.Code == 0 {
+		res.Code = code
+		res.Message = strings.TrimSpace(string(body))
+		if len(res.Message) == 0 {
+			res.Message = http.StatusText(code)
+		}
+	}
+	return res
+}
+
+// logParams return the query parameters for job log.
+func logParams(id string, counter int64) (params url.Values) {
+	params = url.Values{}
+	params.Set(paramNameID, id)
+	params.Set(paramNameCounter, strconv.FormatInt(counter, 10))
+	return params
 }
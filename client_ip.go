@@ -0,0 +1,108 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// headerXForwardedFor the HTTP header that contains the list of client
+// and proxies IP addresses, set by reverse proxy.
+const headerXForwardedFor = `X-Forwarded-For`
+
+// initTrustedProxy parse each TrustedProxy as IP address or network
+// in CIDR notation.
+func (env *Env) initTrustedProxy() (err error) {
+	var (
+		logp = `initTrustedProxy`
+
+		v      string
+		prefix netip.Prefix
+		addr   netip.Addr
+	)
+
+	env.trustedProxies = nil
+
+	for _, v = range env.TrustedProxy {
+		v = strings.TrimSpace(v)
+		if len(v) == 0 {
+			continue
+		}
+		if strings.IndexByte(v, '/') > 0 {
+			prefix, err = netip.ParsePrefix(v)
+			if err != nil {
+				return fmt.Errorf(`%s: %w`, logp, err)
+			}
+		} else {
+			addr, err = netip.ParseAddr(v)
+			if err != nil {
+				return fmt.Errorf(`%s: %w`, logp, err)
+			}
+			prefix = netip.PrefixFrom(addr, addr.BitLen())
+		}
+		env.trustedProxies = append(env.trustedProxies, prefix.Masked())
+	}
+	return nil
+}
+
+// isTrustedProxy return true if the IP address is one of TrustedProxy.
+func (env *Env) isTrustedProxy(ip string) bool {
+	var addr, err = netip.ParseAddr(ip)
+	if err != nil {
+		return false
+	}
+	addr = addr.Unmap()
+
+	var prefix netip.Prefix
+	for _, prefix = range env.trustedProxies {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// clientIP return the IP address of client.
+// If the request come from TrustedProxy, the client IP address is the
+// last address in the X-Forwarded-For header that is not TrustedProxy.
+// Otherwise, it return the remote address of request.
+func (env *Env) clientIP(req *http.Request) (ip string) {
+	ip = remoteIP(req)
+	if !env.isTrustedProxy(ip) {
+		return ip
+	}
+
+	var (
+		list = strings.Split(strings.Join(req.Header.Values(headerXForwardedFor), `,`), `,`)
+
+		x    int
+		addr string
+	)
+	for x = len(list) - 1; x >= 0; x-- {
+		addr = strings.TrimSpace(list[x])
+		if len(addr) == 0 {
+			continue
+		}
+		ip = addr
+		if !env.isTrustedProxy(ip) {
+			break
+		}
+	}
+	return ip
+}
+
+// remoteIP return the IP address of client without port.
+func remoteIP(req *http.Request) (ip string) {
+	var err error
+
+	ip, _, err = net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return ip
+}
@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_clientIP(t *testing.T) {
+	type testCase struct {
+		desc         string
+		remoteAddr   string
+		forwardedFor []string
+		exp          string
+	}
+
+	var env = &Env{
+		TrustedProxy: []string{
+			`10.0.0.1`,
+			`192.168.1.0/24`,
+			`::1`,
+		},
+	}
+
+	var err = env.initTrustedProxy()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases = []testCase{{
+		desc:         `untrusted remote`,
+		remoteAddr:   `10.0.0.2:1234`,
+		forwardedFor: []string{`1.1.1.1`},
+		exp:          `10.0.0.2`,
+	}, {
+		desc:       `trusted without header`,
+		remoteAddr: `10.0.0.1:1234`,
+		exp:        `10.0.0.1`,
+	}, {
+		desc:         `trusted`,
+		remoteAddr:   `10.0.0.1:1234`,
+		forwardedFor: []string{`1.1.1.1`},
+		exp:          `1.1.1.1`,
+	}, {
+		desc:         `spoofed by client`,
+		remoteAddr:   `10.0.0.1:1234`,
+		forwardedFor: []string{`8.8.8.8, 1.1.1.1`},
+		exp:          `1.1.1.1`,
+	}, {
+		desc:         `chain of trusted proxies`,
+		remoteAddr:   `[::1]:1234`,
+		forwardedFor: []string{`8.8.8.8, 1.1.1.1`, `192.168.1.10`},
+		exp:          `1.1.1.1`,
+	}, {
+		desc:         `all trusted`,
+		remoteAddr:   `10.0.0.1:1234`,
+		forwardedFor: []string{`192.168.1.10`},
+		exp:          `192.168.1.10`,
+	}}
+
+	var (
+		c   testCase
+		req *http.Request
+		v   string
+	)
+	for _, c = range cases {
+		req = httptest.NewRequest(http.MethodGet, `/`, nil)
+		req.RemoteAddr = c.remoteAddr
+		for _, v = range c.forwardedFor {
+			req.Header.Add(headerXForwardedFor, v)
+		}
+		test.Assert(t, c.desc, c.exp, env.clientIP(req))
+	}
+}
+
+func TestEnv_initTrustedProxy(t *testing.T) {
+	var env = &Env{
+		TrustedProxy: []string{`10.0.0.300`},
+	}
+	var err = env.initTrustedProxy()
+	test.Assert(t, `error`,
+		`initTrustedProxy: ParseAddr("10.0.0.300"): IPv4 field has value >255`,
+		err.Error())
+}
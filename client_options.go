@@ -3,7 +3,17 @@
 
 package karajo
 
-import libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+import (
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// defClientRetryDelay the default delay before the first retry.
+const defClientRetryDelay = time.Second
+
+// maxClientRetryDelay the maximum delay between retries.
+const maxClientRetryDelay = time.Minute
 
 // ClientOptions define the options for Karajo HTTP client.
 type ClientOptions struct {
@@ -11,5 +21,37 @@ type ClientOptions struct {
 	// resume a job.
 	Secret string
 
+	// CAFile the path to the PEM encoded certificate authorities that
+	// used to verify the server certificate, in addition to the
+	// system certificate authorities.
+	// This field is optional.
+	CAFile string
+
 	libhttp.ClientOptions
+
+	// RequestTimeout the maximum duration of each request, including
+	// reading the response body.
+	// It does not affect the request that stream the job log.
+	// This field is optional, default to no timeout.
+	RequestTimeout time.Duration
+
+	// RetryDelay the delay before retrying the failed request.
+	// The delay is doubled on each retry, up to one minute.
+	// This field is optional, default to one second.
+	RetryDelay time.Duration
+
+	// MaxRetries the maximum number of retries when the request failed
+	// because of connection error or the server return 5xx.
+	// The GET request is retried on any connection error or 5xx, while
+	// the other requests are retried only if the connection cannot be
+	// established or the response status is 502, 503, or 504, so the
+	// same job is not triggered twice.
+	// This field is optional, default to zero, no retry.
+	MaxRetries int
+}
+
+func (opts *ClientOptions) init() {
+	if opts.RetryDelay <= 0 {
+		opts.RetryDelay = defClientRetryDelay
+	}
 }
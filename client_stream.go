@@ -0,0 +1,257 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// defClientWatchInterval the default interval to poll the changes in
+// [Client.WatchEnv].
+const defClientWatchInterval = 5 * time.Second
+
+// JobLogEvent contains the event from streaming the job log.
+type JobLogEvent struct {
+	// Type of event, one of "stdout", "stderr", "exit", "end", or
+	// "error".
+	Type string
+
+	// Data contains the line of log for "stdout" and "stderr", the exit
+	// information for "exit", the log status for "end", or the error
+	// message for "error".
+	Data string
+
+	// Offset the offset of the next line, that can be used to resume
+	// the stream.
+	Offset int64
+}
+
+// tailLog stream the job log from the SSE apiPath.
+//
+// The handle is called for each event in the stream.
+// The stream stopped when the server send the event "end" or "error", the
+// ctx canceled, or the handle return an error.
+// The event "error" is returned as error.
+func (cl *Client) tailLog(
+	ctx context.Context, logp, apiPath, id string, counter, offset int64,
+	handle func(ev JobLogEvent) error,
+) (err error) {
+	var params = logParams(id, counter)
+
+	if offset > 0 {
+		params.Set(paramNameOffset, strconv.FormatInt(offset, 10))
+	}
+
+	var (
+		header    = http.Header{}
+		clientReq = libhttp.ClientRequest{
+			Type:   libhttp.RequestTypeQuery,
+			Path:   apiPath,
+			Header: header,
+			Params: params,
+		}
+
+		// The stream is kept open until the job finished, so it
+		// should not be limited by the RequestTimeout.
+		httpc = *cl.Client.Client
+
+		httpRes *http.Response
+		errBody []byte
+	)
+
+	header.Set(libhttp.HeaderAccept, contentTypeEventStream)
+	httpc.Timeout = 0
+
+	httpRes, errBody, err = cl.send(ctx, &httpc, clientReq)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if httpRes.StatusCode != http.StatusOK {
+		return responseError(httpRes.StatusCode, errBody)
+	}
+	defer httpRes.Body.Close()
+
+	var (
+		reader = bufio.NewReader(httpRes.Body)
+		ev     = JobLogEvent{Offset: offset}
+
+		line    string
+		field   string
+		value   string
+		hasData bool
+	)
+	for {
+		line, err = reader.ReadString('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		line = strings.TrimSuffix(line, "\n")
+
+		if len(line) == 0 {
+			// Empty line dispatch the event.
+			if !hasData {
+				continue
+			}
+			if ev.Type == sseEventError {
+				return fmt.Errorf(`%s: %s`, logp, ev.Data)
+			}
+			err = handle(ev)
+			if err != nil {
+				return err
+			}
+			if ev.Type == sseEventEnd {
+				return nil
+			}
+			ev = JobLogEvent{Offset: ev.Offset}
+			hasData = false
+			continue
+		}
+		if line[0] == ':' {
+			// Comment, the keep alive message.
+			continue
+		}
+
+		field, value, _ = strings.Cut(line, `:`)
+		switch field {
+		case `event`:
+			ev.Type = value
+		case `data`:
+			if hasData {
+				ev.Data += "\n"
+			}
+			ev.Data += value
+			hasData = true
+		case `id`:
+			ev.Offset, _ = strconv.ParseInt(value, 10, 64)
+		}
+	}
+}
+
+// WatchEnv watch the state of jobs in the server environment, by polling
+// the changes every interval, until the ctx canceled or the handle return
+// an error.
+// If interval is zero or negative it will default to 5 seconds.
+//
+// The handle is called with the whole environment on the first call, and
+// each time the jobs state changed, with the changed jobs merged into
+// the environment.
+// If the server restarted, the whole environment is fetched again.
+//
+// It return nil if the ctx is canceled.
+func (cl *Client) WatchEnv(ctx context.Context, interval time.Duration, handle func(env *Env) error) (err error) {
+	var logp = `WatchEnv`
+
+	if interval <= 0 {
+		interval = defClientWatchInterval
+	}
+
+	var (
+		env     *Env
+		changes *EnvChanges
+		epoch   int64
+		rev     int64
+	)
+
+	// Get the revision before the environment, so any changes in
+	// between will be received on the next poll.
+	changes, err = cl.ChangesContext(ctx, 0)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	epoch, rev = changes.Epoch, changes.Revision
+
+	env, err = cl.EnvContext(ctx)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = handle(env)
+	if err != nil {
+		return err
+	}
+
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+
+		changes, err = cl.ChangesContext(ctx, rev)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		if changes.Epoch != epoch {
+			epoch, rev = changes.Epoch, changes.Revision
+
+			env, err = cl.EnvContext(ctx)
+			if err != nil {
+				if ctx.Err() != nil {
+					return nil
+				}
+				return fmt.Errorf(`%s: %w`, logp, err)
+			}
+		} else {
+			rev = changes.Revision
+			if !env.mergeChanges(changes) {
+				continue
+			}
+		}
+		err = handle(env)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// mergeChanges replace the jobs in env with the one in changes.
+// It return true if there is at least one job changed.
+func (env *Env) mergeChanges(changes *EnvChanges) (changed bool) {
+	var (
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+	for name, job = range changes.ExecJobs {
+		if env.ExecJobs == nil {
+			env.ExecJobs = make(map[string]*JobExec)
+		}
+		env.ExecJobs[name] = job
+		changed = true
+	}
+	for name, jobHTTP = range changes.HTTPJobs {
+		if env.HTTPJobs == nil {
+			env.HTTPJobs = make(map[string]*JobHTTP)
+		}
+		env.HTTPJobs[name] = jobHTTP
+		changed = true
+	}
+	for name, jobSQL = range changes.SQLJobs {
+		if env.SQLJobs == nil {
+			env.SQLJobs = make(map[string]*JobSQL)
+		}
+		env.SQLJobs[name] = jobSQL
+		changed = true
+	}
+	return changed
+}
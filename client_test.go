@@ -0,0 +1,371 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"encoding/pem"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestClient(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddressAdmin: `127.0.0.1:0`,
+			DirBase:            t.TempDir(),
+			Secret:             `s3cret`,
+			Users: map[string]*User{
+				`tester`: {
+					Password: `$2a$10$9XMRfqpnzY2421fwYm5dd.CidJf7dHHWIESeeNGXuajHRf.Lqzy7a`, // s3cret
+				},
+			},
+			ExecJobs: map[string]*JobExec{
+				`hello`: &JobExec{
+					Commands: []string{`echo hello`},
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	var srv = httptest.NewServer(k.HTTPd.Server.Handler)
+	t.Cleanup(srv.Close)
+
+	var cl *Client
+
+	cl, err = NewClient(ClientOptions{
+		ClientOptions: libhttp.ClientOptions{
+			ServerURL: srv.URL,
+		},
+		Secret: env.Secret,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run(`Login`, func(tt *testing.T) {
+		err = cl.Login(`tester`, `invalid`, ``)
+		test.Assert(tt, `invalid password`, errAuthLogin.Message, err.Error())
+		test.Assert(tt, `invalid password: ErrAuthLogin`, true, errors.Is(err, ErrAuthLogin))
+
+		err = cl.Login(`tester`, `s3cret`, ``)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		// The logout require the CSRF token from login.
+		err = cl.Logout()
+		if err != nil {
+			tt.Fatal(err)
+		}
+	})
+
+	var job = env.jobExec(`hello`)
+	job.run(nil)
+
+	t.Run(`JobExecLogTail`, func(tt *testing.T) {
+		var evs []JobLogEvent
+
+		err = cl.JobExecLogTail(context.Background(), job.ID, 1, 0,
+			func(ev JobLogEvent) error {
+				evs = append(evs, ev)
+				return nil
+			})
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		var last = evs[len(evs)-1]
+		test.Assert(tt, `last event`, sseEventEnd, last.Type)
+		test.Assert(tt, `last event data`, JobStatusSuccess, last.Data)
+
+		var gotHello bool
+		var ev JobLogEvent
+		for _, ev = range evs {
+			if ev.Type == sseEventStdout && strings.HasSuffix(ev.Data, `: hello`) {
+				gotHello = true
+			}
+		}
+		test.Assert(tt, `has hello`, true, gotHello)
+
+		// Resume from the offset of last line.
+		var offset = evs[len(evs)-2].Offset
+		evs = nil
+		err = cl.JobExecLogTail(context.Background(), job.ID, 1, offset,
+			func(ev JobLogEvent) error {
+				evs = append(evs, ev)
+				return nil
+			})
+		if err != nil {
+			tt.Fatal(err)
+		}
+		test.Assert(tt, `resume events`, 1, len(evs))
+
+		err = cl.JobExecLogTail(context.Background(), job.ID, 99, 0,
+			func(JobLogEvent) error { return nil })
+		test.Assert(tt, `not found`, `JobExecLogTail: log #99 not found`, err.Error())
+	})
+
+	t.Run(`JobExecLogRaw`, func(tt *testing.T) {
+		var content []byte
+
+		content, err = cl.JobExecLogRaw(job.ID, 1)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		test.Assert(tt, `has hello`, true, strings.Contains(string(content), `: hello`))
+	})
+
+	t.Run(`JobExecLogDiff`, func(tt *testing.T) {
+		var content []byte
+
+		content, err = cl.JobExecLogDiff(job.ID, 1, 1)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		test.Assert(tt, `same log`, ``, string(content))
+
+		_, err = cl.JobExecLogDiff(job.ID, 1, 2)
+		test.Assert(tt, `not found`, `log #2 not found`, err.Error())
+	})
+
+	t.Run(`JobExecLogSearch`, func(tt *testing.T) {
+		var list []LogSearchResult
+
+		list, err = cl.JobExecLogSearch(`hello`, job.ID, ``)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		test.Assert(tt, `result`, 1, len(list))
+
+		_, err = cl.JobExecLogSearch(` `, ``, ``)
+		test.Assert(tt, `empty query`, `empty query`, err.Error())
+	})
+
+	t.Run(`WatchEnv`, func(tt *testing.T) {
+		var (
+			ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+			errStop     = errors.New(`stop`)
+			got         *Env
+		)
+		defer cancel()
+
+		err = cl.WatchEnv(ctx, time.Millisecond, func(env *Env) error {
+			got = env
+			return errStop
+		})
+		test.Assert(tt, `error`, errStop, err)
+		test.Assert(tt, `job status`, JobStatusSuccess, got.ExecJobs[`hello`].Status)
+	})
+
+	t.Run(`JobExecSecretRotate`, func(tt *testing.T) {
+		var rot *JobExecSecretRotate
+
+		rot, err = cl.JobExecSecretRotate(job.ID, time.Hour)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		test.Assert(tt, `prev_expired_at`, timeNow().Add(time.Hour), rot.PrevExpiredAt)
+
+		var list []JobExecSecret
+
+		list, err = cl.JobExecSecrets()
+		if err != nil {
+			tt.Fatal(err)
+		}
+		test.Assert(tt, `number of jobs`, 1, len(list))
+		test.Assert(tt, `is_shared`, false, list[0].IsShared)
+		test.Assert(tt, `rotated_at`, timeNow(), *list[0].RotatedAt)
+
+		_, err = cl.JobExecSecretRotate(`unknown`, 0)
+		test.Assert(tt, `not found`, `job not found: unknown`, err.Error())
+		test.Assert(tt, `not found: ErrJobNotFound`, true, errors.Is(err, ErrJobNotFound))
+		test.Assert(tt, `not found: ErrJobPaused`, false, errors.Is(err, ErrJobPaused))
+	})
+
+	t.Run(`JobSQL`, func(tt *testing.T) {
+		_, err = cl.JobSQLContext(context.Background(), `unknown`)
+		test.Assert(tt, `not found`, `invalid or empty job id: unknown`, err.Error())
+		test.Assert(tt, `not found: ErrInvalidJobID`, true, errors.Is(err, ErrInvalidJobID))
+	})
+}
+
+func TestEnv_mergeChanges(t *testing.T) {
+	var (
+		env = &Env{
+			ExecJobs: map[string]*JobExec{
+				`a`: {JobBase: JobBase{Status: JobStatusStarted}},
+				`b`: {JobBase: JobBase{Status: JobStatusStarted}},
+			},
+		}
+		changes = &EnvChanges{
+			ExecJobs: map[string]*JobExec{
+				`a`: {JobBase: JobBase{Status: JobStatusFailed}},
+			},
+		}
+	)
+
+	test.Assert(t, `empty changes`, false, env.mergeChanges(&EnvChanges{}))
+	test.Assert(t, `changed`, true, env.mergeChanges(changes))
+	test.Assert(t, `a`, JobStatusFailed, env.ExecJobs[`a`].Status)
+	test.Assert(t, `b`, JobStatusStarted, env.ExecJobs[`b`].Status)
+}
+
+func TestClient_retry(t *testing.T) {
+	var (
+		count int
+		srv   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			count++
+			if count%2 == 1 {
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+			_, _ = w.Write([]byte(`{"code":200,"data":{"epoch":1,"revision":2}}`))
+		}))
+
+		cl  *Client
+		err error
+	)
+	t.Cleanup(srv.Close)
+
+	cl, err = NewClient(ClientOptions{
+		ClientOptions: libhttp.ClientOptions{
+			ServerURL: srv.URL,
+		},
+		RetryDelay: time.Millisecond,
+		MaxRetries: 1,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes *EnvChanges
+
+	changes, err = cl.Changes(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `GET: revision`, int64(2), changes.Revision)
+	test.Assert(t, `GET: count`, 2, count)
+
+	// The POST is not retried on 500, since the request may already
+	// processed by server.
+	count = 0
+	_, err = cl.JobExecPause(`test`)
+	test.Assert(t, `POST: count`, 1, count)
+	if err == nil {
+		t.Fatal(`POST: expecting error`)
+	}
+}
+
+func TestClient_timeout(t *testing.T) {
+	var (
+		donec = make(chan struct{})
+		srv   = httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+			<-donec
+		}))
+
+		cl  *Client
+		err error
+	)
+	t.Cleanup(srv.Close)
+	t.Cleanup(func() { close(donec) })
+
+	cl, err = NewClient(ClientOptions{
+		ClientOptions: libhttp.ClientOptions{
+			ServerURL: srv.URL,
+		},
+		RequestTimeout: 50 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = cl.Changes(0)
+	var errNet net.Error
+	test.Assert(t, `timeout`, true, errors.As(err, &errNet) && errNet.Timeout())
+}
+
+func TestNewClient_caFile(t *testing.T) {
+	var srv = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(`{"code":200,"data":{"revision":1}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		dir    = t.TempDir()
+		caFile = filepath.Join(dir, `ca.pem`)
+		block  = &pem.Block{
+			Type:  `CERTIFICATE`,
+			Bytes: srv.Certificate().Raw,
+		}
+
+		cl  *Client
+		err error
+	)
+
+	err = os.WriteFile(caFile, pem.EncodeToMemory(block), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var opts = ClientOptions{
+		ClientOptions: libhttp.ClientOptions{
+			ServerURL: srv.URL,
+		},
+	}
+
+	cl, err = NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = cl.Changes(0)
+	if err == nil {
+		t.Fatal(`expecting unknown authority error`)
+	}
+
+	opts.CAFile = caFile
+	cl, err = NewClient(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var changes *EnvChanges
+
+	changes, err = cl.Changes(0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `revision`, int64(1), changes.Revision)
+
+	opts.CAFile = filepath.Join(dir, `empty.pem`)
+	err = os.WriteFile(opts.CAFile, nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = NewClient(opts)
+	test.Assert(t, `empty CA file`,
+		`NewClient: `+opts.CAFile+`: no certificate found`, err.Error())
+}
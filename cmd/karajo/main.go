@@ -40,25 +40,138 @@ Workflow on karajo,
 	                      +-----------------+
 	                      | Commands / Call |
 	                      +-----------------+`
+
+Usage,
+
+	karajo [-config <file>] [command]
+
+List of command,
+
+	agent <name> <server-url>
+		Run as agent that execute the commands of job with "target"
+		set to <name>.
+		The agent poll the job from karajo server at <server-url>,
+		for example "http://controller:31937", execute the commands
+		in this host, and stream the output back to the server.
+		The requests to server are signed using the secret from the
+		configuration file, which must be equal with the server.
+		The job working directory is located under
+		"{DirBase}/var/lib/karajo/agent".
+
+	backup [-config <file>] [-log-max-age <duration>] <file>
+		Write the configurations in "{DirBase}/etc/karajo", the job
+		states in "{DirBase}/var/lib/karajo", and the job logs in
+		"{DirBase}/var/log/karajo" modified in the last
+		"log-max-age" (default to 168h), into gzip compressed tar
+		<file>.
+		The archive contains "manifest.json" with the SHA-256 of
+		each file.
+
+	doctor [-config <file>]
+		Verify the configuration before running the server and print
+		the report of each check: the directories under DirBase are
+		writable, the SMTP server of each notification with kind
+		"email" can be connected, the "http_url" of each JobHTTP can
+		be reached using HEAD request, and the schedule of each job
+		is valid.
+		It exit with non-zero status if one of the check failed.
+
+	export <crontab|systemd> [-config <file>] [-dir <dir>]
+		Print the jobs that have schedule or interval in the
+		configuration file as crontab lines, or as systemd timer and
+		service units "karajo-<id>.timer" and "karajo-<id>.service".
+		If "-dir" is set, the generated files are written into the
+		directory instead.
+		Each command is executed using "/bin/sh -c" inside the job
+		working directory.
+		The job that cannot be converted, for example the interval
+		that cannot be expressed in crontab, is printed as comment.
+
+	export k8s [-config <file>] [-dir <dir>] [-image <name>]
+	[-namespace <name>] [-apply] [-kubeconfig <file>]
+		Print the jobs that have schedule or interval as Kubernetes
+		CronJob manifests, named "karajo-<id>", where the commands
+		are executed inside the container "image" (default to
+		"alpine:3").
+		With "-apply", the CronJobs are created or updated in the
+		cluster of current context in "kubeconfig" (default to
+		$KUBECONFIG or "$HOME/.kube/config") instead of printed.
+
+	restore [-dir-base <dir>] <file>
+		Restore the archive created by "backup" into "dir-base"
+		(default to "/").
+		Each file in the archive is verified against the manifest
+		before replacing the existing files.
+		The karajo server must be stopped before restoring.
+
+	schedule preview [-config <file>] -from <date> -to <date>
+		Print the time when each job will run between the date
+		"from" and "to", in chronological order, based on the
+		schedule or interval in the configuration file, without
+		running the server.
+		The date is in the format "YYYY-MM-DD" or RFC 3339, for
+		example "2024-01-01" or "2024-01-01T10:00:00+07:00".
+		The job with interval is assumed to be started at "from".
+
+	job dry-run <id>
+		Print the working directory, environment variables, and
+		commands that the JobExec will execute on the next run,
+		without running it.
+		The karajo server defined in the configuration file must be
+		running.
+
+	job secret-rotate [-grace <duration>] <id>
+		Generate new secret for the JobExec and print it.
+		The previous secret is still valid until the "grace" duration
+		passed (default to 24h), so the webhook sender can be
+		updated without losing any request.
+		The new secret is stored in
+		"{DirBase}/var/run/karajo/job_secret/<id>", and it replace the
+		secret in the configuration file.
+		The karajo server defined in the configuration file must be
+		running.
+
+	version
+		Print the karajo version.
+
+Without command, it will run the karajo server.
 */
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"runtime/debug"
+	"sort"
 	"strings"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
 
 	"git.sr.ht/~shulhan/karajo"
 )
 
 const (
-	cmdVersion = `version`
+	cmdAgent    = `agent`
+	cmdBackup   = `backup`
+	cmdDoctor   = `doctor`
+	cmdExport   = `export`
+	cmdJob      = `job`
+	cmdRestore  = `restore`
+	cmdSchedule = `schedule`
+	cmdVersion  = `version`
+
+	subcmdDryRun       = `dry-run`
+	subcmdPreview      = `preview`
+	subcmdSecretRotate = `secret-rotate`
 )
 
 func main() {
@@ -82,6 +195,36 @@ func main() {
 	case cmdVersion:
 		fmt.Println(`karajo version ` + karajo.Version)
 		return
+	case cmdSchedule:
+		err = doSchedule(config, flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
+	case cmdBackup:
+		err = doBackup(config, flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
+	case cmdDoctor:
+		err = doDoctor(config, flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
+	case cmdExport:
+		err = doExport(config, flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
+	case cmdRestore:
+		err = doRestore(flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
 	}
 
 	if len(config) == 0 {
@@ -94,6 +237,21 @@ func main() {
 		mlog.Fatalf(err.Error())
 	}
 
+	switch cmd {
+	case cmdAgent:
+		err = doAgent(env, flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
+	case cmdJob:
+		err = doJob(env, flag.Args()[1:])
+		if err != nil {
+			mlog.Fatalf(err.Error())
+		}
+		return
+	}
+
 	k, err = karajo.New(env)
 	if err != nil {
 		mlog.Fatalf(err.Error())
@@ -127,3 +285,501 @@ func main() {
 
 	mlog.Flush()
 }
+
+// doAgent run the agent with specific name, polling the job from the
+// server URL.
+func doAgent(env *karajo.Env, args []string) (err error) {
+	if len(args) < 2 {
+		return fmt.Errorf(`%s: missing agent name or server URL`, cmdAgent)
+	}
+
+	var secret string
+
+	secret, err = env.FetchSecret()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdAgent, err)
+	}
+
+	var (
+		opts = karajo.AgentOptions{
+			Name:    args[0],
+			DirWork: filepath.Join(env.DirBase, `var`, `lib`, `karajo`, `agent`),
+			ClientOptions: karajo.ClientOptions{
+				ClientOptions: libhttp.ClientOptions{
+					ServerURL: args[1],
+				},
+				Secret: secret,
+			},
+		}
+		agent *karajo.Agent
+	)
+
+	agent, err = karajo.NewAgent(opts)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdAgent, err)
+	}
+
+	go func() {
+		var c = make(chan os.Signal, 1)
+
+		signal.Notify(c, syscall.SIGHUP, syscall.SIGINT, syscall.SIGTERM)
+		<-c
+		agent.Stop()
+	}()
+
+	agent.Start()
+	mlog.Flush()
+	return nil
+}
+
+// doBackup write the backup of karajo configurations, states, and recent
+// job logs into file.
+func doBackup(config string, args []string) (err error) {
+	var (
+		flags = flag.NewFlagSet(cmdBackup, flag.ContinueOnError)
+
+		logMaxAge time.Duration
+	)
+
+	flags.StringVar(&config, `config`, config, `The karajo configuration file`)
+	flags.DurationVar(&logMaxAge, `log-max-age`, karajo.DefBackupLogMaxAge,
+		`Include only the job logs modified in this duration`)
+
+	err = flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdBackup, err)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf(`%s: missing file`, cmdBackup)
+	}
+
+	var env *karajo.Env
+
+	env, err = karajo.LoadEnv(config)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdBackup, err)
+	}
+
+	var (
+		file = flags.Arg(0)
+		f    *os.File
+	)
+
+	f, err = os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdBackup, err)
+	}
+
+	var manifest *karajo.BackupManifest
+
+	manifest, err = env.Backup(f, logMaxAge)
+	if err != nil {
+		_ = f.Close()
+		_ = os.Remove(file)
+		return fmt.Errorf(`%s: %w`, cmdBackup, err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdBackup, err)
+	}
+
+	fmt.Printf("Backup %d files into %s\n", len(manifest.Files), file)
+	return nil
+}
+
+// doDoctor verify the configuration and print the report.
+func doDoctor(config string, args []string) (err error) {
+	var flags = flag.NewFlagSet(cmdDoctor, flag.ContinueOnError)
+
+	flags.StringVar(&config, `config`, config, `The karajo configuration file`)
+
+	err = flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdDoctor, err)
+	}
+
+	var env *karajo.Env
+
+	env, err = karajo.LoadEnv(config)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdDoctor, err)
+	}
+
+	var rep *karajo.DoctorReport
+
+	rep, err = env.Doctor(context.Background())
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdDoctor, err)
+	}
+
+	var (
+		tw    = tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+		check karajo.DoctorCheck
+	)
+	fmt.Fprintln(tw, "STATUS\tKIND\tTARGET\tMESSAGE")
+	for _, check = range rep.Checks {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", check.Status, check.Kind,
+			check.Target, check.Message)
+	}
+	_ = tw.Flush()
+
+	var nfailed = rep.Failed()
+	if nfailed > 0 {
+		return fmt.Errorf(`%s: %d of %d checks failed`, cmdDoctor, nfailed, len(rep.Checks))
+	}
+	fmt.Printf("\nAll %d checks passed.\n", len(rep.Checks))
+	return nil
+}
+
+// doExport print or write the jobs in the configuration as crontab or
+// systemd units.
+func doExport(config string, args []string) (err error) {
+	if len(args) == 0 {
+		return fmt.Errorf(`%s: missing format`, cmdExport)
+	}
+
+	var (
+		format = strings.ToLower(args[0])
+		logp   = cmdExport + ` ` + format
+		flags  = flag.NewFlagSet(logp, flag.ContinueOnError)
+
+		opts = karajo.JobExportOptions{
+			Format: format,
+		}
+		dir        string
+		kubeconfig string
+		isApply    bool
+	)
+
+	flags.StringVar(&config, `config`, config, `The karajo configuration file`)
+	flags.StringVar(&dir, `dir`, ``, `The directory where the files written`)
+	if format == karajo.JobExportFormatK8s {
+		flags.StringVar(&opts.Image, `image`, ``, `The container image that run the commands`)
+		flags.StringVar(&opts.Namespace, `namespace`, ``, `The namespace of CronJob`)
+		flags.BoolVar(&isApply, `apply`, false, `Apply the CronJob into cluster`)
+		flags.StringVar(&kubeconfig, `kubeconfig`, ``, `The kubeconfig file`)
+	}
+
+	err = flags.Parse(args[1:])
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var env *karajo.Env
+
+	env, err = karajo.LoadEnv(config)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	if isApply {
+		var (
+			names []string
+			name  string
+		)
+
+		names, err = env.ApplyJobsK8s(opts, kubeconfig)
+		for _, name = range names {
+			fmt.Printf("cronjob/%s applied\n", name)
+		}
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		return nil
+	}
+
+	var files []karajo.ExportFile
+
+	files, err = env.ExportJobs(opts)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var file karajo.ExportFile
+	for _, file = range files {
+		if len(dir) == 0 {
+			if len(files) > 1 {
+				fmt.Printf("### %s\n", file.Name)
+			}
+			fmt.Printf("%s\n", file.Content)
+			continue
+		}
+		err = os.WriteFile(filepath.Join(dir, file.Name), file.Content, 0644)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return nil
+}
+
+// doRestore restore the backup file into directory base.
+func doRestore(args []string) (err error) {
+	var (
+		flags = flag.NewFlagSet(cmdRestore, flag.ContinueOnError)
+
+		dirBase string
+	)
+
+	flags.StringVar(&dirBase, `dir-base`, `/`, `The base directory to restore`)
+
+	err = flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdRestore, err)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf(`%s: missing file`, cmdRestore)
+	}
+
+	var f *os.File
+
+	f, err = os.Open(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdRestore, err)
+	}
+	defer f.Close()
+
+	var (
+		env = karajo.Env{
+			DirBase: dirBase,
+		}
+		manifest *karajo.BackupManifest
+	)
+
+	manifest, err = env.Restore(f)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, cmdRestore, err)
+	}
+
+	fmt.Printf("Restore %d files into %s from backup of %q at %s, created by karajo %s\n",
+		len(manifest.Files), dirBase, manifest.Hostname,
+		manifest.CreatedAt.Format(time.RFC3339), manifest.Version)
+	return nil
+}
+
+// doJob execute the sub command for job.
+func doJob(env *karajo.Env, args []string) (err error) {
+	if len(args) == 0 {
+		return fmt.Errorf(`%s: missing sub command`, cmdJob)
+	}
+
+	var subcmd = strings.ToLower(args[0])
+
+	switch subcmd {
+	case subcmdDryRun:
+		if len(args) < 2 {
+			return fmt.Errorf(`%s %s: missing job ID`, cmdJob, subcmd)
+		}
+		return doJobDryRun(env, args[1])
+	case subcmdSecretRotate:
+		return doJobSecretRotate(env, args[1:])
+	}
+	return fmt.Errorf(`%s: unknown sub command %q`, cmdJob, subcmd)
+}
+
+// doSchedule execute the sub command for schedule.
+func doSchedule(config string, args []string) (err error) {
+	if len(args) == 0 {
+		return fmt.Errorf(`%s: missing sub command`, cmdSchedule)
+	}
+
+	var subcmd = strings.ToLower(args[0])
+
+	switch subcmd {
+	case subcmdPreview:
+		return doSchedulePreview(config, args[1:])
+	}
+	return fmt.Errorf(`%s: unknown sub command %q`, cmdSchedule, subcmd)
+}
+
+// doSchedulePreview print the next runs of all jobs in the configuration
+// between "-from" and "-to" date.
+func doSchedulePreview(config string, args []string) (err error) {
+	var (
+		logp  = cmdSchedule + ` ` + subcmdPreview
+		flags = flag.NewFlagSet(logp, flag.ContinueOnError)
+
+		fromStr string
+		toStr   string
+	)
+
+	flags.StringVar(&config, `config`, config, `The karajo configuration file`)
+	flags.StringVar(&fromStr, `from`, ``, `The start date, "YYYY-MM-DD" or RFC 3339`)
+	flags.StringVar(&toStr, `to`, ``, `The end date, "YYYY-MM-DD" or RFC 3339`)
+
+	err = flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var from, to time.Time
+
+	from, err = parseDate(fromStr)
+	if err != nil {
+		return fmt.Errorf(`%s: invalid from: %w`, logp, err)
+	}
+	to, err = parseDate(toStr)
+	if err != nil {
+		return fmt.Errorf(`%s: invalid to: %w`, logp, err)
+	}
+
+	var env *karajo.Env
+
+	env, err = karajo.LoadEnv(config)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var sch *karajo.Schedule
+
+	sch, err = env.PreviewSchedule(from, to)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	type occurrence struct {
+		at  time.Time
+		job *karajo.ScheduleJob
+	}
+
+	var (
+		list []occurrence
+		sj   *karajo.ScheduleJob
+		at   time.Time
+	)
+	for _, sj = range sch.Jobs {
+		for _, at = range sj.NextRuns {
+			list = append(list, occurrence{at: at, job: sj})
+		}
+	}
+	sort.SliceStable(list, func(x, y int) bool {
+		return list[x].at.Before(list[y].at)
+	})
+
+	var occ occurrence
+	for _, occ = range list {
+		fmt.Printf("%s  %-8s  %s\n", occ.at.Format(time.RFC3339), occ.job.Kind, occ.job.ID)
+	}
+	return nil
+}
+
+// parseDate parse the date in the format "YYYY-MM-DD" or RFC 3339.
+func parseDate(v string) (t time.Time, err error) {
+	if len(v) == 0 {
+		return t, errors.New(`empty date`)
+	}
+	t, err = time.Parse(time.DateOnly, v)
+	if err == nil {
+		return t, nil
+	}
+	return time.Parse(time.RFC3339, v)
+}
+
+// doJobDryRun print how the JobExec with specific id will be executed.
+func doJobDryRun(env *karajo.Env, id string) (err error) {
+	var cl *karajo.Client
+
+	cl, err = newClient(env)
+	if err != nil {
+		return err
+	}
+
+	var dry *karajo.JobExecDryRun
+
+	dry, err = cl.JobExecDryRun(id)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID: %s\n", dry.ID)
+	fmt.Printf("Working directory: %s\n", dry.DirWork)
+
+	if dry.IsCall {
+		fmt.Println(`Execute: Call handler`)
+		return nil
+	}
+
+	fmt.Println(`Environment variables:`)
+	var v string
+	for _, v = range dry.Envs {
+		fmt.Printf("  %s\n", v)
+	}
+
+	printDryRunCommands(`Pre-commands:`, dry.PreCommands)
+	printDryRunCommands(`Commands:`, dry.Commands)
+	printDryRunCommands(`Post-commands:`, dry.PostCommands)
+	return nil
+}
+
+// doJobSecretRotate generate new secret for the JobExec and print it.
+func doJobSecretRotate(env *karajo.Env, args []string) (err error) {
+	var (
+		logp  = cmdJob + ` ` + subcmdSecretRotate
+		flags = flag.NewFlagSet(logp, flag.ContinueOnError)
+
+		grace time.Duration
+	)
+
+	flags.DurationVar(&grace, `grace`, 0,
+		`The duration where the previous secret still valid (default to 24h)`)
+
+	err = flags.Parse(args)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if flags.NArg() == 0 {
+		return fmt.Errorf(`%s: missing job ID`, logp)
+	}
+
+	var cl *karajo.Client
+
+	cl, err = newClient(env)
+	if err != nil {
+		return err
+	}
+
+	var rot *karajo.JobExecSecretRotate
+
+	rot, err = cl.JobExecSecretRotate(flags.Arg(0), grace)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("ID: %s\n", rot.ID)
+	fmt.Printf("Secret: %s\n", rot.Secret)
+	fmt.Printf("Previous secret valid until: %s\n", rot.PrevExpiredAt.Format(time.RFC3339))
+	return nil
+}
+
+// printDryRunCommands print the list of commands with its index, if its
+// not empty.
+func printDryRunCommands(title string, cmds []string) {
+	if len(cmds) == 0 {
+		return
+	}
+	fmt.Println(title)
+	var (
+		v string
+		x int
+	)
+	for x, v = range cmds {
+		fmt.Printf("  %2d: %s\n", x, v)
+	}
+}
+
+// newClient create new karajo HTTP client to the server defined in env.
+func newClient(env *karajo.Env) (cl *karajo.Client, err error) {
+	var secret string
+
+	secret, err = env.FetchSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	var clientOpts = karajo.ClientOptions{
+		ClientOptions: libhttp.ClientOptions{
+			ServerURL: `http://` + env.ServerAddress(),
+		},
+		Secret: secret,
+	}
+	return karajo.NewClient(clientOpts)
+}
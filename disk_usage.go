@@ -0,0 +1,246 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// defJobDiskUsageName the name of built-in JobExec that report the disk
+// usage of DirBase.
+const defJobDiskUsageName = `karajo disk usage`
+
+// diskUsageReport contains the size, in bytes, of each directory under
+// DirBase at one point in time.
+type diskUsageReport struct {
+	CheckedAt time.Time `json:"checked_at"`
+
+	// Logs the size of job logs directory, "var/log/karajo".
+	Logs int64 `json:"logs"`
+
+	// Lib the size of job state directory, "var/lib/karajo", excluding
+	// the artifacts.
+	Lib int64 `json:"lib"`
+
+	// Artifacts the size of JobExec artifacts directory,
+	// "var/lib/karajo/artifacts".
+	Artifacts int64 `json:"artifacts"`
+
+	// Total the sum of Logs, Lib, and Artifacts.
+	Total int64 `json:"total"`
+
+	// Max the value of env DiskUsageMax.
+	Max int64 `json:"max,omitempty"`
+
+	// IsOverMax true if the Total is greater or equal to Max.
+	IsOverMax bool `json:"is_over_max,omitempty"`
+}
+
+// diskUsage contains the last report of disk usage, updated by the
+// built-in job "karajo disk usage".
+type diskUsage struct {
+	// revision bumped when the report updated.
+	revision *envRevision
+
+	last *diskUsageReport
+	mtx  sync.Mutex
+}
+
+// newDiskUsage create new diskUsage without report.
+func newDiskUsage(revision *envRevision) (du *diskUsage) {
+	du = &diskUsage{
+		revision: revision,
+	}
+	return du
+}
+
+// report return the last report, or nil if the disk usage has not been
+// measured yet.
+func (du *diskUsage) report() (rep *diskUsageReport) {
+	if du == nil {
+		return nil
+	}
+	du.mtx.Lock()
+	rep = du.last
+	du.mtx.Unlock()
+	return rep
+}
+
+// set replace the last report with rep and return the previous one.
+func (du *diskUsage) set(rep *diskUsageReport) (prev *diskUsageReport) {
+	du.mtx.Lock()
+	prev = du.last
+	du.last = rep
+	du.mtx.Unlock()
+	du.revision.bump()
+	return prev
+}
+
+// MarshalJSON encode the last report as JSON object, or null if the disk
+// usage has not been measured yet.
+func (du *diskUsage) MarshalJSON() ([]byte, error) {
+	return json.Marshal(du.report())
+}
+
+// jobDiskUsage measure the disk usage of DirBase.
+type jobDiskUsage struct {
+	env *Env
+}
+
+// newJobDiskUsage create new JobExec that measure the disk usage of
+// DirBase every env DiskUsageInterval.
+// The job failed, and notification sent to DiskUsageNotif, when the total
+// usage cross the DiskUsageMax.
+func newJobDiskUsage(env *Env) (job *JobExec) {
+	var jdu = &jobDiskUsage{
+		env: env,
+	}
+
+	job = &JobExec{
+		JobBase: JobBase{
+			Description:   `Report the disk usage of logs, lib, and artifacts directories.`,
+			Interval:      env.DiskUsageInterval,
+			NotifOnFailed: env.DiskUsageNotif,
+		},
+		Call: jdu.call,
+	}
+	return job
+}
+
+// call measure the disk usage, write the report into the job log, and
+// return an error if the total usage cross the DiskUsageMax since the
+// previous run.
+func (jdu *jobDiskUsage) call(_ context.Context, log io.Writer, _ *libhttp.EndpointRequest) (err error) {
+	var rep *diskUsageReport
+
+	rep, err = jdu.env.measureDiskUsage(timeNow())
+	if err != nil {
+		return err
+	}
+
+	var prev = jdu.env.DiskUsage.set(rep)
+
+	_, err = log.Write(rep.format())
+	if err != nil {
+		return err
+	}
+
+	if !rep.IsOverMax {
+		if prev != nil && prev.IsOverMax {
+			fmt.Fprintf(log, "Total usage back below the disk_usage_max %d bytes.\n", rep.Max)
+		}
+		return nil
+	}
+	if prev != nil && prev.IsOverMax {
+		// The notification only sent once when the usage cross the
+		// threshold, not on every run.
+		fmt.Fprintf(log, "Total usage still exceed the disk_usage_max %d bytes.\n", rep.Max)
+		return nil
+	}
+	return fmt.Errorf(`total usage %d bytes exceed the disk_usage_max %d bytes`,
+		rep.Total, rep.Max)
+}
+
+// measureDiskUsage walk the logs, lib, and artifacts directories and sum
+// the size of their files.
+func (env *Env) measureDiskUsage(now time.Time) (rep *diskUsageReport, err error) {
+	var (
+		logp   = `measureDiskUsage`
+		dirLog = filepath.Dir(env.dirLogJob)
+		dirLib = filepath.Dir(env.dirLibJob)
+	)
+
+	rep = &diskUsageReport{
+		CheckedAt: now,
+		Max:       env.DiskUsageMax,
+	}
+
+	rep.Logs, err = dirSize(dirLog, ``)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	rep.Lib, err = dirSize(dirLib, env.dirLibArtifact)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	rep.Artifacts, err = dirSize(env.dirLibArtifact, ``)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	rep.Total = rep.Logs + rep.Lib + rep.Artifacts
+	rep.IsOverMax = rep.Max > 0 && rep.Total >= rep.Max
+
+	return rep, nil
+}
+
+// dirSize return the total size of regular files inside the dir,
+// excluding the sub directory skip.
+// The file that removed while walking, for example by log janitor, is
+// ignored.
+func dirSize(dir, skip string) (size int64, err error) {
+	err = filepath.WalkDir(dir, func(path string, de fs.DirEntry, errWalk error) error {
+		if errWalk != nil {
+			if errors.Is(errWalk, fs.ErrNotExist) {
+				return nil
+			}
+			return errWalk
+		}
+		if de.IsDir() {
+			if len(skip) != 0 && path == skip {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !de.Type().IsRegular() {
+			return nil
+		}
+
+		var fi, errInfo = de.Info()
+		if errInfo != nil {
+			if errors.Is(errInfo, os.ErrNotExist) {
+				return nil
+			}
+			return errInfo
+		}
+		size += fi.Size()
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return size, nil
+}
+
+// format the report as list of directory and its size.
+func (rep *diskUsageReport) format() []byte {
+	var (
+		buf bytes.Buffer
+		tw  = tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+	)
+
+	fmt.Fprintf(tw, "logs\t%d\n", rep.Logs)
+	fmt.Fprintf(tw, "lib\t%d\n", rep.Lib)
+	fmt.Fprintf(tw, "artifacts\t%d\n", rep.Artifacts)
+	fmt.Fprintf(tw, "total\t%d\n", rep.Total)
+	if rep.Max > 0 {
+		fmt.Fprintf(tw, "max\t%d\n", rep.Max)
+	}
+	_ = tw.Flush()
+
+	return buf.Bytes()
+}
@@ -0,0 +1,119 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobDiskUsage_call(t *testing.T) {
+	var (
+		env = Env{
+			DirBase:           t.TempDir(),
+			Secret:            `s3cret`,
+			DiskUsageInterval: time.Minute,
+			DiskUsageMax:      100,
+		}
+		err error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job = env.ExecJobs[defJobDiskUsageName]
+	if job == nil {
+		t.Fatalf(`built-in job %q not found`, defJobDiskUsageName)
+	}
+
+	var writeFile = func(dir, name string, size int) {
+		err = os.WriteFile(filepath.Join(dir, name), bytes.Repeat([]byte(`x`), size), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	type testCase struct {
+		desc     string
+		dir      string
+		expError string
+		expRep   diskUsageReport
+		size     int
+	}
+
+	var cases = []testCase{{
+		desc: `below max`,
+		dir:  env.dirLogJob,
+		size: 40,
+		expRep: diskUsageReport{
+			Logs:  40,
+			Total: 40,
+			Max:   100,
+		},
+	}, {
+		desc:     `cross the max`,
+		dir:      env.dirLibArtifact,
+		size:     60,
+		expError: `total usage 100 bytes exceed the disk_usage_max 100 bytes`,
+		expRep: diskUsageReport{
+			Logs:      40,
+			Artifacts: 60,
+			Total:     100,
+			Max:       100,
+			IsOverMax: true,
+		},
+	}, {
+		desc: `still over the max`,
+		dir:  env.dirLibJob,
+		size: 10,
+		expRep: diskUsageReport{
+			Logs:      40,
+			Lib:       10,
+			Artifacts: 60,
+			Total:     110,
+			Max:       100,
+			IsOverMax: true,
+		},
+	}}
+
+	var (
+		c   testCase
+		buf bytes.Buffer
+		rep *diskUsageReport
+	)
+	for _, c = range cases {
+		writeFile(c.dir, c.desc, c.size)
+
+		buf.Reset()
+		err = job.Call(context.Background(), &buf, nil)
+		if err != nil {
+			test.Assert(t, c.desc+`: error`, c.expError, err.Error())
+		} else {
+			test.Assert(t, c.desc+`: error`, c.expError, ``)
+		}
+
+		rep = env.DiskUsage.report()
+		c.expRep.CheckedAt = rep.CheckedAt
+		test.Assert(t, c.desc+`: report`, c.expRep, *rep)
+		test.Assert(t, c.desc+`: log`, true, bytes.HasPrefix(buf.Bytes(), rep.format()))
+	}
+
+	var got []byte
+
+	got, err = json.Marshal(env.DiskUsage)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var exp = `{"checked_at":"2023-01-09T00:00:00Z","logs":40,"lib":10,"artifacts":60,"total":110,"max":100,"is_over_max":true}`
+	test.Assert(t, `MarshalJSON`, exp, string(got))
+}
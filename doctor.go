@@ -0,0 +1,409 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/smtp"
+)
+
+// defDoctorTimeout the maximum duration for each network check in
+// [Env.Doctor].
+const defDoctorTimeout = 10 * time.Second
+
+// List of kind of check in [DoctorCheck].
+const (
+	DoctorKindDir      = `dir`
+	DoctorKindNotif    = `notif`
+	DoctorKindHTTP     = `http`
+	DoctorKindSchedule = `schedule`
+)
+
+// List of status of check in [DoctorCheck].
+const (
+	DoctorStatusOK   = `ok`
+	DoctorStatusWarn = `warn`
+	DoctorStatusFail = `fail`
+	DoctorStatusSkip = `skip`
+)
+
+// DoctorCheck contains the result of single check.
+type DoctorCheck struct {
+	// Kind of check, one of the DoctorKind*.
+	Kind string `json:"kind"`
+
+	// Target of check, for example the directory path, the
+	// notification name, or the job ID.
+	Target string `json:"target"`
+
+	// Status of check, one of the DoctorStatus*.
+	Status string `json:"status"`
+
+	Message string `json:"message,omitempty"`
+}
+
+// DoctorReport contains the result of all checks from [Env.Doctor].
+type DoctorReport struct {
+	Checks []DoctorCheck `json:"checks"`
+}
+
+// Failed return the number of checks with status fail.
+func (rep *DoctorReport) Failed() (n int) {
+	var check DoctorCheck
+	for _, check = range rep.Checks {
+		if check.Status == DoctorStatusFail {
+			n++
+		}
+	}
+	return n
+}
+
+// add the result of check into report.
+func (rep *DoctorReport) add(kind, target, status, msg string) {
+	rep.Checks = append(rep.Checks, DoctorCheck{
+		Kind:    kind,
+		Target:  target,
+		Status:  status,
+		Message: msg,
+	})
+}
+
+// Doctor verify the configuration in the environment before running the
+// server, and return the report of each check.
+//
+// The following checks are run,
+//
+//   - the directories under DirBase exist and writable by current user,
+//   - the SMTP server of each notification with kind "email" can be
+//     connected and authenticated,
+//   - the JobHTTP http_url can be reached using HEAD request,
+//   - the schedule of each job is valid.
+//
+// The network check is limited to 10 seconds each.
+// It will return an error only if the jobs in the job.d, job_http.d, and
+// job_sql.d directories cannot be loaded.
+// This method should be called on Env that has not been initialized, for
+// example the one returned from [LoadEnv].
+func (env *Env) Doctor(ctx context.Context) (rep *DoctorReport, err error) {
+	var logp = `Doctor`
+
+	env.initDirsConfig()
+
+	err = env.loadJobd()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = env.loadJobHTTPd()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = env.loadJobSQLd()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	rep = &DoctorReport{}
+
+	env.doctorDirs(rep)
+	env.doctorNotifs(ctx, rep)
+	env.doctorJobHTTPs(ctx, rep)
+	env.doctorSchedules(rep)
+
+	return rep, nil
+}
+
+// doctorDirs check the configuration directory is readable and the
+// state, log, and run directories are writable.
+func (env *Env) doctorDirs(rep *DoctorReport) {
+	var (
+		dirLib = filepath.Join(env.DirBase, `var`, `lib`, defEnvName)
+		dirLog = filepath.Join(env.DirBase, `var`, `log`, defEnvName)
+		dirRun = filepath.Join(env.DirBase, `var`, `run`, defEnvName)
+
+		listWritable = []string{
+			dirLib,
+			filepath.Join(dirLib, `job`),
+			filepath.Join(dirLib, `job_http`),
+			filepath.Join(dirLib, `job_sql`),
+			filepath.Join(dirLib, `artifacts`),
+			dirLog,
+			filepath.Join(dirLog, `job`),
+			filepath.Join(dirLog, `job_http`),
+			filepath.Join(dirLog, `job_sql`),
+			dirRun,
+			filepath.Join(dirRun, `job_http`),
+			filepath.Join(dirRun, `job_secret`),
+		}
+
+		dir    string
+		status string
+		msg    string
+	)
+
+	status, msg = doctorDirReadable(env.dirConfig)
+	rep.add(DoctorKindDir, env.dirConfig, status, msg)
+
+	if len(env.DirPublic) != 0 {
+		status, msg = doctorDirReadable(env.DirPublic)
+		rep.add(DoctorKindDir, env.DirPublic, status, msg)
+	}
+
+	for _, dir = range listWritable {
+		status, msg = doctorDirWritable(dir)
+		rep.add(DoctorKindDir, dir, status, msg)
+	}
+}
+
+// doctorDirReadable check if the dir exist and can be read.
+// The missing dir is reported as warning, since its optional.
+func doctorDirReadable(dir string) (status, msg string) {
+	var _, err = os.ReadDir(dir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return DoctorStatusWarn, `not exist`
+		}
+		return DoctorStatusFail, err.Error()
+	}
+	return DoctorStatusOK, `readable`
+}
+
+// doctorDirWritable check if the file can be created inside the dir.
+// If the dir does not exist, it check if the dir can be created by
+// checking its nearest parent.
+func doctorDirWritable(dir string) (status, msg string) {
+	var fi, err = os.Stat(dir)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			return DoctorStatusFail, err.Error()
+		}
+		var parent = filepath.Dir(dir)
+		for parent != filepath.Dir(parent) {
+			fi, err = os.Stat(parent)
+			if err == nil {
+				break
+			}
+			parent = filepath.Dir(parent)
+		}
+		status, msg = doctorDirWritable(parent)
+		if status == DoctorStatusFail {
+			return DoctorStatusFail, fmt.Sprintf(`not exist and cannot be created: %s: %s`, parent, msg)
+		}
+		return DoctorStatusWarn, `not exist, will be created on start`
+	}
+	if !fi.IsDir() {
+		return DoctorStatusFail, `not a directory`
+	}
+
+	var f *os.File
+
+	f, err = os.CreateTemp(dir, `.karajo-doctor-*`)
+	if err != nil {
+		return DoctorStatusFail, `not writable: ` + err.Error()
+	}
+	_ = f.Close()
+	_ = os.Remove(f.Name())
+
+	if fi.Mode().Perm()&0o002 != 0 {
+		return DoctorStatusWarn, fmt.Sprintf(`writable by others (%s)`, fi.Mode().Perm())
+	}
+	return DoctorStatusOK, `writable`
+}
+
+// doctorNotifs check the connection to SMTP server for each notification
+// with kind email.
+func (env *Env) doctorNotifs(ctx context.Context, rep *DoctorReport) {
+	var (
+		names = make([]string, 0, len(env.Notif))
+
+		name     string
+		envNotif EnvNotif
+		err      error
+	)
+	for name = range env.Notif {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name = range names {
+		envNotif = env.Notif[name]
+		envNotif.Name = name
+		envNotif.init()
+
+		if envNotif.Kind != notifKindEmail {
+			rep.add(DoctorKindNotif, name, DoctorStatusSkip,
+				fmt.Sprintf(`kind %q is not checked`, envNotif.Kind))
+			continue
+		}
+
+		err = doctorSMTP(ctx, envNotif.smtpClientOptions())
+		if err != nil {
+			rep.add(DoctorKindNotif, name, DoctorStatusFail,
+				fmt.Sprintf(`%s: %s`, envNotif.SMTPServer, err))
+			continue
+		}
+		rep.add(DoctorKindNotif, name, DoctorStatusOK,
+			envNotif.SMTPServer+`: connected`)
+	}
+}
+
+// doctorSMTP connect and authenticate to the SMTP server.
+// The [smtp.NewClient] does not have timeout, so it is run in goroutine
+// that is abandoned when the ctx done or timeout.
+func doctorSMTP(ctx context.Context, opts smtp.ClientOptions) (err error) {
+	var errq = make(chan error, 1)
+
+	go func() {
+		var cl, errClient = smtp.NewClient(opts)
+		if errClient == nil {
+			_, _ = cl.Quit()
+		}
+		errq <- errClient
+	}()
+
+	var timer = time.NewTimer(defDoctorTimeout)
+	defer timer.Stop()
+
+	select {
+	case err = <-errq:
+		return err
+	case <-timer.C:
+		return fmt.Errorf(`timeout after %s`, defDoctorTimeout)
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// doctorJobHTTPs send the HEAD request to http_url of each JobHTTP.
+// Any response from server means the URL is reachable, but the status
+// code 4xx and 5xx is reported as warning.
+func (env *Env) doctorJobHTTPs(ctx context.Context, rep *DoctorReport) {
+	var (
+		httpc = &http.Client{
+			Timeout: defDoctorTimeout,
+		}
+		names = make([]string, 0, len(env.HTTPJobs))
+
+		name    string
+		jobHTTP *JobHTTP
+		httpURL string
+		status  string
+		msg     string
+	)
+	for name = range env.HTTPJobs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name = range names {
+		jobHTTP = env.HTTPJobs[name]
+		httpURL = expandEnv(jobHTTP.HTTPURL)
+
+		switch {
+		case len(httpURL) == 0:
+			status, msg = DoctorStatusFail, `empty http_url`
+		case strings.HasPrefix(httpURL, `/`):
+			status, msg = DoctorStatusSkip, httpURL+`: relative to karajo server`
+		default:
+			status, msg = doctorHead(ctx, httpc, httpURL)
+			msg = jobHTTP.HTTPURL + `: ` + msg
+		}
+		rep.add(DoctorKindHTTP, name, status, msg)
+	}
+}
+
+// doctorHead send the HEAD request to the URL.
+func doctorHead(ctx context.Context, httpc *http.Client, rawURL string) (status, msg string) {
+	var (
+		httpReq *http.Request
+		err     error
+	)
+
+	httpReq, err = http.NewRequestWithContext(ctx, http.MethodHead, rawURL, nil)
+	if err != nil {
+		return DoctorStatusFail, err.Error()
+	}
+
+	var httpRes *http.Response
+
+	httpRes, err = httpc.Do(httpReq)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+		return DoctorStatusFail, err.Error()
+	}
+	_ = httpRes.Body.Close()
+
+	if httpRes.StatusCode >= http.StatusBadRequest {
+		return DoctorStatusWarn, httpRes.Status
+	}
+	return DoctorStatusOK, httpRes.Status
+}
+
+// doctorSchedules check the schedule and interval of each job.
+func (env *Env) doctorSchedules(rep *DoctorReport) {
+	type doctorJob struct {
+		job  *JobBase
+		name string
+	}
+
+	var (
+		list    []doctorJob
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+	for name, job = range env.ExecJobs {
+		list = append(list, doctorJob{&job.JobBase, string(jobKindExec) + ` ` + name})
+	}
+	for name, jobHTTP = range env.HTTPJobs {
+		list = append(list, doctorJob{&jobHTTP.JobBase, string(jobKindHTTP) + ` ` + name})
+	}
+	for name, jobSQL = range env.SQLJobs {
+		list = append(list, doctorJob{&jobSQL.JobBase, string(jobKindSQL) + ` ` + name})
+	}
+	sort.Slice(list, func(x, y int) bool {
+		return list[x].name < list[y].name
+	})
+
+	var (
+		dj       doctorJob
+		schedule string
+		err      error
+	)
+	for _, dj = range list {
+		schedule = strings.TrimSpace(dj.job.Schedule)
+
+		switch {
+		case len(schedule) != 0:
+			_, err = newScheduleMatcher(schedule)
+			if err != nil {
+				rep.add(DoctorKindSchedule, dj.name, DoctorStatusFail, err.Error())
+				continue
+			}
+			rep.add(DoctorKindSchedule, dj.name, DoctorStatusOK, schedule)
+
+		case dj.job.Interval > 0 && dj.job.Interval < time.Minute:
+			rep.add(DoctorKindSchedule, dj.name, DoctorStatusWarn,
+				fmt.Sprintf(`interval %s is less than 1m, will be set to 1m`, dj.job.Interval))
+
+		case dj.job.Interval > 0:
+			rep.add(DoctorKindSchedule, dj.name, DoctorStatusOK,
+				`every `+dj.job.Interval.String())
+		}
+	}
+}
@@ -0,0 +1,146 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_Doctor(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == `/missing` {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		dirBase = t.TempDir()
+		dirLog  = filepath.Join(dirBase, `var`, `log`, defEnvName)
+		env     = Env{
+			DirBase: dirBase,
+			Notif: map[string]EnvNotif{
+				`ntfy`: {
+					Kind: notifKindNtfy,
+				},
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`ping`: {
+					HTTPURL: srv.URL + `/ping`,
+				},
+				`missing`: {
+					HTTPURL: srv.URL + `/missing`,
+				},
+				`self`: {
+					HTTPURL: `/karajo/api/environment`,
+				},
+			},
+			ExecJobs: map[string]*JobExec{
+				`nightly`: {
+					JobBase: JobBase{
+						Schedule: `daily@00:00`,
+					},
+				},
+				`bad schedule`: {
+					JobBase: JobBase{
+						Schedule: `yearly@00:00`,
+					},
+				},
+				`fast`: {
+					JobBase: JobBase{
+						Interval: 10 * time.Second,
+					},
+				},
+			},
+		}
+		rep *DoctorReport
+		err error
+	)
+
+	err = os.MkdirAll(filepath.Join(dirLog, `job`), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The job_sql log directory is a file.
+	err = os.WriteFile(filepath.Join(dirLog, `job_sql`), nil, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rep, err = env.Doctor(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		gotDirs = map[string]string{}
+		got     []DoctorCheck
+		check   DoctorCheck
+	)
+	for _, check = range rep.Checks {
+		if check.Kind == DoctorKindDir {
+			gotDirs[check.Target] = check.Status + `: ` + check.Message
+			continue
+		}
+		got = append(got, check)
+	}
+
+	test.Assert(t, `dir config`, `warn: not exist`,
+		gotDirs[filepath.Join(dirBase, `etc`, defEnvName)])
+	test.Assert(t, `dir log`, `ok: writable`, gotDirs[dirLog])
+	test.Assert(t, `dir log job`, `ok: writable`, gotDirs[filepath.Join(dirLog, `job`)])
+	test.Assert(t, `dir log job_sql`, `fail: not a directory`,
+		gotDirs[filepath.Join(dirLog, `job_sql`)])
+	test.Assert(t, `dir lib job`, `warn: not exist, will be created on start`,
+		gotDirs[filepath.Join(dirBase, `var`, `lib`, defEnvName, `job`)])
+
+	var exp = []DoctorCheck{{
+		Kind:    DoctorKindNotif,
+		Target:  `ntfy`,
+		Status:  DoctorStatusSkip,
+		Message: `kind "ntfy" is not checked`,
+	}, {
+		Kind:    DoctorKindHTTP,
+		Target:  `missing`,
+		Status:  DoctorStatusWarn,
+		Message: srv.URL + `/missing: 404 Not Found`,
+	}, {
+		Kind:    DoctorKindHTTP,
+		Target:  `ping`,
+		Status:  DoctorStatusOK,
+		Message: srv.URL + `/ping: 200 OK`,
+	}, {
+		Kind:    DoctorKindHTTP,
+		Target:  `self`,
+		Status:  DoctorStatusSkip,
+		Message: `/karajo/api/environment: relative to karajo server`,
+	}, {
+		Kind:    DoctorKindSchedule,
+		Target:  `job bad schedule`,
+		Status:  DoctorStatusFail,
+		Message: `yearly@00:00: unknown schedule`,
+	}, {
+		Kind:    DoctorKindSchedule,
+		Target:  `job fast`,
+		Status:  DoctorStatusWarn,
+		Message: `interval 10s is less than 1m, will be set to 1m`,
+	}, {
+		Kind:    DoctorKindSchedule,
+		Target:  `job nightly`,
+		Status:  DoctorStatusOK,
+		Message: `daily@00:00`,
+	}}
+	test.Assert(t, `checks`, exp, got)
+	test.Assert(t, `Failed`, 2, rep.Failed())
+}
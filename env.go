@@ -4,12 +4,18 @@
 package karajo
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
 	"io/fs"
+	"net/netip"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
@@ -26,6 +32,12 @@ const (
 	defMaxJobRunning = 1
 )
 
+// defNotifQueueSize the default value of NotifQueueSize.
+const defNotifQueueSize = 64
+
+// defHALeaseMin the minimum value of HALease.
+const defHALeaseMin = 3 * time.Second
+
 // Env contains configuration for HTTP server, logs, and list of jobs.
 type Env struct {
 	// List of JobExec by name.
@@ -34,17 +46,82 @@ type Env struct {
 	// List of JobHTTP by name.
 	HTTPJobs map[string]*JobHTTP `ini:"job.http" json:"http_jobs"`
 
+	// List of JobSQL by name.
+	SQLJobs map[string]*JobSQL `ini:"job.sql" json:"sql_jobs,omitempty"`
+
 	// Notif contains list of notification setting.
 	Notif map[string]EnvNotif `ini:"notif" json:"-"`
 
 	// Index of notification client by its name.
-	notif map[string]notifClient
+	notif map[string]NotifClient
+
+	// execJobByID index of JobExec by its ID, built during init.
+	execJobByID map[string]*JobExec
+
+	// httpJobByID index of JobHTTP by its ID, built during init.
+	httpJobByID map[string]*JobHTTP
+
+	// sqlJobByID index of JobSQL by its ID, built during init.
+	sqlJobByID map[string]*JobSQL
+
+	// jobsMtx protect the ExecJobs and its index from being changed by
+	// Karajo.AddJobExec or Karajo.RemoveJobExec while being read.
+	jobsMtx sync.RWMutex
+
+	// revision track the changes on the state of jobs, used to
+	// generate the ETag of environment API.
+	revision *envRevision
+
+	// Storage contains list of external storage setting.
+	// Currently, only storage with name "logs" is used, to store the
+	// job logs.
+	Storage map[string]EnvStorage `ini:"storage" json:"-"`
+
+	// Templates contains list of commands shared by JobExec, indexed
+	// by its name.
+	// See [EnvTemplate] for more information.
+	Templates map[string]*EnvTemplate `ini:"template" json:"templates,omitempty"`
+
+	// logStorage the storage for job logs.
+	// If its nil, the job logs is stored in the local directory.
+	logStorage Storage
+
+	// Peers contains list of other karajo instances, indexed by its
+	// name, whose jobs are displayed read-only in the WUI.
+	// Each peer is defined in section "[peer \"name\"]".
+	Peers map[string]*Peer `ini:"peer" json:"peers,omitempty"`
+
+	// Namespaces contains the group of jobs with their own users,
+	// secret, and directories, indexed by its name.
+	// Each namespace is defined in section "[namespace \"name\"]".
+	Namespaces map[string]*Namespace `ini:"namespace" json:"-"`
+
+	// AuthOIDC define the OpenID Connect provider for login into
+	// web user interface, in addition to Users.
+	// It is defined in section "[auth \"oidc\"]".
+	AuthOIDC *AuthOIDC `ini:"auth:oidc" json:"auth_oidc,omitempty"`
+
+	// RateLimits define the rate limit of HTTP request per path.
+	// Each rate limit is defined in section "[rate_limit \"<path>\"]".
+	RateLimits map[string]*RateLimit `ini:"rate_limit" json:"rate_limits,omitempty"`
+
+	// rateLimit the global rate limit created from RateLimit and
+	// RateLimitBurst.
+	rateLimit *RateLimit
+
+	// rateLimits the list of RateLimits sorted by the longest path.
+	rateLimits []*RateLimit
 
 	// Users list of user that can access web user interface.
 	// The list of user optionally loaded from
 	// $DirBase/etc/karajo/user.conf if the file exist.
 	Users map[string]*User `json:"-"`
 
+	// Admins list of user name that can run, pause, or change all of
+	// the jobs, regardless of the job Owner and AllowedUsers.
+	// This field is optional.
+	Admins []string `ini:"karajo::admin" json:"-"`
+
 	// Name of the service.
 	// The Name will be used for title on the web user interface, as log
 	// prefix, as file prefix on the jobs state, and as file prefix on
@@ -53,9 +130,33 @@ type Env struct {
 	Name string `ini:"karajo::name" json:"name"`
 	name string
 
+	// Include list of glob pattern of configuration files to be merged
+	// into this configuration, for example
+	// "/etc/karajo/conf.d/*.conf".
+	// The relative pattern is relative to the directory of file that
+	// include it.
+	// The included file can include other files.
+	// This option is only processed when loaded from file using
+	// [LoadEnv].
+	Include []string `ini:"karajo::include" json:"-"`
+
 	// Define the address for WUI, default to ":31937".
+	// If the port is 0, the server listen on random port and the
+	// actual address is written into file
+	// $DirBase/var/run/karajo/address.
 	ListenAddress string `ini:"karajo::listen_address" json:"listen_address"`
 
+	// ListenAddressAdmin define the address for WUI and all HTTP APIs.
+	// If its set, it replace the ListenAddress.
+	ListenAddressAdmin string `ini:"karajo::listen_address_admin" json:"-"`
+
+	// ListenAddressHooks define the additional address that serve only
+	// the JobExec hooks, "/karajo/api/job_exec/run/*".
+	// Use this option to expose the webhooks to the public network
+	// while keeping the WUI in private network.
+	// This field is optional.
+	ListenAddressHooks string `ini:"karajo::listen_address_hooks" json:"-"`
+
 	// DirBase define the base directory where configuration, job state,
 	// and job log stored.
 	// This field is optional, default to current directory.
@@ -66,12 +167,15 @@ type Env struct {
 	//	+-- /etc/karajo/ +-- karajo.conf
 	//	|                +-- job.d/
 	//	|                +-- job_http.d/
+	//	|                +-- job_sql.d/
 	//	|
 	//	+-- /var/lib/karajo/ +-- job/$JobExec.ID
 	//	|                    +-- job_http/$JobHTTP.ID
+	//	|                    +-- job_sql/$JobSQL.ID
 	//	|
 	//	+-- /var/log/karajo/ +-- job/$JobExec.ID
 	//	|                    +-- job_http/$JobHTTP.ID
+	//	|                    +-- job_sql/$JobSQL.ID
 	//	|
 	//	+-- /var/run/karajo/job_http/$JobHTTP.ID
 	//
@@ -93,15 +197,37 @@ type Env struct {
 	// Each JobHTTP configuration end with `.conf`.
 	dirConfigJobHTTPd string
 
+	// dirConfigJobSQLd the directory where JobSQL configuration
+	// loaded.
+	dirConfigJobSQLd string
+
 	dirLibJob     string
 	dirLibJobHTTP string
+	dirLibJobSQL  string
+
+	// dirLibArtifact define the directory where the JobExec artifacts
+	// are stored.
+	dirLibArtifact string
 
 	dirLogJob     string
 	dirLogJobHTTP string
+	dirLogJobSQL  string
+
+	// dirRun define the directory where karajo runtime files, like the
+	// address file, is stored.
+	dirRun string
 
 	// dirRunJobHTTP define the directory where JobHTTP state is stored.
 	dirRunJobHTTP string
 
+	// dirRunJobSecret define the directory where the rotated JobExec
+	// secret is stored.
+	dirRunJobSecret string
+
+	// dirRunJobDisabled define the directory where the disabled state
+	// of job, set through HTTP API, is stored.
+	dirRunJobDisabled string
+
 	file string
 
 	// DirPublic define a path to serve to public.
@@ -121,8 +247,18 @@ type Env struct {
 	// string.
 	// This field is optional, if its empty the new secret will be
 	// generated and printed to standard output on each run.
-	Secret  string `ini:"karajo::secret" json:"-"`
-	secretb []byte
+	// The secret can be read from external source using
+	// "<scheme>:<path>" format, see [Env.RegisterSecretProvider].
+	Secret string `ini:"karajo::secret" json:"-"`
+	secret *secretValue
+
+	// secretProviders contains the provider to fetch external secret,
+	// indexed by its scheme.
+	secretProviders map[string]SecretProvider
+
+	// jobMetrics the built-in job that collect internal statistics.
+	// It is set by [New] if MetricsInterval is set.
+	jobMetrics *JobExec
 
 	// HTTPTimeout define the global HTTP client timeout when executing
 	// each jobs.
@@ -131,23 +267,252 @@ type Env struct {
 	// format, for example, "30s" for 30 seconds, "1m" for 1 minute.
 	HTTPTimeout time.Duration `ini:"karajo::http_timeout" json:"http_timeout"`
 
+	// SignMaxAge define the maximum difference between the parameter
+	// "_karajo_epoch" in the signed request and the server time.
+	// The request with epoch older or newer than this value is rejected,
+	// and the same signed request, or webhook with the same nonce, is
+	// accepted only once within this window, to prevent the request
+	// being replayed.
+	// This field is optional, default to zero, where the epoch is not
+	// verified.
+	SignMaxAge time.Duration `ini:"karajo::sign_max_age" json:"sign_max_age,omitempty"`
+
+	// SummarySchedule define the schedule when the summary of all jobs
+	// execution in the last 24 hours is generated and send to
+	// SummaryNotif.
+	// The summary contains the number of runs, failures, and the slowest
+	// run for each job.
+	// See [JobBase.Schedule] for its format.
+	// This field is optional, if its empty no summary will be generated.
+	SummarySchedule string `ini:"karajo::summary_schedule" json:"summary_schedule,omitempty"`
+
+	// SummaryNotif define list of notification where the summary will
+	// be send.
+	SummaryNotif []string `ini:"karajo::summary_notif" json:"summary_notif,omitempty"`
+
+	// MetricsInterval define the interval when the internal statistics
+	// of karajo, like number of goroutines, jobs running and queued,
+	// pending notifications, and memfs cache size, is written into the
+	// log of job named "karajo metrics".
+	// The minimum value is one minute.
+	// This field is optional, if its zero no metrics will be collected.
+	MetricsInterval time.Duration `ini:"karajo::metrics_interval" json:"metrics_interval,omitempty"`
+
+	// NotifQueueSize define the maximum number of finished job logs
+	// waiting to be processed by the notification worker.
+	// This field is optional, default to 64.
+	NotifQueueSize int `ini:"karajo::notif_queue_size" json:"notif_queue_size,omitempty"`
+
+	// NotifQueueTimeout define how long the job wait for the free slot
+	// in notification queue when its full, before the notification is
+	// dropped.
+	// This field is optional, default to 0, the notification is dropped
+	// immediately when the queue is full.
+	NotifQueueTimeout time.Duration `ini:"karajo::notif_queue_timeout" json:"notif_queue_timeout,omitempty"`
+
+	// notifDropped count the job logs with notification that are
+	// dropped because the notification queue is full.
+	notifDropped atomic.Int64
+
 	// MaxJobRunning define the maximum job running at the same time.
 	// This field is optional default to 1.
 	MaxJobRunning int `ini:"karajo::max_job_running" json:"max_job_running"`
 
-	// IsDevelopment if its true, the files in DirPublic will be loaded
-	// directly from disk instead from embedded memfs.
+	// Strict define whether duplicate job should be treated as an
+	// error.
+	// A job is duplicate if its name is defined more than once, or its
+	// name normalized to the same ID as another job with the same type.
+	// If its set to false, the duplicate job is logged as warning and
+	// the last loaded job will replace the previous one.
+	// This field is optional, default to true.
+	Strict *bool `ini:"karajo::strict" json:"strict,omitempty"`
+
+	// RateLimit define the global maximum number of HTTP API requests
+	// per client IP address, in the format "<number>/<unit>", for
+	// example "10/s".
+	// See [RateLimit.Limit] for its format.
+	// This field is optional, if its empty there is no global rate
+	// limit.
+	RateLimit string `ini:"karajo::rate_limit" json:"rate_limit,omitempty"`
+
+	// RateLimitBurst define the maximum number of requests that can be
+	// made at once for global rate limit.
+	// This field is optional, default to the number in RateLimit.
+	RateLimitBurst int `ini:"karajo::rate_limit_burst" json:"rate_limit_burst,omitempty"`
+
+	// MaxRequestBody define the maximum size of HTTP request body, in
+	// bytes.
+	// Request with larger body is rejected with status 413 before its
+	// read into memory.
+	// This field is optional, default to 4 MiB.
+	MaxRequestBody int64 `ini:"karajo::max_request_body" json:"max_request_body"`
+
+	// AuthMaxAttempts define the maximum number of consecutive failed
+	// login from the same IP address or for the same user name before
+	// the login is locked for AuthLockoutDuration.
+	// This field is optional, default to 5.
+	AuthMaxAttempts int `ini:"karajo::auth_max_attempts" json:"auth_max_attempts"`
+
+	// AuthLockoutDuration define how long the login is locked after
+	// reaching AuthMaxAttempts.
+	// This field is optional, default to 15 minutes.
+	AuthLockoutDuration time.Duration `ini:"karajo::auth_lockout_duration" json:"auth_lockout_duration"`
+
+	// TrustedProxy define the IP address or network, in CIDR notation,
+	// of reverse proxy in front of karajo.
+	// For request from TrustedProxy, the client IP address, that is
+	// used to limit the failed login and the rate of requests, is read
+	// from the X-Forwarded-For header.
+	// This option can be defined multiple times.
+	TrustedProxy   []string `ini:"karajo::trusted_proxy" json:"-"`
+	trustedProxies []netip.Prefix
+
+	// SessionTTL define how long the user session is valid after login.
+	// The sessions are stored in $DirBase/var/run/karajo/sessions, so
+	// user does not need to login again after karajo restarted.
+	// This field is optional, default to 24 hours.
+	SessionTTL time.Duration `ini:"karajo::session_ttl" json:"session_ttl"`
+
+	// BasePath define the URL path prefix where karajo is served, for
+	// example "/ops/scheduler", when karajo is behind reverse proxy.
+	// All WUI and HTTP API paths, including the session cookies, are
+	// prefixed with BasePath.
+	// This field is optional, default to empty, where the WUI is
+	// served at "/karajo/".
+	BasePath string `ini:"karajo::base_path" json:"base_path,omitempty"`
+
+	// WUITheme define the default theme for the web user interface.
+	// The valid values are "auto", "light", or "dark".
+	// The "auto" theme follow the browser or operating system setting.
+	// User can override the theme from the WUI, which is stored in the
+	// browser.
+	// This field is optional, default to "auto".
+	WUITheme string `ini:"karajo::wui_theme" json:"wui_theme"`
+
+	// HALease enable the leader election between karajo instances that
+	// share the same DirBase, for high availability.
+	// Only the leader run the scheduled jobs, while the jobs triggered
+	// by HTTP request run in any instance.
+	// The value define how long the leader hold the lease without
+	// renewing it, before one of the follower take over.
+	// This field is optional, default to zero, where the leader
+	// election is disabled.
+	HALease time.Duration `ini:"karajo::ha_lease" json:"ha_lease,omitempty"`
+
+	// HARole contains the role of this instance in leader election,
+	// either "leader" or "follower".
+	// It is empty if the leader election is disabled.
+	HARole *leaderElection `ini:"-" json:"ha_role,omitempty"`
+
+	// ReadOnly if its true, all of the HTTP APIs that change the state,
+	// including triggering the job, are rejected and the jobs are not
+	// executed, while the status pages and logs are still served.
+	// For example, when running as reporting replica or during
+	// incident freeze.
+	// The mode can be toggled at runtime using the HTTP API.
+	// This field is optional, default to false.
+	ReadOnly bool `ini:"karajo::read_only" json:"-"`
+
+	// ReadOnlyMode contains the current read-only mode, initialized
+	// from ReadOnly.
+	ReadOnlyMode *readOnlyMode `ini:"-" json:"read_only,omitempty"`
+
+	// Mutexes contains the state of each mutex defined in the job
+	// MutexName, indexed by its name.
+	Mutexes map[string]*jobMutex `ini:"-" json:"mutexes,omitempty"`
+
+	// Middlewares define the JobMiddleware that called around the
+	// execution of all JobExec and JobHTTP.
+	// This field is optional, it is only used if Env created through
+	// code.
+	Middlewares []JobMiddleware `ini:"-" json:"-"`
+
+	// LogMaxAge define the default maximum age of job logs to keep in
+	// storage, for job that does not set its own.
+	// This field is optional, default to zero, where the logs is not
+	// removed by age.
+	LogMaxAge time.Duration `ini:"karajo::log_max_age" json:"log_max_age,omitempty"`
+
+	// LogMaxTotalSize define the default maximum total size of logs,
+	// in bytes, for each job that does not set its own.
+	// This field is optional, default to zero, where the logs is not
+	// removed by size.
+	LogMaxTotalSize int64 `ini:"karajo::log_max_total_size" json:"log_max_total_size,omitempty"`
+
+	// DiskUsageInterval define the interval when the disk usage of
+	// logs, lib, and artifacts directories under DirBase is measured,
+	// by the job named "karajo disk usage".
+	// The minimum value is one minute.
+	// This field is optional, if its zero the disk usage is not
+	// measured.
+	DiskUsageInterval time.Duration `ini:"karajo::disk_usage_interval" json:"disk_usage_interval,omitempty"`
+
+	// DiskUsageMax define the threshold of total disk usage, in bytes.
+	// When the total usage cross this value, the job "karajo disk
+	// usage" is failed and notification is send to DiskUsageNotif.
+	// This field is optional, default to zero, no threshold.
+	DiskUsageMax int64 `ini:"karajo::disk_usage_max" json:"disk_usage_max,omitempty"`
+
+	// DiskUsageNotif define list of notification where the alert will
+	// be send when the total disk usage cross the DiskUsageMax.
+	DiskUsageNotif []string `ini:"karajo::disk_usage_notif" json:"disk_usage_notif,omitempty"`
+
+	// DiskUsage contains the last disk usage report, initialized only
+	// if DiskUsageInterval is set.
+	DiskUsage *diskUsage `ini:"-" json:"disk_usage,omitempty"`
+
+	// QueueBackend define the queue for JobExec triggered by HTTP
+	// request.
+	// Supported backend are,
+	//
+	//   - memory: each JobExec have its own queue in memory.
+	//   - redis: the request is pushed to the Redis list, shared
+	//     between karajo instances, and pulled by one of them.
+	//     The request is removed from the queue after the job finished,
+	//     so its run at least once.
+	//
+	// This field is optional, default to "memory".
+	QueueBackend string `ini:"karajo::queue_backend" json:"queue_backend,omitempty"`
+
+	// QueueAddress define the address of queue backend.
+	// For redis, the format is "redis://[:password@]host[:port][/db]".
+	QueueAddress string `ini:"karajo::queue_address" json:"-"`
+	jobQueue     jobQueue
+
+	// jobRunQueue limit the number of JobExec running at the same time
+	// to MaxJobRunning.
+	jobRunQueue *jobRunQueue
+
+	// agents the hub that dispatch the JobExec with Target to the
+	// agent.
+	agents *agentHub
+
+	// nonces contains the recently authorized signed requests, to
+	// reject the replayed one.
+	// It is nil if SignMaxAge is not set.
+	nonces *nonceCache
+
+	// IsDevelopment if its true, the files in DirPublic and the WUI
+	// files in "_www" will be loaded directly from disk instead from
+	// embedded memfs, with the browser cache disabled.
+	// Each WUI page listen to the SSE "/karajo/api/dev/reload" and
+	// reload itself when the files in "_www" changes or the server
+	// restarted.
 	IsDevelopment bool `ini:"karajo::is_development" json:"is_development"`
 }
 
 // LoadEnv load the configuration from the ini file format.
+// If the file name end with ".yaml", ".yml", or ".toml" it will be loaded
+// using YAML or TOML format.
+// The files in the "include" option are merged into the configuration,
+// see [Env.Include].
 func LoadEnv(file string) (env *Env, err error) {
 	var (
 		logp = `LoadEnv`
 		cfg  *ini.Ini
 	)
 
-	cfg, err = ini.Open(file)
+	cfg, err = openConfigInclude(file)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
@@ -162,6 +527,8 @@ func LoadEnv(file string) (env *Env, err error) {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	env.loadParams(cfg)
+
 	return env, nil
 }
 
@@ -173,6 +540,7 @@ func NewEnv() (env *Env) {
 		Name:          defEnvName,
 		ExecJobs:      make(map[string]*JobExec),
 		HTTPJobs:      make(map[string]*JobHTTP),
+		SQLJobs:       make(map[string]*JobSQL),
 		Users:         make(map[string]*User),
 		ListenAddress: defListenAddress,
 		DirBase:       defDirBase,
@@ -193,16 +561,31 @@ func ParseEnv(content []byte) (env *Env, err error) {
 		Version: Version,
 	}
 
-	err = ini.Unmarshal(content, env)
+	var cfg *ini.Ini
+
+	cfg, err = ini.Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = cfg.Unmarshal(env)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	env.loadParams(cfg)
+
 	return env, nil
 }
 
 // jobExec get the JobExec by its ID.
 func (env *Env) jobExec(id string) (job *JobExec) {
+	env.jobsMtx.RLock()
+	defer env.jobsMtx.RUnlock()
+
+	if env.execJobByID != nil {
+		return env.execJobByID[id]
+	}
 	for _, job = range env.ExecJobs {
 		if job.ID == id {
 			return job
@@ -213,6 +596,9 @@ func (env *Env) jobExec(id string) (job *JobExec) {
 
 // jobHTTP get the registered JobHTTP by its ID.
 func (env *Env) jobHTTP(id string) (job *JobHTTP) {
+	if env.httpJobByID != nil {
+		return env.httpJobByID[id]
+	}
 	for _, job = range env.HTTPJobs {
 		if job.ID == id {
 			return job
@@ -221,12 +607,26 @@ func (env *Env) jobHTTP(id string) (job *JobHTTP) {
 	return nil
 }
 
+// jobSQL get the registered JobSQL by its ID.
+func (env *Env) jobSQL(id string) (job *JobSQL) {
+	if env.sqlJobByID != nil {
+		return env.sqlJobByID[id]
+	}
+	for _, job = range env.SQLJobs {
+		if job.ID == id {
+			return job
+		}
+	}
+	return nil
+}
+
 func (env *Env) init() (err error) {
 	var (
 		logp = `init`
 
 		job     *JobExec
 		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
 		name    string
 	)
 
@@ -235,6 +635,9 @@ func (env *Env) init() (err error) {
 	}
 	env.name = libhtml.NormalizeForID(env.Name)
 
+	if len(env.ListenAddressAdmin) != 0 {
+		env.ListenAddress = env.ListenAddressAdmin
+	}
 	if len(env.ListenAddress) == 0 {
 		env.ListenAddress = defListenAddress
 	}
@@ -244,14 +647,44 @@ func (env *Env) init() (err error) {
 	if env.MaxJobRunning <= 0 {
 		env.MaxJobRunning = defMaxJobRunning
 	}
+	if env.NotifQueueSize <= 0 {
+		env.NotifQueueSize = defNotifQueueSize
+	}
+	if env.NotifQueueTimeout < 0 {
+		env.NotifQueueTimeout = 0
+	}
+	if env.MaxRequestBody <= 0 {
+		env.MaxRequestBody = defMaxRequestBody
+	}
+	if env.AuthMaxAttempts <= 0 {
+		env.AuthMaxAttempts = defAuthMaxAttempts
+	}
+	if env.AuthLockoutDuration <= 0 {
+		env.AuthLockoutDuration = defAuthLockoutDuration
+	}
+	if env.SessionTTL <= 0 {
+		env.SessionTTL = defSessionTTL
+	}
+
+	err = env.initBasePath()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = env.initWUITheme()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
 
+	env.Secret = expandEnv(env.Secret)
 	if len(env.Secret) == 0 {
 		var secret = ascii.Random([]byte(ascii.LettersNumber), 32)
 		env.Secret = string(secret)
 
 		mlog.Outf(`!!! WARNING: Your secret is empty and has been generated: %s`, secret)
 	}
-	env.secretb = []byte(env.Secret)
+	env.initSecretProviders()
+	env.secret = env.newSecretValue(env.Secret)
 
 	err = env.initDirs()
 	if err != nil {
@@ -263,28 +696,127 @@ func (env *Env) init() (err error) {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	err = env.initStorage()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
 	err = env.initUsers()
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	if env.AuthOIDC != nil {
+		err = env.AuthOIDC.init(env)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	err = env.initPeers()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = env.initNamespaces()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = env.initTrustedProxy()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = env.initRateLimits()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	env.revision = newEnvRevision()
+
+	env.HARole = nil
+	if env.HALease > 0 {
+		if env.HALease < defHALeaseMin {
+			return fmt.Errorf(`%s: ha_lease must be at least %s`, logp, defHALeaseMin)
+		}
+		env.HARole = newLeaderElection(env.dirRun, env.HALease)
+		env.HARole.revision = env.revision
+	}
+
+	env.ReadOnlyMode = newReadOnlyMode(env.ReadOnly, env.revision)
+
+	env.agents = newAgentHub()
+
+	env.nonces = nil
+	if env.SignMaxAge > 0 {
+		// The request epoch is valid within SignMaxAge before and
+		// after the server time, so the same request can be
+		// accepted for twice of SignMaxAge.
+		env.nonces = newNonceCache(2 * env.SignMaxAge)
+	}
+	env.jobRunQueue = newJobRunQueue(env.MaxJobRunning)
+	env.Mutexes = nil
+
+	env.QueueBackend = strings.ToLower(strings.TrimSpace(env.QueueBackend))
+	env.jobQueue, err = newJobQueue(env.QueueBackend, expandEnv(env.QueueAddress))
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
 	err = env.loadJobd()
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	for name, job = range env.ExecJobs {
-		err = job.init(env, name)
+	err = env.loadJobHTTPd()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = env.loadJobSQLd()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	if len(env.SummarySchedule) != 0 {
+		err = env.addBuiltinJob(defJobSummaryName, newJobSummary(env))
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	if env.jobMetrics != nil {
+		err = env.addBuiltinJob(defJobMetricsName, env.jobMetrics)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	env.DiskUsage = nil
+	if env.DiskUsageInterval > 0 {
+		env.DiskUsage = newDiskUsage(env.revision)
+		err = env.addBuiltinJob(defJobDiskUsageName, newJobDiskUsage(env))
 		if err != nil {
 			return fmt.Errorf(`%s: %w`, logp, err)
 		}
 	}
 
-	err = env.loadJobHTTPd()
+	err = env.checkJobIDs()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = env.initTemplates()
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	for name, job = range env.ExecJobs {
+		err = job.init(env, name)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
 	for name, jobHTTP = range env.HTTPJobs {
 		err = jobHTTP.init(env, name)
 		if err != nil {
@@ -292,15 +824,46 @@ func (env *Env) init() (err error) {
 		}
 	}
 
+	for name, jobSQL = range env.SQLJobs {
+		err = jobSQL.init(env, name)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	env.indexJobs()
+
 	return nil
 }
 
-// initDirs create all job and log directories.
-func (env *Env) initDirs() (err error) {
+// indexJobs build the index of JobExec, JobHTTP, and JobSQL by its ID.
+// It must be called after all jobs has been initialized, and each time
+// the ExecJobs, HTTPJobs, or SQLJobs changes.
+func (env *Env) indexJobs() {
 	var (
-		logp = `initDirs`
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
 	)
 
+	env.execJobByID = make(map[string]*JobExec, len(env.ExecJobs))
+	for _, job = range env.ExecJobs {
+		env.execJobByID[job.ID] = job
+	}
+
+	env.httpJobByID = make(map[string]*JobHTTP, len(env.HTTPJobs))
+	for _, jobHTTP = range env.HTTPJobs {
+		env.httpJobByID[jobHTTP.ID] = jobHTTP
+	}
+
+	env.sqlJobByID = make(map[string]*JobSQL, len(env.SQLJobs))
+	for _, jobSQL = range env.SQLJobs {
+		env.sqlJobByID[jobSQL.ID] = jobSQL
+	}
+}
+
+// initDirsConfig set the configuration directories based on DirBase.
+func (env *Env) initDirsConfig() {
 	if len(env.DirBase) == 0 {
 		env.DirBase = defDirBase
 	}
@@ -308,6 +871,16 @@ func (env *Env) initDirs() (err error) {
 	env.dirConfig = filepath.Join(env.DirBase, `etc`, defEnvName)
 	env.dirConfigJobd = filepath.Join(env.DirBase, `etc`, defEnvName, `job.d`)
 	env.dirConfigJobHTTPd = filepath.Join(env.DirBase, `etc`, defEnvName, `job_http.d`)
+	env.dirConfigJobSQLd = filepath.Join(env.DirBase, `etc`, defEnvName, `job_sql.d`)
+}
+
+// initDirs create all job and log directories.
+func (env *Env) initDirs() (err error) {
+	var (
+		logp = `initDirs`
+	)
+
+	env.initDirsConfig()
 
 	env.dirLibJob = filepath.Join(env.DirBase, `var`, `lib`, defEnvName, `job`)
 	err = os.MkdirAll(env.dirLibJob, 0700)
@@ -321,6 +894,18 @@ func (env *Env) initDirs() (err error) {
 		return fmt.Errorf(`%s: %s: %w`, logp, env.dirLibJobHTTP, err)
 	}
 
+	env.dirLibJobSQL = filepath.Join(env.DirBase, `var`, `lib`, defEnvName, `job_sql`)
+	err = os.MkdirAll(env.dirLibJobSQL, 0700)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, env.dirLibJobSQL, err)
+	}
+
+	env.dirLibArtifact = filepath.Join(env.DirBase, `var`, `lib`, defEnvName, `artifacts`)
+	err = os.MkdirAll(env.dirLibArtifact, 0700)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, env.dirLibArtifact, err)
+	}
+
 	env.dirLogJob = filepath.Join(env.DirBase, `var`, `log`, defEnvName, `job`)
 	err = os.MkdirAll(env.dirLogJob, 0700)
 	if err != nil {
@@ -333,12 +918,31 @@ func (env *Env) initDirs() (err error) {
 		return fmt.Errorf(`%s: %s: %w`, logp, env.dirLogJobHTTP, err)
 	}
 
-	env.dirRunJobHTTP = filepath.Join(env.DirBase, `var`, `run`, defEnvName, `job_http`)
+	env.dirLogJobSQL = filepath.Join(env.DirBase, `var`, `log`, defEnvName, `job_sql`)
+	err = os.MkdirAll(env.dirLogJobSQL, 0700)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, env.dirLogJobSQL, err)
+	}
+
+	env.dirRun = filepath.Join(env.DirBase, `var`, `run`, defEnvName)
+	env.dirRunJobHTTP = filepath.Join(env.dirRun, `job_http`)
 	err = os.MkdirAll(env.dirRunJobHTTP, 0700)
 	if err != nil {
 		return fmt.Errorf(`%s: %s: %w`, logp, env.dirRunJobHTTP, err)
 	}
 
+	env.dirRunJobSecret = filepath.Join(env.dirRun, `job_secret`)
+	err = os.MkdirAll(env.dirRunJobSecret, 0700)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, env.dirRunJobSecret, err)
+	}
+
+	env.dirRunJobDisabled = filepath.Join(env.dirRun, `job_disabled`)
+	err = os.MkdirAll(env.dirRunJobDisabled, 0700)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, env.dirRunJobDisabled, err)
+	}
+
 	return nil
 }
 
@@ -349,9 +953,9 @@ func (env *Env) initNotifs() (err error) {
 
 		name        string
 		envNotif    EnvNotif
-		clientNotif notifClient
+		clientNotif NotifClient
 	)
-	env.notif = make(map[string]notifClient)
+	env.notif = make(map[string]NotifClient)
 	for name, envNotif = range env.Notif {
 		envNotif.Name = name
 
@@ -366,6 +970,48 @@ func (env *Env) initNotifs() (err error) {
 	return nil
 }
 
+// initStorage initialize the external storage.
+func (env *Env) initStorage() (err error) {
+	var (
+		logp = `initStorage`
+
+		name       string
+		envStorage EnvStorage
+	)
+	env.logStorage = nil
+	for name, envStorage = range env.Storage {
+		if name != storageNameLogs {
+			return fmt.Errorf(`%s: unknown storage %q`, logp, name)
+		}
+
+		envStorage.Name = name
+		envStorage.init()
+
+		env.logStorage, err = envStorage.createLogStorage(env.HTTPTimeout)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return nil
+}
+
+// initPeers initialize the client for each peer.
+func (env *Env) initPeers() (err error) {
+	var (
+		logp = `initPeers`
+
+		name string
+		peer *Peer
+	)
+	for name, peer = range env.Peers {
+		err = peer.init(name)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return nil
+}
+
 // initUsers load users for authentication from $DirBase/etc/karajo/user.conf.
 func (env *Env) initUsers() (err error) {
 	var (
@@ -390,6 +1036,12 @@ func (env *Env) initUsers() (err error) {
 	for name, u = range listUser {
 		env.Users[name] = u
 	}
+	for _, u = range env.Users {
+		err = u.initTOTP()
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
 
 	return nil
 }
@@ -408,7 +1060,7 @@ func (env *Env) loadConfigJob(conf string) (jobs map[string]*JobExec, err error)
 		cfg *ini.Ini
 	)
 
-	cfg, err = ini.Open(conf)
+	cfg, err = openConfig(conf)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %s: %w`, logp, conf, err)
 	}
@@ -423,6 +1075,10 @@ func (env *Env) loadConfigJob(conf string) (jobs map[string]*JobExec, err error)
 	jobs = jobc.ExecJobs
 	jobc.ExecJobs = nil
 
+	loadJobParams(cfg, jobs)
+	loadJobSteps(cfg, jobs)
+	loadJobMatrix(cfg, jobs)
+
 	return jobs, nil
 }
 
@@ -438,7 +1094,7 @@ func (env *Env) loadConfigJobHTTP(conf string) (httpJobs map[string]*JobHTTP, er
 		cfg *ini.Ini
 	)
 
-	cfg, err = ini.Open(conf)
+	cfg, err = openConfig(conf)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %s: %w`, logp, conf, err)
 	}
@@ -502,7 +1158,7 @@ func (env *Env) loadJobd() (err error) {
 			// Exclude hidden file.
 			continue
 		}
-		if !strings.HasSuffix(name, `.conf`) {
+		if !isConfigFile(name) {
 			continue
 		}
 
@@ -514,6 +1170,16 @@ func (env *Env) loadJobd() (err error) {
 		}
 
 		for name, job = range jobs {
+			job.source = jobConf
+
+			var prevJob = env.ExecJobs[name]
+			if prevJob != nil {
+				err = env.duplicateJob(jobKindExec, name,
+					env.jobSource(&prevJob.JobBase), jobConf)
+				if err != nil {
+					return fmt.Errorf(`%s: %w`, logp, err)
+				}
+			}
 			env.ExecJobs[name] = job
 		}
 	}
@@ -566,7 +1232,7 @@ func (env *Env) loadJobHTTPd() (err error) {
 			// Exclude hidden file.
 			continue
 		}
-		if !strings.HasSuffix(name, `.conf`) {
+		if !isConfigFile(name) {
 			continue
 		}
 
@@ -578,12 +1244,364 @@ func (env *Env) loadJobHTTPd() (err error) {
 		}
 
 		for name, httpJob = range httpJobs {
+			httpJob.source = fileConf
+
+			var prevJob = env.HTTPJobs[name]
+			if prevJob != nil {
+				err = env.duplicateJob(jobKindHTTP, name,
+					env.jobSource(&prevJob.JobBase), fileConf)
+				if err != nil {
+					return fmt.Errorf(`%s: %w`, logp, err)
+				}
+			}
 			env.HTTPJobs[name] = httpJob
 		}
 	}
 	return nil
 }
 
+// loadConfigJobSQL load JobSQL configuration from file.
+func (env *Env) loadConfigJobSQL(conf string) (sqlJobs map[string]*JobSQL, err error) {
+	type jobContainer struct {
+		SQLJobs map[string]*JobSQL `ini:"job.sql"`
+	}
+
+	var (
+		logp = `loadConfigJobSQL`
+
+		cfg *ini.Ini
+	)
+
+	cfg, err = openConfig(conf)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, conf, err)
+	}
+
+	var jobc = jobContainer{}
+
+	err = cfg.Unmarshal(&jobc)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, conf, err)
+	}
+
+	sqlJobs = jobc.SQLJobs
+	jobc.SQLJobs = nil
+
+	return sqlJobs, nil
+}
+
+// loadJobSQLd load all JobSQL configurations from a directory.
+func (env *Env) loadJobSQLd() (err error) {
+	var (
+		logp = `loadJobSQLd`
+
+		jobd     *os.File
+		listde   []os.DirEntry
+		de       os.DirEntry
+		fm       os.FileMode
+		name     string
+		fileConf string
+		sqlJobs  map[string]*JobSQL
+		sqlJob   *JobSQL
+	)
+
+	jobd, err = os.Open(env.dirConfigJobSQLd)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	listde, err = jobd.ReadDir(0)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	if env.SQLJobs == nil {
+		env.SQLJobs = make(map[string]*JobSQL)
+	}
+
+	for _, de = range listde {
+		if de.IsDir() {
+			continue
+		}
+		fm = de.Type()
+		if !fm.IsRegular() {
+			continue
+		}
+		name = de.Name()
+
+		if name[0] == '.' {
+			// Exclude hidden file.
+			continue
+		}
+		if !isConfigFile(name) {
+			continue
+		}
+
+		fileConf = filepath.Join(env.dirConfigJobSQLd, name)
+
+		sqlJobs, err = env.loadConfigJobSQL(fileConf)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+
+		for name, sqlJob = range sqlJobs {
+			sqlJob.source = fileConf
+
+			var prevJob = env.SQLJobs[name]
+			if prevJob != nil {
+				err = env.duplicateJob(jobKindSQL, name,
+					env.jobSource(&prevJob.JobBase), fileConf)
+				if err != nil {
+					return fmt.Errorf(`%s: %w`, logp, err)
+				}
+			}
+			env.SQLJobs[name] = sqlJob
+		}
+	}
+	return nil
+}
+
+// FetchSecret return the value of Secret.
+// If the Secret reference an external secret, it will be fetched from the
+// provider.
+func (env *Env) FetchSecret() (secret string, err error) {
+	env.initSecretProviders()
+
+	var (
+		sv = newSecretValue(expandEnv(env.Secret), env.secretProviders)
+		b  []byte
+	)
+	b, err = sv.get()
+	if err != nil {
+		return ``, err
+	}
+	return string(b), nil
+}
+
+// ServerAddress return the address where the karajo server listen.
+// If the server is running, the address is read from file
+// $DirBase/var/run/karajo/address, otherwise it return the ListenAddress.
+func (env *Env) ServerAddress() string {
+	var dirBase = env.DirBase
+	if len(dirBase) == 0 {
+		dirBase = defDirBase
+	}
+
+	var (
+		fileAddress = filepath.Join(dirBase, `var`, `run`, defEnvName, defFileAddress)
+
+		addr []byte
+		err  error
+	)
+	addr, err = os.ReadFile(fileAddress)
+	if err == nil {
+		addr = bytes.TrimSpace(addr)
+		if len(addr) != 0 {
+			return string(addr)
+		}
+	}
+	if len(env.ListenAddressAdmin) != 0 {
+		return env.ListenAddressAdmin
+	}
+	if len(env.ListenAddress) == 0 {
+		return defListenAddress
+	}
+	return env.ListenAddress
+}
+
+// RegisterSecretProvider register the provider to fetch the secret that
+// start with "<scheme>:".
+// The built-in providers are "file", that read the secret from file, and
+// "vault", that read the secret from HashiCorp Vault using the address and
+// token from system environment VAULT_ADDR and VAULT_TOKEN.
+// Registering provider with the same scheme replace the existing one.
+//
+// The provider must be registered before the Env initialized.
+func (env *Env) RegisterSecretProvider(scheme string, provider SecretProvider) {
+	if env.secretProviders == nil {
+		env.secretProviders = make(map[string]SecretProvider)
+	}
+	env.secretProviders[scheme] = provider
+}
+
+// initSecretProviders register the built-in secret providers, unless its
+// has been replaced by user.
+func (env *Env) initSecretProviders() {
+	if env.secretProviders[secretSchemeFile] == nil {
+		env.RegisterSecretProvider(secretSchemeFile, secretProviderFile{})
+	}
+	if env.secretProviders[secretSchemeVault] == nil {
+		env.RegisterSecretProvider(secretSchemeVault, secretProviderVault{})
+	}
+}
+
+// newSecretValue create new secretValue using the registered providers.
+// If the secret is external, it will be fetched immediately.
+// If fetching the secret failed, it will be logged and fetched again on the
+// first time the secret is used.
+func (env *Env) newSecretValue(value string) (sv *secretValue) {
+	sv = newSecretValue(value, env.secretProviders)
+	if !sv.isExternal() {
+		return sv
+	}
+
+	var err error
+	_, err = sv.get()
+	if err != nil {
+		mlog.Errf(`!!! WARNING: %s, it will be fetched later`, err)
+	}
+	return sv
+}
+
+// checkJobIDs check for jobs with different names but normalized into the
+// same ID.
+func (env *Env) checkJobIDs() (err error) {
+	var (
+		logp     = `checkJobIDs`
+		execJobs = make(map[string]*JobBase, len(env.ExecJobs))
+		httpJobs = make(map[string]*JobBase, len(env.HTTPJobs))
+		sqlJobs  = make(map[string]*JobBase, len(env.SQLJobs))
+
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+
+	for name, job = range env.ExecJobs {
+		execJobs[name] = &job.JobBase
+	}
+	err = env.checkDuplicateID(jobKindExec, execJobs)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	for name, jobHTTP = range env.HTTPJobs {
+		httpJobs[name] = &jobHTTP.JobBase
+	}
+	err = env.checkDuplicateID(jobKindHTTP, httpJobs)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	for name, jobSQL = range env.SQLJobs {
+		sqlJobs[name] = &jobSQL.JobBase
+	}
+	err = env.checkDuplicateID(jobKindSQL, sqlJobs)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return nil
+}
+
+// addBuiltinJob register the built-in JobExec with reserved name.
+// It will return an error if user define a job with the same name or
+// with name that normalized into the same ID, even if Strict is false.
+func (env *Env) addBuiltinJob(name string, job *JobExec) (err error) {
+	var (
+		id = libhtml.NormalizeForID(name)
+
+		userName string
+		userJob  *JobExec
+	)
+	for userName, userJob = range env.ExecJobs {
+		if libhtml.NormalizeForID(userName) != id {
+			continue
+		}
+		return fmt.Errorf(`job %q in %s: [job %q] is reserved for built-in job`,
+			userName, env.jobSource(&userJob.JobBase), name)
+	}
+
+	if env.ExecJobs == nil {
+		env.ExecJobs = make(map[string]*JobExec)
+	}
+	env.ExecJobs[name] = job
+	return nil
+}
+
+// checkDuplicateID check if two or more jobs, indexed by its name, have the
+// same ID.
+func (env *Env) checkDuplicateID(kind jobKind, jobs map[string]*JobBase) (err error) {
+	var (
+		listName = make(map[string][]string)
+
+		name  string
+		id    string
+		names []string
+	)
+	for name = range jobs {
+		id = libhtml.NormalizeForID(name)
+		listName[id] = append(listName[id], name)
+	}
+
+	var listID = make([]string, 0, len(listName))
+	for id = range listName {
+		listID = append(listID, id)
+	}
+	sort.Strings(listID)
+
+	var listDup []string
+	for _, id = range listID {
+		names = listName[id]
+		if len(names) == 1 {
+			continue
+		}
+		sort.Strings(names)
+
+		var sources = make([]string, 0, len(names))
+		for _, name = range names {
+			sources = append(sources, fmt.Sprintf(`%s: [%s %q]`,
+				env.jobSource(jobs[name]), kind.section(), name))
+		}
+		listDup = append(listDup, fmt.Sprintf(`%s ID %q defined in %s`,
+			kind, id, strings.Join(sources, `, `)))
+	}
+	if len(listDup) == 0 {
+		return nil
+	}
+
+	var msg = strings.Join(listDup, `; `)
+	if env.isStrict() {
+		return fmt.Errorf(`duplicate %s`, msg)
+	}
+	mlog.Errf(`!!! WARNING: duplicate %s`, msg)
+	return nil
+}
+
+// duplicateJob return an error if env is strict, otherwise log the
+// duplicate job name as warning.
+func (env *Env) duplicateJob(kind jobKind, name, prevSource, source string) (err error) {
+	var msg = fmt.Sprintf(`%s %q defined in %s: [%s %q] and %s: [%s %q]`,
+		kind, name, prevSource, kind.section(), name, source,
+		kind.section(), name)
+	if env.isStrict() {
+		return fmt.Errorf(`duplicate %s`, msg)
+	}
+	mlog.Errf(`!!! WARNING: duplicate %s, the last one will be used`, msg)
+	return nil
+}
+
+// isStrict return true if Strict is not set or set to true.
+func (env *Env) isStrict() bool {
+	return env.Strict == nil || *env.Strict
+}
+
+// jobSource return the file where the job is defined.
+// If the job is not loaded from job.d or job_http.d directory, it will
+// return the main configuration file.
+func (env *Env) jobSource(job *JobBase) string {
+	if len(job.source) != 0 {
+		return job.source
+	}
+	if len(env.file) != 0 {
+		return env.file
+	}
+	return `(code)`
+}
+
 func (env *Env) lockAllJob() {
 	var job *JobExec
 	for _, job = range env.ExecJobs {
@@ -594,6 +1612,11 @@ func (env *Env) lockAllJob() {
 	for _, jobHTTP = range env.HTTPJobs {
 		jobHTTP.Lock()
 	}
+
+	var jobSQL *JobSQL
+	for _, jobSQL = range env.SQLJobs {
+		jobSQL.Lock()
+	}
 }
 
 func (env *Env) unlockAllJob() {
@@ -606,4 +1629,96 @@ func (env *Env) unlockAllJob() {
 	for _, jobHTTP = range env.HTTPJobs {
 		jobHTTP.Unlock()
 	}
+
+	var jobSQL *JobSQL
+	for _, jobSQL = range env.SQLJobs {
+		jobSQL.Unlock()
+	}
+}
+
+// envNames return the list of unique NAME from each "${NAME}" in value.
+func envNames(value string) (names []string) {
+	var (
+		name  string
+		begin int
+		end   int
+	)
+	for {
+		begin = strings.Index(value, `${`)
+		if begin < 0 {
+			break
+		}
+		end = strings.IndexByte(value[begin:], '}')
+		if end < 0 {
+			break
+		}
+		end += begin
+
+		name = value[begin+2 : end]
+		if len(name) != 0 && !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+		value = value[end+1:]
+	}
+	return names
+}
+
+// redactEnv replace the value of each system environment variable in names
+// inside raw with "${NAME}".
+func redactEnv(raw []byte, names []string) []byte {
+	var (
+		name string
+		val  string
+		ok   bool
+	)
+	for _, name = range names {
+		val, ok = os.LookupEnv(name)
+		if !ok || len(val) == 0 {
+			continue
+		}
+		raw = bytes.ReplaceAll(raw, []byte(val), []byte(`${`+name+`}`))
+	}
+	return raw
+}
+
+// expandEnv replace each "${NAME}" in value with the value of system
+// environment variable NAME.
+// If the variable is not set, the "${NAME}" is kept as is, so it can be
+// expanded later by the shell when running the command.
+// The variable without bracket, "$NAME", is not expanded.
+func expandEnv(value string) string {
+	var (
+		sb strings.Builder
+
+		name  string
+		val   string
+		begin int
+		end   int
+		ok    bool
+	)
+	for {
+		begin = strings.Index(value, `${`)
+		if begin < 0 {
+			break
+		}
+		end = strings.IndexByte(value[begin:], '}')
+		if end < 0 {
+			break
+		}
+		end += begin
+
+		sb.WriteString(value[:begin])
+
+		name = value[begin+2 : end]
+		val, ok = os.LookupEnv(name)
+		if ok {
+			sb.WriteString(val)
+		} else {
+			sb.WriteString(value[begin : end+1])
+		}
+		value = value[end+1:]
+	}
+	sb.WriteString(value)
+
+	return sb.String()
 }
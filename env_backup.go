@@ -0,0 +1,417 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefBackupLogMaxAge the default maximum age of job logs to be included
+// in the backup.
+const DefBackupLogMaxAge = 7 * 24 * time.Hour
+
+// backupManifestName the name of manifest file inside the backup archive.
+const backupManifestName = `manifest.json`
+
+// BackupManifest contains the information of backup archive and the list
+// of files inside it.
+type BackupManifest struct {
+	CreatedAt time.Time `json:"created_at"`
+
+	// LogSince only the job logs modified after this time are included
+	// in the backup.
+	LogSince time.Time `json:"log_since"`
+
+	// Version the karajo version that create the backup.
+	Version string `json:"version"`
+
+	// Name the Env name.
+	Name string `json:"name"`
+
+	// Hostname the host where the backup created.
+	Hostname string `json:"hostname"`
+
+	Files []BackupFile `json:"files"`
+}
+
+// BackupFile contains the information of file inside the backup archive.
+type BackupFile struct {
+	ModTime time.Time `json:"mod_time"`
+
+	// Path the file path relative to the DirBase, using slash as
+	// separator.
+	Path string `json:"path"`
+
+	// SHA256 the hex encoded SHA-256 of file content.
+	SHA256 string `json:"sha256"`
+
+	Size int64 `json:"size"`
+
+	Mode fs.FileMode `json:"mode"`
+}
+
+// backupDirs return the list of directories, relative to DirBase, that
+// are included in the backup.
+func backupDirs() []string {
+	return []string{
+		path.Join(`etc`, defEnvName),
+		path.Join(`var`, `lib`, defEnvName),
+		path.Join(`var`, `log`, defEnvName),
+	}
+}
+
+// Backup write the configurations in "$DirBase/etc/karajo", the states in
+// "$DirBase/var/lib/karajo", and the job logs in "$DirBase/var/log/karajo"
+// that modified in the last logMaxAge, as gzip compressed tar into w.
+// If logMaxAge is zero, it will set to [DefBackupLogMaxAge].
+//
+// The manifest, that contains the checksum of each file, is written as
+// the last file in the archive with name "manifest.json".
+func (env *Env) Backup(w io.Writer, logMaxAge time.Duration) (manifest *BackupManifest, err error) {
+	var logp = `Backup`
+
+	if logMaxAge <= 0 {
+		logMaxAge = DefBackupLogMaxAge
+	}
+	if len(env.DirBase) == 0 {
+		env.DirBase = defDirBase
+	}
+
+	var now = timeNow()
+
+	manifest = &BackupManifest{
+		CreatedAt: now,
+		LogSince:  now.Add(-logMaxAge),
+		Version:   Version,
+		Name:      env.Name,
+	}
+	manifest.Hostname, _ = os.Hostname()
+
+	var (
+		gzw = gzip.NewWriter(w)
+		tw  = tar.NewWriter(gzw)
+		dir string
+	)
+	for _, dir = range backupDirs() {
+		err = env.backupDir(tw, manifest, dir)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	var rawManifest []byte
+
+	rawManifest, err = json.MarshalIndent(manifest, ``, "\t")
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var hdr = &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     backupManifestName,
+		Size:     int64(len(rawManifest)),
+		Mode:     0600,
+		ModTime:  now,
+	}
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	_, err = tw.Write(rawManifest)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = gzw.Close()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return manifest, nil
+}
+
+// backupDir write all regular files inside the dir, relative to DirBase,
+// into tw.
+// The file inside the log directory is skipped if its modified before the
+// manifest LogSince.
+func (env *Env) backupDir(tw *tar.Writer, manifest *BackupManifest, dir string) (err error) {
+	var (
+		root  = filepath.Join(env.DirBase, filepath.FromSlash(dir))
+		isLog = strings.HasPrefix(dir, `var/log/`)
+	)
+
+	err = filepath.WalkDir(root, func(fpath string, de fs.DirEntry, errWalk error) error {
+		if errWalk != nil {
+			if errors.Is(errWalk, fs.ErrNotExist) {
+				return nil
+			}
+			return errWalk
+		}
+		if !de.Type().IsRegular() {
+			return nil
+		}
+
+		var fi, errInfo = de.Info()
+		if errInfo != nil {
+			return errInfo
+		}
+		if isLog && fi.ModTime().Before(manifest.LogSince) {
+			return nil
+		}
+
+		var rel, _ = filepath.Rel(env.DirBase, fpath)
+
+		return backupFile(tw, manifest, fpath, filepath.ToSlash(rel), fi)
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, dir, err)
+	}
+	return nil
+}
+
+// backupFile write the content of file fpath into tw with name rel.
+// Only the first fi.Size bytes are written, in case the file is being
+// appended, for example the log of running job.
+func backupFile(tw *tar.Writer, manifest *BackupManifest, fpath, rel string, fi fs.FileInfo) (err error) {
+	var f *os.File
+
+	f, err = os.Open(fpath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	var hdr = &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     rel,
+		Size:     fi.Size(),
+		Mode:     int64(fi.Mode().Perm()),
+		ModTime:  fi.ModTime(),
+	}
+	err = tw.WriteHeader(hdr)
+	if err != nil {
+		return err
+	}
+
+	var h = sha256.New()
+
+	_, err = io.CopyN(io.MultiWriter(tw, h), f, fi.Size())
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, rel, err)
+	}
+
+	manifest.Files = append(manifest.Files, BackupFile{
+		ModTime: fi.ModTime(),
+		Path:    rel,
+		SHA256:  hex.EncodeToString(h.Sum(nil)),
+		Size:    fi.Size(),
+		Mode:    fi.Mode().Perm(),
+	})
+	return nil
+}
+
+// Restore the archive created by [Env.Backup] from r into DirBase.
+//
+// The archive is extracted into temporary directory inside DirBase
+// first, and each file is verified against the manifest.
+// Only if all of the files are valid, they are moved into DirBase,
+// replacing the existing files.
+// The karajo server should not be running while restoring.
+func (env *Env) Restore(r io.Reader) (manifest *BackupManifest, err error) {
+	var logp = `Restore`
+
+	if len(env.DirBase) == 0 {
+		env.DirBase = defDirBase
+	}
+
+	var dirStage string
+
+	dirStage, err = os.MkdirTemp(env.DirBase, `.karajo-restore-`)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	defer os.RemoveAll(dirStage)
+
+	var sums map[string]string
+
+	manifest, sums, err = extractBackup(r, dirStage)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = manifest.verify(sums)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		bf  BackupFile
+		fi  fs.FileInfo
+		src string
+		dst string
+	)
+	for _, bf = range manifest.Files {
+		dst = filepath.Join(env.DirBase, filepath.FromSlash(bf.Path))
+		fi, err = os.Stat(dst)
+		if err == nil && fi.IsDir() {
+			return nil, fmt.Errorf(`%s: %s: is a directory`, logp, bf.Path)
+		}
+	}
+	for _, bf = range manifest.Files {
+		src = filepath.Join(dirStage, filepath.FromSlash(bf.Path))
+		dst = filepath.Join(env.DirBase, filepath.FromSlash(bf.Path))
+
+		err = os.MkdirAll(filepath.Dir(dst), 0700)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+		err = os.Rename(src, dst)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+		err = os.Chmod(dst, bf.Mode.Perm())
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+		err = os.Chtimes(dst, bf.ModTime, bf.ModTime)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return manifest, nil
+}
+
+// extractBackup extract the files in backup archive from r into dir.
+// It return the manifest and the SHA-256 of each extracted file.
+func extractBackup(r io.Reader, dir string) (manifest *BackupManifest, sums map[string]string, err error) {
+	var gzr *gzip.Reader
+
+	gzr, err = gzip.NewReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer gzr.Close()
+
+	var (
+		tr   = tar.NewReader(gzr)
+		h    hash.Hash
+		hdr  *tar.Header
+		f    *os.File
+		dst  string
+		name string
+	)
+
+	sums = make(map[string]string)
+
+	for {
+		hdr, err = tr.Next()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, nil, err
+		}
+		if hdr.Typeflag != tar.TypeReg {
+			return nil, nil, fmt.Errorf(`%s: unsupported file type`, hdr.Name)
+		}
+
+		name = hdr.Name
+		if name == backupManifestName {
+			manifest = &BackupManifest{}
+			err = json.NewDecoder(tr).Decode(manifest)
+			if err != nil {
+				return nil, nil, fmt.Errorf(`%s: %w`, name, err)
+			}
+			continue
+		}
+		if !isBackupPath(name) {
+			return nil, nil, fmt.Errorf(`%s: invalid path`, name)
+		}
+
+		dst = filepath.Join(dir, filepath.FromSlash(name))
+
+		err = os.MkdirAll(filepath.Dir(dst), 0700)
+		if err != nil {
+			return nil, nil, err
+		}
+		f, err = os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		h = sha256.New()
+
+		_, err = io.Copy(io.MultiWriter(f, h), tr)
+		if err != nil {
+			_ = f.Close()
+			return nil, nil, fmt.Errorf(`%s: %w`, name, err)
+		}
+		err = f.Close()
+		if err != nil {
+			return nil, nil, err
+		}
+		sums[name] = hex.EncodeToString(h.Sum(nil))
+	}
+	if manifest == nil {
+		return nil, nil, fmt.Errorf(`missing %s`, backupManifestName)
+	}
+	return manifest, sums, nil
+}
+
+// isBackupPath return true if the name is local path inside one of the
+// backup directories.
+func isBackupPath(name string) bool {
+	if !filepath.IsLocal(name) || path.Clean(name) != name {
+		return false
+	}
+	var dir string
+	for _, dir = range backupDirs() {
+		if strings.HasPrefix(name, dir+`/`) {
+			return true
+		}
+	}
+	return false
+}
+
+// verify that each file in manifest has been extracted with the same
+// checksum, and no file extracted outside of manifest.
+func (manifest *BackupManifest) verify(sums map[string]string) (err error) {
+	if len(manifest.Files) != len(sums) {
+		return fmt.Errorf(`manifest contains %d files, got %d files`,
+			len(manifest.Files), len(sums))
+	}
+
+	var (
+		bf  BackupFile
+		sum string
+		ok  bool
+	)
+	for _, bf = range manifest.Files {
+		sum, ok = sums[bf.Path]
+		if !ok {
+			return fmt.Errorf(`%s: missing in archive`, bf.Path)
+		}
+		if sum != bf.SHA256 {
+			return fmt.Errorf(`%s: checksum mismatch`, bf.Path)
+		}
+	}
+	return nil
+}
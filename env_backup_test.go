@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_BackupRestore(t *testing.T) {
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+		}
+		files = map[string]string{
+			`etc/karajo/karajo.conf`:        "[karajo]\nname = test\n",
+			`etc/karajo/job.d/a.conf`:       "[job \"a\"]\n",
+			`var/lib/karajo/job/a`:          `state`,
+			`var/log/karajo/job/a/a.1.fail`: `recent log`,
+			`var/log/karajo/job/a/a.0.ok`:   `old log`,
+			`var/cache/karajo/ignored`:      `ignored`,
+		}
+		name string
+		err  error
+	)
+
+	for name = range files {
+		writeTestFile(t, env.DirBase, name, files[name])
+	}
+
+	var (
+		recent = timeNow().Add(-time.Hour)
+		old    = timeNow().Add(-30 * 24 * time.Hour)
+	)
+	for name = range files {
+		err = os.Chtimes(filepath.Join(env.DirBase, name), recent, recent)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = os.Chtimes(filepath.Join(env.DirBase, `var/log/karajo/job/a/a.0.ok`), old, old)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		archive  bytes.Buffer
+		manifest *BackupManifest
+	)
+
+	manifest, err = env.Backup(&archive, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		expPaths = []string{
+			`etc/karajo/job.d/a.conf`,
+			`etc/karajo/karajo.conf`,
+			`var/lib/karajo/job/a`,
+			`var/log/karajo/job/a/a.1.fail`,
+		}
+		gotPaths []string
+		bf       BackupFile
+	)
+	for _, bf = range manifest.Files {
+		gotPaths = append(gotPaths, bf.Path)
+	}
+	test.Assert(t, `backup files`, expPaths, gotPaths)
+
+	var restored = Env{
+		DirBase: t.TempDir(),
+	}
+
+	_, err = restored.Restore(bytes.NewReader(archive.Bytes()))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw []byte
+	for _, name = range expPaths {
+		raw, err = os.ReadFile(filepath.Join(restored.DirBase, name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, name, files[name], string(raw))
+	}
+
+	_, err = os.Stat(filepath.Join(restored.DirBase, `var/log/karajo/job/a/a.0.ok`))
+	if !os.IsNotExist(err) {
+		t.Fatalf(`expecting old log is not restored, got %v`, err)
+	}
+}
+
+func TestEnv_Restore_invalid(t *testing.T) {
+	type testCase struct {
+		desc   string
+		expErr string
+		files  []tarTestFile
+	}
+
+	var manifest = `{"files":[{"path":"etc/karajo/karajo.conf",` +
+		`"sha256":"2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"}]}`
+
+	var cases = []testCase{{
+		desc: `missing manifest`,
+		files: []tarTestFile{{
+			name:    `etc/karajo/karajo.conf`,
+			content: `hello`,
+		}},
+		expErr: `Restore: missing manifest.json`,
+	}, {
+		desc: `path outside backup directories`,
+		files: []tarTestFile{{
+			name:    `etc/passwd`,
+			content: `root`,
+		}},
+		expErr: `Restore: etc/passwd: invalid path`,
+	}, {
+		desc: `path traversal`,
+		files: []tarTestFile{{
+			name:    `etc/karajo/../../root`,
+			content: `root`,
+		}},
+		expErr: `Restore: etc/karajo/../../root: invalid path`,
+	}, {
+		desc: `tampered content`,
+		files: []tarTestFile{{
+			name:    `etc/karajo/karajo.conf`,
+			content: `hellO`,
+		}, {
+			name:    backupManifestName,
+			content: manifest,
+		}},
+		expErr: `Restore: etc/karajo/karajo.conf: checksum mismatch`,
+	}, {
+		desc: `file not in manifest`,
+		files: []tarTestFile{{
+			name:    `etc/karajo/karajo.conf`,
+			content: `hello`,
+		}, {
+			name:    `etc/karajo/other.conf`,
+			content: `other`,
+		}, {
+			name:    backupManifestName,
+			content: manifest,
+		}},
+		expErr: `Restore: manifest contains 1 files, got 2 files`,
+	}}
+
+	var (
+		c   testCase
+		env Env
+		err error
+	)
+	for _, c = range cases {
+		env = Env{
+			DirBase: t.TempDir(),
+		}
+		_, err = env.Restore(newTestTarGz(t, c.files))
+		if err == nil {
+			t.Fatalf(`%s: expecting error`, c.desc)
+		}
+		test.Assert(t, c.desc, c.expErr, err.Error())
+
+		_, err = os.Stat(filepath.Join(env.DirBase, `etc`))
+		if !os.IsNotExist(err) {
+			t.Fatalf(`%s: expecting nothing restored, got %v`, c.desc, err)
+		}
+	}
+}
+
+type tarTestFile struct {
+	name    string
+	content string
+}
+
+func newTestTarGz(t *testing.T, files []tarTestFile) *bytes.Buffer {
+	var (
+		buf bytes.Buffer
+		gzw = gzip.NewWriter(&buf)
+		tw  = tar.NewWriter(gzw)
+		f   tarTestFile
+		err error
+	)
+	for _, f = range files {
+		err = tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.name,
+			Size:     int64(len(f.content)),
+			Mode:     0600,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = tw.Write([]byte(f.content))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = tw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = gzw.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &buf
+}
+
+func writeTestFile(t *testing.T, dir, name, content string) {
+	var fpath = filepath.Join(dir, filepath.FromSlash(name))
+
+	var err = os.MkdirAll(filepath.Dir(fpath), 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(fpath, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+// EnvChanges contains the jobs whose state changed since specific
+// revision of Env.
+type EnvChanges struct {
+	// List of JobExec that changed, by name.
+	ExecJobs map[string]*JobExec `json:"jobs,omitempty"`
+
+	// List of JobHTTP that changed, by name.
+	HTTPJobs map[string]*JobHTTP `json:"http_jobs,omitempty"`
+
+	// List of JobSQL that changed, by name.
+	SQLJobs map[string]*JobSQL `json:"sql_jobs,omitempty"`
+
+	// Epoch identify the lifetime of the revision.
+	// The epoch changes when the karajo restarted, in which case the
+	// client should fetch the whole environment again.
+	Epoch int64 `json:"epoch"`
+
+	// Revision the current revision, to be used as "since" on the
+	// next request.
+	Revision int64 `json:"revision"`
+}
+
+// changes return the jobs whose state changed after the revision since.
+func (env *Env) changes(since int64) (changes *EnvChanges) {
+	changes = &EnvChanges{}
+
+	if env.revision != nil {
+		changes.Epoch, changes.Revision = env.revision.state()
+	}
+
+	var (
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+		rev     int64
+	)
+	env.jobsMtx.RLock()
+	defer env.jobsMtx.RUnlock()
+
+	for name, job = range env.ExecJobs {
+		job.Lock()
+		rev = job.lastRevision
+		job.Unlock()
+
+		if rev <= since {
+			continue
+		}
+		if changes.ExecJobs == nil {
+			changes.ExecJobs = make(map[string]*JobExec)
+		}
+		changes.ExecJobs[name] = job
+	}
+	for name, jobHTTP = range env.HTTPJobs {
+		jobHTTP.Lock()
+		rev = jobHTTP.lastRevision
+		jobHTTP.Unlock()
+
+		if rev <= since {
+			continue
+		}
+		if changes.HTTPJobs == nil {
+			changes.HTTPJobs = make(map[string]*JobHTTP)
+		}
+		changes.HTTPJobs[name] = jobHTTP
+	}
+	for name, jobSQL = range env.SQLJobs {
+		jobSQL.Lock()
+		rev = jobSQL.lastRevision
+		jobSQL.Unlock()
+
+		if rev <= since {
+			continue
+		}
+		if changes.SQLJobs == nil {
+			changes.SQLJobs = make(map[string]*JobSQL)
+		}
+		changes.SQLJobs[name] = jobSQL
+	}
+	return changes
+}
@@ -0,0 +1,103 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_changes(t *testing.T) {
+	var (
+		rev  = newEnvRevision()
+		jobA = &JobExec{JobBase: JobBase{ID: `job_a`, revision: rev}}
+		jobB = &JobExec{JobBase: JobBase{ID: `job_b`, revision: rev}}
+		jobC = &JobHTTP{JobBase: JobBase{ID: `job_c`, revision: rev}}
+		env  = &Env{
+			ExecJobs: map[string]*JobExec{
+				`Job A`: jobA,
+				`Job B`: jobB,
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`Job C`: jobC,
+			},
+			revision: rev,
+		}
+
+		changes *EnvChanges
+	)
+
+	changes = env.changes(0)
+	test.Assert(t, `no changes`, &EnvChanges{Epoch: rev.epoch}, changes)
+
+	jobA.pause(time.Time{}, ``)
+	jobC.pause(time.Time{}, ``)
+
+	changes = env.changes(0)
+	test.Assert(t, `revision`, int64(2), changes.Revision)
+	test.Assert(t, `jobs`, map[string]*JobExec{`Job A`: jobA}, changes.ExecJobs)
+	test.Assert(t, `http_jobs`, map[string]*JobHTTP{`Job C`: jobC}, changes.HTTPJobs)
+
+	// Only the job that changed after the revision 1 is returned.
+	changes = env.changes(1)
+	test.Assert(t, `since 1: jobs`, map[string]*JobExec(nil), changes.ExecJobs)
+	test.Assert(t, `since 1: http_jobs`, map[string]*JobHTTP{`Job C`: jobC}, changes.HTTPJobs)
+
+	changes = env.changes(changes.Revision)
+	test.Assert(t, `since last: jobs`, map[string]*JobExec(nil), changes.ExecJobs)
+	test.Assert(t, `since last: http_jobs`, map[string]*JobHTTP(nil), changes.HTTPJobs)
+}
+
+func TestKarajo_apiChanges(t *testing.T) {
+	type testCase struct {
+		since    string
+		expError string
+	}
+
+	var cases = []testCase{{
+		since: ``,
+	}, {
+		since: `10`,
+	}, {
+		since:    `-1`,
+		expError: `invalid since "-1"`,
+	}, {
+		since:    `x`,
+		expError: `invalid since "x"`,
+	}}
+
+	var (
+		k = &Karajo{
+			env: &Env{
+				revision: newEnvRevision(),
+			},
+		}
+
+		c   testCase
+		epr *libhttp.EndpointRequest
+		err error
+	)
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  httptest.NewRecorder(),
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiChanges+`?since=`+c.since, nil),
+		}
+		err = epr.HTTPRequest.ParseForm()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = k.apiChanges(epr)
+		if len(c.expError) != 0 {
+			test.Assert(t, c.since, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.since, nil, err)
+	}
+}
@@ -0,0 +1,301 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+// List of configuration file extension that are loaded using YAML or TOML
+// format.
+// Other extensions are loaded using INI format.
+const (
+	configExtTOML = `.toml`
+	configExtYAML = `.yaml`
+	configExtYML  = `.yml`
+)
+
+// openConfig open the configuration file and convert it into INI, based on
+// the file extension.
+//
+// The YAML and TOML configuration use the same structure as INI, where the
+// top level keys are the section names.
+// A section without sub-section contains the variables directly, while
+// section with sub-section contains the sub-section names as keys and the
+// variables as values.
+// For example, the following INI
+//
+//	[karajo]
+//	name = My karajo
+//
+//	[job "Test"]
+//	commands = echo A
+//	commands = echo B
+//
+// is equal to the following YAML,
+//
+//	karajo:
+//	  name: My karajo
+//	job:
+//	  Test:
+//	    commands:
+//	      - echo A
+//	      - echo B
+func openConfig(file string) (cfg *ini.Ini, err error) {
+	var ext = strings.ToLower(filepath.Ext(file))
+
+	switch ext {
+	case configExtTOML, configExtYAML, configExtYML:
+	default:
+		return ini.Open(file)
+	}
+
+	var content []byte
+
+	content, err = os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc map[string]any
+
+	if ext == configExtTOML {
+		doc, err = parseTOML(content)
+	} else {
+		doc, err = parseYAML(content)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, file, err)
+	}
+
+	cfg, err = newIniFromMap(doc)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, file, err)
+	}
+	return cfg, nil
+}
+
+// isConfigFile return true if the file name end with ".conf" or with one of
+// the YAML or TOML extension.
+func isConfigFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case `.conf`, configExtTOML, configExtYAML, configExtYML:
+		return true
+	}
+	return false
+}
+
+// parseTOML parse the TOML content into map.
+func parseTOML(content []byte) (doc map[string]any, err error) {
+	_, err = toml.Decode(string(content), &doc)
+	if err != nil {
+		return nil, err
+	}
+	return doc, nil
+}
+
+// parseYAML parse the YAML content into map.
+func parseYAML(content []byte) (doc map[string]any, err error) {
+	var raw map[string]any
+
+	err = yaml.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		key string
+		val any
+	)
+	doc = make(map[string]any, len(raw))
+	for key, val = range raw {
+		doc[key], err = normalizeYAML(val)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return doc, nil
+}
+
+// normalizeYAML convert the map with interface keys, as decoded by YAML,
+// into map with string keys.
+func normalizeYAML(in any) (out any, err error) {
+	switch val := in.(type) {
+	case map[any]any:
+		var (
+			m = make(map[string]any, len(val))
+
+			k, v any
+			key  string
+			ok   bool
+		)
+		for k, v = range val {
+			key, ok = k.(string)
+			if !ok {
+				key = fmt.Sprint(k)
+			}
+			m[key], err = normalizeYAML(v)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return m, nil
+
+	case []any:
+		var (
+			list = make([]any, 0, len(val))
+			v    any
+		)
+		for _, v = range val {
+			v, err = normalizeYAML(v)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, v)
+		}
+		return list, nil
+	}
+	return in, nil
+}
+
+// newIniFromMap convert the map of sections into INI.
+// The map is converted into INI formatted text first, so multiple values
+// with the same key are preserved.
+func newIniFromMap(doc map[string]any) (cfg *ini.Ini, err error) {
+	var (
+		buf bytes.Buffer
+
+		secName string
+		vars    map[string]any
+		ok      bool
+	)
+	for _, secName = range sortedKeys(doc) {
+		vars, ok = doc[secName].(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf(`section %q: expecting map, got %T`,
+				secName, doc[secName])
+		}
+
+		var (
+			keys    []string
+			subKeys []string
+			key     string
+		)
+		for _, key = range sortedKeys(vars) {
+			_, ok = vars[key].(map[string]any)
+			if ok {
+				subKeys = append(subKeys, key)
+			} else {
+				keys = append(keys, key)
+			}
+		}
+
+		if len(keys) != 0 {
+			fmt.Fprintf(&buf, "[%s]\n", secName)
+			err = writeIniVars(&buf, secName, ``, keys, vars)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		// The rest of keys are sub-section names.
+		var sub map[string]any
+		for _, key = range subKeys {
+			sub = vars[key].(map[string]any)
+
+			fmt.Fprintf(&buf, "[%s %s]\n", secName, quoteIni(key))
+			err = writeIniVars(&buf, secName, key, sortedKeys(sub), sub)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	cfg, err = ini.Parse(buf.Bytes())
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// writeIniVars write each key and its value in vars as INI variable into
+// buf.
+// If the value is a list, each item in the list will be written using the
+// same key.
+func writeIniVars(buf *bytes.Buffer, secName, subName string, keys []string, vars map[string]any) (err error) {
+	var (
+		key  string
+		list []any
+		item any
+		str  string
+		ok   bool
+	)
+	for _, key = range keys {
+		list, ok = vars[key].([]any)
+		if !ok {
+			list = []any{vars[key]}
+		}
+		for _, item = range list {
+			str, err = scalarString(item)
+			if err != nil {
+				return fmt.Errorf(`[%s %q] %s: %w`, secName, subName, key, err)
+			}
+			fmt.Fprintf(buf, "%s = %s\n", key, quoteIni(str))
+		}
+	}
+	return nil
+}
+
+// quoteIni quote the string v with double quote and escape the backslash,
+// double quote, new line, and tab inside it.
+func quoteIni(v string) string {
+	v = strings.ReplaceAll(v, `\`, `\\`)
+	v = strings.ReplaceAll(v, `"`, `\"`)
+	v = strings.ReplaceAll(v, "\n", `\n`)
+	v = strings.ReplaceAll(v, "\t", `\t`)
+	return `"` + v + `"`
+}
+
+// scalarString convert the scalar value into string.
+func scalarString(value any) (str string, err error) {
+	switch v := value.(type) {
+	case nil:
+		return ``, nil
+	case string:
+		return v, nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int:
+		return strconv.Itoa(v), nil
+	case int64:
+		return strconv.FormatInt(v, 10), nil
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64), nil
+	case map[string]any, []any:
+		return ``, fmt.Errorf(`unsupported nested value %T`, value)
+	}
+	return fmt.Sprint(value), nil
+}
+
+// sortedKeys return the keys of map m in sorted order.
+func sortedKeys(m map[string]any) (keys []string) {
+	var key string
+
+	keys = make([]string, 0, len(m))
+	for key = range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestLoadEnv_format(t *testing.T) {
+	var (
+		envINI  *Env
+		expINI  []byte
+		expJSON []byte
+		err     error
+	)
+
+	envINI, err = LoadEnv(`testdata/env_config/karajo.conf`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expJSON, err = json.MarshalIndent(envINI, ``, `  `)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expINI, err = ini.Marshal(envINI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var listFile = []string{
+		`testdata/env_config/karajo.yaml`,
+		`testdata/env_config/karajo.toml`,
+	}
+	var (
+		file string
+		env  *Env
+		got  []byte
+	)
+	for _, file = range listFile {
+		env, err = LoadEnv(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err = json.MarshalIndent(env, ``, `  `)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, file+`: JSON`, string(expJSON), string(got))
+
+		// Marshaling back the Env into INI should produce the same
+		// INI as the original.
+		got, err = ini.Marshal(env)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, file+`: INI`, string(expINI), string(got))
+	}
+
+	var expCommands = []string{
+		`echo A`,
+		`echo A`,
+		`x=$(($RANDOM%10)) && echo "sleep in ${x}s"`,
+	}
+	test.Assert(t, `Commands`, expCommands, env.ExecJobs[`Test A`].Commands)
+	test.Assert(t, `Description`, `Job with "quote" and \ backslash.`,
+		env.ExecJobs[`Test A`].Description)
+}
+
+func TestEnv_loadJobd_format(t *testing.T) {
+	var (
+		dirBase       = t.TempDir()
+		dirJobd       = filepath.Join(dirBase, `job.d`)
+		dirJobHTTPd   = filepath.Join(dirBase, `job_http.d`)
+		fileJobConfig = map[string]string{
+			filepath.Join(dirJobd, `a.conf`): "[job \"Job A\"]\ncommand = echo A\n",
+			filepath.Join(dirJobd, `b.yaml`): "job:\n  Job B:\n    command:\n      - echo B\n",
+			filepath.Join(dirJobd, `c.toml`): "[job.\"Job C\"]\ncommand = [\"echo C\"]\n",
+			filepath.Join(dirJobd, `d.txt`):  "[job \"Job D\"]\ncommand = echo D\n",
+
+			filepath.Join(dirJobHTTPd, `a.yml`): "job.http:\n  HTTP A:\n    http_url: /a\n",
+		}
+
+		file    string
+		content string
+		err     error
+	)
+
+	for _, file = range []string{dirJobd, dirJobHTTPd} {
+		err = os.MkdirAll(file, 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	for file, content = range fileJobConfig {
+		err = os.WriteFile(file, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var env = &Env{
+		dirConfigJobd:     dirJobd,
+		dirConfigJobHTTPd: dirJobHTTPd,
+	}
+
+	err = env.loadJobd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = env.loadJobHTTPd()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		expCommands = map[string][]string{
+			`Job A`: {`echo A`},
+			`Job B`: {`echo B`},
+			`Job C`: {`echo C`},
+		}
+		gotCommands = map[string][]string{}
+
+		name string
+		job  *JobExec
+	)
+	for name, job = range env.ExecJobs {
+		gotCommands[name] = job.Commands
+	}
+	test.Assert(t, `ExecJobs`, expCommands, gotCommands)
+	test.Assert(t, `HTTPJobs`, `/a`, env.HTTPJobs[`HTTP A`].HTTPURL)
+}
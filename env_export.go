@@ -0,0 +1,177 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+)
+
+// List of format for exporting the Env.
+const (
+	envExportFormatINI  = `ini`
+	envExportFormatJSON = `json`
+)
+
+// envRedacted the value that replace the secret in the exported Env.
+const envRedacted = `<redacted>`
+
+// envSecretKeys list of section name and key in the INI that contains
+// secret.
+// The key "http_header" only redact the header value.
+var envSecretKeys = map[string][]string{
+	`auth`:     {`client_secret`},
+	`job`:      {`secret`, `heartbeat_url`},
+	`job.http`: {`secret`, `http_header`, `heartbeat_url`},
+	`job.sql`:  {`sql_dsn`, `heartbeat_url`},
+	`karajo`:   {`secret`, `queue_address`},
+	`notif`:    {`smtp_password`, `token`},
+	`peer`:     {`token`},
+	`storage`:  {`access_key`, `secret_key`},
+}
+
+// export the Env, after its initialized, in the INI or JSON format with
+// all of the secrets redacted.
+//
+// In the INI format, the "template" option in each job is removed, since
+// the template commands has been rendered into the job commands.
+func (env *Env) export(format string) (out []byte, err error) {
+	var logp = `export`
+
+	env.jobsMtx.RLock()
+	switch format {
+	case envExportFormatINI:
+		env.lockAllJob()
+		out, err = ini.Marshal(env)
+		env.unlockAllJob()
+	case envExportFormatJSON:
+		// Each job lock itself while being encoded.
+		out, err = json.MarshalIndent(env, ``, "\t")
+	default:
+		err = fmt.Errorf(`unknown format %q`, format)
+	}
+	env.jobsMtx.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	if format == envExportFormatJSON {
+		var doc any
+
+		err = json.Unmarshal(out, &doc)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+
+		redactJSON(doc)
+
+		out, err = json.MarshalIndent(doc, ``, "\t")
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+		return out, nil
+	}
+
+	var cfg *ini.Ini
+
+	cfg, err = ini.Parse(out)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	redactINI(cfg)
+
+	var buf bytes.Buffer
+
+	err = cfg.Write(&buf)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// redactINI replace the non-empty value of secret keys in the cfg with
+// envRedacted, and remove the "template" option in each job.
+func redactINI(cfg *ini.Ini) {
+	var (
+		secName  string
+		subNames []string
+		subName  string
+		keys     []string
+		key      string
+		vals     []string
+		val      string
+		sub      *ini.Section
+	)
+	for _, sub = range cfg.Subs(`job`) {
+		cfg.UnsetAll(`job`, sub.SubName(), `template`)
+	}
+
+	for secName, keys = range envSecretKeys {
+		subNames = []string{``}
+		for _, sub = range cfg.Subs(secName) {
+			subNames = append(subNames, sub.SubName())
+		}
+		for _, subName = range subNames {
+			for _, key = range keys {
+				vals = cfg.Gets(secName, subName, key)
+				if len(strings.Join(vals, ``)) == 0 {
+					continue
+				}
+				cfg.UnsetAll(secName, subName, key)
+				for _, val = range vals {
+					if len(val) != 0 {
+						cfg.Add(secName, subName, key, redactValue(key, val))
+					}
+				}
+			}
+		}
+	}
+}
+
+// redactJSON replace the value of "http_headers" in the doc, the only
+// secret that is not excluded from the JSON, with envRedacted.
+func redactJSON(doc any) {
+	switch v := doc.(type) {
+	case map[string]any:
+		var (
+			key  string
+			val  any
+			list []any
+			x    int
+		)
+		for key, val = range v {
+			list, _ = val.([]any)
+			if key != `http_headers` || list == nil {
+				redactJSON(val)
+				continue
+			}
+			for x = range list {
+				var str, _ = list[x].(string)
+				list[x] = redactValue(`http_header`, str)
+			}
+		}
+	case []any:
+		var val any
+		for _, val = range v {
+			redactJSON(val)
+		}
+	}
+}
+
+// redactValue return the redacted value of key.
+// For "http_header", only the header value is redacted.
+func redactValue(key, val string) string {
+	if key == `http_header` {
+		var name, _, found = strings.Cut(val, `:`)
+		if found {
+			return name + `: ` + envRedacted
+		}
+	}
+	return envRedacted
+}
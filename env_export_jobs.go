@@ -0,0 +1,536 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	libhtml "git.sr.ht/~shulhan/pakakeh.go/lib/html"
+	libtime "git.sr.ht/~shulhan/pakakeh.go/lib/time"
+)
+
+// List of format for exporting the JobExec.
+const (
+	JobExportFormatCrontab = `crontab`
+	JobExportFormatK8s     = `k8s`
+	JobExportFormatSystemd = `systemd`
+)
+
+// jobExportShell the shell that execute each command in the exported job.
+const jobExportShell = `/bin/sh -c `
+
+// ExportFile contains the name and content of file generated by
+// [Env.ExportJobs].
+type ExportFile struct {
+	Name    string
+	Content []byte
+}
+
+// JobExportOptions define the options for [Env.ExportJobs].
+type JobExportOptions struct {
+	// Format of exported jobs, one of "crontab", "k8s", or "systemd".
+	Format string
+
+	// Image the container image that run the job commands in "k8s"
+	// format, default to "alpine:3".
+	// The image must provide "/bin/sh" and the programs used by the
+	// commands.
+	Image string
+
+	// Namespace of CronJob in "k8s" format.
+	// If its empty, the namespace is not set in the manifest.
+	Namespace string
+}
+
+// ExportJobs load the JobExec from configuration and render it as crontab,
+// Kubernetes CronJob manifests, or systemd timer and service units,
+// without running the server.
+//
+// Only the job with schedule or interval is exported.
+// Each command is executed using "/bin/sh -c" inside the job working
+// directory, in the same order as karajo does.
+// The job that cannot be exported, for example the interval cannot be
+// expressed in crontab, is written as comment.
+func (env *Env) ExportJobs(opts JobExportOptions) (files []ExportFile, err error) {
+	var logp = `ExportJobs`
+
+	var jobs []*JobExec
+
+	jobs, err = env.loadExportedJobs()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	switch opts.Format {
+	case JobExportFormatCrontab:
+		files, err = exportCrontab(jobs)
+	case JobExportFormatK8s:
+		files, err = exportK8s(jobs, opts)
+	case JobExportFormatSystemd:
+		files, err = exportSystemd(jobs)
+	default:
+		err = fmt.Errorf(`unknown format %q`, opts.Format)
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return files, nil
+}
+
+// loadExportedJobs load the JobExec from job.d directory, render its
+// template, and order its steps, without initializing it.
+// It return the jobs sorted by ID, with its ID and working directory set.
+func (env *Env) loadExportedJobs() (jobs []*JobExec, err error) {
+	env.initDirsConfig()
+
+	err = env.loadJobd()
+	if err != nil {
+		return nil, err
+	}
+	err = env.initTemplates()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dirLibJob = filepath.Join(env.DirBase, `var`, `lib`, defEnvName, `job`)
+
+		name string
+		job  *JobExec
+	)
+	for name, job = range env.ExecJobs {
+		job.Name = name
+		job.ID = libhtml.NormalizeForID(name)
+		job.Schedule = strings.TrimSpace(job.Schedule)
+		job.dirWork = filepath.Join(dirLibJob, job.ID)
+		err = job.initSteps()
+		if err != nil {
+			return nil, fmt.Errorf(`job %q: %w`, name, err)
+		}
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(x, y int) bool {
+		return jobs[x].ID < jobs[y].ID
+	})
+	return jobs, nil
+}
+
+// exportCrontab render the jobs as single crontab file.
+// Since the karajo schedule is in UTC, the crontab set CRON_TZ to UTC.
+func exportCrontab(jobs []*JobExec) (files []ExportFile, err error) {
+	var (
+		buf bytes.Buffer
+
+		job   *JobExec
+		lines []string
+		line  string
+		cmd   string
+	)
+
+	buf.WriteString("# Generated by karajo " + Version + ".\n")
+	buf.WriteString("CRON_TZ=UTC\n")
+	buf.WriteString("PATH=" + jobEnvPathValue + "\n")
+
+	for _, job = range jobs {
+		buf.WriteString("\n# " + job.Name + "\n")
+
+		err = exportJobError(job)
+		if err != nil {
+			fmt.Fprintf(&buf, "# skipped: %s\n", err)
+			continue
+		}
+
+		lines, err = crontabTimes(job)
+		if err != nil {
+			fmt.Fprintf(&buf, "# skipped: %s\n", err)
+			continue
+		}
+
+		cmd, err = crontabCommand(job)
+		if err != nil {
+			fmt.Fprintf(&buf, "# skipped: %s\n", err)
+			continue
+		}
+		for _, line = range lines {
+			buf.WriteString(line + ` ` + cmd + "\n")
+		}
+	}
+
+	files = append(files, ExportFile{
+		Name:    JobExportFormatCrontab,
+		Content: buf.Bytes(),
+	})
+	return files, nil
+}
+
+// exportJobError return an error if the job cannot be exported, for
+// example the job with matrix that run the commands multiple times.
+func exportJobError(job *JobExec) error {
+	if len(job.Matrix) != 0 {
+		return fmt.Errorf(`matrix cannot be exported`)
+	}
+	return nil
+}
+
+// crontabTimes convert the job schedule or interval into list of crontab
+// time fields.
+func crontabTimes(job *JobExec) (list []string, err error) {
+	if len(job.Schedule) != 0 {
+		var sm *scheduleMatcher
+
+		sm, err = newScheduleMatcher(job.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		return sm.crontab(), nil
+	}
+	if job.Interval <= 0 {
+		return nil, fmt.Errorf(`no schedule or interval`)
+	}
+
+	var field string
+	switch {
+	case job.Interval%time.Hour == 0 && time.Duration(24)*time.Hour%job.Interval == 0:
+		var hours = int(job.Interval / time.Hour)
+		if hours == 24 {
+			field = `0 0 * * *`
+		} else {
+			field = fmt.Sprintf(`0 */%d * * *`, hours)
+		}
+	case job.Interval%time.Minute == 0 && time.Hour%job.Interval == 0:
+		var minutes = int(job.Interval / time.Minute)
+		if minutes == 1 {
+			field = `* * * * *`
+		} else {
+			field = fmt.Sprintf(`*/%d * * * *`, minutes)
+		}
+	default:
+		return nil, fmt.Errorf(`interval %s cannot be expressed in crontab`, job.Interval)
+	}
+	return []string{field}, nil
+}
+
+// crontab return the schedule as list of crontab time fields.
+// The daily, weekly, and monthly schedule with different minutes
+// generate one line for each minute.
+func (sm *scheduleMatcher) crontab() (list []string) {
+	switch sm.kind {
+	case libtime.ScheduleKindHourly:
+		return []string{joinSortedInts(sm.minutes, `,`, `%d`) + ` * * * *`}
+	case libtime.ScheduleKindMinutely:
+		return []string{`* * * * *`}
+	}
+
+	var (
+		dom = `*`
+		dow = `*`
+	)
+	switch sm.kind {
+	case libtime.ScheduleKindWeekly:
+		var (
+			days = map[int]bool{}
+			day  time.Weekday
+		)
+		for day = range sm.dow {
+			days[int(day)] = true
+		}
+		dow = joinSortedInts(days, `,`, `%d`)
+	case libtime.ScheduleKindMonthly:
+		dom = joinSortedInts(sm.dom, `,`, `%d`)
+	}
+
+	// Group the hours by minute.
+	var (
+		hoursByMinute = map[int]map[int]bool{}
+		tod           int
+	)
+	for tod = range sm.tod {
+		if hoursByMinute[tod%60] == nil {
+			hoursByMinute[tod%60] = map[int]bool{}
+		}
+		hoursByMinute[tod%60][tod/60] = true
+	}
+
+	var (
+		minutes = make([]int, 0, len(hoursByMinute))
+		minute  int
+	)
+	for minute = range hoursByMinute {
+		minutes = append(minutes, minute)
+	}
+	sort.Ints(minutes)
+
+	for _, minute = range minutes {
+		list = append(list, fmt.Sprintf(`%d %s %s * %s`, minute,
+			joinSortedInts(hoursByMinute[minute], `,`, `%d`), dom, dow))
+	}
+	return list
+}
+
+// crontabCommand return the job commands as single line of shell,
+// executed inside the job working directory.
+func crontabCommand(job *JobExec) (line string, err error) {
+	line = `cd ` + shellQuote(job.dirWork)
+
+	var script = exportShellScript(job)
+	if len(script) != 0 {
+		line += ` && ` + script
+	}
+	if strings.ContainsAny(line, "\r\n") {
+		return ``, fmt.Errorf(`command contains new line`)
+	}
+
+	// The "%" in crontab command is converted into new line.
+	line = strings.ReplaceAll(line, `%`, `\%`)
+	return line, nil
+}
+
+// exportShellScript return the job commands as single line of shell.
+// The PostCommands are executed after the Commands with
+// KARAJO_JOB_STATUS set to "success" or "failed", and the line exit with
+// the status of Commands.
+func exportShellScript(job *JobExec) (script string) {
+	var (
+		mainCmds = job.mainCommands()
+		cmds     = make([]string, 0, len(job.PreCommands)+len(mainCmds))
+		cmd      string
+	)
+
+	for _, cmd = range job.PreCommands {
+		cmds = append(cmds, exportShellCommand(cmd, job.ContinueOnError))
+	}
+	for _, cmd = range mainCmds {
+		cmds = append(cmds, exportShellCommand(cmd, job.ContinueOnError))
+	}
+
+	if len(cmds) == 0 && len(job.PostCommands) != 0 {
+		cmds = append(cmds, `true`)
+	}
+
+	var sb strings.Builder
+
+	sb.WriteString(strings.Join(cmds, ` && `))
+	if len(job.PostCommands) != 0 {
+		sb.WriteString(`; rc=$?; ` + jobEnvStatus + `=` + JobStatusFailed)
+		sb.WriteString(`; [ $rc -eq 0 ] && ` + jobEnvStatus + `=` + JobStatusSuccess)
+		sb.WriteString(`; export ` + jobEnvStatus)
+		for _, cmd = range job.PostCommands {
+			sb.WriteString(`; ` + exportShellCommand(cmd, true))
+		}
+		sb.WriteString(`; exit $rc`)
+	}
+	return sb.String()
+}
+
+// exportShellCommand return the cmd executed by shell.
+// If the command is allowed to fail, its exit status is ignored.
+func exportShellCommand(cmd string, isContinueOnError bool) string {
+	var isIgnoreError bool
+
+	cmd, isIgnoreError = parseCommand(cmd, isContinueOnError)
+	cmd = jobExportShell + shellQuote(cmd)
+	if isIgnoreError {
+		return `{ ` + cmd + ` || true; }`
+	}
+	return cmd
+}
+
+// shellQuote quote the s using single quote for shell.
+func shellQuote(s string) string {
+	return `'` + strings.ReplaceAll(s, `'`, `'\''`) + `'`
+}
+
+// exportSystemd render each job as systemd timer and oneshot service
+// units, named "karajo-<id>.timer" and "karajo-<id>.service".
+func exportSystemd(jobs []*JobExec) (files []ExportFile, err error) {
+	var (
+		job      *JobExec
+		timers   []string
+		timer    string
+		cmd      string
+		unitName string
+		skipped  bytes.Buffer
+	)
+
+	for _, job = range jobs {
+		err = exportJobError(job)
+		if err != nil {
+			fmt.Fprintf(&skipped, "# %s: skipped: %s\n", job.Name, err)
+			continue
+		}
+
+		timers, err = systemdTimers(job)
+		if err != nil {
+			fmt.Fprintf(&skipped, "# %s: skipped: %s\n", job.Name, err)
+			continue
+		}
+
+		unitName = `karajo-` + job.ID
+
+		var timerUnit bytes.Buffer
+
+		timerUnit.WriteString("[Unit]\n")
+		timerUnit.WriteString("Description=Timer for karajo job " + job.Name + "\n")
+		timerUnit.WriteString("\n[Timer]\n")
+		for _, timer = range timers {
+			timerUnit.WriteString(timer + "\n")
+		}
+		timerUnit.WriteString("\n[Install]\nWantedBy=timers.target\n")
+
+		var svcUnit bytes.Buffer
+
+		svcUnit.WriteString("[Unit]\n")
+		svcUnit.WriteString("Description=karajo job " + job.Name + "\n")
+		svcUnit.WriteString("\n[Service]\n")
+		svcUnit.WriteString("Type=oneshot\n")
+		svcUnit.WriteString("WorkingDirectory=" + strings.ReplaceAll(job.dirWork, `%`, `%%`) + "\n")
+		svcUnit.WriteString("Environment=PATH=" + jobEnvPathValue + "\n")
+		for _, cmd = range job.PreCommands {
+			svcUnit.WriteString("ExecStartPre=" + systemdCommand(cmd, job.ContinueOnError) + "\n")
+		}
+		for _, cmd = range job.mainCommands() {
+			svcUnit.WriteString("ExecStart=" + systemdCommand(cmd, job.ContinueOnError) + "\n")
+		}
+		for _, cmd = range job.PostCommands {
+			// The SERVICE_RESULT is set by systemd on ExecStopPost.
+			cmd, _ = parseCommand(cmd, true)
+			cmd = jobEnvStatus + `=` + JobStatusFailed + `; ` +
+				`[ "$SERVICE_RESULT" = success ] && ` + jobEnvStatus + `=` + JobStatusSuccess + `; ` +
+				`export ` + jobEnvStatus + `; ` + cmd
+			svcUnit.WriteString("ExecStopPost=" + systemdCommand(jobCommandIgnoreError+cmd, true) + "\n")
+		}
+
+		files = append(files, ExportFile{
+			Name:    unitName + `.service`,
+			Content: svcUnit.Bytes(),
+		}, ExportFile{
+			Name:    unitName + `.timer`,
+			Content: timerUnit.Bytes(),
+		})
+	}
+	if skipped.Len() != 0 {
+		files = append(files, ExportFile{
+			Name:    `skipped`,
+			Content: skipped.Bytes(),
+		})
+	}
+	return files, nil
+}
+
+// systemdTimers convert the job schedule or interval into list of
+// options in systemd [Timer] section.
+func systemdTimers(job *JobExec) (list []string, err error) {
+	if len(job.Schedule) != 0 {
+		var sm *scheduleMatcher
+
+		sm, err = newScheduleMatcher(job.Schedule)
+		if err != nil {
+			return nil, err
+		}
+		return sm.systemdOnCalendar(), nil
+	}
+	if job.Interval <= 0 {
+		return nil, fmt.Errorf(`no schedule or interval`)
+	}
+
+	var secs = strconv.FormatInt(int64(job.Interval/time.Second), 10) + `s`
+
+	list = []string{
+		`OnActiveSec=` + secs,
+		`OnUnitActiveSec=` + secs,
+	}
+	return list, nil
+}
+
+// systemdOnCalendar return the schedule as list of OnCalendar option in
+// UTC.
+func (sm *scheduleMatcher) systemdOnCalendar() (list []string) {
+	switch sm.kind {
+	case libtime.ScheduleKindMinutely:
+		return []string{`OnCalendar=*-*-* *:*:00 UTC`}
+	case libtime.ScheduleKindHourly:
+		return []string{`OnCalendar=*-*-* *:` + joinSortedInts(sm.minutes, `,`, `%02d`) + `:00 UTC`}
+	}
+
+	var date = `*-*-*`
+
+	switch sm.kind {
+	case libtime.ScheduleKindWeekly:
+		var (
+			days []string
+			day  time.Weekday
+		)
+		for day = time.Sunday; day <= time.Saturday; day++ {
+			if sm.dow[day] {
+				days = append(days, day.String()[:3])
+			}
+		}
+		date = strings.Join(days, `,`) + ` ` + date
+	case libtime.ScheduleKindMonthly:
+		date = `*-*-` + joinSortedInts(sm.dom, `,`, `%02d`)
+	}
+
+	var (
+		tods = make([]int, 0, len(sm.tod))
+		tod  int
+	)
+	for tod = range sm.tod {
+		tods = append(tods, tod)
+	}
+	sort.Ints(tods)
+
+	for _, tod = range tods {
+		list = append(list, fmt.Sprintf(`OnCalendar=%s %02d:%02d:00 UTC`, date, tod/60, tod%60))
+	}
+	return list
+}
+
+// systemdCommand return the cmd as systemd command line executed by
+// shell.
+// If the command is allowed to fail, it is prefixed with "-".
+func systemdCommand(cmd string, isContinueOnError bool) string {
+	var isIgnoreError bool
+
+	cmd, isIgnoreError = parseCommand(cmd, isContinueOnError)
+	cmd = strings.ReplaceAll(cmd, `\`, `\\`)
+	cmd = strings.ReplaceAll(cmd, `'`, `\'`)
+	cmd = jobExportShell + `'` + systemdEscape(cmd) + `'`
+	if isIgnoreError {
+		return jobCommandIgnoreError + cmd
+	}
+	return cmd
+}
+
+// systemdEscape escape the specifier "%", the variable "$", and new line
+// in systemd command line.
+func systemdEscape(s string) string {
+	s = strings.ReplaceAll(s, `%`, `%%`)
+	s = strings.ReplaceAll(s, `$`, `$$`)
+	s = strings.ReplaceAll(s, "\n", `\n`)
+	return s
+}
+
+// joinSortedInts join the keys in set in ascending order using sep, with
+// each number formatted using format.
+func joinSortedInts(set map[int]bool, sep, format string) string {
+	var (
+		list = make([]string, 0, len(set))
+		keys = make([]int, 0, len(set))
+		n    int
+	)
+	for n = range set {
+		keys = append(keys, n)
+	}
+	sort.Ints(keys)
+	for _, n = range keys {
+		list = append(list, fmt.Sprintf(format, n))
+	}
+	return strings.Join(list, sep)
+}
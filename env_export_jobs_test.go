@@ -0,0 +1,172 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestScheduleMatcher_export(t *testing.T) {
+	type testCase struct {
+		schedule      string
+		expCrontab    []string
+		expOnCalendar []string
+	}
+
+	var cases = []testCase{{
+		schedule:      `minutely`,
+		expCrontab:    []string{`* * * * *`},
+		expOnCalendar: []string{`OnCalendar=*-*-* *:*:00 UTC`},
+	}, {
+		schedule:      `hourly@30,0,15`,
+		expCrontab:    []string{`0,15,30 * * * *`},
+		expOnCalendar: []string{`OnCalendar=*-*-* *:00,15,30:00 UTC`},
+	}, {
+		schedule: `daily@18:00,06:30,00:00`,
+		expCrontab: []string{
+			`0 0,18 * * *`,
+			`30 6 * * *`,
+		},
+		expOnCalendar: []string{
+			`OnCalendar=*-*-* 00:00:00 UTC`,
+			`OnCalendar=*-*-* 06:30:00 UTC`,
+			`OnCalendar=*-*-* 18:00:00 UTC`,
+		},
+	}, {
+		schedule:      `weekly@Friday,Sunday@15:00`,
+		expCrontab:    []string{`0 15 * * 0,5`},
+		expOnCalendar: []string{`OnCalendar=Sun,Fri *-*-* 15:00:00 UTC`},
+	}, {
+		schedule:      `monthly@15,1@18:00`,
+		expCrontab:    []string{`0 18 1,15 * *`},
+		expOnCalendar: []string{`OnCalendar=*-*-01,15 18:00:00 UTC`},
+	}}
+
+	var (
+		c   testCase
+		sm  *scheduleMatcher
+		err error
+	)
+	for _, c = range cases {
+		sm, err = newScheduleMatcher(c.schedule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, c.schedule+`: crontab`, c.expCrontab, sm.crontab())
+		test.Assert(t, c.schedule+`: OnCalendar`, c.expOnCalendar, sm.systemdOnCalendar())
+	}
+}
+
+func TestEnv_ExportJobs(t *testing.T) {
+	var env = Env{
+		DirBase: `/karajo`,
+		ExecJobs: map[string]*JobExec{
+			`Clean up`: {
+				JobBase: JobBase{
+					Schedule: `daily@01:00`,
+				},
+				PreCommands:  []string{`mkdir -p out`},
+				Commands:     []string{`find out -mtime +7 -delete`, `-rm -f 'a file' 100%`},
+				PostCommands: []string{`echo $KARAJO_JOB_STATUS`},
+			},
+			`every 15m`: {
+				JobBase: JobBase{
+					Interval: 15 * time.Minute,
+				},
+				Commands: []string{`date`},
+			},
+			`odd`: {
+				JobBase: JobBase{
+					Interval: 7 * time.Minute,
+				},
+				Commands: []string{`date`},
+			},
+			`webhook`: {
+				Path:     `/webhook`,
+				Commands: []string{`date`},
+			},
+		},
+	}
+
+	var (
+		files []ExportFile
+		err   error
+	)
+
+	files, err = env.ExportJobs(JobExportOptions{Format: JobExportFormatCrontab})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = `# Generated by karajo ` + Version + `.
+CRON_TZ=UTC
+PATH=` + jobEnvPathValue + `
+
+# Clean up
+0 1 * * * cd '/karajo/var/lib/karajo/job/clean_up' && /bin/sh -c 'mkdir -p out' && /bin/sh -c 'find out -mtime +7 -delete' && { /bin/sh -c 'rm -f '\''a file'\'' 100\%' || true; }; rc=$?; KARAJO_JOB_STATUS=failed; [ $rc -eq 0 ] && KARAJO_JOB_STATUS=success; export KARAJO_JOB_STATUS; { /bin/sh -c 'echo $KARAJO_JOB_STATUS' || true; }; exit $rc
+
+# every 15m
+*/15 * * * * cd '/karajo/var/lib/karajo/job/every_15m' && /bin/sh -c 'date'
+
+# odd
+# skipped: interval 7m0s cannot be expressed in crontab
+
+# webhook
+# skipped: no schedule or interval
+`
+	test.Assert(t, `crontab`, 1, len(files))
+	test.Assert(t, `crontab`, exp, string(files[0].Content))
+
+	files, err = env.ExportJobs(JobExportOptions{Format: JobExportFormatSystemd})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var names []string
+	var file ExportFile
+	for _, file = range files {
+		names = append(names, file.Name)
+	}
+	test.Assert(t, `systemd files`, []string{
+		`karajo-clean_up.service`,
+		`karajo-clean_up.timer`,
+		`karajo-every_15m.service`,
+		`karajo-every_15m.timer`,
+		`karajo-odd.service`,
+		`karajo-odd.timer`,
+		`skipped`,
+	}, names)
+
+	exp = `[Unit]
+Description=karajo job Clean up
+
+[Service]
+Type=oneshot
+WorkingDirectory=/karajo/var/lib/karajo/job/clean_up
+Environment=PATH=` + jobEnvPathValue + `
+ExecStartPre=/bin/sh -c 'mkdir -p out'
+ExecStart=/bin/sh -c 'find out -mtime +7 -delete'
+ExecStart=-/bin/sh -c 'rm -f \'a file\' 100%%'
+ExecStopPost=-/bin/sh -c 'KARAJO_JOB_STATUS=failed; [ "$$SERVICE_RESULT" = success ] && KARAJO_JOB_STATUS=success; export KARAJO_JOB_STATUS; echo $$KARAJO_JOB_STATUS'
+`
+	test.Assert(t, files[0].Name, exp, string(files[0].Content))
+
+	exp = `[Unit]
+Description=Timer for karajo job every 15m
+
+[Timer]
+OnActiveSec=900s
+OnUnitActiveSec=900s
+
+[Install]
+WantedBy=timers.target
+`
+	test.Assert(t, files[3].Name, exp, string(files[3].Content))
+
+	_, err = env.ExportJobs(JobExportOptions{Format: `launchd`})
+	test.Assert(t, `unknown format`, `ExportJobs: unknown format "launchd"`, err.Error())
+}
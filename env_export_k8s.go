@@ -0,0 +1,517 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"gopkg.in/yaml.v2"
+)
+
+// defK8sImage the default container image for exported CronJob.
+const defK8sImage = `alpine:3`
+
+// k8sFieldManager the field manager name on server-side apply.
+const k8sFieldManager = `karajo`
+
+// k8sMaxCronJobName the maximum length of CronJob name, since the Job
+// created from it append 11 characters suffix.
+const k8sMaxCronJobName = 52
+
+// k8sCronJob the manifest of Kubernetes CronJob in batch/v1.
+type k8sCronJob struct {
+	APIVersion string         `json:"apiVersion" yaml:"apiVersion"`
+	Kind       string         `json:"kind" yaml:"kind"`
+	Metadata   k8sMetadata    `json:"metadata" yaml:"metadata"`
+	Spec       k8sCronJobSpec `json:"spec" yaml:"spec"`
+}
+
+type k8sMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty" yaml:"annotations,omitempty"`
+	Name        string            `json:"name" yaml:"name"`
+	Namespace   string            `json:"namespace,omitempty" yaml:"namespace,omitempty"`
+}
+
+type k8sCronJobSpec struct {
+	Schedule          string             `json:"schedule" yaml:"schedule"`
+	TimeZone          string             `json:"timeZone" yaml:"timeZone"`
+	ConcurrencyPolicy string             `json:"concurrencyPolicy" yaml:"concurrencyPolicy"`
+	JobTemplate       k8sJobTemplateSpec `json:"jobTemplate" yaml:"jobTemplate"`
+}
+
+type k8sJobTemplateSpec struct {
+	Spec k8sJobSpec `json:"spec" yaml:"spec"`
+}
+
+type k8sJobSpec struct {
+	Template     k8sPodTemplateSpec `json:"template" yaml:"template"`
+	BackoffLimit int                `json:"backoffLimit" yaml:"backoffLimit"`
+}
+
+type k8sPodTemplateSpec struct {
+	Spec k8sPodSpec `json:"spec" yaml:"spec"`
+}
+
+type k8sPodSpec struct {
+	RestartPolicy string         `json:"restartPolicy" yaml:"restartPolicy"`
+	Containers    []k8sContainer `json:"containers" yaml:"containers"`
+}
+
+type k8sContainer struct {
+	Name    string   `json:"name" yaml:"name"`
+	Image   string   `json:"image" yaml:"image"`
+	Command []string `json:"command" yaml:"command"`
+}
+
+// exportK8s render the jobs as Kubernetes CronJob manifests in single
+// YAML file, separated by "---".
+func exportK8s(jobs []*JobExec, opts JobExportOptions) (files []ExportFile, err error) {
+	var (
+		cronJobs []*k8sCronJob
+		skipped  []string
+	)
+
+	cronJobs, skipped = newK8sCronJobs(jobs, opts)
+
+	var (
+		buf bytes.Buffer
+		v   string
+	)
+
+	buf.WriteString("# Generated by karajo " + Version + ".\n")
+	for _, v = range skipped {
+		buf.WriteString("# " + v + "\n")
+	}
+
+	var (
+		cj  *k8sCronJob
+		raw []byte
+	)
+	for _, cj = range cronJobs {
+		raw, err = yaml.Marshal(cj)
+		if err != nil {
+			return nil, err
+		}
+		buf.WriteString("---\n")
+		buf.Write(raw)
+	}
+
+	files = append(files, ExportFile{
+		Name:    `cronjobs.yaml`,
+		Content: buf.Bytes(),
+	})
+	return files, nil
+}
+
+// newK8sCronJobs convert each job into CronJob.
+// The job with multiple crontab lines is converted into multiple
+// CronJob, with index suffix in its name.
+// It also return the list of job that cannot be converted with the
+// reason.
+func newK8sCronJobs(jobs []*JobExec, opts JobExportOptions) (cronJobs []*k8sCronJob, skipped []string) {
+	if len(opts.Image) == 0 {
+		opts.Image = defK8sImage
+	}
+
+	var (
+		job    *JobExec
+		lines  []string
+		line   string
+		name   string
+		script string
+		x      int
+		err    error
+	)
+	for _, job = range jobs {
+		err = exportJobError(job)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf(`%s: skipped: %s`, job.Name, err))
+			continue
+		}
+
+		lines, err = crontabTimes(job)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf(`%s: skipped: %s`, job.Name, err))
+			continue
+		}
+
+		script = exportShellScript(job)
+		if len(script) == 0 {
+			script = `true`
+		}
+
+		for x, line = range lines {
+			name = k8sName(job.ID, x, len(lines))
+
+			var cj = &k8sCronJob{
+				APIVersion: `batch/v1`,
+				Kind:       `CronJob`,
+				Metadata: k8sMetadata{
+					Name:      name,
+					Namespace: opts.Namespace,
+					Labels: map[string]string{
+						`app.kubernetes.io/managed-by`: k8sFieldManager,
+					},
+					Annotations: map[string]string{
+						`karajo/job`: job.Name,
+					},
+				},
+				Spec: k8sCronJobSpec{
+					Schedule: line,
+					TimeZone: `UTC`,
+					// The karajo never run the same job
+					// concurrently.
+					ConcurrencyPolicy: `Forbid`,
+					JobTemplate: k8sJobTemplateSpec{
+						Spec: k8sJobSpec{
+							Template: k8sPodTemplateSpec{
+								Spec: k8sPodSpec{
+									RestartPolicy: `Never`,
+									Containers: []k8sContainer{{
+										Name:    `job`,
+										Image:   opts.Image,
+										Command: []string{`/bin/sh`, `-c`, script},
+									}},
+								},
+							},
+						},
+					},
+				},
+			}
+			cronJobs = append(cronJobs, cj)
+		}
+	}
+	return cronJobs, skipped
+}
+
+// k8sName convert the job ID into valid CronJob name, prefixed with
+// "karajo-".
+// If the job has more than one CronJob, the name is suffixed with "-x".
+func k8sName(id string, x, total int) string {
+	var sb strings.Builder
+
+	sb.WriteString(`karajo-`)
+	var r rune
+	for _, r = range strings.ToLower(id) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			sb.WriteRune(r)
+		} else {
+			sb.WriteByte('-')
+		}
+	}
+
+	var (
+		name   = sb.String()
+		suffix string
+	)
+	if total > 1 {
+		suffix = `-` + strconv.Itoa(x)
+	}
+	if len(name)+len(suffix) > k8sMaxCronJobName {
+		name = name[:k8sMaxCronJobName-len(suffix)]
+	}
+	name = strings.TrimRight(name, `-`)
+	return name + suffix
+}
+
+// ApplyJobsK8s create or update the Kubernetes CronJob of each JobExec
+// in the cluster using server-side apply, with the cluster and credential
+// from the current context in kubeconfig.
+// If kubeconfig is empty, it will use the environment variable
+// KUBECONFIG or "$HOME/.kube/config".
+// If opts.Namespace is empty, it will use the namespace in the context
+// or "default".
+//
+// It return the name of applied CronJobs.
+func (env *Env) ApplyJobsK8s(opts JobExportOptions, kubeconfig string) (names []string, err error) {
+	var logp = `ApplyJobsK8s`
+
+	var kc *kubeClient
+
+	kc, err = newKubeClient(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if len(opts.Namespace) == 0 {
+		opts.Namespace = kc.namespace
+	}
+
+	var jobs []*JobExec
+
+	jobs, err = env.loadExportedJobs()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		cronJobs, _ = newK8sCronJobs(jobs, opts)
+		cj          *k8sCronJob
+	)
+	for _, cj = range cronJobs {
+		err = kc.applyCronJob(cj)
+		if err != nil {
+			return names, fmt.Errorf(`%s: %s: %w`, logp, cj.Metadata.Name, err)
+		}
+		names = append(names, cj.Metadata.Name)
+	}
+	return names, nil
+}
+
+// kubeConfig the subset of kubeconfig file.
+type kubeConfig struct {
+	CurrentContext string `yaml:"current-context"`
+	Clusters       []struct {
+		Name    string `yaml:"name"`
+		Cluster struct {
+			Server                   string `yaml:"server"`
+			CertificateAuthority     string `yaml:"certificate-authority"`
+			CertificateAuthorityData string `yaml:"certificate-authority-data"`
+			InsecureSkipTLSVerify    bool   `yaml:"insecure-skip-tls-verify"`
+		} `yaml:"cluster"`
+	} `yaml:"clusters"`
+	Users []struct {
+		Name string `yaml:"name"`
+		User struct {
+			Token                 string `yaml:"token"`
+			TokenFile             string `yaml:"tokenFile"`
+			ClientCertificate     string `yaml:"client-certificate"`
+			ClientCertificateData string `yaml:"client-certificate-data"`
+			ClientKey             string `yaml:"client-key"`
+			ClientKeyData         string `yaml:"client-key-data"`
+		} `yaml:"user"`
+	} `yaml:"users"`
+	Contexts []struct {
+		Name    string `yaml:"name"`
+		Context struct {
+			Cluster   string `yaml:"cluster"`
+			User      string `yaml:"user"`
+			Namespace string `yaml:"namespace"`
+		} `yaml:"context"`
+	} `yaml:"contexts"`
+}
+
+// kubeClient the minimal Kubernetes API client for applying CronJob.
+type kubeClient struct {
+	httpc     *http.Client
+	server    string
+	token     string
+	namespace string
+}
+
+// newKubeClient create the kubeClient from the current context in
+// kubeconfig file.
+func newKubeClient(file string) (kc *kubeClient, err error) {
+	if len(file) == 0 {
+		file = os.Getenv(`KUBECONFIG`)
+		if len(file) == 0 {
+			var home string
+			home, err = os.UserHomeDir()
+			if err != nil {
+				return nil, err
+			}
+			file = filepath.Join(home, `.kube`, `config`)
+		}
+		// The KUBECONFIG may contains list of files, use the
+		// first one.
+		file = filepath.SplitList(file)[0]
+	}
+
+	var raw []byte
+
+	raw, err = os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var cfg kubeConfig
+
+	err = yaml.Unmarshal(raw, &cfg)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, file, err)
+	}
+
+	var (
+		dir        = filepath.Dir(file)
+		clusterIdx = -1
+		userIdx    = -1
+		x          int
+	)
+
+	kc = &kubeClient{
+		namespace: `default`,
+	}
+
+	for x = range cfg.Contexts {
+		if cfg.Contexts[x].Name != cfg.CurrentContext {
+			continue
+		}
+		var kctx = cfg.Contexts[x].Context
+		if len(kctx.Namespace) != 0 {
+			kc.namespace = kctx.Namespace
+		}
+		for x = range cfg.Clusters {
+			if cfg.Clusters[x].Name == kctx.Cluster {
+				clusterIdx = x
+			}
+		}
+		for x = range cfg.Users {
+			if cfg.Users[x].Name == kctx.User {
+				userIdx = x
+			}
+		}
+		break
+	}
+	if clusterIdx < 0 {
+		return nil, fmt.Errorf(`%s: cluster for context %q not found`, file, cfg.CurrentContext)
+	}
+
+	var (
+		cluster   = cfg.Clusters[clusterIdx].Cluster
+		tlsConfig = &tls.Config{
+			InsecureSkipVerify: cluster.InsecureSkipTLSVerify,
+		}
+		pem []byte
+	)
+
+	kc.server = strings.TrimRight(cluster.Server, `/`)
+
+	pem, err = kubeConfigData(dir, cluster.CertificateAuthority, cluster.CertificateAuthorityData)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: certificate-authority: %w`, file, err)
+	}
+	if len(pem) != 0 {
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf(`%s: certificate-authority: invalid PEM`, file)
+		}
+	}
+
+	if userIdx >= 0 {
+		var user = cfg.Users[userIdx].User
+
+		kc.token = user.Token
+		if len(kc.token) == 0 && len(user.TokenFile) != 0 {
+			pem, err = kubeConfigData(dir, user.TokenFile, ``)
+			if err != nil {
+				return nil, fmt.Errorf(`%s: tokenFile: %w`, file, err)
+			}
+			kc.token = strings.TrimSpace(string(pem))
+		}
+
+		var certPEM, keyPEM []byte
+
+		certPEM, err = kubeConfigData(dir, user.ClientCertificate, user.ClientCertificateData)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: client-certificate: %w`, file, err)
+		}
+		keyPEM, err = kubeConfigData(dir, user.ClientKey, user.ClientKeyData)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: client-key: %w`, file, err)
+		}
+		if len(certPEM) != 0 {
+			var cert tls.Certificate
+
+			cert, err = tls.X509KeyPair(certPEM, keyPEM)
+			if err != nil {
+				return nil, fmt.Errorf(`%s: client certificate: %w`, file, err)
+			}
+			tlsConfig.Certificates = []tls.Certificate{cert}
+		}
+	}
+
+	kc.httpc = &http.Client{
+		Timeout: time.Minute,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: tlsConfig,
+		},
+	}
+	return kc, nil
+}
+
+// kubeConfigData return the base64 decoded data, or the content of file
+// relative to dir.
+func kubeConfigData(dir, file, data string) ([]byte, error) {
+	if len(data) != 0 {
+		return base64.StdEncoding.DecodeString(data)
+	}
+	if len(file) == 0 {
+		return nil, nil
+	}
+	if !filepath.IsAbs(file) {
+		file = filepath.Join(dir, file)
+	}
+	return os.ReadFile(file)
+}
+
+// applyCronJob create or update the CronJob using server-side apply.
+func (kc *kubeClient) applyCronJob(cj *k8sCronJob) (err error) {
+	var body []byte
+
+	body, err = json.Marshal(cj)
+	if err != nil {
+		return err
+	}
+
+	var (
+		q = url.Values{}
+
+		apiPath = fmt.Sprintf(`%s/apis/batch/v1/namespaces/%s/cronjobs/%s`,
+			kc.server, url.PathEscape(cj.Metadata.Namespace), url.PathEscape(cj.Metadata.Name))
+	)
+	q.Set(`fieldManager`, k8sFieldManager)
+	q.Set(`force`, `true`)
+
+	var req *http.Request
+
+	req, err = http.NewRequest(http.MethodPatch, apiPath+`?`+q.Encode(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set(libhttp.HeaderContentType, `application/apply-patch+yaml`)
+	req.Header.Set(libhttp.HeaderAccept, libhttp.ContentTypeJSON)
+	if len(kc.token) != 0 {
+		req.Header.Set(libhttp.HeaderAuthorization, `Bearer `+kc.token)
+	}
+
+	var res *http.Response
+
+	res, err = kc.httpc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+
+	body, err = io.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if res.StatusCode == http.StatusOK || res.StatusCode == http.StatusCreated {
+		return nil
+	}
+
+	var status struct {
+		Message string `json:"message"`
+	}
+	_ = json.Unmarshal(body, &status)
+	if len(status.Message) == 0 {
+		status.Message = string(bytes.TrimSpace(body))
+	}
+	return fmt.Errorf(`%s: %s`, res.Status, status.Message)
+}
@@ -0,0 +1,239 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestK8sName(t *testing.T) {
+	type testCase struct {
+		id    string
+		exp   string
+		x     int
+		total int
+	}
+
+	var cases = []testCase{{
+		id:    `backup_db`,
+		total: 1,
+		exp:   `karajo-backup-db`,
+	}, {
+		id:    `backup_db`,
+		x:     1,
+		total: 2,
+		exp:   `karajo-backup-db-1`,
+	}, {
+		id:    strings.Repeat(`a`, 44) + `_b`,
+		x:     1,
+		total: 2,
+		exp:   `karajo-` + strings.Repeat(`a`, 43) + `-1`,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.id, c.exp, k8sName(c.id, c.x, c.total))
+	}
+}
+
+func TestEnv_ExportJobs_k8s(t *testing.T) {
+	var env = Env{
+		DirBase: t.TempDir(),
+		ExecJobs: map[string]*JobExec{
+			`every 15m`: {
+				JobBase: JobBase{
+					Interval: 15 * time.Minute,
+				},
+				Commands: []string{`date`},
+			},
+			`webhook`: {
+				Path:     `/webhook`,
+				Commands: []string{`date`},
+			},
+		},
+	}
+
+	var (
+		opts = JobExportOptions{
+			Format:    JobExportFormatK8s,
+			Namespace: `ops`,
+		}
+		files []ExportFile
+		err   error
+	)
+
+	files, err = env.ExportJobs(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = `# Generated by karajo ` + Version + `.
+# webhook: skipped: no schedule or interval
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  labels:
+    app.kubernetes.io/managed-by: karajo
+  annotations:
+    karajo/job: every 15m
+  name: karajo-every-15m
+  namespace: ops
+spec:
+  schedule: '*/15 * * * *'
+  timeZone: UTC
+  concurrencyPolicy: Forbid
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+          containers:
+          - name: job
+            image: alpine:3
+            command:
+            - /bin/sh
+            - -c
+            - /bin/sh -c 'date'
+      backoffLimit: 0
+`
+	test.Assert(t, `cronjobs.yaml`, exp, string(files[0].Content))
+}
+
+func TestEnv_ApplyJobsK8s(t *testing.T) {
+	type request struct {
+		method      string
+		path        string
+		query       string
+		auth        string
+		contentType string
+		image       string
+		schedule    string
+	}
+
+	var (
+		mu   sync.Mutex
+		reqs []request
+	)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var (
+			body, _ = io.ReadAll(r.Body)
+			cj      k8sCronJob
+		)
+		_ = json.Unmarshal(body, &cj)
+
+		mu.Lock()
+		reqs = append(reqs, request{
+			method:      r.Method,
+			path:        r.URL.Path,
+			query:       r.URL.RawQuery,
+			auth:        r.Header.Get(`Authorization`),
+			contentType: r.Header.Get(`Content-Type`),
+			image:       cj.Spec.JobTemplate.Spec.Template.Spec.Containers[0].Image,
+			schedule:    cj.Spec.Schedule,
+		})
+		mu.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, `/karajo-forbidden`) {
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"kind":"Status","message":"cronjobs is forbidden"}`))
+			return
+		}
+		_, _ = w.Write(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		kubeconfig = filepath.Join(t.TempDir(), `config`)
+		rawConfig  = `apiVersion: v1
+kind: Config
+current-context: test
+clusters:
+- name: local
+  cluster:
+    server: ` + srv.URL + `
+contexts:
+- name: test
+  context:
+    cluster: local
+    user: admin
+    namespace: batch
+users:
+- name: admin
+  user:
+    token: s3cret
+`
+		err error
+	)
+
+	err = os.WriteFile(kubeconfig, []byte(rawConfig), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env = Env{
+		DirBase: t.TempDir(),
+		ExecJobs: map[string]*JobExec{
+			`Backup DB`: {
+				JobBase: JobBase{
+					Schedule: `daily@01:00`,
+				},
+				Commands: []string{`pg_dump db`},
+			},
+		},
+	}
+
+	var (
+		opts = JobExportOptions{
+			Image: `postgres:16`,
+		}
+		names []string
+	)
+
+	names, err = env.ApplyJobsK8s(opts, kubeconfig)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `names`, []string{`karajo-backup-db`}, names)
+
+	var expReqs = []request{{
+		method:      http.MethodPatch,
+		path:        `/apis/batch/v1/namespaces/batch/cronjobs/karajo-backup-db`,
+		query:       `fieldManager=karajo&force=true`,
+		auth:        `Bearer s3cret`,
+		contentType: `application/apply-patch+yaml`,
+		image:       `postgres:16`,
+		schedule:    `0 1 * * *`,
+	}}
+	test.Assert(t, `requests`, expReqs, reqs)
+
+	env = Env{
+		DirBase: t.TempDir(),
+		ExecJobs: map[string]*JobExec{
+			`forbidden`: {
+				JobBase: JobBase{
+					Interval: time.Hour,
+				},
+				Commands: []string{`date`},
+			},
+		},
+	}
+
+	_, err = env.ApplyJobsK8s(opts, kubeconfig)
+	test.Assert(t, `error`,
+		`ApplyJobsK8s: karajo-forbidden: 403 Forbidden: cronjobs is forbidden`,
+		err.Error())
+}
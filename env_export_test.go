@@ -0,0 +1,109 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_export(t *testing.T) {
+	var (
+		config = `
+[karajo]
+secret = envs3cret
+queue_address = redis://:redisp4ss@127.0.0.1
+
+[template "hello"]
+command = echo {{name}}
+
+[job "hello"]
+template = hello
+param.name = world
+secret = jobs3cret
+
+[job.http "ping"]
+http_url = http://127.0.0.1/ping
+http_header = Authorization: Bearer t0ken
+http_header = Accept: text/plain
+`
+		secrets = []string{
+			`envs3cret`, `redisp4ss`, `s3p4ss`, `jobs3cret`, `t0ken`,
+		}
+
+		env *Env
+		err error
+	)
+
+	env, err = ParseEnv([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.DirBase = t.TempDir()
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The storage is set after init to prevent connecting to it.
+	env.Storage = map[string]EnvStorage{
+		storageNameLogs: {
+			Kind:      storageKindS3,
+			SecretKey: `s3p4ss`,
+		},
+	}
+
+	var (
+		k = &Karajo{env: env}
+
+		format string
+		secret string
+		got    string
+		rec    *httptest.ResponseRecorder
+		epr    *libhttp.EndpointRequest
+	)
+	for _, format = range []string{``, `json`, `xml`} {
+		rec = httptest.NewRecorder()
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiEnvExport+`?format=`+format, nil),
+		}
+		_ = epr.HTTPRequest.ParseForm()
+
+		_, err = k.apiEnvExport(epr)
+		if err != nil {
+			test.Assert(t, `invalid format`, `invalid format "xml"`, err.Error())
+			continue
+		}
+
+		got = rec.Body.String()
+		for _, secret = range secrets {
+			test.Assert(t, format+`: `+secret, false, strings.Contains(got, secret))
+		}
+		if format == `json` {
+			continue
+		}
+
+		var exps = []string{
+			"secret = <redacted>\n",
+			"queue_address = <redacted>\n",
+			"secret_key = <redacted>\n",
+			"http_header = Authorization: <redacted>\n",
+			"http_header = Accept: <redacted>\n",
+			"command = echo world\n",
+		}
+		var exp string
+		for _, exp = range exps {
+			test.Assert(t, exp, true, strings.Contains(got, exp))
+		}
+		test.Assert(t, `template removed`, false,
+			strings.Contains(got, "template = hello\n"))
+	}
+}
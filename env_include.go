@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+)
+
+// openConfigInclude open the configuration file and merge all of the
+// files in its "include" option, recursively.
+//
+// Each include is a glob pattern, relative to the directory of file that
+// include it if its not absolute.
+// The matched files are merged after the file that include it, in
+// lexical order, so the option with single value in the included file
+// override the one in the parent file.
+//
+// It will return an error if the file include itself, directly or through
+// other included files.
+func openConfigInclude(file string) (cfg *ini.Ini, err error) {
+	return openConfigIncludeStack(file, nil)
+}
+
+func openConfigIncludeStack(file string, stack []string) (cfg *ini.Ini, err error) {
+	var absFile string
+
+	absFile, err = filepath.Abs(file)
+	if err != nil {
+		return nil, err
+	}
+	if slices.Contains(stack, absFile) {
+		return nil, fmt.Errorf(`include cycle: %s -> %s`,
+			strings.Join(stack, ` -> `), absFile)
+	}
+	stack = append(stack, absFile)
+
+	cfg, err = openConfig(file)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		dir      = filepath.Dir(file)
+		patterns = cfg.Gets(`karajo`, ``, `include`)
+
+		pattern string
+		matches []string
+		match   string
+		incCfg  *ini.Ini
+	)
+	for _, pattern = range patterns {
+		pattern = strings.TrimSpace(expandEnv(pattern))
+		if len(pattern) == 0 {
+			continue
+		}
+		if !filepath.IsAbs(pattern) {
+			pattern = filepath.Join(dir, pattern)
+		}
+
+		matches, err = filepath.Glob(pattern)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: include %q: %w`, file, pattern, err)
+		}
+		sort.Strings(matches)
+
+		for _, match = range matches {
+			incCfg, err = openConfigIncludeStack(match, stack)
+			if err != nil {
+				return nil, err
+			}
+			cfg.Rebase(incCfg)
+		}
+	}
+	return cfg, nil
+}
@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestLoadEnv_include(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		files = map[string]string{
+			`karajo.conf`: `
+[karajo]
+name = main
+max_job_running = 2
+include = conf.d/*.conf
+`,
+			`conf.d/10-notif.conf`: `
+[notif "email"]
+kind = email
+
+[karajo]
+include = ../shared/*.conf
+`,
+			`conf.d/20-override.conf`: `
+[karajo]
+max_job_running = 4
+`,
+			`conf.d/ignored.txt`: `
+[karajo]
+name = ignored
+`,
+			`shared/queue.conf`: `
+[karajo]
+queue_backend = redis
+`,
+		}
+		name    string
+		content string
+		err     error
+	)
+
+	for name, content = range files {
+		var path = filepath.Join(dir, name)
+		err = os.MkdirAll(filepath.Dir(path), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(path, []byte(content), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var env *Env
+
+	env, err = LoadEnv(filepath.Join(dir, `karajo.conf`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, `Name`, `main`, env.Name)
+	test.Assert(t, `MaxJobRunning`, 4, env.MaxJobRunning)
+	test.Assert(t, `QueueBackend`, `redis`, env.QueueBackend)
+	test.Assert(t, `Notif`, `email`, env.Notif[`email`].Kind)
+
+	// Include cycle.
+	err = os.WriteFile(filepath.Join(dir, `shared/queue.conf`),
+		[]byte("[karajo]\ninclude = ../karajo.conf\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = LoadEnv(filepath.Join(dir, `karajo.conf`))
+	test.Assert(t, `cycle`, true, err != nil &&
+		strings.Contains(err.Error(), `include cycle: `))
+}
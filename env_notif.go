@@ -7,12 +7,19 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 )
 
 const (
-	notifKindEmail = `email`
+	notifKindEmail  = `email`
+	notifKindGotify = `gotify`
+	notifKindNtfy   = `ntfy`
 )
 
+// defNotifAttachLogMaxSize the default maximum size of log attached to
+// the email, 10 MiB.
+const defNotifAttachLogMaxSize = 10 << 20
+
 // EnvNotif environment for notification.
 type EnvNotif struct {
 	Name         string
@@ -22,29 +29,91 @@ type EnvNotif struct {
 	SMTPPassword string   `ini:"::smtp_password"`
 	From         string   `ini:"::from"`
 	To           []string `ini:"::to"`
-	SMTPInsecure bool     `ini:"::smtp_insecure"`
+
+	// ServerURL the URL of ntfy or Gotify server, for example
+	// "https://ntfy.sh".
+	ServerURL string `ini:"::server_url"`
+
+	// Topic the ntfy topic where the message published.
+	Topic string `ini:"::topic"`
+
+	// Token the access token for ntfy, or the application token for
+	// Gotify.
+	Token string `ini:"::token"`
+
+	// Priority define the message priority for ntfy or Gotify based on
+	// the job status, in the format "<status>:<priority>", for example
+	// "failed:5".
+	Priority []string `ini:"::priority"`
+
+	// AttachLogMaxSize define the maximum size of log, before
+	// compressed, that is attached to the email.
+	// If the log is larger, only the last AttachLogMaxSize bytes are
+	// attached.
+	// Default to 10 MiB.
+	AttachLogMaxSize int64 `ini:"::attach_log_max_size"`
+
+	// MaxConcurrent define the maximum number of notifications send at
+	// the same time.
+	// Default to 1, the notifications are send one by one in order.
+	MaxConcurrent int `ini:"::max_concurrent"`
+
+	SMTPInsecure bool `ini:"::smtp_insecure"`
+
+	// AttachLog if true, the job log is attached to the email as gzip
+	// file, and the email body only contains the summary of job and
+	// the last lines of log.
+	AttachLog bool `ini:"::attach_log"`
 }
 
 // init initialize the envNotif.
+// Each "${NAME}" in SMTP settings is replaced with the value of system
+// environment variable NAME.
+// For backward compatibility, if SMTPUser or SMTPPassword start with "$"
+// the whole value is read from system environment.
 func (envNotif *EnvNotif) init() {
-	if envNotif.SMTPUser[0] == '$' {
-		envNotif.SMTPUser = os.Getenv(envNotif.SMTPUser)
+	envNotif.SMTPServer = expandEnv(envNotif.SMTPServer)
+	envNotif.SMTPUser = expandEnvLegacy(envNotif.SMTPUser)
+	envNotif.SMTPPassword = expandEnvLegacy(envNotif.SMTPPassword)
+	envNotif.From = expandEnv(envNotif.From)
+	envNotif.ServerURL = expandEnv(envNotif.ServerURL)
+	envNotif.Token = expandEnv(envNotif.Token)
+	if envNotif.AttachLogMaxSize <= 0 {
+		envNotif.AttachLogMaxSize = defNotifAttachLogMaxSize
 	}
-	if envNotif.SMTPPassword[0] == '$' {
-		envNotif.SMTPPassword = os.Getenv(envNotif.SMTPPassword)
+}
+
+// expandEnvLegacy read the value from system environment if its start
+// with "$" but not "${", otherwise it expand the value using expandEnv.
+func expandEnvLegacy(value string) string {
+	if len(value) > 1 && value[0] == '$' && value[1] != '{' {
+		return os.Getenv(value[1:])
 	}
+	return expandEnv(value)
 }
 
 // createClient create client for notification based on its kind.
 // It will return an error if kind is unknown or the client failed to created.
-func (envNotif *EnvNotif) createClient() (cl notifClient, err error) {
+func (envNotif *EnvNotif) createClient() (cl NotifClient, err error) {
 	var logp = `createClient`
 
 	switch envNotif.Kind {
 	case notifKindEmail:
 		cl, err = newClientSMTP(*envNotif)
+	case notifKindGotify:
+		cl, err = newClientGotify(*envNotif)
+	case notifKindNtfy:
+		cl, err = newClientNtfy(*envNotif)
 	default:
-		err = fmt.Errorf(`unknown kind %q`, envNotif.Kind)
+		var factory = customNotifKind(strings.ToLower(envNotif.Kind))
+		if factory == nil {
+			err = fmt.Errorf(`unknown kind %q`, envNotif.Kind)
+			break
+		}
+		cl, err = factory(*envNotif)
+		if err == nil && cl == nil {
+			err = fmt.Errorf(`kind %q: nil client`, envNotif.Kind)
+		}
 	}
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %s: %w`, logp, envNotif.Name, err)
@@ -54,3 +123,29 @@ func (envNotif *EnvNotif) createClient() (cl notifClient, err error) {
 
 	return cl, nil
 }
+
+// priorities parse the Priority into map of job status and its priority.
+// The status that is not defined use the priority in defPriority.
+func (envNotif *EnvNotif) priorities(defPriority map[string]string) (prio map[string]string, err error) {
+	prio = make(map[string]string, len(defPriority))
+
+	var (
+		entry  string
+		status string
+		value  string
+		found  bool
+	)
+	for status, value = range defPriority {
+		prio[status] = value
+	}
+	for _, entry = range envNotif.Priority {
+		status, value, found = strings.Cut(entry, `:`)
+		status = strings.ToLower(strings.TrimSpace(status))
+		value = strings.TrimSpace(value)
+		if !found || len(status) == 0 || len(value) == 0 {
+			return nil, fmt.Errorf(`invalid priority %q`, entry)
+		}
+		prio[status] = value
+	}
+	return prio, nil
+}
@@ -4,6 +4,7 @@
 package karajo
 
 import (
+	"errors"
 	"testing"
 
 	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
@@ -39,3 +40,102 @@ func TestEnvNotif_ParseEnv(t *testing.T) {
 
 	test.Assert(t, `Notif: ParseEnv`, string(expRawEnv), string(gotRawEnv))
 }
+
+func TestEnvNotif_init(t *testing.T) {
+	t.Setenv(`KARAJO_TEST_SMTP_HOST`, `mail.example.com`)
+	t.Setenv(`KARAJO_TEST_SMTP_USER`, `ops@example.com`)
+	t.Setenv(`KARAJO_TEST_SMTP_PASS`, `s3cret`)
+
+	var (
+		envNotif = EnvNotif{
+			SMTPServer:   `smtps://${KARAJO_TEST_SMTP_HOST}:465`,
+			SMTPUser:     `$KARAJO_TEST_SMTP_USER`,
+			SMTPPassword: `${KARAJO_TEST_SMTP_PASS}`,
+			From:         `Karajo <${KARAJO_TEST_SMTP_USER}>`,
+		}
+		exp = EnvNotif{
+			SMTPServer:   `smtps://mail.example.com:465`,
+			SMTPUser:     `ops@example.com`,
+			SMTPPassword: `s3cret`,
+			From:         `Karajo <ops@example.com>`,
+
+			AttachLogMaxSize: defNotifAttachLogMaxSize,
+		}
+	)
+
+	envNotif.init()
+
+	test.Assert(t, `init`, exp, envNotif)
+}
+
+func TestRegisterNotifKind(t *testing.T) {
+	var factory = func(envNotif EnvNotif) (NotifClient, error) {
+		if len(envNotif.ServerURL) == 0 {
+			return nil, errors.New(`empty server_url`)
+		}
+		return &fakeNotifClient{}, nil
+	}
+
+	type testCase struct {
+		factory  NotifClientFactory
+		name     string
+		expError string
+	}
+
+	var cases = []testCase{{
+		name:     ``,
+		factory:  factory,
+		expError: `RegisterNotifKind: empty name`,
+	}, {
+		name:     `Email`,
+		factory:  factory,
+		expError: `RegisterNotifKind: "email" is built-in`,
+	}, {
+		name:     `pager`,
+		expError: `RegisterNotifKind: "pager": nil factory`,
+	}, {
+		name:    `Pager`,
+		factory: factory,
+	}}
+
+	var (
+		c   testCase
+		err error
+	)
+	for _, c = range cases {
+		err = RegisterNotifKind(c.name, c.factory)
+		if err != nil {
+			test.Assert(t, c.name, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.name, c.expError, ``)
+	}
+	t.Cleanup(func() {
+		notifKindsMtx.Lock()
+		delete(notifKinds, `pager`)
+		notifKindsMtx.Unlock()
+	})
+
+	var (
+		envNotif = EnvNotif{
+			Name:      `oncall`,
+			Kind:      `PAGER`,
+			ServerURL: `http://127.0.0.1`,
+		}
+		cl NotifClient
+	)
+
+	cl, err = envNotif.createClient()
+	test.Assert(t, `createClient`, nil, err)
+	test.Assert(t, `createClient: client`, true, cl != nil)
+
+	envNotif.ServerURL = ``
+	_, err = envNotif.createClient()
+	test.Assert(t, `createClient: factory error`,
+		`createClient: oncall: empty server_url`, err.Error())
+
+	envNotif.Kind = `unknown`
+	_, err = envNotif.createClient()
+	test.Assert(t, `createClient: unknown kind`,
+		`createClient: oncall: unknown kind "unknown"`, err.Error())
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// headerLastModified the HTTP header that contains the time when the
+// resource last changed.
+const headerLastModified = `Last-Modified`
+
+// envRevision track the changes on the state of Env and its jobs, so the
+// client that poll the environment can check whether it has been changed
+// since the last request.
+type envRevision struct {
+	// modTime the time when the revision last changed.
+	modTime time.Time
+
+	// epoch the time when the revision created, to make the ETag
+	// different between restart.
+	epoch int64
+
+	// counter incremented each time the state changed.
+	counter int64
+
+	mtx sync.Mutex
+}
+
+// newEnvRevision create new envRevision start from the current time.
+func newEnvRevision() (rev *envRevision) {
+	var now = timeNow()
+
+	rev = &envRevision{
+		modTime: now,
+		epoch:   now.Unix(),
+	}
+	return rev
+}
+
+// bump increment the revision and return its new counter.
+// It will do nothing and return 0 if the rev is nil.
+func (rev *envRevision) bump() (counter int64) {
+	if rev == nil {
+		return 0
+	}
+	rev.mtx.Lock()
+	rev.counter++
+	rev.modTime = timeNow()
+	counter = rev.counter
+	rev.mtx.Unlock()
+	return counter
+}
+
+// current return the weak ETag and the last modification time of the
+// current revision.
+func (rev *envRevision) current() (etag string, modTime time.Time) {
+	rev.mtx.Lock()
+	etag = fmt.Sprintf(`W/"%x-%x"`, rev.epoch, rev.counter)
+	modTime = rev.modTime
+	rev.mtx.Unlock()
+	return etag, modTime
+}
+
+// state return the epoch and the counter of current revision.
+func (rev *envRevision) state() (epoch, counter int64) {
+	rev.mtx.Lock()
+	epoch = rev.epoch
+	counter = rev.counter
+	rev.mtx.Unlock()
+	return epoch, counter
+}
+
+// isNotModified return true if the request has header If-None-Match that
+// match with etag, or, if the request does not have If-None-Match, the
+// header If-Modified-Since is equal or after the modTime.
+func isNotModified(req *http.Request, etag string, modTime time.Time) bool {
+	var ifNoneMatch = req.Header.Get(libhttp.HeaderIfNoneMatch)
+	if len(ifNoneMatch) != 0 {
+		var (
+			tag = strings.TrimPrefix(etag, `W/`)
+
+			v string
+		)
+		for _, v = range strings.Split(ifNoneMatch, `,`) {
+			v = strings.TrimSpace(v)
+			if v == `*` || strings.TrimPrefix(v, `W/`) == tag {
+				return true
+			}
+		}
+		return false
+	}
+
+	var ifModifiedSince = req.Header.Get(libhttp.HeaderIfModifiedSince)
+	if len(ifModifiedSince) == 0 {
+		return false
+	}
+
+	var since, err = http.ParseTime(ifModifiedSince)
+	if err != nil {
+		return false
+	}
+	return !modTime.Truncate(time.Second).After(since)
+}
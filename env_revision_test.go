@@ -0,0 +1,123 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestIsNotModified(t *testing.T) {
+	type testCase struct {
+		desc            string
+		ifNoneMatch     string
+		ifModifiedSince string
+		exp             bool
+	}
+
+	var (
+		etag    = `W/"63bb5900-2"`
+		modTime = time.Date(2023, 1, 9, 0, 0, 0, 500, time.UTC)
+	)
+
+	var cases = []testCase{{
+		desc: `without conditional headers`,
+	}, {
+		desc:        `If-None-Match match`,
+		ifNoneMatch: etag,
+		exp:         true,
+	}, {
+		desc:        `If-None-Match match in list without weak prefix`,
+		ifNoneMatch: `"63bb5900-1", "63bb5900-2"`,
+		exp:         true,
+	}, {
+		desc:        `If-None-Match any`,
+		ifNoneMatch: `*`,
+		exp:         true,
+	}, {
+		desc:            `If-None-Match not match, If-Modified-Since ignored`,
+		ifNoneMatch:     `W/"63bb5900-1"`,
+		ifModifiedSince: `Mon, 09 Jan 2023 00:00:00 GMT`,
+	}, {
+		desc:            `If-Modified-Since equal`,
+		ifModifiedSince: `Mon, 09 Jan 2023 00:00:00 GMT`,
+		exp:             true,
+	}, {
+		desc:            `If-Modified-Since before`,
+		ifModifiedSince: `Sun, 08 Jan 2023 23:59:59 GMT`,
+	}, {
+		desc:            `If-Modified-Since invalid`,
+		ifModifiedSince: `yesterday`,
+	}}
+
+	var (
+		c   testCase
+		req *http.Request
+	)
+	for _, c = range cases {
+		req = httptest.NewRequest(http.MethodGet, apiEnv, nil)
+		if len(c.ifNoneMatch) != 0 {
+			req.Header.Set(libhttp.HeaderIfNoneMatch, c.ifNoneMatch)
+		}
+		if len(c.ifModifiedSince) != 0 {
+			req.Header.Set(libhttp.HeaderIfModifiedSince, c.ifModifiedSince)
+		}
+		test.Assert(t, c.desc, c.exp, isNotModified(req, etag, modTime))
+	}
+}
+
+func TestKarajo_apiEnvNotModified(t *testing.T) {
+	var (
+		k = &Karajo{
+			env: &Env{
+				revision: newEnvRevision(),
+			},
+		}
+
+		epr  *libhttp.EndpointRequest
+		rec  *httptest.ResponseRecorder
+		etag string
+		got  []byte
+		err  error
+	)
+
+	var doRequest = func(ifNoneMatch string) {
+		rec = httptest.NewRecorder()
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiEnv, nil),
+		}
+		if len(ifNoneMatch) != 0 {
+			epr.HTTPRequest.Header.Set(libhttp.HeaderIfNoneMatch, ifNoneMatch)
+		}
+		got, err = k.apiEnv(epr)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	doRequest(``)
+	etag = rec.Header().Get(libhttp.HeaderETag)
+	test.Assert(t, `ETag`, `W/"63bb5900-0"`, etag)
+	test.Assert(t, `Last-Modified`, `Mon, 09 Jan 2023 00:00:00 GMT`,
+		rec.Header().Get(headerLastModified))
+	test.Assert(t, `has body`, true, len(got) != 0)
+
+	doRequest(etag)
+	test.Assert(t, `unchanged: status`, http.StatusNotModified, rec.Code)
+	test.Assert(t, `unchanged: body`, 0, len(got))
+
+	k.env.revision.bump()
+
+	doRequest(etag)
+	test.Assert(t, `changed: status`, http.StatusOK, rec.Code)
+	test.Assert(t, `changed: ETag`, `W/"63bb5900-1"`,
+		rec.Header().Get(libhttp.HeaderETag))
+	test.Assert(t, `changed: has body`, true, len(got) != 0)
+}
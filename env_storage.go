@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// List of storage name.
+const (
+	storageNameLogs = `logs`
+)
+
+// List of storage kind.
+const (
+	storageKindS3 = `s3`
+)
+
+// EnvStorage environment for external storage.
+//
+// The storage configuration in INI format,
+//
+//	[storage "logs"]
+//	kind = s3
+//	endpoint = <URL>
+//	region = <string>
+//	bucket = <string>
+//	prefix = <string>
+//	access_key = <string>
+//	secret_key = <string>
+//	path_style = <bool>
+type EnvStorage struct {
+	Name string `ini:"-"`
+
+	// Kind of storage, "s3" or the custom kind registered using
+	// [RegisterStorageKind].
+	Kind string `ini:"::kind"`
+
+	// Endpoint the URL of S3-compatible server, for example
+	// "https://s3.us-east-1.amazonaws.com".
+	Endpoint string `ini:"::endpoint"`
+
+	// Region of bucket, default to "us-east-1".
+	Region string `ini:"::region"`
+
+	Bucket string `ini:"::bucket"`
+
+	// Prefix of object key, for example "karajo/".
+	Prefix string `ini:"::prefix"`
+
+	AccessKey string `ini:"::access_key"`
+	SecretKey string `ini:"::secret_key"`
+
+	// PathStyle if true the bucket is set in the URL path,
+	// "<endpoint>/<bucket>/<key>", instead of in the host name,
+	// "<bucket>.<endpoint host>/<key>".
+	// Most of self-hosted S3-compatible server require this option.
+	PathStyle bool `ini:"::path_style"`
+}
+
+// init initialize the EnvStorage.
+// Each "${NAME}" in the settings is replaced with the value of system
+// environment variable NAME.
+func (envStorage *EnvStorage) init() {
+	envStorage.Endpoint = expandEnv(envStorage.Endpoint)
+	envStorage.Bucket = expandEnv(envStorage.Bucket)
+	envStorage.AccessKey = expandEnv(envStorage.AccessKey)
+	envStorage.SecretKey = expandEnv(envStorage.SecretKey)
+	if len(envStorage.Region) == 0 {
+		envStorage.Region = defS3Region
+	}
+}
+
+// createLogStorage create the storage for job logs based on its kind.
+func (envStorage *EnvStorage) createLogStorage(timeout time.Duration) (storage Storage, err error) {
+	var logp = `createLogStorage`
+
+	switch envStorage.Kind {
+	case storageKindS3:
+		storage, err = newLogStorageS3(*envStorage, timeout)
+	default:
+		var factory = customStorageKind(strings.ToLower(envStorage.Kind))
+		if factory == nil {
+			err = fmt.Errorf(`unknown kind %q`, envStorage.Kind)
+			break
+		}
+		storage, err = factory(*envStorage, timeout)
+		if err == nil && storage == nil {
+			err = fmt.Errorf(`kind %q: nil storage`, envStorage.Kind)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, envStorage.Name, err)
+	}
+	return storage, nil
+}
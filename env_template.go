@@ -0,0 +1,165 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+)
+
+// templateParamPrefix the prefix of key for template parameter in the job
+// and template section.
+const templateParamPrefix = `param.`
+
+// templateParamRegex match the parameter "{{name}}" in the template
+// commands.
+var templateParamRegex = regexp.MustCompile(`\{\{\s*([A-Za-z0-9_.-]+)\s*\}\}`)
+
+// EnvTemplate define the list of commands that can be shared by
+// JobExec.
+//
+// The template configuration in INI format,
+//
+//	[template "name"]
+//	description = <string>
+//	command = <string>
+//	...
+//	param.<name> = <default value>
+//	...
+//
+// Each "{{<name>}}" in the commands is replaced with the value of
+// "param.<name>" in the JobExec that reference the template, or with the
+// default value in the template if the JobExec does not set it.
+type EnvTemplate struct {
+	// Params contains the default value of parameters, loaded from
+	// "param.<name>" keys.
+	Params map[string]string `ini:"-" json:"params,omitempty"`
+
+	Name        string   `ini:"-" json:"name"`
+	Description string   `ini:"::description" json:"description,omitempty"`
+	Commands    []string `ini:"::command" json:"commands,omitempty"`
+}
+
+// render return the template commands with each parameter replaced by the
+// value in params or in the template Params.
+// It will return an error if one of the parameter does not have value.
+func (tmpl *EnvTemplate) render(params map[string]string) (cmds []string, err error) {
+	var (
+		cmd     string
+		missing string
+	)
+	for _, cmd = range tmpl.Commands {
+		cmd = templateParamRegex.ReplaceAllStringFunc(cmd, func(match string) string {
+			var (
+				name = strings.ToLower(templateParamRegex.FindStringSubmatch(match)[1])
+				val  string
+				ok   bool
+			)
+			val, ok = params[name]
+			if !ok {
+				val, ok = tmpl.Params[name]
+			}
+			if !ok && len(missing) == 0 {
+				missing = name
+			}
+			return val
+		})
+		if len(missing) != 0 {
+			return nil, fmt.Errorf(`template %q: missing param %q`, tmpl.Name, missing)
+		}
+		cmds = append(cmds, cmd)
+	}
+	return cmds, nil
+}
+
+// loadTemplateParams load the "param.<name>" keys in each section secName
+// into Params of template or JobExec.
+// The setParams is called for each sub-section that has at least one
+// parameter.
+func loadTemplateParams(cfg *ini.Ini, secName string, setParams func(subName string, params map[string]string)) {
+	var (
+		sec    *ini.Section
+		params map[string]string
+		vals   []string
+		key    string
+	)
+	for _, sec = range cfg.Subs(secName) {
+		params = nil
+		for key, vals = range cfg.AsMap(secName, sec.SubName()) {
+			if !strings.HasPrefix(key, templateParamPrefix) {
+				continue
+			}
+			if params == nil {
+				params = make(map[string]string)
+			}
+			params[strings.TrimPrefix(key, templateParamPrefix)] = vals[len(vals)-1]
+		}
+		if params != nil {
+			setParams(sec.SubName(), params)
+		}
+	}
+}
+
+// loadParams load the parameters of each template and JobExec, and the
+// steps and matrix of each JobExec, in cfg.
+func (env *Env) loadParams(cfg *ini.Ini) {
+	loadTemplateParams(cfg, `template`, func(subName string, params map[string]string) {
+		var tmpl = env.Templates[subName]
+		if tmpl != nil {
+			tmpl.Params = params
+		}
+	})
+	loadJobParams(cfg, env.ExecJobs)
+	loadJobSteps(cfg, env.ExecJobs)
+	loadJobMatrix(cfg, env.ExecJobs)
+}
+
+// loadJobParams load the template parameters of each JobExec in cfg.
+func loadJobParams(cfg *ini.Ini, jobs map[string]*JobExec) {
+	loadTemplateParams(cfg, `job`, func(subName string, params map[string]string) {
+		var job = jobs[subName]
+		if job != nil {
+			job.Params = params
+		}
+	})
+}
+
+// initTemplates initialize the templates and render the commands of each
+// JobExec that reference it.
+// The template commands are executed before the JobExec Commands.
+func (env *Env) initTemplates() (err error) {
+	var (
+		logp = `initTemplates`
+
+		tmpl *EnvTemplate
+		job  *JobExec
+		name string
+		cmds []string
+	)
+
+	for name, tmpl = range env.Templates {
+		tmpl.Name = name
+	}
+
+	for name, job = range env.ExecJobs {
+		job.Template = strings.TrimSpace(job.Template)
+		if len(job.Template) == 0 {
+			continue
+		}
+		tmpl = env.Templates[job.Template]
+		if tmpl == nil {
+			return fmt.Errorf(`%s: job %q: unknown template %q`, logp, name, job.Template)
+		}
+
+		cmds, err = tmpl.render(job.Params)
+		if err != nil {
+			return fmt.Errorf(`%s: job %q: %w`, logp, name, err)
+		}
+		job.Commands = append(cmds, job.Commands...)
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_initTemplates(t *testing.T) {
+	type testCase struct {
+		desc     string
+		config   string
+		expError string
+		exp      map[string][]string
+	}
+
+	var cases = []testCase{{
+		desc: `With params`,
+		config: `
+[template "backup"]
+command = pg_dump {{db}} > {{ dir }}/{{db}}.sql
+command = echo done
+param.dir = /var/backup
+
+[job "backup a"]
+template = backup
+param.db = a
+command = echo after
+
+[job "backup b"]
+template = backup
+param.db = b
+param.dir = /mnt/backup
+`,
+		exp: map[string][]string{
+			`backup a`: {
+				`pg_dump a > /var/backup/a.sql`,
+				`echo done`,
+				`echo after`,
+			},
+			`backup b`: {
+				`pg_dump b > /mnt/backup/b.sql`,
+				`echo done`,
+			},
+		},
+	}, {
+		desc: `Missing param`,
+		config: `
+[template "backup"]
+command = pg_dump {{db}}
+
+[job "backup a"]
+template = backup
+`,
+		expError: `init: initTemplates: job "backup a": template "backup": missing param "db"`,
+	}, {
+		desc: `Unknown template`,
+		config: `
+[job "backup a"]
+template = restore
+`,
+		expError: `init: initTemplates: job "backup a": unknown template "restore"`,
+	}}
+
+	var (
+		c   testCase
+		env *Env
+		err error
+	)
+	for _, c = range cases {
+		env, err = ParseEnv([]byte(c.config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.DirBase = t.TempDir()
+
+		err = env.init()
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+
+		var (
+			got  = map[string][]string{}
+			name string
+			job  *JobExec
+		)
+		for name, job = range env.ExecJobs {
+			got[name] = job.Commands
+		}
+		test.Assert(t, c.desc, c.exp, got)
+	}
+}
@@ -4,7 +4,12 @@
 package karajo
 
 import (
+	"bytes"
 	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
@@ -63,6 +68,7 @@ func TestEnv_loadJobs(t *testing.T) {
 			`Scheduler hourly 5m`: &JobExec{
 				JobBase: JobBase{
 					Schedule: `hourly@0,5,10,15,20,25,30,35,40,45,50,55`,
+					source:   `testdata/etc/karajo/job.d/scheduler_hourly_5m.conf`,
 				},
 				Path:   `/scheduler-hourly-5m`,
 				Secret: `s3cret`,
@@ -73,6 +79,7 @@ func TestEnv_loadJobs(t *testing.T) {
 			`Scheduler minutely`: &JobExec{
 				JobBase: JobBase{
 					Schedule: `minutely`,
+					source:   `testdata/etc/karajo/job.d/scheduler_minutely.conf`,
 				},
 				Secret: `s3cret`,
 				Path:   `/scheduler-minutely`,
@@ -81,6 +88,9 @@ func TestEnv_loadJobs(t *testing.T) {
 				},
 			},
 			`Test auth_kind github`: &JobExec{
+				JobBase: JobBase{
+					source: `testdata/etc/karajo/job.d/github.conf`,
+				},
 				AuthKind: `github`,
 				Path:     `/github`,
 				Secret:   `s3cret`,
@@ -89,6 +99,9 @@ func TestEnv_loadJobs(t *testing.T) {
 				},
 			},
 			`test success`: &JobExec{
+				JobBase: JobBase{
+					source: `testdata/etc/karajo/job.d/job_success.conf`,
+				},
 				Path:   `/test-success`,
 				Secret: `s3cret`,
 				Commands: []string{
@@ -107,6 +120,7 @@ func TestEnv_loadJobs(t *testing.T) {
 					NotifOnFailed: []string{
 						`email-to-shulhan`,
 					},
+					source: `testdata/etc/karajo/job.d/notif.conf`,
 				},
 				Path: `/notif-email-success`,
 				Commands: []string{
@@ -125,3 +139,274 @@ func TestEnv_loadJobs(t *testing.T) {
 
 	test.Assert(t, `loadJobs`, expJobs, env.ExecJobs)
 }
+
+func TestEnv_checkJobIDs(t *testing.T) {
+	var (
+		env = &Env{
+			file: `karajo.conf`,
+			ExecJobs: map[string]*JobExec{
+				`Test A`: &JobExec{},
+				`test-a`: &JobExec{
+					JobBase: JobBase{
+						source: `job.d/a.conf`,
+					},
+				},
+				`test_a`: &JobExec{},
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`test a`: &JobHTTP{},
+			},
+		}
+
+		expError = `checkJobIDs: duplicate job ID "test_a" defined in` +
+			` karajo.conf: [job "Test A"], karajo.conf: [job "test_a"]`
+
+		err error
+	)
+
+	err = env.checkJobIDs()
+	test.Assert(t, `strict`, expError, err.Error())
+
+	var strict bool
+	env.Strict = &strict
+
+	err = env.checkJobIDs()
+	test.Assert(t, `not strict`, nil, err)
+}
+
+func TestParseEnv_strict(t *testing.T) {
+	var (
+		content = []byte("[karajo]\nstrict = false\n")
+
+		env *Env
+		err error
+	)
+
+	env, err = ParseEnv(content)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `isStrict`, false, env.isStrict())
+}
+
+func TestExpandEnv(t *testing.T) {
+	type testCase struct {
+		value string
+		exp   string
+	}
+
+	t.Setenv(`KARAJO_TEST_SECRET`, `s3cret`)
+	t.Setenv(`KARAJO_TEST_HOST`, `127.0.0.1`)
+
+	var cases = []testCase{{
+		value: `plain`,
+		exp:   `plain`,
+	}, {
+		value: `${KARAJO_TEST_SECRET}`,
+		exp:   `s3cret`,
+	}, {
+		value: `http://${KARAJO_TEST_HOST}:31937/${KARAJO_TEST_SECRET}`,
+		exp:   `http://127.0.0.1:31937/s3cret`,
+	}, {
+		// Variable that is not set is kept as is.
+		value: `echo "sleep in ${x}s"`,
+		exp:   `echo "sleep in ${x}s"`,
+	}, {
+		// Variable without bracket is not expanded.
+		value: `echo $KARAJO_TEST_SECRET ${KARAJO_TEST_SECRET}`,
+		exp:   `echo $KARAJO_TEST_SECRET s3cret`,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.value, c.exp, expandEnv(c.value))
+	}
+}
+
+func TestEnv_indexJobs(t *testing.T) {
+	var (
+		jobA = &JobExec{JobBase: JobBase{ID: `job_a`}}
+		jobB = &JobHTTP{JobBase: JobBase{ID: `job_b`}}
+		env  = &Env{
+			ExecJobs: map[string]*JobExec{
+				`Job A`: jobA,
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`Job B`: jobB,
+			},
+		}
+	)
+
+	// Before indexed, the job is found by scanning the map.
+	test.Assert(t, `jobExec: not indexed`, jobA, env.jobExec(`job_a`))
+
+	env.indexJobs()
+
+	test.Assert(t, `jobExec`, jobA, env.jobExec(`job_a`))
+	test.Assert(t, `jobExec: unknown`, (*JobExec)(nil), env.jobExec(`job_b`))
+	test.Assert(t, `jobHTTP`, jobB, env.jobHTTP(`job_b`))
+	test.Assert(t, `jobHTTP: unknown`, (*JobHTTP)(nil), env.jobHTTP(`job_a`))
+}
+
+func TestEnv_addBuiltinJob(t *testing.T) {
+	type testCase struct {
+		userName string
+		expError string
+	}
+
+	var cases = []testCase{{
+		userName: `test`,
+	}, {
+		userName: defJobSummaryName,
+		expError: `job "karajo summary" in karajo.conf: [job "karajo summary"] is reserved for built-in job`,
+	}, {
+		userName: `karajo_summary`,
+		expError: `job "karajo_summary" in karajo.conf: [job "karajo summary"] is reserved for built-in job`,
+	}}
+
+	var (
+		c   testCase
+		env *Env
+		err error
+	)
+	for _, c = range cases {
+		env = &Env{
+			file: `karajo.conf`,
+			ExecJobs: map[string]*JobExec{
+				c.userName: &JobExec{},
+			},
+		}
+
+		err = env.addBuiltinJob(defJobSummaryName, newJobSummary(env))
+		if len(c.expError) != 0 {
+			test.Assert(t, c.userName, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.userName, nil, err)
+		test.Assert(t, c.userName+`: added`, true,
+			env.ExecJobs[defJobSummaryName] != nil)
+	}
+}
+
+func TestEnv_init_duplicateJobHTTPd(t *testing.T) {
+	var (
+		dirBase = t.TempDir()
+		dirJobd = filepath.Join(dirBase, `etc`, `karajo`, `job_http.d`)
+
+		err error
+	)
+
+	err = os.MkdirAll(dirJobd, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dirJobd, `a.conf`),
+		[]byte("[job.http \"Test A\"]\nhttp_url = http://127.0.0.1/a\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dirJobd, `b.conf`),
+		[]byte("[job.http \"test_a\"]\nhttp_url = http://127.0.0.1/b\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env = &Env{
+		DirBase: dirBase,
+		Secret:  `s3cret`,
+	}
+
+	err = env.init()
+	if err == nil {
+		t.Fatal(`expecting error on duplicate job ID`)
+	}
+
+	var expError = `init: checkJobIDs: duplicate job_http ID "test_a" defined in ` +
+		filepath.Join(dirJobd, `a.conf`) + `: [job.http "Test A"], ` +
+		filepath.Join(dirJobd, `b.conf`) + `: [job.http "test_a"]`
+	test.Assert(t, `error`, expError, err.Error())
+}
+
+func TestEnv_secretNotExposed(t *testing.T) {
+	const secret = `t0ps3cret`
+
+	t.Setenv(`KARAJO_TEST_SECRET`, secret)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(srv.Close)
+
+	var (
+		jobExec = &JobExec{
+			JobBase: JobBase{
+				Name: `Test secret`,
+			},
+			Commands: []string{
+				`test -n "${KARAJO_TEST_SECRET}"`,
+				`test "${KARAJO_TEST_SECRET}" != '${KARAJO_TEST_SECRET}'`,
+				`echo ${KARAJO_TEST_SECRET} | wc -c`,
+			},
+		}
+		jobHTTP = &JobHTTP{
+			JobBase: JobBase{
+				Name: `Test secret`,
+			},
+			HTTPURL: srv.URL + `/hook?token=${KARAJO_TEST_SECRET}`,
+		}
+		env = Env{
+			DirBase: t.TempDir(),
+			ExecJobs: map[string]*JobExec{
+				`test_secret`: jobExec,
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`test_secret`: jobHTTP,
+			},
+		}
+
+		jlog *JobLog
+		got  []byte
+		err  error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err = json.Marshal(&env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `env JSON contains secret`, false,
+		bytes.Contains(got, []byte(secret)))
+	test.Assert(t, `env JSON contains command`, true,
+		bytes.Contains(got, []byte(`echo ${KARAJO_TEST_SECRET} | wc -c`)))
+
+	got, err = json.Marshal(jobExec.dryRun())
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `dry run contains secret`, false,
+		bytes.Contains(got, []byte(secret)))
+	test.Assert(t, `dry run contains redacted env`, true,
+		bytes.Contains(got, []byte(`KARAJO_TEST_SECRET=***`)))
+
+	jlog, err = jobExec.execute(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var content, _ = jlog.read()
+	test.Assert(t, `JobExec log contains secret`, false,
+		bytes.Contains(content, []byte(secret)))
+
+	jlog, err = jobHTTP.execute()
+	if err != nil {
+		t.Fatal(err)
+	}
+	content, _ = jlog.read()
+	test.Assert(t, `JobHTTP log contains secret`, false,
+		bytes.Contains(content, []byte(secret)))
+	test.Assert(t, `JobHTTP log contains request`, true,
+		bytes.Contains(content, []byte(`/hook?token=${KARAJO_TEST_SECRET}`)))
+}
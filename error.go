@@ -9,48 +9,321 @@ import (
 	liberrors "git.sr.ht/~shulhan/pakakeh.go/lib/errors"
 )
 
+// List of error name, returned by the HTTP API in the field "name" of
+// the response body.
+// The names are stable and can be used by the API consumers to check the
+// kind of error.
+const (
+	ErrNameAuthLogin              = `ERR_AUTH_LOGIN`
+	ErrNameAuthCSRF               = `ERR_AUTH_CSRF`
+	ErrNameAuthLocked             = `ERR_AUTH_LOCKED`
+	ErrNameAuthOIDC               = `ERR_AUTH_OIDC`
+	ErrNameAuthTOTP               = `ERR_AUTH_TOTP`
+	ErrNameAuthTOTPNotEnabled     = `ERR_AUTH_TOTP_NOT_ENABLED`
+	ErrNameAuthEpoch              = `ERR_AUTH_EPOCH`
+	ErrNameAuthReplay             = `ERR_AUTH_REPLAY`
+	ErrNameAuthUnauthorized       = `ERR_AUTH_UNAUTHORIZED`
+	ErrNameNamespaceForbidden     = `ERR_NAMESPACE_FORBIDDEN`
+	ErrNameNamespaceQuotaRunning  = `ERR_NAMESPACE_QUOTA_RUNNING`
+	ErrNameNamespaceQuotaRuns     = `ERR_NAMESPACE_QUOTA_RUNS`
+	ErrNameNamespaceQuotaLog      = `ERR_NAMESPACE_QUOTA_LOG`
+	ErrNameReadOnly               = `ERR_READ_ONLY`
+	ErrNameRequestTooLarge        = `ERR_REQUEST_TOO_LARGE`
+	ErrNameRateLimited            = `ERR_RATE_LIMITED`
+	ErrNameJobAlreadyRun          = `ERR_JOB_ALREADY_RUN`
+	ErrNameJobApprovalNotFound    = `ERR_JOB_APPROVAL_NOT_FOUND`
+	ErrNameJobApprovalPending     = `ERR_JOB_APPROVAL_PENDING`
+	ErrNameJobApprovalSelf        = `ERR_JOB_APPROVAL_SELF`
+	ErrNameJobCanceled            = `ERR_JOB_CANCELED`
+	ErrNameJobDisabled            = `ERR_JOB_DISABLED`
+	ErrNameJobEmptyCommandsOrCall = `ERR_JOB_EMPTY_COMMANDS_OR_CALL`
+	ErrNameJobForbidden           = `ERR_JOB_FORBIDDEN`
+	ErrNameJobUserForbidden       = `ERR_JOB_USER_FORBIDDEN`
+	ErrNameJobPaused              = `ERR_JOB_PAUSED`
+	ErrNameArtifactNotFound       = `ERR_ARTIFACT_NOT_FOUND`
+	ErrNameInvalidAgentName       = `ERR_INVALID_AGENT_NAME`
+	ErrNameInvalidJobID           = `ERR_INVALID_JOB_ID`
+	ErrNameJobNotFound            = `ERR_JOB_NOT_FOUND`
+	ErrNameJobParam               = `ERR_JOB_PARAM`
+)
+
+// List of errors returned by the HTTP API, that can be checked using
+// [errors.Is] with the error returned by [Client], for example,
+//
+//	errors.Is(err, karajo.ErrJobPaused)
+//
+// The error is matched by its code and name, not by its message.
+var (
+	ErrAuthLogin              = exportError(errAuthLogin)
+	ErrAuthCSRF               = exportError(errAuthCSRF)
+	ErrAuthLocked             = exportError(errAuthLocked)
+	ErrAuthOIDC               = exportError(errAuthOIDC)
+	ErrAuthTOTP               = exportError(errAuthTOTP)
+	ErrAuthTOTPNotEnabled     = exportError(errAuthTOTPNotEnabled)
+	ErrAuthEpoch              = exportError(errAuthEpoch)
+	ErrAuthReplay             = exportError(errAuthReplay)
+	ErrAuthUnauthorized       = exportError(errAuthUnauthorized)
+	ErrNamespaceForbidden     = exportError(errNamespaceForbidden)
+	ErrNamespaceQuotaRunning  = exportError(errNamespaceQuotaRunning)
+	ErrNamespaceQuotaRuns     = exportError(errNamespaceQuotaRuns)
+	ErrNamespaceQuotaLog      = exportError(errNamespaceQuotaLog)
+	ErrReadOnly               = exportError(errReadOnly)
+	ErrRequestTooLarge        = exportError(errRequestTooLarge)
+	ErrRateLimited            = exportError(errRateLimited)
+	ErrJobAlreadyRun          = exportError(errJobAlreadyRun)
+	ErrJobApprovalNotFound    = exportError(errJobApprovalNotFound)
+	ErrJobApprovalPending     = exportError(errJobApprovalPending)
+	ErrJobApprovalSelf        = exportError(errJobApprovalSelf)
+	ErrJobCanceled            = exportError(errJobCanceled)
+	ErrJobDisabled            = exportError(errJobDisabled)
+	ErrJobEmptyCommandsOrCall = exportError(errJobEmptyCommandsOrCall)
+	ErrJobForbidden           = exportError(errJobForbidden)
+	ErrJobUserForbidden       = exportError(errJobUserForbidden)
+	ErrJobPaused              = exportError(errJobPaused)
+
+	ErrArtifactNotFound = &liberrors.E{
+		Code:    http.StatusNotFound,
+		Name:    ErrNameArtifactNotFound,
+		Message: `artifact not found`,
+	}
+	ErrInvalidAgentName = &liberrors.E{
+		Code:    http.StatusBadRequest,
+		Name:    ErrNameInvalidAgentName,
+		Message: `invalid or empty agent name`,
+	}
+	ErrInvalidJobID = &liberrors.E{
+		Code:    http.StatusBadRequest,
+		Name:    ErrNameInvalidJobID,
+		Message: `invalid or empty job id`,
+	}
+	ErrJobNotFound = &liberrors.E{
+		Code:    http.StatusNotFound,
+		Name:    ErrNameJobNotFound,
+		Message: `job not found`,
+	}
+	ErrJobParam = &liberrors.E{
+		Code:    http.StatusBadRequest,
+		Name:    ErrNameJobParam,
+		Message: `invalid job parameter`,
+	}
+)
+
+// exportError return the copy of internal error, so the exported error
+// cannot modify the one returned by server.
+func exportError(e liberrors.E) *liberrors.E {
+	return &liberrors.E{
+		Code:    e.Code,
+		Name:    e.Name,
+		Message: e.Message,
+	}
+}
+
 // errAuthLogin error for failed authentication due to invalid user or
 // password.
 var errAuthLogin = liberrors.E{
 	Code:    http.StatusBadRequest,
-	Name:    `ERR_AUTH_LOGIN`,
+	Name:    ErrNameAuthLogin,
 	Message: `invalid user name and/or password`,
 }
 
+// errAuthCSRF error for state-changing request with missing or invalid
+// CSRF token.
+var errAuthCSRF = liberrors.E{
+	Code:    http.StatusForbidden,
+	Name:    ErrNameAuthCSRF,
+	Message: `missing or invalid CSRF token`,
+}
+
+// errAuthLocked error for login that is locked due to too many failed
+// attempts.
+var errAuthLocked = liberrors.E{
+	Code:    http.StatusTooManyRequests,
+	Name:    ErrNameAuthLocked,
+	Message: `too many failed login attempts, try again later`,
+}
+
+// errAuthOIDC error when login using OpenID Connect failed.
+var errAuthOIDC = liberrors.E{
+	Code:    http.StatusUnauthorized,
+	Name:    ErrNameAuthOIDC,
+	Message: `OpenID Connect login failed`,
+}
+
+// errAuthTOTP error for login with missing or invalid TOTP code.
+var errAuthTOTP = liberrors.E{
+	Code:    http.StatusBadRequest,
+	Name:    ErrNameAuthTOTP,
+	Message: `invalid or missing TOTP code`,
+}
+
+// errAuthTOTPNotEnabled error when requesting TOTP provisioning for user
+// that does not have TOTP secret.
+var errAuthTOTPNotEnabled = liberrors.E{
+	Code:    http.StatusNotFound,
+	Name:    ErrNameAuthTOTPNotEnabled,
+	Message: `TOTP is not enabled for this user`,
+}
+
+// errAuthEpoch error for signed request with missing or invalid
+// "_karajo_epoch", or its value is outside of [Env.SignMaxAge] from the
+// server time.
+var errAuthEpoch = liberrors.E{
+	Code:    http.StatusUnauthorized,
+	Name:    ErrNameAuthEpoch,
+	Message: `invalid or expired epoch`,
+}
+
+// errAuthReplay error for signed request that has been accepted before.
+var errAuthReplay = liberrors.E{
+	Code:    http.StatusUnauthorized,
+	Name:    ErrNameAuthReplay,
+	Message: `request has been used`,
+}
+
+// errAuthUnauthorized error for request that require user session.
+var errAuthUnauthorized = liberrors.E{
+	Code:    http.StatusUnauthorized,
+	Name:    ErrNameAuthUnauthorized,
+	Message: `unauthorized`,
+}
+
+// errNamespaceForbidden error when the user that is member of namespaces
+// access the job outside of their namespaces.
+var errNamespaceForbidden = liberrors.E{
+	Code:    http.StatusForbidden,
+	Name:    ErrNameNamespaceForbidden,
+	Message: `forbidden, the job is not in your namespaces`,
+}
+
+// errNamespaceQuotaRunning error when the number of running jobs in
+// namespace reach its MaxRunning.
+var errNamespaceQuotaRunning = liberrors.E{
+	Code:    http.StatusTooManyRequests,
+	Name:    ErrNameNamespaceQuotaRunning,
+	Message: `namespace reach the maximum running jobs`,
+}
+
+// errNamespaceQuotaRuns error when the number of runs in namespace today
+// reach its MaxRunsPerDay.
+var errNamespaceQuotaRuns = liberrors.E{
+	Code:    http.StatusTooManyRequests,
+	Name:    ErrNameNamespaceQuotaRuns,
+	Message: `namespace reach the maximum runs per day`,
+}
+
+// errNamespaceQuotaLog error when the total size of logs in namespace
+// reach its MaxLogSize.
+var errNamespaceQuotaLog = liberrors.E{
+	Code:    http.StatusForbidden,
+	Name:    ErrNameNamespaceQuotaLog,
+	Message: `namespace reach the maximum log size`,
+}
+
+// errReadOnly error for request that change the state while the server is
+// in read-only mode.
+var errReadOnly = liberrors.E{
+	Code:    http.StatusServiceUnavailable,
+	Name:    ErrNameReadOnly,
+	Message: `server is in read-only mode`,
+}
+
+// errRequestTooLarge error when the size of request body exceed the
+// maximum limit.
+var errRequestTooLarge = liberrors.E{
+	Code:    http.StatusRequestEntityTooLarge,
+	Name:    ErrNameRequestTooLarge,
+	Message: `request body too large`,
+}
+
+// errRateLimited error when the client exceed the rate limit.
+var errRateLimited = liberrors.E{
+	Code:    http.StatusTooManyRequests,
+	Name:    ErrNameRateLimited,
+	Message: `too many requests`,
+}
+
 var errJobAlreadyRun = liberrors.E{
 	Code:    http.StatusTooManyRequests,
-	Name:    `ERR_JOB_ALREADY_RUN`,
+	Name:    ErrNameJobAlreadyRun,
 	Message: `job already run`,
 }
 
+var errJobApprovalNotFound = liberrors.E{
+	Code:    http.StatusNotFound,
+	Name:    ErrNameJobApprovalNotFound,
+	Message: `job does not have run waiting approval`,
+}
+
+var errJobApprovalPending = liberrors.E{
+	Code:    http.StatusConflict,
+	Name:    ErrNameJobApprovalPending,
+	Message: `job has run waiting approval`,
+}
+
+var errJobApprovalSelf = liberrors.E{
+	Code:    http.StatusForbidden,
+	Name:    ErrNameJobApprovalSelf,
+	Message: `run cannot be approved by its requester`,
+}
+
 var errJobCanceled = liberrors.E{
 	Code:    http.StatusGone,
-	Name:    `ERR_JOB_CANCELED`,
+	Name:    ErrNameJobCanceled,
 	Message: `job is canceled`,
 }
 
 var errJobEmptyCommandsOrCall = liberrors.E{
 	Code:    http.StatusBadRequest,
-	Name:    `ERR_JOB_EMPTY_COMMANDS_OR_CALL`,
+	Name:    ErrNameJobEmptyCommandsOrCall,
 	Message: `empty commands or call handle`,
 }
 
+var errJobDisabled = liberrors.E{
+	Code:    http.StatusPreconditionFailed,
+	Name:    ErrNameJobDisabled,
+	Message: `job is disabled`,
+}
+
 var errJobForbidden = liberrors.E{
 	Code:    http.StatusForbidden,
-	Name:    `ERR_JOB_FORBIDDEN`,
+	Name:    ErrNameJobForbidden,
 	Message: `forbidden`,
 }
 
+// errJobUserForbidden error when the logged in user is not the owner or
+// allowed users of the job.
+var errJobUserForbidden = liberrors.E{
+	Code:    http.StatusForbidden,
+	Name:    ErrNameJobUserForbidden,
+	Message: `forbidden, you are not the owner or allowed user of the job`,
+}
+
 var errJobPaused = liberrors.E{
 	Code:    http.StatusPreconditionFailed,
-	Name:    `ERR_JOB_PAUSED`,
+	Name:    ErrNameJobPaused,
 	Message: `job is paused`,
 }
 
+func errArtifactNotFound(name string) error {
+	return &liberrors.E{
+		Code:    http.StatusNotFound,
+		Name:    ErrNameArtifactNotFound,
+		Message: `artifact not found: ` + name,
+	}
+}
+
+func errInvalidAgentName(name string) error {
+	return &liberrors.E{
+		Code:    http.StatusBadRequest,
+		Name:    ErrNameInvalidAgentName,
+		Message: `invalid or empty agent name: ` + name,
+	}
+}
+
 func errInvalidJobID(id string) error {
 	return &liberrors.E{
 		Code:    http.StatusBadRequest,
-		Name:    `ERR_INVALID_JOB_ID`,
+		Name:    ErrNameInvalidJobID,
 		Message: `invalid or empty job id: ` + id,
 	}
 }
@@ -58,7 +331,15 @@ func errInvalidJobID(id string) error {
 func errJobNotFound(jobPath string) error {
 	return &liberrors.E{
 		Code:    http.StatusNotFound,
-		Name:    `ERR_JOB_NOT_FOUND`,
+		Name:    ErrNameJobNotFound,
 		Message: `job not found: ` + jobPath,
 	}
 }
+
+func errJobParam(msg string) error {
+	return &liberrors.E{
+		Code:    http.StatusBadRequest,
+		Name:    ErrNameJobParam,
+		Message: msg,
+	}
+}
@@ -7,15 +7,23 @@ import (
 	"bytes"
 	"compress/gzip"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"path"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"git.sr.ht/~shulhan/karajo/internal/qrcode"
+	liberrors "git.sr.ht/~shulhan/pakakeh.go/lib/errors"
 	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+	"github.com/andybalholm/brotli"
 )
 
 // HeaderNameXKarajoSign the header key for the signature of body.
@@ -23,21 +31,91 @@ const HeaderNameXKarajoSign = `X-Karajo-Sign`
 
 // List of HTTP API.
 const (
-	apiAuthLogin = `/karajo/api/auth/login`
+	apiAgentDone = `/karajo/api/agent/done`
+	apiAgentLog  = `/karajo/api/agent/log`
+	apiAgentPoll = `/karajo/api/agent/poll`
+
+	apiAuthLogin  = `/karajo/api/auth/login`
+	apiAuthLogout = `/karajo/api/auth/logout`
+	apiAuthTOTPQR = `/karajo/api/auth/totp/qr`
+
+	apiAuthOIDCCallback = `/karajo/api/auth/oidc/callback`
+	apiAuthOIDCLogin    = `/karajo/api/auth/oidc/login`
+
+	apiChanges = `/karajo/api/changes`
+
+	apiDevReload = `/karajo/api/dev/reload`
+
+	apiEnv         = `/karajo/api/environment`
+	apiEnvExport   = `/karajo/api/environment/export`
+	apiEnvReadOnly = `/karajo/api/environment/read_only`
+
+	apiBackup = `/karajo/api/backup`
+
+	apiFederation = `/karajo/api/federation`
+
+	apiSchedule        = `/karajo/api/schedule`
+	apiSchedulePreview = `/karajo/api/schedule/preview`
+
+	apiJobHTTP        = `/karajo/api/job_http`
+	apiJobHTTPDisable = `/karajo/api/job_http/disable`
+	apiJobHTTPEnable  = `/karajo/api/job_http/enable`
+	apiJobHTTPLog     = `/karajo/api/job_http/log`
+	apiJobHTTPLogSSE  = `/karajo/api/job_http/log/stream`
+	apiJobHTTPPause   = `/karajo/api/job_http/pause`
+	apiJobHTTPResume  = `/karajo/api/job_http/resume`
+
+	apiJobSQL        = `/karajo/api/job_sql`
+	apiJobSQLDisable = `/karajo/api/job_sql/disable`
+	apiJobSQLEnable  = `/karajo/api/job_sql/enable`
+	apiJobSQLLog     = `/karajo/api/job_sql/log`
+	apiJobSQLLogSSE  = `/karajo/api/job_sql/log/stream`
+	apiJobSQLPause   = `/karajo/api/job_sql/pause`
+	apiJobSQLResume  = `/karajo/api/job_sql/resume`
+
+	apiJobExecApprove          = `/karajo/api/job_exec/approve`
+	apiJobExecArtifact         = `/karajo/api/job_exec/artifact`
+	apiJobExecArtifactDownload = `/karajo/api/job_exec/artifact/download`
+	apiJobExecCancel           = `/karajo/api/job_exec/cancel`
+	apiJobExecDisable          = `/karajo/api/job_exec/disable`
+	apiJobExecDryRun           = `/karajo/api/job_exec/dry_run`
+	apiJobExecEnable           = `/karajo/api/job_exec/enable`
+	apiJobExecLog              = `/karajo/api/job_exec/log`
+	apiJobExecLogDiff          = `/karajo/api/job_exec/log/diff`
+	apiJobExecLogRaw           = `/karajo/api/job_exec/log/raw`
+	apiJobExecLogSSE           = `/karajo/api/job_exec/log/stream`
+	apiJobExecLogSearch        = `/karajo/api/job_exec/log/search`
+	apiJobExecNext             = `/karajo/api/job_exec/next`
+	apiJobExecPause            = `/karajo/api/job_exec/pause`
+	apiJobExecResume           = `/karajo/api/job_exec/resume`
+	apiJobExecRun              = `/karajo/api/job_exec/run`
+	apiJobExecSecret           = `/karajo/api/job_exec/secret`
+	apiJobExecSecretRotate     = `/karajo/api/job_exec/secret/rotate`
+)
 
-	apiEnv = `/karajo/api/environment`
+// List of content encoding for HTTP API response, in addition to the one
+// provided by libhttp.
+const (
+	contentEncodingBrotli   = `br`
+	contentEncodingIdentity = `identity`
+)
 
-	apiJobHTTP       = `/karajo/api/job_http`
-	apiJobHTTPLog    = `/karajo/api/job_http/log`
-	apiJobHTTPPause  = `/karajo/api/job_http/pause`
-	apiJobHTTPResume = `/karajo/api/job_http/resume`
+// headerVary the HTTP header that list the request headers that affect
+// the response.
+const headerVary = `Vary`
 
-	apiJobExecCancel = `/karajo/api/job_exec/cancel`
-	apiJobExecLog    = `/karajo/api/job_exec/log`
-	apiJobExecPause  = `/karajo/api/job_exec/pause`
-	apiJobExecResume = `/karajo/api/job_exec/resume`
-	apiJobExecRun    = `/karajo/api/job_exec/run`
-)
+// HeaderNameXKarajoEpoch the response header that contains the server
+// time in Unix epoch, when the signed request is rejected because of its
+// "_karajo_epoch".
+const HeaderNameXKarajoEpoch = `X-Karajo-Epoch`
+
+// headerRetryAfter the HTTP header that contains the number of seconds
+// the client should wait before making another request.
+const headerRetryAfter = `Retry-After`
+
+// headerContentDisposition the HTTP header that indicate the response
+// should be downloaded as file.
+const headerContentDisposition = `Content-Disposition`
 
 // List of known pathes.
 const (
@@ -47,11 +125,32 @@ const (
 
 // List of known HTTP request parameters.
 const (
+	paramNameCode        = `code`
+	paramNameA           = `a`
+	paramNameB           = `b`
 	paramNameCounter     = `counter`
+	paramNameDuration    = `duration`
+	paramNameError       = `error`
+	paramNameFormat      = `format`
+	paramNameFrom        = `from`
+	paramNameGracePeriod = `grace_period`
 	paramNameID          = `id`
 	paramNameKarajoEpoch = `_karajo_epoch`
+	paramNameKarajoNonce = `_karajo_nonce`
+	paramNameLogMaxAge   = `log_max_age`
+	paramNameN           = `n`
 	paramNameName        = `name`
+	paramNameOffset      = `offset`
+	paramNameOutput      = `output`
 	paramNamePassword    = `password`
+	paramNameQuery       = `q`
+	paramNameReadOnly    = `read_only`
+	paramNameSince       = `since`
+	paramNameState       = `state`
+	paramNameStatus      = `status`
+	paramNameTo          = `to`
+	paramNameTOTP        = `totp`
+	paramNameUntil       = `until`
 )
 
 // initHTTPd initialize the HTTP server, including registering its endpoints
@@ -67,7 +166,7 @@ func (k *Karajo) initHTTPd() (err error) {
 				MaxHeaderBytes: 1 << 20,
 			},
 			HandleFS:        k.handleFSAuth,
-			Memfs:           memfsWww,
+			Memfs:           k.www,
 			EnableIndexHTML: true,
 		}
 	)
@@ -76,6 +175,18 @@ func (k *Karajo) initHTTPd() (err error) {
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
+	k.HTTPd.Server.Handler = k.serveAddedHooks(k.HTTPd.Server.Handler)
+	if k.env.isRateLimited() {
+		k.HTTPd.Server.Handler = withResponseWriter(k.HTTPd.Server.Handler)
+		k.evals = append(k.evals, k.evalRateLimit)
+	}
+	k.HTTPd.Server.Handler = k.stripBasePath(k.limitRequestBody(k.HTTPd.Server.Handler))
+	k.evals = append(k.evals, k.evalCSRF, k.evalReadOnly, k.evalNamespace, k.evalJobACL)
+
+	var eval libhttp.Evaluator
+	for _, eval = range k.evals {
+		k.HTTPd.RegisterEvaluator(eval)
+	}
 
 	err = k.registerAPIs()
 	if err != nil {
@@ -94,7 +205,7 @@ func (k *Karajo) initHTTPd() (err error) {
 func (k *Karajo) registerAPIs() (err error) {
 	var logp = `registerAPIs`
 
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodPost,
 		Path:         apiAuthLogin,
 		RequestType:  libhttp.RequestTypeForm,
@@ -105,7 +216,53 @@ func (k *Karajo) registerAPIs() (err error) {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiAuthLogout,
+		RequestType:  libhttp.RequestTypeNone,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiAuthLogout,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiAuthLogout, err)
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiAuthTOTPQR,
+		RequestType:  libhttp.RequestTypeNone,
+		ResponseType: libhttp.ResponseTypeNone,
+		Call:         k.apiAuthTOTPQR,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiAuthTOTPQR, err)
+	}
+
+	if k.env.AuthOIDC != nil {
+		err = k.registerEndpoint(libhttp.Endpoint{
+			Method:       libhttp.RequestMethodGet,
+			Path:         apiAuthOIDCLogin,
+			RequestType:  libhttp.RequestTypeNone,
+			ResponseType: libhttp.ResponseTypeNone,
+			Call:         k.apiAuthOIDCLogin,
+		})
+		if err != nil {
+			return fmt.Errorf(`%s: %s: %w`, logp, apiAuthOIDCLogin, err)
+		}
+
+		err = k.registerEndpoint(libhttp.Endpoint{
+			Method:       libhttp.RequestMethodGet,
+			Path:         apiAuthOIDCCallback,
+			RequestType:  libhttp.RequestTypeQuery,
+			ResponseType: libhttp.ResponseTypeNone,
+			Call:         k.apiAuthOIDCCallback,
+		})
+		if err != nil {
+			return fmt.Errorf(`%s: %s: %w`, logp, apiAuthOIDCCallback, err)
+		}
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodGet,
 		Path:         apiEnv,
 		RequestType:  libhttp.RequestTypeNone,
@@ -115,8 +272,100 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiEnvExport,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeNone,
+		Call:         k.apiEnvExport,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiEnvExport, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiEnvReadOnly,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiEnvReadOnly,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiEnvReadOnly, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiNamespaceJobExec,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiNamespaceJobExec,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiNamespaceJobExec, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiBackup,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeNone,
+		Call:         k.apiBackup,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiBackup, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiChanges,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiChanges,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiChanges, err)
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiFederation,
+		RequestType:  libhttp.RequestTypeNone,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiFederation,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiFederation, err)
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiSchedule,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiSchedule,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiSchedule, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiSchedulePreview,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiSchedulePreview,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiSchedulePreview, err)
+	}
 
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobExecApprove,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecApprove,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecApprove, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodPost,
 		Path:         apiJobExecCancel,
 		RequestType:  libhttp.RequestTypeForm,
@@ -126,7 +375,17 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobExecDryRun,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecDryRun,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecDryRun, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodGet,
 		Path:         apiJobExecLog,
 		RequestType:  libhttp.RequestTypeQuery,
@@ -136,7 +395,75 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecArtifact,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecArtifact,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecArtifact, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecArtifactDownload,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeBinary,
+		Call:         k.apiJobExecArtifactDownload,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecArtifactDownload, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecLogRaw,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypePlain,
+		Call:         k.apiJobExecLogRaw,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecLogRaw, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecLogDiff,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypePlain,
+		Call:         k.apiJobExecLogDiff,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecLogDiff, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecLogSearch,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecLogSearch,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecLogSearch, err)
+	}
+	err = k.registerSSE(libhttp.SSEEndpoint{
+		Path:              apiJobExecLogSSE,
+		Call:              k.apiJobExecLogSSE,
+		KeepAliveInterval: defSSEKeepAliveInterval,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecLogSSE, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecNext,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecNext,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecNext, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodPost,
 		Path:         apiJobExecPause,
 		RequestType:  libhttp.RequestTypeForm,
@@ -146,7 +473,7 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecPause, err)
 	}
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodPost,
 		Path:         apiJobExecResume,
 		RequestType:  libhttp.RequestTypeForm,
@@ -156,8 +483,48 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecResume, err)
 	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobExecDisable,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecDisable,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecDisable, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobExecEnable,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecEnable,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecEnable, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobExecSecret,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecSecret,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecSecret, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobExecSecretRotate,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobExecSecretRotate,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobExecSecretRotate, err)
+	}
 
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodGet,
 		Path:         apiJobHTTP,
 		RequestType:  libhttp.RequestTypeQuery,
@@ -167,7 +534,7 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodGet,
 		Path:         apiJobHTTPLog,
 		RequestType:  libhttp.RequestTypeQuery,
@@ -177,7 +544,15 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerSSE(libhttp.SSEEndpoint{
+		Path:              apiJobHTTPLogSSE,
+		Call:              k.apiJobHTTPLogSSE,
+		KeepAliveInterval: defSSEKeepAliveInterval,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobHTTPLogSSE, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodPost,
 		Path:         apiJobHTTPPause,
 		RequestType:  libhttp.RequestTypeQuery,
@@ -187,7 +562,7 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
-	err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
+	err = k.registerEndpoint(libhttp.Endpoint{
 		Method:       libhttp.RequestMethodPost,
 		Path:         apiJobHTTPResume,
 		RequestType:  libhttp.RequestTypeQuery,
@@ -197,13 +572,162 @@ func (k *Karajo) registerAPIs() (err error) {
 	if err != nil {
 		return err
 	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobHTTPDisable,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobHTTPDisable,
+	})
+	if err != nil {
+		return err
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobHTTPEnable,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobHTTPEnable,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobSQL,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobSQL,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQL, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiJobSQLLog,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobSQLLog,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQLLog, err)
+	}
+	err = k.registerSSE(libhttp.SSEEndpoint{
+		Path:              apiJobSQLLogSSE,
+		Call:              k.apiJobSQLLogSSE,
+		KeepAliveInterval: defSSEKeepAliveInterval,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQLLogSSE, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobSQLPause,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobSQLPause,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQLPause, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobSQLResume,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobSQLResume,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQLResume, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobSQLDisable,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobSQLDisable,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQLDisable, err)
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiJobSQLEnable,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiJobSQLEnable,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiJobSQLEnable, err)
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiAgentPoll,
+		RequestType:  libhttp.RequestTypeQuery,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiAgentPoll,
+	})
+	if err != nil {
+		return err
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiAgentLog,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiAgentLog,
+	})
+	if err != nil {
+		return err
+	}
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         apiAgentDone,
+		RequestType:  libhttp.RequestTypeForm,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiAgentDone,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = k.registerEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodGet,
+		Path:         apiOpenAPI,
+		RequestType:  libhttp.RequestTypeNone,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         k.apiOpenAPI,
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, apiOpenAPI, err)
+	}
+
+	if k.env.IsDevelopment {
+		err = k.registerSSE(libhttp.SSEEndpoint{
+			Path:              apiDevReload,
+			Call:              k.apiDevReload,
+			KeepAliveInterval: defSSEKeepAliveInterval,
+		})
+		if err != nil {
+			return fmt.Errorf(`%s: %s: %w`, logp, apiDevReload, err)
+		}
+	}
 
 	return nil
 }
 
 // registerJobsHook register endpoint for executing JobExec using HTTP POST.
 func (k *Karajo) registerJobsHook() (err error) {
-	var job *JobExec
+	var (
+		job      *JobExec
+		hookPath string
+	)
+
+	k.bodyLimits = make(map[string]int64)
+	k.hookJobs = make(map[string]*JobExec)
+	k.hookServers = make(map[string]*libhttp.Server)
 
 	for _, job = range k.env.ExecJobs {
 		if len(job.Path) == 0 {
@@ -211,13 +735,9 @@ func (k *Karajo) registerJobsHook() (err error) {
 			continue
 		}
 
-		err = k.HTTPd.RegisterEndpoint(libhttp.Endpoint{
-			Method:       libhttp.RequestMethodPost,
-			Path:         path.Join(apiJobExecRun, job.Path),
-			RequestType:  libhttp.RequestTypeJSON,
-			ResponseType: libhttp.ResponseTypeJSON,
-			Call:         job.handleHTTP,
-		})
+		hookPath = k.addJobHook(job)
+
+		err = k.HTTPd.RegisterEndpoint(newJobHookEndpoint(hookPath, k.handleJobHook))
 		if err != nil {
 			return err
 		}
@@ -236,23 +756,27 @@ func (k *Karajo) handleFSAuth(node *memfs.Node, w http.ResponseWriter, req *http
 		if isLoginPage(path) {
 			// Redirect user to app page if cookie is valid and
 			// user in login page.
-			http.Redirect(w, req, pathKarajoApp, http.StatusFound)
+			http.Redirect(w, req, k.urlPath(pathKarajoApp), http.StatusFound)
 			return nil
 		}
-		return node
-	}
-	if isRequireAuth(path) {
+	} else if isRequireAuth(path) {
 		k.unauthorized(w, req)
 		return nil
 	}
 
+	if node != nil {
+		k.setCacheControl(w, req, node)
+		if k.env.IsDevelopment {
+			node = k.injectDevJS(node)
+		}
+	}
 	return node
 }
 
-// isAuthorized return true env.Users is empty OR if the cookie exist and
-// valid.
+// isAuthorized return true env.Users is empty and OpenID Connect is not
+// configured, OR if the cookie exist and valid.
 func (k *Karajo) isAuthorized(req *http.Request) bool {
-	if len(k.env.Users) == 0 {
+	if len(k.env.Users) == 0 && k.env.AuthOIDC == nil {
 		return true
 	}
 
@@ -269,11 +793,26 @@ func (k *Karajo) isAuthorized(req *http.Request) bool {
 	return user != nil
 }
 
-func isRequireAuth(path string) bool {
-	if strings.HasPrefix(path, pathKarajoApp) {
-		return true
-	}
-	if strings.HasPrefix(path, pathKarajoAPI) {
+// sessionUser return the user from session cookie in the request.
+// It will return nil if the cookie does not exist or the session is not
+// valid.
+func (k *Karajo) sessionUser(req *http.Request) (user *User) {
+	var (
+		cookie *http.Cookie
+		err    error
+	)
+	cookie, err = req.Cookie(cookieName)
+	if err != nil {
+		return nil
+	}
+	return k.sm.get(cookie.Value)
+}
+
+func isRequireAuth(path string) bool {
+	if strings.HasPrefix(path, pathKarajoApp) {
+		return true
+	}
+	if strings.HasPrefix(path, pathKarajoAPI) {
 		return true
 	}
 	return false
@@ -293,24 +832,32 @@ func (k *Karajo) unauthorized(w http.ResponseWriter, _ *http.Request) {
 //
 // A valid user's account will receive authorization cookie named `karajo`
 // that can be used as authorization for subsequent request.
+// If the user has TOTP secret, the parameter "totp" must contains the
+// code from authenticator application.
 //
 // Request format,
 //
 //	POST /karajo/api/auth/login
 //	Content-Type: application/x-www-form-urlencoded
 //
-//	name=&password=
+//	name=&password=&totp=
 //
 // List of response,
 //
 //   - 200 OK: success.
 //   - 400 ERR_AUTH_LOGIN: invalid name and/or password.
+//   - 400 ERR_AUTH_TOTP: invalid or missing TOTP code.
+//   - 429 ERR_AUTH_LOCKED: too many failed login from the same IP address
+//     or for the same user name.
+//     The header "Retry-After" contains the number of seconds until the
+//     login is allowed again.
 //   - 500 ERR_INTERNAL: internal server error.
 func (k *Karajo) apiAuthLogin(epr *libhttp.EndpointRequest) (respBody []byte, err error) {
 	var (
 		logp = `apiAuthLogin`
 		name = epr.HTTPRequest.Form.Get(paramNameName)
 		pass = epr.HTTPRequest.Form.Get(paramNamePassword)
+		ip   = k.env.clientIP(epr.HTTPRequest)
 	)
 
 	name = strings.TrimSpace(name)
@@ -318,20 +865,44 @@ func (k *Karajo) apiAuthLogin(epr *libhttp.EndpointRequest) (respBody []byte, er
 		return nil, &errAuthLogin
 	}
 
+	var lockedUntil = k.authl.lockedUntil(ip, name)
+	if !lockedUntil.IsZero() {
+		var retryAfter = lockedUntil.Sub(timeNow()).Round(time.Second)
+		epr.HTTPWriter.Header().Set(headerRetryAfter,
+			strconv.FormatInt(int64(retryAfter.Seconds()), 10))
+		mlog.Errf(`%s: login locked for user %q from %s`, logp, name, ip)
+		return nil, &errAuthLocked
+	}
+
 	pass = strings.TrimSpace(pass)
 	if len(pass) == 0 {
 		return nil, &errAuthLogin
 	}
 
 	var user = k.env.Users[name]
-	if user == nil {
+	if user == nil || !user.authenticate(pass) {
+		mlog.Errf(`%s: failed login for user %q from %s`, logp, name, ip)
+		if k.authl.fail(ip, name) {
+			mlog.Errf(`%s: login for user %q from %s is locked for %s`,
+				logp, name, ip, k.authl.lockoutDuration)
+		}
 		return nil, &errAuthLogin
 	}
 
-	if !user.authenticate(pass) {
-		return nil, &errAuthLogin
+	if len(user.totpKey) != 0 {
+		var code = epr.HTTPRequest.Form.Get(paramNameTOTP)
+		if !user.verifyTOTP(code) {
+			mlog.Errf(`%s: invalid TOTP for user %q from %s`, logp, name, ip)
+			if k.authl.fail(ip, name) {
+				mlog.Errf(`%s: login for user %q from %s is locked for %s`,
+					logp, name, ip, k.authl.lockoutDuration)
+			}
+			return nil, &errAuthTOTP
+		}
 	}
 
+	k.authl.succeed(ip, name)
+
 	err = k.sessionNew(epr.HTTPWriter, user)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
@@ -348,75 +919,225 @@ func (k *Karajo) apiAuthLogin(epr *libhttp.EndpointRequest) (respBody []byte, er
 	return respBody, nil
 }
 
-func (k *Karajo) apiEnv(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
-	var (
-		logp = `apiEnv`
-		res  = &libhttp.EndpointResponse{}
-	)
+// apiAuthLogout remove the user session.
+//
+// Request format,
+//
+//	POST /karajo/api/auth/logout
+//	Cookie: karajo=<session>
+//
+// List of response,
+//
+//   - 200 OK: success, the cookie is expired.
+//   - 500 ERR_INTERNAL: internal server error.
+func (k *Karajo) apiAuthLogout(epr *libhttp.EndpointRequest) (respBody []byte, err error) {
+	var logp = `apiAuthLogout`
 
-	res.Code = http.StatusOK
-	res.Data = k.env
+	k.sessionDelete(epr.HTTPWriter, epr.HTTPRequest)
 
-	k.env.lockAllJob()
-	resbody, err = json.Marshal(res)
-	k.env.unlockAllJob()
+	var res = &libhttp.EndpointResponse{}
 
+	res.Code = http.StatusOK
+	respBody, err = json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	resbody, err = compressGzip(resbody)
+	return respBody, nil
+}
+
+// apiAuthOIDCLogin redirect the user to OpenID Connect provider for
+// login.
+// This API is registered only if section "[auth \"oidc\"]" is defined.
+//
+// Request format,
+//
+//	GET /karajo/api/auth/oidc/login
+//
+// List of response,
+//
+//   - 302 Found: redirect to provider authorization endpoint.
+//     The login state is stored in short-lived cookie, to be checked
+//     on callback.
+//   - 500 ERR_INTERNAL: failed to fetch the provider metadata.
+func (k *Karajo) apiAuthOIDCLogin(epr *libhttp.EndpointRequest) (respBody []byte, err error) {
+	var (
+		logp = `apiAuthOIDCLogin`
+
+		authURL string
+		state   string
+	)
+
+	authURL, state, err = k.env.AuthOIDC.authURL()
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	epr.HTTPWriter.Header().Set(libhttp.HeaderContentEncoding, libhttp.ContentEncodingGzip)
+	var cookie = &http.Cookie{
+		Name:     cookieNameOIDC,
+		Value:    state,
+		MaxAge:   int(defOIDCPendingTTL.Seconds()),
+		Path:     k.cookiePath(),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(epr.HTTPWriter, cookie)
 
-	return resbody, nil
+	http.Redirect(epr.HTTPWriter, epr.HTTPRequest, authURL, http.StatusFound)
+	return nil, nil
 }
 
-// apiJobExecCancel cancel the JobExec execution.
+// apiAuthOIDCCallback handle the redirect from OpenID Connect provider
+// after user login.
+// If the state match with the one in login cookie, the ID token is
+// valid, and the user is allowed, the user receive the session cookie and
+// redirected to WUI dashboard.
 //
 // Request format,
 //
-//	POST /karajo/api/job_exec/cancel
-//	Content-Type: application/x-www-form-urlencoded
-//	X-Karajo-Sign: <signature>
+//	GET /karajo/api/auth/oidc/callback?code=&state=
 //
-//	_karajo_epoch=&id=
+// List of response,
 //
-// Response format,
+//   - 302 Found: success, redirect to dashboard.
+//   - 401 ERR_AUTH_OIDC: login failed.
+//   - 500 ERR_INTERNAL: internal server error.
+func (k *Karajo) apiAuthOIDCCallback(epr *libhttp.EndpointRequest) (respBody []byte, err error) {
+	var (
+		logp  = `apiAuthOIDCCallback`
+		form  = epr.HTTPRequest.Form
+		state = form.Get(paramNameState)
+		code  = form.Get(paramNameCode)
+
+		user         *User
+		cookie       *http.Cookie
+		browserState string
+	)
+
+	cookie, err = epr.HTTPRequest.Cookie(cookieNameOIDC)
+	if err == nil {
+		browserState = cookie.Value
+	}
+
+	// Expire the login state cookie, it can only be used once.
+	cookie = &http.Cookie{
+		Name:     cookieNameOIDC,
+		Path:     k.cookiePath(),
+		MaxAge:   -1,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	http.SetCookie(epr.HTTPWriter, cookie)
+
+	user, err = k.env.AuthOIDC.callback(state, browserState, code)
+	if err != nil {
+		mlog.Errf(`%s: from %s: %s`, logp, k.env.clientIP(epr.HTTPRequest), err)
+		return nil, &errAuthOIDC
+	}
+
+	err = k.sessionNew(epr.HTTPWriter, user)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	http.Redirect(epr.HTTPWriter, epr.HTTPRequest, k.urlPath(pathKarajoApp), http.StatusFound)
+	return nil, nil
+}
+
+// apiAuthTOTPQR return the QR code image of TOTP provisioning URI for the
+// logged in user, to be scanned by authenticator application.
 //
-//	Content-Type: application/json
-//	{
-//		"data": <JobExec>
-//	}
-func (k *Karajo) apiJobExecCancel(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
-	var logp = `apiJobExecCancel`
+// Request format,
+//
+//	GET /karajo/api/auth/totp/qr
+//	Cookie: karajo=<session>
+//
+// List of response,
+//
+//   - 200 OK: the QR code as PNG image.
+//   - 401 ERR_AUTH_UNAUTHORIZED: the user is not logged in.
+//   - 404 ERR_AUTH_TOTP_NOT_ENABLED: the user does not have TOTP secret.
+//   - 500 ERR_INTERNAL: internal server error.
+func (k *Karajo) apiAuthTOTPQR(epr *libhttp.EndpointRequest) (respBody []byte, err error) {
+	var (
+		logp = `apiAuthTOTPQR`
+		user = k.sessionUser(epr.HTTPRequest)
+	)
+	if user == nil {
+		return nil, &errAuthUnauthorized
+	}
+	if len(user.totpKey) == 0 {
+		return nil, &errAuthTOTPNotEnabled
+	}
 
-	err = k.httpAuthorize(epr, epr.RequestBody)
+	var code *qrcode.Code
+
+	code, err = qrcode.Encode([]byte(user.totpURI(k.env.Name)))
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	var id = epr.HTTPRequest.Form.Get(paramNameID)
+	var buf bytes.Buffer
 
-	var job = k.env.jobExec(id)
-	if job == nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	err = code.WritePNG(&buf, 6)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	job.JobBase.Cancel()
+	var header = epr.HTTPWriter.Header()
+	header.Set(libhttp.HeaderContentType, `image/png`)
+	header.Set(libhttp.HeaderCacheControl, `no-store`)
+	epr.HTTPWriter.WriteHeader(http.StatusOK)
 
-	var res = &libhttp.EndpointResponse{}
+	_, err = epr.HTTPWriter.Write(buf.Bytes())
+	if err != nil {
+		mlog.Errf(`%s: %s`, logp, err)
+	}
+	return nil, nil
+}
+
+func (k *Karajo) apiEnv(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp = `apiEnv`
+		res  = &libhttp.EndpointResponse{}
+
+		etag    string
+		modTime time.Time
+	)
+
+	if k.env.revision != nil {
+		// The revision is read before the Env encoded, so any
+		// changes during encoding generate different ETag on the
+		// next request.
+		etag, modTime = k.env.revision.current()
+
+		var header = epr.HTTPWriter.Header()
+		header.Set(libhttp.HeaderETag, etag)
+		header.Set(headerLastModified, modTime.UTC().Format(http.TimeFormat))
+
+		if isNotModified(epr.HTTPRequest, etag, modTime) {
+			header.Set(headerVary, libhttp.HeaderAcceptEncoding)
+			epr.HTTPWriter.WriteHeader(http.StatusNotModified)
+			return nil, nil
+		}
+	}
 
 	res.Code = http.StatusOK
-	res.Data = job
+	res.Data = k.env
+
+	var names = k.env.userNamespaces(k.sessionUser(epr.HTTPRequest))
+	if len(names) != 0 {
+		res.Data = k.env.namespaceView(names)
+	}
 
-	job.Lock()
+	k.env.jobsMtx.RLock()
 	resbody, err = json.Marshal(res)
-	job.Unlock()
+	k.env.jobsMtx.RUnlock()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 
+	resbody, err = compressResponse(epr, resbody)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
@@ -424,342 +1145,2098 @@ func (k *Karajo) apiJobExecCancel(epr *libhttp.EndpointRequest) (resbody []byte,
 	return resbody, nil
 }
 
-// apiJobExecLog get the JobExec log by its ID and counter.
+// apiChanges return the jobs whose state changed since specific revision,
+// so the client can update the jobs incrementally instead of fetching the
+// whole environment.
 //
 // Request format,
 //
-//	GET /karajo/api/job_exec/log?id=<jobID>&counter=<counter>
+//	GET /karajo/api/changes?since=<revision>
+//
+// The "since" is the revision returned by the previous request, default
+// to 0.
 //
 // Response format,
 //
-//	content-encoding: gzip
-//	content-type: application/json
-//	{
-//		"data": <JobLog>
-//	}
+//	Content-Type: application/json
 //
-// If the jobID and counter exist it will return the JobLog object as JSON.
-func (k *Karajo) apiJobExecLog(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+//	{"code":200,"data":<EnvChanges>}
+func (k *Karajo) apiChanges(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
 	var (
-		logp       = `apiJobExecLog`
-		res        = &libhttp.EndpointResponse{}
-		id         = epr.HTTPRequest.Form.Get(paramNameID)
-		counterStr = epr.HTTPRequest.Form.Get(paramNameCounter)
+		logp     = `apiChanges`
+		res      = &libhttp.EndpointResponse{}
+		sinceStr = epr.HTTPRequest.Form.Get(paramNameSince)
 
-		buf     bytes.Buffer
-		job     *JobExec
-		jlog    *JobLog
-		counter int64
+		since int64
 	)
 
-	id = strings.ToLower(id)
-	job = k.env.jobExec(id)
-	if job == nil {
-		res.Code = http.StatusNotFound
-		res.Message = fmt.Sprintf(`job ID %s not found`, id)
-		return nil, res
-	}
-
-	counter, err = strconv.ParseInt(counterStr, 10, 64)
-	if err != nil {
-		res.Code = http.StatusNotFound
-		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
-		return nil, res
-	}
-
-	jlog = job.JobBase.getLog(counter)
-	if jlog == nil {
-		res.Code = http.StatusNotFound
-		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
-		goto out
-	}
-
-	err = jlog.load()
-	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	if len(sinceStr) != 0 {
+		since, err = strconv.ParseInt(sinceStr, 10, 64)
+		if err != nil || since < 0 {
+			res.Code = http.StatusBadRequest
+			res.Message = fmt.Sprintf(`invalid since %q`, sinceStr)
+			return nil, res
+		}
 	}
 
-	resbody, err = jlog.marshalJSON()
-	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	var (
+		changes = k.env.changes(since)
+		names   = k.env.userNamespaces(k.sessionUser(epr.HTTPRequest))
+	)
+	if len(names) != 0 {
+		changes.filterNamespaces(names)
 	}
 
-	fmt.Fprintf(&buf, `{"code":200,"data":%s}`, resbody)
+	res.Code = http.StatusOK
+	res.Data = changes
 
-	resbody, err = compressGzip(buf.Bytes())
+	resbody, err = json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	epr.HTTPWriter.Header().Set(libhttp.HeaderContentEncoding, libhttp.ContentEncodingGzip)
-	return resbody, nil
-
-out:
-	resbody, err = json.Marshal(res)
+	resbody, err = compressResponse(epr, resbody)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 	return resbody, nil
 }
 
-// apiJobExecPause pause the JobExec.
+// apiEnvReadOnly enable or disable the read-only mode at runtime.
 //
 // Request format,
 //
-//	POST /karajo/api/job_exec/pause
+//	POST /karajo/api/environment/read_only
 //	Content-Type: application/x-www-form-urlencoded
+//	X-Karajo-Sign: <signature>
 //
-//	_karajo_epoch=&id=
+//	_karajo_epoch=&read_only=<true|false>
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <boolean>
+//	}
 //
 // List of response,
 //
-//   - 200: OK, if job ID is valid.
-//   - 404: If job ID not found.
-func (k *Karajo) apiJobExecPause(epr *libhttp.EndpointRequest) (resb []byte, err error) {
-	var (
-		logp = `apiJobExecPause`
-
-		res *libhttp.EndpointResponse
-		job *JobExec
-		id  string
-	)
+//   - 200 OK: the current read-only mode.
+//   - 400: the read_only value is invalid.
+func (k *Karajo) apiEnvReadOnly(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiEnvReadOnly`
 
 	err = k.httpAuthorize(epr, epr.RequestBody)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	id = epr.HTTPRequest.Form.Get(paramNameID)
+	var (
+		raw = epr.HTTPRequest.Form.Get(paramNameReadOnly)
+		v   bool
+	)
+	v, err = strconv.ParseBool(raw)
+	if err != nil {
+		var res = &libhttp.EndpointResponse{}
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`invalid %s %q`, paramNameReadOnly, raw)
+		return nil, res
+	}
 
-	job = k.env.jobExec(id)
-	if job == nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	if k.env.ReadOnlyMode.set(v) {
+		mlog.Outf(`%s: read-only mode set to %t`, logp, v)
 	}
 
-	job.pause()
+	var res = &libhttp.EndpointResponse{}
 
-	res = &libhttp.EndpointResponse{}
 	res.Code = http.StatusOK
-	res.Data = job
-
-	job.Lock()
-	resb, err = json.Marshal(res)
-	job.Unlock()
+	res.Data = k.env.ReadOnlyMode
 
-	return resb, err
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
 }
 
-// apiJobExecResume resume the paused JobExec.
+// apiEnvExport export the effective configuration, after the defaults,
+// includes, templates, and job.d has been applied, with all of the secrets
+// redacted.
 //
-// # Request
+// Request format,
 //
-//	POST /karajo/api/job_exec/resume
-//	Content-Type: application/x-www-form-urlencoded
+//	GET /karajo/api/environment/export?format=<ini|json>
 //
-//	_karajo_epoch=&id=
+// The format is optional, default to "ini".
 //
-// # Response
+// Response format,
 //
-//   - 200: OK, if job ID is valid.
-//   - 404: If job ID not found.
-func (k *Karajo) apiJobExecResume(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+//	Content-Type: <text/plain|application/json>
+//
+//	<configuration>
+func (k *Karajo) apiEnvExport(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
 	var (
-		logp = `apiJobExecResume`
-
-		res *libhttp.EndpointResponse
-		job *JobExec
-		id  string
+		logp   = `apiEnvExport`
+		format = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameFormat))
+		ctype  = libhttp.ContentTypePlain
 	)
 
-	err = k.httpAuthorize(epr, epr.RequestBody)
+	switch format {
+	case ``:
+		format = envExportFormatINI
+	case envExportFormatINI:
+	case envExportFormatJSON:
+		ctype = libhttp.ContentTypeJSON
+	default:
+		var res = &libhttp.EndpointResponse{}
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`invalid format %q`, format)
+		return nil, res
+	}
+
+	resbody, err = k.env.export(format)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	id = epr.HTTPRequest.Form.Get(paramNameID)
+	var header = epr.HTTPWriter.Header()
+	header.Set(libhttp.HeaderContentType, ctype)
+	header.Set(libhttp.HeaderCacheControl, `no-store`)
+	epr.HTTPWriter.WriteHeader(http.StatusOK)
 
-	job = k.env.jobExec(id)
-	if job == nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	_, err = epr.HTTPWriter.Write(resbody)
+	if err != nil {
+		mlog.Errf(`%s: %s`, logp, err)
 	}
+	return nil, nil
+}
 
-	job.resume(``)
-
-	res = &libhttp.EndpointResponse{}
-	res.Code = http.StatusOK
-	res.Data = job
+// apiBackup write the backup of configurations, states, and recent job
+// logs as gzip compressed tar.
+//
+// Request format,
+//
+//	GET /karajo/api/backup?_karajo_epoch=&log_max_age=
+//	X-Karajo-Sign: <signature>
+//
+// The log_max_age is optional, in the Go duration format, default to
+// 168h.
+//
+// Response format,
+//
+//	Content-Type: application/gzip
+//	Content-Disposition: attachment; filename="karajo-<name>-<time>.tar.gz"
+//
+//	<archive>
+func (k *Karajo) apiBackup(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiBackup`
 
-	job.Lock()
-	resb, err = json.Marshal(res)
-	job.Unlock()
+	err = k.httpAuthorize(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 
-	return resb, err
-}
+	var (
+		rawAge    = epr.HTTPRequest.Form.Get(paramNameLogMaxAge)
+		logMaxAge time.Duration
+	)
+	if len(rawAge) != 0 {
+		logMaxAge, err = time.ParseDuration(rawAge)
+		if err != nil {
+			var res = &libhttp.EndpointResponse{}
+			res.Code = http.StatusBadRequest
+			res.Message = fmt.Sprintf(`invalid %s %q`, paramNameLogMaxAge, rawAge)
+			return nil, res
+		}
+	}
 
-// apiJobHTTP HTTP API to get the JobHTTP information by its ID.
-func (k *Karajo) apiJobHTTP(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
 	var (
-		res     = &libhttp.EndpointResponse{}
-		id      = epr.HTTPRequest.Form.Get(paramNameID)
-		jobHTTP = k.env.jobHTTP(id)
+		header = epr.HTTPWriter.Header()
+		fname  = fmt.Sprintf(`karajo-%s-%s.tar.gz`, k.env.name,
+			timeNow().UTC().Format(`20060102150405`))
 	)
+	header.Set(libhttp.HeaderContentType, `application/gzip`)
+	header.Set(libhttp.HeaderCacheControl, `no-store`)
+	header.Set(headerContentDisposition, fmt.Sprintf(`attachment; filename=%q`, fname))
+	epr.HTTPWriter.WriteHeader(http.StatusOK)
 
-	if jobHTTP == nil {
-		return nil, errInvalidJobID(id)
+	// The response has been started, the error can only be logged.
+	_, err = k.env.Backup(epr.HTTPWriter, logMaxAge)
+	if err != nil {
+		mlog.Errf(`%s: %s`, logp, err)
 	}
+	return nil, nil
+}
+
+// apiFederation return the environment, including the jobs status, of
+// each peer.
+// The environment of peers are fetched concurrently and cached for ten
+// seconds.
+//
+// Request format,
+//
+//	GET /karajo/api/federation
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": [<PeerStatus>, ...]
+//	}
+func (k *Karajo) apiFederation(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp = `apiFederation`
+		res  = &libhttp.EndpointResponse{}
+	)
 
 	res.Code = http.StatusOK
-	res.Data = jobHTTP
+	res.Data = k.fed.get()
 
-	jobHTTP.Lock()
 	resbody, err = json.Marshal(res)
-	jobHTTP.Unlock()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 
-	return resbody, err
+	resbody, err = compressResponse(epr, resbody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	return resbody, nil
 }
 
-// apiJobHTTPLog HTTP API to get the logs for JobHTTP by its ID.
+// apiSchedule return the past runs and the next runs of all jobs between
+// "from" and "to".
 //
 // Request format,
 //
-//	GET /karajo/api/job_http/log?id=<jobID>&counter=<counter>
+//	GET /karajo/api/schedule?from=<RFC3339>&to=<RFC3339>
 //
-// If the jobID and counter exist it will return the JobLog object as JSON.
-func (k *Karajo) apiJobHTTPLog(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+// If "from" is empty it will default to 24 hours before now, and if "to"
+// is empty it will default to 24 hours after now.
+// The range between "from" and "to" must not exceed 31 days.
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <Schedule>
+//	}
+func (k *Karajo) apiSchedule(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
 	var (
-		logp       = `apiJobHTTPLog`
-		res        = &libhttp.EndpointResponse{}
-		id         = epr.HTTPRequest.Form.Get(paramNameID)
-		counterStr = epr.HTTPRequest.Form.Get(paramNameCounter)
+		logp = `apiSchedule`
+		res  = &libhttp.EndpointResponse{}
+		now  = timeNow().UTC()
 
-		buf     bytes.Buffer
-		job     *JobHTTP
-		jlog    *JobLog
-		counter int64
+		from time.Time
+		to   time.Time
 	)
 
-	id = strings.ToLower(id)
-	job = k.env.jobHTTP(id)
-	if job == nil {
-		return nil, errInvalidJobID(id)
-	}
-
-	counter, err = strconv.ParseInt(counterStr, 10, 64)
+	from, to, err = parseScheduleRange(epr, now.Add(-defScheduleRange), now.Add(defScheduleRange))
 	if err != nil {
-		res.Code = http.StatusNotFound
-		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
-		return nil, res
+		return nil, err
 	}
 
-	jlog = job.JobBase.getLog(counter)
-	if jlog == nil {
-		res.Code = http.StatusNotFound
-		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
-		goto out
-	}
+	res.Code = http.StatusOK
+	res.Data = newSchedule(k.env, from, to, now)
 
-	err = jlog.load()
+	resbody, err = json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	resbody, err = jlog.marshalJSON()
+	resbody, err = compressResponse(epr, resbody)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
+	return resbody, nil
+}
 
-	fmt.Fprintf(&buf, `{"code":200,"data":%s}`, resbody)
+// apiSchedulePreview return the next runs of all jobs between "from" and
+// "to" based on their schedule or interval, ignoring the job states, for
+// example to check when the jobs will run in the future.
+// The paused job is included, and the job with interval is assumed to be
+// started at "from".
+//
+// Request format,
+//
+//	GET /karajo/api/schedule/preview?from=<RFC3339>&to=<RFC3339>
+//
+// If "from" is empty it will default to now, and if "to" is empty it will
+// default to 24 hours after "from".
+// The range between "from" and "to" must not exceed 31 days.
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <Schedule>
+//	}
+func (k *Karajo) apiSchedulePreview(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp = `apiSchedulePreview`
+		res  = &libhttp.EndpointResponse{}
+		now  = timeNow().UTC()
+
+		from time.Time
+		to   time.Time
+	)
 
-	resbody, err = compressGzip(buf.Bytes())
+	from, err = parseTimeParam(epr.HTTPRequest.Form.Get(paramNameFrom), now)
 	if err != nil {
-		return nil, fmt.Errorf(`%s: %w`, logp, err)
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`invalid from: %s`, err)
+		return nil, res
+	}
+	from, to, err = parseScheduleRange(epr, from, from.Add(defScheduleRange))
+	if err != nil {
+		return nil, err
 	}
 
-	epr.HTTPWriter.Header().Set(libhttp.HeaderContentEncoding, libhttp.ContentEncodingGzip)
-	return resbody, nil
+	res.Code = http.StatusOK
+	res.Data, err = k.env.previewSchedule(from, to)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 
-out:
 	resbody, err = json.Marshal(res)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
+
+	resbody, err = compressResponse(epr, resbody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 	return resbody, nil
 }
 
-// apiJobHTTPPause HTTP API to pause running the JobHTTP.
-func (k *Karajo) apiJobHTTPPause(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+// parseScheduleRange parse the "from" and "to" parameters in the request.
+// If the parameter is empty, it will be set to defFrom or defTo.
+// It will return an error if the range is invalid or exceed
+// defScheduleRangeMax.
+func parseScheduleRange(epr *libhttp.EndpointRequest, defFrom, defTo time.Time) (from, to time.Time, err error) {
+	var res = &libhttp.EndpointResponse{}
+
+	from, err = parseTimeParam(epr.HTTPRequest.Form.Get(paramNameFrom), defFrom)
+	if err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`invalid from: %s`, err)
+		return from, to, res
+	}
+	to, err = parseTimeParam(epr.HTTPRequest.Form.Get(paramNameTo), defTo)
+	if err != nil {
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`invalid to: %s`, err)
+		return from, to, res
+	}
+	if to.Before(from) {
+		res.Code = http.StatusBadRequest
+		res.Message = `to is before from`
+		return from, to, res
+	}
+	if to.Sub(from) > defScheduleRangeMax {
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`range must not exceed %s`, defScheduleRangeMax)
+		return from, to, res
+	}
+	return from, to, nil
+}
+
+// parseTimeParam parse the time in RFC 3339 format from query parameter.
+// If v is empty it will return def.
+func parseTimeParam(v string, def time.Time) (t time.Time, err error) {
+	if len(v) == 0 {
+		return def, nil
+	}
+	t, err = time.Parse(time.RFC3339, v)
+	if err != nil {
+		return t, err
+	}
+	return t.UTC(), nil
+}
+
+// parsePauseUntil parse the optional parameter "until" or "duration" in
+// the pause request into the time when the paused job will be resumed
+// automatically.
+// The "until" is in RFC3339 format, while the "duration" is in Go
+// duration format, for example "1h30m", counted from now.
+// Only one of them can be set.
+// If both are empty, it return zero time, which pause the job
+// indefinitely.
+func parsePauseUntil(epr *libhttp.EndpointRequest, now time.Time) (until time.Time, err error) {
 	var (
 		res = &libhttp.EndpointResponse{}
 
-		id      string
-		jobHTTP *JobHTTP
+		untilStr = epr.HTTPRequest.Form.Get(paramNameUntil)
+		durStr   = epr.HTTPRequest.Form.Get(paramNameDuration)
 	)
 
-	err = k.httpAuthorize(epr, []byte(epr.HTTPRequest.URL.RawQuery))
-	if err != nil {
-		return nil, err
+	res.Code = http.StatusBadRequest
+
+	switch {
+	case len(untilStr) != 0 && len(durStr) != 0:
+		res.Message = `only one of until or duration can be set`
+		return until, res
+
+	case len(untilStr) != 0:
+		until, err = parseTimeParam(untilStr, until)
+		if err != nil {
+			res.Message = fmt.Sprintf(`invalid until: %s`, err)
+			return until, res
+		}
+
+	case len(durStr) != 0:
+		var dur time.Duration
+
+		dur, err = time.ParseDuration(durStr)
+		if err != nil {
+			res.Message = fmt.Sprintf(`invalid duration: %s`, err)
+			return until, res
+		}
+		until = now.Add(dur)
+
+	default:
+		return until, nil
 	}
 
-	id = epr.HTTPRequest.Form.Get(paramNameID)
-	jobHTTP = k.env.jobHTTP(id)
-	if jobHTTP == nil {
-		return nil, errInvalidJobID(id)
+	if !until.After(now) {
+		res.Message = fmt.Sprintf(`the resume time %s is not after now`,
+			until.Format(time.RFC3339))
+		return time.Time{}, res
 	}
+	return until.UTC(), nil
+}
+
+// apiJobExecArtifact list the artifacts of JobExec run by its ID and
+// counter.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/artifact?id=<jobID>&counter=<counter>
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": [<JobArtifact>, ...]
+//	}
+func (k *Karajo) apiJobExecArtifact(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp    = `apiJobExecArtifact`
+		res     = &libhttp.EndpointResponse{}
+		job     *JobExec
+		counter int64
+	)
 
-	jobHTTP.pause()
+	job, counter, err = k.artifactJob(epr)
+	if err != nil {
+		return nil, err
+	}
 
 	res.Code = http.StatusOK
-	res.Data = jobHTTP
+	res.Data, err = job.listArtifacts(counter)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 
-	return json.Marshal(res)
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
 }
 
-// apiJobHTTPResume HTTP API to resume running JobHTTP.
-func (k *Karajo) apiJobHTTPResume(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+// apiJobExecArtifactDownload download the artifact of JobExec run by its
+// ID, counter, and name.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/artifact/download?id=<jobID>&counter=<counter>&name=<name>
+//
+// Response format,
+//
+//	Content-Type: application/octet-stream
+//	Content-Disposition: attachment; filename="<base name>"
+//
+//	<artifact content>
+func (k *Karajo) apiJobExecArtifactDownload(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
 	var (
-		res = &libhttp.EndpointResponse{}
-
-		id      string
-		jobHTTP *JobHTTP
+		logp    = `apiJobExecArtifactDownload`
+		name    = epr.HTTPRequest.Form.Get(paramNameName)
+		job     *JobExec
+		fpath   string
+		counter int64
 	)
 
-	err = k.httpAuthorize(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	job, counter, err = k.artifactJob(epr)
 	if err != nil {
 		return nil, err
 	}
 
-	id = epr.HTTPRequest.Form.Get(paramNameID)
-	jobHTTP = k.env.jobHTTP(id)
-	if jobHTTP == nil {
-		return nil, errInvalidJobID(id)
+	fpath, err = job.artifactPath(counter, name)
+	if err == nil {
+		resbody, err = os.ReadFile(fpath)
+	}
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf(`%s: %w`, logp, errArtifactNotFound(name))
+		}
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	jobHTTP.resume(JobStatusStarted)
-
-	res.Code = http.StatusOK
-	res.Data = jobHTTP
+	epr.HTTPWriter.Header().Set(headerContentDisposition,
+		fmt.Sprintf(`attachment; filename=%q`, filepath.Base(fpath)))
 
-	return json.Marshal(res)
+	return resbody, nil
 }
 
-// httpAuthorize authorize request by checking the signature.
-func (k *Karajo) httpAuthorize(epr *libhttp.EndpointRequest, payload []byte) (err error) {
+// artifactJob return the JobExec and counter of artifact from the request
+// parameters "id" and "counter".
+func (k *Karajo) artifactJob(epr *libhttp.EndpointRequest) (job *JobExec, counter int64, err error) {
 	var (
-		gotSign string
-		expSign string
+		res        = &libhttp.EndpointResponse{}
+		id         = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		counterStr = epr.HTTPRequest.Form.Get(paramNameCounter)
 	)
 
-	gotSign = epr.HTTPRequest.Header.Get(HeaderNameXKarajoSign)
-	if len(gotSign) == 0 {
-		return &errUnauthorized
+	job = k.env.jobExec(id)
+	if job == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`job ID %s not found`, id)
+		return nil, 0, res
 	}
 
-	expSign = Sign(payload, k.env.secretb)
-	if expSign != gotSign {
-		return &errUnauthorized
+	counter, err = strconv.ParseInt(counterStr, 10, 64)
+	if err != nil || job.JobBase.getLog(counter) == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+		return nil, 0, res
 	}
+	return job, counter, nil
+}
 
-	return nil
+// apiJobExecApprove approve the run of JobExec that wait for approval.
+// The approver is the logged in user, and must be different from the user
+// that trigger the run.
+//
+// Request format,
+//
+//	POST /karajo/api/job_exec/approve
+//	Content-Type: application/x-www-form-urlencoded
+//	Cookie: karajo=<session>
+//	X-Karajo-Sign: <signature>
+//
+//	_karajo_epoch=&id=
+//
+// List of response,
+//
+//   - 200 OK: the run is approved and started.
+//   - 401 ERR_AUTH_UNAUTHORIZED: the user is not logged in.
+//   - 403 ERR_JOB_APPROVAL_SELF: the user is the requester of run.
+//   - 404 ERR_JOB_APPROVAL_NOT_FOUND: the job does not have run waiting
+//     approval or its expired.
+func (k *Karajo) apiJobExecApprove(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiJobExecApprove`
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var user = k.sessionUser(epr.HTTPRequest)
+	if user == nil {
+		return nil, &errAuthUnauthorized
+	}
+
+	var id = epr.HTTPRequest.Form.Get(paramNameID)
+
+	var job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+
+	err = job.approve(user.Name)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, job.ID, err)
+	}
+
+	var res = &libhttp.EndpointResponse{}
+
+	res.Code = http.StatusOK
+	res.Data = job
+
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobExecCancel cancel the JobExec execution.
+//
+// Request format,
+//
+//	POST /karajo/api/job_exec/cancel
+//	Content-Type: application/x-www-form-urlencoded
+//	X-Karajo-Sign: <signature>
+//
+//	_karajo_epoch=&id=
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <JobExec>
+//	}
+func (k *Karajo) apiJobExecCancel(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiJobExecCancel`
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var id = epr.HTTPRequest.Form.Get(paramNameID)
+
+	var job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+
+	job.JobBase.Cancel()
+
+	var res = &libhttp.EndpointResponse{}
+
+	res.Code = http.StatusOK
+	res.Data = job
+
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	return resbody, nil
+}
+
+// apiJobExecDryRun return the working directory, environment variables, and
+// list of commands that the JobExec will execute on the next run, without
+// running it.
+//
+// Request format,
+//
+//	POST /karajo/api/job_exec/dry_run
+//	Content-Type: application/x-www-form-urlencoded
+//	X-Karajo-Sign: <signature>
+//
+//	_karajo_epoch=&id=
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <JobExecDryRun>
+//	}
+func (k *Karajo) apiJobExecDryRun(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiJobExecDryRun`
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var id = epr.HTTPRequest.Form.Get(paramNameID)
+
+	var job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+
+	var res = &libhttp.EndpointResponse{}
+
+	res.Code = http.StatusOK
+	res.Data = job.dryRun()
+
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobExecLog get the JobExec log by its ID and counter.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/log?id=<jobID>&counter=<counter>
+//
+// Response format,
+//
+//	content-encoding: gzip
+//	content-type: application/json
+//	{
+//		"data": <JobLog>
+//	}
+//
+// If the jobID and counter exist it will return the JobLog object as JSON.
+func (k *Karajo) apiJobExecLog(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp       = `apiJobExecLog`
+		res        = &libhttp.EndpointResponse{}
+		id         = epr.HTTPRequest.Form.Get(paramNameID)
+		counterStr = epr.HTTPRequest.Form.Get(paramNameCounter)
+
+		buf     bytes.Buffer
+		job     *JobExec
+		jlog    *JobLog
+		counter int64
+	)
+
+	id = strings.ToLower(id)
+	job = k.env.jobExec(id)
+	if job == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`job ID %s not found`, id)
+		return nil, res
+	}
+
+	counter, err = strconv.ParseInt(counterStr, 10, 64)
+	if err != nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+		return nil, res
+	}
+
+	jlog = job.getLog(counter)
+	if jlog == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+		goto out
+	}
+
+	err = jlog.load()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	resbody, err = jlog.marshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	fmt.Fprintf(&buf, `{"code":200,"data":%s}`, resbody)
+
+	resbody, err = compressResponse(epr, buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+
+out:
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobExecLogRaw download the JobExec log by its ID and counter as
+// plain text.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/log/raw?id=<jobID>&counter=<counter>
+//
+// Response format,
+//
+//	Content-Type: text/plain; charset=utf-8
+//	Content-Disposition: attachment; filename="<log name>.log"
+//
+//	<log content>
+//
+// The response is compressed if the client accept it.
+func (k *Karajo) apiJobExecLogRaw(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp       = `apiJobExecLogRaw`
+		res        = &libhttp.EndpointResponse{}
+		id         = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		counterStr = epr.HTTPRequest.Form.Get(paramNameCounter)
+
+		job     *JobExec
+		jlog    *JobLog
+		counter int64
+	)
+
+	job = k.env.jobExec(id)
+	if job == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`job ID %s not found`, id)
+		return nil, res
+	}
+
+	counter, err = strconv.ParseInt(counterStr, 10, 64)
+	if err == nil {
+		jlog = job.JobBase.getLog(counter)
+	}
+	if jlog == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+		return nil, res
+	}
+
+	resbody, err = jlog.read()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	epr.HTTPWriter.Header().Set(headerContentDisposition,
+		fmt.Sprintf(`attachment; filename="%s.log"`, jlog.Name))
+
+	resbody, err = compressResponse(epr, resbody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobExecLogDiff compare two logs of JobExec, for example the last
+// success and the first failure, and return the difference in the
+// unified diff format.
+// The timestamp on each line is removed before compared.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/log/diff?id=<jobID>&a=<counter>&b=<counter>
+//
+// Response format,
+//
+//	Content-Type: text/plain; charset=utf-8
+//
+//	--- <log name of a>
+//	+++ <log name of b>
+//	@@ -<line>,<count> +<line>,<count> @@
+//	...
+//
+// The response body is empty if both logs have the same lines.
+// The response is compressed if the client accept it.
+func (k *Karajo) apiJobExecLogDiff(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp = `apiJobExecLogDiff`
+		res  = &libhttp.EndpointResponse{}
+		id   = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		job  = k.env.jobExec(id)
+	)
+	if job == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`job ID %s not found`, id)
+		return nil, res
+	}
+
+	var (
+		names    = []string{paramNameA, paramNameB}
+		jlogs    = make([]*JobLog, len(names))
+		contents = make([][]byte, len(names))
+
+		name       string
+		counterStr string
+		counter    int64
+		x          int
+	)
+	for x, name = range names {
+		counterStr = epr.HTTPRequest.Form.Get(name)
+		counter, err = strconv.ParseInt(counterStr, 10, 64)
+		if err == nil {
+			jlogs[x] = job.JobBase.getLog(counter)
+		}
+		if jlogs[x] == nil {
+			res.Code = http.StatusNotFound
+			res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+			return nil, res
+		}
+
+		contents[x], err = jlogs[x].read()
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	resbody = diffLogs(jlogs[0].Name, contents[0], jlogs[1].Name, contents[1])
+
+	resbody, err = compressResponse(epr, resbody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobExecLogSearch search the text in the logs of all JobExec.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/log/search?q=<text>&id=<jobID>&status=<status>
+//
+// The "q" parameter is required, it is matched case insensitively on each
+// line of log.
+// The "id" and "status" parameters are optional, to search only the logs
+// of specific job or with specific status.
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": [<LogSearchResult>, ...]
+//	}
+func (k *Karajo) apiJobExecLogSearch(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp   = `apiJobExecLogSearch`
+		res    = &libhttp.EndpointResponse{}
+		query  = epr.HTTPRequest.Form.Get(paramNameQuery)
+		id     = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		status = epr.HTTPRequest.Form.Get(paramNameStatus)
+	)
+
+	if len(strings.TrimSpace(query)) == 0 {
+		res.Code = http.StatusBadRequest
+		res.Message = `empty query`
+		return nil, res
+	}
+
+	res.Code = http.StatusOK
+	res.Data = k.env.searchLogs(query, id, status)
+
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	resbody, err = compressResponse(epr, resbody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobExecLogSSE stream the JobExec log using Server-Sent Events.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/log/stream?id=<jobID>&counter=<counter>&offset=<offset>
+//	Accept: text/event-stream
+//
+// See [Karajo.streamJobLog] for the response format.
+func (k *Karajo) apiJobExecLogSSE(sse *libhttp.SSEConn) {
+	var (
+		id  = strings.ToLower(sse.HTTPRequest.Form.Get(paramNameID))
+		job = k.env.jobExec(id)
+	)
+	if job == nil {
+		_ = sse.WriteEvent(sseEventError, fmt.Sprintf(`job ID %s not found`, id), nil)
+		return
+	}
+	k.streamJobLog(sse, &job.JobBase)
+}
+
+// apiJobExecNext return the next n runs of JobExec, computed from its
+// schedule or interval, up to one year from now.
+//
+// Request format,
+//
+//	GET /karajo/api/job_exec/next?id=<string>&n=<number>
+//
+// The "n" is optional, default to 10 and must not exceed 1000.
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <JobNextRuns>
+//	}
+//
+// List of response,
+//
+//   - 200: OK, if job ID is valid.
+//   - 400: If the "n" is invalid.
+//   - 404: If job ID not found.
+func (k *Karajo) apiJobExecNext(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		logp = `apiJobExecNext`
+		res  = &libhttp.EndpointResponse{}
+		id   = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		nstr = epr.HTTPRequest.Form.Get(paramNameN)
+		n    = defJobNextRuns
+	)
+
+	if len(nstr) != 0 {
+		n, err = strconv.Atoi(nstr)
+		if err != nil || n <= 0 || n > defScheduleMaxNextRuns {
+			res.Code = http.StatusBadRequest
+			res.Message = fmt.Sprintf(`invalid n %q, must be between 1 and %d`,
+				nstr, defScheduleMaxNextRuns)
+			return nil, res
+		}
+	}
+
+	var job = k.env.jobExec(id)
+	if job == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`job ID %s not found`, id)
+		return nil, res
+	}
+
+	res.Code = http.StatusOK
+	res.Data = job.listNextRuns(timeNow().UTC(), n)
+
+	resb, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resb, nil
+}
+
+// apiJobExecPause pause the JobExec.
+//
+// Request format,
+//
+//	POST /karajo/api/job_exec/pause
+//	Content-Type: application/x-www-form-urlencoded
+//
+//	_karajo_epoch=&id=&until=&duration=
+//
+// The "until" and "duration" are optional, see [parsePauseUntil].
+//
+// List of response,
+//
+//   - 200: OK, if job ID is valid.
+//   - 400: If the "until" or "duration" is invalid.
+//   - 404: If job ID not found.
+func (k *Karajo) apiJobExecPause(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		logp = `apiJobExecPause`
+
+		res   *libhttp.EndpointResponse
+		job   *JobExec
+		id    string
+		until time.Time
+	)
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	until, err = parsePauseUntil(epr, timeNow())
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+
+	job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+
+	job.pause(until, ``)
+
+	res = &libhttp.EndpointResponse{}
+	res.Code = http.StatusOK
+	res.Data = job
+
+	resb, err = json.Marshal(res)
+
+	return resb, err
+}
+
+// apiJobExecResume resume the paused JobExec.
+//
+// # Request
+//
+//	POST /karajo/api/job_exec/resume
+//	Content-Type: application/x-www-form-urlencoded
+//
+//	_karajo_epoch=&id=
+//
+// # Response
+//
+//   - 200: OK, if job ID is valid.
+//   - 404: If job ID not found.
+func (k *Karajo) apiJobExecResume(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		logp = `apiJobExecResume`
+
+		res *libhttp.EndpointResponse
+		job *JobExec
+		id  string
+	)
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+
+	job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+
+	job.resume(``)
+
+	res = &libhttp.EndpointResponse{}
+	res.Code = http.StatusOK
+	res.Data = job
+
+	resb, err = json.Marshal(res)
+
+	return resb, err
+}
+
+// apiJobExecDisable disable the JobExec until it enabled again, even
+// after restart.
+//
+// # Request
+//
+//	POST /karajo/api/job_exec/disable
+//	Content-Type: application/x-www-form-urlencoded
+//
+//	_karajo_epoch=&id=
+//
+// # Response
+//
+//   - 200: OK, if job ID is valid.
+//   - 404: If job ID not found.
+func (k *Karajo) apiJobExecDisable(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	return k.jobExecSetDisabled(epr, `apiJobExecDisable`, true)
+}
+
+// apiJobExecEnable enable the disabled JobExec.
+//
+// # Request
+//
+//	POST /karajo/api/job_exec/enable
+//	Content-Type: application/x-www-form-urlencoded
+//
+//	_karajo_epoch=&id=
+//
+// # Response
+//
+//   - 200: OK, if job ID is valid.
+//   - 404: If job ID not found.
+func (k *Karajo) apiJobExecEnable(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	return k.jobExecSetDisabled(epr, `apiJobExecEnable`, false)
+}
+
+// jobExecSetDisabled set the Disabled state of JobExec in request.
+func (k *Karajo) jobExecSetDisabled(epr *libhttp.EndpointRequest, logp string, disabled bool) (resb []byte, err error) {
+	var (
+		res *libhttp.EndpointResponse
+		job *JobExec
+		id  string
+	)
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+
+	job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+
+	err = job.setDisabled(disabled)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	res = &libhttp.EndpointResponse{}
+	res.Code = http.StatusOK
+	res.Data = job
+
+	resb, err = json.Marshal(res)
+
+	return resb, err
+}
+
+// apiJobExecSecret list the status of secret of all JobExec, without the
+// secret itself.
+//
+// # Request
+//
+//	GET /karajo/api/job_exec/secret?_karajo_epoch=
+//	X-Karajo-Sign: <signature>
+//
+// # Response
+//
+//   - 200: OK, with list of [JobExecSecret] ordered by job ID.
+//   - 401: If the signature is empty or invalid.
+func (k *Karajo) apiJobExecSecret(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var logp = `apiJobExecSecret`
+
+	err = k.httpAuthorize(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	k.env.jobsMtx.RLock()
+	var (
+		list = make([]JobExecSecret, 0, len(k.env.ExecJobs))
+		job  *JobExec
+	)
+	for _, job = range k.env.ExecJobs {
+		list = append(list, job.secretStatus(k.env))
+	}
+	k.env.jobsMtx.RUnlock()
+	sort.Slice(list, func(x, y int) bool {
+		return list[x].ID < list[y].ID
+	})
+
+	var res = &libhttp.EndpointResponse{}
+	res.Code = http.StatusOK
+	res.Data = list
+
+	return json.Marshal(res)
+}
+
+// apiJobExecSecretRotate generate new secret for the JobExec.
+// The previous secret is still valid until the grace period passed, so
+// the webhook sender can be updated without losing any request.
+//
+// # Request
+//
+//	POST /karajo/api/job_exec/secret/rotate
+//	Content-Type: application/x-www-form-urlencoded
+//	X-Karajo-Sign: <signature>
+//
+//	_karajo_epoch=&id=&grace_period=
+//
+// The grace_period is optional, default to 24 hours.
+//
+// # Response
+//
+//   - 200: OK, with [JobExecSecretRotate] that contains the new secret.
+//   - 400: If the grace_period is invalid or the job does not use
+//     secret.
+//   - 401: If the signature is empty or invalid.
+//   - 404: If job ID not found.
+func (k *Karajo) apiJobExecSecretRotate(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var logp = `apiJobExecSecretRotate`
+
+	err = k.httpAuthorizeJob(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		id       = epr.HTTPRequest.Form.Get(paramNameID)
+		rawGrace = epr.HTTPRequest.Form.Get(paramNameGracePeriod)
+		grace    = defJobSecretGracePeriod
+		res      = &libhttp.EndpointResponse{}
+	)
+
+	var job = k.env.jobExec(id)
+	if job == nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, errJobNotFound(id))
+	}
+	if job.AuthKind == JobAuthKindSourcehut {
+		res.Code = http.StatusBadRequest
+		res.Message = fmt.Sprintf(`job %s use %s signature that does not require secret`,
+			id, job.AuthKind)
+		return nil, res
+	}
+	if len(rawGrace) != 0 {
+		grace, err = time.ParseDuration(rawGrace)
+		if err != nil || grace < 0 {
+			res.Code = http.StatusBadRequest
+			res.Message = fmt.Sprintf(`invalid %s %q`, paramNameGracePeriod, rawGrace)
+			return nil, res
+		}
+	}
+
+	var rot *JobExecSecretRotate
+
+	rot, err = job.rotateSecret(grace)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, id, err)
+	}
+
+	mlog.Outf(`%s: %s: secret rotated, previous secret valid until %s`,
+		logp, id, rot.PrevExpiredAt.Format(time.RFC3339))
+
+	epr.HTTPWriter.Header().Set(libhttp.HeaderCacheControl, `no-store`)
+
+	res.Code = http.StatusOK
+	res.Data = rot
+
+	return json.Marshal(res)
+}
+
+// apiJobHTTP HTTP API to get the JobHTTP information by its ID.
+func (k *Karajo) apiJobHTTP(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		res     = &libhttp.EndpointResponse{}
+		id      = epr.HTTPRequest.Form.Get(paramNameID)
+		jobHTTP = k.env.jobHTTP(id)
+	)
+
+	if jobHTTP == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	res.Code = http.StatusOK
+	res.Data = jobHTTP
+
+	resbody, err = json.Marshal(res)
+
+	return resbody, err
+}
+
+// apiJobHTTPLogSSE stream the JobHTTP log using Server-Sent Events.
+//
+// Request format,
+//
+//	GET /karajo/api/job_http/log/stream?id=<jobID>&counter=<counter>&offset=<offset>
+//	Accept: text/event-stream
+//
+// See [Karajo.streamJobLog] for the response format.
+func (k *Karajo) apiJobHTTPLogSSE(sse *libhttp.SSEConn) {
+	var (
+		id  = strings.ToLower(sse.HTTPRequest.Form.Get(paramNameID))
+		job = k.env.jobHTTP(id)
+	)
+	if job == nil {
+		_ = sse.WriteEvent(sseEventError, fmt.Sprintf(`job ID %s not found`, id), nil)
+		return
+	}
+	k.streamJobLog(sse, &job.JobBase)
+}
+
+// apiJobHTTPLog HTTP API to get the logs for JobHTTP by its ID.
+//
+// Request format,
+//
+//	GET /karajo/api/job_http/log?id=<jobID>&counter=<counter>
+//
+// If the jobID and counter exist it will return the JobLog object as JSON.
+func (k *Karajo) apiJobHTTPLog(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		id  = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		job = k.env.jobHTTP(id)
+	)
+	if job == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	return jobLogResponse(epr, `apiJobHTTPLog`, &job.JobBase)
+}
+
+// jobLogResponse return the job log with the counter from request as
+// JSON response.
+// The logp is used as prefix of returned error.
+func jobLogResponse(epr *libhttp.EndpointRequest, logp string, job *JobBase) (resbody []byte, err error) {
+	var (
+		res        = &libhttp.EndpointResponse{}
+		counterStr = epr.HTTPRequest.Form.Get(paramNameCounter)
+
+		buf     bytes.Buffer
+		jlog    *JobLog
+		counter int64
+	)
+
+	counter, err = strconv.ParseInt(counterStr, 10, 64)
+	if err != nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+		return nil, res
+	}
+
+	jlog = job.getLog(counter)
+	if jlog == nil {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`log #%s not found`, counterStr)
+		goto out
+	}
+
+	err = jlog.load()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	resbody, err = jlog.marshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	fmt.Fprintf(&buf, `{"code":200,"data":%s}`, resbody)
+
+	resbody, err = compressResponse(epr, buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+
+out:
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiJobHTTPPause HTTP API to pause running the JobHTTP.
+func (k *Karajo) apiJobHTTPPause(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		res = &libhttp.EndpointResponse{}
+
+		id      string
+		jobHTTP *JobHTTP
+	)
+
+	err = k.httpAuthorizeJob(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	var until time.Time
+
+	until, err = parsePauseUntil(epr, timeNow())
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+	jobHTTP = k.env.jobHTTP(id)
+	if jobHTTP == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	jobHTTP.pause(until, JobStatusStarted)
+
+	res.Code = http.StatusOK
+	res.Data = jobHTTP
+
+	return json.Marshal(res)
+}
+
+// apiJobHTTPDisable HTTP API to disable the JobHTTP until it enabled
+// again, even after restart.
+func (k *Karajo) apiJobHTTPDisable(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	return k.jobHTTPSetDisabled(epr, true)
+}
+
+// apiJobHTTPEnable HTTP API to enable the disabled JobHTTP.
+func (k *Karajo) apiJobHTTPEnable(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	return k.jobHTTPSetDisabled(epr, false)
+}
+
+// jobHTTPSetDisabled set the Disabled state of JobHTTP in request.
+func (k *Karajo) jobHTTPSetDisabled(epr *libhttp.EndpointRequest, disabled bool) (resb []byte, err error) {
+	var (
+		res = &libhttp.EndpointResponse{}
+
+		id      string
+		jobHTTP *JobHTTP
+	)
+
+	err = k.httpAuthorizeJob(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+	jobHTTP = k.env.jobHTTP(id)
+	if jobHTTP == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	err = jobHTTP.setDisabled(disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Code = http.StatusOK
+	res.Data = jobHTTP
+
+	return json.Marshal(res)
+}
+
+// apiJobHTTPResume HTTP API to resume running JobHTTP.
+func (k *Karajo) apiJobHTTPResume(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		res = &libhttp.EndpointResponse{}
+
+		id      string
+		jobHTTP *JobHTTP
+	)
+
+	err = k.httpAuthorizeJob(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+	jobHTTP = k.env.jobHTTP(id)
+	if jobHTTP == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	jobHTTP.resume(JobStatusStarted)
+
+	res.Code = http.StatusOK
+	res.Data = jobHTTP
+
+	return json.Marshal(res)
+}
+
+// apiJobSQL HTTP API to get the JobSQL information by its ID.
+func (k *Karajo) apiJobSQL(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		res    = &libhttp.EndpointResponse{}
+		id     = epr.HTTPRequest.Form.Get(paramNameID)
+		jobSQL = k.env.jobSQL(id)
+	)
+
+	if jobSQL == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	res.Code = http.StatusOK
+	res.Data = jobSQL
+
+	return json.Marshal(res)
+}
+
+// apiJobSQLLogSSE stream the JobSQL log using Server-Sent Events.
+//
+// Request format,
+//
+//	GET /karajo/api/job_sql/log/stream?id=<jobID>&counter=<counter>&offset=<offset>
+func (k *Karajo) apiJobSQLLogSSE(sse *libhttp.SSEConn) {
+	var (
+		id  = strings.ToLower(sse.HTTPRequest.Form.Get(paramNameID))
+		job = k.env.jobSQL(id)
+	)
+	if job == nil {
+		_ = sse.WriteEvent(sseEventError, fmt.Sprintf(`job ID %s not found`, id), nil)
+		return
+	}
+	k.streamJobLog(sse, &job.JobBase)
+}
+
+// apiJobSQLLog HTTP API to get the logs for JobSQL by its ID.
+//
+// Request format,
+//
+//	GET /karajo/api/job_sql/log?id=<jobID>&counter=<counter>
+//
+// If the jobID and counter exist it will return the JobLog object as JSON.
+func (k *Karajo) apiJobSQLLog(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		id  = strings.ToLower(epr.HTTPRequest.Form.Get(paramNameID))
+		job = k.env.jobSQL(id)
+	)
+	if job == nil {
+		return nil, errInvalidJobID(id)
+	}
+	return jobLogResponse(epr, `apiJobSQLLog`, &job.JobBase)
+}
+
+// apiJobSQLPause HTTP API to pause running the JobSQL.
+func (k *Karajo) apiJobSQLPause(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		res = &libhttp.EndpointResponse{}
+
+		id     string
+		jobSQL *JobSQL
+	)
+
+	err = k.httpAuthorizeJob(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	var until time.Time
+
+	until, err = parsePauseUntil(epr, timeNow())
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+	jobSQL = k.env.jobSQL(id)
+	if jobSQL == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	jobSQL.pause(until, JobStatusStarted)
+
+	res.Code = http.StatusOK
+	res.Data = jobSQL
+
+	return json.Marshal(res)
+}
+
+// apiJobSQLDisable HTTP API to disable the JobSQL until it enabled
+// again, even after restart.
+func (k *Karajo) apiJobSQLDisable(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	return k.jobSQLSetDisabled(epr, true)
+}
+
+// apiJobSQLEnable HTTP API to enable the disabled JobSQL.
+func (k *Karajo) apiJobSQLEnable(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	return k.jobSQLSetDisabled(epr, false)
+}
+
+// jobSQLSetDisabled set the Disabled state of JobSQL in request.
+func (k *Karajo) jobSQLSetDisabled(epr *libhttp.EndpointRequest, disabled bool) (resb []byte, err error) {
+	var (
+		res = &libhttp.EndpointResponse{}
+
+		id     string
+		jobSQL *JobSQL
+	)
+
+	err = k.httpAuthorizeJob(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+	jobSQL = k.env.jobSQL(id)
+	if jobSQL == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	err = jobSQL.setDisabled(disabled)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Code = http.StatusOK
+	res.Data = jobSQL
+
+	return json.Marshal(res)
+}
+
+// apiJobSQLResume HTTP API to resume running JobSQL.
+func (k *Karajo) apiJobSQLResume(epr *libhttp.EndpointRequest) (resb []byte, err error) {
+	var (
+		res = &libhttp.EndpointResponse{}
+
+		id     string
+		jobSQL *JobSQL
+	)
+
+	err = k.httpAuthorizeJob(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, err
+	}
+
+	id = epr.HTTPRequest.Form.Get(paramNameID)
+	jobSQL = k.env.jobSQL(id)
+	if jobSQL == nil {
+		return nil, errInvalidJobID(id)
+	}
+
+	jobSQL.resume(JobStatusStarted)
+
+	res.Code = http.StatusOK
+	res.Data = jobSQL
+
+	return json.Marshal(res)
+}
+
+// apiAgentPoll wait for the task of JobExec that target the agent name.
+// The request wait until new task is available or 30 seconds passed,
+// whichever come first.
+//
+// Request format,
+//
+//	GET /karajo/api/agent/poll?_karajo_epoch=&name=
+//	X-Karajo-Sign: <signature>
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"data": <AgentTask>
+//	}
+//
+// The data is null if there is no task.
+func (k *Karajo) apiAgentPoll(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiAgentPoll`
+
+	err = k.httpAuthorize(epr, []byte(epr.HTTPRequest.URL.RawQuery))
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var name = strings.TrimSpace(epr.HTTPRequest.Form.Get(paramNameName))
+	if len(name) == 0 {
+		return nil, fmt.Errorf(`%s: %w`, logp, errInvalidAgentName(name))
+	}
+
+	var res = &libhttp.EndpointResponse{}
+
+	res.Code = http.StatusOK
+	res.Data = k.env.agents.poll(epr.HTTPRequest.Context(), name, defAgentPollWait)
+
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
+
+// apiAgentLog append the output from agent into the log of task.
+//
+// Request format,
+//
+//	POST /karajo/api/agent/log
+//	Content-Type: application/x-www-form-urlencoded
+//	X-Karajo-Sign: <signature>
+//
+//	_karajo_epoch=&id=&output=
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"code": 200
+//	}
+//
+// If the task has been canceled, the response code is 410, signaling
+// the agent to stop executing the task.
+func (k *Karajo) apiAgentLog(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiAgentLog`
+
+	err = k.httpAuthorize(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		id     = epr.HTTPRequest.Form.Get(paramNameID)
+		output = epr.HTTPRequest.Form.Get(paramNameOutput)
+	)
+
+	err = k.env.agents.log(id, []byte(output))
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var res = &libhttp.EndpointResponse{}
+	res.Code = http.StatusOK
+
+	return json.Marshal(res)
+}
+
+// apiAgentDone mark the task as done by agent.
+// The error parameter contains the error from executing the commands, or
+// empty if all commands run successfully.
+//
+// Request format,
+//
+//	POST /karajo/api/agent/done
+//	Content-Type: application/x-www-form-urlencoded
+//	X-Karajo-Sign: <signature>
+//
+//	_karajo_epoch=&id=&error=
+//
+// Response format,
+//
+//	Content-Type: application/json
+//	{
+//		"code": 200
+//	}
+func (k *Karajo) apiAgentDone(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var logp = `apiAgentDone`
+
+	err = k.httpAuthorize(epr, epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		id     = epr.HTTPRequest.Form.Get(paramNameID)
+		errMsg = epr.HTTPRequest.Form.Get(paramNameError)
+	)
+
+	err = k.env.agents.finish(id, errMsg)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var res = &libhttp.EndpointResponse{}
+	res.Code = http.StatusOK
+
+	return json.Marshal(res)
+}
+
+// httpAuthorize authorize request by checking the signature using the
+// global secret.
+func (k *Karajo) httpAuthorize(epr *libhttp.EndpointRequest, payload []byte) (err error) {
+	var gotSign = epr.HTTPRequest.Header.Get(HeaderNameXKarajoSign)
+
+	err = k.verifySign(payload, gotSign)
+	if err != nil {
+		return err
+	}
+	return k.httpAuthorizeRequest(epr, payload, gotSign)
+}
+
+// httpAuthorizeJob authorize request for the job API by checking the
+// signature using the global secret or, if the job is in namespace, the
+// namespace secret.
+// It must be used only by the API that operate on the job in parameter
+// "id" or in the namespace path.
+func (k *Karajo) httpAuthorizeJob(epr *libhttp.EndpointRequest, payload []byte) (err error) {
+	var gotSign = epr.HTTPRequest.Header.Get(HeaderNameXKarajoSign)
+
+	err = k.verifySign(payload, gotSign)
+	if err != nil {
+		var ns = k.requestNamespace(epr.HTTPRequest)
+		if ns == nil || ns.secret == k.env.secret {
+			return err
+		}
+		err = verifySecretSign(ns.secret, payload, gotSign)
+		if err != nil {
+			return err
+		}
+	}
+	return k.httpAuthorizeRequest(epr, payload, gotSign)
+}
+
+// httpAuthorizeRequest verify the epoch and the replay of the signed
+// request.
+func (k *Karajo) httpAuthorizeRequest(epr *libhttp.EndpointRequest, payload []byte, gotSign string) (err error) {
+	err = k.verifyEpoch(payload)
+	if err != nil {
+		epr.HTTPWriter.Header().Set(HeaderNameXKarajoEpoch,
+			strconv.FormatInt(timeNow().Unix(), 10))
+		return err
+	}
+
+	// The GET request does not change the state, so it can be
+	// repeated, for example when polling the log.
+	if epr.HTTPRequest.Method != http.MethodGet && k.env.nonces.isReplayed(gotSign) {
+		return &errAuthReplay
+	}
+	return nil
+}
+
+// verifyEpoch verify that the parameter "_karajo_epoch" in the payload is
+// within the [Env.SignMaxAge] from the server time.
+// The payload is the signed query or form body.
+func (k *Karajo) verifyEpoch(payload []byte) (err error) {
+	if k.env.SignMaxAge <= 0 {
+		return nil
+	}
+
+	var (
+		now = timeNow().Unix()
+
+		params url.Values
+		epoch  int64
+	)
+
+	// The ParseQuery return the valid parameters even if some of them
+	// is invalid.
+	params, _ = url.ParseQuery(string(payload))
+
+	epoch, err = strconv.ParseInt(params.Get(paramNameKarajoEpoch), 10, 64)
+	if err != nil {
+		return &liberrors.E{
+			Code: errAuthEpoch.Code,
+			Name: errAuthEpoch.Name,
+			Message: fmt.Sprintf(`%s: missing or invalid %s, server epoch is %d`,
+				errAuthEpoch.Message, paramNameKarajoEpoch, now),
+		}
+	}
+
+	var skew = time.Duration(now-epoch) * time.Second
+	if skew < 0 {
+		skew = -skew
+	}
+	if skew > k.env.SignMaxAge {
+		return &liberrors.E{
+			Code: errAuthEpoch.Code,
+			Name: errAuthEpoch.Name,
+			Message: fmt.Sprintf(`%s: epoch %d is off by %s, server epoch is %d`,
+				errAuthEpoch.Message, epoch, skew, now),
+		}
+	}
+	return nil
+}
+
+// verifySign verify the signature of payload using the Env Secret.
+func (k *Karajo) verifySign(payload []byte, gotSign string) (err error) {
+	return verifySecretSign(k.env.secret, payload, gotSign)
+}
+
+// verifySecretSign verify the signature of payload using the secret sv.
+func verifySecretSign(sv *secretValue, payload []byte, gotSign string) (err error) {
+	if len(gotSign) == 0 {
+		return &errUnauthorized
+	}
+
+	var (
+		secret  []byte
+		expSign string
+	)
+
+	secret, err = sv.get()
+	if err != nil {
+		mlog.Errf(`httpAuthorize: %s`, err)
+		return &errUnauthorized
+	}
+
+	expSign = Sign(payload, secret)
+	if expSign == gotSign {
+		return nil
+	}
+
+	// The external secret may has been changed, fetch it again.
+	var changed bool
+
+	changed, err = sv.refresh()
+	if err != nil {
+		mlog.Errf(`httpAuthorize: %s`, err)
+		return &errUnauthorized
+	}
+	if !changed {
+		return &errUnauthorized
+	}
+
+	secret, _ = sv.get()
+	expSign = Sign(payload, secret)
+	if expSign != gotSign {
+		return &errUnauthorized
+	}
+	return nil
+}
+
+// compressResponse compress the response body based on the
+// Accept-Encoding in the request header.
+// If the client does not accept brotli or gzip, the body is returned as
+// is.
+func compressResponse(epr *libhttp.EndpointRequest, in []byte) (out []byte, err error) {
+	var (
+		logp     = `compressResponse`
+		encoding = negotiateEncoding(epr.HTTPRequest.Header.Get(libhttp.HeaderAcceptEncoding))
+	)
+
+	epr.HTTPWriter.Header().Add(headerVary, libhttp.HeaderAcceptEncoding)
+
+	switch encoding {
+	case contentEncodingBrotli:
+		out, err = compressBrotli(in)
+	case libhttp.ContentEncodingGzip:
+		out, err = compressGzip(in)
+	default:
+		return in, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	epr.HTTPWriter.Header().Set(libhttp.HeaderContentEncoding, encoding)
+
+	return out, nil
+}
+
+// negotiateEncoding return the content encoding for response based on the
+// value of Accept-Encoding header.
+// The brotli is preferred over gzip, and gzip over identity, if they have
+// the same quality value.
+// If none of them is acceptable it will return identity.
+func negotiateEncoding(acceptEncoding string) (encoding string) {
+	if len(acceptEncoding) == 0 {
+		return contentEncodingIdentity
+	}
+
+	var (
+		qvalues = make(map[string]float64)
+
+		item   string
+		coding string
+		params string
+		param  string
+		q      float64
+		ok     bool
+		err    error
+	)
+	for _, item = range strings.Split(acceptEncoding, `,`) {
+		coding, params, _ = strings.Cut(item, `;`)
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		if len(coding) == 0 {
+			continue
+		}
+
+		q = 1
+		for _, param = range strings.Split(params, `;`) {
+			param = strings.TrimSpace(param)
+			if !strings.HasPrefix(param, `q=`) {
+				continue
+			}
+			q, err = strconv.ParseFloat(param[2:], 64)
+			if err != nil {
+				q = 0
+			}
+		}
+		qvalues[coding] = q
+	}
+
+	var (
+		identityq float64
+		bestq     float64
+	)
+
+	// The identity is always acceptable as the last resort, unless its
+	// quality value is defined explicitly.
+	identityq, ok = qvalues[contentEncodingIdentity]
+	if !ok {
+		identityq = qvalues[`*`]
+	}
+
+	encoding = contentEncodingIdentity
+	for _, coding = range []string{contentEncodingBrotli, libhttp.ContentEncodingGzip} {
+		q, ok = qvalues[coding]
+		if !ok {
+			q = qvalues[`*`]
+		}
+		if q > bestq && q >= identityq {
+			encoding = coding
+			bestq = q
+		}
+	}
+	return encoding
+}
+
+// compressBrotli compress the input using brotli.
+func compressBrotli(in []byte) (out []byte, err error) {
+	var (
+		logp  = `compressBrotli`
+		bufbr = bytes.Buffer{}
+		brw   = brotli.NewWriter(&bufbr)
+	)
+
+	_, err = brw.Write(in)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = brw.Close()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	out = bufbr.Bytes()
+
+	return out, nil
 }
 
 func compressGzip(in []byte) (out []byte, err error) {
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// listenHooks open the network listener on ListenAddressHooks, if its
+// set.
+// If the port in ListenAddressHooks is 0, the ListenAddressHooks is
+// replaced with the actual address.
+func (k *Karajo) listenHooks() (err error) {
+	if len(k.env.ListenAddressHooks) == 0 {
+		return nil
+	}
+
+	var logp = `listenHooks`
+
+	k.listenerHooks, err = net.Listen(`tcp`, k.env.ListenAddressHooks)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		addr    = k.listenerHooks.Addr().String()
+		port    string
+		errPort error
+	)
+	_, port, errPort = net.SplitHostPort(k.env.ListenAddressHooks)
+	if errPort == nil && port == `0` {
+		k.env.ListenAddressHooks = addr
+	}
+
+	mlog.Outf(`listening hooks at %s`, addr)
+
+	return nil
+}
+
+// initHTTPdHooks create the HTTP server for the hooks listener.
+// The server use the same handler as HTTPd, so the rate limit, request
+// body limit, and the hook authorization still applied.
+func (k *Karajo) initHTTPdHooks() {
+	if k.listenerHooks == nil {
+		return
+	}
+	k.httpdHooks = &http.Server{
+		Handler:        k.onlyHooks(k.HTTPd.Server.Handler),
+		ReadTimeout:    k.HTTPd.Server.ReadTimeout,
+		WriteTimeout:   k.HTTPd.Server.WriteTimeout,
+		MaxHeaderBytes: k.HTTPd.Server.MaxHeaderBytes,
+	}
+}
+
+// onlyHooks wrap the HTTP handler to serve only the JobExec hooks, with
+// or without BasePath, any other requests are responded with 404.
+func (k *Karajo) onlyHooks(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var path = strings.TrimPrefix(req.URL.Path, k.env.BasePath)
+		if !isHookPath(path) {
+			http.NotFound(w, req)
+			return
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// serveHooks serve the HTTP request on the hooks listener.
+func (k *Karajo) serveHooks() {
+	var err = k.httpdHooks.Serve(k.listenerHooks)
+	if err != nil && !errors.Is(err, http.ErrServerClosed) {
+		mlog.Errf(`serveHooks: %s`, err)
+	}
+}
+
+// stopHooks shutdown the hooks server, waiting for the active requests
+// until timeout, and close its listener.
+func (k *Karajo) stopHooks(timeout time.Duration) (err error) {
+	if k.listenerHooks == nil {
+		return nil
+	}
+
+	var ctx, cancel = context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	err = k.httpdHooks.Shutdown(ctx)
+
+	// Close the listener in case the server is not started.
+	_ = k.listenerHooks.Close()
+
+	return err
+}
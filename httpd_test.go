@@ -4,16 +4,21 @@
 package karajo
 
 import (
+	"bytes"
 	"net/http"
 	"net/http/httptest"
 	"net/http/httputil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"regexp"
 	"testing"
+	"time"
 
 	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/totp"
 )
 
 func TestKarajo_apiAuthLogin(t *testing.T) {
@@ -29,8 +34,9 @@ func TestKarajo_apiAuthLogin(t *testing.T) {
 			},
 		}
 		k = &Karajo{
-			env: env,
-			sm:  newSessionManager(),
+			env:   env,
+			sm:    newSessionManager(),
+			authl: newAuthLimiter(2, time.Minute),
 		}
 	)
 
@@ -68,12 +74,28 @@ func TestKarajo_apiAuthLogin(t *testing.T) {
 		expResBody: `{"code":200}`,
 		expResHeader: "HTTP/1.1 200 OK\r\n" +
 			"Connection: close\r\n" +
-			"Set-Cookie: karajo=.{32}?; Path=/; Max-Age=86400; HttpOnly\r\n\r\n",
+			"Set-Cookie: karajo=.{32}?; Path=/; Max-Age=86400; HttpOnly; SameSite=Lax\r\n" +
+			"Set-Cookie: karajo_csrf=.{32}?; Path=/; Max-Age=86400; SameSite=Strict\r\n\r\n",
 		expSM: &sessionManager{
-			value: map[string]*User{
-				`<RANDOM>`: user,
+			value: map[string]*session{
+				`<RANDOM>`: {user: user},
 			},
 		},
+	}, {
+		desc:     `with invalid password after success`,
+		name:     user.Name,
+		pass:     `invalid`,
+		expError: errAuthLogin.Message,
+	}, {
+		desc:     `with invalid password reaching max attempts`,
+		name:     user.Name,
+		pass:     `invalid`,
+		expError: errAuthLogin.Message,
+	}, {
+		desc:     `with valid password while locked`,
+		name:     user.Name,
+		pass:     `s3cret`,
+		expError: errAuthLocked.Message,
 	}}
 
 	var (
@@ -120,6 +142,198 @@ func TestKarajo_apiAuthLogin(t *testing.T) {
 	}
 }
 
+func TestKarajo_apiAuthLogin_withTOTP(t *testing.T) {
+	var (
+		user = &User{
+			Name:       `tester`,
+			Password:   `$2a$10$9XMRfqpnzY2421fwYm5dd.CidJf7dHHWIESeeNGXuajHRf.Lqzy7a`, // s3cret
+			TOTPSecret: `JBSWY3DPEHPK3PXP`,
+		}
+		k = &Karajo{
+			env: &Env{
+				Users: map[string]*User{
+					user.Name: user,
+				},
+			},
+			sm:    newSessionManager(),
+			authl: newAuthLimiter(0, 0),
+		}
+		proto = totp.New(totp.DefHash, totp.DefCodeDigits, totp.DefTimeStep)
+
+		code string
+		err  error
+	)
+
+	err = user.initTOTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	code, err = proto.GenerateWithTime(timeNow(), user.totpKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		desc     string
+		code     string
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc:     `without code`,
+		expError: errAuthTOTP.Message,
+	}, {
+		desc:     `with invalid code`,
+		code:     `000000`,
+		expError: errAuthTOTP.Message,
+	}, {
+		desc: `with valid code`,
+		code: code,
+	}}
+
+	var (
+		c        testCase
+		epr      *libhttp.EndpointRequest
+		respBody []byte
+	)
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter: httptest.NewRecorder(),
+			HTTPRequest: &http.Request{
+				Form: url.Values{
+					paramNameName:     []string{user.Name},
+					paramNamePassword: []string{`s3cret`},
+					paramNameTOTP:     []string{c.code},
+				},
+			},
+		}
+
+		respBody, err = k.apiAuthLogin(epr)
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, `{"code":200}`, string(respBody))
+	}
+}
+
+func TestKarajo_apiAuthTOTPQR(t *testing.T) {
+	var (
+		userTOTP = &User{
+			Name:       `tester`,
+			TOTPSecret: `JBSWY3DPEHPK3PXP`,
+		}
+		userNoTOTP = &User{
+			Name: `nototp`,
+		}
+		k = &Karajo{
+			env: &Env{
+				Name: `karajo`,
+			},
+			sm: newSessionManager(),
+		}
+		err error
+	)
+
+	err = userTOTP.initTOTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		desc     string
+		user     *User
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc:     `without session`,
+		expError: errAuthUnauthorized.Message,
+	}, {
+		desc:     `with user without TOTP`,
+		user:     userNoTOTP,
+		expError: errAuthTOTPNotEnabled.Message,
+	}, {
+		desc: `with user with TOTP`,
+		user: userTOTP,
+	}}
+
+	var (
+		c   testCase
+		rec *httptest.ResponseRecorder
+		req *http.Request
+		epr *libhttp.EndpointRequest
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodGet, apiAuthTOTPQR, nil)
+		if c.user != nil {
+			req.AddCookie(&http.Cookie{
+				Name:  cookieName,
+				Value: k.sm.new(c.user),
+			})
+		}
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: req,
+		}
+
+		_, err = k.apiAuthTOTPQR(epr)
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc+`: Content-Type`, `image/png`,
+			rec.Header().Get(`Content-Type`))
+		test.Assert(t, c.desc+`: PNG`, true,
+			bytes.HasPrefix(rec.Body.Bytes(), []byte("\x89PNG")))
+	}
+}
+
+func TestKarajo_apiAuthLogout(t *testing.T) {
+	var (
+		user = &User{
+			Name: `tester`,
+		}
+		k = &Karajo{
+			env: &Env{},
+			sm:  newSessionManager(),
+		}
+		key = k.sm.new(user)
+
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(http.MethodPost, apiAuthLogout, nil)
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: req,
+		}
+
+		respBody []byte
+		err      error
+	)
+
+	req.AddCookie(&http.Cookie{
+		Name:  cookieName,
+		Value: key,
+	})
+
+	respBody, err = k.apiAuthLogout(epr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, `respBody`, `{"code":200}`, string(respBody))
+	var expCookies = []string{
+		`karajo=; Path=/; Max-Age=0; HttpOnly; SameSite=Lax`,
+		`karajo_csrf=; Path=/; Max-Age=0; SameSite=Strict`,
+	}
+	test.Assert(t, `Set-Cookie`, expCookies, rec.Header().Values(`Set-Cookie`))
+
+	var nilUser *User
+	test.Assert(t, `session deleted`, nilUser, k.sm.get(key))
+}
+
 func TestKarajo_handleFSAuth(t *testing.T) {
 	var (
 		env = &Env{
@@ -152,7 +366,11 @@ func testHandleFSAuthWithUser(t *testing.T, k *Karajo) {
 	)
 
 	k.env.Users[user.Name] = user
-	k.sm.value[cookie.Value] = user
+	k.sm.value[sessionKeyHash(cookie.Value)] = &session{
+		user:      user,
+		UserName:  user.Name,
+		ExpiredAt: timeNow().Add(time.Hour),
+	}
 
 	type testCase struct {
 		cookie *http.Cookie
@@ -174,6 +392,10 @@ func testHandleFSAuthWithUser(t *testing.T, k *Karajo) {
 		path:   `/karajo/`,
 		exp:    false, // Request redirect to app.
 		cookie: cookie,
+	}, {
+		desc: `to login asset without cookie`,
+		path: `/karajo/theme.css`,
+		exp:  true,
 	}, {
 		desc: `to app without cookie`,
 		path: `/karajo/app/index.html`,
@@ -294,3 +516,480 @@ func testHandleFSAuthWithoutUser(t *testing.T, k *Karajo) {
 		test.Assert(t, c.desc, c.exp, got)
 	}
 }
+
+func TestNegotiateEncoding(t *testing.T) {
+	type testCase struct {
+		acceptEncoding string
+		exp            string
+	}
+
+	var cases = []testCase{{
+		acceptEncoding: ``,
+		exp:            contentEncodingIdentity,
+	}, {
+		acceptEncoding: `gzip`,
+		exp:            libhttp.ContentEncodingGzip,
+	}, {
+		acceptEncoding: `gzip, deflate, br`,
+		exp:            contentEncodingBrotli,
+	}, {
+		acceptEncoding: `br;q=0.5, gzip;q=0.8`,
+		exp:            libhttp.ContentEncodingGzip,
+	}, {
+		acceptEncoding: `gzip;q=0.5, identity`,
+		exp:            contentEncodingIdentity,
+	}, {
+		acceptEncoding: `br;q=0, gzip;q=0`,
+		exp:            contentEncodingIdentity,
+	}, {
+		acceptEncoding: `*`,
+		exp:            contentEncodingBrotli,
+	}, {
+		acceptEncoding: `*;q=0, gzip`,
+		exp:            libhttp.ContentEncodingGzip,
+	}, {
+		acceptEncoding: `deflate`,
+		exp:            contentEncodingIdentity,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.acceptEncoding, c.exp, negotiateEncoding(c.acceptEncoding))
+	}
+}
+
+func TestCompressResponse(t *testing.T) {
+	type testCase struct {
+		acceptEncoding string
+		expEncoding    string
+	}
+
+	var cases = []testCase{{
+		acceptEncoding: ``,
+	}, {
+		acceptEncoding: `gzip`,
+		expEncoding:    libhttp.ContentEncodingGzip,
+	}, {
+		acceptEncoding: `br`,
+		expEncoding:    contentEncodingBrotli,
+	}}
+
+	var (
+		body = []byte(`{"code":200}`)
+
+		c   testCase
+		epr *libhttp.EndpointRequest
+		rec *httptest.ResponseRecorder
+		got []byte
+		err error
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiEnv, nil),
+		}
+		epr.HTTPRequest.Header.Set(libhttp.HeaderAcceptEncoding, c.acceptEncoding)
+
+		got, err = compressResponse(epr, body)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		test.Assert(t, c.acceptEncoding+`: Content-Encoding`, c.expEncoding,
+			rec.Header().Get(libhttp.HeaderContentEncoding))
+		test.Assert(t, c.acceptEncoding+`: Vary`, libhttp.HeaderAcceptEncoding,
+			rec.Header().Get(headerVary))
+
+		if len(c.expEncoding) == 0 {
+			test.Assert(t, c.acceptEncoding+`: body`, string(body), string(got))
+		}
+	}
+}
+
+func TestKarajo_apiSchedule(t *testing.T) {
+	type testCase struct {
+		query    string
+		expError string
+	}
+
+	var cases = []testCase{{
+		query:    `from=2023-01-09`,
+		expError: `invalid from: parsing time "2023-01-09" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "T"`,
+	}, {
+		query:    `from=2023-01-09T00:00:00Z&to=2023-01-08T00:00:00Z`,
+		expError: `to is before from`,
+	}, {
+		query:    `from=2023-01-01T00:00:00Z&to=2023-03-01T00:00:00Z`,
+		expError: `range must not exceed 744h0m0s`,
+	}, {
+		query: `from=2023-01-08T00:00:00Z&to=2023-01-10T00:00:00Z`,
+	}}
+
+	var (
+		k = &Karajo{
+			env: &Env{},
+		}
+
+		c   testCase
+		epr *libhttp.EndpointRequest
+		got []byte
+		err error
+	)
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  httptest.NewRecorder(),
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiSchedule+`?`+c.query, nil),
+		}
+		_ = epr.HTTPRequest.ParseForm()
+
+		got, err = k.apiSchedule(epr)
+		if err != nil {
+			test.Assert(t, c.query, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.query, `{"data":{"from":"2023-01-08T00:00:00Z","to":"2023-01-10T00:00:00Z","jobs":[]},"code":200}`, string(got))
+	}
+}
+
+func TestKarajo_apiJobExecNext(t *testing.T) {
+	type testCase struct {
+		query    string
+		expError string
+		exp      string
+	}
+
+	var k = &Karajo{
+		env: &Env{
+			ExecJobs: map[string]*JobExec{
+				`nightly`: {
+					JobBase: JobBase{
+						ID:       `nightly`,
+						Status:   JobStatusStarted,
+						Schedule: `weekly@Mon,Fri@01:30`,
+						NextRun:  timeNow().Add(90 * time.Minute),
+					},
+				},
+				`poll`: {
+					JobBase: JobBase{
+						ID:       `poll`,
+						Status:   JobStatusSuccess,
+						Interval: 90 * time.Minute,
+						NextRun:  timeNow().Add(-10 * time.Minute),
+					},
+				},
+				`paused`: {
+					JobBase: JobBase{
+						ID:       `paused`,
+						Status:   JobStatusPaused,
+						Interval: time.Hour,
+						NextRun:  timeNow(),
+					},
+				},
+			},
+		},
+	}
+
+	var cases = []testCase{{
+		query:    `id=unknown`,
+		expError: `job ID unknown not found`,
+	}, {
+		query:    `id=nightly&n=0`,
+		expError: `invalid n "0", must be between 1 and 1000`,
+	}, {
+		query:    `id=nightly&n=1001`,
+		expError: `invalid n "1001", must be between 1 and 1000`,
+	}, {
+		query: `id=nightly&n=3`,
+		exp:   `{"data":{"next_run":"2023-01-09T01:30:00Z","id":"nightly","name":"","status":"started","schedule":"weekly@Mon,Fri@01:30","next_runs":["2023-01-09T01:30:00Z","2023-01-13T01:30:00Z","2023-01-16T01:30:00Z"],"next_in":5400000000000},"code":200}`,
+	}, {
+		query: `id=poll&n=2`,
+		exp:   `{"data":{"next_run":"2023-01-08T23:50:00Z","id":"poll","name":"","status":"success","next_runs":["2023-01-09T01:20:00Z","2023-01-09T02:50:00Z"],"interval":5400000000000,"next_in":4800000000000},"code":200}`,
+	}, {
+		query: `id=paused`,
+		exp:   `{"data":{"next_run":"2023-01-09T00:00:00Z","id":"paused","name":"","status":"paused","next_runs":[],"interval":3600000000000},"code":200}`,
+	}}
+
+	var (
+		c   testCase
+		epr *libhttp.EndpointRequest
+		got []byte
+		err error
+	)
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  httptest.NewRecorder(),
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiJobExecNext+`?`+c.query, nil),
+		}
+		_ = epr.HTTPRequest.ParseForm()
+
+		got, err = k.apiJobExecNext(epr)
+		if err != nil {
+			test.Assert(t, c.query, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.query, c.exp, string(got))
+	}
+}
+
+func TestParsePauseUntil(t *testing.T) {
+	type testCase struct {
+		query    string
+		expError string
+		exp      time.Time
+	}
+
+	var (
+		now   = timeNow()
+		cases = []testCase{{
+			query: ``,
+		}, {
+			query: `until=2023-01-10T07:00:00%2B07:00`,
+			exp:   time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC),
+		}, {
+			query: `duration=1h30m`,
+			exp:   now.Add(90 * time.Minute),
+		}, {
+			query:    `until=2023-01-10&duration=1h`,
+			expError: `only one of until or duration can be set`,
+		}, {
+			query:    `until=2023-01-10`,
+			expError: `invalid until: parsing time "2023-01-10" as "2006-01-02T15:04:05Z07:00": cannot parse "" as "T"`,
+		}, {
+			query:    `duration=1x`,
+			expError: `invalid duration: time: unknown unit "x" in duration "1x"`,
+		}, {
+			query:    `duration=-1h`,
+			expError: `the resume time 2023-01-08T23:00:00Z is not after now`,
+		}}
+
+		c   testCase
+		epr *libhttp.EndpointRequest
+		got time.Time
+		err error
+	)
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPRequest: httptest.NewRequest(http.MethodPost, apiJobExecPause+`?`+c.query, nil),
+		}
+		_ = epr.HTTPRequest.ParseForm()
+
+		got, err = parsePauseUntil(epr, now)
+		if err != nil {
+			test.Assert(t, c.query, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.query, c.exp, got)
+	}
+}
+
+func TestKarajo_apiJobExecLogRaw(t *testing.T) {
+	type testCase struct {
+		query     string
+		expError  string
+		expHeader string
+		exp       string
+	}
+
+	var (
+		jlog = &JobLog{
+			Name:    `test.1.success`,
+			path:    filepath.Join(t.TempDir(), `test.1.success`),
+			Status:  JobStatusSuccess,
+			Counter: 1,
+		}
+		content = "line 1\nline 2\n"
+		err     error
+	)
+
+	err = os.WriteFile(jlog.path, []byte(content), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = jlog.compress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var k = &Karajo{
+		env: &Env{
+			ExecJobs: map[string]*JobExec{
+				`test`: {
+					JobBase: JobBase{
+						ID:   `test`,
+						Logs: []*JobLog{jlog},
+					},
+				},
+			},
+		},
+	}
+
+	var cases = []testCase{{
+		query:    `id=unknown&counter=1`,
+		expError: `job ID unknown not found`,
+	}, {
+		query:    `id=test&counter=2`,
+		expError: `log #2 not found`,
+	}, {
+		query:     `id=test&counter=1`,
+		expHeader: `attachment; filename="test.1.success.log"`,
+		exp:       content,
+	}}
+
+	var (
+		c   testCase
+		rec *httptest.ResponseRecorder
+		epr *libhttp.EndpointRequest
+		got []byte
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiJobExecLogRaw+`?`+c.query, nil),
+		}
+		_ = epr.HTTPRequest.ParseForm()
+
+		got, err = k.apiJobExecLogRaw(epr)
+		if err != nil {
+			test.Assert(t, c.query, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.query+`: header`, c.expHeader,
+			rec.Header().Get(headerContentDisposition))
+		test.Assert(t, c.query, c.exp, string(got))
+	}
+
+	// The log content is not kept in memory.
+	test.Assert(t, `content`, 0, len(jlog.content))
+}
+
+func TestKarajo_httpAuthorize_epoch(t *testing.T) {
+	type testCase struct {
+		desc     string
+		payload  string
+		expError string
+		expEpoch string
+	}
+
+	var cases = []testCase{{
+		desc:     `missing epoch`,
+		payload:  `id=test`,
+		expError: `invalid or expired epoch: missing or invalid _karajo_epoch, server epoch is 1673222400`,
+		expEpoch: `1673222400`,
+	}, {
+		desc:     `too old`,
+		payload:  `_karajo_epoch=1673222099&id=test`,
+		expError: `invalid or expired epoch: epoch 1673222099 is off by 5m1s, server epoch is 1673222400`,
+		expEpoch: `1673222400`,
+	}, {
+		desc:     `too new`,
+		payload:  `_karajo_epoch=1673222701&id=test`,
+		expError: `invalid or expired epoch: epoch 1673222701 is off by 5m1s, server epoch is 1673222400`,
+		expEpoch: `1673222400`,
+	}, {
+		desc:    `within skew`,
+		payload: `_karajo_epoch=1673222100&id=test`,
+	}}
+
+	var (
+		env = &Env{
+			Secret:     `s3cret`,
+			SignMaxAge: 5 * time.Minute,
+		}
+		k = &Karajo{
+			env: env,
+		}
+
+		c   testCase
+		rec *httptest.ResponseRecorder
+		epr *libhttp.EndpointRequest
+		err error
+	)
+
+	env.secret = env.newSecretValue(env.Secret)
+
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  rec,
+			HTTPRequest: httptest.NewRequest(http.MethodPost, apiJobExecPause, nil),
+		}
+		epr.HTTPRequest.Header.Set(HeaderNameXKarajoSign,
+			Sign([]byte(c.payload), []byte(env.Secret)))
+
+		err = k.httpAuthorize(epr, []byte(c.payload))
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+		} else {
+			test.Assert(t, c.desc, c.expError, ``)
+		}
+		test.Assert(t, c.desc+`: `+HeaderNameXKarajoEpoch, c.expEpoch,
+			rec.Header().Get(HeaderNameXKarajoEpoch))
+	}
+}
+
+func TestKarajo_httpAuthorize_replay(t *testing.T) {
+	type testCase struct {
+		desc     string
+		method   string
+		payload  string
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc:    `POST`,
+		method:  http.MethodPost,
+		payload: `_karajo_epoch=1673222400&_karajo_nonce=a&id=test`,
+	}, {
+		desc:     `POST replayed`,
+		method:   http.MethodPost,
+		payload:  `_karajo_epoch=1673222400&_karajo_nonce=a&id=test`,
+		expError: errAuthReplay.Message,
+	}, {
+		desc:    `POST with different nonce`,
+		method:  http.MethodPost,
+		payload: `_karajo_epoch=1673222400&_karajo_nonce=b&id=test`,
+	}, {
+		desc:    `GET`,
+		method:  http.MethodGet,
+		payload: `_karajo_epoch=1673222400&id=test`,
+	}, {
+		desc:    `GET repeated`,
+		method:  http.MethodGet,
+		payload: `_karajo_epoch=1673222400&id=test`,
+	}}
+
+	var (
+		env = &Env{
+			Secret:     `s3cret`,
+			SignMaxAge: 5 * time.Minute,
+		}
+		k = &Karajo{
+			env: env,
+		}
+
+		c   testCase
+		epr *libhttp.EndpointRequest
+		err error
+	)
+
+	env.secret = env.newSecretValue(env.Secret)
+	env.nonces = newNonceCache(2 * env.SignMaxAge)
+
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  httptest.NewRecorder(),
+			HTTPRequest: httptest.NewRequest(c.method, apiJobExecPause, nil),
+		}
+		epr.HTTPRequest.Header.Set(HeaderNameXKarajoSign,
+			Sign([]byte(c.payload), []byte(env.Secret)))
+
+		err = k.httpAuthorize(epr, []byte(c.payload))
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+		} else {
+			test.Assert(t, c.desc, c.expError, ``)
+		}
+	}
+}
@@ -0,0 +1,694 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+// Package qrcode implement minimal QR code encoder, using byte mode and
+// error correction level M, for version 1 until 9.
+//
+// This package is created to render the TOTP provisioning URI as image
+// without depends on third party module.
+// The implementation follow the ISO/IEC 18004 specification.
+package qrcode
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+)
+
+// ErrDataTooLong returned by [Encode] if the data does not fit into the
+// maximum version.
+var ErrDataTooLong = errors.New(`data too long`)
+
+// quietZone the number of light modules surrounding the symbol.
+const quietZone = 4
+
+// maxVersion the maximum version supported by this package.
+// The character count indicator for byte mode is 8 bits until version 9.
+const maxVersion = 9
+
+// ecBlock define the error correction block structure for each version
+// at error correction level M.
+type ecBlock struct {
+	// ecLen the number of error correction codewords per block.
+	ecLen int
+
+	// numShort the number of blocks in the first group.
+	numShort int
+
+	// shortLen the number of data codewords per block in the first
+	// group.
+	// The second group, if any, has shortLen+1 data codewords per
+	// block.
+	shortLen int
+
+	// numLong the number of blocks in the second group.
+	numLong int
+}
+
+// listECBlock contains the error correction block for level M, indexed by
+// version.
+var listECBlock = [maxVersion + 1]ecBlock{
+	1: {ecLen: 10, numShort: 1, shortLen: 16},
+	2: {ecLen: 16, numShort: 1, shortLen: 28},
+	3: {ecLen: 26, numShort: 1, shortLen: 44},
+	4: {ecLen: 18, numShort: 2, shortLen: 32},
+	5: {ecLen: 24, numShort: 2, shortLen: 43},
+	6: {ecLen: 16, numShort: 4, shortLen: 27},
+	7: {ecLen: 18, numShort: 4, shortLen: 31},
+	8: {ecLen: 22, numShort: 2, shortLen: 38, numLong: 2},
+	9: {ecLen: 22, numShort: 3, shortLen: 36, numLong: 2},
+}
+
+// listAlignment contains the center position of alignment patterns,
+// indexed by version.
+var listAlignment = [maxVersion + 1][]int{
+	2: {6, 18},
+	3: {6, 22},
+	4: {6, 26},
+	5: {6, 30},
+	6: {6, 34},
+	7: {6, 22, 38},
+	8: {6, 24, 42},
+	9: {6, 26, 46},
+}
+
+// dataLen return the total number of data codewords.
+func (ecb ecBlock) dataLen() int {
+	return ecb.numShort*ecb.shortLen + ecb.numLong*(ecb.shortLen+1)
+}
+
+// Code contains the QR code modules.
+type Code struct {
+	// modules contains the color of each module, true for dark.
+	// The first index is row (y) and the second is column (x).
+	modules [][]bool
+
+	// isFunction mark the module as part of function patterns, which
+	// is not masked.
+	isFunction [][]bool
+
+	version int
+	size    int
+	mask    int
+}
+
+// Encode the data into QR code using the smallest version that can
+// contains it.
+func Encode(data []byte) (code *Code, err error) {
+	var version int
+
+	for version = 1; version <= maxVersion; version++ {
+		// 4 bits of mode indicator plus 8 bits of character count.
+		if 12+8*len(data) <= 8*listECBlock[version].dataLen() {
+			break
+		}
+	}
+	if version > maxVersion {
+		return nil, fmt.Errorf(`Encode: %w`, ErrDataTooLong)
+	}
+
+	code = &Code{
+		version: version,
+		size:    4*version + 17,
+	}
+	code.modules = newGrid(code.size)
+	code.isFunction = newGrid(code.size)
+
+	code.drawFunctionPatterns()
+
+	var codewords = addECAndInterleave(version, encodeData(version, data))
+
+	code.drawCodewords(codewords)
+
+	// Select the mask with the lowest penalty.
+	var (
+		minPenalty = -1
+		mask       int
+		penalty    int
+	)
+	for mask = 0; mask < 8; mask++ {
+		code.applyMask(mask)
+		code.drawFormatBits(mask)
+		penalty = code.penaltyScore()
+		if minPenalty < 0 || penalty < minPenalty {
+			minPenalty = penalty
+			code.mask = mask
+		}
+		// Applying the same mask again revert it.
+		code.applyMask(mask)
+	}
+	code.applyMask(code.mask)
+	code.drawFormatBits(code.mask)
+
+	return code, nil
+}
+
+// Size return the number of modules on each side, excluding the quiet
+// zone.
+func (code *Code) Size() int {
+	return code.size
+}
+
+// Module return true if the module at column x and row y is dark.
+func (code *Code) Module(x, y int) bool {
+	return code.modules[y][x]
+}
+
+// Image return the QR code as grayscale image, where each module is
+// rendered as scale x scale pixels, including the quiet zone.
+func (code *Code) Image(scale int) (img *image.Gray) {
+	if scale <= 0 {
+		scale = 1
+	}
+
+	var (
+		dim = (code.size + 2*quietZone) * scale
+		x   int
+		y   int
+	)
+
+	img = image.NewGray(image.Rect(0, 0, dim, dim))
+	for y = 0; y < dim; y++ {
+		for x = 0; x < dim; x++ {
+			img.SetGray(x, y, color.Gray{Y: 0xFF})
+		}
+	}
+
+	var (
+		px int
+		py int
+	)
+	for y = 0; y < code.size; y++ {
+		for x = 0; x < code.size; x++ {
+			if !code.modules[y][x] {
+				continue
+			}
+			for py = 0; py < scale; py++ {
+				for px = 0; px < scale; px++ {
+					img.SetGray((x+quietZone)*scale+px,
+						(y+quietZone)*scale+py, color.Gray{})
+				}
+			}
+		}
+	}
+	return img
+}
+
+// WritePNG write the QR code as PNG image into w.
+func (code *Code) WritePNG(w io.Writer, scale int) (err error) {
+	err = png.Encode(w, code.Image(scale))
+	if err != nil {
+		return fmt.Errorf(`WritePNG: %w`, err)
+	}
+	return nil
+}
+
+func newGrid(size int) (grid [][]bool) {
+	grid = make([][]bool, size)
+	var y int
+	for y = range grid {
+		grid[y] = make([]bool, size)
+	}
+	return grid
+}
+
+func (code *Code) setFunction(x, y int, isDark bool) {
+	code.modules[y][x] = isDark
+	code.isFunction[y][x] = true
+}
+
+func (code *Code) drawFunctionPatterns() {
+	var i int
+
+	// Timing patterns.
+	for i = 0; i < code.size; i++ {
+		code.setFunction(6, i, i%2 == 0)
+		code.setFunction(i, 6, i%2 == 0)
+	}
+
+	// Finder patterns, including its separators.
+	code.drawFinder(3, 3)
+	code.drawFinder(code.size-4, 3)
+	code.drawFinder(3, code.size-4)
+
+	// Alignment patterns, except the one that overlap with finder
+	// patterns.
+	var (
+		pos  = listAlignment[code.version]
+		last = len(pos) - 1
+		j    int
+	)
+	for i = range pos {
+		for j = range pos {
+			if (i == 0 && j == 0) || (i == 0 && j == last) || (i == last && j == 0) {
+				continue
+			}
+			code.drawAlignment(pos[i], pos[j])
+		}
+	}
+
+	// Reserve the format bits area, it will be drawn later after the
+	// mask is selected.
+	code.drawFormatBits(0)
+
+	code.drawVersion()
+}
+
+// drawFinder draw finder pattern with its center at column x and row y.
+func (code *Code) drawFinder(x, y int) {
+	var (
+		dx, dy int
+		xx, yy int
+		dist   int
+	)
+	for dy = -4; dy <= 4; dy++ {
+		for dx = -4; dx <= 4; dx++ {
+			xx = x + dx
+			yy = y + dy
+			if xx < 0 || xx >= code.size || yy < 0 || yy >= code.size {
+				continue
+			}
+			dist = max(abs(dx), abs(dy))
+			code.setFunction(xx, yy, dist != 2 && dist != 4)
+		}
+	}
+}
+
+// drawAlignment draw alignment pattern with its center at column x and
+// row y.
+func (code *Code) drawAlignment(x, y int) {
+	var dx, dy int
+	for dy = -2; dy <= 2; dy++ {
+		for dx = -2; dx <= 2; dx++ {
+			code.setFunction(x+dx, y+dy, max(abs(dx), abs(dy)) != 1)
+		}
+	}
+}
+
+// formatBits return the 15 bits format information for error correction
+// level M and the mask.
+func formatBits(mask int) (bits int) {
+	// The error correction level M is 00.
+	var (
+		data = mask
+		rem  = data
+		i    int
+	)
+	for i = 0; i < 10; i++ {
+		rem = (rem << 1) ^ ((rem >> 9) * 0x537)
+	}
+	bits = (data<<10 | rem) ^ 0x5412
+	return bits
+}
+
+func (code *Code) drawFormatBits(mask int) {
+	var (
+		bits = formatBits(mask)
+		i    int
+	)
+
+	// First copy, around the top-left finder.
+	for i = 0; i <= 5; i++ {
+		code.setFunction(8, i, getBit(bits, i))
+	}
+	code.setFunction(8, 7, getBit(bits, 6))
+	code.setFunction(8, 8, getBit(bits, 7))
+	code.setFunction(7, 8, getBit(bits, 8))
+	for i = 9; i < 15; i++ {
+		code.setFunction(14-i, 8, getBit(bits, i))
+	}
+
+	// Second copy, split between the top-right and bottom-left
+	// finder.
+	for i = 0; i < 8; i++ {
+		code.setFunction(code.size-1-i, 8, getBit(bits, i))
+	}
+	for i = 8; i < 15; i++ {
+		code.setFunction(8, code.size-15+i, getBit(bits, i))
+	}
+
+	// The dark module.
+	code.setFunction(8, code.size-8, true)
+}
+
+// versionBits return the 18 bits version information.
+func versionBits(version int) (bits int) {
+	var (
+		rem = version
+		i   int
+	)
+	for i = 0; i < 12; i++ {
+		rem = (rem << 1) ^ ((rem >> 11) * 0x1F25)
+	}
+	bits = version<<12 | rem
+	return bits
+}
+
+func (code *Code) drawVersion() {
+	if code.version < 7 {
+		return
+	}
+
+	var (
+		bits = versionBits(code.version)
+
+		i, a, b int
+		bit     bool
+	)
+	for i = 0; i < 18; i++ {
+		bit = getBit(bits, i)
+		a = code.size - 11 + i%3
+		b = i / 3
+		code.setFunction(a, b, bit)
+		code.setFunction(b, a, bit)
+	}
+}
+
+// drawCodewords place the data and error correction codewords in zig-zag
+// order, starting from bottom-right corner.
+func (code *Code) drawCodewords(codewords []byte) {
+	var (
+		nbits = 8 * len(codewords)
+
+		i, right, vert, j int
+		x, y              int
+		upward            bool
+	)
+	for right = code.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			// Skip the vertical timing pattern.
+			right = 5
+		}
+		upward = (right+1)&2 == 0
+		for vert = 0; vert < code.size; vert++ {
+			for j = 0; j < 2; j++ {
+				x = right - j
+				if upward {
+					y = code.size - 1 - vert
+				} else {
+					y = vert
+				}
+				if code.isFunction[y][x] || i >= nbits {
+					continue
+				}
+				code.modules[y][x] = getBit(int(codewords[i>>3]), 7-(i&7))
+				i++
+			}
+		}
+	}
+}
+
+// applyMask flip the non-function modules based on mask pattern.
+func (code *Code) applyMask(mask int) {
+	var (
+		x, y   int
+		invert bool
+	)
+	for y = 0; y < code.size; y++ {
+		for x = 0; x < code.size; x++ {
+			if code.isFunction[y][x] {
+				continue
+			}
+			switch mask {
+			case 0:
+				invert = (x+y)%2 == 0
+			case 1:
+				invert = y%2 == 0
+			case 2:
+				invert = x%3 == 0
+			case 3:
+				invert = (x+y)%3 == 0
+			case 4:
+				invert = (x/3+y/2)%2 == 0
+			case 5:
+				invert = x*y%2+x*y%3 == 0
+			case 6:
+				invert = (x*y%2+x*y%3)%2 == 0
+			case 7:
+				invert = ((x+y)%2+x*y%3)%2 == 0
+			}
+			if invert {
+				code.modules[y][x] = !code.modules[y][x]
+			}
+		}
+	}
+}
+
+// penaltyScore calculate the penalty of the current modules to select the
+// best mask.
+func (code *Code) penaltyScore() (score int) {
+	var (
+		dark int
+		x, y int
+	)
+
+	for y = 0; y < code.size; y++ {
+		score += penaltyLine(code.size, func(i int) bool { return code.modules[y][i] })
+	}
+	for x = 0; x < code.size; x++ {
+		score += penaltyLine(code.size, func(i int) bool { return code.modules[i][x] })
+	}
+
+	// Block of 2x2 modules with the same color.
+	var c bool
+	for y = 0; y < code.size-1; y++ {
+		for x = 0; x < code.size-1; x++ {
+			c = code.modules[y][x]
+			if c == code.modules[y][x+1] && c == code.modules[y+1][x] &&
+				c == code.modules[y+1][x+1] {
+				score += 3
+			}
+		}
+	}
+
+	// Balance of dark and light modules.
+	for y = 0; y < code.size; y++ {
+		for x = 0; x < code.size; x++ {
+			if code.modules[y][x] {
+				dark++
+			}
+		}
+	}
+	var (
+		total = code.size * code.size
+		k     = (abs(dark*20-total*10)+total-1)/total - 1
+	)
+	score += max(k, 0) * 10
+
+	return score
+}
+
+// penaltyLine calculate the penalty for consecutive modules with the same
+// color and for pattern similar to finder in a row or column.
+func penaltyLine(size int, get func(i int) bool) (score int) {
+	var (
+		run  = 1
+		i, j int
+	)
+	for i = 1; i <= size; i++ {
+		if i < size && get(i) == get(i-1) {
+			run++
+			continue
+		}
+		if run >= 5 {
+			score += 3 + run - 5
+		}
+		run = 1
+	}
+
+	// Pattern dark-light-dark-dark-dark-light-dark preceded or followed
+	// by four light modules.
+	var (
+		finder = []bool{true, false, true, true, true, false, true}
+		match  bool
+	)
+	for i = 0; i+7 <= size; i++ {
+		match = true
+		for j = 0; j < 7; j++ {
+			if get(i+j) != finder[j] {
+				match = false
+				break
+			}
+		}
+		if !match {
+			continue
+		}
+		if isLight(size, get, i-4, i) || isLight(size, get, i+7, i+11) {
+			score += 40
+		}
+	}
+	return score
+}
+
+// isLight return true if all modules between start and end are light,
+// where module outside the symbol is considered light.
+func isLight(size int, get func(i int) bool, start, end int) bool {
+	var i int
+	for i = start; i < end; i++ {
+		if i >= 0 && i < size && get(i) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeData encode the data into data codewords using byte mode,
+// including the terminator and padding.
+func encodeData(version int, data []byte) (codewords []byte) {
+	var (
+		capacity = listECBlock[version].dataLen()
+		bb       bitBuffer
+		b        byte
+	)
+
+	bb.append(0b0100, 4)
+	bb.append(len(data), 8)
+	for _, b = range data {
+		bb.append(int(b), 8)
+	}
+
+	// Terminator.
+	bb.append(0, min(4, capacity*8-len(bb)))
+
+	// Pad to byte boundary.
+	bb.append(0, (8-len(bb)%8)%8)
+
+	codewords = bb.bytes()
+
+	// Pad bytes.
+	for b = 0xEC; len(codewords) < capacity; b ^= 0xEC ^ 0x11 {
+		codewords = append(codewords, b)
+	}
+	return codewords
+}
+
+// addECAndInterleave split the data codewords into blocks, compute the
+// error correction codewords of each block, and interleave them.
+func addECAndInterleave(version int, data []byte) (result []byte) {
+	var (
+		ecb       = listECBlock[version]
+		numBlocks = ecb.numShort + ecb.numLong
+		divisor   = rsDivisor(ecb.ecLen)
+		blocks    = make([][]byte, 0, numBlocks)
+		ecs       = make([][]byte, 0, numBlocks)
+
+		x, n, off int
+	)
+	for x = 0; x < numBlocks; x++ {
+		n = ecb.shortLen
+		if x >= ecb.numShort {
+			n++
+		}
+		blocks = append(blocks, data[off:off+n])
+		ecs = append(ecs, rsRemainder(data[off:off+n], divisor))
+		off += n
+	}
+
+	var i int
+	for i = 0; i <= ecb.shortLen; i++ {
+		for x = range blocks {
+			if i < len(blocks[x]) {
+				result = append(result, blocks[x][i])
+			}
+		}
+	}
+	for i = 0; i < ecb.ecLen; i++ {
+		for x = range ecs {
+			result = append(result, ecs[x][i])
+		}
+	}
+	return result
+}
+
+// rsDivisor return the Reed-Solomon generator polynomial coefficients
+// for degree, excluding the leading term.
+func rsDivisor(degree int) (result []byte) {
+	result = make([]byte, degree)
+	result[degree-1] = 1
+
+	var (
+		root byte = 1
+		i, j int
+	)
+	for i = 0; i < degree; i++ {
+		for j = 0; j < degree; j++ {
+			result[j] = gfMultiply(result[j], root)
+			if j+1 < degree {
+				result[j] ^= result[j+1]
+			}
+		}
+		root = gfMultiply(root, 0x02)
+	}
+	return result
+}
+
+// rsRemainder return the Reed-Solomon error correction codewords of data.
+func rsRemainder(data, divisor []byte) (result []byte) {
+	result = make([]byte, len(divisor))
+
+	var (
+		b, factor byte
+		i         int
+	)
+	for _, b = range data {
+		factor = b ^ result[0]
+		copy(result, result[1:])
+		result[len(result)-1] = 0
+		for i = range result {
+			result[i] ^= gfMultiply(divisor[i], factor)
+		}
+	}
+	return result
+}
+
+// gfMultiply multiply x and y in Galois field GF(2^8) with modulo
+// polynomial 0x11D.
+func gfMultiply(x, y byte) byte {
+	var (
+		z int
+		i int
+	)
+	for i = 7; i >= 0; i-- {
+		z = (z << 1) ^ ((z >> 7) * 0x11D)
+		z ^= int((y>>i)&1) * int(x)
+	}
+	return byte(z)
+}
+
+// bitBuffer contains list of bits.
+type bitBuffer []bool
+
+func (bb *bitBuffer) append(val, n int) {
+	var i int
+	for i = n - 1; i >= 0; i-- {
+		*bb = append(*bb, getBit(val, i))
+	}
+}
+
+func (bb bitBuffer) bytes() (out []byte) {
+	out = make([]byte, len(bb)/8)
+	var (
+		i   int
+		bit bool
+	)
+	for i, bit = range bb {
+		if bit {
+			out[i/8] |= 1 << (7 - i%8)
+		}
+	}
+	return out
+}
+
+func getBit(x, i int) bool {
+	return (x>>i)&1 != 0
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
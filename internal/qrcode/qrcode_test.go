@@ -0,0 +1,316 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package qrcode
+
+import (
+	"bytes"
+	"errors"
+	"strconv"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestRSRemainder(t *testing.T) {
+	// Data and error correction codewords of "HELLO WORLD" in version
+	// 1-M, taken from the QR code tutorial in thonky.com.
+	var (
+		data = []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236,
+			17, 236, 17, 236, 17}
+		exp = []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+	)
+	test.Assert(t, `rsRemainder`, exp, rsRemainder(data, rsDivisor(10)))
+}
+
+func TestFormatBits(t *testing.T) {
+	// Values from the format information table in ISO/IEC 18004 for
+	// error correction level M.
+	var exp = []int{
+		0b101010000010010,
+		0b101000100100101,
+		0b101111001111100,
+		0b101101101001011,
+		0b100010111111001,
+		0b100000011001110,
+		0b100111110010111,
+		0b100101010100000,
+	}
+	var mask int
+	for mask = range exp {
+		test.Assert(t, `formatBits`, exp[mask], formatBits(mask))
+	}
+}
+
+func TestVersionBits(t *testing.T) {
+	test.Assert(t, `version 7`, 0x07C94, versionBits(7))
+	test.Assert(t, `version 8`, 0x085BC, versionBits(8))
+	test.Assert(t, `version 9`, 0x09A99, versionBits(9))
+}
+
+func TestEncode(t *testing.T) {
+	type testCase struct {
+		data       string
+		expVersion int
+	}
+
+	var cases = []testCase{{
+		data:       `HELLO WORLD`,
+		expVersion: 1,
+	}, {
+		data:       `otpauth://totp/karajo:tester?secret=JBSWY3DPEHPK3PXP&issuer=karajo`,
+		expVersion: 5,
+	}, {
+		data:       strings.Repeat(`x`, 100),
+		expVersion: 6,
+	}, {
+		data:       strings.Repeat(`y`, 150),
+		expVersion: 8,
+	}, {
+		data:       strings.Repeat(`z`, 180),
+		expVersion: 9,
+	}}
+
+	var (
+		c    testCase
+		code *Code
+		err  error
+	)
+	for _, c = range cases {
+		code, err = Encode([]byte(c.data))
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, `version`, c.expVersion, code.version)
+		test.Assert(t, `size`, 4*c.expVersion+17, code.Size())
+		test.Assert(t, `decode`, c.data, string(decode(t, code)))
+	}
+
+	_, err = Encode(bytes.Repeat([]byte(`a`), 181))
+	test.Assert(t, `too long`, true, errors.Is(err, ErrDataTooLong))
+}
+
+// TestEncode_reference compare the modules with the QR code generated by
+// other implementation.
+func TestEncode_reference(t *testing.T) {
+	var (
+		tdata *test.Data
+		err   error
+	)
+
+	tdata, err = test.LoadData(`testdata/encode_test.txt`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		name    string
+		input   []byte
+		outName string
+		exp     []byte
+		code    *Code
+		mask    int
+	)
+	for name, input = range tdata.Input {
+		input = bytes.TrimSpace(input)
+		for outName, exp = range tdata.Output {
+			if !strings.HasPrefix(outName, name+`.`) {
+				continue
+			}
+			mask, err = strconv.Atoi(strings.TrimPrefix(outName, name+`.`))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			code, err = Encode(input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if mask != code.mask {
+				// Revert the selected mask and apply the
+				// reference mask.
+				code.applyMask(code.mask)
+				code.applyMask(mask)
+				code.drawFormatBits(mask)
+			}
+			test.Assert(t, outName, string(bytes.TrimSpace(exp)), code.String())
+		}
+	}
+}
+
+// String return the modules as text, where "#" is dark module and "." is
+// light module.
+func (code *Code) String() string {
+	var (
+		sb   strings.Builder
+		x, y int
+	)
+	for y = 0; y < code.size; y++ {
+		if y > 0 {
+			sb.WriteByte('\n')
+		}
+		for x = 0; x < code.size; x++ {
+			if code.modules[y][x] {
+				sb.WriteByte('#')
+			} else {
+				sb.WriteByte('.')
+			}
+		}
+	}
+	return sb.String()
+}
+
+func TestCode_WritePNG(t *testing.T) {
+	var (
+		code *Code
+		err  error
+	)
+	code, err = Encode([]byte(`karajo`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var buf bytes.Buffer
+	err = code.WritePNG(&buf, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `PNG signature`, true,
+		bytes.HasPrefix(buf.Bytes(), []byte("\x89PNG\r\n\x1a\n")))
+
+	var img = code.Image(2)
+	test.Assert(t, `image size`, (code.Size()+2*quietZone)*2, img.Bounds().Dx())
+}
+
+// decode read back the data from code modules, using the reverse of
+// Encode steps.
+func decode(t *testing.T, code *Code) (data []byte) {
+	// Read the format bits from the second copy and compare it with
+	// the first copy.
+	var (
+		bits1, bits2 int
+		i            int
+	)
+	for i = 0; i < 8; i++ {
+		if code.Module(code.size-1-i, 8) {
+			bits2 |= 1 << i
+		}
+	}
+	for i = 8; i < 15; i++ {
+		if code.Module(8, code.size-15+i) {
+			bits2 |= 1 << i
+		}
+	}
+	for i = 0; i <= 5; i++ {
+		if code.Module(8, i) {
+			bits1 |= 1 << i
+		}
+	}
+	if code.Module(8, 7) {
+		bits1 |= 1 << 6
+	}
+	if code.Module(8, 8) {
+		bits1 |= 1 << 7
+	}
+	if code.Module(7, 8) {
+		bits1 |= 1 << 8
+	}
+	for i = 9; i < 15; i++ {
+		if code.Module(14-i, 8) {
+			bits1 |= 1 << i
+		}
+	}
+	test.Assert(t, `format bits copy`, bits1, bits2)
+
+	var mask = ((bits1 ^ 0x5412) >> 10) & 0x7
+	test.Assert(t, `error correction level M`, 0, (bits1^0x5412)>>13)
+	test.Assert(t, `format bits`, formatBits(mask), bits1)
+
+	// Rebuild the function patterns to know which modules contains
+	// the codewords, and unmask the copy of modules.
+	var ref = &Code{
+		version: code.version,
+		size:    code.size,
+	}
+	ref.modules = newGrid(ref.size)
+	ref.isFunction = newGrid(ref.size)
+	ref.drawFunctionPatterns()
+
+	var y int
+	for y = range ref.modules {
+		copy(ref.modules[y], code.modules[y])
+	}
+	ref.applyMask(mask)
+
+	// Read the codewords in zig-zag order.
+	var (
+		ecb      = listECBlock[code.version]
+		nblocks  = ecb.numShort + ecb.numLong
+		total    = ecb.dataLen() + nblocks*ecb.ecLen
+		raw      = make([]byte, total)
+		bitIndex int
+
+		right, vert, j, x int
+		upward            bool
+	)
+	for right = ref.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		upward = (right+1)&2 == 0
+		for vert = 0; vert < ref.size; vert++ {
+			for j = 0; j < 2; j++ {
+				x = right - j
+				y = vert
+				if upward {
+					y = ref.size - 1 - vert
+				}
+				if ref.isFunction[y][x] || bitIndex >= 8*total {
+					continue
+				}
+				if ref.modules[y][x] {
+					raw[bitIndex>>3] |= 1 << (7 - bitIndex&7)
+				}
+				bitIndex++
+			}
+		}
+	}
+
+	// De-interleave the blocks and verify the error correction.
+	var (
+		blocks = make([][]byte, nblocks)
+		off    int
+		b      int
+	)
+	for i = 0; i <= ecb.shortLen; i++ {
+		for b = 0; b < nblocks; b++ {
+			if i == ecb.shortLen && b < ecb.numShort {
+				continue
+			}
+			blocks[b] = append(blocks[b], raw[off])
+			off++
+		}
+	}
+	var divisor = rsDivisor(ecb.ecLen)
+	for i = 0; i < ecb.ecLen; i++ {
+		for b = 0; b < nblocks; b++ {
+			test.Assert(t, `ec codeword`, rsRemainder(blocks[b], divisor)[i], raw[off])
+			off++
+		}
+	}
+
+	var codewords []byte
+	for b = range blocks {
+		codewords = append(codewords, blocks[b]...)
+	}
+
+	// Parse the byte mode segment.
+	test.Assert(t, `mode`, byte(0b0100), codewords[0]>>4)
+
+	var n = int(codewords[0]&0x0F)<<4 | int(codewords[1]>>4)
+	for i = 0; i < n; i++ {
+		data = append(data, codewords[1+i]<<4|codewords[2+i]>>4)
+	}
+	return data
+}
@@ -0,0 +1,81 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// isAdmin return true if the user name is listed in Admins.
+func (env *Env) isAdmin(user *User) bool {
+	return slices.Contains(env.Admins, user.Name)
+}
+
+// jobBase return the base of job by its ID, for any kind of job.
+// It will return nil if the job does not exist.
+func (env *Env) jobBase(id string) *JobBase {
+	var job = env.jobExec(id)
+	if job != nil {
+		return &job.JobBase
+	}
+	var jobHTTP = env.jobHTTP(id)
+	if jobHTTP != nil {
+		return &jobHTTP.JobBase
+	}
+	var jobSQL = env.jobSQL(id)
+	if jobSQL != nil {
+		return &jobSQL.JobBase
+	}
+	return nil
+}
+
+// isAllowedUser return true if the job does not have Owner and
+// AllowedUsers, or the user is the Owner or listed in AllowedUsers.
+func (job *JobBase) isAllowedUser(user *User) bool {
+	if len(job.Owner) == 0 && len(job.AllowedUsers) == 0 {
+		return true
+	}
+	if user.Name == job.Owner {
+		return true
+	}
+	return slices.Contains(job.AllowedUsers, user.Name)
+}
+
+// evalJobACL reject the request from logged in user that change the
+// state of job, for example run, pause, or disable, if the user is not
+// the job owner, not listed in the job allowed users, and not admin.
+//
+// The request that does not change the state is allowed.
+// The request without user session, for example the job hook or the
+// agent, is authorized by its signature.
+func (k *Karajo) evalJobACL(req *http.Request, _ []byte) error {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+
+	var user = k.sessionUser(req)
+	if user == nil || k.env.isAdmin(user) {
+		return nil
+	}
+
+	var (
+		path = req.URL.Path
+		job  *JobBase
+	)
+	if isHookPath(path) {
+		var jobExec = k.hookJob(path)
+		if jobExec != nil {
+			job = &jobExec.JobBase
+		}
+	} else {
+		job = k.env.jobBase(strings.ToLower(req.Form.Get(paramNameID)))
+	}
+	if job == nil || job.isAllowedUser(user) {
+		return nil
+	}
+	return &errJobUserForbidden
+}
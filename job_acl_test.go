@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_evalJobACL(t *testing.T) {
+	var (
+		deploy = &JobExec{
+			JobBase: JobBase{
+				ID:           `deploy`,
+				Owner:        `alice`,
+				AllowedUsers: []string{`bob`},
+			},
+			Path: `/deploy`,
+		}
+		env = &Env{
+			Admins: []string{`root`},
+			ExecJobs: map[string]*JobExec{
+				`deploy`: deploy,
+				`public`: {
+					JobBase: JobBase{ID: `public`},
+				},
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`ping`: {
+					JobBase: JobBase{ID: `ping`, Owner: `bob`},
+				},
+			},
+		}
+		k = &Karajo{
+			env: env,
+			sm:  newSessionManager(),
+			hookJobs: map[string]*JobExec{
+				jobHookPath(deploy): deploy,
+			},
+		}
+		alice = &User{Name: `alice`}
+		bob   = &User{Name: `bob`}
+		carol = &User{Name: `carol`}
+		root  = &User{Name: `root`}
+	)
+
+	type testCase struct {
+		user     *User
+		desc     string
+		method   string
+		path     string
+		id       string
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc: `without session`,
+		path: apiJobExecPause,
+		id:   `deploy`,
+	}, {
+		desc: `owner`,
+		user: alice,
+		path: apiJobExecPause,
+		id:   `DEPLOY`,
+	}, {
+		desc: `allowed user`,
+		user: bob,
+		path: apiJobExecDisable,
+		id:   `deploy`,
+	}, {
+		desc: `admin`,
+		user: root,
+		path: apiJobHTTPPause,
+		id:   `ping`,
+	}, {
+		desc:   `read only request`,
+		user:   carol,
+		method: http.MethodGet,
+		path:   apiJobExecLog,
+		id:     `deploy`,
+	}, {
+		desc: `job without owner`,
+		user: carol,
+		path: apiJobExecPause,
+		id:   `public`,
+	}, {
+		desc:     `not allowed`,
+		user:     carol,
+		path:     apiJobExecPause,
+		id:       `deploy`,
+		expError: errJobUserForbidden.Message,
+	}, {
+		desc:     `JobHTTP not allowed`,
+		user:     alice,
+		path:     apiJobHTTPResume,
+		id:       `ping`,
+		expError: errJobUserForbidden.Message,
+	}, {
+		desc:     `hook not allowed`,
+		user:     carol,
+		path:     apiJobExecRun + `/deploy`,
+		expError: errJobUserForbidden.Message,
+	}, {
+		desc: `hook by owner`,
+		user: alice,
+		path: apiJobExecRun + `/deploy`,
+	}}
+
+	var (
+		c   testCase
+		req *http.Request
+		err error
+	)
+	for _, c = range cases {
+		if len(c.method) == 0 {
+			c.method = http.MethodPost
+		}
+		req = httptest.NewRequest(c.method, c.path, nil)
+		req.Form = url.Values{}
+		req.Form.Set(paramNameID, c.id)
+		if c.user != nil {
+			req.AddCookie(&http.Cookie{
+				Name:  cookieName,
+				Value: k.sm.new(c.user),
+			})
+		}
+
+		err = k.evalJobACL(req, nil)
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, c.expError, ``)
+	}
+}
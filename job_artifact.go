@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// JobArtifact contains the metadata of file collected from the JobExec
+// working directory after the job run.
+type JobArtifact struct {
+	ModTime time.Time `json:"mod_time"`
+
+	// Name of artifact, the file path relative to the working
+	// directory.
+	Name string `json:"name"`
+
+	Size int64 `json:"size"`
+}
+
+// artifactDir return the directory where the artifacts of the run with
+// counter are stored.
+func (job *JobBase) artifactDir(counter int64) string {
+	return filepath.Join(job.dirArtifact, strconv.FormatInt(counter, 10))
+}
+
+// collectArtifacts copy the files in the working directory that match
+// with the Artifacts patterns into the artifact directory of jlog.
+// The file that cannot be copied is reported in the log, without failing
+// the job.
+func (job *JobExec) collectArtifacts(jlog *JobLog) {
+	if len(job.Artifacts) == 0 || len(job.dirArtifact) == 0 {
+		return
+	}
+
+	var (
+		dir     = job.artifactDir(jlog.Counter)
+		seen    = map[string]bool{}
+		pattern string
+		matches []string
+		path    string
+		name    string
+		err     error
+	)
+
+	jlog.Write([]byte("--- Collecting artifacts\n"))
+
+	for _, pattern = range job.Artifacts {
+		matches, err = filepath.Glob(filepath.Join(job.dirWork, pattern))
+		if err != nil {
+			fmt.Fprintf(jlog, "!!! artifact %q: %s\n", pattern, err)
+			continue
+		}
+		for _, path = range matches {
+			name, err = filepath.Rel(job.dirWork, path)
+			if err != nil || !filepath.IsLocal(name) || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			err = copyArtifact(path, filepath.Join(dir, name))
+			if err != nil {
+				fmt.Fprintf(jlog, "!!! artifact %q: %s\n", name, err)
+				continue
+			}
+			fmt.Fprintf(jlog, "--- Artifact: %s\n", name)
+		}
+	}
+}
+
+// copyArtifact copy the regular file src into dst, creating the parent
+// directories of dst if its not exist.
+// The src that is not regular file, for example directory, is ignored.
+func copyArtifact(src, dst string) (err error) {
+	var fi fs.FileInfo
+
+	fi, err = os.Lstat(src)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return nil
+	}
+
+	err = os.MkdirAll(filepath.Dir(dst), 0700)
+	if err != nil {
+		return err
+	}
+
+	var fin, fout *os.File
+
+	fin, err = os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer fin.Close()
+
+	fout, err = os.OpenFile(dst, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(fout, fin)
+	if err != nil {
+		_ = fout.Close()
+		return err
+	}
+	return fout.Close()
+}
+
+// listArtifacts return the artifacts of the run with counter, sorted by
+// name.
+func (job *JobBase) listArtifacts(counter int64) (list []JobArtifact, err error) {
+	var dir = job.artifactDir(counter)
+
+	list = []JobArtifact{}
+
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		var fi, errInfo = d.Info()
+		if errInfo != nil {
+			return errInfo
+		}
+		var name, _ = filepath.Rel(dir, path)
+
+		list = append(list, JobArtifact{
+			Name:    filepath.ToSlash(name),
+			Size:    fi.Size(),
+			ModTime: fi.ModTime().UTC().Round(time.Second),
+		})
+		return nil
+	})
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf(`listArtifacts: %w`, err)
+	}
+
+	sort.Slice(list, func(x, y int) bool {
+		return list[x].Name < list[y].Name
+	})
+	return list, nil
+}
+
+// artifactPath return the path of artifact file by its name.
+// It will return os.ErrNotExist if the name is not local to the artifact
+// directory.
+func (job *JobBase) artifactPath(counter int64, name string) (path string, err error) {
+	name = filepath.FromSlash(name)
+	if !filepath.IsLocal(name) {
+		return ``, os.ErrNotExist
+	}
+	return filepath.Join(job.artifactDir(counter), name), nil
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_collectArtifacts(t *testing.T) {
+	var (
+		env = &Env{
+			DirBase: t.TempDir(),
+			ExecJobs: map[string]*JobExec{
+				`report`: {
+					Commands: []string{
+						`mkdir -p out/sub && echo report > out/report.txt && echo sub > out/sub/data.txt && echo skip > out/skip.log`,
+					},
+					Artifacts: []string{
+						`out/*.txt`,
+						`out/sub/*`,
+						`out/report.txt`,
+						`../*`,
+					},
+				},
+			},
+		}
+		err error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job = env.ExecJobs[`report`]
+
+	job.logq = make(chan *JobLog, 1)
+
+	job.run(nil)
+
+	var list []JobArtifact
+
+	list, err = job.listArtifacts(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		art   JobArtifact
+		names []string
+	)
+	for _, art = range list {
+		names = append(names, art.Name)
+	}
+	test.Assert(t, `listArtifacts`, []string{`out/report.txt`, `out/sub/data.txt`}, names)
+
+	// Download the artifact.
+	var k = &Karajo{env: env}
+
+	type testCase struct {
+		query    string
+		expError string
+		exp      string
+	}
+
+	var cases = []testCase{{
+		query: `id=report&counter=1&name=out/report.txt`,
+		exp:   "report\n",
+	}, {
+		query:    `id=report&counter=1&name=../../job/report/out/skip.log`,
+		expError: `apiJobExecArtifactDownload: artifact not found: ../../job/report/out/skip.log`,
+	}, {
+		query:    `id=report&counter=2&name=out/report.txt`,
+		expError: `log #2 not found`,
+	}}
+
+	var (
+		c   testCase
+		epr *libhttp.EndpointRequest
+		got []byte
+	)
+	for _, c = range cases {
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  httptest.NewRecorder(),
+			HTTPRequest: httptest.NewRequest(http.MethodGet, apiJobExecArtifactDownload+`?`+c.query, nil),
+		}
+		_ = epr.HTTPRequest.ParseForm()
+
+		got, err = k.apiJobExecArtifactDownload(epr)
+		if err != nil {
+			test.Assert(t, c.query, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.query, c.exp, string(got))
+	}
+
+	// The artifacts is removed along with the log.
+	job.LogRetention = 1
+	job.run(nil)
+
+	_, err = os.Stat(job.artifactDir(1))
+	test.Assert(t, `removed`, true, os.IsNotExist(err))
+
+	list, _ = job.listArtifacts(2)
+	test.Assert(t, `artifacts #2`, 2, len(list))
+}
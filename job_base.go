@@ -9,8 +9,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	libhtml "git.sr.ht/~shulhan/pakakeh.go/lib/html"
@@ -26,26 +29,53 @@ import (
 //	                     +-> canceled --+--+
 //	                     |              |  |
 //	                     +-> failed  ---+  +--> running
+//
+// The status skipped is only set on the JobLog, for the run that is not
+// executed, see [JobLog.Reason].
 const (
 	JobStatusCanceled = `canceled`
 	JobStatusFailed   = `failed`
 	JobStatusPaused   = `paused`
 	JobStatusRunning  = `running`
+	JobStatusSkipped  = `skipped`
 	JobStatusStarted  = `started`
 	JobStatusSuccess  = `success`
 )
 
+// List of reason why the run is skipped.
+const (
+	// jobSkipOverlap the scheduled run is triggered while the
+	// previous run is still running.
+	jobSkipOverlap = `previous run is still running`
+
+	// jobSkipQueueFull the run triggered by HTTP request while the
+	// queue already has pending request.
+	jobSkipQueueFull = `queue is full`
+
+	// jobSkipReadOnly the run triggered by HTTP request while the
+	// server is in read-only mode.
+	jobSkipReadOnly = `server is in read-only mode`
+)
+
 // JobBase define the base fields and commons methods for all job types.
 //
 // The base configuration in INI format,
 //
 //	[job "name"]
 //	description =
+//	disabled =
+//	owner =
+//	allowed_users =
 //	schedule =
 //	interval =
 //	log_retention =
 //	notif_on_success =
 //	notif_on_failed =
+//	notif_throttle =
+//	notif_digest =
+//	notif_on_category =
+//	failure_rule =
+//	heartbeat_url =
 type JobBase struct {
 	// The last time the job is finished running, in UTC.
 	LastRun time.Time `ini:"-" json:"last_run,omitempty"`
@@ -53,6 +83,14 @@ type JobBase struct {
 	// The next time the job will running, in UTC.
 	NextRun time.Time `ini:"-" json:"next_run,omitempty"`
 
+	// PausedUntil the time when the paused job will be resumed
+	// automatically, in UTC.
+	// It is nil if the job is not paused or paused indefinitely.
+	PausedUntil *time.Time `ini:"-" json:"paused_until,omitempty"`
+
+	// pauseTimer the timer to resume the job at PausedUntil.
+	pauseTimer *time.Timer
+
 	scheduler *libtime.Scheduler
 
 	// ctxCancel define the function to cancel job execution with
@@ -63,6 +101,37 @@ type JobBase struct {
 	// communicate job log for notification.
 	logq chan<- *JobLog
 
+	// notifDropped count the job log with notification that is dropped
+	// because the logq is full.
+	notifDropped *atomic.Int64
+
+	// leader the leader election, if its enabled.
+	// The scheduled run is skipped if this instance is not the leader.
+	leader *leaderElection
+
+	// readOnly the read-only mode of server.
+	// The job is not executed while the read-only mode is enabled.
+	readOnly *readOnlyMode
+
+	// ns the namespace where the job belong, nil if the job is not in
+	// namespace.
+	ns *Namespace
+
+	// middlewares contains the global and job Middlewares.
+	middlewares []JobMiddleware
+
+	// logForward the forwarder for LogForward.
+	logForward *logForwarder
+
+	// logStorage the external storage for logs, if its set.
+	// Otherwise, the logs are stored in the dirLog, see
+	// [JobBase.logStore].
+	logStorage Storage
+
+	// notifBatch collect the logs to be notified, only set if
+	// NotifThrottle or NotifDigest is set.
+	notifBatch *jobNotifBatch
+
 	// ID of the job.
 	// It must be unique, otherwise when jobs loaded, the last job will
 	// replace the previous job with the same ID.
@@ -80,6 +149,21 @@ type JobBase struct {
 	// Status of the job on last execution.
 	Status string `ini:"-" json:"status,omitempty"`
 
+	// HeartbeatURL define the URL of external monitor, for example
+	// healthchecks.io, that is pinged on each run.
+	// The "<HeartbeatURL>/start" is pinged when the job started, the
+	// "<HeartbeatURL>" when the job finished successfully, and the
+	// "<HeartbeatURL>/fail" when the job failed or canceled.
+	// The "${NAME}" in HeartbeatURL is expanded with the value of
+	// system environment variable NAME.
+	//
+	// This field is not exported into JSON since the URL commonly
+	// contains the secret.
+	HeartbeatURL string `ini:"::heartbeat_url" json:"-"`
+
+	// heartbeatURL the HeartbeatURL after expanded.
+	heartbeatURL string
+
 	// Schedule a timer that run periodically based on calendar or day
 	// time.
 	// A schedule is divided into monthly, weekly, daily, hourly, and
@@ -95,8 +179,17 @@ type JobBase struct {
 	// will be executed.
 	dirWork string
 
+	// source define the file where the job is loaded.
+	// It is used to report the duplicate job.
+	source string
+
 	dirLog string
 
+	// dirArtifact define the directory where the artifacts of each
+	// run are stored, "$BASE/var/lib/karajo/artifacts/$JOB_ID".
+	// It is empty for JobHTTP.
+	dirArtifact string
+
 	// NotifOnSuccess define list of notification where the job's log will
 	// be send when job execution finish successfully.
 	NotifOnSuccess []string `ini:"::notif_on_success" json:"notif_on_success,omitempty"`
@@ -105,6 +198,37 @@ type JobBase struct {
 	// be send when job execution failed.
 	NotifOnFailed []string `ini:"::notif_on_failed" json:"notif_on_failed,omitempty"`
 
+	// NotifDigest if set to "hourly" or "daily", the job logs are
+	// notified as single digest at the start of each hour or each day
+	// in UTC, instead of one notification per run.
+	NotifDigest string `ini:"::notif_digest" json:"notif_digest,omitempty"`
+
+	// NotifOnCategory define list of notification where the job's log
+	// will be send when job execution failed with specific category,
+	// in the format "<category>:<notif>", for example
+	// "disk-full:pager".
+	NotifOnCategory []string `ini:"::notif_on_category" json:"notif_on_category,omitempty"`
+
+	// notifOnCategory the list of notification indexed by category,
+	// parsed from NotifOnCategory.
+	notifOnCategory map[string][]string
+
+	// FailureRule define list of rule to classify the failed job in
+	// the format "<category>:<regex>", for example
+	// "disk-full:No space left on device".
+	// The category of the first rule that match with the log content
+	// is stored in the [JobLog.Category].
+	FailureRule []string `ini:"::failure_rule" json:"failure_rule,omitempty"`
+
+	// failureRules the compiled FailureRule.
+	failureRules []jobFailureRule
+
+	// Middlewares define the JobMiddleware that called around the job
+	// execution, after the global [Env.Middlewares].
+	// This field is optional, it is only used if job created through
+	// code.
+	Middlewares []JobMiddleware `ini:"-" json:"-"`
+
 	kind jobKind
 
 	// Logs contains cache of log sorted by its counter.
@@ -122,6 +246,100 @@ type JobBase struct {
 	// This field is optional, default to 5.
 	LogRetention int `ini:"::log_retention" json:"log_retention,omitempty"`
 
+	// LogMaxAge define the maximum age of logs to keep in storage.
+	// The log that finished before the age is removed periodically,
+	// except the last one.
+	// This field is optional, default to LogMaxAge in Env.
+	LogMaxAge time.Duration `ini:"::log_max_age" json:"log_max_age,omitempty"`
+
+	// LogMaxTotalSize define the maximum total size of logs to keep in
+	// storage, in bytes.
+	// If the total size of logs exceed it, the oldest logs are removed
+	// periodically, except the last one.
+	// This field is optional, default to LogMaxTotalSize in Env.
+	LogMaxTotalSize int64 `ini:"::log_max_total_size" json:"log_max_total_size,omitempty"`
+
+	// LogMaxSize define the maximum size of each log, in bytes.
+	// If the output of job exceed it, only the first and the last half
+	// of LogMaxSize are kept, separated by the line
+	// "[... N bytes truncated ...]", so a looping command cannot fill
+	// the disk.
+	// This field is optional, default to 0 or unlimited.
+	LogMaxSize int64 `ini:"::log_max_size" json:"log_max_size,omitempty"`
+
+	// LogForward define the URL of remote collector where each line of
+	// job log is forwarded live, in the format
+	// "<scheme>://<host>[:port][/path]".
+	// The scheme "syslog" or "udp", and "tcp", send each line in
+	// syslog RFC 5424 format, with the job ID and counter as
+	// structured data.
+	// The scheme "loki" push each line to the Loki HTTP API.
+	// This field is optional.
+	LogForward string `ini:"::log_forward" json:"log_forward,omitempty"`
+
+	// LogCompress if true, the previous log is compressed using gzip
+	// once the new log is flushed into storage.
+	// The compressed log is decompressed transparently when its
+	// loaded.
+	LogCompress bool `ini:"::log_compress" json:"log_compress,omitempty"`
+
+	// Disabled if true the job is not executed, neither by schedule,
+	// interval, nor HTTP request, until its enabled.
+	// Unlike pause, the job can be disabled or enabled through the
+	// HTTP API and the state is kept after restart, see
+	// [JobBase.setDisabled].
+	Disabled bool `ini:"::disabled" json:"disabled,omitempty"`
+
+	// disabledFile the file where the Disabled state set through HTTP
+	// API is stored.
+	disabledFile string
+
+	// NotifThrottle define the minimum duration between notifications.
+	// The job logs that finished within the duration after the last
+	// notification are notified as single digest once the duration
+	// passed.
+	// This field is ignored if NotifDigest is set.
+	NotifThrottle time.Duration `ini:"::notif_throttle" json:"notif_throttle,omitempty"`
+
+	// mutex the named semaphore acquired before the job run.
+	mutex *jobMutex
+
+	// Namespace define the name of namespace where the job belong.
+	// The job working directory and logs are stored in the namespace
+	// directory, and only the users in the namespace, or the users that
+	// is not member of any namespace, can access the job.
+	// This field is optional.
+	Namespace string `ini:"::namespace" json:"namespace,omitempty"`
+
+	// Owner define the name of user that own the job.
+	// If Owner or AllowedUsers is set, only the owner, the allowed
+	// users, and the Env Admins can run, pause, or change the job
+	// through the HTTP API or WUI.
+	// This field is optional.
+	Owner string `ini:"::owner" json:"owner,omitempty"`
+
+	// AllowedUsers list of user name, in addition to Owner, that can
+	// run, pause, or change the job.
+	// This field is optional.
+	AllowedUsers []string `ini:"::allowed_users" json:"allowed_users,omitempty"`
+
+	// MutexName define the name of mutex that the job acquire before
+	// running.
+	// Jobs that have the same MutexName never run concurrently, for
+	// example two jobs that touch the same database.
+	// This field is optional.
+	MutexName string `ini:"::mutex" json:"mutex,omitempty"`
+
+	// notifTimeout define how long to wait for logq when its full.
+	notifTimeout time.Duration
+
+	// revision of Env, bumped each time the job state changes.
+	revision *envRevision
+
+	// lastRevision the counter of revision when the job state last
+	// changed.
+	lastRevision int64
+
 	sync.Mutex
 }
 
@@ -145,16 +363,72 @@ func (job *JobBase) init(env *Env, name string) (err error) {
 	job.Name = name
 	job.ID = libhtml.NormalizeForID(name)
 	job.Status = JobStatusStarted
+	job.leader = env.HARole
+	job.readOnly = env.ReadOnlyMode
+	job.logStorage = env.logStorage
+	job.revision = env.revision
+	job.notifTimeout = env.NotifQueueTimeout
+	job.notifDropped = &env.notifDropped
+
+	job.Namespace = strings.TrimSpace(job.Namespace)
+	job.ns, err = env.namespace(job.Namespace)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = job.ns.addJob(job)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	job.initMiddlewares(env)
+
+	job.LogForward = strings.TrimSpace(expandEnv(job.LogForward))
+	job.logForward = nil
+	if len(job.LogForward) != 0 {
+		job.logForward, err = newLogForwarder(job.LogForward)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	job.heartbeatURL = strings.TrimRight(strings.TrimSpace(expandEnv(job.HeartbeatURL)), `/`)
+
+	job.MutexName = strings.TrimSpace(job.MutexName)
+	job.mutex = nil
+	if len(job.MutexName) != 0 {
+		job.mutex = env.jobMutex(job.MutexName, job.ID)
+	}
 
 	if job.LogRetention <= 0 {
 		job.LogRetention = defJobLogRetention
 	}
+	if job.LogMaxAge <= 0 {
+		job.LogMaxAge = env.LogMaxAge
+	}
+	if job.LogMaxTotalSize <= 0 {
+		job.LogMaxTotalSize = env.LogMaxTotalSize
+	}
+
+	err = job.initNotif()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = job.initFailureRules()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
 
 	err = job.initDirsState(env)
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	err = job.initDisabled(env)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
 	err = job.initLogs()
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
@@ -176,33 +450,42 @@ func (job *JobBase) init(env *Env, name string) (err error) {
 // For job with type http, the working directory should be at
 // "$BASE/var/lib/karajo/job_http/$JOB_ID" and the log should be at
 // "$BASE/var/log/karajo/job_http/$JOB_ID".
+//
+// For job with type sql, the working directory should be at
+// "$BASE/var/lib/karajo/job_sql/$JOB_ID" and the log should be at
+// "$BASE/var/log/karajo/job_sql/$JOB_ID".
+//
+// For job in namespace, the "ns/$NAMESPACE" is inserted before the job
+// type, for example "$BASE/var/lib/karajo/ns/$NAMESPACE/job/$JOB_ID".
 func (job *JobBase) initDirsState(env *Env) (err error) {
 	var logp = `initDirsState`
 
 	switch job.kind {
 	case jobKindExec:
-		job.dirWork = filepath.Join(env.dirLibJob, job.ID)
+		job.dirWork = filepath.Join(namespaceDir(env.dirLibJob, job.Namespace), job.ID)
 		err = os.MkdirAll(job.dirWork, 0700)
 		if err != nil {
 			return fmt.Errorf(`%s: %w`, logp, err)
 		}
 
-		job.dirLog = filepath.Join(env.dirLogJob, job.ID)
+		job.dirLog = filepath.Join(namespaceDir(env.dirLogJob, job.Namespace), job.ID)
 		err = os.MkdirAll(job.dirLog, 0700)
 		if err != nil {
 			return fmt.Errorf(`%s: %w`, logp, err)
 		}
 
+		job.dirArtifact = filepath.Join(namespaceDir(env.dirLibArtifact, job.Namespace), job.ID)
+
 		return nil
 
 	case jobKindHTTP:
-		job.dirWork = filepath.Join(env.dirLibJobHTTP, job.ID)
+		job.dirWork = filepath.Join(namespaceDir(env.dirLibJobHTTP, job.Namespace), job.ID)
 		err = os.MkdirAll(job.dirWork, 0700)
 		if err != nil {
 			return fmt.Errorf(`%s: %w`, logp, err)
 		}
 
-		job.dirLog = filepath.Join(env.dirLogJobHTTP, job.ID)
+		job.dirLog = filepath.Join(namespaceDir(env.dirLogJobHTTP, job.Namespace), job.ID)
 
 		// Remove previous log file.
 		_ = os.Remove(job.dirLog)
@@ -211,51 +494,96 @@ func (job *JobBase) initDirsState(env *Env) (err error) {
 		if err != nil {
 			return fmt.Errorf(`%s: %w`, logp, err)
 		}
+
+	case jobKindSQL:
+		job.dirWork = filepath.Join(namespaceDir(env.dirLibJobSQL, job.Namespace), job.ID)
+		err = os.MkdirAll(job.dirWork, 0700)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+
+		job.dirLog = filepath.Join(namespaceDir(env.dirLogJobSQL, job.Namespace), job.ID)
+		err = os.MkdirAll(job.dirLog, 0700)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
 	}
 	return nil
 }
 
+// logStore return the storage of logs and the key prefix of job logs in
+// the storage.
+// If the external storage is not set, the logs are stored in the local
+// file system, in the job log directory.
+func (job *JobBase) logStore() (storage Storage, prefix string) {
+	if job.logStorage == nil {
+		return newLogStorageFS(job.dirLog), ``
+	}
+	return job.logStorage, string(job.kind) + `/` + job.ID + `/`
+}
+
 // initLogs load the job logs state, counter, and status.
 //
-// For each file in job's log directory, parse the log file name in the form
-// of "$JOB_ID.$COUNTER.$STATUS" to get its counter and status.
+// For each file in job's log directory, or each object in the log
+// storage, parse the log file name in the form of
+// "$JOB_ID.$COUNTER.$STATUS" to get its counter and status.
 //
 // The logs then stored in ascending order by its counter.
 func (job *JobBase) initLogs() (err error) {
 	var (
-		dir       *os.File
-		hlog      *JobLog
-		fi        os.FileInfo
-		fiModTime time.Time
-		fis       []os.FileInfo
+		storage, prefix = job.logStore()
+		objs            []StorageObject
 	)
 
-	dir, err = os.Open(job.dirLog)
-	if err != nil {
-		return err
-	}
-	fis, err = dir.Readdir(0)
+	objs, err = storage.List(prefix)
 	if err != nil {
 		return err
 	}
 
-	for _, fi = range fis {
-		hlog = parseJobLogName(job.dirLog, fi.Name())
+	var (
+		hlog          *JobLog
+		obj           StorageObject
+		statusCounter int64
+	)
+	for _, obj = range objs {
+		hlog = parseJobLogName(job.dirLog, obj.Name)
 		if hlog == nil {
 			// Skip log with invalid file name.
 			continue
 		}
 
+		hlog.jobKind = job.kind
+		hlog.storage = job.logStorage
+		hlog.timeEnd = obj.ModTime.UTC().Round(time.Second)
+		hlog.size = obj.Size
+
 		job.Logs = append(job.Logs, hlog)
 
 		if hlog.Counter > job.counter {
 			job.counter = hlog.Counter
+		}
+		switch hlog.Status {
+		case JobStatusCanceled, JobStatusFailed:
+			hlog.loadExit()
+			hlog.loadCategory()
+			hlog.loadSteps()
+			hlog.loadUsage()
+			hlog.loadReaped()
+		case JobStatusSkipped:
+			hlog.loadReason()
+		case JobStatusSuccess:
+			hlog.loadSteps()
+			hlog.loadUsage()
+			hlog.loadReaped()
+		}
+		if hlog.Status != JobStatusSkipped && hlog.Counter > statusCounter {
+			// The skipped run does not change the job status.
+			statusCounter = hlog.Counter
 			job.Status = hlog.Status
 		}
 
-		fiModTime = fi.ModTime()
-		if fiModTime.After(job.LastRun) {
-			job.LastRun = fiModTime
+		if obj.ModTime.After(job.LastRun) {
+			job.LastRun = obj.ModTime
 		}
 	}
 
@@ -330,27 +658,115 @@ func (job *JobBase) logsPrune() {
 		// Delete old logs.
 		indexMin = totalLog - job.LogRetention
 		for _, hlog = range job.Logs[:indexMin] {
-			_ = os.Remove(hlog.path)
+			job.removeLog(hlog)
 		}
 		job.Logs = job.Logs[indexMin:]
 	}
 }
 
-// newLog create new JobLog.
-func (job *JobBase) newLog() (ctx context.Context, jlog *JobLog) {
+// removeLog remove the log from storage, including its artifacts.
+func (job *JobBase) removeLog(hlog *JobLog) {
+	hlog.remove()
+	if len(job.dirArtifact) != 0 {
+		_ = os.RemoveAll(job.artifactDir(hlog.Counter))
+	}
+}
+
+// logsCompress compress the finished log before the jlog.
+func (job *JobBase) logsCompress(jlog *JobLog) {
+	var (
+		x    = len(job.Logs) - 1
+		prev *JobLog
+		err  error
+	)
+	for ; x > 0; x-- {
+		if job.Logs[x] == jlog {
+			prev = job.Logs[x-1]
+			break
+		}
+	}
+	if prev == nil || !prev.isFinished() {
+		return
+	}
+	err = prev.compress()
+	if err != nil {
+		mlog.Errf(`job: %s: %s`, job.ID, err)
+	}
+}
+
+// logsSize return the total size of logs.
+func (job *JobBase) logsSize() (size int64) {
+	job.Lock()
+	var logs = job.Logs
+	job.Unlock()
+
+	var jlog *JobLog
+	for _, jlog = range logs {
+		size += jlog.logSize()
+	}
+	return size
+}
+
+// logsPruneAgeSize remove the finished logs that older than LogMaxAge or
+// exceed the LogMaxTotalSize, except the last log.
+// It return the number of logs removed.
+func (job *JobBase) logsPruneAgeSize(now time.Time) (n int) {
 	job.Lock()
 	defer job.Unlock()
 
-	job.counter++
+	if len(job.Logs) <= 1 {
+		return 0
+	}
+	if job.LogMaxAge <= 0 && job.LogMaxTotalSize <= 0 {
+		return 0
+	}
 
-	jlog = &JobLog{
-		jobKind: job.kind,
-		JobID:   job.ID,
-		Name:    fmt.Sprintf(`%s.%d`, job.ID, job.counter),
-		Counter: job.counter,
+	var (
+		last      = len(job.Logs) - 1
+		totalSize = job.Logs[last].logSize()
+		keep      = []*JobLog{job.Logs[last]}
+		minTime   = now.Add(-job.LogMaxAge)
+
+		hlog     *JobLog
+		x        int
+		isRemove bool
+	)
+	for x = last - 1; x >= 0; x-- {
+		hlog = job.Logs[x]
+		if !hlog.isFinished() {
+			keep = append(keep, hlog)
+			continue
+		}
+
+		totalSize += hlog.logSize()
+
+		isRemove = false
+		if job.LogMaxAge > 0 && hlog.timeEnd.Before(minTime) {
+			isRemove = true
+		}
+		if job.LogMaxTotalSize > 0 && totalSize > job.LogMaxTotalSize {
+			isRemove = true
+		}
+		if isRemove {
+			job.removeLog(hlog)
+			n++
+			continue
+		}
+		keep = append(keep, hlog)
 	}
 
-	jlog.path = filepath.Join(job.dirLog, jlog.Name)
+	slices.Reverse(keep)
+	job.Logs = keep
+
+	return n
+}
+
+// newLog create new JobLog.
+func (job *JobBase) newLog() (ctx context.Context, jlog *JobLog) {
+	job.Lock()
+	defer job.Unlock()
+
+	jlog = job.nextLog()
 
 	if job.Status == JobStatusPaused {
 		jlog.Status = JobStatusPaused
@@ -359,23 +775,109 @@ func (job *JobBase) newLog() (ctx context.Context, jlog *JobLog) {
 		jlog.Status = JobStatusRunning
 
 		ctx, job.ctxCancel = context.WithCancel(context.Background())
+
+		job.heartbeat(heartbeatStart, nil)
 	}
 
 	job.Logs = append(job.Logs, jlog)
 	job.logsPrune()
+	job.lastRevision = job.revision.bump()
 
 	return ctx, jlog
 }
 
-// canStart check if the job can be started or return an error if its paused
-// or reached maximum running.
+// nextLog increment the counter and return new JobLog for it.
+// The caller must hold the job lock.
+func (job *JobBase) nextLog() (jlog *JobLog) {
+	job.counter++
+
+	jlog = &JobLog{
+		storage: job.logStorage,
+		jobKind: job.kind,
+		JobID:   job.ID,
+		Name:    fmt.Sprintf(`%s.%d`, job.ID, job.counter),
+		Counter: job.counter,
+		maxSize: job.LogMaxSize,
+
+		timeBegin: timeNow(),
+	}
+
+	jlog.path = filepath.Join(job.dirLog, jlog.Name)
+	jlog.forward = job.logForward.open(jlog)
+
+	if len(job.dirLog) != 0 {
+		// The spool file name does not have status, so it will be
+		// removed by initLogs if karajo stopped before the log
+		// flushed.
+		var err = jlog.openSpool()
+		if err != nil {
+			mlog.Errf(`job: %s: %s`, job.ID, err)
+		}
+	}
+
+	return jlog
+}
+
+// skip record the run that is not executed as JobLog with status skipped
+// and the reason, so the run that is never attempted can be distinguished
+// from the one that finished.
+// The job status is not changed.
+func (job *JobBase) skip(reason string) (jlog *JobLog) {
+	job.Lock()
+	jlog = job.nextLog()
+	jlog.Status = JobStatusSkipped
+	jlog.Reason = reason
+	jlog.timeEnd = jlog.timeBegin
+	job.Logs = append(job.Logs, jlog)
+	if job.scheduler != nil {
+		job.NextRun = job.scheduler.Next()
+	}
+	job.lastRevision = job.revision.bump()
+	job.Unlock()
+
+	jlog.Write([]byte(jobLogSkipPrefix + reason + "\n"))
+
+	var err = jlog.flush()
+	if err != nil {
+		mlog.Errf(`job: %s: %s`, job.ID, err)
+	}
+	mlog.Outf(`%s: %s: #%d skipped: %s`, job.kind, job.ID, jlog.Counter, reason)
+
+	return jlog
+}
+
+// skipOverlap return true if the scheduled run at time t is triggered
+// while the previous run is still running, that is the previous run
+// finished after t.
+// The overlapped run is recorded as skipped by the leader.
+func (job *JobBase) skipOverlap(t time.Time) bool {
+	job.Lock()
+	var isOverlap = t.Before(job.LastRun)
+	job.Unlock()
+
+	if !isOverlap {
+		return false
+	}
+	if job.leader.isLeader() {
+		job.skip(jobSkipOverlap)
+	}
+	return true
+}
+
+// canStart check if the job can be started or return an error if its
+// disabled, paused, or its namespace reach the quota.
 func (job *JobBase) canStart() (err error) {
 	job.Lock()
-	if job.Status == JobStatusPaused {
+	if job.Disabled {
+		err = &errJobDisabled
+	} else if job.Status == JobStatusPaused {
 		err = &errJobPaused
 	}
 	job.Unlock()
-	return err
+	if err != nil {
+		return err
+	}
+	return job.ns.check()
 }
 
 // finish mark the job as finished.
@@ -404,33 +906,47 @@ func (job *JobBase) finish(jlog *JobLog, err error) {
 		}
 	}
 
+	jlog.writeUsage(timeNow())
+	if job.Status == JobStatusFailed {
+		job.classify(jlog)
+	}
 	jlog.setStatus(job.Status)
 	err = jlog.flush()
 	if err != nil {
 		mlog.Errf(`job: %s: %s`, job.ID, err)
 	}
+	if job.LogCompress {
+		job.logsCompress(jlog)
+	}
 
 	job.LastRun = timeNow()
+
+	jlog.Lock()
+	jlog.timeEnd = job.LastRun
+	jlog.Unlock()
+
+	job.heartbeatFinish(jlog)
+
 	if job.scheduler != nil {
 		job.NextRun = job.scheduler.Next()
 	} else if job.Interval > 0 {
 		job.NextRun = job.LastRun.Add(job.Interval)
 	}
 
-	if job.kind == jobKindExec {
+	job.lastRevision = job.revision.bump()
+
+	if job.kind == jobKindExec || job.kind == jobKindSQL {
 		switch jlog.Status {
 		case JobStatusSuccess:
 			jlog.listNotif = append(jlog.listNotif, job.NotifOnSuccess...)
 
 		case JobStatusFailed:
 			jlog.listNotif = append(jlog.listNotif, job.NotifOnFailed...)
+			jlog.listNotif = append(jlog.listNotif, job.categoryNotif(jlog)...)
 		}
 	}
 
-	select {
-	case job.logq <- jlog:
-	default:
-	}
+	job.notify(jlog)
 }
 
 // computeNextInterval compute the duration when the job will be running based
@@ -447,15 +963,57 @@ func (job *JobBase) computeNextInterval(now time.Time) time.Duration {
 }
 
 // pause the job execution.
-func (job *JobBase) pause() {
+// If until is not zero, the job is resumed automatically at that time,
+// with its status set to resumeStatus.
+func (job *JobBase) pause(until time.Time, resumeStatus string) {
 	job.Lock()
+	job.stopPauseTimer()
 	job.Status = JobStatusPaused
+	if !until.IsZero() {
+		until = until.UTC()
+		job.PausedUntil = &until
+		job.pauseTimer = time.AfterFunc(until.Sub(timeNow()), func() {
+			job.resumePaused(until, resumeStatus)
+		})
+	}
+	job.lastRevision = job.revision.bump()
 	job.Unlock()
 }
 
 // resume the job execution.
 func (job *JobBase) resume(status string) {
 	job.Lock()
+	job.stopPauseTimer()
 	job.Status = status
+	job.lastRevision = job.revision.bump()
 	job.Unlock()
 }
+
+// resumePaused resume the job that is paused until the time until.
+// If the job has been resumed or paused again with different time, it
+// will do nothing.
+func (job *JobBase) resumePaused(until time.Time, status string) {
+	job.Lock()
+	defer job.Unlock()
+
+	if job.Status != JobStatusPaused || job.PausedUntil == nil ||
+		!job.PausedUntil.Equal(until) {
+		return
+	}
+	job.PausedUntil = nil
+	job.pauseTimer = nil
+	job.Status = status
+	job.lastRevision = job.revision.bump()
+	mlog.Outf(`%s: %s: resumed after paused until %s`,
+		job.kind, job.ID, until.Format(time.RFC3339))
+}
+
+// stopPauseTimer stop the timer that resume the paused job.
+// The caller must hold the lock.
+func (job *JobBase) stopPauseTimer() {
+	if job.pauseTimer != nil {
+		job.pauseTimer.Stop()
+		job.pauseTimer = nil
+	}
+	job.PausedUntil = nil
+}
@@ -4,6 +4,9 @@
 package karajo
 
 import (
+	"fmt"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -68,3 +71,217 @@ func TestJobBase_computeNextInterval(t *testing.T) {
 		test.Assert(t, c.desc, c.exp, got)
 	}
 }
+
+func TestJobBase_logsPruneAgeSize(t *testing.T) {
+	type testCase struct {
+		desc    string
+		exp     []string
+		maxAge  time.Duration
+		maxSize int64
+	}
+
+	var (
+		now = time.Date(2026, 1, 10, 0, 0, 0, 0, time.UTC)
+
+		// newLogs create five logs, one per day, with the size 10
+		// bytes each.
+		// The last log is still running.
+		newLogs = func(dir string) (logs []*JobLog) {
+			var (
+				jlog *JobLog
+				x    int
+			)
+			for x = 1; x <= 5; x++ {
+				jlog = &JobLog{
+					Name:    fmt.Sprintf(`job.%d.success`, x),
+					Status:  JobStatusSuccess,
+					timeEnd: now.Add(time.Duration(x-6) * 24 * time.Hour),
+					size:    10,
+				}
+				jlog.path = filepath.Join(dir, jlog.Name)
+				var err = os.WriteFile(jlog.path, []byte(`0123456789`), 0600)
+				if err != nil {
+					t.Fatal(err)
+				}
+				logs = append(logs, jlog)
+			}
+			logs[4].Status = JobStatusRunning
+			return logs
+		}
+	)
+
+	var cases = []testCase{{
+		desc: `Disabled`,
+		exp: []string{`job.1.success`, `job.2.success`, `job.3.success`,
+			`job.4.success`, `job.5.success`},
+	}, {
+		desc:   `By age`,
+		maxAge: 72 * time.Hour,
+		exp:    []string{`job.3.success`, `job.4.success`, `job.5.success`},
+	}, {
+		desc:    `By size`,
+		maxSize: 25,
+		exp:     []string{`job.4.success`, `job.5.success`},
+	}, {
+		desc:   `Keep the last log`,
+		maxAge: time.Hour,
+		exp:    []string{`job.5.success`},
+	}}
+
+	var (
+		c    testCase
+		job  *JobBase
+		jlog *JobLog
+		got  []string
+		dir  string
+	)
+	for _, c = range cases {
+		dir = t.TempDir()
+		job = &JobBase{
+			Logs:            newLogs(dir),
+			LogMaxAge:       c.maxAge,
+			LogMaxTotalSize: c.maxSize,
+		}
+
+		job.logsPruneAgeSize(now)
+
+		got = nil
+		for _, jlog = range job.Logs {
+			got = append(got, jlog.Name)
+		}
+		test.Assert(t, c.desc, c.exp, got)
+
+		var fis, _ = os.ReadDir(dir)
+		test.Assert(t, c.desc+`: files`, len(c.exp), len(fis))
+	}
+}
+
+func TestJobBase_logsCompress(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		job  = &JobBase{}
+		jlog *JobLog
+		x    int
+		err  error
+	)
+	for x = 1; x <= 3; x++ {
+		jlog = &JobLog{
+			Name:   fmt.Sprintf(`job.%d.success`, x),
+			Status: JobStatusSuccess,
+		}
+		jlog.path = filepath.Join(dir, jlog.Name)
+		err = os.WriteFile(jlog.path, []byte(`output`), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+		job.Logs = append(job.Logs, jlog)
+	}
+
+	job.logsCompress(jlog)
+
+	var (
+		exp = []bool{false, true, false}
+		got []bool
+		b   []byte
+	)
+	for _, jlog = range job.Logs {
+		b, _ = os.ReadFile(jlog.path)
+		got = append(got, isGzip(b))
+	}
+	test.Assert(t, `compressed`, exp, got)
+}
+
+func TestJobBase_skipOverlap(t *testing.T) {
+	var (
+		now = time.Date(2026, 1, 10, 10, 0, 0, 0, time.UTC)
+		job = &JobBase{
+			kind:    jobKindExec,
+			ID:      `job`,
+			Status:  JobStatusSuccess,
+			LastRun: now,
+			dirLog:  t.TempDir(),
+		}
+	)
+
+	test.Assert(t, `after last run`, false, job.skipOverlap(now.Add(time.Minute)))
+	test.Assert(t, `before last run`, true, job.skipOverlap(now.Add(-time.Minute)))
+
+	var jlog = job.Logs[0]
+
+	test.Assert(t, `job status`, JobStatusSuccess, job.Status)
+	test.Assert(t, `log name`, `job.1.skipped`, jlog.Name)
+	test.Assert(t, `log reason`, jobSkipOverlap, jlog.Reason)
+
+	// The reason and job status should be loaded from storage.
+	var reload = &JobBase{
+		kind:         jobKindExec,
+		ID:           `job`,
+		dirLog:       job.dirLog,
+		LogRetention: defJobLogRetention,
+	}
+	var err = os.WriteFile(filepath.Join(job.dirLog, `job.2.success`), []byte(`output`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.Rename(jlog.path, filepath.Join(job.dirLog, `job.3.skipped`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = reload.initLogs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `reload counter`, int64(3), reload.counter)
+	test.Assert(t, `reload status`, JobStatusSuccess, reload.Status)
+	test.Assert(t, `reload reason`, jobSkipOverlap, reload.Logs[1].Reason)
+}
+
+func TestJobBase_pauseUntil(t *testing.T) {
+	var (
+		job = &JobBase{
+			kind:     jobKindHTTP,
+			ID:       `job`,
+			Status:   JobStatusSuccess,
+			revision: newEnvRevision(),
+		}
+		until = timeNow().Add(50 * time.Millisecond)
+	)
+
+	job.pause(until, JobStatusStarted)
+
+	job.Lock()
+	test.Assert(t, `Status`, JobStatusPaused, job.Status)
+	test.Assert(t, `PausedUntil`, until, *job.PausedUntil)
+	job.Unlock()
+
+	// Pause indefinitely stop the previous timer.
+	job.pause(time.Time{}, JobStatusStarted)
+	time.Sleep(100 * time.Millisecond)
+
+	job.Lock()
+	test.Assert(t, `paused indefinitely`, JobStatusPaused, job.Status)
+	test.Assert(t, `paused indefinitely: PausedUntil`, (*time.Time)(nil), job.PausedUntil)
+	job.Unlock()
+
+	job.pause(until, JobStatusStarted)
+
+	var (
+		status string
+		x      int
+	)
+	for x = 0; x < 50; x++ {
+		time.Sleep(10 * time.Millisecond)
+		job.Lock()
+		status = job.Status
+		job.Unlock()
+		if status != JobStatusPaused {
+			break
+		}
+	}
+
+	job.Lock()
+	test.Assert(t, `resumed`, JobStatusStarted, job.Status)
+	test.Assert(t, `resumed: PausedUntil`, (*time.Time)(nil), job.PausedUntil)
+	job.Unlock()
+}
@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// initDisabled load the Disabled state that set through HTTP API from
+// file "$DirBase/var/run/karajo/job_disabled/$JOB_KIND.$JOB_ID", if its
+// exist.
+// The state in file replace the Disabled from configuration, so the job
+// that disabled or enabled through HTTP API stay that way after restart.
+func (job *JobBase) initDisabled(env *Env) (err error) {
+	var logp = `initDisabled`
+
+	job.disabledFile = ``
+	if len(env.dirRunJobDisabled) == 0 {
+		return nil
+	}
+	job.disabledFile = filepath.Join(env.dirRunJobDisabled, string(job.kind)+`.`+job.ID)
+
+	var raw []byte
+
+	raw, err = os.ReadFile(job.disabledFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	job.Disabled, err = strconv.ParseBool(strings.TrimSpace(string(raw)))
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, job.disabledFile, err)
+	}
+	return nil
+}
+
+// setDisabled disable or enable the job and store the state in file.
+func (job *JobBase) setDisabled(disabled bool) (err error) {
+	var logp = `setDisabled`
+
+	job.Lock()
+	defer job.Unlock()
+
+	if len(job.disabledFile) != 0 {
+		err = os.WriteFile(job.disabledFile, []byte(strconv.FormatBool(disabled)+"\n"), 0600)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	job.Disabled = disabled
+	job.lastRevision = job.revision.bump()
+	return nil
+}
+
+// isDisabled return true if the job is disabled.
+func (job *JobBase) isDisabled() (disabled bool) {
+	job.Lock()
+	disabled = job.Disabled
+	job.Unlock()
+	return disabled
+}
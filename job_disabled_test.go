@@ -0,0 +1,89 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobBase_setDisabled(t *testing.T) {
+	var (
+		dirBase = t.TempDir()
+		newEnv  = func(disabled bool) *Env {
+			return &Env{
+				DirBase: dirBase,
+				ExecJobs: map[string]*JobExec{
+					`disabled`: {
+						JobBase: JobBase{
+							Disabled: disabled,
+							Interval: time.Hour,
+						},
+						Call: func(_ context.Context, _ io.Writer, _ *libhttp.EndpointRequest) error {
+							return nil
+						},
+					},
+				},
+			}
+		}
+		env = newEnv(true)
+		err error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job = env.ExecJobs[`disabled`]
+
+	test.Assert(t, `Disabled from config`, true, job.isDisabled())
+	test.Assert(t, `canStart`, true, errors.Is(job.canStart(), &errJobDisabled))
+	test.Assert(t, `nextRuns`, 0,
+		len(job.nextRuns(timeNow(), timeNow().Add(24*time.Hour), timeNow(), 10)))
+
+	err = job.setDisabled(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `canStart: enabled`, nil, job.canStart())
+
+	var content []byte
+
+	content, err = os.ReadFile(filepath.Join(env.dirRunJobDisabled, `job.disabled`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `stored state`, "false\n", string(content))
+
+	// Restart, the state from HTTP API replace the configuration.
+	env = newEnv(true)
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = env.ExecJobs[`disabled`]
+	test.Assert(t, `Disabled after restart`, false, job.isDisabled())
+
+	err = job.setDisabled(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	env = newEnv(false)
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	job = env.ExecJobs[`disabled`]
+	test.Assert(t, `Disabled after restart`, true, job.isDisabled())
+}
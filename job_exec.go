@@ -16,20 +16,36 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"os"
 	"os/exec"
+	"slices"
 	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+	"github.com/fsnotify/fsnotify"
 )
 
 const (
-	defJobLogRetention = 5
+	defJobLogRetention  = 5
+	defJobExecWaitDelay = 5 * time.Second
+
+	// jobCommandIgnoreError the prefix of command that is allowed to
+	// fail.
+	jobCommandIgnoreError = `-`
+
+	// List of label printed in the log before executing each command.
+	jobCommandLabelMain = `Execute`
+	jobCommandLabelPost = `Post-execute`
+	jobCommandLabelPre  = `Pre-execute`
 
 	jobEnvCounter   = `KARAJO_JOB_COUNTER`
 	jobEnvPath      = `PATH`
 	jobEnvPathValue = `/usr/local/sbin:/usr/local/bin:/usr/bin:/usr/bin/site_perl:/usr/bin/vendor_perl:/usr/bin/core_perl`
+	jobEnvStatus    = `KARAJO_JOB_STATUS`
 )
 
 // List of [JobExec.AuthKind] for authorization.
@@ -40,8 +56,9 @@ const (
 )
 
 const (
-	githubHeaderSign256 = `X-Hub-Signature-256`
-	githubHeaderSign    = `X-Hub-Signature`
+	githubHeaderSign256  = `X-Hub-Signature-256`
+	githubHeaderSign     = `X-Hub-Signature`
+	githubHeaderDelivery = `X-GitHub-Delivery`
 
 	sourcehutHeaderSign  = `X-Payload-Signature`
 	sourcehutHeaderNonce = `X-Payload-Nonce`
@@ -50,6 +67,8 @@ const (
 
 // JobExecHTTPHandler define an handler for triggering a JobExec using HTTP.
 //
+// The ctx parameter is canceled when the job canceled, the job stopped,
+// or the job Timeout reached, so the handler can abort cleanly.
 // The log parameter is used to log all output and error.
 // The epr parameter contains HTTP request, body, and response writer.
 type JobExecHTTPHandler func(ctx context.Context, log io.Writer, epr *libhttp.EndpointRequest) error
@@ -74,10 +93,6 @@ type JobExecHTTPHandler func(ctx context.Context, log io.Writer, epr *libhttp.En
 //	secret =
 //	command =
 type JobExec struct {
-	// jobq is a channel passed by Karajo instance to limit number of
-	// job running at the same time.
-	jobq chan struct{}
-
 	httpq chan *libhttp.EndpointRequest
 	stopq chan struct{}
 
@@ -109,7 +124,10 @@ type JobExec struct {
 	//
 	//   - sourcehut: See https://man.sr.ht/api-conventions.md#webhooks
 	//
-	// If this field is empty or invalid it will be set to hmac-sha256.
+	// The other AuthKind can be registered using [RegisterAuthKind].
+	//
+	// If this field is empty it will be set to hmac-sha256.
+	// If this field is unknown, the job initialization will fail.
 	AuthKind string `ini:"::auth_kind" json:"auth_kind,omitempty"`
 
 	// HeaderSign define the HTTP header where the signature is read.
@@ -118,7 +136,28 @@ type JobExec struct {
 
 	// Secret define a string to validate the signature of request.
 	// If its empty, it will be set to global Secret from Env.
+	// The secret can be read from external source using
+	// "<scheme>:<path>" format, see [Env.RegisterSecretProvider].
 	Secret string `ini:"::secret" json:"-"`
+	secret *secretValue
+
+	// secretFile the file where the rotated secret stored.
+	secretFile string
+
+	// secretState contains the rotated secret, if the secret has been
+	// rotated.
+	secretState *jobSecretState
+
+	// secretMtx protect the secret and secretState from being
+	// replaced by rotation while being read.
+	secretMtx sync.Mutex
+
+	// MaxRequestBody define the maximum size of request body to Path,
+	// in bytes.
+	// Set this field if the webhook payload may be larger than the
+	// global MaxRequestBody in Env.
+	// This field is optional, default to MaxRequestBody in Env.
+	MaxRequestBody int64 `ini:"::max_request_body" json:"max_request_body,omitempty"`
 
 	// Commands list of command to be executed.
 	// This option can be defined multiple times.
@@ -126,11 +165,312 @@ type JobExec struct {
 	// command:
 	//
 	//   - KARAJO_JOB_COUNTER: contains the current job counter.
+	//   - KARAJO_JOB_WATCH_FILES: contains the list of changed files,
+	//     only set if the job triggered by Watch.
+	//   - KARAJO_JOB_MESSAGE_TOPIC: contains the topic of message, only
+	//     set if the job triggered by message from SubscribeKind.
+	//   - KARAJO_JOB_MAIL_FROM: contains the sender addresses of mail,
+	//     separated by comma, only set if the job triggered by IMAPURL.
+	//   - KARAJO_JOB_MAIL_SUBJECT: contains the subject of mail, only
+	//     set if the job triggered by IMAPURL.
+	//
+	// If the job triggered by HTTP request, by message, or by mail, its
+	// body is passed as standard input to each command.
+	//
+	// The commands are not expanded by karajo.
+	// Instead, each system environment variable referenced as "${NAME}"
+	// in the commands is passed to the command, so it is expanded by
+	// the shell.
+	//
+	// A command that start with "-", for example "- rm -f /tmp/lock",
+	// is allowed to fail; its error is logged and the next command is
+	// executed.
 	Commands []string `ini:"::command" json:"commands,omitempty"`
 
+	// StepOrder define the order of Steps by their name.
+	// If its empty, the steps are executed in ascending order by their
+	// name.
+	// This option can be defined multiple times.
+	StepOrder []string `ini:"::step" json:"-"`
+
+	// Steps list of named group of commands, loaded from
+	// "step.<name>.command" keys in the job section, as alternative to
+	// Commands.
+	// The steps are executed in order, and once one of the step fail the
+	// rest of steps are skipped.
+	// The status and duration of each step are recorded in the
+	// [JobLog.Steps].
+	Steps []*JobExecStep `ini:"-" json:"steps,omitempty"`
+
+	// Matrix contains the list of values for each variable, loaded from
+	// "matrix.<var>" keys in the job section, with the values separated
+	// by comma.
+	// If its set, the Commands are executed once for each combination
+	// of values, with the environment variable KARAJO_MATRIX_<VAR> set
+	// to the value.
+	// The job is failed if one of the run failed.
+	Matrix map[string][]string `ini:"-" json:"matrix,omitempty"`
+
+	// matrixRuns list of run for each combination of Matrix values.
+	matrixRuns []jobMatrixRun
+
+	// ContinueOnError if its true, all of the Commands are allowed to
+	// fail, as if each of them start with "-".
+	ContinueOnError bool `ini:"::continue_on_error" json:"continue_on_error,omitempty"`
+
+	// PreCommands list of command to be executed before the Call or
+	// Commands, for example to setup the job.
+	// If one of the PreCommands fail, the Call or Commands are not
+	// executed and the job is marked as failed.
+	// This option can be defined multiple times.
+	PreCommands []string `ini:"::pre_command" json:"pre_commands,omitempty"`
+
+	// PostCommands list of command to be executed after the Call or
+	// Commands, for example to teardown the job.
+	// The PostCommands are always executed, even if the PreCommands,
+	// Call, or Commands fail or the job is canceled.
+	// The environment variable KARAJO_JOB_STATUS is set to the status of
+	// job before executing the PostCommands: "success", "failed", or
+	// "canceled".
+	// This option can be defined multiple times.
+	PostCommands []string `ini:"::post_command" json:"post_commands,omitempty"`
+
+	// Template define the name of [EnvTemplate] where the commands are
+	// rendered and executed before the Commands.
+	// This field is optional.
+	Template string `ini:"::template" json:"template,omitempty"`
+
+	// Params contains the value of template parameters, loaded from
+	// "param.<name>" keys in the job section.
+	// The parameter name is in lower case.
+	Params map[string]string `ini:"-" json:"params,omitempty"`
+
+	// RunParams list of parameter name that can be passed in the
+	// "params" object of JSON request body that trigger the job, for
+	// example to run "deploy version X" manually.
+	// Each parameter is passed to the Commands as environment variable
+	// KARAJO_PARAM_<NAME>, with the name in upper case and "-" replaced
+	// by "_".
+	// The request with parameter that is not listed is rejected.
+	// This option can be defined multiple times.
+	RunParams []string `ini:"::run_param" json:"run_params,omitempty"`
+
+	// Approval the triggered run that wait for approval.
+	Approval *JobExecApproval `ini:"-" json:"approval,omitempty"`
+
+	// sessionUser return the logged in user from the request, used to
+	// get the name of user that trigger or approve the run.
+	sessionUser func(req *http.Request) *User
+
+	// RequireApproval if its true, the run triggered by HTTP request
+	// wait until its approved by the logged in user, different from
+	// the user that trigger it, for example for production deploy
+	// under change control.
+	// The run is discarded if its not approved after ApprovalExpiry.
+	// The run triggered by schedule, interval, or other events are not
+	// affected.
+	RequireApproval bool `ini:"::require_approval" json:"require_approval,omitempty"`
+
+	// ApprovalExpiry define the time to wait for approval.
+	// This field is optional, default to 1 hour.
+	ApprovalExpiry time.Duration `ini:"::approval_expiry" json:"approval_expiry,omitempty"`
+	approvalExpiry time.Duration
+
+	// Target define the name of agent where the Commands executed.
+	// The agent, started using "karajo agent <name> <server-url>",
+	// poll the job from this karajo server, execute the Commands in
+	// its host, and stream the output back.
+	// The system environment variables in Commands are read from the
+	// agent host.
+	// This field is optional, default to execute the Commands in this
+	// host.
+	// It is ignored if the Call is set.
+	Target string `ini:"::target" json:"target,omitempty"`
+
+	// Artifacts list of glob patterns, relative to the working
+	// directory, of files to be collected after the job run.
+	// The matched files are copied into
+	// "$BASE/var/lib/karajo/artifacts/$JOB_ID/$COUNTER" and can be
+	// downloaded using the HTTP API.
+	// The artifacts are removed along with their log.
+	// This option can be defined multiple times.
+	// It is ignored if the Target is set.
+	Artifacts []string `ini:"::artifact" json:"artifacts,omitempty"`
+
+	// runq limit the number of JobExec running at the same time.
+	runq *jobRunQueue
+
+	// Priority define the priority of job to get the slot for running,
+	// when the number of running jobs reach the MaxJobRunning in Env.
+	// Valid values are "low", "normal", or "high".
+	// The job with higher priority run first, for example to prevent
+	// critical webhook deploys stuck behind the long low priority
+	// periodic jobs.
+	// This field is optional, default to "normal".
+	Priority string `ini:"::priority" json:"priority,omitempty"`
+	priority int
+
+	// StopSignal define the signal send to the running command, and
+	// all of its child processes, when the job canceled or karajo
+	// stopped.
+	// Valid values are "SIGHUP", "SIGINT", "SIGQUIT", "SIGKILL",
+	// "SIGUSR1", "SIGUSR2", or "SIGTERM", with or without prefix
+	// "SIG".
+	// This field is optional, default to "SIGTERM".
+	StopSignal string `ini:"::stop_signal" json:"stop_signal,omitempty"`
+	stopSignal syscall.Signal
+
+	// StopGracePeriod define the time to wait for the command to exit
+	// after receiving the StopSignal.
+	// If the command, or one of its child processes, still running
+	// after the grace period, they are killed.
+	// This field is optional, default to 10 seconds.
+	StopGracePeriod time.Duration `ini:"::stop_grace_period" json:"stop_grace_period,omitempty"`
+	stopGracePeriod time.Duration
+
+	// Timeout define the maximum duration of the job run.
+	// Once the timeout reached, the context passed to Call is canceled
+	// and the running command is stopped using the StopSignal, and
+	// the job is failed.
+	// The PostCommands is still executed after the timeout.
+	// This field is optional, default to zero, no timeout.
+	Timeout time.Duration `ini:"::timeout" json:"timeout,omitempty"`
+
+	// Watch list of glob patterns of files, for example
+	// "/srv/incoming/*.csv", that trigger the job when they changed.
+	// Only the file name can contains the pattern, the directory of
+	// pattern is watched non-recursively.
+	// The job run once no more changes after the WatchDelay, with the
+	// environment variable KARAJO_JOB_WATCH_FILES contains the list of
+	// changed files, separated by new line.
+	// This option can be defined multiple times.
+	Watch []string `ini:"::watch" json:"watch,omitempty"`
+
+	// WatchEvents list of file events that trigger the job.
+	// Valid values are "create", "write", "remove", "rename", or
+	// "chmod".
+	// This option can be defined multiple times.
+	// This field is optional, default to "create" and "write".
+	WatchEvents []string `ini:"::watch_event" json:"watch_events,omitempty"`
+
+	// WatchDelay define the time to wait after the last changes before
+	// running the job, so the file that written in several steps
+	// trigger the job only once.
+	// This field is optional, default to 1 second.
+	WatchDelay time.Duration `ini:"::watch_delay" json:"watch_delay,omitempty"`
+	watchDelay time.Duration
+
+	// watcher watch the files in Watch, only set if the job started
+	// and Watch is not empty.
+	watcher *jobWatcher
+
+	// watchDirs list of directory of Watch patterns.
+	watchDirs []string
+
+	// watchOps the fsnotify operations of WatchEvents.
+	watchOps fsnotify.Op
+
+	// SubscribeKind define the kind of message broker where the job
+	// subscribe to, so the job run for each message received.
+	// Valid values are "mqtt", "nats", or "amqp".
+	// The message payload is passed as the request body to the Call,
+	// and as the standard input to the Commands.
+	// This field is optional.
+	SubscribeKind string `ini:"::subscribe_kind" json:"subscribe_kind,omitempty"`
+
+	// SubscribeURL the URL of message broker, for example
+	// "mqtt://127.0.0.1:1883", "nats://127.0.0.1:4222", or
+	// "amqp://127.0.0.1:5672/vhost".
+	SubscribeURL string `ini:"::subscribe_url" json:"subscribe_url,omitempty"`
+
+	// SubscribeTopic the MQTT topic filter, the NATS subject, or the
+	// AMQP queue name, where the messages consumed.
+	SubscribeTopic string `ini:"::subscribe_topic" json:"subscribe_topic,omitempty"`
+
+	// SubscribeUser and SubscribePassword define the credential to
+	// connect to the message broker.
+	// The value can reference system environment variable using
+	// "${NAME}" format.
+	SubscribeUser     string `ini:"::subscribe_user" json:"-"`
+	SubscribePassword string `ini:"::subscribe_password" json:"-"`
+	subscribeUser     string
+	subscribePassword string
+
+	// subscribeCancel stop consuming the messages, only set if the
+	// job started and SubscribeKind is not empty.
+	subscribeCancel context.CancelFunc
+
+	// IMAPURL the URL of IMAP mailbox to be polled, in the format
+	// "imaps://host[:port][/mailbox]" or "imap://host[:port][/mailbox]",
+	// so the job run for each new mail.
+	// The mailbox is optional, default to "INBOX".
+	// The text body of mail is passed as the request body to the Call,
+	// and as the standard input to the Commands.
+	// This field is optional.
+	IMAPURL string `ini:"::imap_url" json:"imap_url,omitempty"`
+
+	// IMAPUser and IMAPPassword define the credential to login to the
+	// IMAP server.
+	// The value can reference system environment variable using
+	// "${NAME}" format.
+	IMAPUser     string `ini:"::imap_user" json:"-"`
+	IMAPPassword string `ini:"::imap_password" json:"-"`
+
+	// IMAPSubject and IMAPFrom filter the new mail by its subject and
+	// sender, case-insensitively.
+	// The mail is matched if its header contains the value.
+	// This field is optional.
+	IMAPSubject string `ini:"::imap_subject" json:"imap_subject,omitempty"`
+	IMAPFrom    string `ini:"::imap_from" json:"imap_from,omitempty"`
+
+	// IMAPInterval define the interval to poll the mailbox.
+	// This field is optional, default to 1 minute.
+	IMAPInterval time.Duration `ini:"::imap_interval" json:"imap_interval,omitempty"`
+
+	// mailbox the parsed IMAPURL and its options, only set if IMAPURL
+	// is not empty.
+	mailbox *jobMailbox
+
+	// mailboxCancel stop polling the mailbox, only set if the job
+	// started and IMAPURL is not empty.
+	mailboxCancel context.CancelFunc
+
+	// cmdDones contains the channel for each running command, closed
+	// when the command exited.
+	// There can be more than one command running at the same time if
+	// the job has Matrix.
+	cmdDones []chan struct{}
+
+	// queue the queue shared between karajo instances, if
+	// Env.QueueBackend is not memory.
+	queue jobQueue
+
+	// agents the hub to dispatch the Commands to agent with name
+	// Target.
+	agents *agentHub
+
+	// nonces the shared cache to reject the replayed webhook request.
+	nonces *nonceCache
+
+	// cmdEnvNames contains the name of system environment variables
+	// referenced in Commands.
+	cmdEnvNames []string
+
 	JobBase
 }
 
+// MarshalJSON encode the current state of job as JSON object.
+// The job is locked only while its being encoded, so encoding list of jobs
+// does not block the other jobs.
+func (job *JobExec) MarshalJSON() ([]byte, error) {
+	type jobExecJSON JobExec
+
+	job.Lock()
+	defer job.Unlock()
+
+	return json.Marshal((*jobExecJSON)(job))
+}
+
 // authorize the hook based on the AuthKind.
 func (job *JobExec) authorize(headers http.Header, reqbody []byte) (err error) {
 	var (
@@ -150,9 +490,27 @@ func (job *JobExec) authorize(headers http.Header, reqbody []byte) (err error) {
 			return fmt.Errorf(`%s: %w`, logp, err)
 		}
 		err = job.authSourcehut(headers, reqbody, pub)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		return nil
 
-	default:
+	case ``, JobAuthKindHmacSha256:
 		err = job.authHmacSha256(headers, reqbody)
+
+	default:
+		err = job.authCustom(headers, reqbody)
+	}
+	if err != nil && errors.Is(err, &errJobForbidden) && job.refreshSecret() {
+		// Retry with the new secret.
+		switch job.AuthKind {
+		case JobAuthKindGithub:
+			err = job.authGithub(headers, reqbody)
+		case ``, JobAuthKindHmacSha256:
+			err = job.authHmacSha256(headers, reqbody)
+		default:
+			err = job.authCustom(headers, reqbody)
+		}
 	}
 	if err != nil {
 		return fmt.Errorf(`%s: %w`, logp, err)
@@ -160,28 +518,77 @@ func (job *JobExec) authorize(headers http.Header, reqbody []byte) (err error) {
 	return nil
 }
 
+// requestNonce return the unique value of authorized webhook request,
+// prefixed with job ID.
+// For Sourcehut it is the nonce, for GitHub it is the delivery ID, and for
+// other it is the signature.
+func (job *JobExec) requestNonce(headers http.Header) (nonce string) {
+	switch job.AuthKind {
+	case JobAuthKindGithub:
+		nonce = headers.Get(githubHeaderDelivery)
+	case JobAuthKindSourcehut:
+		nonce = headers.Get(sourcehutHeaderNonce)
+	default:
+		nonce = headers.Get(job.HeaderSign)
+	}
+	if len(nonce) == 0 {
+		return ``
+	}
+	return job.ID + `:` + nonce
+}
+
+// refreshSecret fetch the external secret again.
+// It return true if the secret has been changed.
+func (job *JobExec) refreshSecret() bool {
+	var (
+		changed bool
+		err     error
+	)
+	job.secretMtx.Lock()
+	var secret = job.secret
+	job.secretMtx.Unlock()
+
+	changed, err = secret.refresh()
+	if err != nil {
+		mlog.Errf(`job: %s: refreshSecret: %s`, job.ID, err)
+		return false
+	}
+	return changed
+}
+
 // authGithub authorize the Github Webhook request.
 func (job *JobExec) authGithub(headers http.Header, reqbody []byte) (err error) {
 	var (
 		logp    = `authGithub`
 		gotSign = headers.Get(githubHeaderSign256)
-		secret  = []byte(job.Secret)
 
+		secrets [][]byte
+		secret  []byte
 		expSign string
 	)
 
-	if len(gotSign) != 0 {
+	secrets, err = job.secretList()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var isSha256 = len(gotSign) != 0
+	if isSha256 {
 		gotSign = strings.TrimPrefix(gotSign, `sha256=`)
-		expSign = Sign(reqbody, secret)
 	} else {
 		gotSign = headers.Get(githubHeaderSign)
-		expSign = signHmacSha1(reqbody, secret)
 	}
-	if expSign != gotSign {
-		return fmt.Errorf(`%s: %w`, logp, &errJobForbidden)
+	for _, secret = range secrets {
+		if isSha256 {
+			expSign = Sign(reqbody, secret)
+		} else {
+			expSign = signHmacSha1(reqbody, secret)
+		}
+		if expSign == gotSign {
+			return nil
+		}
 	}
-
-	return nil
+	return fmt.Errorf(`%s: %w`, logp, &errJobForbidden)
 }
 
 // authGithub authorize the Sourcehut Webhook request.
@@ -233,22 +640,129 @@ func (job *JobExec) authHmacSha256(headers http.Header, reqbody []byte) (err err
 	}
 
 	var (
-		secret  = []byte(job.Secret)
-		expSign = Sign(reqbody, secret)
+		secrets [][]byte
+		secret  []byte
 	)
-	if gotSign != expSign {
-		return fmt.Errorf(`%s: %w`, logp, &errJobForbidden)
+
+	secrets, err = job.secretList()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	for _, secret = range secrets {
+		if Sign(reqbody, secret) == gotSign {
+			return nil
+		}
 	}
+	return fmt.Errorf(`%s: %w`, logp, &errJobForbidden)
+}
 
-	return nil
+// secretBytes return the secret to validate the signature of request.
+func (job *JobExec) secretBytes() (secret []byte, err error) {
+	job.secretMtx.Lock()
+	var sv = job.secret
+	job.secretMtx.Unlock()
+
+	if sv == nil {
+		return []byte(job.Secret), nil
+	}
+	return sv.get()
 }
 
-func (job *JobExec) generateCmdEnvs() (env []string) {
-	env = append(env, fmt.Sprintf(`%s=%d`, jobEnvCounter, job.counter))
+// dryRun return the information on how the job will be executed on the
+// next run, without running it.
+func (job *JobExec) dryRun() (dry *JobExecDryRun) {
+	job.Lock()
+	defer job.Unlock()
+
+	dry = &JobExecDryRun{
+		ID:      job.ID,
+		DirWork: job.dirWork,
+		IsCall:  job.Call != nil,
+	}
+	if dry.IsCall {
+		return dry
+	}
+
+	dry.Envs = job.generateCmdEnvs(job.counter+1, true)
+	dry.PreCommands = append(dry.PreCommands, job.PreCommands...)
+	dry.Commands = append(dry.Commands, job.Commands...)
+	dry.Steps = append(dry.Steps, job.Steps...)
+
+	var run jobMatrixRun
+	for _, run = range job.matrixRuns {
+		dry.Matrix = append(dry.Matrix, run.name)
+	}
+	dry.PostCommands = append(dry.PostCommands, job.PostCommands...)
+
+	return dry
+}
+
+// generateCmdEnvs generate the environment variables for command.
+// If isRedacted is true, the value of system environment variables
+// referenced in Commands is replaced with "***", so its can be displayed
+// to user.
+func (job *JobExec) generateCmdEnvs(counter int64, isRedacted bool) (env []string) {
+	env = append(env, fmt.Sprintf(`%s=%d`, jobEnvCounter, counter))
 	env = append(env, fmt.Sprintf(`%s=%s`, jobEnvPath, jobEnvPathValue))
+
+	var (
+		name string
+		val  string
+		ok   bool
+	)
+	for _, name = range job.cmdEnvNames {
+		val, ok = os.LookupEnv(name)
+		if !ok {
+			continue
+		}
+		if isRedacted {
+			val = `***`
+		}
+		env = append(env, name+`=`+val)
+	}
 	return env
 }
 
+// runEnvs return the environment variables for commands in the run of
+// jlog, including the one set by the job middlewares.
+func (job *JobExec) runEnvs(jlog *JobLog) (envs []string) {
+	envs = job.generateCmdEnvs(jlog.Counter, false)
+	envs = append(envs, jlog.envs...)
+	return envs
+}
+
+// initCmdEnvNames collect the name of system environment variables
+// referenced in PreCommands, Commands, and PostCommands, except the one that is set by karajo.
+func (job *JobExec) initCmdEnvNames() {
+	job.cmdEnvNames = nil
+
+	var (
+		cmds []string
+		cmd  string
+		name string
+	)
+	cmds = append(cmds, job.PreCommands...)
+	cmds = append(cmds, job.mainCommands()...)
+	cmds = append(cmds, job.PostCommands...)
+	for _, cmd = range cmds {
+		for _, name = range envNames(cmd) {
+			switch name {
+			case jobEnvCounter, jobEnvPath, jobEnvStatus, jobEnvWatchFiles,
+				jobEnvMessageTopic, jobEnvMailFrom, jobEnvMailSubject:
+				continue
+			}
+			if strings.HasPrefix(name, jobEnvMatrixPrefix) ||
+				strings.HasPrefix(name, jobEnvParamPrefix) {
+				continue
+			}
+			if slices.Contains(job.cmdEnvNames, name) {
+				continue
+			}
+			job.cmdEnvNames = append(job.cmdEnvNames, name)
+		}
+	}
+}
+
 // init initialize the JobExec.
 //
 // For JobExec that need to be triggered by HTTP request the Path and Secret
@@ -273,12 +787,85 @@ func (job *JobExec) init(env *Env, name string) (err error) {
 	job.stopq = make(chan struct{}, 1)
 
 	job.Path = strings.TrimSpace(job.Path)
-	job.Secret = strings.TrimSpace(job.Secret)
-	if len(job.Secret) == 0 {
+	job.Target = strings.TrimSpace(job.Target)
+	job.agents = env.agents
+	job.nonces = env.nonces
+	job.queue = env.jobQueue
+	job.runq = env.jobRunQueue
+
+	job.priority, err = parseJobPriority(job.Priority)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	job.stopSignal, err = parseStopSignal(job.StopSignal)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	job.stopGracePeriod = job.StopGracePeriod
+	if job.stopGracePeriod <= 0 {
+		job.stopGracePeriod = defJobExecStopGracePeriod
+	}
+	job.watchDirs, err = parseJobWatch(job.Watch)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	job.watchOps, err = parseJobWatchEvents(job.WatchEvents)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	job.watchDelay = job.WatchDelay
+	if job.watchDelay <= 0 {
+		job.watchDelay = defJobExecWatchDelay
+	}
+	err = job.initSubscribe()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = job.initIMAP()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	// The namespace has been validated in the JobBase.init.
+	var ns, _ = env.namespace(job.Namespace)
+
+	job.Secret = strings.TrimSpace(expandEnv(job.Secret))
+	if len(job.Secret) == 0 && ns != nil && len(ns.Secret) != 0 {
+		job.Secret = ns.Secret
+		job.secret = ns.secret
+	} else if len(job.Secret) == 0 {
 		job.Secret = env.Secret
+		job.secret = env.secret
+	} else {
+		job.secret = env.newSecretValue(job.Secret)
+	}
+	err = job.initSecretState(env)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	if len(job.Commands) == 0 && job.Call == nil {
+	err = job.initSteps()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = job.initMatrix()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = job.initRunParams()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if job.RequireApproval && len(env.Users) == 0 && env.AuthOIDC == nil {
+		return fmt.Errorf(`%s: require_approval need user or auth:oidc`, logp)
+	}
+	job.approvalExpiry = job.ApprovalExpiry
+	if job.approvalExpiry <= 0 {
+		job.approvalExpiry = defJobApprovalExpiry
+	}
+
+	job.initCmdEnvNames()
+
+	if len(job.Commands) == 0 && len(job.Steps) == 0 && job.Call == nil {
 		return &errJobEmptyCommandsOrCall
 	}
 
@@ -286,13 +873,15 @@ func (job *JobExec) init(env *Env, name string) (err error) {
 		job.HeaderSign = HeaderNameXKarajoSign
 	}
 
-	job.AuthKind = strings.ToLower(job.AuthKind)
+	job.AuthKind = strings.ToLower(strings.TrimSpace(job.AuthKind))
 
-	switch job.AuthKind {
-	case JobAuthKindGithub, JobAuthKindSourcehut, JobAuthKindHmacSha256:
-		// OK.
-	default:
+	switch {
+	case len(job.AuthKind) == 0:
 		job.AuthKind = JobAuthKindHmacSha256
+	case isBuiltinAuthKind(job.AuthKind):
+		// OK.
+	case customAuthKind(job.AuthKind) == nil:
+		return fmt.Errorf(`%s: unknown auth_kind %q`, logp, job.AuthKind)
 	}
 
 	return nil
@@ -310,6 +899,13 @@ func (job *JobExec) handleHTTP(epr *libhttp.EndpointRequest) (resbody []byte, er
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %s: %w`, logp, job.ID, err)
 	}
+	if job.nonces.isReplayed(job.requestNonce(epr.HTTPRequest.Header)) {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, job.ID, &errAuthReplay)
+	}
+	_, err = job.paramEnvs(epr.RequestBody)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %s: %w`, logp, job.ID, err)
+	}
 
 	err = job.canStart()
 	if err != nil {
@@ -318,40 +914,99 @@ func (job *JobExec) handleHTTP(epr *libhttp.EndpointRequest) (resbody []byte, er
 
 	var res libhttp.EndpointResponse
 
-	select {
-	case job.httpq <- epr:
-		res.Code = http.StatusOK
-		res.Message = `OK`
-		res.Data = job
-	default:
-		return nil, &errJobAlreadyRun
+	res.Code = http.StatusOK
+	res.Message = `OK`
+	res.Data = job
+
+	if job.RequireApproval {
+		err = job.requestApproval(epr)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: %s: %w`, logp, job.ID, err)
+		}
+		res.Code = http.StatusAccepted
+		res.Message = `waiting approval`
+	} else {
+		err = job.trigger(epr)
+		if err != nil {
+			if errors.Is(err, &errJobAlreadyRun) {
+				return nil, err
+			}
+			return nil, fmt.Errorf(`%s: %s: %w`, logp, job.ID, err)
+		}
 	}
 
-	job.Lock()
 	resbody, err = json.Marshal(&res)
-	job.Unlock()
 
 	return resbody, err
 }
 
-// Start the job queue, either by scheduler, interval, or waiting for
-// request.
-func (job *JobExec) Start(jobq chan struct{}, logq chan<- *JobLog) {
-	job.jobq = jobq
-	job.JobBase.logq = logq
+// trigger run the job with the request, either by pushing it to the
+// shared queue or to the job queue.
+func (job *JobExec) trigger(epr *libhttp.EndpointRequest) (err error) {
+	if job.queue != nil {
+		// Push the request to the shared queue, to be run by one of
+		// the karajo instances.
+		var msg []byte
 
-	// Signal to the caller that job has started.
-	jobq <- struct{}{}
+		msg, err = newJobQueueMessage(job, epr)
+		if err != nil {
+			return err
+		}
+		return job.queue.push(msg)
+	}
+	select {
+	case job.httpq <- epr:
+		return nil
+	default:
+		job.skip(jobSkipQueueFull)
+		return &errJobAlreadyRun
+	}
+}
 
+// Start the job queue in the background, either by scheduler, interval, or
+// waiting for request.
+// The finished job log is published to logq.
+//
+// Starting the job does not take the slot defined by Env.MaxJobRunning,
+// the slot is only acquired while the job is executed.
+func (job *JobExec) Start(logq chan<- *JobLog) {
+	job.JobBase.logq = logq
+
+	if len(job.Watch) != 0 {
+		job.startWatcher()
+	}
+	if len(job.SubscribeKind) != 0 {
+		job.startSubscriber()
+	}
+	if job.mailbox != nil {
+		job.startMailbox()
+	}
 	if job.scheduler != nil {
-		job.startScheduler()
+		go job.startScheduler()
 		return
 	}
 	if job.Interval > 0 {
-		job.startInterval()
+		go job.startInterval()
+		return
+	}
+	go job.startQueue()
+}
+
+// startWatcher start watching the files in Watch.
+// If the watcher cannot be created, the job can still be triggered by
+// HTTP request, interval, or schedule.
+func (job *JobExec) startWatcher() {
+	var w, err = newJobWatcher(job)
+	if err != nil {
+		mlog.Errf(`job: %s: %s`, job.ID, err)
 		return
 	}
-	job.startQueue()
+
+	job.Lock()
+	job.watcher = w
+	job.Unlock()
+
+	go w.start()
 }
 
 // startQueue start JobExec queue that triggered only by HTTP request.
@@ -370,11 +1025,17 @@ func (job *JobExec) startQueue() {
 }
 
 func (job *JobExec) startScheduler() {
-	var epr *libhttp.EndpointRequest
+	var (
+		epr *libhttp.EndpointRequest
+		t   time.Time
+	)
 
 	for {
 		select {
-		case <-job.scheduler.C:
+		case t = <-job.scheduler.C:
+			if job.skipOverlap(t) {
+				continue
+			}
 			epr = nil
 
 		case epr = <-job.httpq:
@@ -402,6 +1063,7 @@ func (job *JobExec) startInterval() {
 		now = timeNow()
 		nextInterval = job.computeNextInterval(now)
 		job.NextRun = now.Add(nextInterval)
+		job.lastRevision = job.revision.bump()
 		job.Unlock()
 
 		if timer == nil {
@@ -427,25 +1089,54 @@ func (job *JobExec) startInterval() {
 }
 
 func (job *JobExec) run(epr *libhttp.EndpointRequest) {
+	if epr == nil && !job.leader.isLeader() {
+		// Scheduled run is handled by the leader.
+		return
+	}
+	if job.readOnly.is() {
+		if epr != nil {
+			// The request that has been queued before the
+			// read-only mode enabled.
+			job.skip(jobSkipReadOnly)
+			jobQueueAck(epr)
+		}
+		return
+	}
+	if job.isDisabled() {
+		jobQueueAck(epr)
+		return
+	}
+
 	var (
 		jlog *JobLog
 		err  error
 	)
 
-	job.jobq <- struct{}{}
+	err = job.ns.acquire()
+	if err != nil {
+		job.skip(err.Error())
+		jobQueueAck(epr)
+		return
+	}
+	defer job.ns.release()
+
+	job.mutex.lock(job.ID)
+	job.runq.acquire(job.priority)
 	jlog, err = job.execute(epr)
-	<-job.jobq
+	if jlog.Status != JobStatusPaused && len(job.Target) == 0 {
+		job.collectArtifacts(jlog)
+	}
+	job.runq.release()
+	job.mutex.unlock()
 
 	job.finish(jlog, err)
+
+	jobQueueAck(epr)
 }
 
-// execute the job Call or Commands.
+// execute the job PreCommands, Call or Commands, and PostCommands.
 func (job *JobExec) execute(epr *libhttp.EndpointRequest) (jlog *JobLog, err error) {
-	var (
-		ctx context.Context
-		cmd string
-		x   int
-	)
+	var ctx context.Context
 
 	ctx, jlog = job.JobBase.newLog()
 	if jlog.Status == JobStatusPaused {
@@ -453,45 +1144,316 @@ func (job *JobExec) execute(epr *libhttp.EndpointRequest) (jlog *JobLog, err err
 	}
 	defer job.JobBase.ctxCancel()
 
+	if job.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, job.Timeout)
+		defer cancel()
+	}
+
 	jlog.Write([]byte("=== BEGIN\n"))
 
-	// Call the job.
+	var run *JobRun
+
+	run, err = job.beforeRun(ctx, jlog)
+	if err != nil {
+		return jlog, err
+	}
+	defer func() {
+		job.afterRun(ctx, run, err)
+	}()
+
+	if len(job.PreCommands) != 0 {
+		var envs = job.runEnvs(jlog)
+		_, err = job.runCommands(ctx, jlog, jobCommandLabelPre, job.PreCommands, envs, nil)
+	}
+	if err == nil {
+		err = job.executeMain(ctx, jlog, epr)
+	}
+	if len(job.PostCommands) != 0 {
+		var errPost = job.executePost(ctx, jlog, err)
+		if err == nil {
+			err = errPost
+		}
+	}
+	if err != nil {
+		var ctxErr = ctx.Err()
+		if ctxErr != nil && errors.Is(ctxErr, context.Canceled) {
+			return jlog, &errJobCanceled
+		}
+		if errors.Is(ctxErr, context.DeadlineExceeded) {
+			return jlog, fmt.Errorf(`timeout after %s: %w`, job.Timeout, err)
+		}
+		return jlog, err
+	}
+	if job.Call == nil {
+		jlog.Write([]byte("=== DONE\n"))
+	}
+	return jlog, nil
+}
+
+// executeMain run the job Call, or the Commands in agent or locally, or
+// the Steps, or the Commands for each Matrix run.
+func (job *JobExec) executeMain(ctx context.Context, jlog *JobLog, epr *libhttp.EndpointRequest) (err error) {
 	if job.Call != nil {
-		err = job.Call(ctx, jlog, epr)
-		if err != nil {
-			goto onerror
+		return job.Call(ctx, jlog, epr)
+	}
+
+	var paramEnvs []string
+	if epr != nil {
+		// The params has been validated when the job triggered.
+		paramEnvs, _ = job.paramEnvs(epr.RequestBody)
+	}
+	if len(job.Target) != 0 {
+		return job.agents.dispatch(ctx, job, jlog, paramEnvs)
+	}
+
+	var (
+		envs = job.runEnvs(jlog)
+
+		stdin []byte
+		x     int
+	)
+	if epr != nil {
+		envs = append(envs, jobRequestEnvs(epr)...)
+		envs = append(envs, paramEnvs...)
+		stdin = epr.RequestBody
+	}
+	if len(job.Steps) != 0 {
+		return job.runSteps(ctx, jlog, envs, stdin)
+	}
+	if len(job.matrixRuns) != 0 {
+		return job.runMatrix(ctx, jlog, envs, stdin)
+	}
+	x, err = job.runCommands(ctx, jlog, jobCommandLabelMain, job.Commands, envs, stdin)
+	if err != nil {
+		jlog.setExit(x, err)
+	}
+	return err
+}
+
+// executePost run the PostCommands with the environment variable
+// KARAJO_JOB_STATUS set to the status of job based on errMain.
+// The PostCommands is executed even if the job has been canceled.
+func (job *JobExec) executePost(ctx context.Context, jlog *JobLog, errMain error) (err error) {
+	var status = JobStatusSuccess
+	if errMain != nil {
+		status = JobStatusFailed
+		if errors.Is(ctx.Err(), context.Canceled) {
+			status = JobStatusCanceled
 		}
-		return jlog, nil
 	}
 
-	// Run commands.
-	for x, cmd = range job.Commands {
+	var envs = job.runEnvs(jlog)
+
+	envs = append(envs, jobEnvStatus+`=`+status)
+
+	_, err = job.runCommands(context.WithoutCancel(ctx), jlog,
+		jobCommandLabelPost, job.PostCommands, envs, nil)
+	return err
+}
+
+// ctxKeyJobEnvs the key for storing the additional environment variables
+// of commands in the context of request that trigger the job.
+type ctxKeyJobEnvs struct{}
+
+// jobRequestEnvs return the additional environment variables of commands
+// from the request that trigger the job, for example the list of changed
+// files if the job triggered by Watch.
+func jobRequestEnvs(epr *libhttp.EndpointRequest) (envs []string) {
+	if epr == nil || epr.HTTPRequest == nil {
+		return nil
+	}
+	envs, _ = epr.HTTPRequest.Context().Value(ctxKeyJobEnvs{}).([]string)
+	return envs
+}
+
+// runCommands execute each of cmds using shell in order, with label
+// printed before each command.
+// It stop and return the index and error of the first command that fail,
+// unless the command is allowed to fail.
+// If stdin is not empty, it is passed as standard input to each command.
+func (job *JobExec) runCommands(ctx context.Context, jlog *JobLog, label string, cmds, envs []string, stdin []byte) (x int, err error) {
+	var (
+		execCmd       *exec.Cmd
+		cmd           string
+		isIgnoreError bool
+	)
+	for x, cmd = range cmds {
 		jlog.Write([]byte("\n"))
-		fmt.Fprintf(jlog, "--- Execute %2d: %s\n", x, cmd)
+		fmt.Fprintf(jlog, "--- %s %2d: %s\n", label, x, cmd)
 
-		var execCmd = exec.CommandContext(ctx, `/bin/sh`, `-c`, cmd)
+		cmd, isIgnoreError = parseCommand(cmd, job.ContinueOnError)
 
+		execCmd = exec.CommandContext(ctx, `/bin/sh`, `-c`, cmd)
 		execCmd.Dir = job.dirWork
-		execCmd.Env = job.generateCmdEnvs()
-		execCmd.Stdout = jlog
-		execCmd.Stderr = jlog
+		execCmd.Env = envs
+		if len(stdin) != 0 {
+			execCmd.Stdin = bytes.NewReader(stdin)
+		}
+
+		// Run the shell in its own session, and its own process
+		// group, so once the context canceled the stop signal is
+		// send to the shell and all of its child processes.
+		execCmd.SysProcAttr = &syscall.SysProcAttr{Setsid: true}
+		execCmd.Cancel = job.cancelCommand(jlog, execCmd)
 
-		err = execCmd.Run()
+		// Limit the time to wait for the shell to exit after the
+		// process group stopped, before its killed.
+		execCmd.WaitDelay = defJobExecWaitDelay
+
+		err = job.runCommand(jlog, execCmd)
+		if execCmd.ProcessState != nil {
+			jlog.addUsage(execCmd.ProcessState)
+		}
 		if err != nil {
-			goto onerror
+			if isIgnoreError && ctx.Err() == nil {
+				fmt.Fprintf(jlog, "--- %s %2d: error ignored: %s\n", label, x, err)
+				err = nil
+				continue
+			}
+			return x, err
 		}
 	}
+	return x, nil
+}
+
+// runCommand run the execCmd, with its output written into jlog, and
+// wait until its finished.
+// Once the shell exited, its child processes that still running in the
+// background are killed and recorded in the jlog.
+// While the command is running, the [JobExec.waitCommand] will wait
+// until it finished.
+func (job *JobExec) runCommand(jlog *JobLog, execCmd *exec.Cmd) (err error) {
+	var (
+		logp = `runCommand`
+
+		outr, outw *os.File
+		errr, errw *os.File
+	)
+
+	// Use the pipe, instead of letting the exec create it, so the
+	// shell can be waited without waiting for the output of its child
+	// processes.
+	outr, outw, err = os.Pipe()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	errr, errw, err = os.Pipe()
+	if err != nil {
+		_ = outr.Close()
+		_ = outw.Close()
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	execCmd.Stdout = outw
+	execCmd.Stderr = errw
 
-	jlog.Write([]byte("=== DONE\n"))
+	var copyq = make(chan struct{}, 2)
+	go func() {
+		_, _ = io.Copy(jlog, outr)
+		copyq <- struct{}{}
+	}()
+	go func() {
+		_, _ = io.Copy(jobLogStderr{jlog}, errr)
+		copyq <- struct{}{}
+	}()
 
-	return jlog, nil
+	var done = make(chan struct{})
+
+	job.Lock()
+	job.cmdDones = append(job.cmdDones, done)
+	job.Unlock()
+
+	err = execCmd.Run()
 
-onerror:
-	var ctxErr = ctx.Err()
-	if ctxErr != nil && errors.Is(ctxErr, context.Canceled) {
-		return jlog, &errJobCanceled
+	// Close our copy of the write end, so the output reaches EOF once
+	// all of the processes that hold it exited.
+	_ = outw.Close()
+	_ = errw.Close()
+
+	var pids []int
+	if execCmd.Process != nil {
+		pids = reapSession(execCmd.Process.Pid)
 	}
-	return jlog, err
+
+	// The process that create new session may still hold the
+	// output open.
+	// Limit the time to wait for the output before returning.
+	var (
+		timer = time.NewTimer(defJobExecWaitDelay)
+		x     int
+	)
+	for x = 0; x < 2; x++ {
+		select {
+		case <-copyq:
+		case <-timer.C:
+			_ = outr.Close()
+			_ = errr.Close()
+			<-copyq
+		}
+	}
+	timer.Stop()
+	_ = outr.Close()
+	_ = errr.Close()
+
+	jlog.addReaped(pids)
+
+	job.Lock()
+	job.cmdDones = slices.DeleteFunc(job.cmdDones, func(c chan struct{}) bool {
+		return c == done
+	})
+	job.Unlock()
+	close(done)
+
+	return err
+}
+
+// cancelCommand return the function that stop the execCmd, and all of its
+// child processes, once the context canceled.
+func (job *JobExec) cancelCommand(jlog *JobLog, execCmd *exec.Cmd) func() error {
+	return func() error {
+		fmt.Fprintf(jlog, "--- Stopping with %s, grace period %s\n",
+			job.stopSignal, job.stopGracePeriod)
+		return stopProcessGroup(execCmd.Process.Pid, job.stopSignal,
+			job.stopGracePeriod)
+	}
+}
+
+// waitCommand wait until the current running commands exited, or until
+// the grace period and the delay for reading its output passed.
+func (job *JobExec) waitCommand() {
+	job.Lock()
+	var dones = slices.Clone(job.cmdDones)
+	job.Unlock()
+
+	if len(dones) == 0 {
+		return
+	}
+
+	var (
+		timer = time.NewTimer(job.stopGracePeriod + defJobExecWaitDelay)
+		done  chan struct{}
+	)
+	defer timer.Stop()
+
+	for _, done = range dones {
+		select {
+		case <-done:
+		case <-timer.C:
+			mlog.Errf(`job: %s: command still running after stopped`, job.ID)
+			return
+		}
+	}
+}
+
+// parseCommand remove the prefix "-" from cmd and return true if the
+// error of cmd should be ignored, either because it has prefix "-" or
+// isContinueOnError is true.
+func parseCommand(cmd string, isContinueOnError bool) (string, bool) {
+	var trimmed = strings.TrimSpace(cmd)
+	if strings.HasPrefix(trimmed, jobCommandIgnoreError) {
+		return strings.TrimSpace(trimmed[1:]), true
+	}
+	return cmd, isContinueOnError
 }
 
 // Stop the JobExec queue.
@@ -500,6 +1462,21 @@ func (job *JobExec) Stop() {
 
 	job.JobBase.Cancel()
 
+	job.Lock()
+	if job.watcher != nil {
+		job.watcher.close()
+		job.watcher = nil
+	}
+	if job.subscribeCancel != nil {
+		job.subscribeCancel()
+		job.subscribeCancel = nil
+	}
+	if job.mailboxCancel != nil {
+		job.mailboxCancel()
+		job.mailboxCancel = nil
+	}
+	job.Unlock()
+
 	select {
 	case job.stopq <- struct{}{}:
 	default:
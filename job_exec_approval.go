@@ -0,0 +1,107 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defJobApprovalExpiry the default time the triggered run wait for
+// approval.
+const defJobApprovalExpiry = time.Hour
+
+// JobExecApproval contains the state of triggered run that wait for
+// approval.
+type JobExecApproval struct {
+	// RequestedAt the time when the run triggered.
+	RequestedAt time.Time `json:"requested_at"`
+
+	// ExpiredAt the time when the run discarded if its not approved.
+	ExpiredAt time.Time `json:"expired_at"`
+
+	// epr the request that trigger the run.
+	epr *libhttp.EndpointRequest
+
+	// Requester the name of user that trigger the run.
+	// It is empty if the run is not triggered from the logged in
+	// user, for example by webhook.
+	Requester string `json:"requester,omitempty"`
+}
+
+// requester return the name of logged in user from the request that
+// trigger the job.
+func (job *JobExec) requester(req *http.Request) string {
+	if job.sessionUser == nil || req == nil {
+		return ``
+	}
+	var user = job.sessionUser(req)
+	if user == nil {
+		return ``
+	}
+	return user.Name
+}
+
+// pendingApproval return the run that wait for approval.
+// The expired approval is discarded.
+// The caller must hold the lock.
+func (job *JobExec) pendingApproval() *JobExecApproval {
+	if job.Approval == nil {
+		return nil
+	}
+	if !job.Approval.ExpiredAt.After(timeNow()) {
+		mlog.Outf(`job: %s: approval expired, requested by %q`,
+			job.ID, job.Approval.Requester)
+		job.Approval = nil
+	}
+	return job.Approval
+}
+
+// requestApproval hold the triggered run until its approved.
+// Only one run can wait for approval at the same time.
+func (job *JobExec) requestApproval(epr *libhttp.EndpointRequest) (err error) {
+	var now = timeNow()
+
+	job.Lock()
+	defer job.Unlock()
+
+	if job.pendingApproval() != nil {
+		return &errJobApprovalPending
+	}
+
+	job.Approval = &JobExecApproval{
+		RequestedAt: now,
+		ExpiredAt:   now.Add(job.approvalExpiry),
+		Requester:   job.requester(epr.HTTPRequest),
+		epr:         epr,
+	}
+	mlog.Outf(`job: %s: waiting approval, requested by %q`,
+		job.ID, job.Approval.Requester)
+	return nil
+}
+
+// approve run the job that wait for approval.
+// The approver must be different from the user that trigger the run.
+func (job *JobExec) approve(approver string) (err error) {
+	job.Lock()
+	var approval = job.pendingApproval()
+	if approval == nil {
+		job.Unlock()
+		return &errJobApprovalNotFound
+	}
+	if approver == approval.Requester {
+		job.Unlock()
+		return &errJobApprovalSelf
+	}
+	job.Approval = nil
+	job.Unlock()
+
+	mlog.Outf(`job: %s: approved by %q, requested by %q`,
+		job.ID, approver, approval.Requester)
+
+	return job.trigger(approval.epr)
+}
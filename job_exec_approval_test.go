@@ -0,0 +1,82 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_approve(t *testing.T) {
+	var job = &JobExec{
+		JobBase: JobBase{
+			ID: `deploy`,
+		},
+		httpq:          make(chan *libhttp.EndpointRequest, 1),
+		approvalExpiry: time.Hour,
+		sessionUser: func(_ *http.Request) *User {
+			return &User{Name: `alice`}
+		},
+	}
+
+	var (
+		epr = &libhttp.EndpointRequest{
+			HTTPRequest: httptest.NewRequest(http.MethodPost, `/`, nil),
+		}
+		err error
+	)
+
+	err = job.approve(`bob`)
+	test.Assert(t, `approve without request`, errJobApprovalNotFound.Error(), err.Error())
+
+	err = job.requestApproval(epr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `Requester`, `alice`, job.Approval.Requester)
+
+	err = job.requestApproval(epr)
+	test.Assert(t, `request twice`, errJobApprovalPending.Error(), err.Error())
+
+	err = job.approve(`alice`)
+	test.Assert(t, `approve by requester`, errJobApprovalSelf.Error(), err.Error())
+
+	err = job.approve(`bob`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `Approval`, (*JobExecApproval)(nil), job.Approval)
+	test.Assert(t, `triggered`, epr, <-job.httpq)
+
+	// The run that is not approved is discarded after expired.
+	err = job.requestApproval(epr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.Approval.ExpiredAt = timeNow()
+
+	err = job.approve(`bob`)
+	test.Assert(t, `approve expired`, errJobApprovalNotFound.Error(), err.Error())
+}
+
+func TestJobExec_init_requireApproval(t *testing.T) {
+	var config = `
+[job "deploy"]
+require_approval = true
+command = echo
+`
+	var env, err = ParseEnv([]byte(config))
+	if err != nil {
+		t.Fatal(err)
+	}
+	env.DirBase = t.TempDir()
+
+	err = env.init()
+	test.Assert(t, `error`, `init: init: require_approval need user or auth:oidc`, err.Error())
+}
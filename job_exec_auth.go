@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// AuthKindFunc verify the JobExec webhook request using its headers,
+// body, and the job Secret.
+// It must return non-nil error if the request is not authorized.
+type AuthKindFunc func(headers http.Header, body, secret []byte) error
+
+var (
+	authKinds    = map[string]AuthKindFunc{}
+	authKindsMtx sync.RWMutex
+)
+
+// RegisterAuthKind register custom [JobExec.AuthKind] to verify the
+// webhook request from other providers, for example Stripe or DockerHub.
+// The name is case insensitive and must not be one of the built-in
+// AuthKind: github, hmac-sha256, or sourcehut.
+// Registering with the same name replace the existing one.
+//
+// The AuthKind must be registered before the job initialized.
+func RegisterAuthKind(name string, verify AuthKindFunc) (err error) {
+	var logp = `RegisterAuthKind`
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 {
+		return fmt.Errorf(`%s: empty name`, logp)
+	}
+	if isBuiltinAuthKind(name) {
+		return fmt.Errorf(`%s: %q is built-in`, logp, name)
+	}
+	if verify == nil {
+		return fmt.Errorf(`%s: %q: nil verify function`, logp, name)
+	}
+
+	authKindsMtx.Lock()
+	authKinds[name] = verify
+	authKindsMtx.Unlock()
+
+	return nil
+}
+
+// isBuiltinAuthKind return true if the name is one of built-in AuthKind.
+func isBuiltinAuthKind(name string) bool {
+	switch name {
+	case JobAuthKindGithub, JobAuthKindHmacSha256, JobAuthKindSourcehut:
+		return true
+	}
+	return false
+}
+
+// customAuthKind return the registered AuthKindFunc by its name.
+func customAuthKind(name string) (verify AuthKindFunc) {
+	authKindsMtx.RLock()
+	verify = authKinds[name]
+	authKindsMtx.RUnlock()
+	return verify
+}
+
+// authCustom authorize the webhook request using the registered
+// AuthKindFunc, against the current and previous job secret.
+func (job *JobExec) authCustom(headers http.Header, reqbody []byte) (err error) {
+	var (
+		logp   = `authCustom`
+		verify = customAuthKind(job.AuthKind)
+
+		secrets [][]byte
+		secret  []byte
+	)
+	if verify == nil {
+		return fmt.Errorf(`%s: unknown auth_kind %q`, logp, job.AuthKind)
+	}
+
+	secrets, err = job.secretList()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	for _, secret = range secrets {
+		err = verify(headers, reqbody, secret)
+		if err == nil {
+			return nil
+		}
+	}
+	if errors.Is(err, &errJobForbidden) {
+		return fmt.Errorf(`%s: %s: %w`, logp, job.AuthKind, err)
+	}
+	return fmt.Errorf(`%s: %s: %s: %w`, logp, job.AuthKind, err, &errJobForbidden)
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestRegisterAuthKind(t *testing.T) {
+	var verifyToken = func(headers http.Header, _, secret []byte) error {
+		if headers.Get(`X-Token`) != string(secret) {
+			return errors.New(`invalid token`)
+		}
+		return nil
+	}
+
+	type testCase struct {
+		verify   AuthKindFunc
+		name     string
+		expError string
+	}
+
+	var cases = []testCase{{
+		name:     ` `,
+		verify:   verifyToken,
+		expError: `RegisterAuthKind: empty name`,
+	}, {
+		name:     `GitHub`,
+		verify:   verifyToken,
+		expError: `RegisterAuthKind: "github" is built-in`,
+	}, {
+		name:     `token`,
+		expError: `RegisterAuthKind: "token": nil verify function`,
+	}, {
+		name:   `Token`,
+		verify: verifyToken,
+	}}
+
+	var (
+		c   testCase
+		err error
+	)
+	for _, c = range cases {
+		err = RegisterAuthKind(c.name, c.verify)
+		if err != nil {
+			test.Assert(t, c.name, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.name, c.expError, ``)
+	}
+	t.Cleanup(func() {
+		authKindsMtx.Lock()
+		delete(authKinds, `token`)
+		authKindsMtx.Unlock()
+	})
+
+	var env = &Env{
+		DirBase: t.TempDir(),
+		Secret:  `s3cret`,
+	}
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job = &JobExec{
+		Path:     `/test`,
+		AuthKind: `TOKEN`,
+		Commands: []string{`echo test`},
+	}
+
+	err = job.init(env, `test`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `AuthKind`, `token`, job.AuthKind)
+
+	var header = http.Header{}
+
+	header.Set(`X-Token`, `s3cret`)
+	err = job.authorize(header, nil)
+	test.Assert(t, `authorize: valid token`, nil, err)
+
+	header.Set(`X-Token`, `secret`)
+	err = job.authorize(header, nil)
+	test.Assert(t, `authorize: invalid token`, true, errors.Is(err, &errJobForbidden))
+
+	job = &JobExec{
+		Path:     `/unknown`,
+		AuthKind: `stripe`,
+		Commands: []string{`echo test`},
+	}
+	err = job.init(env, `unknown`)
+	test.Assert(t, `init: unknown auth_kind`,
+		`init: unknown auth_kind "stripe"`, err.Error())
+}
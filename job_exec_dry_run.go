@@ -0,0 +1,41 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+// JobExecDryRun contains the information on how the JobExec will be
+// executed, without running it.
+type JobExecDryRun struct {
+	// ID of the job.
+	ID string `json:"id"`
+
+	// DirWork the working directory where the commands executed.
+	DirWork string `json:"dir_work"`
+
+	// Envs list of environment variables, in the format "KEY=VALUE",
+	// passed to each command.
+	Envs []string `json:"envs,omitempty"`
+
+	// PreCommands list of command that will be executed before the
+	// Commands.
+	PreCommands []string `json:"pre_commands,omitempty"`
+
+	// Commands list of command that will be executed, in order.
+	Commands []string `json:"commands,omitempty"`
+
+	// Steps list of step that will be executed, in order.
+	Steps []*JobExecStep `json:"steps,omitempty"`
+
+	// Matrix list of run for each combination of matrix values, in the
+	// format "<var>=<value>[,...]".
+	// The Commands are executed once for each run.
+	Matrix []string `json:"matrix,omitempty"`
+
+	// PostCommands list of command that will be executed after the
+	// Commands.
+	PostCommands []string `json:"post_commands,omitempty"`
+
+	// IsCall is true if the job execute the Call handler instead of
+	// Commands.
+	IsCall bool `json:"is_call,omitempty"`
+}
@@ -0,0 +1,357 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+	"github.com/emersion/go-imap"
+	imapclient "github.com/emersion/go-imap/client"
+	_ "github.com/emersion/go-message/charset" // Decode non UTF-8 mail.
+	"github.com/emersion/go-message/mail"
+)
+
+const (
+	// defJobIMAPInterval the default interval to poll the mailbox.
+	defJobIMAPInterval = time.Minute
+
+	// defJobIMAPMailbox the default mailbox to be polled.
+	defJobIMAPMailbox = `INBOX`
+
+	// defJobIMAPTimeout the timeout for connecting and for each IMAP
+	// command.
+	defJobIMAPTimeout = 30 * time.Second
+)
+
+// List of environment variables for the job that triggered by mail.
+const (
+	jobEnvMailFrom    = `KARAJO_JOB_MAIL_FROM`
+	jobEnvMailSubject = `KARAJO_JOB_MAIL_SUBJECT`
+)
+
+// jobMailbox poll the IMAP mailbox and trigger the job for each unseen
+// message that match the filters.
+type jobMailbox struct {
+	// addr the IMAP server address in the format "host:port".
+	addr string
+
+	// mailbox the name of mailbox to be polled.
+	mailbox string
+
+	user     string
+	password string
+
+	// subject and from filter the messages using IMAP SEARCH, which
+	// match the sub-string case-insensitively.
+	subject string
+	from    string
+
+	isTLS bool
+}
+
+// parseJobIMAPURL parse the IMAP URL in the format
+// "imaps://host[:port][/mailbox]" or "imap://host[:port][/mailbox]".
+func parseJobIMAPURL(rawurl string) (mbox *jobMailbox, err error) {
+	var imapURL *url.URL
+
+	imapURL, err = url.Parse(rawurl)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid imap_url %q: %w`, rawurl, err)
+	}
+
+	mbox = &jobMailbox{
+		mailbox: strings.Trim(imapURL.Path, `/`),
+	}
+	if len(mbox.mailbox) == 0 {
+		mbox.mailbox = defJobIMAPMailbox
+	}
+
+	var port string
+
+	switch imapURL.Scheme {
+	case `imaps`:
+		mbox.isTLS = true
+		port = `993`
+	case `imap`:
+		port = `143`
+	default:
+		return nil, fmt.Errorf(`invalid imap_url %q: unknown scheme %q`,
+			rawurl, imapURL.Scheme)
+	}
+	if len(imapURL.Hostname()) == 0 {
+		return nil, fmt.Errorf(`invalid imap_url %q: empty host`, rawurl)
+	}
+	if len(imapURL.Port()) != 0 {
+		port = imapURL.Port()
+	}
+	mbox.addr = net.JoinHostPort(imapURL.Hostname(), port)
+	return mbox, nil
+}
+
+// initIMAP validate and normalize the IMAP options.
+func (job *JobExec) initIMAP() (err error) {
+	job.IMAPURL = strings.TrimSpace(job.IMAPURL)
+	if len(job.IMAPURL) == 0 {
+		return nil
+	}
+
+	job.mailbox, err = parseJobIMAPURL(job.IMAPURL)
+	if err != nil {
+		return err
+	}
+	job.mailbox.user = expandEnv(job.IMAPUser)
+	job.mailbox.password = expandEnv(job.IMAPPassword)
+	job.mailbox.subject = job.IMAPSubject
+	job.mailbox.from = job.IMAPFrom
+
+	if job.IMAPInterval <= 0 {
+		job.IMAPInterval = defJobIMAPInterval
+	}
+	return nil
+}
+
+// startMailbox poll the mailbox every IMAPInterval until the job stopped.
+// Only the leader poll the mailbox, so each message trigger the job once.
+func (job *JobExec) startMailbox() {
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	job.Lock()
+	job.mailboxCancel = cancel
+	job.Unlock()
+
+	go func() {
+		var (
+			ticker = time.NewTicker(job.IMAPInterval)
+			err    error
+		)
+		defer ticker.Stop()
+
+		for {
+			if job.leader.isLeader() {
+				err = job.mailbox.poll(ctx, job.httpq)
+				if err != nil {
+					mlog.Errf(`job: %s: imap: %s`, job.ID, err)
+				}
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// dial connect and login to the IMAP server.
+func (mbox *jobMailbox) dial() (cl *imapclient.Client, err error) {
+	var dialer = &net.Dialer{Timeout: defJobIMAPTimeout}
+
+	if mbox.isTLS {
+		cl, err = imapclient.DialWithDialerTLS(dialer, mbox.addr, nil)
+	} else {
+		cl, err = imapclient.DialWithDialer(dialer, mbox.addr)
+	}
+	if err != nil {
+		return nil, err
+	}
+	cl.Timeout = defJobIMAPTimeout
+
+	err = cl.Login(mbox.user, mbox.password)
+	if err != nil {
+		_ = cl.Logout()
+		return nil, err
+	}
+	return cl, nil
+}
+
+// poll search the unseen messages that match the filters, and pass each
+// of them as request to msgq.
+// Once the job take the message, it is marked as seen, so the message
+// trigger the job at most once.
+func (mbox *jobMailbox) poll(ctx context.Context, msgq chan<- *libhttp.EndpointRequest) (err error) {
+	var (
+		logp = `poll`
+		cl   *imapclient.Client
+	)
+
+	cl, err = mbox.dial()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	defer func() {
+		_ = cl.Logout()
+	}()
+
+	_, err = cl.Select(mbox.mailbox, false)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var criteria = imap.NewSearchCriteria()
+
+	criteria.WithoutFlags = []string{imap.SeenFlag}
+	if len(mbox.subject) != 0 {
+		criteria.Header.Add(`Subject`, mbox.subject)
+	}
+	if len(mbox.from) != 0 {
+		criteria.Header.Add(`From`, mbox.from)
+	}
+
+	var uids []uint32
+
+	uids, err = cl.UidSearch(criteria)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		uid uint32
+		epr *libhttp.EndpointRequest
+	)
+	for _, uid = range uids {
+		epr, err = mbox.fetch(cl, uid)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		if !pushJobMessage(ctx, msgq, epr) {
+			return nil
+		}
+		err = mbox.markSeen(cl, uid)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return nil
+}
+
+// fetch the message uid and convert it into request.
+func (mbox *jobMailbox) fetch(cl *imapclient.Client, uid uint32) (epr *libhttp.EndpointRequest, err error) {
+	var (
+		seqset  = new(imap.SeqSet)
+		section = &imap.BodySectionName{Peek: true}
+		msgq    = make(chan *imap.Message, 1)
+	)
+	seqset.AddNum(uid)
+
+	err = cl.UidFetch(seqset, []imap.FetchItem{section.FetchItem()}, msgq)
+	if err != nil {
+		return nil, err
+	}
+
+	var msg = <-msgq
+	if msg == nil {
+		return nil, fmt.Errorf(`message %d not found`, uid)
+	}
+
+	var body = msg.GetBody(section)
+	if body == nil {
+		return nil, fmt.Errorf(`message %d has no body`, uid)
+	}
+	return newJobMailRequest(body)
+}
+
+// markSeen set the flag seen on message uid.
+func (mbox *jobMailbox) markSeen(cl *imapclient.Client, uid uint32) (err error) {
+	var seqset = new(imap.SeqSet)
+
+	seqset.AddNum(uid)
+
+	return cl.UidStore(seqset, imap.FormatFlagsOp(imap.AddFlags, true),
+		[]any{imap.SeenFlag}, nil)
+}
+
+// newJobMailRequest create the request that trigger the job from the raw
+// mail message.
+// The request body is the first text part of the message, and the sender
+// and subject are passed as environment variables of commands.
+func newJobMailRequest(raw io.Reader) (epr *libhttp.EndpointRequest, err error) {
+	var mr *mail.Reader
+
+	mr, err = mail.CreateReader(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		subject, _ = mr.Header.Subject()
+		from, _    = mr.Header.AddressList(`From`)
+		listFrom   = make([]string, 0, len(from))
+		addr       *mail.Address
+	)
+	for _, addr = range from {
+		listFrom = append(listFrom, addr.Address)
+	}
+
+	var body []byte
+
+	body, err = readMailText(mr)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		envs = []string{
+			jobEnvMailFrom + `=` + strings.Join(listFrom, `,`),
+			jobEnvMailSubject + `=` + subject,
+		}
+		ctx = context.WithValue(context.Background(), ctxKeyJobEnvs{}, envs)
+		req *http.Request
+	)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, `mail:`,
+		bytes.NewReader(body))
+
+	epr = &libhttp.EndpointRequest{
+		HTTPRequest: req,
+		RequestBody: body,
+	}
+	return epr, nil
+}
+
+// readMailText return the content of the first "text/plain" part, or the
+// first inline part if there is no "text/plain".
+func readMailText(mr *mail.Reader) (text []byte, err error) {
+	var (
+		part     *mail.Part
+		inline   *mail.InlineHeader
+		ctype    string
+		content  []byte
+		isInline bool
+	)
+	for {
+		part, err = mr.NextPart()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return text, nil
+			}
+			return nil, err
+		}
+		inline, isInline = part.Header.(*mail.InlineHeader)
+		if !isInline {
+			continue
+		}
+		content, err = io.ReadAll(part.Body)
+		if err != nil {
+			return nil, err
+		}
+		ctype, _, _ = inline.ContentType()
+		if ctype == `text/plain` {
+			return content, nil
+		}
+		if text == nil {
+			text = content
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+	"github.com/emersion/go-imap/backend"
+	"github.com/emersion/go-imap/backend/memory"
+	"github.com/emersion/go-imap/server"
+)
+
+func TestParseJobIMAPURL(t *testing.T) {
+	type testCase struct {
+		exp    *jobMailbox
+		rawurl string
+		expErr string
+	}
+
+	var cases = []testCase{{
+		rawurl: `imaps://mail.example.org`,
+		exp: &jobMailbox{
+			addr:    `mail.example.org:993`,
+			mailbox: `INBOX`,
+			isTLS:   true,
+		},
+	}, {
+		rawurl: `imap://127.0.0.1:1143/Jobs/Deploy`,
+		exp: &jobMailbox{
+			addr:    `127.0.0.1:1143`,
+			mailbox: `Jobs/Deploy`,
+		},
+	}, {
+		rawurl: `pop3://mail.example.org`,
+		expErr: `invalid imap_url "pop3://mail.example.org": unknown scheme "pop3"`,
+	}, {
+		rawurl: `imaps:///INBOX`,
+		expErr: `invalid imap_url "imaps:///INBOX": empty host`,
+	}}
+
+	var (
+		c   testCase
+		got *jobMailbox
+		err error
+	)
+	for _, c = range cases {
+		got, err = parseJobIMAPURL(c.rawurl)
+		if err != nil {
+			test.Assert(t, `error`, c.expErr, err.Error())
+			continue
+		}
+		test.Assert(t, c.rawurl, c.exp, got)
+	}
+}
+
+func TestJobMailbox_poll(t *testing.T) {
+	var (
+		be  = memory.New()
+		srv = server.New(be)
+	)
+	srv.AllowInsecureAuth = true
+
+	var (
+		listener net.Listener
+		err      error
+	)
+
+	listener, err = net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	go func() {
+		_ = srv.Serve(listener)
+	}()
+	t.Cleanup(func() {
+		_ = srv.Close()
+	})
+
+	var (
+		user  backend.User
+		inbox backend.Mailbox
+	)
+
+	user, err = be.Login(nil, `username`, `password`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	inbox, err = user.GetMailbox(`INBOX`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mails = []string{
+		"From: Ops <ops@example.org>\r\n" +
+			"Subject: deploy web\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"\r\n" +
+			"v1.0.0",
+		"From: ops@example.org\r\n" +
+			"Subject: weekly report\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"\r\n" +
+			"ignored",
+		"From: ops@example.org\r\n" +
+			"Subject: Deploy API\r\n" +
+			"Content-Type: multipart/alternative; boundary=b\r\n" +
+			"\r\n" +
+			"--b\r\n" +
+			"Content-Type: text/html\r\n" +
+			"\r\n" +
+			"<p>v2.0.0</p>\r\n" +
+			"--b\r\n" +
+			"Content-Type: text/plain\r\n" +
+			"\r\n" +
+			"v2.0.0\r\n" +
+			"--b--\r\n",
+	}
+	var mail string
+	for _, mail = range mails {
+		err = inbox.CreateMessage(nil, time.Now(), bytes.NewBufferString(mail))
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		mbox = &jobMailbox{
+			addr:     listener.Addr().String(),
+			mailbox:  `INBOX`,
+			user:     `username`,
+			password: `password`,
+			subject:  `deploy`,
+			from:     `ops@example.org`,
+		}
+		msgq = make(chan *libhttp.EndpointRequest, len(mails))
+	)
+
+	err = mbox.poll(context.Background(), msgq)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type result struct {
+		body string
+		envs []string
+	}
+
+	var (
+		exp = []result{{
+			body: `v1.0.0`,
+			envs: []string{
+				jobEnvMailFrom + `=ops@example.org`,
+				jobEnvMailSubject + `=deploy web`,
+			},
+		}, {
+			body: `v2.0.0`,
+			envs: []string{
+				jobEnvMailFrom + `=ops@example.org`,
+				jobEnvMailSubject + `=Deploy API`,
+			},
+		}}
+		got []result
+		epr *libhttp.EndpointRequest
+	)
+	for len(msgq) > 0 {
+		epr = <-msgq
+		got = append(got, result{
+			body: string(bytes.TrimSpace(epr.RequestBody)),
+			envs: jobRequestEnvs(epr),
+		})
+	}
+	test.Assert(t, `requests`, exp, got)
+
+	// The matched mails has been marked as seen, so the next poll
+	// should not trigger the job.
+	err = mbox.poll(context.Background(), msgq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `requests after seen`, 0, len(msgq))
+}
@@ -0,0 +1,242 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"unicode"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+)
+
+// jobMatrixKeyPrefix the prefix of key for matrix variable in the job
+// section, "matrix.<var>".
+const jobMatrixKeyPrefix = `matrix.`
+
+// jobEnvMatrixPrefix the prefix of environment variable that contains the
+// value of matrix variable in each run.
+const jobEnvMatrixPrefix = `KARAJO_MATRIX_`
+
+// jobMatrixRun contains the name and environment variables of one
+// combination of matrix values.
+type jobMatrixRun struct {
+	// name of run in the format "<var>=<value>[,...]".
+	name string
+
+	// envs list of "KARAJO_MATRIX_<VAR>=<value>".
+	envs []string
+}
+
+// loadJobMatrix load the "matrix.<var>" keys of each JobExec in cfg into
+// its Matrix.
+// The value is list of values separated by comma.
+func loadJobMatrix(cfg *ini.Ini, jobs map[string]*JobExec) {
+	var (
+		sec    *ini.Section
+		job    *JobExec
+		matrix map[string][]string
+		vals   []string
+		key    string
+		val    string
+	)
+	for _, sec = range cfg.Subs(`job`) {
+		job = jobs[sec.SubName()]
+		if job == nil {
+			continue
+		}
+		matrix = nil
+		for key, vals = range cfg.AsMap(`job`, sec.SubName()) {
+			if !strings.HasPrefix(key, jobMatrixKeyPrefix) {
+				continue
+			}
+			if matrix == nil {
+				matrix = make(map[string][]string)
+			}
+			key = strings.TrimPrefix(key, jobMatrixKeyPrefix)
+			for _, val = range strings.Split(vals[len(vals)-1], `,`) {
+				val = strings.TrimSpace(val)
+				if len(val) != 0 {
+					matrix[key] = append(matrix[key], val)
+				}
+			}
+		}
+		if matrix != nil {
+			job.Matrix = matrix
+		}
+	}
+}
+
+// initMatrix validate the Matrix and generate the list of run for each
+// combination of values.
+func (job *JobExec) initMatrix() (err error) {
+	job.matrixRuns = nil
+	if len(job.Matrix) == 0 {
+		return nil
+	}
+	if len(job.Steps) != 0 {
+		return errors.New(`matrix cannot be used with step`)
+	}
+	if len(job.Target) != 0 {
+		return errors.New(`matrix cannot be used with target`)
+	}
+
+	var (
+		names = make([]string, 0, len(job.Matrix))
+		name  string
+		val   string
+	)
+	for name = range job.Matrix {
+		if len(name) == 0 || strings.ContainsFunc(name, isNotEnvName) {
+			return fmt.Errorf(`invalid matrix variable %q`, name)
+		}
+		if len(job.Matrix[name]) == 0 {
+			return fmt.Errorf(`matrix %q does not have value`, name)
+		}
+		for _, val = range job.Matrix[name] {
+			if strings.ContainsFunc(val, unicode.IsSpace) {
+				return fmt.Errorf(`matrix %q: value %q contains space`, name, val)
+			}
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var (
+		runs []jobMatrixRun
+		run  jobMatrixRun
+	)
+	job.matrixRuns = []jobMatrixRun{{}}
+	for _, name = range names {
+		runs = nil
+		for _, run = range job.matrixRuns {
+			for _, val = range job.Matrix[name] {
+				var next = jobMatrixRun{
+					name: run.name,
+					envs: append([]string{}, run.envs...),
+				}
+				if len(next.name) != 0 {
+					next.name += `,`
+				}
+				next.name += name + `=` + val
+				next.envs = append(next.envs, jobEnvMatrixName(name)+`=`+val)
+				runs = append(runs, next)
+			}
+		}
+		job.matrixRuns = runs
+	}
+	return nil
+}
+
+// isNotEnvName return true if c is not allowed in the name of matrix
+// variable or parameter, which is converted into environment variable.
+func isNotEnvName(c rune) bool {
+	switch {
+	case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z', c >= '0' && c <= '9':
+		return false
+	case c == '_', c == '-':
+		return false
+	}
+	return true
+}
+
+// jobEnvMatrixName return the name of environment variable for matrix
+// variable name, "KARAJO_MATRIX_<NAME>", with "-" replaced by "_".
+func jobEnvMatrixName(name string) string {
+	return jobEnvMatrixPrefix + strings.ToUpper(strings.ReplaceAll(name, `-`, `_`))
+}
+
+// runMatrix execute the Commands for each combination of Matrix values.
+//
+// Each run wait for the free slot in the run queue, so the number of run
+// at the same time is limited by the MaxJobRunning.
+// The parent run release its slot while waiting all of the runs finished.
+// The output of each run is written into the jlog once its finished,
+// and the status and duration of each run is recorded as step.
+//
+// It return the error of the first run that failed, in the order of
+// matrix.
+func (job *JobExec) runMatrix(ctx context.Context, jlog *JobLog, envs []string, stdin []byte) (err error) {
+	var (
+		runs  = job.matrixRuns
+		logs  = make([]*JobLog, len(runs))
+		errs  = make([]error, len(runs))
+		wg    sync.WaitGroup
+		logmu sync.Mutex
+		x     int
+	)
+
+	job.runq.release()
+	defer job.runq.acquire(job.priority)
+
+	for x = range runs {
+		logs[x] = &JobLog{
+			jobKind: jlog.jobKind,
+			JobID:   jlog.JobID,
+		}
+		wg.Add(1)
+		go func(x int) {
+			defer wg.Done()
+
+			job.runq.acquire(job.priority)
+			defer job.runq.release()
+
+			var (
+				run       = runs[x]
+				runEnvs   = append(append([]string{}, envs...), run.envs...)
+				timeBegin = timeNow()
+				cmdx      int
+				status    = JobStatusSuccess
+			)
+
+			logmu.Lock()
+			jlog.setStep(run.name, JobStatusRunning, 0)
+			logmu.Unlock()
+
+			cmdx, errs[x] = job.runCommands(ctx, logs[x], jobCommandLabelMain,
+				job.Commands, runEnvs, stdin)
+			if errs[x] != nil {
+				status = JobStatusFailed
+				if errors.Is(ctx.Err(), context.Canceled) {
+					status = JobStatusCanceled
+				}
+				logs[x].setExit(cmdx, errs[x])
+			}
+
+			logmu.Lock()
+			var errWrite = jlog.writeLog(logs[x])
+			if errWrite != nil && errs[x] == nil {
+				errs[x] = errWrite
+			}
+			jlog.setStep(run.name, status, timeNow().Sub(timeBegin))
+			logmu.Unlock()
+		}(x)
+	}
+	wg.Wait()
+
+	var runLog *JobLog
+	for x, runLog = range logs {
+		jlog.Lock()
+		if runLog.Usage != nil {
+			if jlog.Usage == nil {
+				jlog.Usage = &JobLogUsage{}
+			}
+			jlog.Usage.add(*runLog.Usage)
+		}
+		jlog.Reaped = append(jlog.Reaped, runLog.Reaped...)
+		if jlog.Exit == nil && runLog.Exit != nil {
+			jlog.Exit = runLog.Exit
+		}
+		jlog.Unlock()
+
+		if err == nil && errs[x] != nil {
+			err = fmt.Errorf(`%s: %w`, runs[x].name, errs[x])
+		}
+	}
+	return err
+}
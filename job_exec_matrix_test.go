@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"sort"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_initMatrix(t *testing.T) {
+	type testCase struct {
+		desc     string
+		config   string
+		expError string
+		exp      []string
+	}
+
+	var cases = []testCase{{
+		desc: `Multiple variables`,
+		config: `
+[job "backup"]
+matrix.host = a, b,
+matrix.db-name = x,y
+command = echo $KARAJO_MATRIX_HOST $KARAJO_MATRIX_DB_NAME
+`,
+		exp: []string{
+			`db-name=x,host=a`,
+			`db-name=x,host=b`,
+			`db-name=y,host=a`,
+			`db-name=y,host=b`,
+		},
+	}, {
+		desc: `Empty value`,
+		config: `
+[job "backup"]
+matrix.host = ,
+command = echo
+`,
+		expError: `init: init: matrix "host" does not have value`,
+	}, {
+		desc: `Value with space`,
+		config: `
+[job "backup"]
+matrix.host = a b
+command = echo
+`,
+		expError: `init: init: matrix "host": value "a b" contains space`,
+	}, {
+		desc: `With step`,
+		config: `
+[job "backup"]
+matrix.host = a
+step.build.command = make
+`,
+		expError: `init: init: matrix cannot be used with step`,
+	}}
+
+	var (
+		c   testCase
+		env *Env
+		err error
+	)
+	for _, c = range cases {
+		env, err = ParseEnv([]byte(c.config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.DirBase = t.TempDir()
+
+		err = env.init()
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, c.exp, env.ExecJobs[`backup`].dryRun().Matrix)
+	}
+}
+
+func TestJobExec_runMatrix(t *testing.T) {
+	var env = Env{
+		DirBase:       t.TempDir(),
+		Secret:        `s3cret`,
+		MaxJobRunning: 2,
+	}
+
+	var err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job = &JobExec{
+		JobBase: JobBase{
+			Name: `matrix`,
+		},
+		Matrix: map[string][]string{
+			`host`: {`a`, `b`, `c`},
+		},
+		Commands: []string{
+			`echo host $KARAJO_MATRIX_HOST`,
+			`test "$KARAJO_MATRIX_HOST" != b`,
+		},
+	}
+	err = job.init(&env, job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jlog *JobLog
+
+	jlog, err = job.execute(nil)
+	if err == nil {
+		t.Fatal(`expecting error`)
+	}
+	test.Assert(t, `error`, `host=b: exit status 1`, err.Error())
+
+	var (
+		content, _ = jlog.read()
+		output     string
+	)
+	for _, output = range []string{"host a\n", "host b\n", "host c\n"} {
+		test.Assert(t, output, true, strings.Contains(string(content), output))
+	}
+
+	var (
+		steps = jlog.Steps
+		got   []string
+		step  *JobLogStep
+	)
+	sort.Slice(steps, func(x, y int) bool {
+		return steps[x].Name < steps[y].Name
+	})
+	for _, step = range steps {
+		got = append(got, step.Name+` `+step.Status)
+	}
+	var exp = []string{
+		`host=a success`,
+		`host=b failed`,
+		`host=c success`,
+	}
+	test.Assert(t, `steps`, exp, got)
+	test.Assert(t, `exit`, `command 1 exit with code 1`, jlog.Exit.String())
+
+	var running, _, _ = env.jobRunQueue.stats()
+	test.Assert(t, `run queue released`, 0, running)
+}
@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// jobEnvParamPrefix the prefix of environment variable that contains the
+// value of parameter from the request that trigger the job.
+const jobEnvParamPrefix = `KARAJO_PARAM_`
+
+// jobRunRequest the parameters in the request body that trigger the
+// JobExec.
+type jobRunRequest struct {
+	Params json.RawMessage `json:"params"`
+}
+
+// initRunParams normalize and validate the RunParams.
+func (job *JobExec) initRunParams() (err error) {
+	var (
+		x    int
+		name string
+	)
+	for x, name = range job.RunParams {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if len(name) == 0 || strings.ContainsFunc(name, isNotEnvName) {
+			return fmt.Errorf(`invalid run_param %q`, job.RunParams[x])
+		}
+		job.RunParams[x] = name
+	}
+	return nil
+}
+
+// paramEnvs return the environment variables from the "params" object in
+// the request body, in the format "KARAJO_PARAM_<NAME>=<value>".
+// It will return nil if the body is not JSON object or does not have
+// "params".
+// It will return an error if the params is not object of string, or one
+// of its name is not listed in the RunParams.
+func (job *JobExec) paramEnvs(body []byte) (envs []string, err error) {
+	body = bytes.TrimSpace(body)
+	if len(body) == 0 || body[0] != '{' {
+		return nil, nil
+	}
+
+	var req jobRunRequest
+
+	err = json.Unmarshal(body, &req)
+	if err != nil || len(req.Params) == 0 || string(req.Params) == `null` {
+		// The body is not for running the job with parameters, for
+		// example the webhook payload.
+		return nil, nil
+	}
+
+	var params map[string]string
+
+	err = json.Unmarshal(req.Params, &params)
+	if err != nil {
+		return nil, errJobParam(`params must be object of string`)
+	}
+
+	var (
+		values = make(map[string]string, len(params))
+		names  = make([]string, 0, len(params))
+		name   string
+		val    string
+		ok     bool
+	)
+	for name, val = range params {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if !slices.Contains(job.RunParams, name) {
+			return nil, errJobParam(fmt.Sprintf(`param %q is not allowed`, name))
+		}
+		if strings.ContainsRune(val, 0) {
+			return nil, errJobParam(fmt.Sprintf(`param %q contains NUL`, name))
+		}
+		_, ok = values[name]
+		if !ok {
+			names = append(names, name)
+		}
+		values[name] = val
+	}
+	sort.Strings(names)
+
+	for _, name = range names {
+		envs = append(envs, jobEnvParamName(name)+`=`+values[name])
+	}
+	return envs, nil
+}
+
+// jobEnvParamName return the name of environment variable for parameter
+// name, "KARAJO_PARAM_<NAME>", with "-" replaced by "_".
+func jobEnvParamName(name string) string {
+	return jobEnvParamPrefix + strings.ToUpper(strings.ReplaceAll(name, `-`, `_`))
+}
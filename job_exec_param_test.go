@@ -0,0 +1,72 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_paramEnvs(t *testing.T) {
+	type testCase struct {
+		desc     string
+		body     string
+		expError string
+		exp      []string
+	}
+
+	var job = &JobExec{
+		RunParams: []string{`Version`, `dry-run`},
+	}
+
+	var err = job.initRunParams()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var cases = []testCase{{
+		desc: `Allowed params`,
+		body: `{"_karajo_epoch":1,"params":{"version":"v1.2.0","DRY-RUN":"1"}}`,
+		exp: []string{
+			`KARAJO_PARAM_DRY_RUN=1`,
+			`KARAJO_PARAM_VERSION=v1.2.0`,
+		},
+	}, {
+		desc: `Without params`,
+		body: `{"_karajo_epoch":1}`,
+	}, {
+		desc: `Not JSON`,
+		body: `_karajo_epoch=1`,
+	}, {
+		desc:     `Not allowed`,
+		body:     `{"params":{"host":"a"}}`,
+		expError: `param "host" is not allowed`,
+	}, {
+		desc:     `Not string`,
+		body:     `{"params":{"version":1}}`,
+		expError: `params must be object of string`,
+	}}
+
+	var (
+		c    testCase
+		envs []string
+	)
+	for _, c = range cases {
+		envs, err = job.paramEnvs([]byte(c.body))
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, c.exp, envs)
+	}
+}
+
+func TestJobExec_initRunParams(t *testing.T) {
+	var job = &JobExec{
+		RunParams: []string{`a b`},
+	}
+	var err = job.initRunParams()
+	test.Assert(t, `error`, `invalid run_param "a b"`, err.Error())
+}
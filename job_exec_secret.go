@@ -0,0 +1,201 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+)
+
+// defJobSecretGracePeriod the default duration where the previous secret
+// is still valid after the secret rotated.
+const defJobSecretGracePeriod = 24 * time.Hour
+
+// jobSecretLength the length of generated secret.
+const jobSecretLength = 32
+
+// JobExecSecret contains the status of JobExec secret, without the secret
+// itself.
+type JobExecSecret struct {
+	// RotatedAt the time when the secret last rotated.
+	// It is nil if the secret never rotated.
+	RotatedAt *time.Time `json:"rotated_at,omitempty"`
+
+	// PrevExpiredAt the time when the previous secret is no longer
+	// valid.
+	// It is nil if there is no previous secret or its already expired.
+	PrevExpiredAt *time.Time `json:"prev_expired_at,omitempty"`
+
+	// ID of job.
+	ID string `json:"id"`
+
+	// AuthKind of job.
+	AuthKind string `json:"auth_kind"`
+
+	// IsShared true if the job use the Env secret.
+	IsShared bool `json:"is_shared"`
+
+	// IsExternal true if the secret is fetched from provider.
+	IsExternal bool `json:"is_external"`
+}
+
+// JobExecSecretRotate contains the new secret of JobExec after rotated.
+type JobExecSecretRotate struct {
+	// PrevExpiredAt the time when the previous secret is no longer
+	// valid.
+	PrevExpiredAt time.Time `json:"prev_expired_at"`
+
+	// ID of job.
+	ID string `json:"id"`
+
+	// Secret the new secret.
+	// The secret is only returned once, on rotation.
+	Secret string `json:"secret"`
+}
+
+// jobSecretState the rotated secret of JobExec that stored in file
+// "$DirBase/var/run/karajo/job_secret/$JOB_ID".
+type jobSecretState struct {
+	RotatedAt     time.Time `json:"rotated_at"`
+	PrevExpiredAt time.Time `json:"prev_expired_at"`
+	Secret        string    `json:"secret"`
+	PrevSecret    string    `json:"prev_secret,omitempty"`
+}
+
+// initSecretState load the rotated secret from file, if its exist.
+// The rotated secret replace the Secret from configuration.
+func (job *JobExec) initSecretState(env *Env) (err error) {
+	var logp = `initSecretState`
+
+	job.secretFile = filepath.Join(env.dirRunJobSecret, job.ID)
+
+	var raw []byte
+
+	raw, err = os.ReadFile(job.secretFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var state jobSecretState
+
+	err = json.Unmarshal(raw, &state)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, job.secretFile, err)
+	}
+
+	job.setSecretState(&state)
+
+	return nil
+}
+
+// setSecretState replace the job secret with the one in state.
+func (job *JobExec) setSecretState(state *jobSecretState) {
+	job.secretMtx.Lock()
+	job.secret = newSecretValue(state.Secret, nil)
+	job.secretState = state
+	job.secretMtx.Unlock()
+}
+
+// rotateSecret generate new secret for job and keep the current secret
+// valid until the grace period passed.
+// The new secret is stored in file, so it still used after restart.
+func (job *JobExec) rotateSecret(grace time.Duration) (rot *JobExecSecretRotate, err error) {
+	var (
+		logp = `rotateSecret`
+		now  = timeNow()
+
+		prev []byte
+	)
+
+	prev, err = job.secretBytes()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var state = &jobSecretState{
+		RotatedAt:     now,
+		PrevExpiredAt: now.Add(grace),
+		Secret:        string(ascii.Random([]byte(ascii.LettersNumber), jobSecretLength)),
+		PrevSecret:    string(prev),
+	}
+
+	var raw []byte
+
+	raw, err = json.Marshal(state)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = os.WriteFile(job.secretFile, raw, 0600)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	job.setSecretState(state)
+
+	rot = &JobExecSecretRotate{
+		PrevExpiredAt: state.PrevExpiredAt,
+		ID:            job.ID,
+		Secret:        state.Secret,
+	}
+	return rot, nil
+}
+
+// secretList return the current secret and the previous secret, if its
+// not expired yet, to validate the signature of request.
+func (job *JobExec) secretList() (list [][]byte, err error) {
+	var secret []byte
+
+	secret, err = job.secretBytes()
+	if err != nil {
+		return nil, err
+	}
+	list = append(list, secret)
+
+	job.secretMtx.Lock()
+	var state = job.secretState
+	job.secretMtx.Unlock()
+
+	if state != nil && len(state.PrevSecret) != 0 && timeNow().Before(state.PrevExpiredAt) {
+		list = append(list, []byte(state.PrevSecret))
+	}
+	return list, nil
+}
+
+// secretStatus return the status of job secret.
+func (job *JobExec) secretStatus(env *Env) (status JobExecSecret) {
+	job.secretMtx.Lock()
+	defer job.secretMtx.Unlock()
+
+	status = JobExecSecret{
+		ID:         job.ID,
+		AuthKind:   job.AuthKind,
+		IsShared:   job.secret == env.secret,
+		IsExternal: job.secret.isExternal(),
+	}
+
+	var state = job.secretState
+	if state == nil {
+		return status
+	}
+
+	var rotatedAt = state.RotatedAt
+	status.RotatedAt = &rotatedAt
+
+	if len(state.PrevSecret) != 0 && timeNow().Before(state.PrevExpiredAt) {
+		var prevExpiredAt = state.PrevExpiredAt
+		status.PrevExpiredAt = &prevExpiredAt
+	}
+	return status
+}
@@ -0,0 +1,93 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_rotateSecret(t *testing.T) {
+	var (
+		env = &Env{
+			DirBase: t.TempDir(),
+			Secret:  `s3cret`,
+		}
+		newJob = func() *JobExec {
+			return &JobExec{
+				Path:     `/test`,
+				Commands: []string{`echo test`},
+			}
+		}
+		job = newJob()
+
+		err error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = job.init(env, `test`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var status = job.secretStatus(env)
+	test.Assert(t, `status before rotate`, JobExecSecret{
+		ID:       `test`,
+		AuthKind: JobAuthKindHmacSha256,
+		IsShared: true,
+	}, status)
+
+	var rot *JobExecSecretRotate
+
+	rot, err = job.rotateSecret(time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `secret length`, jobSecretLength, len(rot.Secret))
+
+	var (
+		payload = []byte(`{}`)
+		authz   = func(secret string) error {
+			var header = http.Header{}
+			header.Set(job.HeaderSign, Sign(payload, []byte(secret)))
+			return job.authorize(header, payload)
+		}
+	)
+
+	test.Assert(t, `new secret`, nil, authz(rot.Secret))
+	test.Assert(t, `previous secret`, nil, authz(env.Secret))
+
+	var (
+		now       = timeNow()
+		expPrevAt = now.Add(time.Hour)
+	)
+	status = job.secretStatus(env)
+	test.Assert(t, `status after rotate`, JobExecSecret{
+		RotatedAt:     &now,
+		PrevExpiredAt: &expPrevAt,
+		ID:            `test`,
+		AuthKind:      JobAuthKindHmacSha256,
+	}, status)
+
+	// Expire the previous secret.
+	job.secretState.PrevExpiredAt = now
+	err = authz(env.Secret)
+	test.Assert(t, `expired secret`,
+		`authorize: authHmacSha256: `+errJobForbidden.Message, err.Error())
+
+	// The rotated secret is loaded after restart.
+	job = newJob()
+	err = job.init(env, `test`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `secret after reload`, nil, authz(rot.Secret))
+}
@@ -0,0 +1,194 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+)
+
+// List of prefix and suffix of key for step commands in the job section,
+// "step.<name>.command".
+const (
+	jobStepKeyPrefix = `step.`
+	jobStepKeySuffix = `.command`
+)
+
+// jobCommandLabelStep the label printed before each command in the step.
+const jobCommandLabelStep = `Step`
+
+// JobExecStep define the named group of commands in JobExec.
+//
+// The step configuration in INI format,
+//
+//	[job "name"]
+//	step = <name>
+//	...
+//	step.<name>.command = <string>
+//	...
+type JobExecStep struct {
+	// Name of step.
+	Name string `json:"name"`
+
+	// Commands list of command to be executed in the step.
+	Commands []string `json:"commands"`
+}
+
+// loadJobSteps load the "step.<name>.command" keys of each JobExec in cfg
+// into its Steps, sorted by name.
+// The order of steps is set later when the job initialized, based on the
+// StepOrder.
+func loadJobSteps(cfg *ini.Ini, jobs map[string]*JobExec) {
+	var (
+		sec   *ini.Section
+		job   *JobExec
+		steps []*JobExecStep
+		vals  []string
+		key   string
+		name  string
+	)
+	for _, sec = range cfg.Subs(`job`) {
+		job = jobs[sec.SubName()]
+		if job == nil {
+			continue
+		}
+		steps = nil
+		for key, vals = range cfg.AsMap(`job`, sec.SubName()) {
+			if !strings.HasPrefix(key, jobStepKeyPrefix) ||
+				!strings.HasSuffix(key, jobStepKeySuffix) {
+				continue
+			}
+			name = strings.TrimSuffix(strings.TrimPrefix(key, jobStepKeyPrefix), jobStepKeySuffix)
+			if len(name) == 0 {
+				continue
+			}
+			steps = append(steps, &JobExecStep{
+				Name:     name,
+				Commands: vals,
+			})
+		}
+		if len(steps) == 0 {
+			continue
+		}
+		sort.Slice(steps, func(x, y int) bool {
+			return steps[x].Name < steps[y].Name
+		})
+		job.Steps = steps
+	}
+}
+
+// initSteps validate the Steps and order them based on the StepOrder.
+// If the StepOrder is empty, the steps are executed in ascending order
+// by their name.
+func (job *JobExec) initSteps() (err error) {
+	if len(job.Steps) == 0 {
+		if len(job.StepOrder) != 0 {
+			return fmt.Errorf(`step %q does not have command`, job.StepOrder[0])
+		}
+		return nil
+	}
+	if len(job.Commands) != 0 {
+		return errors.New(`command and step cannot be used together`)
+	}
+	if len(job.Target) != 0 {
+		return errors.New(`step cannot be used with target`)
+	}
+
+	var (
+		byName = make(map[string]*JobExecStep, len(job.Steps))
+		step   *JobExecStep
+	)
+	for _, step = range job.Steps {
+		step.Name = strings.ToLower(strings.TrimSpace(step.Name))
+		if len(step.Commands) == 0 {
+			return fmt.Errorf(`step %q does not have command`, step.Name)
+		}
+		byName[step.Name] = step
+	}
+	if len(job.StepOrder) == 0 {
+		return nil
+	}
+
+	var (
+		steps = make([]*JobExecStep, 0, len(job.Steps))
+		name  string
+	)
+	for _, name = range job.StepOrder {
+		name = strings.ToLower(strings.TrimSpace(name))
+		step = byName[name]
+		if step == nil {
+			return fmt.Errorf(`step %q does not have command`, name)
+		}
+		if slices.Contains(steps, step) {
+			return fmt.Errorf(`duplicate step %q`, name)
+		}
+		steps = append(steps, step)
+	}
+	if len(steps) != len(job.Steps) {
+		for _, step = range job.Steps {
+			if !slices.Contains(steps, step) {
+				return fmt.Errorf(`step %q is not defined in the step order`, step.Name)
+			}
+		}
+	}
+	job.Steps = steps
+	return nil
+}
+
+// mainCommands return the Commands, or the commands of all Steps in
+// order.
+func (job *JobExec) mainCommands() (cmds []string) {
+	if len(job.Steps) == 0 {
+		return job.Commands
+	}
+	var step *JobExecStep
+	for _, step = range job.Steps {
+		cmds = append(cmds, step.Commands...)
+	}
+	return cmds
+}
+
+// runSteps execute the commands of each step in order.
+// Once one of the step fail, the rest of steps are skipped.
+// The status and duration of each step is recorded in the jlog.
+func (job *JobExec) runSteps(ctx context.Context, jlog *JobLog, envs []string, stdin []byte) (err error) {
+	var (
+		step   *JobExecStep
+		status string
+		offset int
+		x      int
+	)
+	for _, step = range job.Steps {
+		if err != nil {
+			jlog.setStep(step.Name, JobStatusSkipped, 0)
+			continue
+		}
+
+		var timeBegin = timeNow()
+
+		jlog.setStep(step.Name, JobStatusRunning, 0)
+
+		x, err = job.runCommands(ctx, jlog, jobCommandLabelStep+` `+step.Name,
+			step.Commands, envs, stdin)
+
+		status = JobStatusSuccess
+		if err != nil {
+			status = JobStatusFailed
+			if errors.Is(ctx.Err(), context.Canceled) {
+				status = JobStatusCanceled
+			}
+			jlog.setExit(offset+x, err)
+		}
+		jlog.setStep(step.Name, status, timeNow().Sub(timeBegin))
+
+		offset += len(step.Commands)
+	}
+	return err
+}
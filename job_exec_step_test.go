@@ -0,0 +1,170 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_initSteps(t *testing.T) {
+	type testCase struct {
+		desc     string
+		config   string
+		expError string
+		exp      []*JobExecStep
+	}
+
+	var cases = []testCase{{
+		desc: `Sorted by name`,
+		config: `
+[job "deploy"]
+step.test.command = make test
+step.build.command = make
+step.build.command = make doc
+`,
+		exp: []*JobExecStep{{
+			Name:     `build`,
+			Commands: []string{`make`, `make doc`},
+		}, {
+			Name:     `test`,
+			Commands: []string{`make test`},
+		}},
+	}, {
+		desc: `With step order`,
+		config: `
+[job "deploy"]
+step = test
+step = Build
+step.build.command = make
+step.test.command = make test
+`,
+		exp: []*JobExecStep{{
+			Name:     `test`,
+			Commands: []string{`make test`},
+		}, {
+			Name:     `build`,
+			Commands: []string{`make`},
+		}},
+	}, {
+		desc: `Unknown step in order`,
+		config: `
+[job "deploy"]
+step = deploy
+step.build.command = make
+`,
+		expError: `init: init: step "deploy" does not have command`,
+	}, {
+		desc: `Missing step in order`,
+		config: `
+[job "deploy"]
+step = build
+step.build.command = make
+step.test.command = make test
+`,
+		expError: `init: init: step "test" is not defined in the step order`,
+	}, {
+		desc: `With command`,
+		config: `
+[job "deploy"]
+command = make
+step.build.command = make
+`,
+		expError: `init: init: command and step cannot be used together`,
+	}}
+
+	var (
+		c   testCase
+		env *Env
+		err error
+	)
+	for _, c = range cases {
+		env, err = ParseEnv([]byte(c.config))
+		if err != nil {
+			t.Fatal(err)
+		}
+		env.DirBase = t.TempDir()
+
+		err = env.init()
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, c.exp, env.ExecJobs[`deploy`].Steps)
+	}
+}
+
+func TestJobExec_runSteps(t *testing.T) {
+	var env = Env{
+		DirBase: t.TempDir(),
+		Secret:  `s3cret`,
+	}
+
+	var err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job = &JobExec{
+		JobBase: JobBase{
+			Name: `steps`,
+		},
+		Steps: []*JobExecStep{{
+			Name:     `build`,
+			Commands: []string{`echo build`},
+		}, {
+			Name:     `test`,
+			Commands: []string{`echo test`, `exit 3`},
+		}, {
+			Name:     `deploy`,
+			Commands: []string{`echo deploy`},
+		}},
+	}
+	err = job.init(&env, job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jlog *JobLog
+
+	jlog, err = job.execute(nil)
+	if err == nil {
+		t.Fatal(`expecting error`)
+	}
+
+	var (
+		content, _ = jlog.read()
+		prefix     = `2023-01-09 00:00:00 UTC job: ` + job.ID + `: `
+		got        = strings.ReplaceAll(string(content), prefix, ``)
+		exp        = "=== BEGIN\n" +
+			"--- STEP: build running\n" +
+			"\n--- Step build  0: echo build\nbuild\n" +
+			"--- STEP: build success 0s\n" +
+			"--- STEP: test running\n" +
+			"\n--- Step test  0: echo test\ntest\n" +
+			"\n--- Step test  1: exit 3\n" +
+			"--- EXIT: command 2 exit with code 3\n" +
+			"--- STEP: test failed 0s\n" +
+			"--- STEP: deploy skipped\n"
+		expSteps = []*JobLogStep{{
+			Name:   `build`,
+			Status: JobStatusSuccess,
+		}, {
+			Name:   `test`,
+			Status: JobStatusFailed,
+		}, {
+			Name:   `deploy`,
+			Status: JobStatusSkipped,
+		}}
+	)
+	test.Assert(t, `content`, exp, got)
+	test.Assert(t, `Steps`, expSteps, jlog.Steps)
+
+	// The steps can be loaded back from the log content.
+	var loaded = &JobLog{content: content}
+	loaded.loadSteps()
+	test.Assert(t, `loadSteps`, expSteps, loaded.Steps)
+}
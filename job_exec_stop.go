@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// defJobExecStopSignal the default signal send to the command when
+	// the job canceled or karajo stopped.
+	defJobExecStopSignal = syscall.SIGTERM
+
+	// defJobExecStopGracePeriod the default time to wait for the
+	// command to exit after receiving the stop signal, before it
+	// killed.
+	defJobExecStopGracePeriod = 10 * time.Second
+
+	// jobExecStopPollInterval the interval to check whether the
+	// command has been exited after receiving the stop signal.
+	jobExecStopPollInterval = 100 * time.Millisecond
+)
+
+// listStopSignal contains the signals that can be set in
+// [JobExec.StopSignal].
+var listStopSignal = map[string]syscall.Signal{
+	`SIGHUP`:  syscall.SIGHUP,
+	`SIGINT`:  syscall.SIGINT,
+	`SIGQUIT`: syscall.SIGQUIT,
+	`SIGKILL`: syscall.SIGKILL,
+	`SIGUSR1`: syscall.SIGUSR1,
+	`SIGUSR2`: syscall.SIGUSR2,
+	`SIGTERM`: syscall.SIGTERM,
+}
+
+// parseStopSignal convert the signal name, with or without prefix "SIG",
+// into its value.
+// Empty name is equal to SIGTERM.
+func parseStopSignal(name string) (sig syscall.Signal, err error) {
+	name = strings.ToUpper(strings.TrimSpace(name))
+	if len(name) == 0 {
+		return defJobExecStopSignal, nil
+	}
+	if !strings.HasPrefix(name, `SIG`) {
+		name = `SIG` + name
+	}
+	var ok bool
+	sig, ok = listStopSignal[name]
+	if !ok {
+		return 0, fmt.Errorf(`invalid stop signal %q`, name)
+	}
+	return sig, nil
+}
+
+// stopProcessGroup send the signal sig to all processes in the group
+// pgid and wait until all of them exited.
+// If they still running after the grace period, the whole group is
+// killed.
+func stopProcessGroup(pgid int, sig syscall.Signal, grace time.Duration) (err error) {
+	err = syscall.Kill(-pgid, sig)
+	if err != nil {
+		if errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+		return err
+	}
+	if sig == syscall.SIGKILL {
+		return nil
+	}
+
+	var deadline = time.Now().Add(grace)
+	for time.Now().Before(deadline) {
+		time.Sleep(jobExecStopPollInterval)
+
+		// Sending signal 0 only check whether the group still
+		// exist.
+		err = syscall.Kill(-pgid, 0)
+		if errors.Is(err, syscall.ESRCH) {
+			return nil
+		}
+	}
+
+	err = syscall.Kill(-pgid, syscall.SIGKILL)
+	if err != nil && !errors.Is(err, syscall.ESRCH) {
+		return err
+	}
+	return nil
+}
+
+// procDir the directory where the information of processes is read.
+const procDir = `/proc`
+
+// listSessionProcess return the ID of processes in the session sid that
+// still running, read from the directory of procfs dir.
+func listSessionProcess(dir string, sid int) (pids []int) {
+	var listde, err = os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var (
+		de        os.DirEntry
+		stat      []byte
+		fields    []string
+		pid       int
+		x         int
+		sessionID int
+	)
+	for _, de = range listde {
+		pid, err = strconv.Atoi(de.Name())
+		if err != nil {
+			continue
+		}
+		stat, err = os.ReadFile(filepath.Join(dir, de.Name(), `stat`))
+		if err != nil {
+			// The process may already exited.
+			continue
+		}
+
+		// The format of stat is "pid (comm) state ppid pgrp
+		// session ...", where comm may contains space or ')'.
+		x = bytes.LastIndexByte(stat, ')')
+		if x < 0 {
+			continue
+		}
+		fields = strings.Fields(string(stat[x+1:]))
+		if len(fields) < 4 {
+			continue
+		}
+		if fields[0] == `Z` {
+			// Skip zombie, its already exited.
+			continue
+		}
+		sessionID, err = strconv.Atoi(fields[3])
+		if err != nil || sessionID != sid {
+			continue
+		}
+		pids = append(pids, pid)
+	}
+	slices.Sort(pids)
+	return pids
+}
+
+// reapSession kill all of processes in session sid that still running
+// after its leader exited, and return their ID.
+// The process may leave the process group of its leader, but not its
+// session, unless it create new session.
+func reapSession(sid int) (pids []int) {
+	pids = listSessionProcess(procDir, sid)
+
+	var pid int
+	for _, pid = range pids {
+		_ = syscall.Kill(pid, syscall.SIGKILL)
+	}
+	return pids
+}
@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestParseStopSignal(t *testing.T) {
+	type testCase struct {
+		name   string
+		expErr string
+		exp    syscall.Signal
+	}
+
+	var cases = []testCase{{
+		name: ``,
+		exp:  syscall.SIGTERM,
+	}, {
+		name: `sigint`,
+		exp:  syscall.SIGINT,
+	}, {
+		name: ` QUIT `,
+		exp:  syscall.SIGQUIT,
+	}, {
+		name: `SIGKILL`,
+		exp:  syscall.SIGKILL,
+	}, {
+		name:   `SIGSTOP`,
+		expErr: `invalid stop signal "SIGSTOP"`,
+	}}
+
+	var (
+		c   testCase
+		got syscall.Signal
+		err error
+	)
+	for _, c = range cases {
+		got, err = parseStopSignal(c.name)
+		if err != nil {
+			test.Assert(t, c.name, c.expErr, err.Error())
+			continue
+		}
+		test.Assert(t, c.name, c.exp, got)
+	}
+}
+
+func TestStopProcessGroup(t *testing.T) {
+	type testCase struct {
+		desc   string
+		cmd    string
+		expErr string
+		sig    syscall.Signal
+	}
+
+	var cases = []testCase{{
+		desc:   `exit on signal`,
+		cmd:    `sleep 30`,
+		sig:    syscall.SIGTERM,
+		expErr: `signal: terminated`,
+	}, {
+		desc:   `killed after grace period`,
+		cmd:    `trap '' TERM; sleep 30`,
+		sig:    syscall.SIGTERM,
+		expErr: `signal: killed`,
+	}, {
+		desc:   `custom signal`,
+		cmd:    `sleep 30`,
+		sig:    syscall.SIGINT,
+		expErr: `signal: interrupt`,
+	}}
+
+	var (
+		c       testCase
+		execCmd *exec.Cmd
+		err     error
+	)
+	for _, c = range cases {
+		execCmd = exec.Command(`/bin/sh`, `-c`, c.cmd)
+		execCmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+		err = execCmd.Start()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// Give the shell time to set the trap.
+		time.Sleep(100 * time.Millisecond)
+
+		err = stopProcessGroup(execCmd.Process.Pid, c.sig, 300*time.Millisecond)
+		if err != nil {
+			t.Fatal(c.desc, err)
+		}
+
+		err = execCmd.Wait()
+		test.Assert(t, c.desc, c.expErr, err.Error())
+	}
+}
+
+func TestListSessionProcess(t *testing.T) {
+	var (
+		dir   = t.TempDir()
+		stats = map[string]string{
+			// Process in the session.
+			`100`: `100 (sh) S 1 100 100 0 -1`,
+			// Process that change its group, but not its
+			// session, with space and ')' in its name.
+			`101`: `101 (my (cmd)) S 100 101 100 0 -1`,
+			// Zombie process.
+			`102`: `102 (sleep) Z 100 100 100 0 -1`,
+			// Process in other session.
+			`200`: `200 (bash) S 1 200 200 0 -1`,
+			// Not a process.
+			`self`: `100 (sh) S 1 100 100 0 -1`,
+		}
+
+		name string
+		stat string
+		err  error
+	)
+	for name, stat = range stats {
+		err = os.MkdirAll(filepath.Join(dir, name), 0700)
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = os.WriteFile(filepath.Join(dir, name, `stat`), []byte(stat), 0600)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	test.Assert(t, `session 100`, []int{100, 101}, listSessionProcess(dir, 100))
+	test.Assert(t, `session 300`, []int(nil), listSessionProcess(dir, 300))
+}
+
+func TestJobExec_executeReaped(t *testing.T) {
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+			Secret:  `s3cret`,
+		}
+		job = JobExec{
+			JobBase: JobBase{
+				Name: `reaped`,
+			},
+			Commands: []string{
+				`sleep 30 & echo $! >orphan.pid`,
+			},
+		}
+
+		jlog *JobLog
+		err  error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = job.init(&env, job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var timeStart = time.Now()
+
+	jlog, err = job.execute(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The job should not wait for the output of orphan process.
+	test.Assert(t, `elapsed < WaitDelay`, true,
+		time.Since(timeStart) < defJobExecWaitDelay)
+
+	var rawpid []byte
+
+	rawpid, err = os.ReadFile(filepath.Join(job.dirWork, `orphan.pid`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var pid int
+
+	pid, err = strconv.Atoi(strings.TrimSpace(string(rawpid)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `Reaped`, []int{pid}, jlog.Reaped)
+
+	var raw, _ = jlog.read()
+	test.Assert(t, `content`, true,
+		strings.Contains(string(raw), jobLogReapedPrefix+strconv.Itoa(pid)+"\n"))
+
+	jlog.Reaped = nil
+	jlog.loadReaped()
+	test.Assert(t, `loadReaped`, []int{pid}, jlog.Reaped)
+}
@@ -0,0 +1,157 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// List of [JobExec.SubscribeKind].
+const (
+	JobSubscribeKindAMQP = `amqp`
+	JobSubscribeKindMQTT = `mqtt`
+	JobSubscribeKindNATS = `nats`
+)
+
+const (
+	// defJobSubscribeRetryDelay the delay before reconnecting to the
+	// message broker after the connection lost.
+	defJobSubscribeRetryDelay = 5 * time.Second
+
+	// defJobSubscribeTimeout the timeout for connecting to the message
+	// broker.
+	defJobSubscribeTimeout = 10 * time.Second
+)
+
+// jobEnvMessageTopic the environment variable that contains the topic of
+// message that trigger the job.
+const jobEnvMessageTopic = `KARAJO_JOB_MESSAGE_TOPIC`
+
+// jobSubscriber define the client that consume the messages from the
+// message broker.
+type jobSubscriber interface {
+	// consume connect to the broker and pass each message as request
+	// to msgq, until the ctx canceled or the connection lost.
+	consume(ctx context.Context, msgq chan<- *libhttp.EndpointRequest) error
+}
+
+// newJobSubscriber create the subscriber based on the job SubscribeKind.
+func newJobSubscriber(job *JobExec) (sub jobSubscriber, err error) {
+	switch job.SubscribeKind {
+	case JobSubscribeKindAMQP:
+		sub, err = newJobSubscriberAMQP(job)
+	case JobSubscribeKindMQTT:
+		sub = newJobSubscriberMQTT(job)
+	case JobSubscribeKindNATS:
+		sub = newJobSubscriberNATS(job)
+	default:
+		err = fmt.Errorf(`invalid subscribe_kind %q`, job.SubscribeKind)
+	}
+	return sub, err
+}
+
+// initSubscribe validate and normalize the subscribe options.
+func (job *JobExec) initSubscribe() (err error) {
+	job.SubscribeKind = strings.ToLower(strings.TrimSpace(job.SubscribeKind))
+	if len(job.SubscribeKind) == 0 {
+		return nil
+	}
+	switch job.SubscribeKind {
+	case JobSubscribeKindAMQP, JobSubscribeKindMQTT, JobSubscribeKindNATS:
+	default:
+		return fmt.Errorf(`invalid subscribe_kind %q`, job.SubscribeKind)
+	}
+
+	job.SubscribeURL = strings.TrimSpace(job.SubscribeURL)
+	if len(job.SubscribeURL) == 0 {
+		return errors.New(`empty subscribe_url`)
+	}
+	job.SubscribeTopic = strings.TrimSpace(job.SubscribeTopic)
+	if len(job.SubscribeTopic) == 0 {
+		return errors.New(`empty subscribe_topic`)
+	}
+	job.subscribeUser = expandEnv(job.SubscribeUser)
+	job.subscribePassword = expandEnv(job.SubscribePassword)
+	return nil
+}
+
+// startSubscriber consume the messages from broker until the job stopped.
+// If the connection lost, it reconnect after defJobSubscribeRetryDelay.
+func (job *JobExec) startSubscriber() {
+	var sub, err = newJobSubscriber(job)
+	if err != nil {
+		mlog.Errf(`job: %s: subscribe: %s`, job.ID, err)
+		return
+	}
+
+	var ctx, cancel = context.WithCancel(context.Background())
+
+	job.Lock()
+	job.subscribeCancel = cancel
+	job.Unlock()
+
+	go func() {
+		for ctx.Err() == nil {
+			err = sub.consume(ctx, job.httpq)
+			if ctx.Err() != nil {
+				return
+			}
+			if err != nil {
+				mlog.Errf(`job: %s: subscribe: %s`, job.ID, err)
+			}
+			select {
+			case <-ctx.Done():
+			case <-time.After(defJobSubscribeRetryDelay):
+			}
+		}
+	}()
+}
+
+// subscribeClientID return the identifier of karajo instance for the job,
+// used as client ID or consumer tag.
+func subscribeClientID(jobID string) string {
+	var hostname, _ = os.Hostname()
+	return fmt.Sprintf(`karajo-%s-%s`, hostname, jobID)
+}
+
+// newJobMessageRequest create the request that trigger the job with the
+// message payload as its body.
+// The ack function, if not nil, is called once the job finished.
+func newJobMessageRequest(topic string, payload []byte, ack func()) (epr *libhttp.EndpointRequest) {
+	var ctx = context.WithValue(context.Background(), ctxKeyJobEnvs{},
+		[]string{jobEnvMessageTopic + `=` + topic})
+	if ack != nil {
+		ctx = context.WithValue(ctx, ctxKeyJobQueueAck{}, ack)
+	}
+
+	var req, _ = http.NewRequestWithContext(ctx, http.MethodPost, `message:`,
+		bytes.NewReader(payload))
+
+	epr = &libhttp.EndpointRequest{
+		HTTPRequest: req,
+		RequestBody: payload,
+	}
+	return epr
+}
+
+// pushJobMessage pass the request to msgq, wait until the job take it or
+// the ctx canceled.
+func pushJobMessage(ctx context.Context, msgq chan<- *libhttp.EndpointRequest, epr *libhttp.EndpointRequest) bool {
+	select {
+	case msgq <- epr:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
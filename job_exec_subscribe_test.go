@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobExec_initSubscribe(t *testing.T) {
+	type testCase struct {
+		desc   string
+		expErr string
+		job    *JobExec
+	}
+
+	t.Setenv(`MQ_PASSWORD`, `s3cret`)
+
+	var cases = []testCase{{
+		desc: `without subscribe`,
+		job:  &JobExec{},
+	}, {
+		desc: `invalid kind`,
+		job: &JobExec{
+			SubscribeKind: `kafka`,
+		},
+		expErr: `invalid subscribe_kind "kafka"`,
+	}, {
+		desc: `empty URL`,
+		job: &JobExec{
+			SubscribeKind: `NATS`,
+		},
+		expErr: `empty subscribe_url`,
+	}, {
+		desc: `empty topic`,
+		job: &JobExec{
+			SubscribeKind: `nats`,
+			SubscribeURL:  `nats://127.0.0.1:4222`,
+		},
+		expErr: `empty subscribe_topic`,
+	}, {
+		desc: `valid`,
+		job: &JobExec{
+			SubscribeKind:     ` MQTT `,
+			SubscribeURL:      `mqtt://127.0.0.1:1883`,
+			SubscribeTopic:    `orders/#`,
+			SubscribeUser:     `karajo`,
+			SubscribePassword: `${MQ_PASSWORD}`,
+		},
+	}}
+
+	var (
+		c   testCase
+		err error
+	)
+	for _, c = range cases {
+		err = c.job.initSubscribe()
+		if err != nil {
+			test.Assert(t, c.desc, c.expErr, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc+`: error`, c.expErr, ``)
+	}
+
+	c = cases[len(cases)-1]
+	test.Assert(t, `kind`, JobSubscribeKindMQTT, c.job.SubscribeKind)
+	test.Assert(t, `password`, `s3cret`, c.job.subscribePassword)
+}
+
+func TestJobExec_executeMessage(t *testing.T) {
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+			Secret:  `s3cret`,
+		}
+		job = JobExec{
+			JobBase: JobBase{
+				Name: `message`,
+			},
+			Commands: []string{
+				`echo "$(cat) on $KARAJO_JOB_MESSAGE_TOPIC"`,
+			},
+		}
+		acked bool
+
+		jlog *JobLog
+		err  error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = job.init(&env, job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var epr = newJobMessageRequest(`orders.new`, []byte(`order 1`), func() {
+		acked = true
+	})
+
+	jlog, err = job.execute(epr)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var raw, _ = jlog.read()
+	test.Assert(t, `content`, true,
+		strings.Contains(string(raw), "order 1 on orders.new\n"))
+
+	jobQueueAck(epr)
+	test.Assert(t, `acked`, true, acked)
+}
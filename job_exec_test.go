@@ -12,7 +12,10 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
@@ -233,10 +236,7 @@ func TestJobExec_handleHTTP(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	var jobq = make(chan struct{}, env.MaxJobRunning)
-
-	go job.Start(jobq, logq)
-	<-jobq
+	job.Start(logq)
 	t.Cleanup(job.Stop)
 
 	var (
@@ -281,13 +281,10 @@ func TestJobExec_handleHTTP(t *testing.T) {
 
 	<-logq
 
-	job.Lock()
 	got, err = json.MarshalIndent(&job, ``, `  `)
 	if err != nil {
-		job.Unlock()
 		t.Fatal(err)
 	}
-	job.Unlock()
 
 	exp = tdata.Output[`job_after.json`]
 	test.Assert(t, `job_after`, string(exp), string(got))
@@ -333,19 +330,360 @@ func TestJobExecCall(t *testing.T) {
 		t.Fatal(err)
 	}
 
-	job.jobq = make(chan struct{}, env.MaxJobRunning)
 	job.logq = make(chan *JobLog)
 
 	job.run(nil)
 
-	job.Lock()
 	got, err = json.MarshalIndent(&job, ``, `  `)
 	if err != nil {
-		job.Unlock()
 		t.Fatal(err)
 	}
-	job.Unlock()
 
 	exp = tdata.Output[`job_after.json`]
 	test.Assert(t, `TestJobExecCall`, string(exp), string(got))
 }
+
+func TestParseCommand(t *testing.T) {
+	type testCase struct {
+		cmd               string
+		expCmd            string
+		isContinueOnError bool
+		expIgnoreError    bool
+	}
+
+	var cases = []testCase{{
+		cmd:    `rm /tmp/lock`,
+		expCmd: `rm /tmp/lock`,
+	}, {
+		cmd:            `- rm /tmp/lock`,
+		expCmd:         `rm /tmp/lock`,
+		expIgnoreError: true,
+	}, {
+		cmd:            ` -rm /tmp/lock`,
+		expCmd:         `rm /tmp/lock`,
+		expIgnoreError: true,
+	}, {
+		cmd:               `rm /tmp/lock`,
+		isContinueOnError: true,
+		expCmd:            `rm /tmp/lock`,
+		expIgnoreError:    true,
+	}}
+
+	var (
+		c             testCase
+		gotCmd        string
+		isIgnoreError bool
+	)
+	for _, c = range cases {
+		gotCmd, isIgnoreError = parseCommand(c.cmd, c.isContinueOnError)
+		test.Assert(t, c.cmd, c.expCmd, gotCmd)
+		test.Assert(t, c.cmd+`: ignore error`, c.expIgnoreError, isIgnoreError)
+	}
+}
+
+func TestJobExec_executeIgnoreError(t *testing.T) {
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+			Secret:  `s3cret`,
+		}
+		job = JobExec{
+			JobBase: JobBase{
+				Name: `ignore error`,
+			},
+			Commands: []string{
+				`- exit 3`,
+				`echo after`,
+				`exit 4`,
+				`echo unreachable`,
+			},
+		}
+
+		jlog *JobLog
+		err  error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = job.init(&env, job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	jlog, err = job.execute(nil)
+	if err == nil {
+		t.Fatal(`expecting error`)
+	}
+
+	var raw, _ = jlog.read()
+	var content = string(raw)
+	test.Assert(t, `ignored`, true,
+		strings.Contains(content, "--- Execute  0: error ignored: exit status 3\n"))
+	test.Assert(t, `after`, true, strings.Contains(content, "after\n"))
+	test.Assert(t, `unreachable`, false, strings.Contains(content, "unreachable\n"))
+	test.Assert(t, `exit`, `command 2 exit with code 4`, jlog.Exit.String())
+}
+
+func TestJobExec_executeHooks(t *testing.T) {
+	type testCase struct {
+		desc     string
+		pre      []string
+		commands []string
+		exp      string
+	}
+
+	var cases = []testCase{{
+		desc:     `success`,
+		pre:      []string{`echo pre`},
+		commands: []string{`echo main`},
+		exp: "=== BEGIN\n" +
+			"\n--- Pre-execute  0: echo pre\npre\n" +
+			"\n--- Execute  0: echo main\nmain\n" +
+			"\n--- Post-execute  0: echo post $KARAJO_JOB_STATUS\npost success\n" +
+			"=== DONE\n",
+	}, {
+		desc:     `pre failed`,
+		pre:      []string{`exit 1`},
+		commands: []string{`echo main`},
+		exp: "=== BEGIN\n" +
+			"\n--- Pre-execute  0: exit 1\n" +
+			"\n--- Post-execute  0: echo post $KARAJO_JOB_STATUS\npost failed\n",
+	}, {
+		desc:     `main failed`,
+		commands: []string{`exit 2`},
+		exp: "=== BEGIN\n" +
+			"\n--- Execute  0: exit 2\n" +
+			"--- EXIT: command 0 exit with code 2\n" +
+			"\n--- Post-execute  0: echo post $KARAJO_JOB_STATUS\npost failed\n",
+	}}
+
+	var env = Env{
+		DirBase: t.TempDir(),
+		Secret:  `s3cret`,
+	}
+
+	var err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		c    testCase
+		job  *JobExec
+		jlog *JobLog
+	)
+	for _, c = range cases {
+		job = &JobExec{
+			JobBase: JobBase{
+				Name: c.desc,
+			},
+			PreCommands:  c.pre,
+			Commands:     c.commands,
+			PostCommands: []string{`echo post $KARAJO_JOB_STATUS`},
+		}
+		err = job.init(&env, job.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		jlog, _ = job.execute(nil)
+
+		var (
+			content, _ = jlog.read()
+			prefix     = `2023-01-09 00:00:00 UTC job: ` + job.ID + `: `
+			got        = strings.ReplaceAll(string(content), prefix, ``)
+		)
+		test.Assert(t, c.desc, c.exp, got)
+	}
+}
+
+// TestJobExec_Start_maxJobRunning test that starting the jobs does not
+// take the slot of Env.MaxJobRunning, and the number of job executed at the
+// same time does not exceed it.
+func TestJobExec_Start_maxJobRunning(t *testing.T) {
+	var (
+		env = Env{
+			DirBase:       t.TempDir(),
+			Secret:        `s3cret`,
+			MaxJobRunning: 2,
+			ExecJobs:      map[string]*JobExec{},
+		}
+		logq     = make(chan *JobLog, 4)
+		releaseq = make(chan struct{})
+		doneq    = make(chan struct{}, 4)
+
+		mtx        sync.Mutex
+		running    int
+		maxRunning int
+		x          int
+	)
+
+	var call = func(_ context.Context, _ io.Writer, _ *libhttp.EndpointRequest) error {
+		mtx.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		mtx.Unlock()
+
+		<-releaseq
+
+		mtx.Lock()
+		running--
+		mtx.Unlock()
+
+		doneq <- struct{}{}
+		return nil
+	}
+
+	for x = 1; x <= 4; x++ {
+		var name = fmt.Sprintf(`job %d`, x)
+		env.ExecJobs[name] = &JobExec{
+			Call: call,
+		}
+	}
+
+	var err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var job *JobExec
+	for _, job = range env.ExecJobs {
+		job.Start(logq)
+		t.Cleanup(job.Stop)
+	}
+
+	var gotRunning, gotWaiting int
+
+	gotRunning, gotWaiting, _ = env.jobRunQueue.stats()
+	test.Assert(t, `running after start`, 0, gotRunning)
+	test.Assert(t, `waiting after start`, 0, gotWaiting)
+
+	for _, job = range env.ExecJobs {
+		job.httpq <- &libhttp.EndpointRequest{}
+	}
+
+	// Wait until all jobs acquire the slot or queued.
+	for gotRunning+gotWaiting != 4 {
+		time.Sleep(time.Millisecond)
+		gotRunning, gotWaiting, _ = env.jobRunQueue.stats()
+	}
+	test.Assert(t, `running`, 2, gotRunning)
+	test.Assert(t, `waiting`, 2, gotWaiting)
+
+	close(releaseq)
+	for x = 1; x <= 4; x++ {
+		<-doneq
+	}
+
+	test.Assert(t, `max running`, 2, maxRunning)
+}
+
+func TestJobExec_requestNonce(t *testing.T) {
+	type testCase struct {
+		authKind string
+		key      string
+		value    string
+		exp      string
+	}
+
+	var cases = []testCase{{
+		authKind: JobAuthKindGithub,
+		key:      githubHeaderDelivery,
+		value:    `72d3162e`,
+		exp:      `test:72d3162e`,
+	}, {
+		authKind: JobAuthKindSourcehut,
+		key:      sourcehutHeaderNonce,
+		value:    `1234`,
+		exp:      `test:1234`,
+	}, {
+		authKind: JobAuthKindHmacSha256,
+		key:      HeaderNameXKarajoSign,
+		value:    `abcd`,
+		exp:      `test:abcd`,
+	}, {
+		authKind: JobAuthKindGithub,
+		key:      githubHeaderSign256,
+		value:    `sha256=abcd`,
+	}}
+
+	var (
+		job = JobExec{
+			HeaderSign: HeaderNameXKarajoSign,
+		}
+
+		c      testCase
+		header http.Header
+	)
+	job.ID = `test`
+
+	for _, c = range cases {
+		header = http.Header{}
+		header.Set(c.key, c.value)
+		job.AuthKind = c.authKind
+		test.Assert(t, c.authKind+`: `+c.key, c.exp, job.requestNonce(header))
+	}
+}
+
+func TestJobExec_executeTimeout(t *testing.T) {
+	type testCase struct {
+		call     JobExecHTTPHandler
+		desc     string
+		commands []string
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc: `Call`,
+		call: func(ctx context.Context, _ io.Writer, _ *libhttp.EndpointRequest) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+		expError: `timeout after 100ms: context deadline exceeded`,
+	}, {
+		desc:     `Commands`,
+		commands: []string{`sleep 10`},
+		expError: `timeout after 100ms: signal: terminated`,
+	}}
+
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+			Secret:  `s3cret`,
+		}
+
+		c   testCase
+		err error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c = range cases {
+		var job = JobExec{
+			JobBase: JobBase{
+				Name: `timeout ` + c.desc,
+			},
+			Call:     c.call,
+			Commands: c.commands,
+			Timeout:  100 * time.Millisecond,
+		}
+
+		err = job.init(&env, job.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		_, err = job.execute(nil)
+		if err == nil {
+			t.Fatalf(`%s: expecting error`, c.desc)
+		}
+		test.Assert(t, c.desc, c.expError, err.Error())
+	}
+}
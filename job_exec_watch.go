@@ -0,0 +1,228 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+	"github.com/fsnotify/fsnotify"
+)
+
+const (
+	// defJobExecWatchDelay the default time to wait after the last
+	// file changes before running the job.
+	defJobExecWatchDelay = time.Second
+
+	// jobEnvWatchFiles the environment variable that contains the list
+	// of changed files, separated by new line, that trigger the job.
+	jobEnvWatchFiles = `KARAJO_JOB_WATCH_FILES`
+)
+
+// List of [JobExec.WatchEvents].
+const (
+	JobWatchEventChmod  = `chmod`
+	JobWatchEventCreate = `create`
+	JobWatchEventRemove = `remove`
+	JobWatchEventRename = `rename`
+	JobWatchEventWrite  = `write`
+)
+
+// listJobWatchEvent map the event name to its fsnotify operation.
+var listJobWatchEvent = map[string]fsnotify.Op{
+	JobWatchEventChmod:  fsnotify.Chmod,
+	JobWatchEventCreate: fsnotify.Create,
+	JobWatchEventRemove: fsnotify.Remove,
+	JobWatchEventRename: fsnotify.Rename,
+	JobWatchEventWrite:  fsnotify.Write,
+}
+
+// parseJobWatchEvents convert the list of event names into fsnotify
+// operations.
+// Empty list is equal to "create" and "write".
+func parseJobWatchEvents(names []string) (ops fsnotify.Op, err error) {
+	if len(names) == 0 {
+		return fsnotify.Create | fsnotify.Write, nil
+	}
+
+	var (
+		name string
+		op   fsnotify.Op
+		ok   bool
+	)
+	for _, name = range names {
+		op, ok = listJobWatchEvent[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return 0, fmt.Errorf(`invalid watch event %q`, name)
+		}
+		ops |= op
+	}
+	return ops, nil
+}
+
+// parseJobWatch validate the list of glob patterns and return the list of
+// directories to be watched.
+// Only the last element of pattern can contains the glob meta characters.
+func parseJobWatch(patterns []string) (dirs []string, err error) {
+	var (
+		pattern string
+		dir     string
+	)
+	for _, pattern = range patterns {
+		_, err = filepath.Match(pattern, ``)
+		if err != nil {
+			return nil, fmt.Errorf(`invalid watch %q: %w`, pattern, err)
+		}
+		dir = filepath.Dir(pattern)
+		if strings.ContainsAny(dir, `*?[\`) {
+			return nil, fmt.Errorf(`invalid watch %q: pattern only allowed in the file name`, pattern)
+		}
+		if !slices.Contains(dirs, dir) {
+			dirs = append(dirs, dir)
+		}
+	}
+	return dirs, nil
+}
+
+// jobWatcher watch the files that match the patterns and trigger the job,
+// through its triggerq, once no more changes after the delay.
+type jobWatcher struct {
+	fsw *fsnotify.Watcher
+
+	triggerq chan<- *libhttp.EndpointRequest
+
+	jobID    string
+	patterns []string
+
+	delay time.Duration
+	ops   fsnotify.Op
+}
+
+// newJobWatcher create and start watching the directories of job
+// patterns.
+func newJobWatcher(job *JobExec) (w *jobWatcher, err error) {
+	var logp = `newJobWatcher`
+
+	w = &jobWatcher{
+		triggerq: job.httpq,
+		jobID:    job.ID,
+		patterns: job.Watch,
+		delay:    job.watchDelay,
+		ops:      job.watchOps,
+	}
+
+	w.fsw, err = fsnotify.NewWatcher()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var dir string
+	for _, dir = range job.watchDirs {
+		err = w.fsw.Add(dir)
+		if err != nil {
+			_ = w.fsw.Close()
+			return nil, fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return w, nil
+}
+
+// isMatch return true if the file name match with one of the patterns.
+func (w *jobWatcher) isMatch(name string) bool {
+	var (
+		pattern string
+		ok      bool
+	)
+	for _, pattern = range w.patterns {
+		ok, _ = filepath.Match(pattern, name)
+		if ok {
+			return true
+		}
+	}
+	return false
+}
+
+// start collecting the changed files until the watcher closed.
+// Once the files not changed after the delay, the job is triggered with
+// the list of changed files.
+// If the job is still running or has pending run, the files are kept
+// and the job triggered again after the next delay.
+func (w *jobWatcher) start() {
+	var (
+		delayc <-chan time.Time
+		files  []string
+		ev     fsnotify.Event
+		err    error
+		ok     bool
+	)
+	for {
+		select {
+		case ev, ok = <-w.fsw.Events:
+			if !ok {
+				return
+			}
+			if ev.Op&w.ops == 0 {
+				continue
+			}
+			if !w.isMatch(ev.Name) {
+				continue
+			}
+			if !slices.Contains(files, ev.Name) {
+				files = append(files, ev.Name)
+			}
+			delayc = time.After(w.delay)
+
+		case err, ok = <-w.fsw.Errors:
+			if !ok {
+				return
+			}
+			mlog.Errf(`job: %s: watch: %s`, w.jobID, err)
+
+		case <-delayc:
+			select {
+			case w.triggerq <- newJobWatchRequest(files):
+				files = nil
+				delayc = nil
+			default:
+				delayc = time.After(w.delay)
+			}
+		}
+	}
+}
+
+// close stop watching the files.
+func (w *jobWatcher) close() {
+	var err = w.fsw.Close()
+	if err != nil {
+		mlog.Errf(`job: %s: watch: %s`, w.jobID, err)
+	}
+}
+
+// newJobWatchRequest create the request that trigger the job with list of
+// changed files.
+func newJobWatchRequest(files []string) (epr *libhttp.EndpointRequest) {
+	var (
+		body = []byte(strings.Join(files, "\n"))
+		ctx  = context.WithValue(context.Background(), ctxKeyJobEnvs{},
+			[]string{jobEnvWatchFiles + `=` + string(body)})
+		req *http.Request
+	)
+
+	req, _ = http.NewRequestWithContext(ctx, http.MethodPost, `watch:`,
+		bytes.NewReader(body))
+
+	epr = &libhttp.EndpointRequest{
+		HTTPRequest: req,
+		RequestBody: body,
+	}
+	return epr
+}
@@ -0,0 +1,160 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+	"github.com/fsnotify/fsnotify"
+)
+
+func TestParseJobWatch(t *testing.T) {
+	type testCase struct {
+		expErr   string
+		patterns []string
+		expDirs  []string
+	}
+
+	var cases = []testCase{{
+		patterns: []string{`/srv/incoming/*.csv`, `/srv/incoming/*.txt`, `/tmp/a`},
+		expDirs:  []string{`/srv/incoming`, `/tmp`},
+	}, {
+		patterns: []string{`/srv/*/a.csv`},
+		expErr:   `invalid watch "/srv/*/a.csv": pattern only allowed in the file name`,
+	}, {
+		patterns: []string{`/srv/[a.csv`},
+		expErr:   `invalid watch "/srv/[a.csv": syntax error in pattern`,
+	}}
+
+	var (
+		c    testCase
+		dirs []string
+		err  error
+	)
+	for _, c = range cases {
+		dirs, err = parseJobWatch(c.patterns)
+		if err != nil {
+			test.Assert(t, `error`, c.expErr, err.Error())
+			continue
+		}
+		test.Assert(t, `dirs`, c.expDirs, dirs)
+	}
+}
+
+func TestParseJobWatchEvents(t *testing.T) {
+	type testCase struct {
+		expErr string
+		names  []string
+		exp    fsnotify.Op
+	}
+
+	var cases = []testCase{{
+		exp: fsnotify.Create | fsnotify.Write,
+	}, {
+		names: []string{`Remove`, ` rename `},
+		exp:   fsnotify.Remove | fsnotify.Rename,
+	}, {
+		names:  []string{`open`},
+		expErr: `invalid watch event "open"`,
+	}}
+
+	var (
+		c   testCase
+		got fsnotify.Op
+		err error
+	)
+	for _, c = range cases {
+		got, err = parseJobWatchEvents(c.names)
+		if err != nil {
+			test.Assert(t, `error`, c.expErr, err.Error())
+			continue
+		}
+		test.Assert(t, `ops`, c.exp, got)
+	}
+}
+
+func TestJobWatcher(t *testing.T) {
+	var (
+		dir      = t.TempDir()
+		triggerq = make(chan *libhttp.EndpointRequest, 1)
+		job      = &JobExec{
+			httpq: triggerq,
+			Watch: []string{filepath.Join(dir, `*.csv`)},
+			JobBase: JobBase{
+				ID: `watch`,
+			},
+		}
+
+		err error
+	)
+
+	job.watchDirs, err = parseJobWatch(job.Watch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.watchOps, err = parseJobWatchEvents(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	job.watchDelay = 100 * time.Millisecond
+
+	var w *jobWatcher
+
+	w, err = newJobWatcher(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.close()
+
+	go w.start()
+
+	var (
+		fileA = filepath.Join(dir, `a.csv`)
+		fileB = filepath.Join(dir, `b.csv`)
+	)
+
+	// Multiple writes on the same file, and the file that does not
+	// match the pattern, should trigger the job once.
+	err = os.WriteFile(fileA, []byte(`1`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dir, `c.txt`), []byte(`1`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(fileB, []byte(`1`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(fileA, []byte(`2`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var epr *libhttp.EndpointRequest
+
+	select {
+	case epr = <-triggerq:
+	case <-time.After(5 * time.Second):
+		t.Fatal(`timeout waiting for trigger`)
+	}
+
+	var files = fileA + "\n" + fileB
+
+	test.Assert(t, `envs`, []string{jobEnvWatchFiles + `=` + files},
+		jobRequestEnvs(epr))
+	test.Assert(t, `body`, files, string(epr.RequestBody))
+
+	select {
+	case epr = <-triggerq:
+		t.Fatalf(`unexpected trigger: %s`, epr.RequestBody)
+	case <-time.After(300 * time.Millisecond):
+	}
+}
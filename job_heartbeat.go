@@ -0,0 +1,76 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"net/http"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defHeartbeatTimeout the timeout for sending ping to heartbeat URL.
+const defHeartbeatTimeout = 10 * time.Second
+
+// List of suffix appended to the heartbeat URL, following the
+// healthchecks.io convention.
+// The successful run is pinged to the URL without suffix.
+const (
+	heartbeatStart = `/start`
+	heartbeatFail  = `/fail`
+)
+
+// heartbeatClient the HTTP client for sending ping to heartbeat URL.
+var heartbeatClient = &http.Client{
+	Timeout: defHeartbeatTimeout,
+}
+
+// heartbeat ping the job heartbeat URL with suffix in the background.
+// The body is send as the request body, for example the last lines of
+// log.
+// It does nothing if the HeartbeatURL is not set.
+func (job *JobBase) heartbeat(suffix string, body []byte) {
+	if len(job.heartbeatURL) == 0 {
+		return
+	}
+	go pingHeartbeat(job.kind, job.ID, job.heartbeatURL+suffix, body)
+}
+
+// heartbeatFinish ping the job heartbeat URL based on the status of
+// finished jlog, with the last lines of log as the body.
+// The paused run is not pinged.
+func (job *JobBase) heartbeatFinish(jlog *JobLog) {
+	var suffix string
+
+	switch jlog.Status {
+	case JobStatusSuccess:
+	case JobStatusCanceled, JobStatusFailed:
+		suffix = heartbeatFail
+	default:
+		return
+	}
+
+	var body = lastLines(jlog.tailContent(), defNotifSummaryLines)
+
+	job.heartbeat(suffix, body)
+}
+
+// pingHeartbeat send POST request to the heartbeat URL.
+// The failure is only logged, so it does not affect the job.
+func pingHeartbeat(kind jobKind, id, url string, body []byte) {
+	var (
+		req *http.Request
+		err error
+	)
+
+	req, err = http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err == nil {
+		req.Header.Set(`Content-Type`, `text/plain; charset=utf-8`)
+		err = doPush(heartbeatClient, req)
+	}
+	if err != nil {
+		mlog.Errf(`%s: %s: heartbeat: %s`, kind, id, err)
+	}
+}
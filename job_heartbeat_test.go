@@ -0,0 +1,64 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobBase_heartbeat(t *testing.T) {
+	type ping struct {
+		path string
+		body string
+	}
+
+	var pingq = make(chan ping, 1)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var body, _ = io.ReadAll(req.Body)
+		pingq <- ping{
+			path: req.URL.Path,
+			body: string(body),
+		}
+	}))
+	defer srv.Close()
+
+	var job = &JobBase{
+		kind:         jobKindExec,
+		ID:           `backup`,
+		dirLog:       t.TempDir(),
+		heartbeatURL: srv.URL + `/ping/uuid`,
+	}
+
+	var (
+		jlog *JobLog
+		got  ping
+	)
+
+	_, jlog = job.newLog()
+	got = <-pingq
+	test.Assert(t, `start`, ping{path: `/ping/uuid/start`}, got)
+
+	jlog.Write([]byte("done\n"))
+	job.finish(jlog, nil)
+	got = <-pingq
+	var exp = ping{
+		path: `/ping/uuid`,
+		body: "2023-01-09 00:00:00 UTC job: backup: done\n" +
+			"2023-01-09 00:00:00 UTC job: backup: === job: backup: finished.\n",
+	}
+	test.Assert(t, `success`, exp, got)
+
+	_, jlog = job.newLog()
+	<-pingq
+	job.finish(jlog, errors.New(`exit status 1`))
+	got = <-pingq
+	test.Assert(t, `fail`, `/ping/uuid/fail`, got.path)
+}
@@ -4,13 +4,17 @@
 package karajo
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"net/http"
 	"net/http/httputil"
 	"net/url"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -33,7 +37,8 @@ const (
 // how to setup periodic time.
 //
 // Each JobHTTP execution send the parameter named "_karajo_epoch" with value
-// set to current server Unix timestamp.
+// set to current server Unix timestamp, unless the request has raw body
+// from HTTPBody or HTTPBodyFile.
 // If the request type is "query" then the parameter is inside the query URL.
 // If the request type is "form" then the parameter is inside the body.
 // If the request type is "json" then the parameter is inside the body as JSON
@@ -48,13 +53,13 @@ const (
 //	http_url =
 //	http_request_type =
 //	http_header =
+//	http_body =
+//	http_body_file =
+//	http_repeat =
+//	http_repeat_max =
 //	http_timeout =
 //	http_insecure =
 type JobHTTP struct {
-	// jobq is a channel passed by Karajo instance to limit number of
-	// job running at the same time.
-	jobq chan struct{}
-
 	headers http.Header
 
 	// httpc define the HTTP client that will execute the http_url.
@@ -68,7 +73,10 @@ type JobHTTP struct {
 	// HMAC+SHA-256.
 	// The signature is sent on HTTP header "X-Karajo-Sign" as hex string.
 	// This field is optional.
+	// The secret can be read from external source using
+	// "<scheme>:<path>" format, see [Env.RegisterSecretProvider].
 	Secret string `ini:"::secret" json:"-"`
+	secret *secretValue
 
 	// HeaderSign define the HTTP header where the signature will be
 	// written in request.
@@ -76,16 +84,26 @@ type JobHTTP struct {
 	HeaderSign string `ini:"::header_sign" json:"header_sign,omitempty"`
 
 	// HTTPMethod HTTP method to be used in request for job execution.
-	// Its accept only GET, POST, PUT, or DELETE.
+	// Its accept only GET, POST, PUT, PATCH, DELETE, HEAD, or OPTIONS.
 	// This field is optional, default to GET.
 	HTTPMethod string `ini:"::http_method" json:"http_method"`
 
 	// The HTTP URL where the job will be executed.
 	// This field is required.
+	// The "${NAME}" in HTTPURL is expanded with the value of system
+	// environment variable when sending the request, while HTTPURL
+	// itself is kept as is.
 	HTTPURL    string `ini:"::http_url" json:"http_url"`
 	baseURI    string
 	requestURI string
 
+	// httpURL the HTTPURL after expanded.
+	httpURL string
+
+	// urlEnvNames contains the name of system environment variables
+	// referenced in HTTPURL, used to redact their values from the log.
+	urlEnvNames []string
+
 	// HTTPRequestType The header Content-Type to be set on request.
 	//
 	//   - (empty string): no header Content-Type set.
@@ -96,7 +114,7 @@ type JobHTTP struct {
 	//   - json: header Content-Type set to "application/json".
 	//
 	// The type "form" and "json" only applicable if the HTTPMethod is
-	// POST or PUT.
+	// POST, PUT, or PATCH.
 	// This field is optional, default to query.
 	HTTPRequestType string `ini:"::http_request_type" json:"http_request_type"`
 
@@ -106,6 +124,44 @@ type JobHTTP struct {
 	// Optional HTTP headers for HTTPURL, in the format of "K: V".
 	HTTPHeaders []string `ini:"::http_header" json:"http_headers,omitempty"`
 
+	// HTTPBody define the raw body to be send in request, as is.
+	// If its set, the parameter "_karajo_epoch" is not send and the
+	// signature is computed from the body.
+	// The header Content-Type is set based on HTTPRequestType, unless
+	// its set in HTTPHeaders.
+	// This field is optional and cannot be used with HTTPBodyFile.
+	HTTPBody string `ini:"::http_body" json:"http_body,omitempty"`
+
+	// HTTPBodyFile define the path to file that contains the raw body
+	// to be send in request, like HTTPBody.
+	// The relative path is relative to Env DirBase.
+	// The file is read on each execution, so its content can be
+	// changed without reloading the job.
+	// This field is optional and cannot be used with HTTPBody.
+	HTTPBodyFile string `ini:"::http_body_file" json:"http_body_file,omitempty"`
+	bodyFile     string
+
+	// HTTPRepeat define the condition to repeat the request in one
+	// execution, for example to fetch all pages of resources or to
+	// drain the external queue,
+	//
+	//   - "link": repeat the request to the URL in response header
+	//     "Link" with rel="next", while its exist.
+	//   - "json:<field>": repeat the same request while the boolean
+	//     <field> in JSON response is true, for example
+	//     "json:has_more".
+	//
+	// Each request and response is written into the job log.
+	// This field is optional.
+	HTTPRepeat string `ini:"::http_repeat" json:"http_repeat,omitempty"`
+	repeat     *httpRepeat
+
+	// HTTPRepeatMax define the maximum number of requests in one
+	// execution with HTTPRepeat.
+	// Once reached, the job is stopped and considered as failed.
+	// This field is optional, default to 100.
+	HTTPRepeatMax int `ini:"::http_repeat_max" json:"http_repeat_max,omitempty"`
+
 	JobBase
 
 	// HTTPTimeout custom HTTP timeout for this job.
@@ -119,27 +175,42 @@ type JobHTTP struct {
 	HTTPInsecure bool `ini:"::http_insecure" json:"http_insecure,omitempty"`
 }
 
-// Start running the job.
-func (job *JobHTTP) Start(jobq chan struct{}, logq chan<- *JobLog) {
-	job.jobq = jobq
-	job.JobBase.logq = logq
+// MarshalJSON encode the current state of job as JSON object.
+// The job is locked only while its being encoded, so encoding list of jobs
+// does not block the other jobs.
+func (job *JobHTTP) MarshalJSON() ([]byte, error) {
+	type jobHTTPJSON JobHTTP
 
-	// Signal to the caller that job has started.
-	jobq <- struct{}{}
+	job.Lock()
+	defer job.Unlock()
+
+	return json.Marshal((*jobHTTPJSON)(job))
+}
+
+// Start running the job in the background, either by scheduler or
+// interval.
+// The finished job log is published to logq.
+func (job *JobHTTP) Start(logq chan<- *JobLog) {
+	job.JobBase.logq = logq
 
 	if job.scheduler != nil {
-		job.startScheduler()
+		go job.startScheduler()
 		return
 	}
 	if job.Interval > 0 {
-		job.startInterval()
+		go job.startInterval()
 	}
 }
 
 func (job *JobHTTP) startScheduler() {
+	var t time.Time
+
 	for {
 		select {
-		case <-job.scheduler.C:
+		case t = <-job.scheduler.C:
+			if job.skipOverlap(t) {
+				continue
+			}
 			job.run()
 
 		case <-job.stopq:
@@ -161,6 +232,7 @@ func (job *JobHTTP) startInterval() {
 		now = timeNow()
 		nextInterval = job.computeNextInterval(now)
 		job.NextRun = now.Add(nextInterval)
+		job.lastRevision = job.revision.bump()
 		job.Unlock()
 
 		if timer == nil {
@@ -183,12 +255,30 @@ func (job *JobHTTP) startInterval() {
 }
 
 func (job *JobHTTP) run() {
+	if !job.leader.isLeader() {
+		// Scheduled run is handled by the leader.
+		return
+	}
+	if job.readOnly.is() || job.isDisabled() {
+		return
+	}
+
 	var (
 		jlog *JobLog
 		err  error
 	)
 
+	err = job.ns.acquire()
+	if err != nil {
+		job.skip(err.Error())
+		return
+	}
+	defer job.ns.release()
+
+	job.mutex.lock(job.ID)
 	jlog, err = job.execute()
+	job.mutex.unlock()
+
 	job.finish(jlog, err)
 }
 
@@ -228,6 +318,13 @@ func (job *JobHTTP) init(env *Env, name string) (err error) {
 		return err
 	}
 
+	job.Secret = expandEnv(job.Secret)
+	if len(job.Secret) != 0 {
+		job.secret = env.newSecretValue(job.Secret)
+	}
+	job.httpURL = expandEnv(job.HTTPURL)
+	job.urlEnvNames = envNames(job.HTTPURL)
+
 	err = job.initHTTPURL(env.ListenAddress)
 	if err != nil {
 		return err
@@ -238,14 +335,20 @@ func (job *JobHTTP) init(env *Env, name string) (err error) {
 		return err
 	}
 
-	job.params = make(map[string]interface{})
+	err = job.initHTTPBody(env.DirBase)
+	if err != nil {
+		return err
+	}
 
-	var httpClientOpts = libhttp.ClientOptions{
-		ServerURL:     job.baseURI,
-		Headers:       job.headers,
-		AllowInsecure: job.HTTPInsecure,
+	job.repeat, err = newHTTPRepeat(job.HTTPRepeat)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, job.ID, err)
 	}
-	job.httpc = libhttp.NewClient(httpClientOpts)
+	if job.repeat != nil && job.HTTPRepeatMax <= 0 {
+		job.HTTPRepeatMax = defJobHTTPRepeatMax
+	}
+
+	job.params = make(map[string]interface{})
 
 	if job.HTTPTimeout == 0 {
 		job.HTTPTimeout = env.HTTPTimeout
@@ -253,7 +356,8 @@ func (job *JobHTTP) init(env *Env, name string) (err error) {
 		// Negative value means 0 on net/http.Client.
 		job.HTTPTimeout = 0
 	}
-	job.httpc.Client.Timeout = job.HTTPTimeout
+
+	job.initHTTPClient()
 
 	if len(job.HeaderSign) == 0 {
 		job.HeaderSign = HeaderNameXKarajoSign
@@ -279,6 +383,12 @@ func (job *JobHTTP) initHTTPMethod() (err error) {
 		job.requestMethod = libhttp.RequestMethodGet
 	case http.MethodDelete:
 		job.requestMethod = libhttp.RequestMethodDelete
+	case http.MethodHead:
+		job.requestMethod = libhttp.RequestMethodHead
+	case http.MethodOptions:
+		job.requestMethod = libhttp.RequestMethodOptions
+	case http.MethodPatch:
+		job.requestMethod = libhttp.RequestMethodPatch
 	case http.MethodPost:
 		job.requestMethod = libhttp.RequestMethodPost
 	case http.MethodPut:
@@ -304,10 +414,42 @@ func (job *JobHTTP) initHTTPRequestType() (err error) {
 	return nil
 }
 
+// setServerAddress change the address of karajo server for job with
+// HTTPURL that start with "/".
+func (job *JobHTTP) setServerAddress(serverAddress string) {
+	if job.httpURL[0] != '/' {
+		return
+	}
+
+	job.Lock()
+	job.baseURI = fmt.Sprintf(`http://%s`, serverAddress)
+	job.initHTTPClient()
+	job.Unlock()
+}
+
+// secretBytes return the secret to sign the request.
+func (job *JobHTTP) secretBytes() (secret []byte, err error) {
+	if job.secret == nil {
+		return []byte(job.Secret), nil
+	}
+	return job.secret.get()
+}
+
+// initHTTPClient create the HTTP client for sending request to baseURI.
+func (job *JobHTTP) initHTTPClient() {
+	var httpClientOpts = libhttp.ClientOptions{
+		ServerURL:     job.baseURI,
+		Headers:       job.headers,
+		AllowInsecure: job.HTTPInsecure,
+	}
+	job.httpc = libhttp.NewClient(httpClientOpts)
+	job.httpc.Client.Timeout = job.HTTPTimeout
+}
+
 func (job *JobHTTP) initHTTPURL(serverAddress string) (err error) {
-	if job.HTTPURL[0] == '/' {
+	if job.httpURL[0] == '/' {
 		job.baseURI = fmt.Sprintf(`http://%s`, serverAddress)
-		job.requestURI = job.HTTPURL
+		job.requestURI = job.httpURL
 		return nil
 	}
 
@@ -316,7 +458,7 @@ func (job *JobHTTP) initHTTPURL(serverAddress string) (err error) {
 		port    string
 	)
 
-	httpURL, err = url.Parse(job.HTTPURL)
+	httpURL, err = url.Parse(job.httpURL)
 	if err != nil {
 		return fmt.Errorf(`%s: invalid http_url %q: %w`, job.ID, job.HTTPURL, err)
 	}
@@ -357,6 +499,63 @@ func (job *JobHTTP) initHTTPHeaders() (err error) {
 	return nil
 }
 
+// initHTTPBody check the HTTPBody and HTTPBodyFile, and resolve the
+// relative HTTPBodyFile to the dirBase.
+func (job *JobHTTP) initHTTPBody(dirBase string) (err error) {
+	job.bodyFile = ``
+
+	var bodyFile = strings.TrimSpace(expandEnv(job.HTTPBodyFile))
+	if len(bodyFile) == 0 {
+		return nil
+	}
+	if len(job.HTTPBody) != 0 {
+		return fmt.Errorf(`%s: http_body and http_body_file cannot be used together`, job.ID)
+	}
+	if !filepath.IsAbs(bodyFile) {
+		bodyFile = filepath.Join(dirBase, bodyFile)
+	}
+
+	_, err = os.Stat(bodyFile)
+	if err != nil {
+		return fmt.Errorf(`%s: http_body_file: %w`, job.ID, err)
+	}
+	job.bodyFile = bodyFile
+	return nil
+}
+
+// hasBody return true if the request has raw body from HTTPBody or
+// HTTPBodyFile.
+func (job *JobHTTP) hasBody() bool {
+	return len(job.HTTPBody) != 0 || len(job.bodyFile) != 0
+}
+
+// body return the raw body of request.
+func (job *JobHTTP) body() (body []byte, err error) {
+	if len(job.bodyFile) == 0 {
+		return []byte(job.HTTPBody), nil
+	}
+	body, err = os.ReadFile(job.bodyFile)
+	if err != nil {
+		return nil, fmt.Errorf(`http_body_file: %w`, err)
+	}
+	return body, nil
+}
+
+// setBody set the raw body of HTTP request, including its Content-Type
+// from the request type if its not set in the HTTPHeaders.
+func (job *JobHTTP) setBody(httpReq *http.Request, body []byte) {
+	httpReq.Body = io.NopCloser(bytes.NewReader(body))
+	httpReq.GetBody = func() (io.ReadCloser, error) {
+		return io.NopCloser(bytes.NewReader(body)), nil
+	}
+	httpReq.ContentLength = int64(len(body))
+
+	var contentType = job.requestType.String()
+	if len(contentType) != 0 && len(httpReq.Header.Get(libhttp.HeaderContentType)) == 0 {
+		httpReq.Header.Set(libhttp.HeaderContentType, contentType)
+	}
+}
+
 func (job *JobHTTP) execute() (jlog *JobLog, err error) {
 	var ctx context.Context
 
@@ -366,40 +565,119 @@ func (job *JobHTTP) execute() (jlog *JobLog, err error) {
 	}
 	defer job.JobBase.ctxCancel()
 
+	var logp = `execute`
+
+	_, _ = jlog.Write([]byte("=== BEGIN\n"))
+
+	var run *JobRun
+
+	run, err = job.beforeRun(ctx, jlog)
+	if err != nil {
+		return jlog, err
+	}
+	defer func() {
+		job.afterRun(ctx, run, err)
+	}()
+
 	var (
-		logp    = `execute`
-		now     = timeNow()
-		headers = http.Header{}
+		requestURI = job.requestURI
 
-		params interface{}
-		rawb   []byte
+		clientResp *libhttp.ClientResponse
+		page       int
+		next       bool
 	)
+	for page = 1; ; page++ {
+		if job.repeat != nil {
+			fmt.Fprintf(jlog, "--- HTTP page %d\n", page)
+		}
 
-	_, _ = jlog.Write([]byte("=== BEGIN\n"))
+		clientResp, err = job.send(ctx, jlog, requestURI)
+		if err != nil {
+			return jlog, fmt.Errorf(`%s: %w`, logp, err)
+		}
+		if job.repeat == nil {
+			break
+		}
 
-	job.params[defJosParamEpoch] = now.Unix()
+		requestURI, next, err = job.repeat.next(job.baseURI, requestURI, clientResp)
+		if err != nil {
+			return jlog, fmt.Errorf(`%s: page %d: %w`, logp, page, err)
+		}
+		if !next {
+			break
+		}
+		if page >= job.HTTPRepeatMax {
+			return jlog, fmt.Errorf(`%s: http_repeat reach the maximum %d pages`,
+				logp, job.HTTPRepeatMax)
+		}
+	}
+
+	_, _ = jlog.Write([]byte("=== DONE\n"))
+
+	return jlog, nil
+}
 
-	switch job.requestType {
-	case libhttp.RequestTypeQuery, libhttp.RequestTypeForm:
-		params, rawb = job.paramsToURLValues()
+// send the HTTP request to requestURI and write the request and response
+// into jlog.
+// It will return an error if the response status code is not 200.
+func (job *JobHTTP) send(ctx context.Context, jlog *JobLog, requestURI string) (
+	clientResp *libhttp.ClientResponse, err error,
+) {
+	var (
+		headers = http.Header{}
+		reqType = job.requestType
+		hasBody = job.hasBody()
 
-	case libhttp.RequestTypeJSON:
-		params, rawb, err = job.paramsToJSON()
+		params interface{}
+		body   []byte
+		rawb   []byte
+	)
+	if hasBody {
+		body, err = job.body()
 		if err != nil {
-			return jlog, fmt.Errorf(`%s: %w`, logp, err)
+			return nil, err
 		}
+		reqType = libhttp.RequestTypeNone
+		rawb = body
+	} else {
+		job.params[defJosParamEpoch] = timeNow().Unix()
+
+		switch job.requestType {
+		case libhttp.RequestTypeQuery, libhttp.RequestTypeForm:
+			params, rawb = job.paramsToURLValues()
+
+		case libhttp.RequestTypeJSON:
+			params, rawb, err = job.paramsToJSON()
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if reqType == libhttp.RequestTypeQuery && strings.Contains(requestURI, `?`) {
+		// Append the parameters into the existing query, for
+		// example in the next page link.
+		requestURI += `&` + string(rawb)
+		params = nil
 	}
 
 	if len(job.Secret) != 0 {
-		var sign = Sign(rawb, []byte(job.Secret))
+		var secret []byte
+
+		secret, err = job.secretBytes()
+		if err != nil {
+			return nil, err
+		}
+
+		var sign = Sign(rawb, secret)
 		headers.Set(job.HeaderSign, sign)
 	}
 
 	var (
 		clientReq = libhttp.ClientRequest{
 			Method: job.requestMethod,
-			Path:   job.requestURI,
-			Type:   job.requestType,
+			Path:   requestURI,
+			Type:   reqType,
 			Header: headers,
 			Params: params,
 		}
@@ -408,43 +686,50 @@ func (job *JobHTTP) execute() (jlog *JobLog, err error) {
 
 	httpReq, err = job.httpc.GenerateHTTPRequest(clientReq)
 	if err != nil {
-		return jlog, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
+	}
+
+	if hasBody {
+		job.setBody(httpReq, body)
 	}
 
 	httpReq = httpReq.WithContext(ctx)
 
 	rawb, err = httputil.DumpRequestOut(httpReq, true)
 	if err != nil {
-		return jlog, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
 
-	fmt.Fprintf(jlog, "--- HTTP request:\n%s\n\n", rawb)
+	rawb = redactEnv(rawb, job.urlEnvNames)
 
-	var clientResp *libhttp.ClientResponse
+	fmt.Fprintf(jlog, "--- HTTP request:\n%s\n\n", rawb)
 
 	clientResp, err = job.httpc.Do(httpReq)
 	if err != nil {
 		var errCtx = ctx.Err()
 		if errCtx != nil && errors.Is(errCtx, context.Canceled) {
-			return jlog, fmt.Errorf(`%s: %w`, logp, &errJobCanceled)
+			return nil, &errJobCanceled
 		}
-		return jlog, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
 
 	rawb, err = httputil.DumpResponse(clientResp.HTTPResponse, true)
 	if err != nil {
-		return jlog, fmt.Errorf(`%s: %w`, logp, err)
+		return nil, err
 	}
 
 	fmt.Fprintf(jlog, "--- HTTP response:\n%s\n\n", rawb)
 
-	if clientResp.HTTPResponse.StatusCode != http.StatusOK {
-		return jlog, fmt.Errorf(`%s: %s`, logp, clientResp.HTTPResponse.Status)
+	var statusCode = clientResp.HTTPResponse.StatusCode
+	if statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden {
+		// The external secret may has been changed, fetch it again
+		// on the next run.
+		job.secret.invalidate()
 	}
-
-	_, _ = jlog.Write([]byte("=== DONE\n"))
-
-	return jlog, nil
+	if statusCode != http.StatusOK {
+		return nil, errors.New(clientResp.HTTPResponse.Status)
+	}
+	return clientResp, nil
 }
 
 func (job *JobHTTP) paramsToJSON() (obj map[string]interface{}, raw []byte, err error) {
@@ -464,7 +749,7 @@ func (job *JobHTTP) paramsToURLValues() (url.Values, []byte) {
 		v interface{}
 	)
 	for k, v = range job.params {
-		urlValues.Set(k, fmt.Sprintf(`%s`, v))
+		urlValues.Set(k, fmt.Sprintf(`%v`, v))
 	}
 	return urlValues, []byte(urlValues.Encode())
 }
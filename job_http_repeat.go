@@ -0,0 +1,190 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"strings"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// defJobHTTPRepeatMax default maximum number of requests in one
+// execution of JobHTTP with HTTPRepeat.
+const defJobHTTPRepeatMax = 100
+
+// List of condition kind for HTTPRepeat.
+const (
+	httpRepeatLink = `link`
+	httpRepeatJSON = `json`
+)
+
+// httpRepeat define the condition to repeat the request of JobHTTP,
+// parsed from HTTPRepeat.
+type httpRepeat struct {
+	kind string
+
+	// fields the path to boolean field in JSON response, for kind
+	// "json".
+	fields []string
+}
+
+// newHTTPRepeat parse the value of HTTPRepeat,
+//
+//   - "link": repeat the request to the URL in response header "Link"
+//     with rel="next", while its exist.
+//   - "json:<field>": repeat the same request while the <field> in
+//     JSON response is true.
+//     The field in nested object can be separated by dot, for example
+//     "json:meta.has_more".
+//
+// It will return nil if the value is empty.
+func newHTTPRepeat(v string) (rep *httpRepeat, err error) {
+	v = strings.TrimSpace(v)
+	if len(v) == 0 {
+		return nil, nil
+	}
+
+	var kind, field, _ = strings.Cut(v, `:`)
+
+	rep = &httpRepeat{
+		kind: strings.ToLower(strings.TrimSpace(kind)),
+	}
+
+	switch rep.kind {
+	case httpRepeatLink:
+		if len(field) != 0 {
+			return nil, fmt.Errorf(`invalid http_repeat %q`, v)
+		}
+
+	case httpRepeatJSON:
+		field = strings.TrimSpace(field)
+		if len(field) == 0 {
+			return nil, fmt.Errorf(`invalid http_repeat %q: empty field`, v)
+		}
+		rep.fields = strings.Split(field, `.`)
+
+	default:
+		return nil, fmt.Errorf(`invalid http_repeat %q`, v)
+	}
+	return rep, nil
+}
+
+// next return the request URI for the next page and true if the request
+// to baseURI and requestURI should be repeated based on its response.
+// The next link must be on the same scheme, host, and port as baseURI.
+func (rep *httpRepeat) next(baseURI, requestURI string, clientResp *libhttp.ClientResponse) (
+	nextURI string, ok bool, err error,
+) {
+	if rep.kind == httpRepeatJSON {
+		ok, err = rep.jsonField(clientResp.Body)
+		return requestURI, ok, err
+	}
+
+	var link = linkNext(clientResp.HTTPResponse.Header.Values(`Link`))
+	if len(link) == 0 {
+		return ``, false, nil
+	}
+
+	var reqURL, nextURL *url.URL
+
+	reqURL, err = url.Parse(baseURI + requestURI)
+	if err != nil {
+		return ``, false, fmt.Errorf(`invalid request URL: %w`, err)
+	}
+
+	nextURL, err = url.Parse(link)
+	if err != nil {
+		return ``, false, fmt.Errorf(`invalid next link %q: %w`, link, err)
+	}
+
+	nextURL = reqURL.ResolveReference(nextURL)
+	if nextURL.Scheme != reqURL.Scheme || nextURL.Hostname() != reqURL.Hostname() ||
+		urlPort(nextURL) != urlPort(reqURL) {
+		return ``, false, fmt.Errorf(`next link %q is not on %s`, link, reqURL.Host)
+	}
+	return nextURL.RequestURI(), true, nil
+}
+
+// urlPort return the port of URL, or the default port of its scheme.
+func urlPort(u *url.URL) string {
+	var port = u.Port()
+	if len(port) != 0 {
+		return port
+	}
+	if u.Scheme == `https` {
+		return `443`
+	}
+	return `80`
+}
+
+// jsonField return the boolean value of fields in JSON body.
+// The missing field is considered as false.
+func (rep *httpRepeat) jsonField(body []byte) (v bool, err error) {
+	var obj any
+
+	err = json.Unmarshal(body, &obj)
+	if err != nil {
+		return false, fmt.Errorf(`http_repeat: %w`, err)
+	}
+
+	var (
+		field string
+		m     map[string]any
+		ok    bool
+	)
+	for _, field = range rep.fields {
+		m, ok = obj.(map[string]any)
+		if !ok {
+			return false, nil
+		}
+		obj = m[field]
+	}
+	if obj == nil {
+		return false, nil
+	}
+	v, ok = obj.(bool)
+	if !ok {
+		return false, fmt.Errorf(`http_repeat: field %s is not boolean`,
+			strings.Join(rep.fields, `.`))
+	}
+	return v, nil
+}
+
+// linkNext return the URL with rel="next" from the values of header
+// Link, for example
+//
+//	Link: <https://api.example.com/items?page=2>; rel="next", <...>; rel="last"
+func linkNext(values []string) string {
+	var (
+		value  string
+		link   string
+		params []string
+		param  string
+		rel    string
+		ok     bool
+	)
+	for _, value = range values {
+		for _, link = range strings.Split(value, `,`) {
+			params = strings.Split(link, `;`)
+			link = strings.TrimSpace(params[0])
+			if !strings.HasPrefix(link, `<`) || !strings.HasSuffix(link, `>`) {
+				continue
+			}
+			for _, param = range params[1:] {
+				rel, ok = strings.CutPrefix(strings.TrimSpace(param), `rel=`)
+				if !ok {
+					continue
+				}
+				rel = strings.Trim(rel, `"`)
+				if strings.Contains(` `+rel+` `, ` next `) {
+					return link[1 : len(link)-1]
+				}
+			}
+		}
+	}
+	return ``
+}
@@ -0,0 +1,211 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestNewHTTPRepeat(t *testing.T) {
+	type testCase struct {
+		exp      *httpRepeat
+		value    string
+		expError string
+	}
+
+	var cases = []testCase{{
+		value: ``,
+	}, {
+		value: ` Link `,
+		exp:   &httpRepeat{kind: httpRepeatLink},
+	}, {
+		value: `json:meta.has_more`,
+		exp: &httpRepeat{
+			kind:   httpRepeatJSON,
+			fields: []string{`meta`, `has_more`},
+		},
+	}, {
+		value:    `json:`,
+		expError: `invalid http_repeat "json:": empty field`,
+	}, {
+		value:    `link:next`,
+		expError: `invalid http_repeat "link:next"`,
+	}, {
+		value:    `header`,
+		expError: `invalid http_repeat "header"`,
+	}}
+
+	var (
+		c   testCase
+		got *httpRepeat
+		err error
+	)
+	for _, c = range cases {
+		got, err = newHTTPRepeat(c.value)
+		if err != nil {
+			test.Assert(t, c.value, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.value, c.exp, got)
+	}
+}
+
+func TestLinkNext(t *testing.T) {
+	type testCase struct {
+		desc   string
+		exp    string
+		values []string
+	}
+
+	var cases = []testCase{{
+		desc: `empty`,
+	}, {
+		desc: `next and last`,
+		values: []string{
+			`<https://api.example.com/items?page=2>; rel="next", <https://api.example.com/items?page=5>; rel="last"`,
+		},
+		exp: `https://api.example.com/items?page=2`,
+	}, {
+		desc: `multiple header and relation`,
+		values: []string{
+			`</items?page=1>; rel="prev"`,
+			`</items?page=3>; title="Next"; rel="next last"`,
+		},
+		exp: `/items?page=3`,
+	}, {
+		desc: `last page`,
+		values: []string{
+			`</items?page=1>; rel="first"`,
+		},
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.desc, c.exp, linkNext(c.values))
+	}
+}
+
+func TestJobHTTP_executeRepeat(t *testing.T) {
+	var (
+		mtx   sync.Mutex
+		queue int
+		pages []string
+		srv   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			mtx.Lock()
+			defer mtx.Unlock()
+
+			var page = req.URL.Query().Get(`page`)
+			pages = append(pages, page)
+
+			switch req.URL.Path {
+			case `/items`:
+				var n, _ = strconv.Atoi(page)
+				if n < 3 {
+					w.Header().Set(`Link`,
+						fmt.Sprintf(`</items?page=%d>; rel="next"`, n+1))
+				}
+				w.WriteHeader(http.StatusOK)
+
+			case `/other_host`:
+				w.Header().Set(`Link`, `<http://example.com/items?page=2>; rel="next"`)
+				w.WriteHeader(http.StatusOK)
+
+			case `/queue`:
+				queue--
+				fmt.Fprintf(w, `{"meta":{"has_more":%t}}`, queue > 0)
+			}
+		}))
+	)
+	t.Cleanup(srv.Close)
+
+	type testCase struct {
+		job      *JobHTTP
+		desc     string
+		expError string
+		expPages []string
+		queue    int
+	}
+
+	var cases = []testCase{{
+		desc: `link`,
+		job: &JobHTTP{
+			HTTPURL:    srv.URL + `/items?page=1`,
+			HTTPRepeat: `link`,
+		},
+		expPages: []string{`1`, `2`, `3`},
+	}, {
+		desc: `link on other host`,
+		job: &JobHTTP{
+			HTTPURL:    srv.URL + `/other_host`,
+			HTTPRepeat: `link`,
+		},
+		expPages: []string{``},
+		expError: `execute: page 1: next link "http://example.com/items?page=2" is not on ` +
+			srv.Listener.Addr().String(),
+	}, {
+		desc: `json`,
+		job: &JobHTTP{
+			HTTPURL:    srv.URL + `/queue`,
+			HTTPRepeat: `json:meta.has_more`,
+		},
+		queue:    3,
+		expPages: []string{``, ``, ``},
+	}, {
+		desc: `json reach maximum`,
+		job: &JobHTTP{
+			HTTPURL:       srv.URL + `/queue`,
+			HTTPRepeat:    `json:meta.has_more`,
+			HTTPRepeatMax: 2,
+		},
+		queue:    5,
+		expPages: []string{``, ``},
+		expError: `execute: http_repeat reach the maximum 2 pages`,
+	}}
+
+	var (
+		c       testCase
+		jlog    *JobLog
+		content []byte
+		err     error
+	)
+	for _, c = range cases {
+		var env = &Env{
+			DirBase: t.TempDir(),
+			HTTPJobs: map[string]*JobHTTP{
+				`test`: c.job,
+			},
+		}
+
+		err = env.init()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		mtx.Lock()
+		queue = c.queue
+		pages = nil
+		mtx.Unlock()
+
+		jlog, err = c.job.execute()
+		if err != nil {
+			test.Assert(t, c.desc+`: error`, c.expError, err.Error())
+		} else {
+			test.Assert(t, c.desc+`: error`, c.expError, ``)
+		}
+		test.Assert(t, c.desc+`: pages`, c.expPages, pages)
+
+		content, _ = jlog.read()
+		test.Assert(t, c.desc+`: log each page`, true,
+			bytes.Contains(content, []byte(
+				fmt.Sprintf("--- HTTP page %d\n", len(c.expPages)))))
+	}
+}
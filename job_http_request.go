@@ -8,4 +8,13 @@ package karajo
 type JobHTTPRequest struct {
 	ID    string `json:"id" form:"id"`
 	Epoch int64  `json:"_karajo_epoch" form:"_karajo_epoch"`
+
+	// Nonce the random value to make the signature of each request
+	// unique, so the request within the same Epoch is not rejected as
+	// replayed.
+	Nonce string `json:"_karajo_nonce,omitempty" form:"_karajo_nonce"`
+
+	// Params the parameters to run the JobExec, see
+	// [JobExec.RunParams].
+	Params map[string]string `json:"params,omitempty" form:"-"`
 }
@@ -0,0 +1,175 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobHTTP_initHTTPMethod(t *testing.T) {
+	type testCase struct {
+		method   string
+		exp      string
+		expError string
+	}
+
+	var cases = []testCase{{
+		exp: http.MethodGet,
+	}, {
+		method: `patch`,
+		exp:    http.MethodPatch,
+	}, {
+		method: `HEAD`,
+		exp:    http.MethodHead,
+	}, {
+		method: `options`,
+		exp:    http.MethodOptions,
+	}, {
+		method:   `TRACE`,
+		expError: `invalid HTTP method "TRACE"`,
+	}}
+
+	var (
+		c   testCase
+		err error
+	)
+	for _, c = range cases {
+		var job = &JobHTTP{
+			HTTPMethod: c.method,
+		}
+		err = job.initHTTPMethod()
+		if err != nil {
+			test.Assert(t, c.method, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.method, c.exp, string(job.requestMethod))
+	}
+}
+
+func TestJobHTTP_executeBody(t *testing.T) {
+	type request struct {
+		method      string
+		contentType string
+		sign        string
+		query       string
+		body        string
+	}
+
+	var (
+		gotReq request
+		srv    = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body, _ = io.ReadAll(req.Body)
+			gotReq = request{
+				method:      req.Method,
+				contentType: req.Header.Get(`Content-Type`),
+				sign:        req.Header.Get(HeaderNameXKarajoSign),
+				query:       req.URL.RawQuery,
+				body:        string(body),
+			}
+			w.WriteHeader(http.StatusOK)
+		}))
+		dirBase = t.TempDir()
+		err     error
+	)
+	t.Cleanup(srv.Close)
+
+	err = os.WriteFile(filepath.Join(dirBase, `body.xml`), []byte(`<a>1</a>`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		job      *JobHTTP
+		desc     string
+		exp      request
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc: `PATCH with inline JSON body`,
+		job: &JobHTTP{
+			HTTPMethod:      `PATCH`,
+			HTTPRequestType: `json`,
+			HTTPBody:        `{"state":"done"}`,
+			Secret:          `s3cret`,
+		},
+		exp: request{
+			method:      http.MethodPatch,
+			contentType: `application/json`,
+			sign:        Sign([]byte(`{"state":"done"}`), []byte(`s3cret`)),
+			body:        `{"state":"done"}`,
+		},
+	}, {
+		desc: `PUT with body file and custom Content-Type`,
+		job: &JobHTTP{
+			HTTPMethod:      `PUT`,
+			HTTPRequestType: `form`,
+			HTTPHeaders:     []string{`Content-Type: application/xml`},
+			HTTPBodyFile:    `body.xml`,
+		},
+		exp: request{
+			method:      http.MethodPut,
+			contentType: `application/xml`,
+			body:        `<a>1</a>`,
+		},
+	}, {
+		desc: `HEAD without body`,
+		job: &JobHTTP{
+			HTTPMethod: `HEAD`,
+		},
+		exp: request{
+			method: http.MethodHead,
+			query:  `_karajo_epoch=1673222400`,
+		},
+	}, {
+		desc: `body and body file`,
+		job: &JobHTTP{
+			HTTPMethod:   `POST`,
+			HTTPBody:     `x`,
+			HTTPBodyFile: `body.xml`,
+		},
+		expError: `init: test: http_body and http_body_file cannot be used together`,
+	}, {
+		desc: `body file not exist`,
+		job: &JobHTTP{
+			HTTPMethod:   `POST`,
+			HTTPBodyFile: `missing.json`,
+		},
+		expError: `init: test: http_body_file: stat ` +
+			filepath.Join(dirBase, `missing.json`) +
+			`: no such file or directory`,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		c.job.HTTPURL = srv.URL + `/hook`
+
+		var env = &Env{
+			DirBase: dirBase,
+			HTTPJobs: map[string]*JobHTTP{
+				`test`: c.job,
+			},
+		}
+
+		err = env.init()
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+
+		gotReq = request{}
+		_, err = c.job.execute()
+		if err != nil {
+			t.Fatalf(`%s: %s`, c.desc, err)
+		}
+		test.Assert(t, c.desc, c.exp, gotReq)
+	}
+}
@@ -10,4 +10,16 @@ type jobKind string
 const (
 	jobKindExec jobKind = `job`
 	jobKindHTTP jobKind = `job_http`
+	jobKindSQL  jobKind = `job_sql`
 )
+
+// section return the name of INI section for the job kind.
+func (kind jobKind) section() string {
+	switch kind {
+	case jobKindHTTP:
+		return `job.http`
+	case jobKindSQL:
+		return `job.sql`
+	}
+	return `job`
+}
@@ -4,16 +4,37 @@
 package karajo
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"encoding/base64"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
 )
 
+const (
+	// defJobLogTailSize the number of bytes in the tail of spooled
+	// log kept in memory.
+	defJobLogTailSize = 64 << 10
+
+	// defJobLogReadSize the maximum number of bytes read from the
+	// spool at once.
+	defJobLogReadSize = 1 << 20
+)
+
+// jobLogTruncatedFormat the format of line in the log that replace the
+// content that is truncated due to [JobBase.LogMaxSize].
+const jobLogTruncatedFormat = `[... %d bytes truncated ...]`
+
 // JobLog contains the content, status, and counter for job's log.
 //
 // Each log file name is using the following format:
@@ -25,20 +46,104 @@ import (
 // Status can be success or fail.
 // If status is missing its considered fail.
 type JobLog struct {
+	// storage the external storage for the log.
+	// If its nil, the log is stored in the directory of path, see
+	// [JobLog.store].
+	storage Storage
+
 	jobKind jobKind
 	JobID   string `json:"job_id"`
 	Name    string `json:"name"`
 	path    string
 	Status  string `json:"status,omitempty"`
+
+	// Reason why the run is skipped, only set if the Status is
+	// skipped.
+	Reason string `json:"reason,omitempty"`
+
+	// Exit contains the exit information of the command that failed,
+	// only set if one of the JobExec Commands failed.
+	Exit *JobLogExit `json:"exit,omitempty"`
+
+	// Steps contains the status of each step, only set if the JobExec
+	// has Steps.
+	Steps []*JobLogStep `json:"steps,omitempty"`
+
+	// Category of failure, only set if the job failed and its log
+	// match with one of the [JobBase.FailureRule].
+	Category string `json:"category,omitempty"`
+
+	// Usage contains the resources used by the commands, only set if
+	// the JobExec Commands executed locally.
+	Usage *JobLogUsage `json:"usage,omitempty"`
+
+	// Reaped contains the ID of child processes that still running
+	// after the command exited, and has been killed.
+	Reaped []int `json:"reaped,omitempty"`
+
 	Content []byte `json:"content,omitempty"` // Only used to transfrom from/to JSON.
 	content []byte
 
+	// spool the file where the content is written while the job is
+	// running, so the output of command is not kept in memory.
+	// Once the log is flushed, the spool is closed and renamed to the
+	// log file, or uploaded to the storage.
+	spool *os.File
+
+	// onLog the function called on each write, set by the job
+	// middlewares.
+	onLog func(b []byte)
+
+	// envs the additional environment variables for commands, set by
+	// the job middlewares.
+	envs []string
+
+	// forward the session to forward each line to the remote
+	// collector, only set if the job has LogForward.
+	forward *logForwardSession
+
+	// tail contains the last bytes of content written into the spool,
+	// used to notify the job result without reading the log file.
+	tail []byte
+
+	// truncTail contains the last bytes of content after the spool
+	// reach half of maxSize, written into the spool once flushed.
+	truncTail []byte
+
+	// maxSize the maximum size of spool, see [JobBase.LogMaxSize].
+	maxSize int64
+
+	// truncated the number of bytes that is dropped from truncTail.
+	truncated int64
+
 	// listNotif contains list of notification where the job log will be
 	// send.
 	listNotif []string
 
+	// stderrRanges contains the start and end offset of content that
+	// are written from the standard error.
+	// This field is not stored, so its only available while the job is
+	// running or until karajo restarted.
+	stderrRanges [][2]int
+
+	// isLastStderr true if the last write is from standard error.
+	isLastStderr bool
+
+	// timeBegin the time when the job started.
+	// For log loaded from storage, it is parsed from the timestamp of
+	// the first line in the content.
+	timeBegin time.Time
+
+	// timeEnd the time when the job finished.
+	// For log loaded from storage, it is set to the file modification
+	// time.
+	timeEnd time.Time
+
 	Counter int64 `json:"counter,omitempty"`
 
+	// size of log content in storage.
+	size int64
+
 	sync.Mutex
 }
 
@@ -79,33 +184,484 @@ func parseJobLogName(dir, name string) (jlog *JobLog) {
 	return jlog
 }
 
+// openSpool create the file in path to write the content while the job
+// is running.
+func (jlog *JobLog) openSpool() (err error) {
+	jlog.spool, err = os.OpenFile(jlog.path, os.O_CREATE|os.O_TRUNC|os.O_RDWR, 0600)
+	if err != nil {
+		return fmt.Errorf(`openSpool: %w`, err)
+	}
+	return nil
+}
+
 func (jlog *JobLog) flush() (err error) {
+	jlog.forward.close()
+
 	jlog.Lock()
+	defer jlog.Unlock()
+
+	var spoolPath = jlog.path
 
 	jlog.Name = jlog.Name + `.` + jlog.Status
 	jlog.path = jlog.path + `.` + jlog.Status
-	err = os.WriteFile(jlog.path, jlog.content, 0600)
 
-	jlog.Unlock()
+	if jlog.spool != nil {
+		return jlog.flushSpool(spoolPath)
+	}
+
+	var storage, key = jlog.store()
+
+	err = storage.Put(key, jlog.content)
+	jlog.size = int64(len(jlog.content))
+
 	return err
 }
 
+// flushSpool close the spool and move it to the log file, or upload it
+// to the storage.
+func (jlog *JobLog) flushSpool(spoolPath string) (err error) {
+	err = jlog.writeTruncTail()
+	if err != nil {
+		_ = jlog.spool.Close()
+		jlog.spool = nil
+		return fmt.Errorf(`flushSpool: %w`, err)
+	}
+
+	err = jlog.spool.Close()
+	jlog.spool = nil
+	if err != nil {
+		return fmt.Errorf(`flushSpool: %w`, err)
+	}
+
+	var storage, key = jlog.store()
+
+	var fsys, isFS = storage.(*logStorageFS)
+	if isFS {
+		// The spool is already in the log directory, no need to
+		// read it.
+		err = fsys.rename(spoolPath, key)
+		if err != nil {
+			return fmt.Errorf(`flushSpool: %w`, err)
+		}
+		return nil
+	}
+
+	var content []byte
+
+	content, err = os.ReadFile(spoolPath)
+	if err == nil {
+		err = storage.Put(key, content)
+	}
+	_ = os.Remove(spoolPath)
+	if err != nil {
+		return fmt.Errorf(`flushSpool: %w`, err)
+	}
+	return nil
+}
+
 // load the content of log from storage.
+// If the stored log is compressed, the content is decompressed.
+// The content of running log is not loaded, since its still written to
+// the spool.
 func (jlog *JobLog) load() (err error) {
 	jlog.Lock()
-	if len(jlog.content) == 0 {
-		jlog.content, err = os.ReadFile(jlog.path)
-	}
+	err = jlog.loadContent()
 	jlog.Unlock()
 	return err
 }
 
+// loadContent load the content of log from storage, if its not loaded
+// yet.
+// The caller must hold the lock.
+func (jlog *JobLog) loadContent() (err error) {
+	if len(jlog.content) != 0 || jlog.spool != nil {
+		return nil
+	}
+
+	var content []byte
+
+	content, err = jlog.readStored()
+	if err != nil {
+		return err
+	}
+	jlog.content, err = decodeJobLog(content)
+	return err
+}
+
+// read return the copy of log content.
+// If the content is not loaded, it is read from storage and decompressed
+// without keeping it in memory.
+func (jlog *JobLog) read() (content []byte, err error) {
+	jlog.Lock()
+	defer jlog.Unlock()
+
+	if len(jlog.content) != 0 {
+		return bytes.Clone(jlog.content), nil
+	}
+	if jlog.spool != nil {
+		return os.ReadFile(jlog.spool.Name())
+	}
+	content, err = jlog.readStored()
+	if err != nil {
+		return nil, err
+	}
+	return decodeJobLog(content)
+}
+
+// readStored read the log as is from storage.
+func (jlog *JobLog) readStored() (content []byte, err error) {
+	var storage, key = jlog.store()
+	return storage.Get(key)
+}
+
+// compress the stored log using gzip.
+// The log name is not changed, the compressed log is detected using the
+// gzip header when its loaded.
+// If the log already compressed, it will return nil.
+func (jlog *JobLog) compress() (err error) {
+	jlog.Lock()
+	defer jlog.Unlock()
+
+	var content []byte
+
+	content, err = jlog.readStored()
+	if err != nil {
+		return fmt.Errorf(`compress %s: %w`, jlog.Name, err)
+	}
+	if isGzip(content) {
+		return nil
+	}
+
+	var (
+		buf bytes.Buffer
+		gzw = gzip.NewWriter(&buf)
+	)
+	_, err = gzw.Write(content)
+	if err == nil {
+		err = gzw.Close()
+	}
+	if err != nil {
+		return fmt.Errorf(`compress %s: %w`, jlog.Name, err)
+	}
+
+	var storage, key = jlog.store()
+
+	err = storage.Put(key, buf.Bytes())
+	if err != nil {
+		return fmt.Errorf(`compress %s: %w`, jlog.Name, err)
+	}
+	jlog.size = int64(buf.Len())
+	return nil
+}
+
+// isGzip return true if the content start with gzip header.
+func isGzip(content []byte) bool {
+	return len(content) >= 2 && content[0] == 0x1f && content[1] == 0x8b
+}
+
+// decodeJobLog return the decompressed content if its compressed using
+// gzip, otherwise it return the content as is.
+func decodeJobLog(content []byte) (raw []byte, err error) {
+	if !isGzip(content) {
+		return content, nil
+	}
+
+	var gzr *gzip.Reader
+
+	gzr, err = gzip.NewReader(bytes.NewReader(content))
+	if err != nil {
+		return nil, err
+	}
+	raw, err = io.ReadAll(gzr)
+	if err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
+
+// remove the log from storage.
+func (jlog *JobLog) remove() {
+	var (
+		storage, key = jlog.store()
+		err          = storage.Remove(key)
+	)
+	if err != nil {
+		mlog.Errf(`job log: %s`, err)
+	}
+}
+
+// logSize return the size of log content in storage, or the size of
+// content in memory if its not flushed yet.
+func (jlog *JobLog) logSize() (size int64) {
+	jlog.Lock()
+	size = jlog.size
+	if size == 0 {
+		size = int64(len(jlog.content))
+	}
+	jlog.Unlock()
+	return size
+}
+
+// store return the storage of log and its key.
+// If the external storage is not set, the log is stored in the local
+// file system, using the path as its directory and key.
+func (jlog *JobLog) store() (storage Storage, key string) {
+	if jlog.storage == nil {
+		return newLogStorageFS(filepath.Dir(jlog.path)), filepath.Base(jlog.path)
+	}
+	return jlog.storage, string(jlog.jobKind) + `/` + jlog.JobID + `/` + jlog.Name
+}
+
+// duration return the elapsed time between job started and finished.
+// It will return 0 if the log is not finished yet or the begin time
+// cannot be parsed from its content.
+func (jlog *JobLog) duration() (d time.Duration) {
+	jlog.Lock()
+	defer jlog.Unlock()
+
+	if jlog.timeEnd.IsZero() {
+		return 0
+	}
+	if jlog.timeBegin.IsZero() {
+		if len(jlog.content) != 0 {
+			jlog.timeBegin = parseJobLogTime(jlog.content)
+		} else {
+			jlog.timeBegin = jlog.readStoredTime()
+		}
+		if jlog.timeBegin.IsZero() {
+			return 0
+		}
+	}
+	d = jlog.timeEnd.Sub(jlog.timeBegin)
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// readStoredTime parse the begin time from the log in storage.
+// For log in the local file system, only the header of file is read.
+func (jlog *JobLog) readStoredTime() (t time.Time) {
+	var storage, key = jlog.store()
+
+	var fsys, isFS = storage.(*logStorageFS)
+	if isFS {
+		return readJobLogTime(fsys.path(key))
+	}
+
+	var content, _ = storage.Get(key)
+	content, _ = decodeJobLog(content)
+	return parseJobLogTime(content)
+}
+
+// jobLogHeaderSize the number of bytes read from the log file to parse
+// the timestamp of the first line.
+const jobLogHeaderSize = 64
+
+// readJobLogTime read only the header of the log file to parse its
+// begin time, so the log content is not loaded into memory.
+// The compressed log file is decompressed while reading.
+func readJobLogTime(path string) (t time.Time) {
+	var (
+		f   *os.File
+		err error
+	)
+	f, err = os.Open(path)
+	if err != nil {
+		return t
+	}
+	defer f.Close()
+
+	var (
+		br = bufio.NewReader(f)
+		r  io.Reader
+	)
+	r = br
+
+	var magic, _ = br.Peek(2)
+	if isGzip(magic) {
+		r, err = gzip.NewReader(br)
+		if err != nil {
+			return t
+		}
+	}
+
+	var (
+		header = make([]byte, jobLogHeaderSize)
+		n      int
+	)
+	n, _ = io.ReadFull(r, header)
+	return parseJobLogTime(header[:n])
+}
+
+// parseJobLogTime parse the timestamp at the beginning of the log content.
+// It will return zero time if the content does not start with timestamp.
+func parseJobLogTime(content []byte) (t time.Time) {
+	var fields = bytes.SplitN(content, []byte(` `), 4)
+	if len(fields) < 4 {
+		return t
+	}
+
+	var (
+		vstr = string(bytes.Join(fields[:3], []byte(` `)))
+		err  error
+	)
+	t, err = time.Parse(defTimeLayout, vstr)
+	if err != nil {
+		return time.Time{}
+	}
+	return t.UTC()
+}
+
+// jobLogSkipPrefix the prefix of line in the content of skipped log that
+// contains the reason.
+const jobLogSkipPrefix = `--- SKIPPED: `
+
+// loadReason read the reason of skipped log from its content.
+func (jlog *JobLog) loadReason() {
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+	var _, reason, found = bytes.Cut(content, []byte(jobLogSkipPrefix))
+	if !found {
+		return
+	}
+	reason, _, _ = bytes.Cut(reason, []byte("\n"))
+	jlog.Reason = string(reason)
+}
+
+// loadExit read the exit information of failed log from its content.
+func (jlog *JobLog) loadExit() {
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+	var _, line, found = bytes.Cut(content, []byte(jobLogExitPrefix))
+	if !found {
+		return
+	}
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+	jlog.Exit = parseJobLogExit(string(line))
+}
+
+// setExit set the exit information from the error of command at index
+// x, and write it into the log.
+func (jlog *JobLog) setExit(x int, err error) {
+	var exit = newJobLogExit(x, err)
+
+	jlog.Write([]byte(jobLogExitPrefix + exit.String() + "\n"))
+
+	jlog.Lock()
+	jlog.Exit = exit
+	jlog.Unlock()
+}
+
+// addUsage add the resource usage of the exited command.
+func (jlog *JobLog) addUsage(state *os.ProcessState) {
+	jlog.Lock()
+	if jlog.Usage == nil {
+		jlog.Usage = &JobLogUsage{}
+	}
+	jlog.Usage.add(processUsage(state))
+	jlog.Unlock()
+}
+
+// jobLogReapedPrefix the prefix of line in the content of log that
+// contains the ID of killed child processes.
+const jobLogReapedPrefix = `--- REAPED: `
+
+// addReaped record the ID of killed child processes into the log.
+// It will do nothing if pids is empty.
+func (jlog *JobLog) addReaped(pids []int) {
+	if len(pids) == 0 {
+		return
+	}
+
+	var (
+		fields = make([]string, 0, len(pids))
+		pid    int
+	)
+	for _, pid = range pids {
+		fields = append(fields, strconv.Itoa(pid))
+	}
+	jlog.Write([]byte(jobLogReapedPrefix + strings.Join(fields, ` `) + "\n"))
+
+	jlog.Lock()
+	jlog.Reaped = append(jlog.Reaped, pids...)
+	jlog.Unlock()
+}
+
+// loadReaped read the ID of killed child processes from its content.
+func (jlog *JobLog) loadReaped() {
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+
+	var (
+		line  []byte
+		found bool
+		field string
+		pid   int
+	)
+	for {
+		_, content, found = bytes.Cut(content, []byte(jobLogReapedPrefix))
+		if !found {
+			return
+		}
+		line, content, _ = bytes.Cut(content, []byte("\n"))
+		for _, field = range strings.Fields(string(line)) {
+			pid, err = strconv.Atoi(field)
+			if err != nil {
+				continue
+			}
+			jlog.Reaped = append(jlog.Reaped, pid)
+		}
+	}
+}
+
+// writeUsage set the wall time of usage based on the time when the job
+// finished, and write the usage into the log.
+// It will do nothing if there is no command executed.
+func (jlog *JobLog) writeUsage(timeEnd time.Time) {
+	jlog.Lock()
+	if jlog.Usage == nil {
+		jlog.Unlock()
+		return
+	}
+	jlog.Usage.Wall = timeEnd.Sub(jlog.timeBegin)
+	var line = jobLogUsagePrefix + jlog.Usage.String() + "\n"
+	jlog.Unlock()
+
+	jlog.Write([]byte(line))
+}
+
+// loadUsage read the resource usage of log from its content.
+func (jlog *JobLog) loadUsage() {
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+	var _, line, found = bytes.Cut(content, []byte(jobLogUsagePrefix))
+	if !found {
+		return
+	}
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+	jlog.Usage = parseJobLogUsage(string(line))
+}
+
 func (jlog *JobLog) marshalJSON() ([]byte, error) {
 	jlog.Lock()
 
+	var raw = jlog.content
+	if jlog.spool != nil {
+		raw, _ = os.ReadFile(jlog.spool.Name())
+	}
+
 	var (
 		buf     bytes.Buffer
-		content = base64.StdEncoding.EncodeToString(jlog.content)
+		content = base64.StdEncoding.EncodeToString(raw)
 	)
 
 	fmt.Fprintf(&buf, `{"job_id":%q,"name":%q,"status":%q,"counter":%d,"content":%q}`,
@@ -121,19 +677,373 @@ func (jlog *JobLog) setStatus(status string) {
 	jlog.Unlock()
 }
 
+// jobLogLine contains single line in the JobLog content.
+type jobLogLine struct {
+	text string
+
+	// next is the offset of the next line in the content.
+	next int
+
+	isStderr bool
+}
+
+// jobLogStderr write the standard error of command into JobLog.
+//
+// The standard output and error of command are read from different pipes,
+// so the order of their output in the log may be different with the order
+// when the command write them.
+// To prevent mixing the partial line from both streams, each write from
+// different stream start on the new line.
+type jobLogStderr struct {
+	jlog *JobLog
+}
+
+// Write the b into JobLog and mark it as standard error.
+func (w jobLogStderr) Write(b []byte) (n int, err error) {
+	return w.jlog.write(b, true)
+}
+
+// isFinished return true if the job has finished writing the log.
+func (jlog *JobLog) isFinished() bool {
+	switch jlog.Status {
+	case JobStatusRunning, JobStatusStarted:
+		return false
+	}
+	return true
+}
+
+// readLines return the complete lines in the content, start from offset.
+// It return the status of log and true if all of the content has been
+// read and no more content will be written.
+// If the log has finished, the last line without new line is returned as
+// well.
+func (jlog *JobLog) readLines(offset int) (lines []jobLogLine, status string, isEOF bool) {
+	jlog.Lock()
+	defer jlog.Unlock()
+
+	status = jlog.Status
+	var isFinished = jlog.isFinished()
+
+	if isFinished {
+		// The log may has been flushed while being read.
+		_ = jlog.loadContent()
+	}
+
+	var size = jlog.contentSize()
+	if offset < 0 || offset > size {
+		offset = size
+	}
+
+	var (
+		content, isPartial = jlog.contentAt(offset)
+
+		idx  int
+		line jobLogLine
+	)
+	for len(content) != 0 {
+		idx = bytes.IndexByte(content, '\n')
+		if idx < 0 {
+			if !isFinished && !isPartial {
+				break
+			}
+			idx = len(content) - 1
+		}
+
+		line = jobLogLine{
+			text:     strings.TrimRight(string(content[:idx+1]), "\r\n"),
+			next:     offset + idx + 1,
+			isStderr: jlog.isStderr(offset),
+		}
+		lines = append(lines, line)
+
+		offset = line.next
+		content = content[idx+1:]
+	}
+
+	isEOF = isFinished && len(content) == 0
+	return lines, status, isEOF
+}
+
+// contentSize return the size of content written so far.
+// The caller must hold the lock.
+func (jlog *JobLog) contentSize() int {
+	if jlog.spool != nil {
+		return int(jlog.size)
+	}
+	return len(jlog.content)
+}
+
+// contentAt return the content start from offset.
+// If the content is in the spool, at most defJobLogReadSize bytes is
+// read and isPartial is true if there are more content after it.
+// The caller must hold the lock.
+func (jlog *JobLog) contentAt(offset int) (content []byte, isPartial bool) {
+	if jlog.spool == nil {
+		return jlog.content[offset:], false
+	}
+
+	var size = int(jlog.size) - offset
+	if size > defJobLogReadSize {
+		size = defJobLogReadSize
+		isPartial = true
+	}
+
+	content = make([]byte, size)
+
+	var n, _ = jlog.spool.ReadAt(content, int64(offset))
+
+	return content[:n], isPartial
+}
+
+// Tail return the copy of the last bytes of log content, that can be used
+// by the custom NotifClient to notify the job result.
+func (jlog *JobLog) Tail() []byte {
+	return jlog.tailContent()
+}
+
+// tailContent return the copy of the last bytes of content, from the
+// tail of spool or from the content in memory.
+func (jlog *JobLog) tailContent() []byte {
+	jlog.Lock()
+	defer jlog.Unlock()
+
+	if len(jlog.content) != 0 {
+		return bytes.Clone(jlog.content)
+	}
+	return bytes.Clone(jlog.tail)
+}
+
+// isStderr return true if the content at offset is written from standard
+// error.
+func (jlog *JobLog) isStderr(offset int) bool {
+	var x = sort.Search(len(jlog.stderrRanges), func(x int) bool {
+		return jlog.stderrRanges[x][1] > offset
+	})
+	if x == len(jlog.stderrRanges) {
+		return false
+	}
+	return jlog.stderrRanges[x][0] <= offset
+}
+
 func (jlog *JobLog) Write(b []byte) (n int, err error) {
+	return jlog.write(b, false)
+}
+
+// write the b into spool or content, and pass it to the forward and
+// onLog.
+func (jlog *JobLog) write(b []byte, isStderr bool) (n int, err error) {
+	n, err = jlog.writeContent(b, isStderr)
+	if err != nil {
+		return n, err
+	}
+	if jlog.forward != nil {
+		jlog.forward.write(b, isStderr)
+	}
+	if jlog.onLog != nil {
+		jlog.onLog(b)
+	}
+	return n, nil
+}
+
+// writeContent write the b into spool or content.
+// Each new line is prefixed with the timestamp, job kind, and job ID.
+// If isStderr is true, the range of written content is recorded as
+// standard error.
+// If the last write is from different stream and does not end with new
+// line, the new line is added before b.
+func (jlog *JobLog) writeContent(b []byte, isStderr bool) (n int, err error) {
 	jlog.Lock()
-	n = len(jlog.content)
-	if n == 0 || n > 0 && jlog.content[n-1] == '\n' {
+	defer jlog.Unlock()
+
+	var (
+		size      = jlog.contentSize()
+		isNewLine = size == 0 || jlog.lastByte() == '\n'
+		chunk     []byte
+	)
+	if !isNewLine && isStderr != jlog.isLastStderr {
+		chunk = append(chunk, '\n')
+		isNewLine = true
+	}
+	jlog.isLastStderr = isStderr
+
+	var start = size + len(chunk)
+	if isNewLine {
 		var timestamp = timeNow().Format(defTimeLayout)
-		jlog.content = append(jlog.content, []byte(timestamp)...)
-		jlog.content = append(jlog.content, ' ')
-		jlog.content = append(jlog.content, []byte(jlog.jobKind)...)
-		jlog.content = append(jlog.content, []byte(": ")...)
-		jlog.content = append(jlog.content, []byte(jlog.JobID)...)
-		jlog.content = append(jlog.content, []byte(": ")...)
-	}
-	jlog.content = append(jlog.content, b...)
-	jlog.Unlock()
+		chunk = append(chunk, []byte(timestamp)...)
+		chunk = append(chunk, ' ')
+		chunk = append(chunk, []byte(jlog.jobKind)...)
+		chunk = append(chunk, []byte(": ")...)
+		chunk = append(chunk, []byte(jlog.JobID)...)
+		chunk = append(chunk, []byte(": ")...)
+	}
+	chunk = append(chunk, b...)
+
+	var written = len(chunk)
+	if jlog.spool == nil {
+		jlog.content = append(jlog.content, chunk...)
+	} else {
+		written, err = jlog.writeSpool(chunk)
+		if err != nil {
+			return 0, err
+		}
+		jlog.addTail(chunk)
+	}
+	if isStderr && size+written > start {
+		jlog.addStderrRange(start, size+written)
+	}
 	return len(b), nil
 }
+
+// writeLog append the content of other log as is, since each of its line
+// already prefixed, including the range of its standard error.
+func (jlog *JobLog) writeLog(other *JobLog) (err error) {
+	other.Lock()
+	var (
+		content      = other.content
+		stderrRanges = other.stderrRanges
+	)
+	other.Unlock()
+
+	if len(content) == 0 {
+		return nil
+	}
+
+	jlog.Lock()
+	defer jlog.Unlock()
+
+	var (
+		size  = jlog.contentSize()
+		chunk []byte
+	)
+	if size != 0 && jlog.lastByte() != '\n' {
+		chunk = append(chunk, '\n')
+	}
+	var start = size + len(chunk)
+	chunk = append(chunk, content...)
+
+	var written = len(chunk)
+	if jlog.spool == nil {
+		jlog.content = append(jlog.content, chunk...)
+	} else {
+		written, err = jlog.writeSpool(chunk)
+		if err != nil {
+			return err
+		}
+		jlog.addTail(chunk)
+	}
+
+	var (
+		end = size + written
+		rg  [2]int
+	)
+	for _, rg = range stderrRanges {
+		if start+rg[0] >= end {
+			break
+		}
+		jlog.addStderrRange(start+rg[0], min(start+rg[1], end))
+	}
+	jlog.isLastStderr = false
+	return nil
+}
+
+// writeSpool write the chunk into spool.
+// If the maxSize is set and the spool reach half of it, the rest of chunk
+// is kept in truncTail, to be written into spool once flushed.
+// It return the number of bytes written into spool.
+func (jlog *JobLog) writeSpool(chunk []byte) (n int, err error) {
+	var headSize = jlog.maxSize / 2
+	if jlog.maxSize > 0 && jlog.size+int64(len(chunk)) > headSize {
+		var room = headSize - jlog.size
+		if room < 0 {
+			room = 0
+		}
+		jlog.addTruncTail(chunk[room:])
+		chunk = chunk[:room]
+	}
+	n, err = jlog.spool.Write(chunk)
+	jlog.size += int64(n)
+	return n, err
+}
+
+// addTruncTail append b into truncTail, keeping only the last half of
+// maxSize bytes.
+func (jlog *JobLog) addTruncTail(b []byte) {
+	var tailSize = int(jlog.maxSize - jlog.maxSize/2)
+
+	jlog.truncTail = append(jlog.truncTail, b...)
+	if len(jlog.truncTail) > 2*tailSize {
+		var n = len(jlog.truncTail) - tailSize
+		jlog.truncated += int64(n)
+		jlog.truncTail = append([]byte(nil), jlog.truncTail[n:]...)
+	}
+}
+
+// writeTruncTail write the truncTail into spool.
+// If some of the content has been dropped, the truncation marker
+// "[... N bytes truncated ...]" is written before it.
+func (jlog *JobLog) writeTruncTail() (err error) {
+	if len(jlog.truncTail) == 0 {
+		return nil
+	}
+
+	var tailSize = int(jlog.maxSize - jlog.maxSize/2)
+	if len(jlog.truncTail) > tailSize {
+		var n = len(jlog.truncTail) - tailSize
+		jlog.truncated += int64(n)
+		jlog.truncTail = jlog.truncTail[n:]
+	}
+
+	var buf bytes.Buffer
+	if jlog.truncated > 0 {
+		var last = make([]byte, 1)
+		if jlog.size > 0 {
+			_, _ = jlog.spool.ReadAt(last, jlog.size-1)
+		}
+		if jlog.size > 0 && last[0] != '\n' {
+			buf.WriteByte('\n')
+		}
+		fmt.Fprintf(&buf, jobLogTruncatedFormat+"\n", jlog.truncated)
+	}
+	buf.Write(jlog.truncTail)
+
+	var n int
+
+	n, err = jlog.spool.Write(buf.Bytes())
+	jlog.size += int64(n)
+	jlog.truncTail = nil
+	return err
+}
+
+// lastByte return the last byte written into spool or content.
+// The caller must hold the lock.
+func (jlog *JobLog) lastByte() byte {
+	var last = jlog.content
+	if jlog.spool != nil {
+		last = jlog.tail
+	}
+	if len(last) == 0 {
+		return 0
+	}
+	return last[len(last)-1]
+}
+
+// addTail append the chunk into tail, keeping only the last
+// defJobLogTailSize bytes.
+func (jlog *JobLog) addTail(chunk []byte) {
+	jlog.tail = append(jlog.tail, chunk...)
+	if len(jlog.tail) > 2*defJobLogTailSize {
+		jlog.tail = append([]byte(nil), jlog.tail[len(jlog.tail)-defJobLogTailSize:]...)
+	}
+}
+
+// addStderrRange record the content from start to end as standard error.
+// If the range is continuation of the last range, the last range is
+// extended.
+func (jlog *JobLog) addStderrRange(start, end int) {
+	var n = len(jlog.stderrRanges)
+	if n > 0 && jlog.stderrRanges[n-1][1] == start {
+		jlog.stderrRanges[n-1][1] = end
+		return
+	}
+	jlog.stderrRanges = append(jlog.stderrRanges, [2]int{start, end})
+}
@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// jobLogCategoryPrefix the prefix of line in the content of failed log
+// that contains the failure category.
+const jobLogCategoryPrefix = `--- CATEGORY: `
+
+// jobFailureRule the compiled [JobBase.FailureRule].
+type jobFailureRule struct {
+	re       *regexp.Regexp
+	category string
+}
+
+// initFailureRules parse the FailureRule and NotifOnCategory.
+func (job *JobBase) initFailureRules() (err error) {
+	job.failureRules = nil
+	job.notifOnCategory = nil
+
+	var (
+		entry    string
+		category string
+		value    string
+		found    bool
+		re       *regexp.Regexp
+	)
+	for _, entry = range job.FailureRule {
+		category, value, found = strings.Cut(entry, `:`)
+		category = strings.ToLower(strings.TrimSpace(category))
+		value = strings.TrimSpace(value)
+		if !found || len(category) == 0 || len(value) == 0 {
+			return fmt.Errorf(`invalid failure_rule %q`, entry)
+		}
+		re, err = regexp.Compile(value)
+		if err != nil {
+			return fmt.Errorf(`invalid failure_rule %q: %w`, entry, err)
+		}
+		job.failureRules = append(job.failureRules, jobFailureRule{
+			re:       re,
+			category: category,
+		})
+	}
+
+	for _, entry = range job.NotifOnCategory {
+		category, value, found = strings.Cut(entry, `:`)
+		category = strings.ToLower(strings.TrimSpace(category))
+		value = strings.TrimSpace(value)
+		if !found || len(category) == 0 || len(value) == 0 {
+			return fmt.Errorf(`invalid notif_on_category %q`, entry)
+		}
+		if job.notifOnCategory == nil {
+			job.notifOnCategory = make(map[string][]string)
+		}
+		job.notifOnCategory[category] = append(job.notifOnCategory[category], value)
+	}
+	return nil
+}
+
+// classify set the Category of failed log using the first FailureRule
+// that match with its content, and write it into the log.
+// It will do nothing if no rule match.
+func (job *JobBase) classify(jlog *JobLog) {
+	if len(job.failureRules) == 0 {
+		return
+	}
+
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+
+	var rule jobFailureRule
+	for _, rule = range job.failureRules {
+		if !rule.re.Match(content) {
+			continue
+		}
+		jlog.Write([]byte(jobLogCategoryPrefix + rule.category + "\n"))
+
+		jlog.Lock()
+		jlog.Category = rule.category
+		jlog.Unlock()
+		return
+	}
+}
+
+// categoryNotif return the list of notification for the category of
+// jlog, excluding the one that already in the list.
+func (job *JobBase) categoryNotif(jlog *JobLog) (list []string) {
+	var name string
+	for _, name = range job.notifOnCategory[jlog.Category] {
+		if slices.Contains(jlog.listNotif, name) || slices.Contains(list, name) {
+			continue
+		}
+		list = append(list, name)
+	}
+	return list
+}
+
+// loadCategory read the failure category of log from its content.
+func (jlog *JobLog) loadCategory() {
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+	var _, line, found = bytes.Cut(content, []byte(jobLogCategoryPrefix))
+	if !found {
+		return
+	}
+	line, _, _ = bytes.Cut(line, []byte("\n"))
+	jlog.Category = string(line)
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobBase_initFailureRules(t *testing.T) {
+	type testCase struct {
+		desc      string
+		expError  string
+		rules     []string
+		notifs    []string
+		expNotifs map[string][]string
+	}
+
+	var cases = []testCase{{
+		desc: `empty`,
+	}, {
+		desc:   `valid`,
+		rules:  []string{`network:connection refused`, ` Disk-Full : no space left`},
+		notifs: []string{`disk-full:pager`, `DISK-FULL: mail`, `network:mail`},
+		expNotifs: map[string][]string{
+			`disk-full`: {`pager`, `mail`},
+			`network`:   {`mail`},
+		},
+	}, {
+		desc:     `missing pattern`,
+		rules:    []string{`network:`},
+		expError: `invalid failure_rule "network:"`,
+	}, {
+		desc:     `missing category`,
+		rules:    []string{`connection refused`},
+		expError: `invalid failure_rule "connection refused"`,
+	}, {
+		desc:     `invalid regex`,
+		rules:    []string{`auth:(denied`},
+		expError: "invalid failure_rule \"auth:(denied\": error parsing regexp: missing closing ): `(denied`",
+	}, {
+		desc:     `invalid notif_on_category`,
+		notifs:   []string{`pager`},
+		expError: `invalid notif_on_category "pager"`,
+	}}
+
+	var (
+		c   testCase
+		job *JobBase
+		err error
+	)
+	for _, c = range cases {
+		job = &JobBase{
+			FailureRule:     c.rules,
+			NotifOnCategory: c.notifs,
+		}
+		err = job.initFailureRules()
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc+`: rules`, len(c.rules), len(job.failureRules))
+		test.Assert(t, c.desc+`: notifs`, c.expNotifs, job.notifOnCategory)
+	}
+}
+
+func TestJobBase_classify(t *testing.T) {
+	var (
+		job = &JobBase{
+			FailureRule: []string{
+				`network:(?i)connection (refused|reset)`,
+				`auth:permission denied`,
+				`disk-full:no space left on device`,
+				`io:device`,
+			},
+			NotifOnFailed:   []string{`mail`},
+			NotifOnCategory: []string{`disk-full:pager`, `disk-full:mail`},
+		}
+		err error
+	)
+
+	err = job.initFailureRules()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		content     string
+		expCategory string
+		expNotif    []string
+	}
+
+	var cases = []testCase{{
+		content: "build ok\n",
+	}, {
+		content:     "dial tcp: Connection Refused\n",
+		expCategory: `network`,
+	}, {
+		// The first rule that match is used.
+		content:     "write: no space left on device\n",
+		expCategory: `disk-full`,
+		expNotif:    []string{`pager`},
+	}}
+
+	var (
+		c    testCase
+		jlog *JobLog
+		got  *JobLog
+	)
+	for _, c = range cases {
+		jlog = &JobLog{
+			Status:    JobStatusFailed,
+			content:   []byte(c.content),
+			listNotif: []string{`mail`},
+		}
+		job.classify(jlog)
+		test.Assert(t, c.content+`: Category`, c.expCategory, jlog.Category)
+		test.Assert(t, c.content+`: notif`, c.expNotif, job.categoryNotif(jlog))
+
+		// The category can be loaded back from the log content.
+		got = &JobLog{content: jlog.content}
+		got.loadCategory()
+		test.Assert(t, c.content+`: loadCategory`, c.expCategory, got.Category)
+	}
+}
@@ -0,0 +1,263 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defLogDiffContext the number of unchanged lines around each changes in
+// the log diff.
+const defLogDiffContext = 3
+
+// maxLogDiffCells the maximum size of table, number of different lines
+// in the first log times the second log, to compute the longest common
+// lines.
+// If the logs differ more than this, all of the different lines are
+// reported as removed and added.
+const maxLogDiffCells = 4 << 20
+
+// List of operation in the log diff.
+const (
+	logDiffEqual = ' '
+	logDiffDel   = '-'
+	logDiffAdd   = '+'
+)
+
+// logDiffLine the line in the log diff with its operation.
+type logDiffLine struct {
+	text string
+	op   byte
+}
+
+// diffLogs return the unified diff between the log content a and b,
+// with the name of each log in the header.
+// The timestamp on each line is removed before compared, so only the
+// output is compared.
+// It return empty if both logs have the same lines.
+func diffLogs(nameA string, a []byte, nameB string, b []byte) (out []byte) {
+	var (
+		linesA = logDiffLines(a)
+		linesB = logDiffLines(b)
+		script = logDiffScript(linesA, linesB)
+	)
+
+	var (
+		buf       bytes.Buffer
+		hasChange bool
+		line      logDiffLine
+	)
+	for _, line = range script {
+		if line.op != logDiffEqual {
+			hasChange = true
+			break
+		}
+	}
+	if !hasChange {
+		return nil
+	}
+
+	fmt.Fprintf(&buf, "--- %s\n+++ %s\n", nameA, nameB)
+
+	var (
+		x    int
+		end  int
+		posA = 1
+		posB = 1
+	)
+	for x < len(script) {
+		if script[x].op == logDiffEqual {
+			posA++
+			posB++
+			x++
+			continue
+		}
+
+		// Found the change, include the previous lines as context.
+		var start = max(x-defLogDiffContext, 0)
+		var startA = posA - (x - start)
+		var startB = posB - (x - start)
+
+		// Extend the hunk until there is more than twice of
+		// context unchanged lines, so the changes that close to each
+		// other are merged into one hunk.
+		end = x
+		var equal int
+		for end < len(script) {
+			if script[end].op == logDiffEqual {
+				if equal == 2*defLogDiffContext {
+					break
+				}
+				equal++
+			} else {
+				equal = 0
+			}
+			end++
+		}
+		end -= max(equal-defLogDiffContext, 0)
+
+		var lenA, lenB int
+		for _, line = range script[start:end] {
+			if line.op != logDiffAdd {
+				lenA++
+			}
+			if line.op != logDiffDel {
+				lenB++
+			}
+		}
+		fmt.Fprintf(&buf, "@@ -%s +%s @@\n",
+			logDiffRange(startA, lenA), logDiffRange(startB, lenB))
+		for _, line = range script[start:end] {
+			buf.WriteByte(line.op)
+			buf.WriteString(line.text)
+			buf.WriteByte('\n')
+		}
+
+		for ; x < end; x++ {
+			if script[x].op != logDiffAdd {
+				posA++
+			}
+			if script[x].op != logDiffDel {
+				posB++
+			}
+		}
+	}
+	return buf.Bytes()
+}
+
+// logDiffRange format the start line and the number of lines in hunk
+// header.
+func logDiffRange(start, n int) string {
+	if n == 0 {
+		// The empty range refer to the line before it.
+		return fmt.Sprintf(`%d,0`, start-1)
+	}
+	if n == 1 {
+		return fmt.Sprintf(`%d`, start)
+	}
+	return fmt.Sprintf(`%d,%d`, start, n)
+}
+
+// logDiffLines split the log content into lines, with the timestamp on
+// each line removed.
+func logDiffLines(content []byte) (lines []string) {
+	var str = strings.TrimSuffix(string(content), "\n")
+	if len(str) == 0 {
+		return nil
+	}
+	lines = strings.Split(str, "\n")
+
+	var x int
+	for x = range lines {
+		lines[x] = stripLogTimestamp(lines[x])
+	}
+	return lines
+}
+
+// stripLogTimestamp remove the timestamp prefix, in the format of
+// defTimeLayout, from the log line.
+func stripLogTimestamp(line string) string {
+	var (
+		fields = strings.SplitN(line, ` `, 4)
+		err    error
+	)
+	if len(fields) != 4 {
+		return line
+	}
+	_, err = time.Parse(defTimeLayout, strings.Join(fields[:3], ` `))
+	if err != nil {
+		return line
+	}
+	return fields[3]
+}
+
+// logDiffScript return the list of lines to change a into b, using the
+// longest common lines between them.
+func logDiffScript(a, b []string) (script []logDiffLine) {
+	// Skip the same lines at the beginning and the end.
+	var prefix int
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	var suffix int
+	for suffix < len(a)-prefix && suffix < len(b)-prefix &&
+		a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	var (
+		midA = a[prefix : len(a)-suffix]
+		midB = b[prefix : len(b)-suffix]
+		line string
+	)
+
+	for _, line = range a[:prefix] {
+		script = append(script, logDiffLine{op: logDiffEqual, text: line})
+	}
+
+	if len(midA)*len(midB) > maxLogDiffCells {
+		for _, line = range midA {
+			script = append(script, logDiffLine{op: logDiffDel, text: line})
+		}
+		for _, line = range midB {
+			script = append(script, logDiffLine{op: logDiffAdd, text: line})
+		}
+	} else {
+		script = append(script, logDiffLCS(midA, midB)...)
+	}
+
+	for _, line = range a[len(a)-suffix:] {
+		script = append(script, logDiffLine{op: logDiffEqual, text: line})
+	}
+	return script
+}
+
+// logDiffLCS return the list of lines to change a into b using the table
+// of longest common subsequence.
+func logDiffLCS(a, b []string) (script []logDiffLine) {
+	var (
+		cols  = len(b) + 1
+		table = make([]int32, (len(a)+1)*cols)
+		x     int
+		y     int
+	)
+
+	// The table[x][y] contains the length of longest common lines
+	// between a[x:] and b[y:].
+	for x = len(a) - 1; x >= 0; x-- {
+		for y = len(b) - 1; y >= 0; y-- {
+			if a[x] == b[y] {
+				table[x*cols+y] = table[(x+1)*cols+y+1] + 1
+			} else {
+				table[x*cols+y] = max(table[(x+1)*cols+y], table[x*cols+y+1])
+			}
+		}
+	}
+
+	x, y = 0, 0
+	for x < len(a) && y < len(b) {
+		switch {
+		case a[x] == b[y]:
+			script = append(script, logDiffLine{op: logDiffEqual, text: a[x]})
+			x++
+			y++
+		case table[(x+1)*cols+y] >= table[x*cols+y+1]:
+			script = append(script, logDiffLine{op: logDiffDel, text: a[x]})
+			x++
+		default:
+			script = append(script, logDiffLine{op: logDiffAdd, text: b[y]})
+			y++
+		}
+	}
+	for ; x < len(a); x++ {
+		script = append(script, logDiffLine{op: logDiffDel, text: a[x]})
+	}
+	for ; y < len(b); y++ {
+		script = append(script, logDiffLine{op: logDiffAdd, text: b[y]})
+	}
+	return script
+}
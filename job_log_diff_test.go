@@ -0,0 +1,139 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestDiffLogs(t *testing.T) {
+	type testCase struct {
+		desc string
+		a    string
+		b    string
+		exp  string
+	}
+
+	var cases = []testCase{{
+		desc: `same lines with different timestamp`,
+		a: "2023-01-09 00:00:00 UTC job: test: === BEGIN\n" +
+			"2023-01-09 00:00:01 UTC job: test: ok\n",
+		b: "2023-01-10 10:00:00 UTC job: test: === BEGIN\n" +
+			"2023-01-10 10:00:05 UTC job: test: ok\n",
+	}, {
+		desc: `changed line`,
+		a: "2023-01-09 00:00:00 UTC job: test: === BEGIN\n" +
+			"2023-01-09 00:00:01 UTC job: test: ok\n" +
+			"2023-01-09 00:00:02 UTC job: test: === job: test: finished.\n",
+		b: "2023-01-10 00:00:00 UTC job: test: === BEGIN\n" +
+			"2023-01-10 00:00:01 UTC job: test: connection refused\n" +
+			"2023-01-10 00:00:02 UTC job: test: === job: test: failed.\n",
+		exp: "--- test.1.success\n" +
+			"+++ test.2.fail\n" +
+			"@@ -1,3 +1,3 @@\n" +
+			" job: test: === BEGIN\n" +
+			"-job: test: ok\n" +
+			"-job: test: === job: test: finished.\n" +
+			"+job: test: connection refused\n" +
+			"+job: test: === job: test: failed.\n",
+	}, {
+		desc: `from empty`,
+		b:    "2023-01-10 00:00:00 UTC job: test: === BEGIN\n",
+		exp: "--- test.1.success\n" +
+			"+++ test.2.fail\n" +
+			"@@ -0,0 +1 @@\n" +
+			"+job: test: === BEGIN\n",
+	}, {
+		desc: `line without timestamp`,
+		a:    "line 1\nline 2",
+		b:    "line 1\nline 3",
+		exp: "--- test.1.success\n" +
+			"+++ test.2.fail\n" +
+			"@@ -1,2 +1,2 @@\n" +
+			" line 1\n" +
+			"-line 2\n" +
+			"+line 3\n",
+	}}
+
+	var (
+		c   testCase
+		got []byte
+	)
+	for _, c = range cases {
+		got = diffLogs(`test.1.success`, []byte(c.a), `test.2.fail`, []byte(c.b))
+		test.Assert(t, c.desc, c.exp, string(got))
+	}
+}
+
+func TestDiffLogs_hunks(t *testing.T) {
+	var (
+		a strings.Builder
+		b strings.Builder
+		x int
+	)
+	for x = 1; x <= 20; x++ {
+		fmt.Fprintf(&a, "line %d\n", x)
+		switch x {
+		case 2, 5, 18:
+			fmt.Fprintf(&b, "changed %d\n", x)
+		default:
+			fmt.Fprintf(&b, "line %d\n", x)
+		}
+	}
+
+	var exp = "--- a\n" +
+		"+++ b\n" +
+		"@@ -1,8 +1,8 @@\n" +
+		" line 1\n" +
+		"-line 2\n" +
+		"+changed 2\n" +
+		" line 3\n" +
+		" line 4\n" +
+		"-line 5\n" +
+		"+changed 5\n" +
+		" line 6\n" +
+		" line 7\n" +
+		" line 8\n" +
+		"@@ -15,6 +15,6 @@\n" +
+		" line 15\n" +
+		" line 16\n" +
+		" line 17\n" +
+		"-line 18\n" +
+		"+changed 18\n" +
+		" line 19\n" +
+		" line 20\n"
+
+	var got = diffLogs(`a`, []byte(a.String()), `b`, []byte(b.String()))
+	test.Assert(t, `hunks`, exp, string(got))
+}
+
+func TestStripLogTimestamp(t *testing.T) {
+	type testCase struct {
+		line string
+		exp  string
+	}
+
+	var cases = []testCase{{
+		line: `2023-01-09 00:00:00 UTC job: test: ok`,
+		exp:  `job: test: ok`,
+	}, {
+		line: `2023-01-09 07:00:00 WIB job: test: ok`,
+		exp:  `job: test: ok`,
+	}, {
+		line: `not a timestamp at all`,
+		exp:  `not a timestamp at all`,
+	}, {
+		line: `short`,
+		exp:  `short`,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.line, c.exp, stripLogTimestamp(c.line))
+	}
+}
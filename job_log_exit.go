@@ -0,0 +1,86 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// jobLogExitPrefix the prefix of line in the content of failed log that
+// contains the exit information.
+const jobLogExitPrefix = `--- EXIT: `
+
+// JobLogExit contains the exit information of the command that failed.
+type JobLogExit struct {
+	// Signal the name of signal that terminate the command, if any,
+	// for example "killed".
+	Signal string `json:"signal,omitempty"`
+
+	// Code the exit code of command, or -1 if the command is
+	// terminated by signal or cannot be started.
+	Code int `json:"code"`
+
+	// Command the index of the failed command in the JobExec
+	// Commands, or in the commands of all Steps, start from 0.
+	Command int `json:"command"`
+}
+
+// newJobLogExit create the JobLogExit from the error returned by
+// running the command at index x.
+func newJobLogExit(x int, err error) (exit *JobLogExit) {
+	exit = &JobLogExit{
+		Command: x,
+		Code:    -1,
+	}
+
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		return exit
+	}
+
+	exit.Code = exitErr.ExitCode()
+
+	var ws, ok = exitErr.Sys().(syscall.WaitStatus)
+	if ok && ws.Signaled() {
+		exit.Signal = ws.Signal().String()
+	}
+	return exit
+}
+
+// parseJobLogExit parse the exit information from the string generated
+// by [JobLogExit.String].
+// It will return nil if the line is not valid.
+func parseJobLogExit(line string) (exit *JobLogExit) {
+	exit = &JobLogExit{}
+
+	var (
+		format = `command %d exit with code %d`
+		signal string
+		found  bool
+	)
+
+	line, signal, found = strings.Cut(line, `, signal `)
+	if found {
+		exit.Signal = signal
+	}
+
+	var _, err = fmt.Sscanf(line, format, &exit.Command, &exit.Code)
+	if err != nil {
+		return nil
+	}
+	return exit
+}
+
+// String return the exit information in human readable format.
+func (exit *JobLogExit) String() (s string) {
+	s = fmt.Sprintf(`command %d exit with code %d`, exit.Command, exit.Code)
+	if len(exit.Signal) != 0 {
+		s += `, signal ` + exit.Signal
+	}
+	return s
+}
@@ -0,0 +1,57 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"os/exec"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestNewJobLogExit(t *testing.T) {
+	type testCase struct {
+		err    error
+		exp    *JobLogExit
+		expStr string
+	}
+
+	var cases = []testCase{{
+		err: exec.Command(`/bin/sh`, `-c`, `exit 3`).Run(),
+		exp: &JobLogExit{
+			Command: 1,
+			Code:    3,
+		},
+		expStr: `command 1 exit with code 3`,
+	}, {
+		err: exec.Command(`/bin/sh`, `-c`, `kill -9 $$`).Run(),
+		exp: &JobLogExit{
+			Command: 1,
+			Code:    -1,
+			Signal:  `killed`,
+		},
+		expStr: `command 1 exit with code -1, signal killed`,
+	}, {
+		err: errors.New(`exec: not started`),
+		exp: &JobLogExit{
+			Command: 1,
+			Code:    -1,
+		},
+		expStr: `command 1 exit with code -1`,
+	}}
+
+	var (
+		c   testCase
+		got *JobLogExit
+	)
+	for _, c = range cases {
+		got = newJobLogExit(1, c.err)
+		test.Assert(t, c.expStr, c.exp, got)
+		test.Assert(t, `String`, c.expStr, got.String())
+		test.Assert(t, `parseJobLogExit`, c.exp, parseJobLogExit(got.String()))
+	}
+
+	test.Assert(t, `parseJobLogExit invalid`, (*JobLogExit)(nil), parseJobLogExit(`invalid`))
+}
@@ -0,0 +1,449 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// List of scheme for [JobBase.LogForward].
+const (
+	logForwardSchemeLoki   = `loki`
+	logForwardSchemeSyslog = `syslog`
+	logForwardSchemeTCP    = `tcp`
+	logForwardSchemeUDP    = `udp`
+)
+
+const (
+	// defLogForwardSyslogPort the default port for syslog, tcp, and
+	// udp scheme.
+	defLogForwardSyslogPort = `514`
+
+	// defLogForwardLokiPort the default port for loki scheme.
+	defLogForwardLokiPort = `3100`
+
+	// defLogForwardLokiPath the path of Loki push API.
+	defLogForwardLokiPath = `/loki/api/v1/push`
+
+	// defLogForwardQueue the maximum number of lines waiting to be
+	// forwarded, the next line is dropped.
+	defLogForwardQueue = 1024
+
+	// defLogForwardBatch the maximum number of lines send in one Loki
+	// push request.
+	defLogForwardBatch = 512
+
+	// defLogForwardTimeout the timeout for connecting and sending the
+	// lines to collector.
+	defLogForwardTimeout = 10 * time.Second
+)
+
+// List of syslog severity and facility, see RFC 5424 section 6.2.1.
+const (
+	syslogFacilityUser = 1
+	syslogSeverityErr  = 3
+	syslogSeverityInfo = 6
+)
+
+// logForwardSDID the SD-ID of structured data in syslog message, using
+// the private enterprise number reserved for documentation.
+const logForwardSDID = `karajo@32473`
+
+// logForwarder ship each line of job log to the remote collector, in
+// syslog RFC 5424 format or to the Loki push API.
+type logForwarder struct {
+	httpc *http.Client
+
+	// scheme of forwarder, where "syslog" is normalized to "udp".
+	scheme string
+
+	// address of syslog collector in "host:port" format, or the URL of
+	// Loki push API.
+	address string
+
+	hostname string
+}
+
+// newLogForwarder create new logForwarder from URL in the format
+// "<scheme>://<host>[:port][/path]".
+// The scheme "syslog" and "udp" send each line as UDP datagram, and
+// "tcp" send each line using octet counting framing (RFC 6587), with
+// default port 514.
+// The scheme "loki" push the lines to the Loki server using HTTP, with
+// default port 3100 and path "/loki/api/v1/push".
+func newLogForwarder(rawURL string) (fwd *logForwarder, err error) {
+	var logp = `newLogForwarder`
+
+	var u *url.URL
+
+	u, err = url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if len(u.Hostname()) == 0 {
+		return nil, fmt.Errorf(`%s: %q: empty host`, logp, rawURL)
+	}
+
+	fwd = &logForwarder{
+		scheme: strings.ToLower(u.Scheme),
+	}
+
+	fwd.hostname, err = os.Hostname()
+	if err != nil || len(fwd.hostname) == 0 {
+		fwd.hostname = `-`
+	}
+
+	var port = u.Port()
+
+	switch fwd.scheme {
+	case logForwardSchemeSyslog, logForwardSchemeTCP, logForwardSchemeUDP:
+		if fwd.scheme == logForwardSchemeSyslog {
+			fwd.scheme = logForwardSchemeUDP
+		}
+		if len(port) == 0 {
+			port = defLogForwardSyslogPort
+		}
+		fwd.address = net.JoinHostPort(u.Hostname(), port)
+
+	case logForwardSchemeLoki:
+		if len(port) == 0 {
+			port = defLogForwardLokiPort
+		}
+		var path = u.Path
+		if len(path) == 0 || path == `/` {
+			path = defLogForwardLokiPath
+		}
+		fwd.address = `http://` + net.JoinHostPort(u.Hostname(), port) + path
+		fwd.httpc = &http.Client{
+			Timeout: defLogForwardTimeout,
+		}
+
+	default:
+		return nil, fmt.Errorf(`%s: %q: unknown scheme %q`, logp, rawURL, u.Scheme)
+	}
+	return fwd, nil
+}
+
+// open create new session to forward the lines of jlog.
+// It return nil if the fwd is nil.
+func (fwd *logForwarder) open(jlog *JobLog) (sess *logForwardSession) {
+	if fwd == nil {
+		return nil
+	}
+	sess = &logForwardSession{
+		fwd:     fwd,
+		jobKind: string(jlog.jobKind),
+		jobID:   jlog.JobID,
+		counter: jlog.Counter,
+		lineq:   make(chan logForwardLine, defLogForwardQueue),
+		done:    make(chan struct{}),
+	}
+	go sess.run()
+	return sess
+}
+
+// logForwardLine a line in job log, without the new line.
+type logForwardLine struct {
+	time     time.Time
+	text     string
+	isStderr bool
+}
+
+// logForwardSession forward the lines of a job log, from the time the
+// log created until its flushed.
+type logForwardSession struct {
+	fwd *logForwarder
+
+	lineq chan logForwardLine
+
+	// done closed once all of the lines has been forwarded.
+	done chan struct{}
+
+	jobKind string
+	jobID   string
+
+	// partial contains the last line that does not end with new line,
+	// for standard output and standard error.
+	partial [2][]byte
+
+	counter int64
+	dropped int
+
+	mtx      sync.Mutex
+	isClosed bool
+}
+
+// write split the b into lines and queue it to be forwarded.
+// The line that does not end with new line is queued on the next write
+// or when the session closed.
+func (sess *logForwardSession) write(b []byte, isStderr bool) {
+	sess.mtx.Lock()
+	defer sess.mtx.Unlock()
+
+	if sess.isClosed {
+		return
+	}
+
+	var idx int
+	if isStderr {
+		idx = 1
+	}
+
+	var (
+		buf = append(sess.partial[idx], b...)
+		now = timeNow()
+		x   int
+	)
+	for {
+		x = bytes.IndexByte(buf, '\n')
+		if x < 0 {
+			break
+		}
+		sess.queue(logForwardLine{
+			time:     now,
+			text:     string(buf[:x]),
+			isStderr: isStderr,
+		})
+		buf = buf[x+1:]
+	}
+	sess.partial[idx] = bytes.Clone(buf)
+}
+
+// queue push the line into queue, or drop it if the queue is full.
+// The caller must hold the mutex.
+func (sess *logForwardSession) queue(line logForwardLine) {
+	select {
+	case sess.lineq <- line:
+	default:
+		sess.dropped++
+	}
+}
+
+// close queue the remaining partial lines and stop the session once all
+// of the lines has been forwarded.
+func (sess *logForwardSession) close() {
+	if sess == nil {
+		return
+	}
+
+	sess.mtx.Lock()
+	if sess.isClosed {
+		sess.mtx.Unlock()
+		return
+	}
+	sess.isClosed = true
+
+	var (
+		now = timeNow()
+		buf []byte
+		idx int
+	)
+	for idx, buf = range sess.partial {
+		if len(buf) == 0 {
+			continue
+		}
+		sess.queue(logForwardLine{
+			time:     now,
+			text:     string(buf),
+			isStderr: idx == 1,
+		})
+		sess.partial[idx] = nil
+	}
+	if sess.dropped > 0 {
+		mlog.Errf(`%s: %s: #%d: log_forward: %d lines dropped`,
+			sess.jobKind, sess.jobID, sess.counter, sess.dropped)
+	}
+	close(sess.lineq)
+	sess.mtx.Unlock()
+}
+
+// run forward the queued lines until the session closed.
+func (sess *logForwardSession) run() {
+	defer close(sess.done)
+
+	var (
+		conn     net.Conn
+		batch    []logForwardLine
+		line     logForwardLine
+		isFailed bool
+		err      error
+		ok       bool
+	)
+	for line = range sess.lineq {
+		batch = append(batch[:0], line)
+	drain:
+		for len(batch) < defLogForwardBatch {
+			select {
+			case line, ok = <-sess.lineq:
+				if !ok {
+					break drain
+				}
+				batch = append(batch, line)
+			default:
+				break drain
+			}
+		}
+
+		if sess.fwd.scheme == logForwardSchemeLoki {
+			err = sess.pushLoki(batch)
+		} else {
+			conn, err = sess.sendSyslog(conn, batch)
+		}
+		if err != nil && !isFailed {
+			// Log the error only once for each session.
+			mlog.Errf(`%s: %s: #%d: log_forward: %s`,
+				sess.jobKind, sess.jobID, sess.counter, err)
+			isFailed = true
+		}
+	}
+	if conn != nil {
+		_ = conn.Close()
+	}
+}
+
+// sendSyslog send each line in batch as syslog message using the conn.
+// If conn is nil, or the previous send failed, new connection is created.
+func (sess *logForwardSession) sendSyslog(conn net.Conn, batch []logForwardLine) (_ net.Conn, err error) {
+	if conn == nil {
+		conn, err = net.DialTimeout(sess.fwd.scheme, sess.fwd.address, defLogForwardTimeout)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var (
+		line logForwardLine
+		msg  []byte
+	)
+	for _, line = range batch {
+		msg = sess.syslogMessage(line)
+		if sess.fwd.scheme == logForwardSchemeTCP {
+			msg = append([]byte(strconv.Itoa(len(msg))+` `), msg...)
+		}
+		_ = conn.SetWriteDeadline(time.Now().Add(defLogForwardTimeout))
+		_, err = conn.Write(msg)
+		if err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+	return conn, nil
+}
+
+// syslogMessage format the line as syslog message based on RFC 5424,
+// with the job kind as MSGID and the job ID and counter as structured
+// data.
+func (sess *logForwardSession) syslogMessage(line logForwardLine) []byte {
+	var severity = syslogSeverityInfo
+	if line.isStderr {
+		severity = syslogSeverityErr
+	}
+
+	var msg bytes.Buffer
+
+	fmt.Fprintf(&msg, `<%d>1 %s %s karajo - %s [%s job_id="%s" counter="%d"] %s`,
+		syslogFacilityUser*8+severity,
+		line.time.UTC().Format(`2006-01-02T15:04:05.000000Z07:00`),
+		sess.fwd.hostname,
+		sess.jobKind,
+		logForwardSDID,
+		syslogEscape(sess.jobID),
+		sess.counter,
+		line.text,
+	)
+	return msg.Bytes()
+}
+
+// syslogEscape escape the characters '"', '\', and ']' in the value of
+// structured data.
+func syslogEscape(v string) string {
+	var r = strings.NewReplacer(`\`, `\\`, `"`, `\"`, `]`, `\]`)
+	return r.Replace(v)
+}
+
+// lokiPush the request body of Loki push API.
+type lokiPush struct {
+	Streams []lokiStream `json:"streams"`
+}
+
+type lokiStream struct {
+	Stream map[string]string `json:"stream"`
+	Values [][]any           `json:"values"`
+}
+
+// pushLoki send the lines in batch to the Loki push API, grouped by its
+// output stream, with the job counter as structured metadata.
+func (sess *logForwardSession) pushLoki(batch []logForwardLine) (err error) {
+	var (
+		streams = [2]lokiStream{{
+			Stream: sess.lokiLabels(`stdout`),
+		}, {
+			Stream: sess.lokiLabels(`stderr`),
+		}}
+		meta = map[string]string{
+			`counter`: strconv.FormatInt(sess.counter, 10),
+		}
+
+		line   logForwardLine
+		stream lokiStream
+		idx    int
+	)
+	for _, line = range batch {
+		idx = 0
+		if line.isStderr {
+			idx = 1
+		}
+		streams[idx].Values = append(streams[idx].Values, []any{
+			strconv.FormatInt(line.time.UnixNano(), 10),
+			line.text,
+			meta,
+		})
+	}
+
+	var push lokiPush
+	for _, stream = range streams {
+		if len(stream.Values) != 0 {
+			push.Streams = append(push.Streams, stream)
+		}
+	}
+
+	var body []byte
+
+	body, err = json.Marshal(&push)
+	if err != nil {
+		return err
+	}
+
+	var httpReq *http.Request
+
+	httpReq, err = http.NewRequest(http.MethodPost, sess.fwd.address, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	httpReq.Header.Set(`Content-Type`, `application/json`)
+
+	return doPush(sess.fwd.httpc, httpReq)
+}
+
+// lokiLabels return the labels of Loki stream for the job.
+func (sess *logForwardSession) lokiLabels(stream string) map[string]string {
+	return map[string]string{
+		`service_name`: `karajo`,
+		`job_kind`:     sess.jobKind,
+		`job_id`:       sess.jobID,
+		`stream`:       stream,
+	}
+}
@@ -0,0 +1,231 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestNewLogForwarder(t *testing.T) {
+	type testCase struct {
+		rawURL     string
+		expScheme  string
+		expAddress string
+		expError   string
+	}
+
+	var cases = []testCase{{
+		rawURL:     `syslog://127.0.0.1`,
+		expScheme:  `udp`,
+		expAddress: `127.0.0.1:514`,
+	}, {
+		rawURL:     `TCP://collector:6514`,
+		expScheme:  `tcp`,
+		expAddress: `collector:6514`,
+	}, {
+		rawURL:     `loki://loki`,
+		expScheme:  `loki`,
+		expAddress: `http://loki:3100/loki/api/v1/push`,
+	}, {
+		rawURL:     `loki://loki:80/api/push`,
+		expScheme:  `loki`,
+		expAddress: `http://loki:80/api/push`,
+	}, {
+		rawURL:   `udp://`,
+		expError: `newLogForwarder: "udp://": empty host`,
+	}, {
+		rawURL:   `http://collector`,
+		expError: `newLogForwarder: "http://collector": unknown scheme "http"`,
+	}}
+
+	var (
+		c   testCase
+		fwd *logForwarder
+		err error
+	)
+	for _, c = range cases {
+		fwd, err = newLogForwarder(c.rawURL)
+		if err != nil {
+			test.Assert(t, c.rawURL, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.rawURL+`: scheme`, c.expScheme, fwd.scheme)
+		test.Assert(t, c.rawURL+`: address`, c.expAddress, fwd.address)
+	}
+}
+
+// writeForwardLog write the lines into the new log with forwarder and
+// wait until all of lines has been forwarded.
+func writeForwardLog(fwd *logForwarder) {
+	var jlog = &JobLog{
+		jobKind: jobKindExec,
+		JobID:   `backup`,
+		Counter: 3,
+	}
+	jlog.forward = fwd.open(jlog)
+
+	_, _ = jlog.write([]byte("hello\nwor"), false)
+	_, _ = jlog.write([]byte("oops"), true)
+	_, _ = jlog.write([]byte("ld\n"), false)
+
+	jlog.forward.close()
+	<-jlog.forward.done
+}
+
+func TestLogForwarder_syslog(t *testing.T) {
+	var (
+		now = timeNow().UTC().Format(`2006-01-02T15:04:05.000000Z07:00`)
+		exp = []string{
+			fmt.Sprintf(`<14>1 %s host karajo - job [karajo@32473 job_id="backup" counter="3"] hello`, now),
+			fmt.Sprintf(`<14>1 %s host karajo - job [karajo@32473 job_id="backup" counter="3"] world`, now),
+			fmt.Sprintf(`<11>1 %s host karajo - job [karajo@32473 job_id="backup" counter="3"] oops`, now),
+		}
+
+		fwd *logForwarder
+		err error
+	)
+
+	t.Run(`udp`, func(tt *testing.T) {
+		var pconn net.PacketConn
+
+		pconn, err = net.ListenPacket(`udp`, `127.0.0.1:0`)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		defer pconn.Close()
+
+		fwd, err = newLogForwarder(`syslog://` + pconn.LocalAddr().String())
+		if err != nil {
+			tt.Fatal(err)
+		}
+		fwd.hostname = `host`
+
+		writeForwardLog(fwd)
+
+		var (
+			buf = make([]byte, 1024)
+			got []string
+			n   int
+		)
+		for range exp {
+			n, _, err = pconn.ReadFrom(buf)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			got = append(got, string(buf[:n]))
+		}
+		test.Assert(tt, `messages`, exp, got)
+	})
+
+	t.Run(`tcp`, func(tt *testing.T) {
+		var ln net.Listener
+
+		ln, err = net.Listen(`tcp`, `127.0.0.1:0`)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		defer ln.Close()
+
+		fwd, err = newLogForwarder(`tcp://` + ln.Addr().String())
+		if err != nil {
+			tt.Fatal(err)
+		}
+		fwd.hostname = `host`
+
+		var gotq = make(chan []byte, 1)
+		go func() {
+			var conn, errAccept = ln.Accept()
+			if errAccept != nil {
+				gotq <- nil
+				return
+			}
+			var all, _ = io.ReadAll(conn)
+			gotq <- all
+		}()
+
+		writeForwardLog(fwd)
+
+		var (
+			rd  = bufio.NewReader(strings.NewReader(string(<-gotq)))
+			got []string
+			n   int
+		)
+		for range exp {
+			_, err = fmt.Fscanf(rd, `%d `, &n)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			var msg = make([]byte, n)
+			_, err = io.ReadFull(rd, msg)
+			if err != nil {
+				tt.Fatal(err)
+			}
+			got = append(got, string(msg))
+		}
+		test.Assert(tt, `messages`, exp, got)
+	})
+}
+
+func TestLogForwarder_loki(t *testing.T) {
+	var (
+		pushq = make(chan lokiPush, defLogForwardQueue)
+		srv   = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var push lokiPush
+			_ = json.NewDecoder(req.Body).Decode(&push)
+			pushq <- push
+			w.WriteHeader(http.StatusNoContent)
+		}))
+
+		fwd *logForwarder
+		err error
+	)
+	t.Cleanup(srv.Close)
+
+	fwd, err = newLogForwarder(`loki://` + strings.TrimPrefix(srv.URL, `http://`) + `/push`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	writeForwardLog(fwd)
+
+	var (
+		push   = <-pushq
+		ts     = fmt.Sprintf(`%d`, timeNow().UnixNano())
+		meta   = map[string]any{`counter`: `3`}
+		labels = func(stream string) map[string]string {
+			return map[string]string{
+				`service_name`: `karajo`,
+				`job_kind`:     `job`,
+				`job_id`:       `backup`,
+				`stream`:       stream,
+			}
+		}
+	)
+
+	// The lines may be pushed in more than one request, depends on
+	// how fast the forwarder read the queue.
+	for len(pushq) != 0 {
+		var next = <-pushq
+		push.Streams = append(push.Streams, next.Streams...)
+	}
+
+	var got = map[string][][]any{}
+	var stream lokiStream
+	for _, stream = range push.Streams {
+		test.Assert(t, `labels`, labels(stream.Stream[`stream`]), stream.Stream)
+		got[stream.Stream[`stream`]] = append(got[stream.Stream[`stream`]], stream.Values...)
+	}
+	test.Assert(t, `stdout`, [][]any{{ts, `hello`, meta}, {ts, `world`, meta}}, got[`stdout`])
+	test.Assert(t, `stderr`, [][]any{{ts, `oops`, meta}}, got[`stderr`])
+}
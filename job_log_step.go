@@ -0,0 +1,117 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"strings"
+	"time"
+)
+
+// jobLogStepPrefix the prefix of line in the content of log that contains
+// the status of step.
+const jobLogStepPrefix = `--- STEP: `
+
+// JobLogStep contains the status of each step in JobExec.
+type JobLogStep struct {
+	// Name of step.
+	Name string `json:"name"`
+
+	// Status of step, one of "running", "success", "failed",
+	// "canceled", or "skipped".
+	Status string `json:"status"`
+
+	// Duration the time elapsed to execute the step commands.
+	Duration time.Duration `json:"duration,omitempty"`
+}
+
+// parseJobLogStep parse the step from the string generated by
+// [JobLogStep.String].
+// It will return nil if the line is not valid.
+func parseJobLogStep(line string) (step *JobLogStep) {
+	var fields = strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil
+	}
+
+	step = &JobLogStep{
+		Name:   fields[0],
+		Status: fields[1],
+	}
+	if len(fields) == 3 {
+		var err error
+
+		step.Duration, err = time.ParseDuration(fields[2])
+		if err != nil {
+			return nil
+		}
+	}
+	return step
+}
+
+// String return the step in the format "<name> <status> [<duration>]".
+// The duration is only printed if the step has been executed.
+func (step *JobLogStep) String() (s string) {
+	s = step.Name + ` ` + step.Status
+	switch step.Status {
+	case JobStatusRunning, JobStatusSkipped:
+	default:
+		s += ` ` + step.Duration.String()
+	}
+	return s
+}
+
+// setStep set the status of step with name, and write it into the log.
+func (jlog *JobLog) setStep(name, status string, dur time.Duration) {
+	var step = &JobLogStep{
+		Name:     name,
+		Status:   status,
+		Duration: dur,
+	}
+
+	jlog.Write([]byte(jobLogStepPrefix + step.String() + "\n"))
+
+	jlog.Lock()
+	jlog.putStep(step)
+	jlog.Unlock()
+}
+
+// putStep replace the step with the same name, or append it if not
+// exist.
+// The caller must hold the lock.
+func (jlog *JobLog) putStep(step *JobLogStep) {
+	var x int
+	for x = range jlog.Steps {
+		if jlog.Steps[x].Name == step.Name {
+			jlog.Steps[x] = step
+			return
+		}
+	}
+	jlog.Steps = append(jlog.Steps, step)
+}
+
+// loadSteps read the status of each step from its content.
+func (jlog *JobLog) loadSteps() {
+	var content, err = jlog.read()
+	if err != nil {
+		return
+	}
+
+	var (
+		line  []byte
+		found bool
+		step  *JobLogStep
+	)
+	for {
+		_, content, found = bytes.Cut(content, []byte(jobLogStepPrefix))
+		if !found {
+			return
+		}
+		line, content, _ = bytes.Cut(content, []byte("\n"))
+		step = parseJobLogStep(string(line))
+		if step != nil {
+			jlog.putStep(step)
+		}
+	}
+}
@@ -0,0 +1,166 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// List of event types in the job log stream.
+const (
+	sseEventEnd    = `end`
+	sseEventError  = `error`
+	sseEventExit   = `exit`
+	sseEventStderr = `stderr`
+	sseEventStdout = `stdout`
+)
+
+// defSSEPollInterval the interval to check for new content in the job log.
+const defSSEPollInterval = 500 * time.Millisecond
+
+// defSSEPingInterval the interval to send an empty message when there is
+// no new content, to keep the connection alive and to detect the closed
+// connection.
+const defSSEPingInterval = 15 * time.Second
+
+// defSSEKeepAliveInterval the keep alive interval for the SSE endpoint in
+// the HTTP library.
+// The library send the keep alive message from different goroutine
+// without synchronization, so we set it high and send our own keep alive
+// message in the same goroutine that write the log.
+const defSSEKeepAliveInterval = time.Hour
+
+// streamJobLog send the content of job log to client, line by line, until
+// the job finished or the connection closed.
+//
+// The log is selected by its "counter" from query parameter.
+// The stream start from the "offset" query parameter or from the
+// "Last-Event-ID" header, default to the beginning of log.
+//
+// Each line is sent as event "stdout" or "stderr", with the id set to the
+// offset of the next line,
+//
+//	event:stdout
+//	data:<line>
+//	id:<offset>
+//
+// Once the job finished and all lines has been sent, it send the event
+// "exit" with the exit information as data, if one of the command failed,
+// and then the event "end" with the log status as data.
+// If the log is not found it send the event "error" with the error message
+// as data.
+func (k *Karajo) streamJobLog(sse *libhttp.SSEConn, job *JobBase) {
+	var (
+		req        = sse.HTTPRequest
+		counterStr = req.Form.Get(paramNameCounter)
+
+		jlog    *JobLog
+		counter int64
+		err     error
+	)
+
+	counter, err = strconv.ParseInt(counterStr, 10, 64)
+	if err == nil {
+		jlog = job.getLog(counter)
+	}
+	if jlog == nil {
+		_ = sse.WriteEvent(sseEventError, fmt.Sprintf(`log #%s not found`, counterStr), nil)
+		return
+	}
+
+	var offsetStr = req.Header.Get(`Last-Event-ID`)
+	if len(offsetStr) == 0 {
+		offsetStr = req.Form.Get(paramNameOffset)
+	}
+
+	var offset int
+
+	if len(offsetStr) != 0 {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil {
+			_ = sse.WriteEvent(sseEventError, fmt.Sprintf(`invalid offset %q`, offsetStr), nil)
+			return
+		}
+	}
+
+	jlog.Lock()
+	var isFinished = jlog.isFinished()
+	jlog.Unlock()
+
+	if isFinished {
+		err = jlog.load()
+		if err != nil {
+			_ = sse.WriteEvent(sseEventError, err.Error(), nil)
+			return
+		}
+	}
+
+	var (
+		ticker   = time.NewTicker(defSSEPollInterval)
+		lastSend = time.Now()
+
+		buf    bytes.Buffer
+		lines  []jobLogLine
+		status string
+		isEOF  bool
+	)
+	defer ticker.Stop()
+
+	for {
+		lines, status, isEOF = jlog.readLines(offset)
+		if len(lines) != 0 {
+			buf.Reset()
+			writeJobLogLines(&buf, lines)
+
+			err = sse.WriteRaw(buf.Bytes())
+			if err != nil {
+				return
+			}
+			offset = lines[len(lines)-1].next
+			lastSend = time.Now()
+		}
+		if isEOF {
+			jlog.Lock()
+			var exit = jlog.Exit
+			jlog.Unlock()
+
+			if exit != nil {
+				_ = sse.WriteEvent(sseEventExit, exit.String(), nil)
+			}
+			_ = sse.WriteEvent(sseEventEnd, status, nil)
+			return
+		}
+		if time.Since(lastSend) >= defSSEPingInterval {
+			err = sse.WriteRaw([]byte(":\n\n"))
+			if err != nil {
+				return
+			}
+			lastSend = time.Now()
+		}
+		<-ticker.C
+	}
+}
+
+// writeJobLogLines write each line as SSE message into buf.
+func writeJobLogLines(buf *bytes.Buffer, lines []jobLogLine) {
+	var (
+		line  jobLogLine
+		event string
+	)
+	for _, line = range lines {
+		event = sseEventStdout
+		if line.isStderr {
+			event = sseEventStderr
+		}
+		// The carriage return is a line separator in SSE.
+		fmt.Fprintf(buf, "event:%s\ndata:%s\nid:%d\n\n", event,
+			strings.ReplaceAll(line.text, "\r", ``), line.next)
+	}
+}
@@ -0,0 +1,369 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobLog_readLines(t *testing.T) {
+	var (
+		jlog = &JobLog{
+			Status: JobStatusRunning,
+		}
+		stderr = jobLogStderr{jlog}
+
+		lines  []jobLogLine
+		status string
+		isEOF  bool
+	)
+
+	jlog.content = []byte("=== BEGIN\n")
+	_, _ = stderr.Write([]byte("error 1\nerror 2\n"))
+	jlog.content = append(jlog.content, []byte(`partial`)...)
+
+	lines, status, isEOF = jlog.readLines(0)
+
+	var exp = []jobLogLine{{
+		text: `=== BEGIN`,
+		next: 10,
+	}, {
+		text:     `2023-01-09 00:00:00 UTC : : error 1`,
+		next:     46,
+		isStderr: true,
+	}, {
+		text:     `error 2`,
+		next:     54,
+		isStderr: true,
+	}}
+	test.Assert(t, `lines`, exp, lines)
+	test.Assert(t, `status`, JobStatusRunning, status)
+	test.Assert(t, `isEOF`, false, isEOF)
+
+	// Read from the last offset after the job finished.
+	jlog.Status = JobStatusSuccess
+	lines, status, isEOF = jlog.readLines(54)
+
+	exp = []jobLogLine{{
+		text: `partial`,
+		next: 61,
+	}}
+	test.Assert(t, `lines after finished`, exp, lines)
+	test.Assert(t, `status after finished`, JobStatusSuccess, status)
+	test.Assert(t, `isEOF after finished`, true, isEOF)
+
+	lines, _, isEOF = jlog.readLines(61)
+	test.Assert(t, `lines at end`, 0, len(lines))
+	test.Assert(t, `isEOF at end`, true, isEOF)
+}
+
+func TestJobLog_write_partialLine(t *testing.T) {
+	var (
+		jlog = &JobLog{
+			jobKind: jobKindExec,
+			JobID:   `test`,
+			Status:  JobStatusSuccess,
+		}
+		stderr = jobLogStderr{jlog}
+	)
+
+	_, _ = jlog.Write([]byte(`out 1`))
+	_, _ = stderr.Write([]byte(`err 1`))
+	_, _ = stderr.Write([]byte(" err 2\n"))
+	_, _ = jlog.Write([]byte(" out 2\n"))
+
+	var (
+		lines, _, _ = jlog.readLines(0)
+		exp         = []jobLogLine{{
+			text: `2023-01-09 00:00:00 UTC job: test: out 1`,
+			next: 41,
+		}, {
+			text:     `2023-01-09 00:00:00 UTC job: test: err 1 err 2`,
+			next:     88,
+			isStderr: true,
+		}, {
+			text: `2023-01-09 00:00:00 UTC job: test:  out 2`,
+			next: 130,
+		}}
+	)
+	test.Assert(t, `lines`, exp, lines)
+}
+
+func TestJobLog_spool(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		jlog = &JobLog{
+			jobKind: jobKindExec,
+			JobID:   `test`,
+			Name:    `test.1`,
+			Status:  JobStatusRunning,
+			path:    filepath.Join(dir, `test.1`),
+		}
+		stderr = jobLogStderr{jlog}
+		err    error
+	)
+
+	err = jlog.openSpool()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, _ = jlog.Write([]byte("out 1\n"))
+	_, _ = stderr.Write([]byte(`err 1`))
+	_, _ = jlog.Write([]byte("out 2\n"))
+
+	test.Assert(t, `content in memory`, 0, len(jlog.content))
+
+	var (
+		exp = "2023-01-09 00:00:00 UTC job: test: out 1\n" +
+			"2023-01-09 00:00:00 UTC job: test: err 1\n" +
+			"2023-01-09 00:00:00 UTC job: test: out 2\n"
+		got []byte
+	)
+	got, err = os.ReadFile(jlog.path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `spool`, exp, string(got))
+	test.Assert(t, `tail`, exp, string(jlog.tailContent()))
+
+	var lines, _, _ = jlog.readLines(41)
+	var expLines = []jobLogLine{{
+		text:     `2023-01-09 00:00:00 UTC job: test: err 1`,
+		next:     82,
+		isStderr: true,
+	}, {
+		text: `2023-01-09 00:00:00 UTC job: test: out 2`,
+		next: 123,
+	}}
+	test.Assert(t, `readLines while running`, expLines, lines)
+
+	jlog.setStatus(JobStatusSuccess)
+	err = jlog.flush()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = os.Stat(filepath.Join(dir, `test.1`))
+	test.Assert(t, `spool removed`, true, os.IsNotExist(err))
+
+	got, err = jlog.read()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `flushed`, exp, string(got))
+
+	var isEOF bool
+	lines, _, isEOF = jlog.readLines(82)
+	test.Assert(t, `readLines after flush`, expLines[1:], lines)
+	test.Assert(t, `isEOF after flush`, true, isEOF)
+}
+
+func TestJobLog_addTail(t *testing.T) {
+	var (
+		jlog  = &JobLog{}
+		chunk = bytes.Repeat([]byte(`x`), defJobLogTailSize)
+		x     int
+	)
+	for x = 0; x < 2; x++ {
+		jlog.addTail(chunk)
+	}
+	test.Assert(t, `len(tail)`, 2*defJobLogTailSize, len(jlog.tail))
+
+	jlog.addTail([]byte(`y`))
+	test.Assert(t, `len(tail) after trimmed`, defJobLogTailSize, len(jlog.tail))
+	test.Assert(t, `last byte`, byte('y'), jlog.tail[len(jlog.tail)-1])
+}
+
+func TestJobLog_maxSize(t *testing.T) {
+	type testCase struct {
+		desc    string
+		nline   int
+		maxSize int64
+	}
+
+	var cases = []testCase{{
+		desc:    `no limit`,
+		nline:   10,
+		maxSize: 0,
+	}, {
+		desc:    `below limit`,
+		nline:   2,
+		maxSize: 200,
+	}, {
+		desc:    `head full, tail not dropped`,
+		nline:   4,
+		maxSize: 200,
+	}, {
+		desc:    `truncated`,
+		nline:   100,
+		maxSize: 200,
+	}}
+
+	var (
+		dir = t.TempDir()
+
+		c   testCase
+		x   int
+		err error
+	)
+	for x, c = range cases {
+		var (
+			full = &JobLog{
+				jobKind: jobKindExec,
+				JobID:   `test`,
+			}
+			jlog = &JobLog{
+				jobKind: jobKindExec,
+				JobID:   `test`,
+				Name:    fmt.Sprintf(`test.%d`, x),
+				Status:  JobStatusSuccess,
+				path:    filepath.Join(dir, fmt.Sprintf(`test.%d`, x)),
+				maxSize: c.maxSize,
+			}
+			line []byte
+			n    int
+		)
+
+		err = jlog.openSpool()
+		if err != nil {
+			t.Fatal(err)
+		}
+		for n = 0; n < c.nline; n++ {
+			line = []byte(fmt.Sprintf("line %d\n", n))
+			_, _ = full.Write(line)
+			_, _ = jlog.Write(line)
+		}
+
+		err = jlog.flush()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var (
+			exp = full.content
+			got []byte
+		)
+		if c.maxSize > 0 && int64(len(exp)) > c.maxSize {
+			var half = c.maxSize / 2
+			exp = []byte(fmt.Sprintf("%s\n[... %d bytes truncated ...]\n%s",
+				full.content[:half], int64(len(full.content))-c.maxSize,
+				full.content[int64(len(full.content))-half:]))
+		}
+
+		got, err = jlog.read()
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, c.desc, string(exp), string(got))
+	}
+}
+
+func TestWriteJobLogLines(t *testing.T) {
+	var (
+		lines = []jobLogLine{{
+			text: "=== BEGIN\r",
+			next: 10,
+		}, {
+			text:     `error`,
+			next:     16,
+			isStderr: true,
+		}}
+		buf bytes.Buffer
+	)
+
+	writeJobLogLines(&buf, lines)
+
+	var exp = "event:stdout\ndata:=== BEGIN\nid:10\n\n" +
+		"event:stderr\ndata:error\nid:16\n\n"
+	test.Assert(t, `writeJobLogLines`, exp, buf.String())
+}
+
+func TestJobLog_duration(t *testing.T) {
+	var (
+		now  = timeNow()
+		path = filepath.Join(t.TempDir(), `test.1.success`)
+		raw  = now.Format(defTimeLayout) + " job: test: === BEGIN\n" +
+			strings.Repeat("output\n", 100)
+		err error
+	)
+
+	err = os.WriteFile(path, []byte(raw), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jlog = &JobLog{
+		path:    path,
+		timeEnd: now.Add(90 * time.Second),
+	}
+
+	test.Assert(t, `duration`, 90*time.Second, jlog.duration())
+	test.Assert(t, `content not loaded`, 0, len(jlog.content))
+
+	jlog = &JobLog{
+		path: path,
+	}
+	test.Assert(t, `not finished`, time.Duration(0), jlog.duration())
+}
+
+func TestJobLog_compress(t *testing.T) {
+	var (
+		now  = timeNow()
+		path = filepath.Join(t.TempDir(), `test.1.success`)
+		raw  = now.Format(defTimeLayout) + " job: test: === BEGIN\n" +
+			strings.Repeat("output\n", 100)
+		err error
+	)
+
+	err = os.WriteFile(path, []byte(raw), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jlog = &JobLog{
+		Name:    `test.1.success`,
+		path:    path,
+		timeEnd: now.Add(90 * time.Second),
+	}
+
+	err = jlog.compress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var stored []byte
+
+	stored, err = os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `isGzip`, true, isGzip(stored))
+	test.Assert(t, `size`, int64(len(stored)), jlog.logSize())
+	test.Assert(t, `smaller`, true, len(stored) < len(raw))
+
+	// Compressing twice does not change the stored log.
+	err = jlog.compress()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got, _ = os.ReadFile(path)
+	test.Assert(t, `compress twice`, stored, got)
+
+	test.Assert(t, `duration`, 90*time.Second, jlog.duration())
+
+	err = jlog.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `load`, raw, string(jlog.content))
+}
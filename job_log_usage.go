@@ -0,0 +1,102 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// jobLogUsagePrefix the prefix of line in the content of log that
+// contains the resource usage.
+const jobLogUsagePrefix = `--- USAGE: `
+
+// JobLogUsage contains the resources used by the commands in single run.
+type JobLogUsage struct {
+	// UserTime the total CPU time spent in user mode by all commands.
+	UserTime time.Duration `json:"user_time"`
+
+	// SysTime the total CPU time spent in kernel mode by all commands.
+	SysTime time.Duration `json:"sys_time"`
+
+	// Wall the elapsed time between the job started and finished.
+	Wall time.Duration `json:"wall"`
+
+	// MaxRSS the maximum resident set size of all commands, in bytes.
+	MaxRSS int64 `json:"max_rss"`
+}
+
+// processUsage return the resource usage of the exited process.
+// It is variable so it can be replaced in the test.
+var processUsage = readProcessUsage
+
+// readProcessUsage read the CPU times and maximum resident set size of the
+// exited process.
+func readProcessUsage(state *os.ProcessState) (usage JobLogUsage) {
+	usage.UserTime = state.UserTime()
+	usage.SysTime = state.SystemTime()
+
+	var rusage, ok = state.SysUsage().(*syscall.Rusage)
+	if ok {
+		// On Linux, the Maxrss is in kilobytes.
+		usage.MaxRSS = int64(rusage.Maxrss) * 1024
+	}
+	return usage
+}
+
+// add the resource usage of the other command.
+// The CPU times are summed, while the MaxRSS is the maximum of both.
+func (usage *JobLogUsage) add(other JobLogUsage) {
+	usage.UserTime += other.UserTime
+	usage.SysTime += other.SysTime
+	if other.MaxRSS > usage.MaxRSS {
+		usage.MaxRSS = other.MaxRSS
+	}
+}
+
+// parseJobLogUsage parse the resource usage from the string generated by
+// [JobLogUsage.String].
+// It will return nil if the line is not valid.
+func parseJobLogUsage(line string) (usage *JobLogUsage) {
+	usage = &JobLogUsage{}
+
+	var (
+		field string
+		key   string
+		val   string
+		found bool
+		err   error
+	)
+	for _, field = range strings.Fields(line) {
+		key, val, found = strings.Cut(field, `=`)
+		if !found {
+			return nil
+		}
+		switch key {
+		case `user`:
+			usage.UserTime, err = time.ParseDuration(val)
+		case `sys`:
+			usage.SysTime, err = time.ParseDuration(val)
+		case `wall`:
+			usage.Wall, err = time.ParseDuration(val)
+		case `max_rss`:
+			usage.MaxRSS, err = strconv.ParseInt(val, 10, 64)
+		}
+		if err != nil {
+			return nil
+		}
+	}
+	return usage
+}
+
+// String return the resource usage in the format
+// "user=<duration> sys=<duration> max_rss=<bytes> wall=<duration>".
+func (usage *JobLogUsage) String() string {
+	return fmt.Sprintf(`user=%s sys=%s max_rss=%d wall=%s`,
+		usage.UserTime, usage.SysTime, usage.MaxRSS, usage.Wall)
+}
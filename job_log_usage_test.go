@@ -0,0 +1,73 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestParseJobLogUsage(t *testing.T) {
+	type testCase struct {
+		exp  *JobLogUsage
+		line string
+	}
+
+	var cases = []testCase{{
+		line: `user=1.5s sys=200ms max_rss=1048576 wall=1m0s`,
+		exp: &JobLogUsage{
+			UserTime: 1500 * time.Millisecond,
+			SysTime:  200 * time.Millisecond,
+			Wall:     time.Minute,
+			MaxRSS:   1048576,
+		},
+	}, {
+		line: `user=1.5s sys`,
+	}, {
+		line: `user=1.5s max_rss=1MB`,
+	}}
+
+	var (
+		c   testCase
+		got *JobLogUsage
+	)
+	for _, c = range cases {
+		got = parseJobLogUsage(c.line)
+		test.Assert(t, c.line, c.exp, got)
+		if got != nil {
+			test.Assert(t, `String`, c.line, got.String())
+		}
+	}
+}
+
+func TestJobLogUsage_add(t *testing.T) {
+	var (
+		usage = JobLogUsage{}
+		cmd   = exec.Command(`/bin/sh`, `-c`, `true`)
+		err   error
+	)
+
+	err = cmd.Run()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var first = readProcessUsage(cmd.ProcessState)
+
+	test.Assert(t, `MaxRSS > 0`, true, first.MaxRSS > 0)
+
+	usage.add(first)
+	usage.add(JobLogUsage{
+		UserTime: time.Second,
+		SysTime:  time.Second,
+		MaxRSS:   1,
+	})
+
+	test.Assert(t, `UserTime`, first.UserTime+time.Second, usage.UserTime)
+	test.Assert(t, `SysTime`, first.SysTime+time.Second, usage.SysTime)
+	test.Assert(t, `MaxRSS`, first.MaxRSS, usage.MaxRSS)
+}
@@ -0,0 +1,199 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"sort"
+	"text/tabwriter"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+)
+
+// defJobMetricsName the name of built-in JobExec that write the internal
+// statistics of karajo into its log.
+const defJobMetricsName = `karajo metrics`
+
+// jobMetrics collect the internal statistics of karajo server.
+type jobMetrics struct {
+	k *Karajo
+}
+
+// metricsSnapshot contains the internal statistics of karajo at one point
+// in time.
+type metricsSnapshot struct {
+	// jobStatus contains the number of jobs per status.
+	jobStatus map[string]int
+
+	goroutines int
+
+	// heapAlloc is the bytes of allocated heap objects.
+	heapAlloc uint64
+
+	// jobRunning is the number of jobs currently holding the slot to
+	// run, while jobRunningMax is the maximum jobs that can run at the
+	// same time.
+	jobRunning    int
+	jobRunningMax int
+
+	// jobWaiting is the number of jobs waiting for the slot to run.
+	jobWaiting int
+
+	// jobExecQueued is the total of HTTP requests waiting to be
+	// processed by all JobExec.
+	jobExecQueued int
+
+	// notifPending is the number of notification that has not been
+	// completely sent.
+	notifPending int64
+
+	// notifQueued is the number of job logs waiting to be processed
+	// by the notification worker, while notifQueueSize is the maximum
+	// of it.
+	notifQueued    int
+	notifQueueSize int
+
+	// notifDropped is the total of job logs with notification that
+	// are dropped because the queue is full.
+	notifDropped int64
+
+	memfsFiles int
+	memfsSize  int64
+}
+
+// newJobMetrics create new JobExec that write the internal statistics of
+// karajo into its log every env MetricsInterval.
+func newJobMetrics(k *Karajo) (job *JobExec) {
+	var jm = &jobMetrics{
+		k: k,
+	}
+
+	job = &JobExec{
+		JobBase: JobBase{
+			Description: `Write the internal statistics of karajo server.`,
+			Interval:    k.env.MetricsInterval,
+		},
+		Call: jm.call,
+	}
+	return job
+}
+
+// call write the current snapshot into the job log.
+func (jm *jobMetrics) call(_ context.Context, log io.Writer, _ *libhttp.EndpointRequest) (err error) {
+	var snap = jm.collect()
+
+	_, err = log.Write(snap.format())
+	return err
+}
+
+// collect the current internal statistics.
+func (jm *jobMetrics) collect() (snap *metricsSnapshot) {
+	var (
+		memStats runtime.MemStats
+		job      *JobExec
+		jobHTTP  *JobHTTP
+		jobSQL   *JobSQL
+	)
+
+	runtime.ReadMemStats(&memStats)
+
+	snap = &metricsSnapshot{
+		jobStatus:    make(map[string]int),
+		goroutines:   runtime.NumGoroutine(),
+		heapAlloc:    memStats.HeapAlloc,
+		notifPending: jm.k.notifPending.Load(),
+
+		notifQueued:    len(jm.k.logq),
+		notifQueueSize: cap(jm.k.logq),
+		notifDropped:   jm.k.env.notifDropped.Load(),
+	}
+
+	snap.jobRunning, snap.jobWaiting, snap.jobRunningMax = jm.k.env.jobRunQueue.stats()
+
+	jm.k.env.jobsMtx.RLock()
+	for _, job = range jm.k.env.ExecJobs {
+		snap.jobExecQueued += len(job.httpq)
+		snap.countStatus(&job.JobBase)
+	}
+	jm.k.env.jobsMtx.RUnlock()
+	for _, jobHTTP = range jm.k.env.HTTPJobs {
+		snap.countStatus(&jobHTTP.JobBase)
+	}
+	for _, jobSQL = range jm.k.env.SQLJobs {
+		snap.countStatus(&jobSQL.JobBase)
+	}
+
+	snap.countMemfs(jm.k.www)
+
+	return snap
+}
+
+// countStatus increment the number of jobs with the same status as job.
+func (snap *metricsSnapshot) countStatus(job *JobBase) {
+	job.Lock()
+	var status = job.Status
+	job.Unlock()
+
+	if len(status) == 0 {
+		status = JobStatusStarted
+	}
+	snap.jobStatus[status]++
+}
+
+// countMemfs count the number of files and their content size that are
+// cached in memory.
+func (snap *metricsSnapshot) countMemfs(mfs *memfs.MemFS) {
+	if mfs == nil || mfs.PathNodes == nil {
+		return
+	}
+
+	var node *memfs.Node
+	for _, node = range mfs.PathNodes.Nodes() {
+		if node.IsDir() {
+			continue
+		}
+		snap.memfsFiles++
+		snap.memfsSize += int64(len(node.Content))
+	}
+}
+
+// format the snapshot as list of name and value.
+func (snap *metricsSnapshot) format() []byte {
+	var (
+		buf bytes.Buffer
+		tw  = tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+
+		listStatus = make([]string, 0, len(snap.jobStatus))
+		status     string
+	)
+
+	fmt.Fprintf(tw, "goroutines\t%d\n", snap.goroutines)
+	fmt.Fprintf(tw, "heap_alloc\t%d\n", snap.heapAlloc)
+	fmt.Fprintf(tw, "job_running\t%d/%d\n", snap.jobRunning, snap.jobRunningMax)
+	fmt.Fprintf(tw, "job_waiting\t%d\n", snap.jobWaiting)
+	fmt.Fprintf(tw, "job_exec_queued\t%d\n", snap.jobExecQueued)
+
+	for status = range snap.jobStatus {
+		listStatus = append(listStatus, status)
+	}
+	sort.Strings(listStatus)
+	for _, status = range listStatus {
+		fmt.Fprintf(tw, "job_status_%s\t%d\n", status, snap.jobStatus[status])
+	}
+
+	fmt.Fprintf(tw, "notif_pending\t%d\n", snap.notifPending)
+	fmt.Fprintf(tw, "notif_queued\t%d/%d\n", snap.notifQueued, snap.notifQueueSize)
+	fmt.Fprintf(tw, "notif_dropped\t%d\n", snap.notifDropped)
+	fmt.Fprintf(tw, "memfs_files\t%d\n", snap.memfsFiles)
+	fmt.Fprintf(tw, "memfs_size\t%d\n", snap.memfsSize)
+
+	_ = tw.Flush()
+
+	return buf.Bytes()
+}
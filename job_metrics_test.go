@@ -0,0 +1,71 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobMetrics_collect(t *testing.T) {
+	var (
+		k = &Karajo{
+			env: &Env{
+				ExecJobs: map[string]*JobExec{
+					`running`: &JobExec{
+						JobBase: JobBase{
+							Status: JobStatusRunning,
+						},
+						httpq: make(chan *libhttp.EndpointRequest, 1),
+					},
+					`paused`: &JobExec{
+						JobBase: JobBase{
+							Status: JobStatusPaused,
+						},
+					},
+				},
+				HTTPJobs: map[string]*JobHTTP{
+					`new`: &JobHTTP{},
+				},
+			},
+		}
+		jm = &jobMetrics{
+			k: k,
+		}
+	)
+
+	k.env.jobRunQueue = newJobRunQueue(2)
+	k.env.jobRunQueue.acquire(jobPriorityNormal)
+	k.env.ExecJobs[`running`].httpq <- &libhttp.EndpointRequest{}
+	k.notifPending.Add(3)
+	k.logq = make(chan *JobLog, 2)
+	k.logq <- &JobLog{}
+	k.env.notifDropped.Add(4)
+
+	var snap = jm.collect()
+
+	// Reset the values that are not predictable.
+	snap.goroutines = 0
+	snap.heapAlloc = 0
+	snap.memfsFiles = 0
+	snap.memfsSize = 0
+
+	var exp = "goroutines          0\n" +
+		"heap_alloc          0\n" +
+		"job_running         1/2\n" +
+		"job_waiting         0\n" +
+		"job_exec_queued     1\n" +
+		"job_status_paused   1\n" +
+		"job_status_running  1\n" +
+		"job_status_started  1\n" +
+		"notif_pending       3\n" +
+		"notif_queued        1/2\n" +
+		"notif_dropped       4\n" +
+		"memfs_files         0\n" +
+		"memfs_size          0\n"
+
+	test.Assert(t, `collect`, exp, string(snap.format()))
+}
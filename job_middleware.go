@@ -0,0 +1,104 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"fmt"
+)
+
+// JobMiddleware define the hooks that called around the JobExec and
+// JobHTTP execution, for cross-cutting concerns like emitting metrics,
+// acquiring external locks, or setting environment variables.
+//
+// The middleware can be registered globally in [Env.Middlewares] or per
+// job in [JobBase.Middlewares].
+// The global middlewares are called before the job middlewares.
+type JobMiddleware interface {
+	// BeforeRun called after the job log created and before the job
+	// executed.
+	// If it return an error, the job is failed with that error and
+	// the AfterRun of previous middlewares are called.
+	BeforeRun(ctx context.Context, run *JobRun) error
+
+	// AfterRun called after the job executed, with the error from
+	// executing the job, in reverse order of BeforeRun.
+	AfterRun(ctx context.Context, run *JobRun, err error)
+
+	// OnLog called on each write to the job log, where b is the
+	// content before prefixed with timestamp.
+	// The b must not be modified or retained.
+	OnLog(run *JobRun, b []byte)
+}
+
+// JobRun contains the information of the job run passed to JobMiddleware.
+type JobRun struct {
+	// Job the JobExec or JobHTTP that is running.
+	Job *JobBase
+
+	// Log of the run.
+	Log *JobLog
+
+	// Envs define the additional environment variables, in the
+	// format "NAME=VALUE", for the JobExec commands.
+	// It can be modified by BeforeRun, and ignored by JobHTTP.
+	Envs []string
+}
+
+// initMiddlewares merge the global middlewares from env with the job
+// Middlewares.
+func (job *JobBase) initMiddlewares(env *Env) {
+	job.middlewares = nil
+	job.middlewares = append(job.middlewares, env.Middlewares...)
+	job.middlewares = append(job.middlewares, job.Middlewares...)
+}
+
+// beforeRun create the JobRun for jlog and call the BeforeRun of each
+// middlewares.
+// If one of the BeforeRun failed, the AfterRun of previous middlewares is
+// called before returning the error.
+func (job *JobBase) beforeRun(ctx context.Context, jlog *JobLog) (run *JobRun, err error) {
+	run = &JobRun{
+		Job: job,
+		Log: jlog,
+	}
+	if len(job.middlewares) == 0 {
+		return run, nil
+	}
+
+	jlog.onLog = func(b []byte) {
+		var mw JobMiddleware
+		for _, mw = range job.middlewares {
+			mw.OnLog(run, b)
+		}
+	}
+
+	var (
+		mw JobMiddleware
+		x  int
+	)
+	for x, mw = range job.middlewares {
+		err = mw.BeforeRun(ctx, run)
+		if err != nil {
+			err = fmt.Errorf(`BeforeRun: %w`, err)
+			afterRun(ctx, job.middlewares[:x], run, err)
+			return run, err
+		}
+	}
+	jlog.envs = run.Envs
+	return run, nil
+}
+
+// afterRun call the AfterRun of job middlewares.
+func (job *JobBase) afterRun(ctx context.Context, run *JobRun, err error) {
+	afterRun(ctx, job.middlewares, run, err)
+}
+
+// afterRun call the AfterRun of each middleware in list in reverse order.
+func afterRun(ctx context.Context, list []JobMiddleware, run *JobRun, err error) {
+	var x int
+	for x = len(list) - 1; x >= 0; x-- {
+		list[x].AfterRun(ctx, run, err)
+	}
+}
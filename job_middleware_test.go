@@ -0,0 +1,120 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+// fakeJobMiddleware record the calls of JobMiddleware.
+type fakeJobMiddleware struct {
+	errBefore error
+	calls     *[]string
+	name      string
+	envs      []string
+	logs      strings.Builder
+	mtx       sync.Mutex
+}
+
+func (mw *fakeJobMiddleware) BeforeRun(_ context.Context, run *JobRun) error {
+	*mw.calls = append(*mw.calls, mw.name+`:BeforeRun`)
+	if mw.errBefore != nil {
+		return mw.errBefore
+	}
+	run.Envs = append(run.Envs, mw.envs...)
+	return nil
+}
+
+func (mw *fakeJobMiddleware) AfterRun(_ context.Context, _ *JobRun, err error) {
+	var call = mw.name + `:AfterRun`
+	if err != nil {
+		call += `:` + err.Error()
+	}
+	*mw.calls = append(*mw.calls, call)
+}
+
+func (mw *fakeJobMiddleware) OnLog(_ *JobRun, b []byte) {
+	mw.mtx.Lock()
+	mw.logs.Write(b)
+	mw.mtx.Unlock()
+}
+
+func TestJobExec_middlewares(t *testing.T) {
+	var (
+		calls  []string
+		global = &fakeJobMiddleware{
+			name:  `global`,
+			calls: &calls,
+			envs:  []string{`MW_GLOBAL=1`},
+		}
+		local = &fakeJobMiddleware{
+			name:  `local`,
+			calls: &calls,
+			envs:  []string{`MW_LOCAL=2`},
+		}
+		env = Env{
+			DirBase:     t.TempDir(),
+			Secret:      `s3cret`,
+			Middlewares: []JobMiddleware{global},
+		}
+		job = JobExec{
+			JobBase: JobBase{
+				Name:        `middleware`,
+				Middlewares: []JobMiddleware{local},
+			},
+			Commands: []string{
+				`echo global=$MW_GLOBAL local=$MW_LOCAL`,
+			},
+		}
+
+		err error
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = job.init(&env, job.Name)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = job.execute(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, `calls`, []string{
+		`global:BeforeRun`,
+		`local:BeforeRun`,
+		`local:AfterRun`,
+		`global:AfterRun`,
+	}, calls)
+	test.Assert(t, `OnLog: envs`, true,
+		strings.Contains(local.logs.String(), "global=1 local=2\n"))
+	test.Assert(t, `OnLog: global`, local.logs.String(), global.logs.String())
+
+	// The BeforeRun that failed cancel the run.
+	calls = nil
+	local.errBefore = errors.New(`lock is held`)
+
+	var jlog *JobLog
+
+	jlog, err = job.execute(nil)
+	test.Assert(t, `BeforeRun error`, `BeforeRun: lock is held`, err.Error())
+	test.Assert(t, `calls on error`, []string{
+		`global:BeforeRun`,
+		`local:BeforeRun`,
+		`global:AfterRun:BeforeRun: lock is held`,
+	}, calls)
+
+	var raw, _ = jlog.read()
+	test.Assert(t, `not executed`, false, strings.Contains(string(raw), `global=`))
+}
@@ -0,0 +1,113 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"slices"
+	"sync"
+)
+
+// jobMutex the named semaphore shared by the jobs that set the same
+// [JobBase.MutexName], so only one of them running at a time, even if they
+// are triggered from different queue.
+type jobMutex struct {
+	// lockq is the channel with one buffer, the job that successfully
+	// send to it hold the mutex.
+	lockq chan struct{}
+
+	// Name of the mutex.
+	Name string `json:"name"`
+
+	// Holder the ID of job that currently hold the mutex.
+	Holder string `json:"holder,omitempty"`
+
+	// Waiting list of job ID that wait to acquire the mutex, in order.
+	Waiting []string `json:"waiting,omitempty"`
+
+	// Jobs list of job ID that use the mutex.
+	Jobs []string `json:"jobs"`
+
+	// revision bumped when the holder or waiting list changes.
+	revision *envRevision
+
+	mtx sync.Mutex
+}
+
+// newJobMutex create new jobMutex with the name.
+func newJobMutex(name string) (sem *jobMutex) {
+	sem = &jobMutex{
+		lockq: make(chan struct{}, 1),
+		Name:  name,
+	}
+	return sem
+}
+
+// MarshalJSON encode the current state of mutex as JSON object.
+func (sem *jobMutex) MarshalJSON() ([]byte, error) {
+	type jobMutexJSON jobMutex
+
+	sem.mtx.Lock()
+	defer sem.mtx.Unlock()
+
+	return json.Marshal((*jobMutexJSON)(sem))
+}
+
+// lock acquire the mutex for the job ID, wait until the other job release
+// it.
+// It will do nothing if the sem is nil.
+func (sem *jobMutex) lock(jobID string) {
+	if sem == nil {
+		return
+	}
+
+	sem.mtx.Lock()
+	sem.Waiting = append(sem.Waiting, jobID)
+	sem.mtx.Unlock()
+	sem.revision.bump()
+
+	sem.lockq <- struct{}{}
+
+	sem.mtx.Lock()
+	var x = slices.Index(sem.Waiting, jobID)
+	if x >= 0 {
+		sem.Waiting = slices.Delete(sem.Waiting, x, x+1)
+	}
+	sem.Holder = jobID
+	sem.mtx.Unlock()
+	sem.revision.bump()
+}
+
+// unlock release the mutex.
+// It will do nothing if the sem is nil.
+func (sem *jobMutex) unlock() {
+	if sem == nil {
+		return
+	}
+
+	sem.mtx.Lock()
+	sem.Holder = ``
+	sem.mtx.Unlock()
+	sem.revision.bump()
+
+	<-sem.lockq
+}
+
+// jobMutex get or create the mutex by its name and register the job ID
+// as its user.
+func (env *Env) jobMutex(name, jobID string) (sem *jobMutex) {
+	if env.Mutexes == nil {
+		env.Mutexes = make(map[string]*jobMutex)
+	}
+	sem = env.Mutexes[name]
+	if sem == nil {
+		sem = newJobMutex(name)
+		sem.revision = env.revision
+		env.Mutexes[name] = sem
+	}
+	if !slices.Contains(sem.Jobs, jobID) {
+		sem.Jobs = append(sem.Jobs, jobID)
+	}
+	return sem
+}
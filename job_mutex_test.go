@@ -0,0 +1,63 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobMutex(t *testing.T) {
+	var (
+		env  = &Env{}
+		semA = env.jobMutex(`db`, `a`)
+		semB = env.jobMutex(`db`, `b`)
+	)
+
+	test.Assert(t, `same mutex`, semA, semB)
+
+	semA.lock(`a`)
+
+	var lockedq = make(chan struct{})
+	go func() {
+		semB.lock(`b`)
+		close(lockedq)
+	}()
+
+	var isWaiting bool
+	for !isWaiting {
+		time.Sleep(time.Millisecond)
+		semA.mtx.Lock()
+		isWaiting = len(semA.Waiting) == 1
+		semA.mtx.Unlock()
+	}
+
+	var (
+		got []byte
+		err error
+	)
+	got, err = json.Marshal(env.Mutexes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = `{"db":{"name":"db","holder":"a","waiting":["b"],"jobs":["a","b"]}}`
+	test.Assert(t, `waiting`, exp, string(got))
+
+	semA.unlock()
+	<-lockedq
+
+	got, err = json.Marshal(env.Mutexes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	exp = `{"db":{"name":"db","holder":"b","jobs":["a","b"]}}`
+	test.Assert(t, `locked by b`, exp, string(got))
+
+	semB.unlock()
+}
@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// List of [JobBase.NotifDigest].
+const (
+	JobNotifDigestDaily  = `daily`
+	JobNotifDigestHourly = `hourly`
+)
+
+// jobNotifBatch collect the job logs to be notified as single digest,
+// based on the job NotifThrottle or NotifDigest.
+type jobNotifBatch struct {
+	// lastSent the time when the last notification is sent.
+	lastSent time.Time
+
+	// timer that send the pending logs once fired.
+	timer *time.Timer
+
+	// pending logs that has not been notified.
+	pending []*JobLog
+
+	sync.Mutex
+}
+
+// initNotif validate the NotifDigest.
+func (job *JobBase) initNotif() (err error) {
+	job.NotifDigest = strings.ToLower(strings.TrimSpace(job.NotifDigest))
+
+	switch job.NotifDigest {
+	case ``, JobNotifDigestDaily, JobNotifDigestHourly:
+	default:
+		return fmt.Errorf(`invalid notif_digest %q`, job.NotifDigest)
+	}
+	if job.NotifThrottle < 0 {
+		job.NotifThrottle = 0
+	}
+	if len(job.NotifDigest) != 0 || job.NotifThrottle > 0 {
+		job.notifBatch = &jobNotifBatch{}
+	}
+	return nil
+}
+
+// notify send the jlog to the notification worker.
+//
+// If NotifDigest is set, the jlog is collected and send as single digest at
+// the start of next hour or day.
+// If NotifThrottle is set, the jlog is send immediately only if there is
+// no notification sent in the last NotifThrottle duration; otherwise it is
+// collected and send as single digest once the throttle duration passed.
+func (job *JobBase) notify(jlog *JobLog) {
+	var batch = job.notifBatch
+	if batch == nil || len(jlog.listNotif) == 0 {
+		job.sendLog(jlog)
+		return
+	}
+
+	var now = timeNow()
+
+	batch.Lock()
+	defer batch.Unlock()
+
+	if len(job.NotifDigest) == 0 && len(batch.pending) == 0 &&
+		now.Sub(batch.lastSent) >= job.NotifThrottle {
+		batch.lastSent = now
+		job.sendLog(jlog)
+		return
+	}
+
+	batch.pending = append(batch.pending, jlog)
+	if batch.timer != nil {
+		return
+	}
+
+	var next time.Time
+
+	switch job.NotifDigest {
+	case JobNotifDigestHourly:
+		next = now.Truncate(time.Hour).Add(time.Hour)
+	case JobNotifDigestDaily:
+		next = now.UTC().Truncate(24 * time.Hour).Add(24 * time.Hour)
+	default:
+		next = batch.lastSent.Add(job.NotifThrottle)
+	}
+	batch.timer = time.AfterFunc(next.Sub(now), job.notifyPending)
+}
+
+// notifyPending send the pending logs as single digest.
+func (job *JobBase) notifyPending() {
+	var batch = job.notifBatch
+
+	batch.Lock()
+	var pending = batch.pending
+	batch.pending = nil
+	batch.timer = nil
+	batch.lastSent = timeNow()
+	batch.Unlock()
+
+	switch len(pending) {
+	case 0:
+		return
+	case 1:
+		job.sendLog(pending[0])
+		return
+	}
+	job.sendLog(newJobLogDigest(pending))
+}
+
+// sendLog send the jlog to the notification worker.
+// If the queue is full, it wait until notifTimeout before the jlog is
+// dropped.
+func (job *JobBase) sendLog(jlog *JobLog) {
+	select {
+	case job.logq <- jlog:
+		return
+	default:
+	}
+
+	if job.notifTimeout > 0 {
+		var timer = time.NewTimer(job.notifTimeout)
+		select {
+		case job.logq <- jlog:
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+	}
+
+	if len(jlog.listNotif) == 0 {
+		return
+	}
+	if job.notifDropped != nil {
+		job.notifDropped.Add(1)
+	}
+	mlog.Errf(`job: %s: notification queue is full, log #%d is dropped`,
+		job.ID, jlog.Counter)
+}
+
+// newJobLogDigest merge the list of logs into single JobLog.
+// The status, category, and counter of digest is set to the last log, and
+// the notifications is the union of all logs notifications.
+func newJobLogDigest(logs []*JobLog) (digest *JobLog) {
+	var (
+		first = logs[0]
+		last  = logs[len(logs)-1]
+		buf   bytes.Buffer
+
+		jlog    *JobLog
+		content []byte
+		name    string
+		err     error
+	)
+
+	digest = &JobLog{
+		jobKind:  last.jobKind,
+		JobID:    last.JobID,
+		Name:     last.JobID + `.digest`,
+		Status:   last.Status,
+		Category: last.Category,
+		Counter:  last.Counter,
+	}
+
+	fmt.Fprintf(&buf, "Digest of %d runs, from #%d until #%d.\n",
+		len(logs), first.Counter, last.Counter)
+
+	for _, jlog = range logs {
+		for _, name = range jlog.listNotif {
+			if !slices.Contains(digest.listNotif, name) {
+				digest.listNotif = append(digest.listNotif, name)
+			}
+		}
+
+		fmt.Fprintf(&buf, "\n=== #%d: %s\n", jlog.Counter, jlog.Status)
+
+		content, err = jlog.read()
+		if err != nil {
+			fmt.Fprintf(&buf, "!!! %s\n", err)
+			continue
+		}
+		buf.Write(content)
+		if len(content) != 0 && content[len(content)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	digest.content = buf.Bytes()
+	return digest
+}
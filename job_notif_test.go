@@ -0,0 +1,141 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobBase_notify(t *testing.T) {
+	var (
+		logq = make(chan *JobLog, 5)
+		job  = &JobBase{
+			ID:            `job`,
+			NotifThrottle: time.Hour,
+			logq:          logq,
+		}
+		err error
+	)
+
+	err = job.initNotif()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		logs []*JobLog
+		jlog *JobLog
+		x    int64
+	)
+	for x = 1; x <= 3; x++ {
+		jlog = &JobLog{
+			jobKind:   jobKindExec,
+			JobID:     `job`,
+			Status:    JobStatusFailed,
+			Counter:   x,
+			content:   []byte(fmt.Sprintf("output %d\n", x)),
+			listNotif: []string{`mail`},
+		}
+		logs = append(logs, jlog)
+		job.notify(jlog)
+	}
+
+	// Only the first log is send immediately.
+	test.Assert(t, `first`, logs[0], <-logq)
+	test.Assert(t, `pending`, 2, len(job.notifBatch.pending))
+
+	job.notifBatch.timer.Stop()
+	job.notifyPending()
+
+	var (
+		digest = <-logq
+		exp    = "Digest of 2 runs, from #2 until #3.\n" +
+			"\n=== #2: failed\noutput 2\n" +
+			"\n=== #3: failed\noutput 3\n"
+	)
+	test.Assert(t, `digest name`, `job.digest`, digest.Name)
+	test.Assert(t, `digest counter`, int64(3), digest.Counter)
+	test.Assert(t, `digest listNotif`, []string{`mail`}, digest.listNotif)
+	test.Assert(t, `digest content`, exp, string(digest.content))
+}
+
+func TestJobBase_initNotif(t *testing.T) {
+	type testCase struct {
+		digest   string
+		expError string
+		expBatch bool
+	}
+
+	var cases = []testCase{{
+		digest: ``,
+	}, {
+		digest:   ` Hourly `,
+		expBatch: true,
+	}, {
+		digest:   `daily`,
+		expBatch: true,
+	}, {
+		digest:   `weekly`,
+		expError: `invalid notif_digest "weekly"`,
+	}}
+
+	var (
+		c   testCase
+		job *JobBase
+		err error
+	)
+	for _, c = range cases {
+		job = &JobBase{
+			NotifDigest: c.digest,
+		}
+		err = job.initNotif()
+		if err != nil {
+			test.Assert(t, c.digest, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.digest, c.expBatch, job.notifBatch != nil)
+	}
+}
+
+func TestJobBase_sendLog(t *testing.T) {
+	var (
+		logq    = make(chan *JobLog, 1)
+		dropped atomic.Int64
+		job     = &JobBase{
+			ID:           `job`,
+			logq:         logq,
+			notifDropped: &dropped,
+			notifTimeout: 10 * time.Millisecond,
+		}
+		jlogNotif = &JobLog{
+			Counter:   1,
+			listNotif: []string{`mail`},
+		}
+	)
+
+	job.sendLog(jlogNotif)
+	test.Assert(t, `queued`, 1, len(logq))
+
+	// The log without notification is not counted as dropped.
+	job.sendLog(&JobLog{Counter: 2})
+	test.Assert(t, `dropped without notif`, int64(0), dropped.Load())
+
+	job.sendLog(&JobLog{Counter: 3, listNotif: []string{`mail`}})
+	test.Assert(t, `dropped with notif`, int64(1), dropped.Load())
+
+	// The log is delivered if the queue has free slot before timeout.
+	go func() {
+		time.Sleep(time.Millisecond)
+		<-logq
+	}()
+	job.notifTimeout = time.Minute
+	job.sendLog(&JobLog{Counter: 4, listNotif: []string{`mail`}})
+	test.Assert(t, `delivered`, int64(4), (<-logq).Counter)
+	test.Assert(t, `dropped after delivered`, int64(1), dropped.Load())
+}
@@ -0,0 +1,217 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defJobQueueRetryDelay the delay before popping the message again after
+// failure.
+const defJobQueueRetryDelay = 5 * time.Second
+
+// List of [Env.QueueBackend].
+const (
+	QueueBackendMemory = `memory` // Default QueueBackend if not set.
+	QueueBackendRedis  = `redis`
+)
+
+// jobQueue define the queue, shared between karajo instances, for
+// JobExec triggered by HTTP request.
+//
+// The message is delivered at least once: the message popped from the
+// queue is kept by the backend until its acknowledged, after the job
+// finished.
+// The message that is not acknowledged, for example the instance is
+// killed while running the job, is pushed back to the queue when the
+// instance started again.
+type jobQueue interface {
+	// push the message to the queue.
+	push(msg []byte) error
+
+	// pop wait for new message.
+	// It return nil message if there is no message after some time,
+	// so the caller can check the ctx.
+	pop(ctx context.Context) (msg []byte, err error)
+
+	// ack remove the message that has been processed.
+	ack(msg []byte) error
+
+	// recover push back the messages that has been popped by this
+	// instance but not acknowledged.
+	recover() error
+}
+
+// newJobQueue create the jobQueue based on the backend.
+// It will return nil jobQueue if the backend is memory, where each
+// JobExec use its own queue in memory.
+func newJobQueue(backend, address string) (q jobQueue, err error) {
+	switch backend {
+	case ``, QueueBackendMemory:
+		return nil, nil
+	case QueueBackendRedis:
+		var name, _ = os.Hostname()
+		return newJobQueueRedis(address, name)
+	}
+	return nil, fmt.Errorf(`unknown queue_backend %q`, backend)
+}
+
+// ctxKeyJobQueueAck the key for storing the function to acknowledge the
+// message in the context of request.
+type ctxKeyJobQueueAck struct{}
+
+// jobQueueMessage the HTTP request that trigger the JobExec, stored in the
+// jobQueue.
+type jobQueueMessage struct {
+	Header http.Header `json:"header"`
+
+	// ID the random identifier, so two requests with the same content
+	// are different message.
+	ID string `json:"id"`
+
+	JobID  string `json:"job_id"`
+	Method string `json:"method"`
+	URL    string `json:"url"`
+	Body   []byte `json:"body"`
+}
+
+// newJobQueueMessage encode the request that trigger the job into
+// message.
+func newJobQueueMessage(job *JobExec, epr *libhttp.EndpointRequest) (msg []byte, err error) {
+	var qmsg = jobQueueMessage{
+		Header: epr.HTTPRequest.Header,
+		ID:     string(ascii.Random([]byte(ascii.LettersNumber), 16)),
+		JobID:  job.ID,
+		Method: epr.HTTPRequest.Method,
+		Body:   epr.RequestBody,
+	}
+	if epr.HTTPRequest.URL != nil {
+		qmsg.URL = epr.HTTPRequest.URL.String()
+	}
+	return json.Marshal(&qmsg)
+}
+
+// endpointRequest decode the message back into the request.
+// The ack function is called once the job finished.
+func (qmsg *jobQueueMessage) endpointRequest(ack func()) (epr *libhttp.EndpointRequest, err error) {
+	var (
+		ctx = context.WithValue(context.Background(), ctxKeyJobQueueAck{}, ack)
+		req *http.Request
+	)
+
+	req, err = http.NewRequestWithContext(ctx, qmsg.Method, qmsg.URL,
+		bytes.NewReader(qmsg.Body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header = qmsg.Header
+
+	epr = &libhttp.EndpointRequest{
+		HTTPRequest: req,
+		RequestBody: qmsg.Body,
+	}
+	return epr, nil
+}
+
+// jobQueueAck acknowledge the message of request, if the request is
+// popped from jobQueue.
+func jobQueueAck(epr *libhttp.EndpointRequest) {
+	if epr == nil || epr.HTTPRequest == nil {
+		return
+	}
+	var ack, ok = epr.HTTPRequest.Context().Value(ctxKeyJobQueueAck{}).(func())
+	if ok {
+		ack()
+	}
+}
+
+// consumeJobQueue pop the message from the jobQueue and pass it to the
+// JobExec until the ctx is canceled.
+func (k *Karajo) consumeJobQueue(ctx context.Context) {
+	var (
+		logp = `consumeJobQueue`
+		q    = k.env.jobQueue
+
+		msg  []byte
+		qmsg jobQueueMessage
+		job  *JobExec
+		epr  *libhttp.EndpointRequest
+		err  error
+	)
+
+	err = q.recover()
+	if err != nil {
+		mlog.Errf(`%s: %s`, logp, err)
+	}
+
+	for ctx.Err() == nil {
+		if k.env.ReadOnlyMode.is() {
+			// Leave the message for the other instances.
+			select {
+			case <-ctx.Done():
+			case <-time.After(defJobQueueRetryDelay):
+			}
+			continue
+		}
+
+		msg, err = q.pop(ctx)
+		if err != nil {
+			mlog.Errf(`%s: %s`, logp, err)
+			select {
+			case <-ctx.Done():
+			case <-time.After(defJobQueueRetryDelay):
+			}
+			continue
+		}
+		if len(msg) == 0 {
+			continue
+		}
+
+		qmsg = jobQueueMessage{}
+		err = json.Unmarshal(msg, &qmsg)
+		if err == nil {
+			job = k.env.jobExec(qmsg.JobID)
+			if job == nil {
+				err = errJobNotFound(qmsg.JobID)
+			}
+		}
+		if err == nil {
+			epr, err = qmsg.endpointRequest(k.jobQueueAcker(msg))
+		}
+		if err != nil {
+			// The message cannot be processed by any instance,
+			// drop it.
+			mlog.Errf(`%s: %s`, logp, err)
+			k.jobQueueAcker(msg)()
+			continue
+		}
+
+		select {
+		case job.httpq <- epr:
+		case <-ctx.Done():
+			// The message will be recovered on the next start.
+			return
+		}
+	}
+}
+
+// jobQueueAcker return the function to acknowledge the message.
+func (k *Karajo) jobQueueAcker(msg []byte) func() {
+	return func() {
+		var err = k.env.jobQueue.ack(msg)
+		if err != nil {
+			mlog.Errf(`jobQueueAck: %s`, err)
+		}
+	}
+}
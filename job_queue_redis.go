@@ -0,0 +1,248 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// defJobQueueRedisKey the Redis key for the list of message.
+const defJobQueueRedisKey = `karajo:jobq`
+
+// defJobQueueRedisPopTimeout the maximum time, in seconds, for Redis to
+// block the pop command.
+const defJobQueueRedisPopTimeout = 5
+
+// defJobQueueRedisTimeout the timeout for connecting and reading
+// non-blocking command.
+const defJobQueueRedisTimeout = 10 * time.Second
+
+// jobQueueRedis implement the jobQueue using Redis list.
+//
+// The message is pushed to the list "karajo:jobq".
+// The pop command atomically move the message to the list
+// "karajo:jobq:<name>", where name is the host name of instance, and the
+// ack remove it from there.
+type jobQueueRedis struct {
+	addr     string
+	password string
+
+	keyQueue      string
+	keyProcessing string
+
+	db int
+}
+
+// newJobQueueRedis create new jobQueueRedis from address in the format
+// "redis://[:password@]host[:port][/db]".
+func newJobQueueRedis(address, name string) (q *jobQueueRedis, err error) {
+	var (
+		logp = `newJobQueueRedis`
+		u    *url.URL
+	)
+
+	u, err = url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if u.Scheme != QueueBackendRedis || len(u.Host) == 0 {
+		return nil, fmt.Errorf(`%s: invalid queue_address %q`, logp, address)
+	}
+
+	q = &jobQueueRedis{
+		addr:          u.Host,
+		keyQueue:      defJobQueueRedisKey,
+		keyProcessing: defJobQueueRedisKey + `:` + name,
+	}
+	if len(u.Port()) == 0 {
+		q.addr = net.JoinHostPort(u.Hostname(), `6379`)
+	}
+	if u.User != nil {
+		q.password, _ = u.User.Password()
+	}
+
+	var db = strings.Trim(u.Path, `/`)
+	if len(db) != 0 {
+		q.db, err = strconv.Atoi(db)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: invalid database %q`, logp, db)
+		}
+	}
+	return q, nil
+}
+
+func (q *jobQueueRedis) push(msg []byte) (err error) {
+	_, err = q.do(defJobQueueRedisTimeout, `LPUSH`, q.keyQueue, string(msg))
+	if err != nil {
+		return fmt.Errorf(`push: %w`, err)
+	}
+	return nil
+}
+
+func (q *jobQueueRedis) pop(ctx context.Context) (msg []byte, err error) {
+	if ctx.Err() != nil {
+		return nil, nil
+	}
+
+	var reply any
+
+	reply, err = q.do(defJobQueueRedisTimeout+defJobQueueRedisPopTimeout*time.Second,
+		`BRPOPLPUSH`, q.keyQueue, q.keyProcessing,
+		strconv.Itoa(defJobQueueRedisPopTimeout))
+	if err != nil {
+		return nil, fmt.Errorf(`pop: %w`, err)
+	}
+	msg, _ = reply.([]byte)
+	return msg, nil
+}
+
+func (q *jobQueueRedis) ack(msg []byte) (err error) {
+	_, err = q.do(defJobQueueRedisTimeout, `LREM`, q.keyProcessing, `1`, string(msg))
+	if err != nil {
+		return fmt.Errorf(`ack: %w`, err)
+	}
+	return nil
+}
+
+func (q *jobQueueRedis) recover() (err error) {
+	var reply any
+	for {
+		reply, err = q.do(defJobQueueRedisTimeout, `RPOPLPUSH`, q.keyProcessing, q.keyQueue)
+		if err != nil {
+			return fmt.Errorf(`recover: %w`, err)
+		}
+		if reply == nil {
+			return nil
+		}
+	}
+}
+
+// do open new connection to Redis, authenticate and select the database
+// if its set, and execute the command.
+func (q *jobQueueRedis) do(timeout time.Duration, args ...string) (reply any, err error) {
+	var conn net.Conn
+
+	conn, err = net.DialTimeout(`tcp`, q.addr, defJobQueueRedisTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	err = conn.SetDeadline(time.Now().Add(timeout))
+	if err != nil {
+		return nil, err
+	}
+
+	var r = bufio.NewReader(conn)
+
+	if len(q.password) != 0 {
+		_, err = redisCommand(conn, r, `AUTH`, q.password)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if q.db != 0 {
+		_, err = redisCommand(conn, r, `SELECT`, strconv.Itoa(q.db))
+		if err != nil {
+			return nil, err
+		}
+	}
+	return redisCommand(conn, r, args...)
+}
+
+// redisCommand write the command in RESP format and read its reply.
+func redisCommand(w io.Writer, r *bufio.Reader, args ...string) (reply any, err error) {
+	var (
+		sb  strings.Builder
+		arg string
+	)
+	fmt.Fprintf(&sb, "*%d\r\n", len(args))
+	for _, arg = range args {
+		fmt.Fprintf(&sb, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+
+	_, err = io.WriteString(w, sb.String())
+	if err != nil {
+		return nil, err
+	}
+	return redisReadReply(r)
+}
+
+// redisReadReply read one reply in RESP format.
+// The simple string and bulk string is returned as []byte, integer as
+// int64, array as []any, and null as nil.
+func redisReadReply(r *bufio.Reader) (reply any, err error) {
+	var line string
+
+	line, err = r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	line = strings.TrimSuffix(line, "\r\n")
+	if len(line) == 0 {
+		return nil, errors.New(`redis: empty reply`)
+	}
+
+	var (
+		kind = line[0]
+		n    int64
+	)
+	line = line[1:]
+
+	switch kind {
+	case '+':
+		return []byte(line), nil
+
+	case '-':
+		return nil, errors.New(`redis: ` + line)
+
+	case ':':
+		return strconv.ParseInt(line, 10, 64)
+
+	case '$':
+		n, err = strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		var b = make([]byte, n+2)
+		_, err = io.ReadFull(r, b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+
+	case '*':
+		n, err = strconv.ParseInt(line, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		var (
+			list = make([]any, n)
+			x    int
+		)
+		for x = range list {
+			list[x], err = redisReadReply(r)
+			if err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	}
+	return nil, fmt.Errorf(`redis: unknown reply %q`, kind)
+}
@@ -0,0 +1,323 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+// fakeRedis implement the subset of Redis commands used by
+// jobQueueRedis.
+type fakeRedis struct {
+	lists    map[string][]string
+	password string
+	mtx      sync.Mutex
+}
+
+func newFakeRedis(t *testing.T, password string) (addr string, fr *fakeRedis) {
+	var ln, err = net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = ln.Close()
+	})
+
+	fr = &fakeRedis{
+		lists:    make(map[string][]string),
+		password: password,
+	}
+	go func() {
+		var conn net.Conn
+		for {
+			conn, err = ln.Accept()
+			if err != nil {
+				return
+			}
+			go fr.serve(conn)
+		}
+	}()
+	return ln.Addr().String(), fr
+}
+
+func (fr *fakeRedis) serve(conn net.Conn) {
+	defer conn.Close()
+
+	var (
+		r      = bufio.NewReader(conn)
+		isAuth = len(fr.password) == 0
+		reply  any
+		args   []any
+		cmd    []string
+		err    error
+	)
+	for {
+		reply, err = redisReadReply(r)
+		if err != nil {
+			return
+		}
+		args, _ = reply.([]any)
+		cmd = cmd[:0]
+		for _, reply = range args {
+			cmd = append(cmd, string(reply.([]byte)))
+		}
+		if cmd[0] == `AUTH` {
+			isAuth = cmd[1] == fr.password
+			_, _ = io.WriteString(conn, "+OK\r\n")
+			continue
+		}
+		if !isAuth {
+			_, _ = io.WriteString(conn, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		_, _ = io.WriteString(conn, fr.exec(cmd))
+	}
+}
+
+// list return the copy of list by its key.
+func (fr *fakeRedis) list(key string) []string {
+	fr.mtx.Lock()
+	defer fr.mtx.Unlock()
+	return slices.Clone(fr.lists[key])
+}
+
+func (fr *fakeRedis) exec(cmd []string) string {
+	fr.mtx.Lock()
+	defer fr.mtx.Unlock()
+
+	switch cmd[0] {
+	case `LPUSH`:
+		fr.lists[cmd[1]] = append([]string{cmd[2]}, fr.lists[cmd[1]]...)
+		return fmt.Sprintf(":%d\r\n", len(fr.lists[cmd[1]]))
+
+	case `BRPOPLPUSH`, `RPOPLPUSH`:
+		var src = fr.lists[cmd[1]]
+		if len(src) == 0 {
+			return "$-1\r\n"
+		}
+		var v = src[len(src)-1]
+		fr.lists[cmd[1]] = src[:len(src)-1]
+		fr.lists[cmd[2]] = append([]string{v}, fr.lists[cmd[2]]...)
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(v), v)
+
+	case `LREM`:
+		var (
+			list = fr.lists[cmd[1]]
+			x    = slices.Index(list, cmd[3])
+		)
+		if x < 0 {
+			return ":0\r\n"
+		}
+		fr.lists[cmd[1]] = slices.Delete(list, x, x+1)
+		return ":1\r\n"
+	}
+	return "-ERR unknown command\r\n"
+}
+
+func TestNewJobQueueRedis(t *testing.T) {
+	type testCase struct {
+		address  string
+		expError string
+		exp      jobQueueRedis
+	}
+
+	var cases = []testCase{{
+		address: `redis://127.0.0.1`,
+		exp: jobQueueRedis{
+			addr:          `127.0.0.1:6379`,
+			keyQueue:      `karajo:jobq`,
+			keyProcessing: `karajo:jobq:host`,
+		},
+	}, {
+		address: `redis://:s3cret@redis.local:6380/2`,
+		exp: jobQueueRedis{
+			addr:          `redis.local:6380`,
+			password:      `s3cret`,
+			keyQueue:      `karajo:jobq`,
+			keyProcessing: `karajo:jobq:host`,
+			db:            2,
+		},
+	}, {
+		address:  `tcp://127.0.0.1:6379`,
+		expError: `newJobQueueRedis: invalid queue_address "tcp://127.0.0.1:6379"`,
+	}, {
+		address:  `redis://127.0.0.1/db`,
+		expError: `newJobQueueRedis: invalid database "db"`,
+	}}
+
+	var (
+		c   testCase
+		q   *jobQueueRedis
+		err error
+	)
+	for _, c = range cases {
+		q, err = newJobQueueRedis(c.address, `host`)
+		if err != nil {
+			test.Assert(t, c.address, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.address, c.exp, *q)
+	}
+}
+
+func TestJobQueueRedis(t *testing.T) {
+	var (
+		addr, fr = newFakeRedis(t, `s3cret`)
+		ctx      = context.Background()
+
+		q   *jobQueueRedis
+		msg []byte
+		err error
+	)
+
+	q, err = newJobQueueRedis(`redis://:s3cret@`+addr, `host`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, msg = range [][]byte{[]byte(`msg1`), []byte("msg\r\n2")} {
+		err = q.push(msg)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// The message is popped in the order its pushed.
+	msg, err = q.pop(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `pop`, `msg1`, string(msg))
+
+	err = q.ack(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The second message is popped but not acknowledged, for example
+	// the instance is killed.
+	msg, err = q.pop(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `pop`, "msg\r\n2", string(msg))
+	test.Assert(t, `processing`, []string{"msg\r\n2"}, fr.list(q.keyProcessing))
+
+	// The next start recover the message.
+	err = q.recover()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `recover`, []string{"msg\r\n2"}, fr.list(q.keyQueue))
+
+	msg, err = q.pop(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `pop after recover`, "msg\r\n2", string(msg))
+
+	// Empty queue.
+	err = q.ack(msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg, err = q.pop(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `empty`, 0, len(msg))
+
+	// Invalid password.
+	q.password = `invalid`
+	err = q.push([]byte(`msg3`))
+	test.Assert(t, `invalid password`, true,
+		strings.Contains(err.Error(), `NOAUTH`))
+}
+
+func TestKarajo_consumeJobQueue(t *testing.T) {
+	var (
+		addr, fr = newFakeRedis(t, ``)
+		env      = Env{
+			DirBase:      t.TempDir(),
+			Secret:       `s3cret`,
+			QueueBackend: QueueBackendRedis,
+			QueueAddress: `redis://` + addr,
+		}
+		callq = make(chan []byte, 1)
+		job   = &JobExec{
+			Path: `/queued`,
+			Call: func(_ context.Context, _ io.Writer, epr *libhttp.EndpointRequest) error {
+				callq <- epr.RequestBody
+				return nil
+			},
+		}
+		logq = make(chan *JobLog, 1)
+		err  error
+	)
+
+	env.ExecJobs = map[string]*JobExec{
+		`queued`: job,
+	}
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job.Start(logq)
+	t.Cleanup(job.Stop)
+
+	var (
+		k           = &Karajo{env: &env}
+		ctx, cancel = context.WithCancel(context.Background())
+	)
+	t.Cleanup(cancel)
+
+	var epr = libhttp.EndpointRequest{
+		HTTPRequest: &http.Request{
+			Method: http.MethodPost,
+			Header: http.Header{},
+		},
+		RequestBody: []byte(`{"_karajo_epoch":1}`),
+	}
+	epr.HTTPRequest.Header.Set(HeaderNameXKarajoSign,
+		Sign(epr.RequestBody, []byte(env.Secret)))
+
+	_, err = job.handleHTTP(&epr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `pushed`, 1, len(fr.list(defJobQueueRedisKey)))
+
+	go k.consumeJobQueue(ctx)
+
+	test.Assert(t, `Call`, string(epr.RequestBody), string(<-callq))
+	<-logq
+
+	// The message is acknowledged after the job finished.
+	var (
+		keyProcessing = env.jobQueue.(*jobQueueRedis).keyProcessing
+		x             int
+	)
+	for x = 0; x < 100; x++ {
+		if len(fr.list(keyProcessing)) == 0 {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	test.Assert(t, `acknowledged`, 0, len(fr.list(keyProcessing)))
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// List of [JobExec.Priority].
+const (
+	JobPriorityHigh   = `high`
+	JobPriorityLow    = `low`
+	JobPriorityNormal = `normal` // Default priority if not set.
+)
+
+// List of priority as index in jobRunQueue.
+const (
+	jobPriorityLow = iota
+	jobPriorityNormal
+	jobPriorityHigh
+	jobPriorityCount
+)
+
+// jobRunQueue limit the number of JobExec running at the same time,
+// defined by Env.MaxJobRunning.
+//
+// If all of the slots are used, the job wait in the queue based on its
+// priority.
+// The job with higher priority get the free slot first, and the jobs with
+// the same priority get the slot in the order they are queued.
+type jobRunQueue struct {
+	// waiting list of job waiting for slot, indexed by priority.
+	// The channel is closed once the job get the slot.
+	waiting [jobPriorityCount][]chan struct{}
+
+	// running the number of job holding the slot.
+	running int
+
+	// max the maximum number of slot.
+	max int
+
+	mtx sync.Mutex
+}
+
+// newJobRunQueue create new jobRunQueue with max number of slots.
+func newJobRunQueue(max int) (runq *jobRunQueue) {
+	runq = &jobRunQueue{
+		max: max,
+	}
+	return runq
+}
+
+// parseJobPriority convert the priority name into its index.
+// Empty priority is equal to normal.
+func parseJobPriority(name string) (prio int, err error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case ``, JobPriorityNormal:
+		return jobPriorityNormal, nil
+	case JobPriorityLow:
+		return jobPriorityLow, nil
+	case JobPriorityHigh:
+		return jobPriorityHigh, nil
+	}
+	return 0, fmt.Errorf(`invalid priority %q`, name)
+}
+
+// acquire the slot for the job with priority prio, wait until one of the
+// slot is free.
+func (runq *jobRunQueue) acquire(prio int) {
+	runq.mtx.Lock()
+	if runq.running < runq.max {
+		runq.running++
+		runq.mtx.Unlock()
+		return
+	}
+
+	var readyq = make(chan struct{})
+
+	runq.waiting[prio] = append(runq.waiting[prio], readyq)
+	runq.mtx.Unlock()
+
+	<-readyq
+}
+
+// release the slot.
+// The slot is passed to the waiting job with the highest priority, if any.
+func (runq *jobRunQueue) release() {
+	runq.mtx.Lock()
+	defer runq.mtx.Unlock()
+
+	var (
+		readyq chan struct{}
+		prio   int
+	)
+	for prio = jobPriorityHigh; prio >= jobPriorityLow; prio-- {
+		if len(runq.waiting[prio]) == 0 {
+			continue
+		}
+		readyq = runq.waiting[prio][0]
+		runq.waiting[prio] = runq.waiting[prio][1:]
+		close(readyq)
+		return
+	}
+	runq.running--
+}
+
+// stats return the number of job holding the slot, the number of job
+// waiting for slot, and the maximum number of slot.
+func (runq *jobRunQueue) stats() (running, waiting, max int) {
+	runq.mtx.Lock()
+	defer runq.mtx.Unlock()
+
+	var list []chan struct{}
+	for _, list = range runq.waiting {
+		waiting += len(list)
+	}
+	return runq.running, waiting, runq.max
+}
@@ -0,0 +1,98 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestParseJobPriority(t *testing.T) {
+	type testCase struct {
+		name     string
+		expError string
+		exp      int
+	}
+
+	var cases = []testCase{{
+		name: ``,
+		exp:  jobPriorityNormal,
+	}, {
+		name: ` High `,
+		exp:  jobPriorityHigh,
+	}, {
+		name: `low`,
+		exp:  jobPriorityLow,
+	}, {
+		name:     `urgent`,
+		expError: `invalid priority "urgent"`,
+	}}
+
+	var (
+		c    testCase
+		prio int
+		err  error
+	)
+	for _, c = range cases {
+		prio, err = parseJobPriority(c.name)
+		if err != nil {
+			test.Assert(t, c.name, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.name, c.exp, prio)
+	}
+}
+
+func TestJobRunQueue(t *testing.T) {
+	var (
+		runq     = newJobRunQueue(1)
+		gotq     = make(chan string, 4)
+		listPrio = []struct {
+			name string
+			prio int
+		}{
+			{`low`, jobPriorityLow},
+			{`normal 1`, jobPriorityNormal},
+			{`high`, jobPriorityHigh},
+			{`normal 2`, jobPriorityNormal},
+		}
+		waiting int
+		x       int
+	)
+
+	runq.acquire(jobPriorityLow)
+
+	for x = range listPrio {
+		var job = listPrio[x]
+		go func() {
+			runq.acquire(job.prio)
+			gotq <- job.name
+		}()
+
+		// Wait until the job is queued, so the order is
+		// predictable.
+		for waiting != x+1 {
+			time.Sleep(time.Millisecond)
+			_, waiting, _ = runq.stats()
+		}
+	}
+
+	var got []string
+	for range listPrio {
+		runq.release()
+		got = append(got, <-gotq)
+	}
+	runq.release()
+
+	var exp = []string{`high`, `normal 1`, `normal 2`, `low`}
+	test.Assert(t, `order`, exp, got)
+
+	var running, max int
+	running, waiting, max = runq.stats()
+	test.Assert(t, `running`, 0, running)
+	test.Assert(t, `waiting`, 0, waiting)
+	test.Assert(t, `max`, 1, max)
+}
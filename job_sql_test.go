@@ -0,0 +1,220 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestSQLExpect(t *testing.T) {
+	type testCase struct {
+		raw    string
+		got    string
+		expErr string
+		exp    bool
+	}
+
+	var cases = []testCase{{
+		raw: `= 0`,
+		got: `0`,
+		exp: true,
+	}, {
+		raw: `>=1`,
+		got: `10`,
+		exp: true,
+	}, {
+		// Compared as number, not as string.
+		raw: `< 9`,
+		got: `10`,
+		exp: false,
+	}, {
+		raw: `!= 1.5`,
+		got: `1.50`,
+		exp: false,
+	}, {
+		raw: `= ok`,
+		got: `ok`,
+		exp: true,
+	}, {
+		raw: `!= NULL`,
+		got: `NULL`,
+		exp: false,
+	}, {
+		raw:    `~ 1`,
+		expErr: `unknown operator`,
+	}, {
+		raw:    `>=`,
+		expErr: `empty value`,
+	}}
+
+	var (
+		c      testCase
+		expect *sqlExpect
+		err    error
+	)
+	for _, c = range cases {
+		expect, err = parseSQLExpect(c.raw)
+		if err != nil {
+			test.Assert(t, c.raw, c.expErr, err.Error())
+			continue
+		}
+		test.Assert(t, c.raw+` `+c.got, c.exp, expect.match(c.got))
+	}
+}
+
+func TestJobSQL_init(t *testing.T) {
+	type testCase struct {
+		job    *JobSQL
+		expErr string
+	}
+
+	var cases = []testCase{{
+		job: &JobSQL{
+			SQLDriver: `oracle`,
+		},
+		expErr: `init_invalid: invalid sql_driver "oracle"`,
+	}, {
+		job: &JobSQL{
+			SQLDriver: `sqlite`,
+		},
+		expErr: `init_invalid: empty sql_dsn`,
+	}, {
+		job: &JobSQL{
+			SQLDriver: `sqlite`,
+			SQLDSN:    `:memory:`,
+			SQLQuery:  []string{` `},
+		},
+		expErr: `init_invalid: empty sql_query`,
+	}, {
+		job: &JobSQL{
+			SQLDriver:     `sqlite`,
+			SQLDSN:        `:memory:`,
+			SQLQuery:      []string{`SELECT 1`},
+			SQLExpectRows: `> some`,
+		},
+		expErr: `init_invalid: invalid sql_expect_rows "> some": expecting number`,
+	}}
+
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+		}
+
+		c   testCase
+		err error
+	)
+
+	err = env.initDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c = range cases {
+		err = c.job.init(&env, `init invalid`)
+		test.Assert(t, `error`, c.expErr, err.Error())
+	}
+}
+
+func TestJobSQL_execute(t *testing.T) {
+	type testCase struct {
+		desc      string
+		expLog    string
+		queries   []string
+		expRows   string
+		expValue  string
+		expStatus string
+	}
+
+	var cases = []testCase{{
+		desc: `rows affected`,
+		queries: []string{
+			`CREATE TABLE session (id INTEGER, expired INTEGER)`,
+			`INSERT INTO session VALUES (1, 1), (2, 0), (3, 1)`,
+			`DELETE FROM session WHERE expired = 1`,
+		},
+		expRows:   `= 5`,
+		expStatus: JobStatusSuccess,
+		expLog:    "--- SQL rows affected: 2\n",
+	}, {
+		desc: `rows affected not expected`,
+		queries: []string{
+			`DELETE FROM session`,
+		},
+		expRows:   `= 0`,
+		expStatus: JobStatusFailed,
+		expLog:    "!!! job_sql: sql_test: execute: rows affected 1, expecting = 0\n",
+	}, {
+		desc: `scalar value`,
+		queries: []string{
+			`INSERT INTO session VALUES (4, 0), (5, 0)`,
+			`SELECT COUNT(*) FROM session`,
+		},
+		expValue:  `<= 2`,
+		expStatus: JobStatusSuccess,
+		expLog:    "--- SQL value: 2\n",
+	}, {
+		desc: `scalar value not expected`,
+		queries: []string{
+			`SELECT MAX(id) FROM session WHERE id > 10`,
+		},
+		expValue:  `!= NULL`,
+		expStatus: JobStatusFailed,
+		expLog:    "!!! job_sql: sql_test: execute: value \"NULL\", expecting != NULL\n",
+	}, {
+		desc: `invalid query`,
+		queries: []string{
+			`SELECT * FROM missing`,
+		},
+		expStatus: JobStatusFailed,
+		expLog:    "!!! job_sql: sql_test: execute: no such table: missing\n",
+	}}
+
+	var (
+		env = Env{
+			DirBase: t.TempDir(),
+		}
+
+		c    testCase
+		job  *JobSQL
+		jlog *JobLog
+		raw  []byte
+		err  error
+	)
+
+	err = env.initDirs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, c = range cases {
+		job = &JobSQL{
+			SQLDriver:      JobSQLDriverSQLite,
+			SQLDSN:         filepath.Join(env.DirBase, `test.db`),
+			SQLQuery:       c.queries,
+			SQLExpectRows:  c.expRows,
+			SQLExpectValue: c.expValue,
+		}
+		err = job.init(&env, `sql test`)
+		if err != nil {
+			t.Fatal(c.desc, err)
+		}
+
+		jlog, err = job.execute()
+		job.finish(jlog, err)
+
+		test.Assert(t, c.desc+`: status`, c.expStatus, job.Status)
+
+		raw, err = jlog.read()
+		if err != nil {
+			t.Fatal(c.desc, err)
+		}
+		if !strings.Contains(string(raw), c.expLog) {
+			t.Fatalf("%s: log does not contains %q:\n%s", c.desc, c.expLog, raw)
+		}
+	}
+}
@@ -0,0 +1,111 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// jobSubscriberAMQP consume the messages from AMQP 0-9-1 queue, for
+// example RabbitMQ.
+// The queue must already exist in the broker.
+// Only one message is delivered at a time, and it is acknowledged once the
+// job finished.
+type jobSubscriberAMQP struct {
+	url         string
+	queue       string
+	consumerTag string
+}
+
+// newJobSubscriberAMQP create the AMQP subscriber for job.
+// The SubscribeURL is in the format "amqp://host:port/vhost", or
+// "amqps://host:port/vhost" for connecting with TLS.
+// The SubscribeTopic is the name of queue.
+func newJobSubscriberAMQP(job *JobExec) (sub *jobSubscriberAMQP, err error) {
+	var amqpURL *url.URL
+
+	amqpURL, err = url.Parse(job.SubscribeURL)
+	if err != nil {
+		return nil, fmt.Errorf(`newJobSubscriberAMQP: %w`, err)
+	}
+	if len(job.subscribeUser) != 0 {
+		amqpURL.User = url.UserPassword(job.subscribeUser, job.subscribePassword)
+	}
+
+	sub = &jobSubscriberAMQP{
+		url:         amqpURL.String(),
+		queue:       job.SubscribeTopic,
+		consumerTag: subscribeClientID(job.ID),
+	}
+	return sub, nil
+}
+
+// consume the messages until the ctx canceled or the connection closed.
+func (sub *jobSubscriberAMQP) consume(ctx context.Context, msgq chan<- *libhttp.EndpointRequest) (err error) {
+	var (
+		logp = `consume`
+		conn *amqp.Connection
+	)
+
+	conn, err = amqp.DialConfig(sub.url, amqp.Config{
+		Dial: amqp.DefaultDial(defJobSubscribeTimeout),
+	})
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	defer conn.Close()
+
+	var ch *amqp.Channel
+
+	ch, err = conn.Channel()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	err = ch.Qos(1, 0, false)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var deliveries <-chan amqp.Delivery
+
+	deliveries, err = ch.Consume(sub.queue, sub.consumerTag, false, false, false, false, nil)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		d   amqp.Delivery
+		epr *libhttp.EndpointRequest
+		ok  bool
+	)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+
+		case d, ok = <-deliveries:
+			if !ok {
+				return fmt.Errorf(`%s: %w`, logp, errors.New(`channel closed`))
+			}
+		}
+		epr = newJobMessageRequest(d.RoutingKey, d.Body, ackAMQPDelivery(d))
+		if !pushJobMessage(ctx, msgq, epr) {
+			return nil
+		}
+	}
+}
+
+// ackAMQPDelivery return the function to acknowledge the delivery.
+func ackAMQPDelivery(d amqp.Delivery) func() {
+	return func() {
+		_ = d.Ack(false)
+	}
+}
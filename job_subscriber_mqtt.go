@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"fmt"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+// jobSubscriberMQTT consume the messages from MQTT broker with QoS 1.
+// The message is acknowledged once the job finished.
+type jobSubscriberMQTT struct {
+	opts  *mqtt.ClientOptions
+	topic string
+}
+
+// newJobSubscriberMQTT create the MQTT subscriber for job.
+// The SubscribeURL is in the format "mqtt://host:port", or
+// "mqtts://host:port" for connecting with TLS.
+func newJobSubscriberMQTT(job *JobExec) (sub *jobSubscriberMQTT) {
+	sub = &jobSubscriberMQTT{
+		topic: job.SubscribeTopic,
+	}
+	sub.opts = mqtt.NewClientOptions().
+		AddBroker(job.SubscribeURL).
+		SetClientID(subscribeClientID(job.ID)).
+		SetUsername(job.subscribeUser).
+		SetPassword(job.subscribePassword).
+		SetConnectTimeout(defJobSubscribeTimeout).
+		SetAutoReconnect(false).
+		SetAutoAckDisabled(true).
+		// Each message is handled in its own goroutine, so the
+		// handler can wait for the job without blocking the client.
+		SetOrderMatters(false)
+	return sub
+}
+
+// consume the messages until the ctx canceled or the connection lost.
+func (sub *jobSubscriberMQTT) consume(ctx context.Context, msgq chan<- *libhttp.EndpointRequest) (err error) {
+	var (
+		logp  = `consume`
+		lostq = make(chan error, 1)
+		opts  = *sub.opts
+	)
+
+	opts.SetConnectionLostHandler(func(_ mqtt.Client, errLost error) {
+		select {
+		case lostq <- errLost:
+		default:
+		}
+	})
+
+	var (
+		cl  = mqtt.NewClient(&opts)
+		tok = cl.Connect()
+	)
+	tok.Wait()
+	err = tok.Error()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	defer cl.Disconnect(250)
+
+	tok = cl.Subscribe(sub.topic, 1, func(_ mqtt.Client, msg mqtt.Message) {
+		var epr = newJobMessageRequest(msg.Topic(), msg.Payload(), msg.Ack)
+		pushJobMessage(ctx, msgq, epr)
+	})
+	tok.Wait()
+	err = tok.Error()
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	select {
+	case <-ctx.Done():
+		return nil
+	case err = <-lostq:
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+}
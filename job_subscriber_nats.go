@@ -0,0 +1,83 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"fmt"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"github.com/nats-io/nats.go"
+)
+
+// jobSubscriberNATS consume the messages from NATS subject.
+// The subscription join the queue group "karajo.<job_id>", so each
+// message is consumed by one karajo instance only.
+type jobSubscriberNATS struct {
+	url      string
+	subject  string
+	queue    string
+	opts     []nats.Option
+	clientID string
+}
+
+// newJobSubscriberNATS create the NATS subscriber for job.
+// The SubscribeURL is in the format "nats://host:port", or
+// "tls://host:port" for connecting with TLS.
+func newJobSubscriberNATS(job *JobExec) (sub *jobSubscriberNATS) {
+	sub = &jobSubscriberNATS{
+		url:      job.SubscribeURL,
+		subject:  job.SubscribeTopic,
+		queue:    `karajo.` + job.ID,
+		clientID: subscribeClientID(job.ID),
+	}
+	sub.opts = append(sub.opts,
+		nats.Name(sub.clientID),
+		nats.Timeout(defJobSubscribeTimeout),
+	)
+	if len(job.subscribeUser) != 0 {
+		sub.opts = append(sub.opts,
+			nats.UserInfo(job.subscribeUser, job.subscribePassword))
+	}
+	return sub
+}
+
+// consume the messages until the ctx canceled or the connection closed.
+func (sub *jobSubscriberNATS) consume(ctx context.Context, msgq chan<- *libhttp.EndpointRequest) (err error) {
+	var (
+		logp = `consume`
+		nc   *nats.Conn
+	)
+
+	nc, err = nats.Connect(sub.url, sub.opts...)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	defer nc.Close()
+
+	var natsSub *nats.Subscription
+
+	natsSub, err = nc.QueueSubscribeSync(sub.subject, sub.queue)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		msg *nats.Msg
+		epr *libhttp.EndpointRequest
+	)
+	for {
+		msg, err = natsSub.NextMsgWithContext(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		epr = newJobMessageRequest(msg.Subject, msg.Data, nil)
+		if !pushJobMessage(ctx, msgq, epr) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	libhtml "git.sr.ht/~shulhan/pakakeh.go/lib/html"
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+const (
+	// defJobSummaryName the name of built-in JobExec that send the
+	// summary of all jobs execution.
+	defJobSummaryName = `karajo summary`
+
+	// defSummaryPeriod define the range of job logs to be included in
+	// the summary, counted backward from the time summary generated.
+	defSummaryPeriod = 24 * time.Hour
+)
+
+// jobSummary generate the summary of all jobs execution in the last 24
+// hours from their logs.
+type jobSummary struct {
+	env *Env
+	id  string
+}
+
+// summaryItem contains the statistic of single job in the summary.
+type summaryItem struct {
+	kind jobKind
+	id   string
+
+	slowest        time.Duration
+	slowestCounter int64
+
+	nrun    int
+	nfailed int
+}
+
+// newJobSummary create new JobExec that generate the summary of all jobs
+// based on env SummarySchedule and send it to SummaryNotif.
+func newJobSummary(env *Env) (job *JobExec) {
+	var js = &jobSummary{
+		env: env,
+		id:  libhtml.NormalizeForID(defJobSummaryName),
+	}
+
+	job = &JobExec{
+		JobBase: JobBase{
+			Description:    `Send the summary of all jobs execution in the last 24 hours.`,
+			Schedule:       env.SummarySchedule,
+			NotifOnSuccess: env.SummaryNotif,
+			NotifOnFailed:  env.SummaryNotif,
+		},
+		Call: js.call,
+	}
+	return job
+}
+
+// call write the summary into the job log.
+func (js *jobSummary) call(_ context.Context, log io.Writer, _ *libhttp.EndpointRequest) (err error) {
+	var summary = js.generate(timeNow())
+
+	_, err = log.Write(summary)
+	return err
+}
+
+// generate the summary of all jobs that finished between (now - 24h) and
+// now.
+func (js *jobSummary) generate(now time.Time) (summary []byte) {
+	var (
+		since = now.Add(-defSummaryPeriod)
+
+		items   []*summaryItem
+		item    *summaryItem
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+
+	js.env.jobsMtx.RLock()
+	for _, job = range js.env.ExecJobs {
+		if job.ID == js.id {
+			continue
+		}
+		item = summarizeJob(&job.JobBase, since, now)
+		items = append(items, item)
+	}
+	for _, jobHTTP = range js.env.HTTPJobs {
+		item = summarizeJob(&jobHTTP.JobBase, since, now)
+		items = append(items, item)
+	}
+	for _, jobSQL = range js.env.SQLJobs {
+		item = summarizeJob(&jobSQL.JobBase, since, now)
+		items = append(items, item)
+	}
+	js.env.jobsMtx.RUnlock()
+
+	sort.Slice(items, func(x, y int) bool {
+		if items[x].kind == items[y].kind {
+			return items[x].id < items[y].id
+		}
+		return items[x].kind < items[y].kind
+	})
+
+	var (
+		buf bytes.Buffer
+		tw  = tabwriter.NewWriter(&buf, 0, 8, 2, ' ', 0)
+
+		nrun    int
+		nfailed int
+		slowest string
+	)
+
+	fmt.Fprintf(&buf, "Summary of jobs execution from %s until %s.\n\n",
+		since.Format(defTimeLayout), now.Format(defTimeLayout))
+
+	fmt.Fprintln(tw, "KIND\tID\tRUNS\tFAILED\tSLOWEST")
+	for _, item = range items {
+		slowest = `-`
+		if item.slowestCounter > 0 {
+			slowest = fmt.Sprintf(`#%d (%s)`, item.slowestCounter, item.slowest)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", item.kind, item.id,
+			item.nrun, item.nfailed, slowest)
+
+		nrun += item.nrun
+		nfailed += item.nfailed
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(&buf, "\nTotal %d jobs, %d runs, %d failed.\n", len(items), nrun, nfailed)
+
+	return buf.Bytes()
+}
+
+// summarizeJob collect the number of runs, failures, and the slowest run
+// from the job logs that finished between since and until.
+func summarizeJob(job *JobBase, since, until time.Time) (item *summaryItem) {
+	var logs []*JobLog
+
+	job.Lock()
+	item = &summaryItem{
+		kind: job.kind,
+		id:   job.ID,
+	}
+	logs = append(logs, job.Logs...)
+	job.Unlock()
+
+	var (
+		jlog     *JobLog
+		timeEnd  time.Time
+		status   string
+		duration time.Duration
+	)
+	for _, jlog = range logs {
+		jlog.Lock()
+		timeEnd = jlog.timeEnd
+		status = jlog.Status
+		jlog.Unlock()
+
+		if timeEnd.IsZero() || timeEnd.Before(since) || timeEnd.After(until) {
+			continue
+		}
+
+		item.nrun++
+		if status == JobStatusFailed {
+			item.nfailed++
+		}
+
+		duration = jlog.duration()
+		if item.slowestCounter == 0 || duration > item.slowest {
+			item.slowest = duration
+			item.slowestCounter = jlog.Counter
+		}
+	}
+	return item
+}
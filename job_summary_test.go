@@ -0,0 +1,91 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestJobSummary_generate(t *testing.T) {
+	var (
+		now = timeNow()
+
+		tdata *test.Data
+		err   error
+	)
+
+	tdata, err = test.LoadData(`testdata/job_summary_test.txt`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		jobFail = &JobExec{
+			JobBase: JobBase{
+				ID:   `test_fail`,
+				kind: jobKindExec,
+				Logs: []*JobLog{{
+					// Outside of summary period.
+					Counter: 1,
+					Status:  JobStatusSuccess,
+					timeEnd: now.Add(-25 * time.Hour),
+				}, {
+					Counter:   2,
+					Status:    JobStatusFailed,
+					timeBegin: now.Add(-2 * time.Hour),
+					timeEnd:   now.Add(-2*time.Hour + 30*time.Second),
+				}, {
+					Counter: 3,
+					Status:  JobStatusSuccess,
+					content: []byte(now.Add(-time.Hour).Format(defTimeLayout) +
+						" job: test_fail: === BEGIN\n"),
+					timeEnd: now.Add(-time.Hour + 90*time.Second),
+				}},
+			},
+		}
+		jobIdle = &JobExec{
+			JobBase: JobBase{
+				ID:   `test_idle`,
+				kind: jobKindExec,
+			},
+		}
+		jobHTTP = &JobHTTP{
+			JobBase: JobBase{
+				ID:   `test_http`,
+				kind: jobKindHTTP,
+				Logs: []*JobLog{{
+					Counter:   7,
+					Status:    JobStatusSuccess,
+					timeBegin: now.Add(-time.Minute),
+					timeEnd:   now.Add(-time.Minute + time.Second),
+				}},
+			},
+		}
+		env = &Env{
+			SummarySchedule: `daily@00:00`,
+			ExecJobs: map[string]*JobExec{
+				`test fail`: jobFail,
+				`test idle`: jobIdle,
+			},
+			HTTPJobs: map[string]*JobHTTP{
+				`test http`: jobHTTP,
+			},
+		}
+	)
+
+	env.ExecJobs[defJobSummaryName] = newJobSummary(env)
+	env.ExecJobs[defJobSummaryName].ID = `karajo_summary`
+
+	var js = &jobSummary{
+		env: env,
+		id:  `karajo_summary`,
+	}
+
+	var got = js.generate(now)
+
+	test.Assert(t, `generate`, string(tdata.Output[`summary.txt`]), string(got))
+}
@@ -14,11 +14,18 @@
 package karajo
 
 import (
+	"context"
 	"crypto/hmac"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
+	"net"
 	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	liberrors "git.sr.ht/~shulhan/pakakeh.go/lib/errors"
@@ -27,6 +34,10 @@ import (
 	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
 )
 
+// defFileAddress the name of file, inside the run directory, that contains
+// the address where the HTTP server listen.
+const defFileAddress = `address`
+
 // Version of this library and program.
 var Version = `0.9.3`
 
@@ -55,13 +66,78 @@ type Karajo struct {
 	env *Env
 	sm  *sessionManager
 
-	// jobq is the channel that limit the number of job running at the
-	// same time.
-	// This limit can be overwritten by MaxJobRunning.
-	jobq chan struct{}
+	// authl limit the failed login per IP address and per user name.
+	authl *authLimiter
 
 	// logq is used to collect all job log once they finished.
 	logq chan *JobLog
+
+	// listener the network where HTTPd listen for connection.
+	listener net.Listener
+
+	// listenerHooks the network where httpdHooks listen for
+	// connection, only if ListenAddressHooks is set.
+	listenerHooks net.Listener
+
+	// httpdHooks the HTTP server that serve only the JobExec hooks.
+	httpdHooks *http.Server
+
+	// janitorStopq stop the log janitor.
+	janitorStopq chan struct{}
+
+	// jobQueueCancel stop consuming the shared job queue.
+	jobQueueCancel context.CancelFunc
+
+	// assetVersion contains the WUI asset path and its version.
+	assetVersion map[string]string
+
+	// www the copy of embedded WUI files for this instance.
+	www *memfs.MemFS
+
+	// evals list of evaluator registered in HTTPd, to be registered in
+	// the HTTP server of JobExec hook that added after started.
+	evals []libhttp.Evaluator
+
+	// hookMtx protect the bodyLimits, hookJobs, and hookServers, since
+	// they can be changed by AddJobExec and RemoveJobExec.
+	hookMtx sync.RWMutex
+
+	// bodyLimits contains the maximum size of request body per JobExec
+	// hook path, that override the global MaxRequestBody.
+	bodyLimits map[string]int64
+
+	// hookJobs contains the JobExec indexed by its hook path.
+	hookJobs map[string]*JobExec
+
+	// hookServers contains the HTTP server for the JobExec hook that
+	// added after the HTTPd initialized, indexed by its hook path,
+	// since the endpoint cannot be registered into the running HTTPd.
+	hookServers map[string]*libhttp.Server
+
+	// apis contains the registered HTTP APIs, used to generate the
+	// OpenAPI document.
+	apis []apiEndpoint
+
+	// fed fetch and cache the jobs status from peers.
+	fed *federation
+
+	// notifPools contains the pool of workers for each notification,
+	// indexed by its name.
+	notifPools map[string]*notifPool
+
+	// notifPending count the notification that are being sent.
+	notifPending atomic.Int64
+
+	// jobsMtx serialize the StartJobs, StopJobs, AddJobExec, and
+	// RemoveJobExec.
+	jobsMtx sync.Mutex
+
+	// isJobsStarted true if the jobs has been started by StartJobs,
+	// so the job added by AddJobExec is started immediately.
+	isJobsStarted bool
+
+	// isEmbedded true if the Karajo created using NewEmbedded.
+	isEmbedded bool
 }
 
 // Sign generate hex string of HMAC + SHA256 of payload using the secret.
@@ -78,33 +154,142 @@ func Sign(payload, secret []byte) (sign string) {
 func New(env *Env) (k *Karajo, err error) {
 	var logp = `New`
 
-	err = env.init()
+	k, err = newKarajo(env)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	k = &Karajo{
-		env:  env,
-		sm:   newSessionManager(),
-		jobq: make(chan struct{}, env.MaxJobRunning),
-		logq: make(chan *JobLog),
+	err = k.listen()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	mlog.SetPrefix(env.Name + `:`)
+	err = k.listenHooks()
+	if err != nil {
+		_ = k.listener.Close()
+		_ = os.Remove(filepath.Join(env.dirRun, defFileAddress))
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
 
-	err = k.initMemfs()
+	err = k.initHTTPd()
+	if err != nil {
+		_ = k.listener.Close()
+		if k.listenerHooks != nil {
+			_ = k.listenerHooks.Close()
+		}
+		_ = os.Remove(filepath.Join(env.dirRun, defFileAddress))
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	k.initHTTPdHooks()
+
+	return k, nil
+}
+
+// NewEmbedded create and initialize Karajo that does not own the
+// network listener, so it can be embedded into the existing HTTP server.
+//
+// The HTTP handler is available from [Karajo.Handler] or registered using
+// [Karajo.RegisterRoutes], and the jobs is started and stopped using
+// [Karajo.StartJobs] and [Karajo.StopJobs].
+// The ListenAddress in Env should be set to the address of the existing
+// HTTP server, since its used by JobHttp to call the karajo APIs.
+// The ListenAddressHooks is ignored.
+func NewEmbedded(env *Env) (k *Karajo, err error) {
+	var logp = `NewEmbedded`
+
+	k, err = newKarajo(env)
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
+	k.isEmbedded = true
+
 	err = k.initHTTPd()
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
+	return k, nil
+}
+
+// newKarajo create and initialize Karajo, except its HTTP server.
+func newKarajo(env *Env) (k *Karajo, err error) {
+	k = &Karajo{
+		env:          env,
+		sm:           newSessionManager(),
+		janitorStopq: make(chan struct{}, 1),
+	}
 
+	if env.MetricsInterval > 0 {
+		env.jobMetrics = newJobMetrics(k)
+	}
+
+	err = env.init()
+	if err != nil {
+		return nil, err
+	}
+
+	k.logq = make(chan *JobLog, env.NotifQueueSize)
+	k.fed = newFederation(env.Peers)
+	k.authl = newAuthLimiter(env.AuthMaxAttempts, env.AuthLockoutDuration)
+
+	k.sm.ttl = env.SessionTTL
+	err = k.sm.load(filepath.Join(env.dirRun, defSessionFile), env.Users,
+		env.AuthOIDC)
+	if err != nil {
+		return nil, err
+	}
+
+	mlog.SetPrefix(env.Name + `:`)
+
+	err = k.initMemfs()
+	if err != nil {
+		return nil, err
+	}
 	return k, nil
 }
 
+// listen open the network listener on ListenAddress.
+// If the port in ListenAddress is 0, the ListenAddress is replaced with the
+// actual address.
+// The actual address is written into file "address" in the run directory,
+// so other program can discover it.
+func (k *Karajo) listen() (err error) {
+	var logp = `listen`
+
+	k.listener, err = net.Listen(`tcp`, k.env.ListenAddress)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		addr    = k.listener.Addr().String()
+		port    string
+		errPort error
+	)
+	_, port, errPort = net.SplitHostPort(k.env.ListenAddress)
+	if errPort == nil && port == `0` {
+		k.env.ListenAddress = addr
+
+		var jobHTTP *JobHTTP
+		for _, jobHTTP = range k.env.HTTPJobs {
+			jobHTTP.setServerAddress(addr)
+		}
+	}
+
+	var fileAddress = filepath.Join(k.env.dirRun, defFileAddress)
+
+	err = os.WriteFile(fileAddress, []byte(addr+"\n"), 0600)
+	if err != nil {
+		_ = k.listener.Close()
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	mlog.Outf(`listening at %s, address written to %s`, addr, fileAddress)
+
+	return nil
+}
+
 // initMemfs initialize the memory file system for serving the WUI and public
 // directory.
 func (k *Karajo) initMemfs() (err error) {
@@ -114,7 +299,12 @@ func (k *Karajo) initMemfs() (err error) {
 		return fmt.Errorf(`%s: empty embedded www`, logp)
 	}
 
-	memfsWww.Opts.TryDirect = k.env.IsDevelopment
+	k.www = cloneMemfs(memfsWww)
+	k.www.Opts.TryDirect = k.env.IsDevelopment
+
+	k.initBasePath(k.www)
+	k.initAssetVersion(k.www)
+	k.initTheme(k.www)
 
 	if len(k.env.DirPublic) == 0 {
 		return nil
@@ -133,69 +323,207 @@ func (k *Karajo) initMemfs() (err error) {
 		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	memfsWww.Merge(memfsPublic)
+	k.www.Merge(memfsPublic)
 
 	return nil
 }
 
+// Handler return the HTTP handler that serve the karajo WUI and APIs.
+// The handler can be mounted on the existing HTTP server when Karajo
+// created using [NewEmbedded].
+func (k *Karajo) Handler() http.Handler {
+	return k.HTTPd.Server.Handler
+}
+
+// RegisterRoutes register the karajo WUI and APIs, "/karajo/" prefixed
+// with BasePath, into mux.
+// The files in DirPublic, if its set, are not registered; use
+// [Karajo.Handler] to serve them.
+func (k *Karajo) RegisterRoutes(mux *http.ServeMux) {
+	mux.Handle(k.urlPath(pathKarajo), k.Handler())
+}
+
 // Start all the jobs and the HTTP server.
 func (k *Karajo) Start() (err error) {
+	if k.isEmbedded {
+		return errors.New(`Start: embedded karajo, use StartJobs instead`)
+	}
+
+	mlog.Outf(`started the karajo server at http://%s%s/karajo`, k.HTTPd.Addr, k.env.BasePath)
+
+	k.StartJobs()
+
+	if k.httpdHooks != nil {
+		go k.serveHooks()
+	}
+
+	err = k.HTTPd.Serve(k.listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		err = nil
+	}
+	return err
+}
+
+// StartJobs start all the jobs and their workers, without the HTTP
+// server.
+func (k *Karajo) StartJobs() {
 	var (
 		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
 		job     *JobExec
 	)
 
-	mlog.Outf(`started the karajo server at http://%s/karajo`, k.HTTPd.Addr)
+	k.jobsMtx.Lock()
+	defer k.jobsMtx.Unlock()
 
 	if len(k.env.notif) > 0 {
+		k.startNotifPools()
 		go k.workerNotification()
 	}
 
+	go k.sm.runSweeper(defSessionSweepInterval)
+	go k.runLogJanitor(defLogJanitorInterval)
+
+	if k.env.HARole != nil {
+		k.env.HARole.campaign(timeNow())
+		go k.env.HARole.run()
+	}
+
+	k.env.jobsMtx.RLock()
 	for _, job = range k.env.ExecJobs {
-		go job.Start(k.jobq, k.logq)
-		<-k.jobq
+		job.Start(k.logq)
 	}
+	k.env.jobsMtx.RUnlock()
 	for _, jobHTTP = range k.env.HTTPJobs {
-		go jobHTTP.Start(k.jobq, k.logq)
-		<-k.jobq
+		jobHTTP.Start(k.logq)
+	}
+	for _, jobSQL = range k.env.SQLJobs {
+		jobSQL.Start(k.logq)
 	}
 
-	return k.HTTPd.Start()
+	if k.env.jobQueue != nil {
+		var ctx context.Context
+		ctx, k.jobQueueCancel = context.WithCancel(context.Background())
+		go k.consumeJobQueue(ctx)
+	}
+
+	k.isJobsStarted = true
 }
 
 // Stop all the jobs and the HTTP server.
 func (k *Karajo) Stop() (err error) {
+	k.StopJobs()
+
+	if k.isEmbedded {
+		return nil
+	}
+
+	err = k.HTTPd.Stop(5 * time.Second)
+
+	var errHooks = k.stopHooks(5 * time.Second)
+	if err == nil {
+		err = errHooks
+	}
+
+	// Close the listener in case the server is not started.
+	_ = k.listener.Close()
+	_ = os.Remove(filepath.Join(k.env.dirRun, defFileAddress))
+
+	return err
+}
+
+// StopJobs stop all the jobs and their workers, without the HTTP server.
+func (k *Karajo) StopJobs() {
 	var (
 		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
 		job     *JobExec
 	)
 
+	k.jobsMtx.Lock()
+	defer k.jobsMtx.Unlock()
+
+	k.isJobsStarted = false
+
 	for _, jobHTTP = range k.env.HTTPJobs {
 		jobHTTP.Stop()
 	}
+	for _, jobSQL = range k.env.SQLJobs {
+		jobSQL.Stop()
+	}
+
+	k.env.jobsMtx.RLock()
+	var jobs = make([]*JobExec, 0, len(k.env.ExecJobs))
 	for _, job = range k.env.ExecJobs {
+		jobs = append(jobs, job)
+	}
+	k.env.jobsMtx.RUnlock()
+
+	for _, job = range jobs {
 		job.Stop()
 	}
+	// Wait for the commands to be stopped gracefully, after all of the
+	// jobs has been canceled.
+	for _, job = range jobs {
+		job.waitCommand()
+	}
+
+	k.sm.stopSweeper()
+	k.stopLogJanitor()
+
+	var pool *notifPool
+	for _, pool = range k.notifPools {
+		pool.Close()
+	}
+
+	var clientNotif NotifClient
+	for _, clientNotif = range k.env.notif {
+		clientNotif.Close()
+	}
+
+	if k.jobQueueCancel != nil {
+		k.jobQueueCancel()
+	}
+
+	if k.env.HARole != nil {
+		k.env.HARole.stop()
+	}
+}
 
-	return k.HTTPd.Stop(5 * time.Second)
+// startNotifPools create and start the pool of workers for each
+// notification client.
+func (k *Karajo) startNotifPools() {
+	var (
+		name        string
+		clientNotif NotifClient
+		pool        *notifPool
+	)
+	k.notifPools = make(map[string]*notifPool, len(k.env.notif))
+	for name, clientNotif = range k.env.notif {
+		pool = newNotifPool(name, clientNotif,
+			k.env.Notif[name].MaxConcurrent, &k.notifPending)
+		pool.start()
+		k.notifPools[name] = pool
+	}
 }
 
 // workerNotification receive JobLog from JobExec and JobHTTP everytime
-// their started, running, success, failed, or paused.
+// their started, running, success, failed, or paused, and push it to the
+// pool of each notification in the job log.
 func (k *Karajo) workerNotification() {
 	var (
 		jlog         *JobLog
-		clientNotif  notifClient
-		notifName    string
+		pool         *notifPool
 		logNotifName string
 	)
 	for jlog = range k.logq {
 		for _, logNotifName = range jlog.listNotif {
-			for notifName, clientNotif = range k.env.notif {
-				if logNotifName != notifName {
-					continue
-				}
-				go clientNotif.Send(jlog)
+			pool = k.notifPools[logNotifName]
+			if pool == nil {
+				continue
+			}
+			if !pool.push(jlog) {
+				k.env.notifDropped.Add(1)
 			}
 		}
 	}
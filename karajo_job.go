@@ -0,0 +1,255 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"net/http"
+	"path"
+	"slices"
+
+	libhtml "git.sr.ht/~shulhan/pakakeh.go/lib/html"
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// AddJobExec initialize and register new JobExec, identified by its Name,
+// after the Karajo created.
+// If the job has Path, its hook is served immediately.
+// If the jobs has been started, the new job is started too.
+//
+// It will return an error if the job is invalid or the job with the same
+// name, ID, or hook path already exist.
+func (k *Karajo) AddJobExec(job *JobExec) (err error) {
+	var logp = `AddJobExec`
+
+	if job == nil || len(job.Name) == 0 {
+		return fmt.Errorf(`%s: empty job name`, logp)
+	}
+
+	k.jobsMtx.Lock()
+	defer k.jobsMtx.Unlock()
+
+	err = k.env.addJobExec(job)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	if len(job.Path) != 0 {
+		err = k.addJobHookServer(job)
+		if err != nil {
+			k.env.removeJobExec(job)
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	if k.isJobsStarted {
+		job.Start(k.logq)
+	}
+	return nil
+}
+
+// RemoveJobExec stop and unregister the JobExec by its ID.
+// The running command is stopped gracefully, see the StopSignal in
+// JobExec.
+// The job logs and directories are not removed.
+func (k *Karajo) RemoveJobExec(id string) (err error) {
+	k.jobsMtx.Lock()
+	defer k.jobsMtx.Unlock()
+
+	var job = k.env.jobExec(id)
+	if job == nil {
+		return fmt.Errorf(`RemoveJobExec: %w`, errJobNotFound(id))
+	}
+
+	k.removeJobHook(job)
+	k.env.removeJobExec(job)
+
+	job.Stop()
+	job.waitCommand()
+
+	return nil
+}
+
+// addJobExec initialize the job and add it into ExecJobs.
+func (env *Env) addJobExec(job *JobExec) (err error) {
+	env.jobsMtx.Lock()
+	defer env.jobsMtx.Unlock()
+
+	var (
+		name = job.Name
+		id   = libhtml.NormalizeForID(name)
+	)
+	if env.ExecJobs[name] != nil {
+		return fmt.Errorf(`job %q already exist`, name)
+	}
+	if env.execJobByID[id] != nil {
+		return fmt.Errorf(`job %q: duplicate job ID %q`, name, id)
+	}
+
+	err = job.init(env, name)
+	if err != nil {
+		job.ns.removeJob(&job.JobBase)
+		return fmt.Errorf(`job %q: %w`, name, err)
+	}
+	job.lastRevision = job.revision.bump()
+
+	if env.ExecJobs == nil {
+		env.ExecJobs = make(map[string]*JobExec)
+	}
+	env.ExecJobs[name] = job
+	if env.execJobByID == nil {
+		env.execJobByID = make(map[string]*JobExec)
+	}
+	env.execJobByID[job.ID] = job
+
+	return nil
+}
+
+// removeJobExec remove the job from ExecJobs, its namespace, and its
+// mutex.
+func (env *Env) removeJobExec(job *JobExec) {
+	env.jobsMtx.Lock()
+	defer env.jobsMtx.Unlock()
+
+	delete(env.ExecJobs, job.Name)
+	delete(env.execJobByID, job.ID)
+
+	job.ns.removeJob(&job.JobBase)
+
+	if job.mutex != nil {
+		job.mutex.mtx.Lock()
+		job.mutex.Jobs = slices.DeleteFunc(job.mutex.Jobs, func(id string) bool {
+			return id == job.ID
+		})
+		job.mutex.mtx.Unlock()
+	}
+
+	env.revision.bump()
+}
+
+// jobHookPath return the path of JobExec hook,
+// "/karajo/api/job_exec/run/<path>" or
+// "/karajo/api/ns/<namespace>/job_exec/run/<path>" if the job is in
+// namespace.
+func jobHookPath(job *JobExec) string {
+	if len(job.Namespace) == 0 {
+		return path.Join(apiJobExecRun, job.Path)
+	}
+	return path.Join(apiNamespace, job.Namespace, `job_exec`, `run`, job.Path)
+}
+
+// newJobHookEndpoint create the HTTP endpoint for JobExec hook.
+func newJobHookEndpoint(hookPath string, call libhttp.Callback) libhttp.Endpoint {
+	return libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         hookPath,
+		RequestType:  libhttp.RequestTypeJSON,
+		ResponseType: libhttp.ResponseTypeJSON,
+		Call:         call,
+	}
+}
+
+// addJobHook register the job hook path and return it.
+func (k *Karajo) addJobHook(job *JobExec) (hookPath string) {
+	hookPath = jobHookPath(job)
+	job.sessionUser = k.sessionUser
+
+	k.hookMtx.Lock()
+	k.hookJobs[hookPath] = job
+	if job.MaxRequestBody > 0 {
+		k.bodyLimits[hookPath] = job.MaxRequestBody
+	}
+	k.hookMtx.Unlock()
+
+	return hookPath
+}
+
+// addJobHookServer register the hook of job that added after the HTTPd
+// initialized, using its own HTTP server with the same evaluators as
+// HTTPd.
+func (k *Karajo) addJobHookServer(job *JobExec) (err error) {
+	var hookPath = jobHookPath(job)
+
+	if k.hookJob(hookPath) != nil {
+		return fmt.Errorf(`job %q: hook %s already exist`, job.Name, hookPath)
+	}
+
+	var srv *libhttp.Server
+
+	srv, err = libhttp.NewServer(libhttp.ServerOptions{})
+	if err != nil {
+		return err
+	}
+
+	var eval libhttp.Evaluator
+	for _, eval = range k.evals {
+		srv.RegisterEvaluator(eval)
+	}
+
+	err = srv.RegisterEndpoint(newJobHookEndpoint(hookPath, k.handleJobHook))
+	if err != nil {
+		return err
+	}
+
+	k.addJobHook(job)
+
+	k.hookMtx.Lock()
+	k.hookServers[hookPath] = srv
+	k.hookMtx.Unlock()
+
+	return nil
+}
+
+// removeJobHook unregister the job hook, if its exist.
+func (k *Karajo) removeJobHook(job *JobExec) {
+	if len(job.Path) == 0 {
+		return
+	}
+
+	var hookPath = jobHookPath(job)
+
+	k.hookMtx.Lock()
+	if k.hookJobs[hookPath] == job {
+		delete(k.hookJobs, hookPath)
+		delete(k.bodyLimits, hookPath)
+		delete(k.hookServers, hookPath)
+	}
+	k.hookMtx.Unlock()
+}
+
+// hookJob return the JobExec by its hook path.
+func (k *Karajo) hookJob(hookPath string) (job *JobExec) {
+	k.hookMtx.RLock()
+	job = k.hookJobs[hookPath]
+	k.hookMtx.RUnlock()
+	return job
+}
+
+// handleJobHook trigger the JobExec by its hook path.
+// It will return 404 if the job has been removed.
+func (k *Karajo) handleJobHook(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var hookPath = epr.HTTPRequest.URL.Path
+
+	var job = k.hookJob(hookPath)
+	if job == nil {
+		return nil, errJobNotFound(hookPath)
+	}
+	return job.handleHTTP(epr)
+}
+
+// serveAddedHooks wrap the HTTP handler to serve the JobExec hook that
+// added after the HTTPd initialized.
+func (k *Karajo) serveAddedHooks(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		k.hookMtx.RLock()
+		var srv = k.hookServers[req.URL.Path]
+		k.hookMtx.RUnlock()
+
+		if srv == nil {
+			next.ServeHTTP(w, req)
+			return
+		}
+		srv.ServeHTTP(w, req)
+	})
+}
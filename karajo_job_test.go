@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_AddJobExec(t *testing.T) {
+	var (
+		env = &Env{
+			DirBase: t.TempDir(),
+			Secret:  `s3cret`,
+			ExecJobs: map[string]*JobExec{
+				`Static`: {
+					Path:     `/static`,
+					Commands: []string{`echo static`},
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = NewEmbedded(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	k.StartJobs()
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	var handler = k.Handler()
+
+	var hookCode = func(hookPath string) int {
+		var rec = httptest.NewRecorder()
+		var req = httptest.NewRequest(http.MethodPost, hookPath, nil)
+		req.Header.Set(`Content-Type`, `application/json`)
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	test.Assert(t, `hook before added`, http.StatusNotFound,
+		hookCode(apiJobExecRun+`/added`))
+
+	var job = &JobExec{
+		JobBase: JobBase{
+			Name: `Added`,
+		},
+		Path:     `/added`,
+		Commands: []string{`echo added`},
+	}
+
+	err = k.AddJobExec(job)
+	test.Assert(t, `AddJobExec`, nil, err)
+	test.Assert(t, `job ID`, `added`, job.ID)
+	test.Assert(t, `jobExec`, job, env.jobExec(`added`))
+
+	// The request without signature is forbidden, but the hook exist.
+	test.Assert(t, `hook after added`, http.StatusForbidden,
+		hookCode(apiJobExecRun+`/added`))
+
+	err = k.AddJobExec(&JobExec{JobBase: JobBase{Name: `Added`}})
+	test.Assert(t, `AddJobExec: duplicate name`,
+		`AddJobExec: job "Added" already exist`, err.Error())
+
+	err = k.AddJobExec(&JobExec{
+		JobBase: JobBase{
+			Name: `Other`,
+		},
+		Path:     `/added`,
+		Commands: []string{`echo other`},
+	})
+	test.Assert(t, `AddJobExec: duplicate hook`,
+		`AddJobExec: job "Other": hook /karajo/api/job_exec/run/added already exist`,
+		err.Error())
+	test.Assert(t, `Other is not registered`, true, env.jobExec(`other`) == nil)
+
+	err = k.RemoveJobExec(`added`)
+	test.Assert(t, `RemoveJobExec`, nil, err)
+	test.Assert(t, `jobExec after removed`, true, env.jobExec(`added`) == nil)
+	test.Assert(t, `hook after removed`, http.StatusNotFound,
+		hookCode(apiJobExecRun+`/added`))
+
+	err = k.RemoveJobExec(`static`)
+	test.Assert(t, `RemoveJobExec: static`, nil, err)
+	test.Assert(t, `static hook after removed`, http.StatusNotFound,
+		hookCode(apiJobExecRun+`/static`))
+
+	err = k.RemoveJobExec(`unknown`)
+	test.Assert(t, `RemoveJobExec: unknown`, true, err != nil)
+}
@@ -4,14 +4,31 @@
 package karajo
 
 import (
+	"crypto/subtle"
 	"fmt"
 	"net/http"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
 )
 
 const (
 	cookieName = `karajo`
+
+	// cookieNameCSRF the cookie that contains the CSRF token for the
+	// session.
+	// Unlike session cookie, this cookie is readable by WUI so it can
+	// be sent back in the HTTP header [HeaderNameXKarajoCSRF].
+	cookieNameCSRF = `karajo_csrf`
+
+	// cookieNameOIDC the cookie that bind the OpenID Connect login
+	// state to the user browser.
+	cookieNameOIDC = `karajo_oidc`
 )
 
+// HeaderNameXKarajoCSRF the header key for the CSRF token, required on
+// state-changing request that authenticated using session cookie.
+const HeaderNameXKarajoCSRF = `X-Karajo-CSRF`
+
 // sessionNew generate and store new session for user.
 func (k *Karajo) sessionNew(w http.ResponseWriter, user *User) (err error) {
 	var (
@@ -27,13 +44,136 @@ func (k *Karajo) sessionNew(w http.ResponseWriter, user *User) (err error) {
 	var cookie = &http.Cookie{
 		Name:     cookieName,
 		Value:    key,
-		MaxAge:   86400, // One day in seconds.
-		Path:     `/`,
+		MaxAge:   int(k.sm.ttl.Seconds()),
+		Path:     k.cookiePath(),
 		Secure:   false,
 		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	http.SetCookie(w, cookie)
+
+	cookie = &http.Cookie{
+		Name:     cookieNameCSRF,
+		Value:    k.sm.csrf(key),
+		MaxAge:   int(k.sm.ttl.Seconds()),
+		Path:     k.cookiePath(),
+		Secure:   false,
+		SameSite: http.SameSiteStrictMode,
+	}
+
+	http.SetCookie(w, cookie)
+
+	return nil
+}
+
+// sessionDelete remove the session in the request cookie, if its exist,
+// and expire the cookie in the client.
+func (k *Karajo) sessionDelete(w http.ResponseWriter, req *http.Request) {
+	var (
+		cookie *http.Cookie
+		err    error
+	)
+	cookie, err = req.Cookie(cookieName)
+	if err != nil {
+		return
+	}
+
+	k.sm.delete(cookie.Value)
+
+	cookie = &http.Cookie{
+		Name:     cookieName,
+		MaxAge:   -1,
+		Path:     k.cookiePath(),
+		Secure:   false,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+
+	http.SetCookie(w, cookie)
+
+	cookie = &http.Cookie{
+		Name:     cookieNameCSRF,
+		MaxAge:   -1,
+		Path:     k.cookiePath(),
+		Secure:   false,
+		SameSite: http.SameSiteStrictMode,
 	}
 
 	http.SetCookie(w, cookie)
+}
+
+// newCSRFToken generate random token for session.
+func newCSRFToken() string {
+	return string(ascii.Random([]byte(ascii.LettersNumber), 32))
+}
 
+// evalCSRF reject the state-changing HTTP request that authenticated
+// using session cookie but without valid CSRF token in the header
+// [HeaderNameXKarajoCSRF].
+//
+// The request is exempted from check if,
+//
+//   - the method is GET, HEAD, or OPTIONS;
+//   - the request is login, since there is no session yet;
+//   - the request contains valid signature in the header
+//     [HeaderNameXKarajoSign], since its authenticated using secret not
+//     cookie; or
+//   - the request does not have valid session cookie.
+func (k *Karajo) evalCSRF(req *http.Request, reqBody []byte) error {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return nil
+	}
+	if req.URL.Path == apiAuthLogin {
+		return nil
+	}
+	if k.isSignVerified(req, reqBody) {
+		return nil
+	}
+
+	var (
+		cookie *http.Cookie
+		err    error
+	)
+	cookie, err = req.Cookie(cookieName)
+	if err != nil {
+		return nil
+	}
+
+	var token = k.sm.csrf(cookie.Value)
+	if len(token) == 0 {
+		return nil
+	}
+
+	var got = req.Header.Get(HeaderNameXKarajoCSRF)
+	if subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+		return &errAuthCSRF
+	}
 	return nil
 }
+
+// isSignVerified return true if the request contains signature in the
+// header [HeaderNameXKarajoSign] and the signature is valid.
+//
+// The JobExec hook is verified using the job authorization, while the
+// other APIs is verified using the Env Secret, where the payload is
+// either the request body or the URL query.
+func (k *Karajo) isSignVerified(req *http.Request, reqBody []byte) bool {
+	var gotSign = req.Header.Get(HeaderNameXKarajoSign)
+	if len(gotSign) == 0 {
+		return false
+	}
+
+	var job = k.hookJob(req.URL.Path)
+	if job != nil {
+		return job.authorize(req.Header, reqBody) == nil
+	}
+
+	var err = k.verifySign(reqBody, gotSign)
+	if err == nil {
+		return true
+	}
+	err = k.verifySign([]byte(req.URL.RawQuery), gotSign)
+	return err == nil
+}
@@ -0,0 +1,167 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_evalCSRF(t *testing.T) {
+	var (
+		env = &Env{}
+		k   = &Karajo{
+			env: env,
+			sm:  newSessionManager(),
+			hookJobs: map[string]*JobExec{
+				apiJobExecRun + `/hook`: {
+					HeaderSign: HeaderNameXKarajoSign,
+					Secret:     `hooks3cret`,
+				},
+			},
+		}
+		key   = k.sm.new(&User{Name: `tester`})
+		token = k.sm.csrf(key)
+
+		body  = `_karajo_epoch=1673222400&id=test`
+		query = `_karajo_epoch=1673222400&id=test`
+	)
+
+	env.secret = env.newSecretValue(`s3cret`)
+
+	var jobHook = k.hookJobs[apiJobExecRun+`/hook`]
+	jobHook.secret = env.newSecretValue(jobHook.Secret)
+
+	type testCase struct {
+		header   map[string]string
+		desc     string
+		method   string
+		path     string
+		query    string
+		body     string
+		cookie   string
+		expError error
+	}
+
+	var cases = []testCase{{
+		desc:   `GET with cookie`,
+		method: http.MethodGet,
+		path:   apiEnv,
+		cookie: key,
+	}, {
+		desc:   `POST without cookie`,
+		method: http.MethodPost,
+		path:   apiAuthLogout,
+	}, {
+		desc:   `POST with unknown session`,
+		method: http.MethodPost,
+		path:   apiAuthLogout,
+		cookie: `unknown`,
+	}, {
+		desc:   `POST login with cookie`,
+		method: http.MethodPost,
+		path:   apiAuthLogin,
+		cookie: key,
+	}, {
+		desc:     `POST with cookie without token`,
+		method:   http.MethodPost,
+		path:     apiAuthLogout,
+		cookie:   key,
+		expError: &errAuthCSRF,
+	}, {
+		desc:   `POST with cookie and invalid token`,
+		method: http.MethodPost,
+		path:   apiJobExecPause,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoCSRF: `invalid`,
+		},
+		expError: &errAuthCSRF,
+	}, {
+		desc:   `POST with cookie and valid token`,
+		method: http.MethodPost,
+		path:   apiJobExecPause,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoCSRF: token,
+		},
+	}, {
+		desc:   `POST with cookie and forged signature`,
+		method: http.MethodPost,
+		path:   apiJobExecPause,
+		body:   body,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoSign: `sign`,
+		},
+		expError: &errAuthCSRF,
+	}, {
+		desc:   `POST with cookie and valid signature on body`,
+		method: http.MethodPost,
+		path:   apiJobExecPause,
+		body:   body,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoSign: Sign([]byte(body), []byte(`s3cret`)),
+		},
+	}, {
+		desc:   `POST with cookie and valid signature on query`,
+		method: http.MethodPost,
+		path:   apiJobHTTPPause,
+		query:  query,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoSign: Sign([]byte(query), []byte(`s3cret`)),
+		},
+	}, {
+		desc:   `POST hook with cookie and signature using Env secret`,
+		method: http.MethodPost,
+		path:   apiJobExecRun + `/hook`,
+		body:   body,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoSign: Sign([]byte(body), []byte(`s3cret`)),
+		},
+		expError: &errAuthCSRF,
+	}, {
+		desc:   `POST hook with cookie and valid signature`,
+		method: http.MethodPost,
+		path:   apiJobExecRun + `/hook`,
+		body:   body,
+		cookie: key,
+		header: map[string]string{
+			HeaderNameXKarajoSign: Sign([]byte(body), []byte(`hooks3cret`)),
+		},
+	}}
+
+	var (
+		c     testCase
+		req   *http.Request
+		name  string
+		value string
+		err   error
+	)
+	for _, c = range cases {
+		var target = c.path
+		if len(c.query) != 0 {
+			target += `?` + c.query
+		}
+		req = httptest.NewRequest(c.method, target, nil)
+		if len(c.cookie) != 0 {
+			req.AddCookie(&http.Cookie{
+				Name:  cookieName,
+				Value: c.cookie,
+			})
+		}
+		for name, value = range c.header {
+			req.Header.Set(name, value)
+		}
+
+		err = k.evalCSRF(req, []byte(c.body))
+		test.Assert(t, c.desc, c.expError, err)
+	}
+}
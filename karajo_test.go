@@ -4,10 +4,18 @@
 package karajo
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -33,6 +41,10 @@ func TestMain(m *testing.M) {
 		return time.Date(2023, time.January, 9, 0, 0, 0, 0, time.UTC).Round(time.Second).UTC()
 	}
 
+	processUsage = func(*os.ProcessState) JobLogUsage {
+		return JobLogUsage{}
+	}
+
 	os.Exit(m.Run())
 }
 
@@ -93,7 +105,10 @@ func TestKarajoAPIs(t *testing.T) {
 		},
 		Secret: `s3cret`,
 	}
-	testClient = NewClient(clientOpts)
+	testClient, err = NewClient(clientOpts)
+	if err != nil {
+		t.Fatal(err)
+	}
 
 	t.Run(`apiEnv`, func(tt *testing.T) {
 		testKarajoAPIEnv(tt, tdata)
@@ -102,6 +117,9 @@ func TestKarajoAPIs(t *testing.T) {
 	t.Run(`apiJobExecCancel`, func(tt *testing.T) {
 		testKarajoAPIJobExecCancel(tt, tdata)
 	})
+	t.Run(`apiJobExecDryRun`, func(tt *testing.T) {
+		testKarajoAPIJobExecDryRun(tt, tdata)
+	})
 	t.Run(`apiJobExecPause`, func(tt *testing.T) {
 		testKarajoAPIJobExecPause(tt, tdata)
 	})
@@ -117,6 +135,9 @@ func TestKarajoAPIs(t *testing.T) {
 	t.Run(`apiJobExecLog`, func(tt *testing.T) {
 		testKarajoAPIJobExecLog(tt, tdata)
 	})
+	t.Run(`apiJobExecLogSSE`, func(tt *testing.T) {
+		testKarajoAPIJobExecLogSSE(tt, tdata)
+	})
 
 	t.Run(`apiJobHTTPSuccess`, func(tt *testing.T) {
 		testKarajoAPIJobHTTPSuccess(tt, tdata)
@@ -197,6 +218,35 @@ func testKarajoAPIJobExecCancel(t *testing.T, tdata *test.Data) {
 	test.Assert(t, `job after cancel`, exp, string(got))
 }
 
+func testKarajoAPIJobExecDryRun(t *testing.T, tdata *test.Data) {
+	var (
+		dry  *JobExecDryRun
+		data interface{}
+		got  []byte
+		err  error
+	)
+
+	dry, err = testClient.JobExecDryRun(`test_job_success`)
+	if err != nil {
+		data = err
+	} else {
+		dry.DirWork = `<REDACTED>`
+		data = dry
+	}
+
+	got, err = json.MarshalIndent(data, ``, `  `)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = tdata.Output[`apiJobExecDryRun.json`]
+	test.Assert(t, `apiJobExecDryRun`, string(exp), string(got))
+
+	_, err = testClient.JobExecDryRun(`test_job_notfound`)
+	test.Assert(t, `apiJobExecDryRun: not found`,
+		`job not found: test_job_notfound`, err.Error())
+}
+
 func testKarajoAPIJobExecPause(t *testing.T, tdata *test.Data) {
 	var (
 		job  *JobExec
@@ -238,6 +288,9 @@ func testKarajoAPIJobExecPause(t *testing.T, tdata *test.Data) {
 
 	exp = tdata.Output[`apiJobExecPause_run.json`]
 	test.Assert(t, `apiJobExecPause_run`, string(exp), string(got))
+
+	var errRun, _ = data.(error)
+	test.Assert(t, `apiJobExecPause_run: ErrJobPaused`, true, errors.Is(errRun, ErrJobPaused))
 }
 
 func testKarajoAPIJobExecRunSuccess(t *testing.T, tdata *test.Data) {
@@ -333,6 +386,51 @@ func testKarajoAPIJobExecLog(t *testing.T, tdata *test.Data) {
 	test.Assert(t, `apiJobExecLog.json`, string(exp), string(got))
 }
 
+func testKarajoAPIJobExecLogSSE(t *testing.T, tdata *test.Data) {
+	type testCase struct {
+		tag   string
+		query string
+	}
+
+	var cases = []testCase{{
+		tag:   `apiJobExecLogSSE_notfound.txt`,
+		query: `id=test_job_success&counter=99`,
+	}, {
+		tag:   `apiJobExecLogSSE_offset.txt`,
+		query: `id=test_job_success&counter=1&offset=677`,
+	}}
+
+	var (
+		c       testCase
+		urlSSE  string
+		httpRes *http.Response
+		got     []byte
+		err     error
+	)
+	for _, c = range cases {
+		urlSSE = fmt.Sprintf(`http://%s%s?%s`, testEnv.ListenAddress,
+			apiJobExecLogSSE, c.query)
+
+		httpRes, err = http.Get(urlSSE)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The server close the connection once all lines has
+		// been sent.
+		got, err = io.ReadAll(httpRes.Body)
+		_ = httpRes.Body.Close()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		// The test data does not preserve the trailing empty
+		// line of last message.
+		test.Assert(t, c.tag, string(tdata.Output[c.tag]),
+			strings.TrimRight(string(got), "\n")+"\n")
+	}
+}
+
 func testKarajoAPIJobExecResume(t *testing.T, tdata *test.Data) {
 	var (
 		exp = tdata.Output[`apiJobExecResume.json`]
@@ -485,3 +583,220 @@ func testKarajoAPIJobHTTPResume(t *testing.T, tdata *test.Data) {
 	}
 	test.Assert(t, `apiJobHTTPResume`, string(exp), string(got))
 }
+
+func TestNew_listenPortZero(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddress: `127.0.0.1:0`,
+			DirBase:       t.TempDir(),
+			Secret:        `s3cret`,
+			HTTPJobs: map[string]*JobHTTP{
+				`relative`: &JobHTTP{
+					HTTPURL: `/karajo/api/environment`,
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	test.Assert(t, `ListenAddress changed`, false,
+		env.ListenAddress == `127.0.0.1:0`)
+
+	var (
+		fileAddress = filepath.Join(env.DirBase, `var`, `run`, `karajo`, `address`)
+		gotAddress  []byte
+	)
+
+	gotAddress, err = os.ReadFile(fileAddress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `address file`, env.ListenAddress+"\n", string(gotAddress))
+
+	test.Assert(t, `JobHTTP baseURI`, `http://`+env.ListenAddress,
+		env.HTTPJobs[`relative`].baseURI)
+
+	var clientEnv = &Env{
+		ListenAddress: `127.0.0.1:0`,
+		DirBase:       env.DirBase,
+	}
+	test.Assert(t, `ServerAddress`, env.ListenAddress, clientEnv.ServerAddress())
+}
+
+func TestNew_listenAddress(t *testing.T) {
+	var (
+		ln  net.Listener
+		err error
+	)
+
+	// Get the free port.
+	ln, err = net.Listen(`tcp`, `127.0.0.1:0`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var port = ln.Addr().(*net.TCPAddr).Port
+	_ = ln.Close()
+
+	var (
+		listenAddress = fmt.Sprintf(`:%d`, port)
+		env           = &Env{
+			ListenAddress: listenAddress,
+			DirBase:       t.TempDir(),
+			Secret:        `s3cret`,
+		}
+		k *Karajo
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, `ListenAddress`, listenAddress, env.ListenAddress)
+
+	err = k.Stop()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// After stopped, the address file is removed.
+	test.Assert(t, `ServerAddress`, listenAddress, env.ServerAddress())
+}
+
+func TestNew_listenAddressHooks(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddressAdmin: `127.0.0.1:0`,
+			ListenAddressHooks: `127.0.0.1:0`,
+			DirBase:            t.TempDir(),
+			Secret:             `s3cret`,
+			ExecJobs: map[string]*JobExec{
+				`hook`: &JobExec{
+					Path: `/hook`,
+					Call: func(_ context.Context, _ io.Writer, _ *libhttp.EndpointRequest) error {
+						return nil
+					},
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	test.Assert(t, `ListenAddress from admin`, false, env.ListenAddress == `127.0.0.1:0`)
+	test.Assert(t, `ListenAddressHooks changed`, false,
+		env.ListenAddressHooks == `127.0.0.1:0`)
+
+	type testCase struct {
+		method  string
+		path    string
+		expCode int
+	}
+
+	var cases = []testCase{{
+		method:  http.MethodGet,
+		path:    `/karajo/`,
+		expCode: http.StatusNotFound,
+	}, {
+		method:  http.MethodGet,
+		path:    apiEnv,
+		expCode: http.StatusNotFound,
+	}, {
+		method:  http.MethodPost,
+		path:    apiJobExecPause,
+		expCode: http.StatusNotFound,
+	}, {
+		// The hook is served but the request is not signed.
+		method:  http.MethodPost,
+		path:    apiJobExecRun + `/hook`,
+		expCode: http.StatusForbidden,
+	}}
+
+	var (
+		c   testCase
+		rec *httptest.ResponseRecorder
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		k.httpdHooks.Handler.ServeHTTP(rec, httptest.NewRequest(c.method, c.path, nil))
+		test.Assert(t, c.path, c.expCode, rec.Code)
+	}
+}
+
+func TestNewEmbedded(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddress: `127.0.0.1:8080`,
+			DirBase:       t.TempDir(),
+			Secret:        `s3cret`,
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = NewEmbedded(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fileAddress = filepath.Join(env.DirBase, `var`, `run`, `karajo`, `address`)
+	_, err = os.Stat(fileAddress)
+	test.Assert(t, `address file not exist`, true, os.IsNotExist(err))
+
+	err = k.Start()
+	test.Assert(t, `Start`, `Start: embedded karajo, use StartJobs instead`, err.Error())
+
+	var mux = http.NewServeMux()
+
+	k.RegisterRoutes(mux)
+	k.StartJobs()
+
+	type testCase struct {
+		path    string
+		expCode int
+	}
+
+	var cases = []testCase{{
+		path:    apiEnv,
+		expCode: http.StatusOK,
+	}, {
+		path:    pathKarajoApp,
+		expCode: http.StatusOK,
+	}, {
+		path:    `/other`,
+		expCode: http.StatusNotFound,
+	}}
+
+	var (
+		c   testCase
+		rec *httptest.ResponseRecorder
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.path, nil))
+		test.Assert(t, c.path, c.expCode, rec.Code)
+	}
+
+	err = k.Stop()
+	test.Assert(t, `Stop`, nil, err)
+}
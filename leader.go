@@ -0,0 +1,223 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defLeaderFile the name of file, inside the run directory, that contains
+// the lease of current leader.
+const defLeaderFile = `leader`
+
+// List of role in leader election.
+const (
+	leaderRoleLeader   = `leader`
+	leaderRoleFollower = `follower`
+)
+
+// leaderElection elect one leader between karajo instances that share the
+// same DirBase, so the scheduled jobs run only once.
+//
+// The leader is the instance that hold the lease in file
+// $DirBase/var/run/karajo/leader.
+// The leader renew its lease every one third of lease duration.
+// If the leader stop renewing the lease, for example the process is
+// killed, one of the follower take over the lease once its expired.
+//
+// The lease is written into temporary file and renamed, so the file is
+// always complete; when two instances write at the same time, the last
+// rename win and the lease is read back to confirm who become the leader.
+type leaderElection struct {
+	stopq chan struct{}
+
+	// file the path to the lease file.
+	file string
+
+	// id the identifier of this instance.
+	id string
+
+	role string
+
+	lease time.Duration
+
+	// revision bumped when the role changes.
+	revision *envRevision
+
+	mtx sync.Mutex
+}
+
+// leaderLease the content of lease file.
+type leaderLease struct {
+	ExpiredAt time.Time `json:"expired_at"`
+	ID        string    `json:"id"`
+}
+
+// newLeaderElection create new leader election with the lease file inside
+// the dirRun.
+func newLeaderElection(dirRun string, lease time.Duration) (le *leaderElection) {
+	var hostname, _ = os.Hostname()
+
+	le = &leaderElection{
+		stopq: make(chan struct{}),
+		file:  filepath.Join(dirRun, defLeaderFile),
+		id: fmt.Sprintf(`%s-%d-%s`, hostname, os.Getpid(),
+			ascii.Random([]byte(ascii.LettersNumber), 8)),
+		role:  leaderRoleFollower,
+		lease: lease,
+	}
+	return le
+}
+
+// isLeader return true if this instance is the leader.
+// If the leader election is not enabled, le is nil, it always return
+// true.
+func (le *leaderElection) isLeader() bool {
+	if le == nil {
+		return true
+	}
+	le.mtx.Lock()
+	var role = le.role
+	le.mtx.Unlock()
+	return role == leaderRoleLeader
+}
+
+// MarshalJSON encode the current role as JSON string.
+func (le *leaderElection) MarshalJSON() ([]byte, error) {
+	le.mtx.Lock()
+	var role = le.role
+	le.mtx.Unlock()
+	return []byte(strconv.Quote(role)), nil
+}
+
+// campaign acquire or renew the lease.
+// The lease is acquired if the lease file does not exist, expired, or
+// owned by this instance.
+func (le *leaderElection) campaign(now time.Time) {
+	var (
+		lease leaderLease
+		err   error
+	)
+
+	lease, err = le.read()
+	if err == nil && lease.ID != le.id && lease.ExpiredAt.After(now) {
+		le.setRole(leaderRoleFollower)
+		return
+	}
+
+	lease = leaderLease{
+		ID:        le.id,
+		ExpiredAt: now.Add(le.lease),
+	}
+
+	err = le.write(lease)
+	if err != nil {
+		mlog.Errf(`leader election: %s`, err)
+		le.setRole(leaderRoleFollower)
+		return
+	}
+
+	// Read it back, in case other instance write the lease at the same
+	// time.
+	lease, err = le.read()
+	if err != nil || lease.ID != le.id {
+		le.setRole(leaderRoleFollower)
+		return
+	}
+	le.setRole(leaderRoleLeader)
+}
+
+// run the campaign periodically until stop is called.
+func (le *leaderElection) run() {
+	var ticker = time.NewTicker(le.lease / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			le.campaign(timeNow())
+		case <-le.stopq:
+			return
+		}
+	}
+}
+
+// stop the campaign and release the lease, if this instance is the
+// leader, so the follower can take over immediately.
+func (le *leaderElection) stop() {
+	select {
+	case le.stopq <- struct{}{}:
+	default:
+	}
+
+	if !le.isLeader() {
+		return
+	}
+
+	var lease, err = le.read()
+	if err == nil && lease.ID == le.id {
+		_ = os.Remove(le.file)
+	}
+	le.setRole(leaderRoleFollower)
+}
+
+// read the lease from file.
+func (le *leaderElection) read() (lease leaderLease, err error) {
+	var raw []byte
+
+	raw, err = os.ReadFile(le.file)
+	if err != nil {
+		return lease, err
+	}
+	err = json.Unmarshal(raw, &lease)
+	if err != nil {
+		return lease, fmt.Errorf(`%s: %w`, le.file, err)
+	}
+	return lease, nil
+}
+
+// write the lease into temporary file and rename it to the lease file.
+func (le *leaderElection) write(lease leaderLease) (err error) {
+	var raw []byte
+
+	raw, err = json.Marshal(lease)
+	if err != nil {
+		return err
+	}
+
+	var fileTmp = le.file + `.` + le.id
+
+	err = os.WriteFile(fileTmp, raw, 0600)
+	if err != nil {
+		return err
+	}
+	err = os.Rename(fileTmp, le.file)
+	if err != nil {
+		_ = os.Remove(fileTmp)
+		return err
+	}
+	return nil
+}
+
+// setRole set the role and log it if its changed.
+func (le *leaderElection) setRole(role string) {
+	le.mtx.Lock()
+	var isChanged = le.role != role
+	le.role = role
+	le.mtx.Unlock()
+
+	if isChanged {
+		le.revision.bump()
+		mlog.Outf(`leader election: %s become %s`, le.id, role)
+	}
+}
@@ -0,0 +1,94 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestLeaderElection_campaign(t *testing.T) {
+	var (
+		dirRun = t.TempDir()
+		lease  = 15 * time.Second
+		now    = timeNow()
+		leA    = newLeaderElection(dirRun, lease)
+		leB    = newLeaderElection(dirRun, lease)
+	)
+
+	leA.campaign(now)
+	leB.campaign(now)
+	test.Assert(t, `A is leader`, true, leA.isLeader())
+	test.Assert(t, `B is follower`, false, leB.isLeader())
+
+	// The leader renew its lease.
+	now = now.Add(lease / 3)
+	leA.campaign(now)
+	leB.campaign(now)
+	test.Assert(t, `A renew`, true, leA.isLeader())
+	test.Assert(t, `B still follower`, false, leB.isLeader())
+
+	// A stop renewing the lease, B take over after its expired.
+	now = now.Add(lease + time.Second)
+	leB.campaign(now)
+	test.Assert(t, `B take over`, true, leB.isLeader())
+	leA.campaign(now)
+	test.Assert(t, `A become follower`, false, leA.isLeader())
+
+	// B stop and release the lease, A take over immediately.
+	leB.stop()
+	test.Assert(t, `B stopped`, false, leB.isLeader())
+	leA.campaign(now)
+	test.Assert(t, `A take over after release`, true, leA.isLeader())
+
+	var (
+		raw []byte
+		err error
+	)
+	raw, err = json.Marshal(leA)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `MarshalJSON`, `"leader"`, string(raw))
+}
+
+func TestLeaderElection_campaign_invalidLease(t *testing.T) {
+	var (
+		le  = newLeaderElection(t.TempDir(), 15*time.Second)
+		err = os.WriteFile(le.file, []byte(`{`), 0600)
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	le.campaign(timeNow())
+	test.Assert(t, `overwrite invalid lease`, true, le.isLeader())
+}
+
+func TestJobExec_run_follower(t *testing.T) {
+	var (
+		le     = newLeaderElection(t.TempDir(), 15*time.Second)
+		isCall bool
+		job    = &JobExec{
+			JobBase: JobBase{
+				leader: le,
+			},
+			Call: func(_ context.Context, _ io.Writer, _ *libhttp.EndpointRequest) error {
+				isCall = true
+				return nil
+			},
+		}
+	)
+
+	// The follower does not run the scheduled job.
+	job.run(nil)
+	test.Assert(t, `follower`, false, isCall)
+}
@@ -0,0 +1,70 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defLogJanitorInterval the interval to remove the job logs based on
+// their age and total size.
+const defLogJanitorInterval = time.Hour
+
+// runLogJanitor remove the job logs that older than LogMaxAge or exceed
+// the LogMaxTotalSize periodically, until stopLogJanitor called.
+func (k *Karajo) runLogJanitor(interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			k.pruneLogs(timeNow())
+
+		case <-k.janitorStopq:
+			return
+		}
+	}
+}
+
+// stopLogJanitor stop the runLogJanitor.
+func (k *Karajo) stopLogJanitor() {
+	select {
+	case k.janitorStopq <- struct{}{}:
+	default:
+	}
+}
+
+// pruneLogs remove the logs of all jobs based on their age and total
+// size.
+func (k *Karajo) pruneLogs(now time.Time) {
+	var (
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+		n       int
+	)
+	k.env.jobsMtx.RLock()
+	for _, job = range k.env.ExecJobs {
+		n = job.logsPruneAgeSize(now)
+		if n > 0 {
+			mlog.Outf(`log janitor: %s: %d logs removed`, job.ID, n)
+		}
+	}
+	k.env.jobsMtx.RUnlock()
+	for _, jobHTTP = range k.env.HTTPJobs {
+		n = jobHTTP.logsPruneAgeSize(now)
+		if n > 0 {
+			mlog.Outf(`log janitor: %s: %d logs removed`, jobHTTP.ID, n)
+		}
+	}
+	for _, jobSQL = range k.env.SQLJobs {
+		n = jobSQL.logsPruneAgeSize(now)
+		if n > 0 {
+			mlog.Outf(`log janitor: %s: %d logs removed`, jobSQL.ID, n)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+const (
+	// defLogSearchMaxResult the maximum number of logs returned by
+	// searchLogs.
+	defLogSearchMaxResult = 100
+
+	// defLogSearchMaxLines the maximum number of matched lines
+	// returned for each log.
+	defLogSearchMaxLines = 10
+)
+
+// LogSearchResult contains the JobExec log that match with the search
+// query.
+type LogSearchResult struct {
+	JobID  string `json:"job_id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+
+	// Lines contains the matched lines, limited to the first 10 lines.
+	Lines []string `json:"lines"`
+
+	Counter int64 `json:"counter"`
+}
+
+// searchLogs search the query in the logs of all JobExec.
+// The query is matched case insensitively on each line of log.
+// If id is not empty, only the logs of JobExec with that ID are searched.
+// If status is not empty, only the logs with that status are searched.
+//
+// The result is sorted by job ID and by counter in descending order,
+// limited to the first 100 matched logs.
+func (env *Env) searchLogs(query, id, status string) (list []LogSearchResult) {
+	var (
+		jobs []*JobExec
+		job  *JobExec
+	)
+	env.jobsMtx.RLock()
+	for _, job = range env.ExecJobs {
+		if len(id) != 0 && job.ID != id {
+			continue
+		}
+		jobs = append(jobs, job)
+	}
+	env.jobsMtx.RUnlock()
+	sort.Slice(jobs, func(x, y int) bool {
+		return jobs[x].ID < jobs[y].ID
+	})
+
+	list = []LogSearchResult{}
+
+	var (
+		q = []byte(strings.ToLower(query))
+
+		logs  []*JobLog
+		jlog  *JobLog
+		lines []string
+		x     int
+	)
+	for _, job = range jobs {
+		job.Lock()
+		logs = make([]*JobLog, len(job.Logs))
+		copy(logs, job.Logs)
+		job.Unlock()
+
+		for x = len(logs) - 1; x >= 0; x-- {
+			jlog = logs[x]
+			if len(status) != 0 && jlog.Status != status {
+				continue
+			}
+			lines = jlog.search(q, defLogSearchMaxLines)
+			if len(lines) == 0 {
+				continue
+			}
+			list = append(list, LogSearchResult{
+				JobID:   jlog.JobID,
+				Name:    jlog.Name,
+				Status:  jlog.Status,
+				Counter: jlog.Counter,
+				Lines:   lines,
+			})
+			if len(list) == defLogSearchMaxResult {
+				return list
+			}
+		}
+	}
+	return list
+}
+
+// search return the lines in the log that contains the lower case query,
+// up to maxLines.
+// If the log content is not loaded, it is read from storage without
+// keeping it in memory.
+func (jlog *JobLog) search(query []byte, maxLines int) (lines []string) {
+	var content, err = jlog.read()
+	if err != nil {
+		return nil
+	}
+
+	var (
+		line []byte
+		idx  int
+	)
+	for len(content) != 0 && len(lines) < maxLines {
+		idx = bytes.IndexByte(content, '\n')
+		if idx < 0 {
+			line = content
+			content = nil
+		} else {
+			line = content[:idx]
+			content = content[idx+1:]
+		}
+		if bytes.Contains(bytes.ToLower(line), query) {
+			lines = append(lines, string(bytes.TrimRight(line, "\r")))
+		}
+	}
+	return lines
+}
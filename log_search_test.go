@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_searchLogs(t *testing.T) {
+	type testCase struct {
+		desc   string
+		query  string
+		id     string
+		status string
+		exp    []LogSearchResult
+	}
+
+	var (
+		dir = t.TempDir()
+
+		newLog = func(id string, counter int64, status, content string) (jlog *JobLog) {
+			jlog = &JobLog{
+				JobID:   id,
+				Name:    fmt.Sprintf(`%s.%d.%s`, id, counter, status),
+				Status:  status,
+				Counter: counter,
+			}
+			jlog.path = filepath.Join(dir, jlog.Name)
+			var err = os.WriteFile(jlog.path, []byte(content), 0600)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return jlog
+		}
+
+		env = &Env{
+			ExecJobs: map[string]*JobExec{
+				`a`: {JobBase: JobBase{ID: `a`}},
+				`b`: {JobBase: JobBase{ID: `b`}},
+			},
+		}
+		jobA = env.ExecJobs[`a`]
+		jobB = env.ExecJobs[`b`]
+	)
+
+	jobA.Logs = []*JobLog{
+		newLog(`a`, 1, JobStatusFailed, "start\ndial: Connection refused\nend\n"),
+		newLog(`a`, 2, JobStatusSuccess, "start\nend\n"),
+	}
+	jobB.Logs = []*JobLog{
+		newLog(`b`, 1, JobStatusFailed, "connection refused\r\n"),
+	}
+
+	// The compressed log is searched as well.
+	var err = jobB.Logs[0].compress()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// The log in memory is searched without reading the storage.
+	jobA.Logs[1].content = []byte("start\nconnection refused once\n")
+
+	var cases = []testCase{{
+		desc:  `All jobs`,
+		query: `Connection REFUSED`,
+		exp: []LogSearchResult{{
+			JobID:   `a`,
+			Name:    `a.2.success`,
+			Status:  JobStatusSuccess,
+			Counter: 2,
+			Lines:   []string{`connection refused once`},
+		}, {
+			JobID:   `a`,
+			Name:    `a.1.failed`,
+			Status:  JobStatusFailed,
+			Counter: 1,
+			Lines:   []string{`dial: Connection refused`},
+		}, {
+			JobID:   `b`,
+			Name:    `b.1.failed`,
+			Status:  JobStatusFailed,
+			Counter: 1,
+			Lines:   []string{`connection refused`},
+		}},
+	}, {
+		desc:  `By ID`,
+		query: `refused`,
+		id:    `b`,
+		exp: []LogSearchResult{{
+			JobID:   `b`,
+			Name:    `b.1.failed`,
+			Status:  JobStatusFailed,
+			Counter: 1,
+			Lines:   []string{`connection refused`},
+		}},
+	}, {
+		desc:   `By status`,
+		query:  `refused`,
+		id:     `a`,
+		status: JobStatusFailed,
+		exp: []LogSearchResult{{
+			JobID:   `a`,
+			Name:    `a.1.failed`,
+			Status:  JobStatusFailed,
+			Counter: 1,
+			Lines:   []string{`dial: Connection refused`},
+		}},
+	}, {
+		desc:  `Not found`,
+		query: `timeout`,
+		exp:   []LogSearchResult{},
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		var got = env.searchLogs(c.query, c.id, c.status)
+		test.Assert(t, c.desc, c.exp, got)
+	}
+}
@@ -0,0 +1,97 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Storage define the storage for job logs.
+// The job states, the last counter and status, are loaded from the list
+// of logs in the storage when karajo started.
+//
+// By default, the logs are stored in the local file system, under the
+// log directory of each job.
+// The custom storage can be registered using [RegisterStorageKind] and
+// enabled by setting the kind in the storage "logs".
+//
+// Each log is stored using key "<job kind>/<job ID>/<log name>", for
+// example "job/my_job/my_job.1.success".
+// The key of log in the default storage is only the log name.
+type Storage interface {
+	// Put store the content of log.
+	Put(key string, content []byte) error
+
+	// Get the content of log.
+	// It must return an error wrapping [os.ErrNotExist] if the key
+	// does not exist.
+	Get(key string) ([]byte, error)
+
+	// Remove the log.
+	// Removing non-existing key must not return an error.
+	Remove(key string) error
+
+	// List the logs with the key prefix.
+	List(prefix string) ([]StorageObject, error)
+}
+
+// StorageObject the metadata of log in the storage.
+type StorageObject struct {
+	ModTime time.Time
+
+	// Name of log, the key without the prefix.
+	Name string
+
+	// Size of log content, in bytes.
+	Size int64
+}
+
+// StorageFactory create new Storage from the storage environment.
+// The timeout is the value of [Env.HTTPTimeout], that can be used by
+// the storage that connect to remote server.
+type StorageFactory func(envStorage EnvStorage, timeout time.Duration) (storage Storage, err error)
+
+var (
+	storageKinds    = map[string]StorageFactory{}
+	storageKindsMtx sync.RWMutex
+)
+
+// RegisterStorageKind register custom [EnvStorage.Kind] to store the job
+// logs in other system, for example database or key-value store.
+// The name is case insensitive and must not be one of the built-in kind:
+// s3.
+// Registering with the same name replace the existing one.
+//
+// The kind must be registered before the Env initialized.
+func RegisterStorageKind(name string, factory StorageFactory) (err error) {
+	var logp = `RegisterStorageKind`
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 {
+		return fmt.Errorf(`%s: empty name`, logp)
+	}
+	if name == storageKindS3 {
+		return fmt.Errorf(`%s: %q is built-in`, logp, name)
+	}
+	if factory == nil {
+		return fmt.Errorf(`%s: %q: nil factory`, logp, name)
+	}
+
+	storageKindsMtx.Lock()
+	storageKinds[name] = factory
+	storageKindsMtx.Unlock()
+
+	return nil
+}
+
+// customStorageKind return the registered StorageFactory by its name.
+func customStorageKind(name string) (factory StorageFactory) {
+	storageKindsMtx.RLock()
+	factory = storageKinds[name]
+	storageKindsMtx.RUnlock()
+	return factory
+}
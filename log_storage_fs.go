@@ -0,0 +1,110 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// logStorageFS implement the [Storage] using the local file system.
+// Each key is stored as file relative to the directory dir.
+// This is the default storage, where dir is the log directory of job.
+type logStorageFS struct {
+	dir string
+}
+
+func newLogStorageFS(dir string) (fsys *logStorageFS) {
+	return &logStorageFS{
+		dir: dir,
+	}
+}
+
+// path return the file path of key.
+func (fsys *logStorageFS) path(key string) string {
+	return filepath.Join(fsys.dir, filepath.FromSlash(key))
+}
+
+func (fsys *logStorageFS) Put(key string, content []byte) (err error) {
+	var path = fsys.path(key)
+
+	err = os.MkdirAll(filepath.Dir(path), 0700)
+	if err == nil {
+		err = os.WriteFile(path, content, 0600)
+	}
+	if err != nil {
+		return fmt.Errorf(`put %s: %w`, key, err)
+	}
+	return nil
+}
+
+func (fsys *logStorageFS) Get(key string) (content []byte, err error) {
+	content, err = os.ReadFile(fsys.path(key))
+	if err != nil {
+		return nil, fmt.Errorf(`get %s: %w`, key, err)
+	}
+	return content, nil
+}
+
+func (fsys *logStorageFS) Remove(key string) (err error) {
+	err = os.Remove(fsys.path(key))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf(`remove %s: %w`, key, err)
+	}
+	return nil
+}
+
+// List the regular files in the directory of prefix, whose name start
+// with the last element of prefix.
+func (fsys *logStorageFS) List(prefix string) (objs []StorageObject, err error) {
+	var (
+		dir        = fsys.dir
+		namePrefix = prefix
+		x          = strings.LastIndexByte(prefix, '/')
+	)
+	if x >= 0 {
+		dir = fsys.path(prefix[:x])
+		namePrefix = prefix[x+1:]
+	}
+
+	var des []os.DirEntry
+
+	des, err = os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf(`list %s: %w`, prefix, err)
+	}
+
+	var (
+		de os.DirEntry
+		fi os.FileInfo
+	)
+	for _, de = range des {
+		if !de.Type().IsRegular() || !strings.HasPrefix(de.Name(), namePrefix) {
+			continue
+		}
+		fi, err = de.Info()
+		if err != nil {
+			// The file may be removed while listing.
+			continue
+		}
+		objs = append(objs, StorageObject{
+			Name:    strings.TrimPrefix(fi.Name(), namePrefix),
+			ModTime: fi.ModTime(),
+			Size:    fi.Size(),
+		})
+	}
+	return objs, nil
+}
+
+// rename move the file src, the spool of running log, into key.
+func (fsys *logStorageFS) rename(src, key string) (err error) {
+	err = os.Rename(src, fsys.path(key))
+	if err != nil {
+		return fmt.Errorf(`rename %s: %w`, key, err)
+	}
+	return nil
+}
@@ -0,0 +1,265 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// defS3Region the default region for S3 storage.
+const defS3Region = `us-east-1`
+
+const (
+	s3Algorithm    = `AWS4-HMAC-SHA256`
+	s3DateLayout   = `20060102`
+	s3TimeLayout   = `20060102T150405Z`
+	s3HeaderDate   = `X-Amz-Date`
+	s3HeaderSHA256 = `X-Amz-Content-Sha256`
+	s3Service      = `s3`
+)
+
+// logStorageS3 implement the [Storage] using S3-compatible object
+// storage.
+// The request is signed using AWS Signature Version 4.
+type logStorageS3 struct {
+	client   *http.Client
+	endpoint *url.URL
+	env      EnvStorage
+}
+
+// s3ListBucketResult the response of ListObjectsV2.
+type s3ListBucketResult struct {
+	NextContinuationToken string     `xml:"NextContinuationToken"`
+	Contents              []s3Object `xml:"Contents"`
+	IsTruncated           bool       `xml:"IsTruncated"`
+}
+
+// s3Object the object metadata in s3ListBucketResult.
+type s3Object struct {
+	LastModified time.Time `xml:"LastModified"`
+	Key          string    `xml:"Key"`
+	Size         int64     `xml:"Size"`
+}
+
+func newLogStorageS3(env EnvStorage, timeout time.Duration) (s3 *logStorageS3, err error) {
+	if len(env.Bucket) == 0 {
+		return nil, errors.New(`empty bucket`)
+	}
+
+	s3 = &logStorageS3{
+		client: &http.Client{
+			Timeout: timeout,
+		},
+		env: env,
+	}
+
+	s3.endpoint, err = url.Parse(env.Endpoint)
+	if err != nil {
+		return nil, fmt.Errorf(`invalid endpoint %q: %w`, env.Endpoint, err)
+	}
+	if len(s3.endpoint.Scheme) == 0 || len(s3.endpoint.Host) == 0 {
+		return nil, fmt.Errorf(`invalid endpoint %q`, env.Endpoint)
+	}
+	return s3, nil
+}
+
+func (s3 *logStorageS3) Put(key string, content []byte) (err error) {
+	_, err = s3.do(http.MethodPut, key, nil, content)
+	if err != nil {
+		return fmt.Errorf(`put %s: %w`, key, err)
+	}
+	return nil
+}
+
+func (s3 *logStorageS3) Get(key string) (content []byte, err error) {
+	content, err = s3.do(http.MethodGet, key, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf(`get %s: %w`, key, err)
+	}
+	return content, nil
+}
+
+func (s3 *logStorageS3) Remove(key string) (err error) {
+	_, err = s3.do(http.MethodDelete, key, nil, nil)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return fmt.Errorf(`remove %s: %w`, key, err)
+	}
+	return nil
+}
+
+func (s3 *logStorageS3) List(prefix string) (objs []StorageObject, err error) {
+	var (
+		query = url.Values{}
+		res   s3ListBucketResult
+		obj   s3Object
+		body  []byte
+	)
+
+	prefix = s3.env.Prefix + prefix
+
+	query.Set(`list-type`, `2`)
+	query.Set(`prefix`, prefix)
+
+	for {
+		body, err = s3.do(http.MethodGet, ``, query, nil)
+		if err != nil {
+			return nil, fmt.Errorf(`list %s: %w`, prefix, err)
+		}
+
+		res = s3ListBucketResult{}
+		err = xml.Unmarshal(body, &res)
+		if err != nil {
+			return nil, fmt.Errorf(`list %s: %w`, prefix, err)
+		}
+
+		for _, obj = range res.Contents {
+			objs = append(objs, StorageObject{
+				Name:    strings.TrimPrefix(obj.Key, prefix),
+				ModTime: obj.LastModified.UTC(),
+				Size:    obj.Size,
+			})
+		}
+		if !res.IsTruncated || len(res.NextContinuationToken) == 0 {
+			return objs, nil
+		}
+		query.Set(`continuation-token`, res.NextContinuationToken)
+	}
+}
+
+// do send the signed request to the object key, or to the bucket if the
+// key is empty.
+// It will return an error wrapping os.ErrNotExist if the object is not
+// found.
+func (s3 *logStorageS3) do(method, key string, query url.Values, payload []byte) (body []byte, err error) {
+	var u = *s3.endpoint
+
+	if s3.env.PathStyle {
+		u.Path = strings.TrimSuffix(u.Path, `/`) + `/` + s3.env.Bucket + `/`
+	} else {
+		u.Host = s3.env.Bucket + `.` + u.Host
+		u.Path = strings.TrimSuffix(u.Path, `/`) + `/`
+	}
+	if len(key) != 0 {
+		u.Path += s3.env.Prefix + key
+	}
+	u.RawQuery = s3EncodeQuery(query)
+
+	var req *http.Request
+
+	req, err = http.NewRequest(method, u.String(), bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+
+	s3Sign(req, payload, s3.env, timeNow())
+
+	var res *http.Response
+
+	res, err = s3.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	body, err = io.ReadAll(res.Body)
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf(`%s: %w`, res.Status, os.ErrNotExist)
+	}
+	if res.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf(`%s: %s`, res.Status, body)
+	}
+	return body, nil
+}
+
+// s3Sign sign the request using AWS Signature Version 4.
+// All of the headers in the request, including the host, are signed.
+func s3Sign(req *http.Request, payload []byte, env EnvStorage, now time.Time) {
+	var (
+		sum         = sha256.Sum256(payload)
+		payloadHash = hex.EncodeToString(sum[:])
+		amzTime     = now.UTC().Format(s3TimeLayout)
+		amzDate     = now.UTC().Format(s3DateLayout)
+	)
+
+	req.Header.Set(s3HeaderSHA256, payloadHash)
+	req.Header.Set(s3HeaderDate, amzTime)
+
+	var (
+		headers = map[string]string{
+			`host`: req.URL.Host,
+		}
+		names = []string{`host`}
+		name  string
+	)
+	for name = range req.Header {
+		var lname = strings.ToLower(name)
+		headers[lname] = strings.TrimSpace(req.Header.Get(name))
+		names = append(names, lname)
+	}
+	sort.Strings(names)
+
+	var canonHeaders strings.Builder
+	for _, name = range names {
+		canonHeaders.WriteString(name + `:` + headers[name] + "\n")
+	}
+
+	var (
+		signedHeaders = strings.Join(names, `;`)
+		canonRequest  = strings.Join([]string{
+			req.Method,
+			req.URL.EscapedPath(),
+			s3EncodeQuery(req.URL.Query()),
+			canonHeaders.String(),
+			signedHeaders,
+			payloadHash,
+		}, "\n")
+		scope = amzDate + `/` + env.Region + `/` + s3Service + `/aws4_request`
+	)
+
+	sum = sha256.Sum256([]byte(canonRequest))
+
+	var (
+		strToSign = s3Algorithm + "\n" + amzTime + "\n" + scope + "\n" +
+			hex.EncodeToString(sum[:])
+
+		key = s3HMAC([]byte(`AWS4`+env.SecretKey), amzDate)
+	)
+	key = s3HMAC(key, env.Region)
+	key = s3HMAC(key, s3Service)
+	key = s3HMAC(key, `aws4_request`)
+
+	var sign = hex.EncodeToString(s3HMAC(key, strToSign))
+
+	req.Header.Set(`Authorization`, fmt.Sprintf(
+		`%s Credential=%s/%s,SignedHeaders=%s,Signature=%s`,
+		s3Algorithm, env.AccessKey, scope, signedHeaders, sign))
+}
+
+func s3HMAC(key []byte, data string) []byte {
+	var h = hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
+
+// s3EncodeQuery encode the query sorted by key, with space encoded as
+// "%20" as required by the canonical request.
+func s3EncodeQuery(query url.Values) string {
+	return strings.ReplaceAll(query.Encode(), `+`, `%20`)
+}
@@ -0,0 +1,249 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+// memStorage implement the Storage in memory.
+type memStorage struct {
+	objects map[string][]byte
+	mtx     sync.Mutex
+}
+
+func (mem *memStorage) Put(key string, content []byte) error {
+	mem.mtx.Lock()
+	mem.objects[key] = append([]byte(nil), content...)
+	mem.mtx.Unlock()
+	return nil
+}
+
+func (mem *memStorage) Get(key string) ([]byte, error) {
+	mem.mtx.Lock()
+	defer mem.mtx.Unlock()
+	var content, ok = mem.objects[key]
+	if !ok {
+		return nil, fmt.Errorf(`get %s: %w`, key, os.ErrNotExist)
+	}
+	return content, nil
+}
+
+func (mem *memStorage) Remove(key string) error {
+	mem.mtx.Lock()
+	delete(mem.objects, key)
+	mem.mtx.Unlock()
+	return nil
+}
+
+func (mem *memStorage) List(prefix string) (objs []StorageObject, err error) {
+	mem.mtx.Lock()
+	defer mem.mtx.Unlock()
+
+	var (
+		key     string
+		content []byte
+	)
+	for key, content = range mem.objects {
+		if !strings.HasPrefix(key, prefix) {
+			continue
+		}
+		objs = append(objs, StorageObject{
+			Name:    strings.TrimPrefix(key, prefix),
+			ModTime: timeNow(),
+			Size:    int64(len(content)),
+		})
+	}
+	sort.Slice(objs, func(x, y int) bool {
+		return objs[x].Name < objs[y].Name
+	})
+	return objs, nil
+}
+
+func TestRegisterStorageKind(t *testing.T) {
+	var (
+		mem = &memStorage{
+			objects: map[string][]byte{},
+		}
+		factory = func(envStorage EnvStorage, _ time.Duration) (Storage, error) {
+			if len(envStorage.Bucket) == 0 {
+				return nil, errors.New(`empty bucket`)
+			}
+			return mem, nil
+		}
+	)
+
+	type testCase struct {
+		factory  StorageFactory
+		name     string
+		expError string
+	}
+
+	var cases = []testCase{{
+		name:     ` `,
+		factory:  factory,
+		expError: `RegisterStorageKind: empty name`,
+	}, {
+		name:     `S3`,
+		factory:  factory,
+		expError: `RegisterStorageKind: "s3" is built-in`,
+	}, {
+		name:     `mem`,
+		expError: `RegisterStorageKind: "mem": nil factory`,
+	}, {
+		name:    `Mem`,
+		factory: factory,
+	}}
+
+	var (
+		c   testCase
+		err error
+	)
+	for _, c = range cases {
+		err = RegisterStorageKind(c.name, c.factory)
+		if err != nil {
+			test.Assert(t, c.name, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.name, c.expError, ``)
+	}
+	t.Cleanup(func() {
+		storageKindsMtx.Lock()
+		delete(storageKinds, `mem`)
+		storageKindsMtx.Unlock()
+	})
+
+	var envStorage = EnvStorage{
+		Name: storageNameLogs,
+		Kind: `MEM`,
+	}
+	_, err = envStorage.createLogStorage(time.Second)
+	test.Assert(t, `createLogStorage: factory error`,
+		`createLogStorage: logs: empty bucket`, err.Error())
+
+	var (
+		dirBase = t.TempDir()
+		newEnv  = func() *Env {
+			return &Env{
+				DirBase: dirBase,
+				Storage: map[string]EnvStorage{
+					storageNameLogs: {
+						Kind:   `mem`,
+						Bucket: `logs`,
+					},
+				},
+				ExecJobs: map[string]*JobExec{
+					`stored`: {
+						Call: func(_ context.Context, log io.Writer, _ *libhttp.EndpointRequest) error {
+							_, _ = io.WriteString(log, "output from call\n")
+							return nil
+						},
+					},
+				},
+			}
+		}
+		env = newEnv()
+	)
+
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		job  = env.ExecJobs[`stored`]
+		jlog *JobLog
+	)
+
+	jlog, err = job.execute(nil)
+	job.finish(jlog, err)
+
+	var content []byte
+
+	content, err = mem.Get(`job/stored/stored.1.success`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `stored`, true, strings.Contains(string(content), `output from call`))
+
+	// Restart, the job state is loaded from the custom storage.
+	env = newEnv()
+	err = env.init()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	job = env.ExecJobs[`stored`]
+	test.Assert(t, `counter`, int64(1), job.counter)
+	test.Assert(t, `Status`, JobStatusSuccess, job.Status)
+
+	jlog = job.getLog(1)
+	err = jlog.load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `load`, string(content), string(jlog.content))
+}
+
+func TestLogStorageFS(t *testing.T) {
+	var (
+		fsys = newLogStorageFS(t.TempDir())
+		err  error
+	)
+
+	err = fsys.Put(`job/a/a.1.success`, []byte(`one`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fsys.Put(`job/a/a.2.failed`, []byte(`two!`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fsys.Put(`job/b/b.1.success`, []byte(`three`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var objs []StorageObject
+
+	objs, err = fsys.List(`job/a/`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		got []string
+		obj StorageObject
+	)
+	for _, obj = range objs {
+		got = append(got, fmt.Sprintf(`%s:%d`, obj.Name, obj.Size))
+	}
+	test.Assert(t, `List`, []string{`a.1.success:3`, `a.2.failed:4`}, got)
+
+	_, err = fsys.Get(`job/a/a.3.success`)
+	test.Assert(t, `Get: not exist`, true, errors.Is(err, os.ErrNotExist))
+
+	err = fsys.Remove(`job/a/a.1.success`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = fsys.Remove(`job/a/a.1.success`)
+	test.Assert(t, `Remove: not exist`, nil, err)
+
+	_, err = os.Stat(filepath.Join(fsys.dir, `job`, `a`, `a.1.success`))
+	test.Assert(t, `removed`, true, errors.Is(err, os.ErrNotExist))
+}
@@ -0,0 +1,397 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// apiNamespace the prefix of HTTP API for namespace,
+// "/karajo/api/ns/<name>/...".
+const apiNamespace = `/karajo/api/ns`
+
+// apiNamespaceJobExec the HTTP API to list the JobExec in namespace.
+const apiNamespaceJobExec = apiNamespace + `/:` + paramNameName + `/job_exec`
+
+// Namespace define a group of jobs, with their own users, secret, and
+// directories, so one karajo instance can serve multiple teams without
+// seeing each other jobs.
+//
+// Each namespace is defined in section "[namespace \"<name>\"]".
+type Namespace struct {
+	secret *secretValue
+
+	// quota the usage of namespace.
+	quota namespaceQuota
+
+	// Name of namespace, set from the sub-section name.
+	Name string `ini:"-" json:"name"`
+
+	// Secret define the secret to authorize the HTTP request for the
+	// jobs in this namespace, and the default secret for the JobExec
+	// hooks in this namespace.
+	// This field is optional, default to the Env Secret.
+	Secret string `ini:"::secret" json:"-"`
+
+	// Users list of user name that can access the jobs in this
+	// namespace.
+	Users []string `ini:"::user" json:"-"`
+
+	// Groups list of OpenID Connect group that can access the jobs in
+	// this namespace.
+	Groups []string `ini:"::group" json:"-"`
+
+	// MaxJobs define the maximum number of jobs, including JobHTTP
+	// and JobSQL, in this namespace.
+	// This field is optional, default to 0 or unlimited.
+	MaxJobs int `ini:"::max_jobs" json:"max_jobs,omitempty"`
+
+	// MaxRunning define the maximum number of jobs in this namespace
+	// that run at the same time.
+	// This field is optional, default to 0 or unlimited.
+	MaxRunning int `ini:"::max_running" json:"max_running,omitempty"`
+
+	// MaxRunsPerDay define the maximum number of runs of all jobs in
+	// this namespace in one day, in UTC.
+	// This field is optional, default to 0 or unlimited.
+	MaxRunsPerDay int `ini:"::max_runs_per_day" json:"max_runs_per_day,omitempty"`
+
+	// MaxLogSize define the maximum total size of logs, in bytes, of
+	// all jobs in this namespace.
+	// Once reached, the jobs in this namespace is not triggered until
+	// their logs is pruned.
+	// This field is optional, default to 0 or unlimited.
+	MaxLogSize int64 `ini:"::max_log_size" json:"max_log_size,omitempty"`
+}
+
+// init validate the namespace name and initialize its secret.
+func (ns *Namespace) init(env *Env, name string) (err error) {
+	if len(name) == 0 || strings.ContainsFunc(name, isNotEnvName) {
+		return fmt.Errorf(`invalid namespace name %q`, name)
+	}
+	ns.Name = name
+
+	ns.Secret = strings.TrimSpace(expandEnv(ns.Secret))
+	if len(ns.Secret) == 0 {
+		ns.secret = env.secret
+	} else {
+		ns.secret = env.newSecretValue(ns.Secret)
+	}
+	return nil
+}
+
+// isMember return true if the user name is listed in Users or one of the
+// user groups is listed in Groups.
+func (ns *Namespace) isMember(user *User) bool {
+	if slices.Contains(ns.Users, user.Name) {
+		return true
+	}
+	var group string
+	for _, group = range user.groups {
+		if slices.Contains(ns.Groups, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// initNamespaces initialize all of the namespaces.
+func (env *Env) initNamespaces() (err error) {
+	var (
+		logp = `initNamespaces`
+		name string
+		ns   *Namespace
+	)
+	for name, ns = range env.Namespaces {
+		err = ns.init(env, name)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+	return nil
+}
+
+// namespace return the namespace by its name.
+// It will return an error if the name is not empty and the namespace is
+// not defined.
+func (env *Env) namespace(name string) (ns *Namespace, err error) {
+	if len(name) == 0 {
+		return nil, nil
+	}
+	ns = env.Namespaces[name]
+	if ns == nil {
+		return nil, fmt.Errorf(`namespace %q is not defined`, name)
+	}
+	return ns, nil
+}
+
+// userNamespaces return the sorted name of namespaces where the user is
+// member.
+// It will return nil if the user is not member of any namespace, which
+// means the user can access all of the jobs.
+func (env *Env) userNamespaces(user *User) (names []string) {
+	if user == nil {
+		return nil
+	}
+	var ns *Namespace
+	for _, ns = range env.Namespaces {
+		if ns.isMember(user) {
+			names = append(names, ns.Name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// jobNamespace return the namespace of job by its ID, for any kind of
+// job.
+// It will return false if the job does not exist.
+func (env *Env) jobNamespace(id string) (name string, ok bool) {
+	var job = env.jobExec(id)
+	if job != nil {
+		return job.Namespace, true
+	}
+	var jobHTTP = env.jobHTTP(id)
+	if jobHTTP != nil {
+		return jobHTTP.Namespace, true
+	}
+	var jobSQL = env.jobSQL(id)
+	if jobSQL != nil {
+		return jobSQL.Namespace, true
+	}
+	return ``, false
+}
+
+// namespaceDir return the directory of namespace for the base directory
+// dir, "<parent of dir>/ns/<name>/<base of dir>".
+// For example, the "$DirBase/var/lib/karajo/job" become
+// "$DirBase/var/lib/karajo/ns/<name>/job".
+func namespaceDir(dir, name string) string {
+	if len(name) == 0 {
+		return dir
+	}
+	return filepath.Join(filepath.Dir(dir), `ns`, name, filepath.Base(dir))
+}
+
+// namespaceOfPath return the namespace name from the request path
+// "/karajo/api/ns/<name>/...".
+func namespaceOfPath(path string) (name string, ok bool) {
+	path, ok = strings.CutPrefix(path, apiNamespace+`/`)
+	if !ok {
+		return ``, false
+	}
+	name, _, _ = strings.Cut(path, `/`)
+	return name, len(name) != 0
+}
+
+// isHookPath return true if the path is the JobExec hook,
+// "/karajo/api/job_exec/run/..." or
+// "/karajo/api/ns/<name>/job_exec/run/...".
+func isHookPath(path string) bool {
+	if strings.HasPrefix(path, apiJobExecRun+`/`) {
+		return true
+	}
+	var name, ok = namespaceOfPath(path)
+	if !ok {
+		return false
+	}
+	return strings.HasPrefix(path, apiNamespace+`/`+name+`/job_exec/run/`)
+}
+
+// requestNamespace return the namespace of the request, from the path
+// "/karajo/api/ns/<name>/..." or from the job in parameter "id".
+// It will return nil if the request is not for the job in namespace.
+// Since the parameter "id" is set by the caller, it must be used only to
+// authorize the API that operate on that job.
+func (k *Karajo) requestNamespace(req *http.Request) (ns *Namespace) {
+	var name, ok = namespaceOfPath(req.URL.Path)
+	if !ok {
+		name, _ = k.env.jobNamespace(strings.ToLower(req.Form.Get(paramNameID)))
+	}
+	ns, _ = k.env.namespace(name)
+	return ns
+}
+
+// evalNamespace limit the user that is member of namespaces to access
+// only the jobs in their namespaces.
+//
+// The environment and changes APIs are allowed, since their response are
+// filtered by namespaces.
+// The API that does not operate on job, for example backup and export, is
+// forbidden.
+// The request without user session, for example the job hook or the
+// agent, is authorized by its signature.
+func (k *Karajo) evalNamespace(req *http.Request, _ []byte) error {
+	if len(k.env.Namespaces) == 0 {
+		return nil
+	}
+	var names = k.env.userNamespaces(k.sessionUser(req))
+	if len(names) == 0 {
+		return nil
+	}
+
+	var path = req.URL.Path
+	switch path {
+	case apiAuthLogin, apiAuthLogout, apiAuthTOTPQR, apiEnv, apiChanges:
+		return nil
+	}
+
+	var (
+		name string
+		ok   bool
+	)
+	name, ok = namespaceOfPath(path)
+	if !ok {
+		name, ok = k.env.jobNamespace(strings.ToLower(req.Form.Get(paramNameID)))
+	}
+	if ok && slices.Contains(names, name) {
+		return nil
+	}
+	return &errNamespaceForbidden
+}
+
+// envNamespaceView the environment as seen by the user that is member of
+// namespaces, which contains only the jobs in their namespaces.
+type envNamespaceView struct {
+	ExecJobs     map[string]*JobExec `json:"jobs"`
+	HTTPJobs     map[string]*JobHTTP `json:"http_jobs"`
+	SQLJobs      map[string]*JobSQL  `json:"sql_jobs,omitempty"`
+	ReadOnlyMode *readOnlyMode       `json:"read_only,omitempty"`
+
+	Name    string `json:"name"`
+	Version string `json:"version"`
+
+	Namespaces []string `json:"namespaces"`
+}
+
+// namespaceView return the environment that contains only the jobs in
+// the namespaces names.
+func (env *Env) namespaceView(names []string) (view *envNamespaceView) {
+	view = &envNamespaceView{
+		ExecJobs:     make(map[string]*JobExec),
+		HTTPJobs:     make(map[string]*JobHTTP),
+		ReadOnlyMode: env.ReadOnlyMode,
+		Name:         env.Name,
+		Version:      env.Version,
+		Namespaces:   names,
+	}
+
+	var (
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+	env.jobsMtx.RLock()
+	for name, job = range env.ExecJobs {
+		if slices.Contains(names, job.Namespace) {
+			view.ExecJobs[name] = job
+		}
+	}
+	for name, jobHTTP = range env.HTTPJobs {
+		if slices.Contains(names, jobHTTP.Namespace) {
+			view.HTTPJobs[name] = jobHTTP
+		}
+	}
+	for name, jobSQL = range env.SQLJobs {
+		if !slices.Contains(names, jobSQL.Namespace) {
+			continue
+		}
+		if view.SQLJobs == nil {
+			view.SQLJobs = make(map[string]*JobSQL)
+		}
+		view.SQLJobs[name] = jobSQL
+	}
+	env.jobsMtx.RUnlock()
+	return view
+}
+
+// filterNamespaces remove the jobs that is not in the namespaces names.
+func (changes *EnvChanges) filterNamespaces(names []string) {
+	var (
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+	for name, job = range changes.ExecJobs {
+		if !slices.Contains(names, job.Namespace) {
+			delete(changes.ExecJobs, name)
+		}
+	}
+	for name, jobHTTP = range changes.HTTPJobs {
+		if !slices.Contains(names, jobHTTP.Namespace) {
+			delete(changes.HTTPJobs, name)
+		}
+	}
+	for name, jobSQL = range changes.SQLJobs {
+		if !slices.Contains(names, jobSQL.Namespace) {
+			delete(changes.SQLJobs, name)
+		}
+	}
+}
+
+// namespaceJobExecs return the JobExec in the namespace name, indexed by
+// the job name.
+func (env *Env) namespaceJobExecs(name string) (jobs map[string]*JobExec) {
+	jobs = make(map[string]*JobExec)
+
+	var (
+		jobName string
+		job     *JobExec
+	)
+	env.jobsMtx.RLock()
+	for jobName, job = range env.ExecJobs {
+		if job.Namespace == name {
+			jobs[jobName] = job
+		}
+	}
+	env.jobsMtx.RUnlock()
+	return jobs
+}
+
+// apiNamespaceJobExec return the JobExec in the namespace.
+//
+// Request format,
+//
+//	GET /karajo/api/ns/<name>/job_exec
+//
+// Response format,
+//
+//	Content-Type: application/json
+//
+//	{"code":200,"data":{"<job name>":<JobExec>, ...}}
+//
+// It will return 404 if the namespace is not defined.
+func (k *Karajo) apiNamespaceJobExec(epr *libhttp.EndpointRequest) (resbody []byte, err error) {
+	var (
+		logp = `apiNamespaceJobExec`
+		name = epr.HTTPRequest.Form.Get(paramNameName)
+		res  = &libhttp.EndpointResponse{}
+	)
+
+	_, err = k.env.namespace(name)
+	if err != nil || len(name) == 0 {
+		res.Code = http.StatusNotFound
+		res.Message = fmt.Sprintf(`namespace %q is not defined`, name)
+		return nil, res
+	}
+
+	res.Code = http.StatusOK
+	res.Data = k.env.namespaceJobExecs(name)
+
+	resbody, err = json.Marshal(res)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return resbody, nil
+}
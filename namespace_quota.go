@@ -0,0 +1,149 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+)
+
+// namespaceQuota the usage of namespace, to enforce its quota.
+type namespaceQuota struct {
+	// day the start of the current day, in UTC, where the runs is
+	// counted.
+	day time.Time
+
+	// jobs list of job in namespace.
+	jobs []*JobBase
+
+	// running the number of running jobs.
+	running int
+
+	// runs the number of runs in the current day.
+	runs int
+
+	sync.Mutex
+}
+
+// addJob register the job into namespace.
+// It will return an error if the number of jobs exceed the MaxJobs.
+func (ns *Namespace) addJob(job *JobBase) (err error) {
+	if ns == nil {
+		return nil
+	}
+
+	ns.quota.Lock()
+	defer ns.quota.Unlock()
+
+	if slices.Contains(ns.quota.jobs, job) {
+		return nil
+	}
+	if ns.MaxJobs > 0 && len(ns.quota.jobs) >= ns.MaxJobs {
+		return fmt.Errorf(`namespace %q reach the maximum jobs %d`, ns.Name, ns.MaxJobs)
+	}
+	ns.quota.jobs = append(ns.quota.jobs, job)
+	return nil
+}
+
+// removeJob unregister the job from namespace.
+func (ns *Namespace) removeJob(job *JobBase) {
+	if ns == nil {
+		return
+	}
+	ns.quota.Lock()
+	ns.quota.jobs = slices.DeleteFunc(ns.quota.jobs, func(other *JobBase) bool {
+		return other == job
+	})
+	ns.quota.Unlock()
+}
+
+// check the quota of namespace before triggering the job.
+// It return errNamespaceQuotaRunning, errNamespaceQuotaRuns, or
+// errNamespaceQuotaLog if one of the quota has been reached.
+func (ns *Namespace) check() (err error) {
+	if ns == nil {
+		return nil
+	}
+
+	ns.quota.Lock()
+	err = ns.checkRuns(timeNow())
+	var jobs = ns.quota.jobs
+	ns.quota.Unlock()
+
+	if err != nil {
+		return err
+	}
+	if ns.MaxLogSize <= 0 {
+		return nil
+	}
+
+	var (
+		total int64
+		job   *JobBase
+	)
+	for _, job = range jobs {
+		total += job.logsSize()
+	}
+	if total >= ns.MaxLogSize {
+		return &errNamespaceQuotaLog
+	}
+	return nil
+}
+
+// checkRuns check the number of running jobs and the runs in the day of
+// now.
+// The caller must hold the quota lock.
+func (ns *Namespace) checkRuns(now time.Time) (err error) {
+	var day = now.UTC().Truncate(24 * time.Hour)
+	if !day.Equal(ns.quota.day) {
+		ns.quota.day = day
+		ns.quota.runs = 0
+	}
+	if ns.MaxRunning > 0 && ns.quota.running >= ns.MaxRunning {
+		return &errNamespaceQuotaRunning
+	}
+	if ns.MaxRunsPerDay > 0 && ns.quota.runs >= ns.MaxRunsPerDay {
+		return &errNamespaceQuotaRuns
+	}
+	return nil
+}
+
+// acquire the quota to run the job.
+// On success, the caller must call release once the job finished.
+func (ns *Namespace) acquire() (err error) {
+	if ns == nil {
+		return nil
+	}
+
+	err = ns.check()
+	if err != nil {
+		return err
+	}
+
+	ns.quota.Lock()
+	defer ns.quota.Unlock()
+
+	// The other job may acquire the quota after check.
+	err = ns.checkRuns(timeNow())
+	if err != nil {
+		return err
+	}
+	ns.quota.running++
+	ns.quota.runs++
+	return nil
+}
+
+// release the running quota acquired by acquire.
+func (ns *Namespace) release() {
+	if ns == nil {
+		return
+	}
+	ns.quota.Lock()
+	if ns.quota.running > 0 {
+		ns.quota.running--
+	}
+	ns.quota.Unlock()
+}
@@ -0,0 +1,101 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestNamespace_addJob(t *testing.T) {
+	var (
+		ns = &Namespace{
+			Name:    `team-a`,
+			MaxJobs: 1,
+		}
+		jobA = &JobBase{ID: `a`}
+		jobB = &JobBase{ID: `b`}
+		err  error
+	)
+
+	err = ns.addJob(jobA)
+	test.Assert(t, `addJob a`, nil, err)
+
+	err = ns.addJob(jobA)
+	test.Assert(t, `addJob a twice`, nil, err)
+
+	err = ns.addJob(jobB)
+	test.Assert(t, `addJob b`, `namespace "team-a" reach the maximum jobs 1`, err.Error())
+
+	// The job without namespace is not limited.
+	ns = nil
+	err = ns.addJob(jobB)
+	test.Assert(t, `nil namespace`, nil, err)
+}
+
+func TestNamespace_acquire(t *testing.T) {
+	var (
+		ns = &Namespace{
+			Name:          `team-a`,
+			MaxRunning:    1,
+			MaxRunsPerDay: 2,
+		}
+		err error
+	)
+
+	err = ns.acquire()
+	test.Assert(t, `acquire #1`, nil, err)
+
+	err = ns.acquire()
+	test.Assert(t, `acquire while running`, errNamespaceQuotaRunning.Error(), err.Error())
+
+	ns.release()
+
+	err = ns.acquire()
+	test.Assert(t, `acquire #2`, nil, err)
+	ns.release()
+
+	err = ns.check()
+	test.Assert(t, `check runs per day`, errNamespaceQuotaRuns.Error(), err.Error())
+
+	// The runs is reset on the next day.
+	ns.quota.Lock()
+	err = ns.checkRuns(timeNow().Add(24 * time.Hour))
+	ns.quota.Unlock()
+	test.Assert(t, `next day`, nil, err)
+	test.Assert(t, `runs`, 0, ns.quota.runs)
+}
+
+func TestNamespace_checkLogSize(t *testing.T) {
+	var (
+		ns = &Namespace{
+			Name:       `team-a`,
+			MaxLogSize: 10,
+		}
+		job = &JobBase{
+			ID: `a`,
+			Logs: []*JobLog{{
+				content: []byte(`12345`),
+			}},
+		}
+		err error
+	)
+
+	err = ns.addJob(job)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ns.check()
+	test.Assert(t, `below quota`, nil, err)
+
+	job.Logs = append(job.Logs, &JobLog{
+		content: []byte(`67890`),
+	})
+
+	err = ns.check()
+	test.Assert(t, `reach quota`, errNamespaceQuotaLog.Error(), err.Error())
+}
@@ -0,0 +1,282 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	liberrors "git.sr.ht/~shulhan/pakakeh.go/lib/errors"
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_evalNamespace(t *testing.T) {
+	var (
+		env = &Env{
+			Namespaces: map[string]*Namespace{
+				`team-a`: {
+					Name:  `team-a`,
+					Users: []string{`alice`},
+				},
+				`team-b`: {
+					Name:   `team-b`,
+					Groups: []string{`ops`},
+				},
+			},
+			ExecJobs: map[string]*JobExec{
+				`deploy-a`: {
+					JobBase: JobBase{ID: `deploy-a`, Namespace: `team-a`},
+				},
+				`deploy-b`: {
+					JobBase: JobBase{ID: `deploy-b`, Namespace: `team-b`},
+				},
+				`global`: {
+					JobBase: JobBase{ID: `global`},
+				},
+			},
+		}
+		k = &Karajo{
+			env: env,
+			sm:  newSessionManager(),
+		}
+		alice = &User{Name: `alice`}
+		carol = &User{Name: `carol`, groups: []string{`ops`}}
+		root  = &User{Name: `root`}
+	)
+
+	type testCase struct {
+		user     *User
+		desc     string
+		path     string
+		id       string
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc: `without session`,
+		path: apiJobExecPause,
+		id:   `deploy-b`,
+	}, {
+		desc: `global user`,
+		user: root,
+		path: apiJobExecPause,
+		id:   `deploy-b`,
+	}, {
+		desc: `environment`,
+		user: alice,
+		path: apiEnv,
+	}, {
+		desc: `job in namespace`,
+		user: alice,
+		path: apiJobExecPause,
+		id:   `DEPLOY-A`,
+	}, {
+		desc:     `job in other namespace`,
+		user:     alice,
+		path:     apiJobExecPause,
+		id:       `deploy-b`,
+		expError: errNamespaceForbidden.Message,
+	}, {
+		desc:     `job without namespace`,
+		user:     alice,
+		path:     apiJobExecPause,
+		id:       `global`,
+		expError: errNamespaceForbidden.Message,
+	}, {
+		desc:     `API without job`,
+		user:     alice,
+		path:     apiBackup,
+		expError: errNamespaceForbidden.Message,
+	}, {
+		desc: `namespace path by group`,
+		user: carol,
+		path: apiNamespace + `/team-b/job_exec`,
+	}, {
+		desc:     `other namespace path`,
+		user:     carol,
+		path:     apiNamespace + `/team-a/job_exec`,
+		expError: errNamespaceForbidden.Message,
+	}}
+
+	var (
+		c   testCase
+		req *http.Request
+		err error
+	)
+	for _, c = range cases {
+		req = httptest.NewRequest(http.MethodPost, c.path, nil)
+		req.Form = url.Values{}
+		req.Form.Set(paramNameID, c.id)
+		if c.user != nil {
+			req.AddCookie(&http.Cookie{
+				Name:  cookieName,
+				Value: k.sm.new(c.user),
+			})
+		}
+
+		err = k.evalNamespace(req, nil)
+		if err != nil {
+			test.Assert(t, c.desc, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.desc, c.expError, ``)
+	}
+
+	var view = env.namespaceView(env.userNamespaces(alice))
+	test.Assert(t, `namespaceView: Namespaces`, []string{`team-a`}, view.Namespaces)
+	test.Assert(t, `namespaceView: ExecJobs`, 1, len(view.ExecJobs))
+	test.Assert(t, `namespaceView: deploy-a`, env.ExecJobs[`deploy-a`], view.ExecJobs[`deploy-a`])
+}
+
+func TestKarajo_httpAuthorize_namespace(t *testing.T) {
+	var (
+		env = &Env{
+			Secret: `s3cret`,
+			Namespaces: map[string]*Namespace{
+				`team-a`: {
+					Secret: `team-a-s3cret`,
+				},
+			},
+			ExecJobs: map[string]*JobExec{
+				`deploy-a`: {
+					JobBase: JobBase{ID: `deploy-a`, Namespace: `team-a`},
+				},
+			},
+		}
+		k = &Karajo{
+			env: env,
+		}
+	)
+
+	env.secret = env.newSecretValue(env.Secret)
+	var err = env.initNamespaces()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	type testCase struct {
+		handler  libhttp.Callback
+		desc     string
+		method   string
+		path     string
+		payload  string
+		expError string
+	}
+
+	var cases = []testCase{{
+		desc:     `apiBackup`,
+		handler:  k.apiBackup,
+		method:   http.MethodGet,
+		path:     apiBackup,
+		payload:  `id=deploy-a`,
+		expError: `apiBackup: ` + errUnauthorized.Message,
+	}, {
+		desc:     `apiEnvReadOnly`,
+		handler:  k.apiEnvReadOnly,
+		method:   http.MethodPost,
+		path:     apiEnvReadOnly,
+		payload:  `id=deploy-a&read_only=false`,
+		expError: `apiEnvReadOnly: ` + errUnauthorized.Message,
+	}, {
+		desc: `httpAuthorizeJob`,
+		handler: func(epr *libhttp.EndpointRequest) ([]byte, error) {
+			return nil, k.httpAuthorizeJob(epr, epr.RequestBody)
+		},
+		method:  http.MethodPost,
+		path:    apiJobExecPause,
+		payload: `id=deploy-a`,
+	}}
+
+	var (
+		c     testCase
+		epr   *libhttp.EndpointRequest
+		errE  *liberrors.E
+		query string
+		body  []byte
+	)
+	for _, c = range cases {
+		query = ``
+		body = nil
+		if c.method == http.MethodGet {
+			query = `?` + c.payload
+		} else {
+			body = []byte(c.payload)
+		}
+
+		epr = &libhttp.EndpointRequest{
+			HTTPWriter:  httptest.NewRecorder(),
+			HTTPRequest: httptest.NewRequest(c.method, c.path+query, nil),
+			RequestBody: body,
+		}
+		epr.HTTPRequest.Form, _ = url.ParseQuery(c.payload)
+		epr.HTTPRequest.Header.Set(HeaderNameXKarajoSign,
+			Sign([]byte(c.payload), []byte(`team-a-s3cret`)))
+
+		_, err = c.handler(epr)
+		if len(c.expError) == 0 {
+			test.Assert(t, c.desc, nil, err)
+			continue
+		}
+		if err == nil {
+			t.Fatalf(`%s: expecting error %q`, c.desc, c.expError)
+		}
+		test.Assert(t, c.desc, c.expError, err.Error())
+		if !errors.As(err, &errE) {
+			t.Fatalf(`%s: expecting *liberrors.E, got %T`, c.desc, err)
+		}
+		test.Assert(t, c.desc+`: Code`, http.StatusUnauthorized, errE.Code)
+	}
+}
+
+func TestNamespaceDir(t *testing.T) {
+	type testCase struct {
+		dir  string
+		name string
+		exp  string
+	}
+
+	var cases = []testCase{{
+		dir: `/var/log/karajo/job`,
+		exp: `/var/log/karajo/job`,
+	}, {
+		dir:  `/var/log/karajo/job`,
+		name: `team-a`,
+		exp:  `/var/log/karajo/ns/team-a/job`,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.dir+` `+c.name, c.exp, namespaceDir(c.dir, c.name))
+	}
+}
+
+func TestIsHookPath(t *testing.T) {
+	type testCase struct {
+		path string
+		exp  bool
+	}
+
+	var cases = []testCase{{
+		path: apiJobExecRun + `/deploy`,
+		exp:  true,
+	}, {
+		path: apiNamespace + `/team-a/job_exec/run/deploy`,
+		exp:  true,
+	}, {
+		path: apiNamespace + `/team-a/job_exec`,
+	}, {
+		path: apiNamespace + `//job_exec/run/deploy`,
+	}, {
+		path: apiEnv,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.path, c.exp, isHookPath(c.path))
+	}
+}
@@ -0,0 +1,85 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+)
+
+// nonceLength the length of nonce generated by newNonce.
+const nonceLength = 16
+
+// nonceCache store the nonce, or signature, of recently authorized
+// requests for the duration of maxAge, so the same request cannot be
+// replayed within that window.
+type nonceCache struct {
+	// seen contains the time when the nonce expired, indexed by the
+	// nonce.
+	seen map[string]time.Time
+
+	// nextPurge the time when the expired nonces should be removed.
+	nextPurge time.Time
+
+	maxAge time.Duration
+
+	mtx sync.Mutex
+}
+
+// newNonceCache create new nonceCache that keep each nonce for maxAge.
+func newNonceCache(maxAge time.Duration) (nc *nonceCache) {
+	nc = &nonceCache{
+		seen:   make(map[string]time.Time),
+		maxAge: maxAge,
+	}
+	return nc
+}
+
+// newNonce generate random string to be send along with the signed
+// request.
+func newNonce() string {
+	return string(ascii.Random([]byte(ascii.LettersNumber), nonceLength))
+}
+
+// isReplayed return true if the nonce has been seen and not expired yet.
+// Otherwise it store the nonce and return false.
+//
+// The nil nonceCache, or empty nonce, is never replayed.
+func (nc *nonceCache) isReplayed(nonce string) bool {
+	if nc == nil || len(nonce) == 0 {
+		return false
+	}
+
+	var now = timeNow()
+
+	nc.mtx.Lock()
+	defer nc.mtx.Unlock()
+
+	if !now.Before(nc.nextPurge) {
+		nc.purge(now)
+		nc.nextPurge = now.Add(nc.maxAge)
+	}
+
+	var expiredAt, ok = nc.seen[nonce]
+	if ok && now.Before(expiredAt) {
+		return true
+	}
+	nc.seen[nonce] = now.Add(nc.maxAge)
+	return false
+}
+
+// purge remove the expired nonces.
+func (nc *nonceCache) purge(now time.Time) {
+	var (
+		nonce     string
+		expiredAt time.Time
+	)
+	for nonce, expiredAt = range nc.seen {
+		if !now.Before(expiredAt) {
+			delete(nc.seen, nonce)
+		}
+	}
+}
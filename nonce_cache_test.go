@@ -0,0 +1,43 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestNonceCache_isReplayed(t *testing.T) {
+	var nc *nonceCache
+
+	test.Assert(t, `nil cache`, false, nc.isReplayed(`a`))
+
+	nc = newNonceCache(time.Minute)
+
+	test.Assert(t, `empty nonce`, false, nc.isReplayed(``))
+	test.Assert(t, `empty nonce again`, false, nc.isReplayed(``))
+	test.Assert(t, `first`, false, nc.isReplayed(`a`))
+	test.Assert(t, `replayed`, true, nc.isReplayed(`a`))
+	test.Assert(t, `other nonce`, false, nc.isReplayed(`b`))
+
+	// Expire the nonce "a".
+	nc.seen[`a`] = timeNow()
+	test.Assert(t, `expired`, false, nc.isReplayed(`a`))
+	test.Assert(t, `replayed after expired`, true, nc.isReplayed(`a`))
+
+	nc.seen[`b`] = timeNow()
+	nc.purge(timeNow())
+	test.Assert(t, `purge`, map[string]time.Time{
+		`a`: timeNow().Add(time.Minute),
+	}, nc.seen)
+}
+
+func TestNewNonce(t *testing.T) {
+	var a = newNonce()
+
+	test.Assert(t, `length`, nonceLength, len(a))
+	test.Assert(t, `unique`, true, a != newNonce())
+}
@@ -3,8 +3,99 @@
 
 package karajo
 
-// notifClient generic client for sending notification.
-type notifClient interface {
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defNotifPushTimeout the timeout for sending the notification to push
+// server, ntfy or Gotify.
+const defNotifPushTimeout = 30 * time.Second
+
+// NotifClient generic client for sending notification.
+// The custom client can be registered using [RegisterNotifKind].
+type NotifClient interface {
 	// Send the job status and log.
-	Send(jlog *JobLog)
+	// It will return an error if the notification cannot be send, in
+	// which case the caller may retry it later.
+	Send(jlog *JobLog) (err error)
+
+	// Close the client.
+	Close()
+}
+
+// NotifClientFactory create new NotifClient from the notification
+// environment.
+type NotifClientFactory func(envNotif EnvNotif) (cl NotifClient, err error)
+
+var (
+	notifKinds    = map[string]NotifClientFactory{}
+	notifKindsMtx sync.RWMutex
+)
+
+// RegisterNotifKind register custom [EnvNotif.Kind] to send the
+// notification to other system.
+// The name is case insensitive and must not be one of the built-in kind:
+// email, gotify, or ntfy.
+// Registering with the same name replace the existing one.
+//
+// The kind must be registered before the Env initialized.
+func RegisterNotifKind(name string, factory NotifClientFactory) (err error) {
+	var logp = `RegisterNotifKind`
+
+	name = strings.ToLower(strings.TrimSpace(name))
+	if len(name) == 0 {
+		return fmt.Errorf(`%s: empty name`, logp)
+	}
+	switch name {
+	case notifKindEmail, notifKindGotify, notifKindNtfy:
+		return fmt.Errorf(`%s: %q is built-in`, logp, name)
+	}
+	if factory == nil {
+		return fmt.Errorf(`%s: %q: nil factory`, logp, name)
+	}
+
+	notifKindsMtx.Lock()
+	notifKinds[name] = factory
+	notifKindsMtx.Unlock()
+
+	return nil
+}
+
+// customNotifKind return the registered NotifClientFactory by its name.
+func customNotifKind(name string) (factory NotifClientFactory) {
+	notifKindsMtx.RLock()
+	factory = notifKinds[name]
+	notifKindsMtx.RUnlock()
+	return factory
+}
+
+// notifTitle return the title of notification for jlog.
+func notifTitle(jlog *JobLog) string {
+	return fmt.Sprintf(`%s: %s: #%d: %s`, jlog.jobKind, jlog.JobID, jlog.Counter, jlog.Status)
+}
+
+// doPush send the request to push server.
+// It will return an error if the server response with non-2xx status
+// code.
+func doPush(httpc *http.Client, req *http.Request) (err error) {
+	var httpRes *http.Response
+
+	httpRes, err = httpc.Do(req)
+	if err != nil {
+		return err
+	}
+	defer httpRes.Body.Close()
+
+	if httpRes.StatusCode >= 200 && httpRes.StatusCode < 300 {
+		return nil
+	}
+
+	var body, _ = io.ReadAll(io.LimitReader(httpRes.Body, 512))
+
+	return fmt.Errorf(`%s: %s`, httpRes.Status, body)
 }
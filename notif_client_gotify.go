@@ -0,0 +1,114 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// clientGotify client for sending notification to Gotify server.
+type clientGotify struct {
+	httpc *http.Client
+
+	// priority of message by job status.
+	priority map[string]int
+
+	// messageURL the URL of Gotify API to create message,
+	// "<server_url>/message".
+	messageURL string
+
+	env EnvNotif
+}
+
+// gotifyMessage the request body to create message in Gotify.
+type gotifyMessage struct {
+	Title    string `json:"title"`
+	Message  string `json:"message"`
+	Priority int    `json:"priority"`
+}
+
+// newClientGotify create new client for Gotify.
+// The default priority is 8 for failed job and 5 for others.
+func newClientGotify(envNotif EnvNotif) (cl *clientGotify, err error) {
+	var logp = `newClientGotify`
+
+	if len(envNotif.ServerURL) == 0 {
+		return nil, fmt.Errorf(`%s: empty server_url`, logp)
+	}
+	if len(envNotif.Token) == 0 {
+		return nil, fmt.Errorf(`%s: empty token`, logp)
+	}
+
+	cl = &clientGotify{
+		httpc: &http.Client{
+			Timeout: defNotifPushTimeout,
+		},
+		priority:   make(map[string]int),
+		messageURL: strings.TrimRight(envNotif.ServerURL, `/`) + `/message`,
+		env:        envNotif,
+	}
+
+	var prio map[string]string
+
+	prio, err = envNotif.priorities(map[string]string{
+		JobStatusFailed:  `8`,
+		JobStatusSuccess: `5`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		status string
+		value  string
+	)
+	for status, value = range prio {
+		cl.priority[status], err = strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf(`%s: invalid priority %q: %w`, logp, value, err)
+		}
+	}
+	return cl, nil
+}
+
+// Send the job status and the last lines of log as Gotify message.
+func (cl *clientGotify) Send(jlog *JobLog) (err error) {
+	var (
+		logp = `clientGotify.Send`
+		msg  = gotifyMessage{
+			Title:    notifTitle(jlog),
+			Message:  string(lastLines(jlog.tailContent(), defNotifSummaryLines)),
+			Priority: cl.priority[jlog.Status],
+		}
+
+		body []byte
+		req  *http.Request
+	)
+
+	body, err = json.Marshal(&msg)
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	req, err = http.NewRequest(http.MethodPost, cl.messageURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+	req.Header.Set(`Content-Type`, `application/json`)
+	req.Header.Set(`X-Gotify-Key`, cl.env.Token)
+
+	err = doPush(cl.httpc, req)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, cl.env.Name, err)
+	}
+	return nil
+}
+
+// Close the client, do nothing.
+func (cl *clientGotify) Close() {}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestClientGotify_Send(t *testing.T) {
+	var (
+		gotReq *http.Request
+		gotMsg gotifyMessage
+	)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotReq = req
+		_ = json.NewDecoder(req.Body).Decode(&gotMsg)
+	}))
+	defer srv.Close()
+
+	var (
+		cl  *clientGotify
+		err error
+	)
+
+	cl, err = newClientGotify(EnvNotif{
+		Name:      `phone`,
+		ServerURL: srv.URL,
+		Token:     `app_secret`,
+		Priority:  []string{`failed:10`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cl.Send(&JobLog{
+		jobKind: jobKindExec,
+		JobID:   `backup`,
+		Status:  JobStatusFailed,
+		Counter: 2,
+		content: []byte("disk full\n"),
+	})
+
+	var exp = gotifyMessage{
+		Title:    `job: backup: #2: failed`,
+		Message:  "disk full\n",
+		Priority: 10,
+	}
+	test.Assert(t, `path`, `/message`, gotReq.URL.Path)
+	test.Assert(t, `X-Gotify-Key`, `app_secret`, gotReq.Header.Get(`X-Gotify-Key`))
+	test.Assert(t, `message`, exp, gotMsg)
+
+	_, err = newClientGotify(EnvNotif{
+		ServerURL: srv.URL,
+		Token:     `app_secret`,
+		Priority:  []string{`failed:high`},
+	})
+	test.Assert(t, `invalid priority`,
+		`newClientGotify: invalid priority "high": strconv.Atoi: parsing "high": invalid syntax`,
+		err.Error())
+}
@@ -0,0 +1,96 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// clientNtfy client for publishing notification to ntfy server.
+type clientNtfy struct {
+	httpc *http.Client
+
+	// priority of message by job status.
+	priority map[string]string
+
+	// topicURL the URL where the message published,
+	// "<server_url>/<topic>".
+	topicURL string
+
+	env EnvNotif
+}
+
+// newClientNtfy create new client for ntfy.
+// The default priority is "high" for failed job and "default" for
+// others.
+func newClientNtfy(envNotif EnvNotif) (cl *clientNtfy, err error) {
+	var logp = `newClientNtfy`
+
+	if len(envNotif.ServerURL) == 0 {
+		return nil, fmt.Errorf(`%s: empty server_url`, logp)
+	}
+	if len(envNotif.Topic) == 0 {
+		return nil, fmt.Errorf(`%s: empty topic`, logp)
+	}
+
+	cl = &clientNtfy{
+		httpc: &http.Client{
+			Timeout: defNotifPushTimeout,
+		},
+		topicURL: strings.TrimRight(envNotif.ServerURL, `/`) + `/` +
+			url.PathEscape(envNotif.Topic),
+		env: envNotif,
+	}
+
+	cl.priority, err = envNotif.priorities(map[string]string{
+		JobStatusFailed: `high`,
+	})
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return cl, nil
+}
+
+// Send publish the job status and the last lines of log to the topic.
+func (cl *clientNtfy) Send(jlog *JobLog) (err error) {
+	var (
+		logp = `clientNtfy.Send`
+		body = lastLines(jlog.tailContent(), defNotifSummaryLines)
+
+		req *http.Request
+	)
+
+	req, err = http.NewRequest(http.MethodPost, cl.topicURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	req.Header.Set(`Title`, notifTitle(jlog))
+	var tags = jlog.Status
+	if len(jlog.Category) != 0 {
+		tags += `,` + jlog.Category
+	}
+	req.Header.Set(`Tags`, tags)
+
+	var priority = cl.priority[jlog.Status]
+	if len(priority) != 0 {
+		req.Header.Set(`Priority`, priority)
+	}
+	if len(cl.env.Token) != 0 {
+		req.Header.Set(`Authorization`, `Bearer `+cl.env.Token)
+	}
+
+	err = doPush(cl.httpc, req)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, cl.env.Name, err)
+	}
+	return nil
+}
+
+// Close the client, do nothing.
+func (cl *clientNtfy) Close() {}
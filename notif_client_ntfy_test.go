@@ -0,0 +1,66 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestClientNtfy_Send(t *testing.T) {
+	var (
+		gotReq  *http.Request
+		gotBody []byte
+	)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		gotReq = req
+		gotBody, _ = io.ReadAll(req.Body)
+	}))
+	defer srv.Close()
+
+	var (
+		cl  *clientNtfy
+		err error
+	)
+
+	cl, err = newClientNtfy(EnvNotif{
+		Name:      `phone`,
+		ServerURL: srv.URL + `/`,
+		Topic:     `karajo jobs`,
+		Token:     `tk_secret`,
+		Priority:  []string{`success: low`},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var jlog = &JobLog{
+		jobKind: jobKindExec,
+		JobID:   `backup`,
+		Status:  JobStatusFailed,
+		Counter: 2,
+		content: []byte("disk full\n"),
+	}
+
+	cl.Send(jlog)
+
+	test.Assert(t, `path`, `/karajo jobs`, gotReq.URL.Path)
+	test.Assert(t, `Title`, `job: backup: #2: failed`, gotReq.Header.Get(`Title`))
+	test.Assert(t, `Priority`, `high`, gotReq.Header.Get(`Priority`))
+	test.Assert(t, `Authorization`, `Bearer tk_secret`, gotReq.Header.Get(`Authorization`))
+	test.Assert(t, `body`, "disk full\n", string(gotBody))
+
+	jlog.Status = JobStatusSuccess
+	cl.Send(jlog)
+
+	test.Assert(t, `Priority success`, `low`, gotReq.Header.Get(`Priority`))
+
+	_, err = newClientNtfy(EnvNotif{ServerURL: srv.URL})
+	test.Assert(t, `empty topic`, `newClientNtfy: empty topic`, err.Error())
+}
@@ -4,18 +4,74 @@
 package karajo
 
 import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
 	"fmt"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/textproto"
+	"os"
+	"sync"
+	"time"
 
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/email"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
 	"git.sr.ht/~shulhan/pakakeh.go/lib/smtp"
 )
 
+// defNotifSummaryLines the number of last lines of log in the email body
+// when the log is attached.
+const defNotifSummaryLines = 20
+
+const (
+	// defNotifSMTPQueueSize the maximum number of messages waiting to
+	// be send.
+	defNotifSMTPQueueSize = 100
+
+	// defNotifSMTPMaxRetry the maximum number of retry before the
+	// message is dropped.
+	defNotifSMTPMaxRetry = 5
+
+	// defNotifSMTPRetryDelay the delay before the first retry, doubled
+	// on each retry up to defNotifSMTPRetryDelayMax.
+	defNotifSMTPRetryDelay    = 5 * time.Second
+	defNotifSMTPRetryDelayMax = time.Minute
+
+	// defNotifSMTPIdle the duration before the idle connection
+	// closed.
+	defNotifSMTPIdle = 30 * time.Second
+)
+
+// smtpConn the connection to SMTP server, implemented by [smtp.Client].
+type smtpConn interface {
+	MailTx(mail *smtp.MailTx) (res *smtp.Response, err error)
+	Quit() (res *smtp.Response, err error)
+}
+
 // clientSMTP client for SMTP.
+//
+// The message is send asynchronously through the bounded queue by single
+// worker, that keep the connection open until its idle.
+// If sending failed, the worker reconnect and retry with exponential
+// backoff, so the burst of failures does not flood the server.
 type clientSMTP struct {
-	conn *smtp.Client
+	conn smtpConn
+
+	// dial connect and authenticate to the SMTP server.
+	dial func() (smtpConn, error)
+
+	queue chan *smtp.MailTx
+	stopq chan struct{}
+	doneq chan struct{}
+
+	closeOnce sync.Once
+
 	opts smtp.ClientOptions
 	env  EnvNotif
+
+	retryDelay time.Duration
 }
 
 // newClientSMTP create new client for SMTP.
@@ -23,83 +79,347 @@ func newClientSMTP(envNotif EnvNotif) (cl *clientSMTP, err error) {
 	var logp = `newClientSMTP`
 
 	cl = &clientSMTP{
-		env: envNotif,
-		opts: smtp.ClientOptions{
-			ServerURL:     envNotif.SMTPServer,
-			AuthUser:      envNotif.SMTPUser,
-			AuthPass:      envNotif.SMTPPassword,
-			AuthMechanism: smtp.SaslMechanismPlain,
-			Insecure:      envNotif.SMTPInsecure,
-		},
+		env:        envNotif,
+		opts:       envNotif.smtpClientOptions(),
+		retryDelay: defNotifSMTPRetryDelay,
+	}
+	cl.dial = func() (conn smtpConn, err error) {
+		var smtpc *smtp.Client
+		smtpc, err = smtp.NewClient(cl.opts)
+		if err != nil {
+			return nil, err
+		}
+		return smtpc, nil
 	}
 
 	// Test connecting and authenticated with the server.
-	cl.conn, err = smtp.NewClient(cl.opts)
+	cl.conn, err = cl.dial()
 	if err != nil {
 		return nil, fmt.Errorf(`%s: %s: %w`, logp, cl.opts.ServerURL, err)
 	}
 
 	_, _ = cl.conn.Quit()
+	cl.conn = nil
+
+	cl.start()
 
 	return cl, nil
 }
 
+// smtpClientOptions return the options to connect and authenticate to
+// the SMTP server.
+func (envNotif *EnvNotif) smtpClientOptions() smtp.ClientOptions {
+	return smtp.ClientOptions{
+		ServerURL:     envNotif.SMTPServer,
+		AuthUser:      envNotif.SMTPUser,
+		AuthPass:      envNotif.SMTPPassword,
+		AuthMechanism: smtp.SaslMechanismPlain,
+		Insecure:      envNotif.SMTPInsecure,
+	}
+}
+
+// start the worker that send the messages in the queue.
+func (cl *clientSMTP) start() {
+	cl.queue = make(chan *smtp.MailTx, defNotifSMTPQueueSize)
+	cl.stopq = make(chan struct{})
+	cl.doneq = make(chan struct{})
+	go cl.worker()
+}
+
+// Close stop the worker and close the connection.
+// The messages that still in the queue are dropped.
+func (cl *clientSMTP) Close() {
+	cl.closeOnce.Do(func() {
+		close(cl.stopq)
+		<-cl.doneq
+	})
+}
+
 // Send the job status and log to user.
-func (cl *clientSMTP) Send(jlog *JobLog) {
+// The message is pushed to the queue, or it will return an error if the
+// queue is full.
+func (cl *clientSMTP) Send(jlog *JobLog) (err error) {
 	var (
 		logp = `clientSMTP.Send`
 		msg  = email.Message{}
 
 		v    string
 		data []byte
-		err  error
 	)
 
 	err = msg.SetFrom(cl.env.From)
 	if err != nil {
-		mlog.Errf(`%s: %s`, logp, err)
-		return
+		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 	for _, v = range cl.env.To {
 		err = msg.AddTo(v)
 		if err != nil {
-			mlog.Errf(`%s: To %s: %s`, logp, v, err)
-			return
+			return fmt.Errorf(`%s: To %s: %w`, logp, v, err)
 		}
 	}
 
-	v = fmt.Sprintf(`%s: %s: #%d: %s`, jlog.jobKind, jlog.JobID, jlog.Counter, jlog.Status)
+	v = notifTitle(jlog)
 	msg.SetSubject(v)
 
-	err = msg.SetBodyText(jlog.content)
+	var content []byte
+
+	content, err = jlog.read()
 	if err != nil {
 		mlog.Errf(`%s: %s`, logp, err)
-		return
+		content = jlog.tailContent()
 	}
 
-	data, err = msg.Pack()
+	if cl.env.AttachLog {
+		data, err = cl.packAttachLog(&msg, jlog, content)
+	} else {
+		err = msg.SetBodyText(content)
+		if err == nil {
+			data, err = msg.Pack()
+		}
+	}
 	if err != nil {
-		mlog.Errf(`%s: %s`, logp, err)
-		return
+		return fmt.Errorf(`%s: %w`, logp, err)
 	}
 
 	var mailtx = smtp.NewMailTx(cl.env.From, cl.env.To, data)
 
-	cl.conn, err = smtp.NewClient(cl.opts)
-	if err != nil {
-		mlog.Errf(`%s: %s: %s`, logp, cl.opts.ServerURL, err)
+	select {
+	case cl.queue <- mailtx:
+	default:
+		return fmt.Errorf(`%s: %s: queue is full, message %q not queued`, logp, cl.env.Name, v)
+	}
+	return nil
+}
+
+// worker send the message in the queue one by one, using the same
+// connection until its idle for defNotifSMTPIdle.
+func (cl *clientSMTP) worker() {
+	var (
+		idle   = time.NewTimer(defNotifSMTPIdle)
+		mailtx *smtp.MailTx
+	)
+	defer close(cl.doneq)
+
+	for {
+		select {
+		case mailtx = <-cl.queue:
+			cl.deliver(mailtx)
+			idle.Reset(defNotifSMTPIdle)
+
+		case <-idle.C:
+			cl.disconnect()
+
+		case <-cl.stopq:
+			idle.Stop()
+			cl.disconnect()
+			return
+		}
+	}
+}
+
+// deliver send the mailtx, reconnecting and retrying with exponential
+// backoff on failure.
+// The message is dropped after defNotifSMTPMaxRetry retries, or if the
+// client is closed.
+func (cl *clientSMTP) deliver(mailtx *smtp.MailTx) {
+	var (
+		logp  = `clientSMTP.deliver`
+		delay = cl.retryDelay
+
+		retry int
+		err   error
+	)
+	for retry = 0; ; retry++ {
+		if cl.conn == nil {
+			cl.conn, err = cl.dial()
+		}
+		if cl.conn != nil {
+			_, err = cl.conn.MailTx(mailtx)
+			if err == nil {
+				return
+			}
+			cl.disconnect()
+		}
+		if retry == defNotifSMTPMaxRetry {
+			mlog.Errf(`%s: %s: %s, message dropped`, logp, cl.opts.ServerURL, err)
+			return
+		}
+		mlog.Errf(`%s: %s: %s, retry in %s`, logp, cl.opts.ServerURL, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-cl.stopq:
+			return
+		}
+		delay = min(delay*2, defNotifSMTPRetryDelayMax)
+	}
+}
+
+// disconnect close the connection to server, if its open.
+func (cl *clientSMTP) disconnect() {
+	if cl.conn == nil {
 		return
 	}
+	_, _ = cl.conn.Quit()
+	cl.conn = nil
+}
+
+// packAttachLog pack the msg as multipart message, with the summary of
+// jlog as the body and the log content as gzip attachment.
+// If the log is larger than AttachLogMaxSize, only the last
+// AttachLogMaxSize bytes are attached.
+func (cl *clientSMTP) packAttachLog(msg *email.Message, jlog *JobLog, content []byte) (data []byte, err error) {
+	var (
+		logp     = `packAttachLog`
+		attached = content
+		maxSize  = cl.env.AttachLogMaxSize
+		body     bytes.Buffer
+		gz       bytes.Buffer
+	)
+
+	if maxSize > 0 && int64(len(attached)) > maxSize {
+		attached = attached[int64(len(attached))-maxSize:]
+	}
 
-	_, err = cl.conn.MailTx(mailtx)
+	var gzw = gzip.NewWriter(&gz)
+	_, err = gzw.Write(attached)
+	if err == nil {
+		err = gzw.Close()
+	}
 	if err != nil {
-		mlog.Errf(`%s: %s`, logp, err)
-		return
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
 	}
 
-	_, err = cl.conn.Quit()
+	var (
+		mw       = multipart.NewWriter(&body)
+		fileName = jlog.Name + `.log.gz`
+		part     = textproto.MIMEHeader{}
+		w        = &bytes.Buffer{}
+	)
+
+	part.Set(`Content-Type`, `text/plain; charset="utf-8"`)
+	part.Set(`Content-Transfer-Encoding`, `quoted-printable`)
+
+	var qpw = quotedprintable.NewWriter(w)
+	_, err = qpw.Write(summarizeJobLog(jlog, fileName, content, len(attached) != len(content)))
+	if err == nil {
+		err = qpw.Close()
+	}
+	if err == nil {
+		err = writePart(mw, part, w.Bytes())
+	}
 	if err != nil {
-		mlog.Errf(`%s: %s`, logp, err)
-		return
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	part = textproto.MIMEHeader{}
+	part.Set(`Content-Type`, fmt.Sprintf(`application/gzip; name=%q`, fileName))
+	part.Set(`Content-Disposition`, fmt.Sprintf(`attachment; filename=%q`, fileName))
+	part.Set(`Content-Transfer-Encoding`, `base64`)
+
+	err = writePart(mw, part, encodeBase64Lines(gz.Bytes()))
+	if err == nil {
+		err = mw.Close()
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var (
+		now         = timeNow()
+		hostname, _ = os.Hostname()
+		id          = fmt.Sprintf(`%d.%s@%s`, now.Unix(),
+			ascii.Random([]byte(ascii.LettersNumber), 8), hostname)
+		contentType = fmt.Sprintf(`multipart/mixed; boundary="%s"`, mw.Boundary())
+	)
+
+	msg.SetID(id)
+	err = msg.Header.Set(email.FieldTypeDate, []byte(now.Format(email.DateFormat)))
+	if err == nil {
+		err = msg.Header.Set(email.FieldTypeMIMEVersion, []byte(`1.0`))
+	}
+	if err == nil {
+		err = msg.Header.Set(email.FieldTypeContentType, []byte(contentType))
+	}
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var out bytes.Buffer
+
+	_, err = msg.Header.WriteTo(&out)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	out.WriteString("\r\n")
+	out.Write(body.Bytes())
+
+	return out.Bytes(), nil
+}
+
+// writePart write the part with header and content into mw.
+func writePart(mw *multipart.Writer, header textproto.MIMEHeader, content []byte) (err error) {
+	var w, errPart = mw.CreatePart(header)
+	if errPart != nil {
+		return errPart
+	}
+	_, err = w.Write(content)
+	return err
+}
+
+// encodeBase64Lines encode the content into base64, with each line at
+// most 76 characters as required by RFC 2045.
+func encodeBase64Lines(content []byte) (out []byte) {
+	var (
+		enc = []byte(base64.StdEncoding.EncodeToString(content))
+		n   int
+	)
+	for len(enc) > 0 {
+		n = min(len(enc), 76)
+		out = append(out, enc[:n]...)
+		out = append(out, '\r', '\n')
+		enc = enc[n:]
+	}
+	return out
+}
+
+// summarizeJobLog return the summary of job log and the last lines of its
+// content for the email body.
+func summarizeJobLog(jlog *JobLog, fileName string, content []byte, isTruncated bool) []byte {
+	var buf bytes.Buffer
+
+	fmt.Fprintf(&buf, "Job: %s %s\n", jlog.jobKind, jlog.JobID)
+	fmt.Fprintf(&buf, "Run: #%d\n", jlog.Counter)
+	fmt.Fprintf(&buf, "Status: %s\n", jlog.Status)
+	if len(jlog.Category) != 0 {
+		fmt.Fprintf(&buf, "Category: %s\n", jlog.Category)
+	}
+	fmt.Fprintf(&buf, "Log: %s, %d bytes", fileName, len(content))
+	if isTruncated {
+		buf.WriteString(", truncated")
+	}
+	fmt.Fprintf(&buf, "\n\nThe last %d lines of log:\n\n", defNotifSummaryLines)
+	buf.Write(lastLines(content, defNotifSummaryLines))
+
+	return buf.Bytes()
+}
+
+// lastLines return the last n lines in the content.
+func lastLines(content []byte, n int) []byte {
+	var end = len(content)
+	if end > 0 && content[end-1] == '\n' {
+		end--
+	}
+	var (
+		start = end
+		idx   int
+	)
+	for ; n > 0; n-- {
+		idx = bytes.LastIndexByte(content[:start], '\n')
+		if idx < 0 {
+			return content
+		}
+		start = idx
 	}
+	return content[start+1:]
 }
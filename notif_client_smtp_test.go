@@ -0,0 +1,207 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/email"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/smtp"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestClientSMTP_packAttachLog(t *testing.T) {
+	var (
+		cl = &clientSMTP{
+			env: EnvNotif{
+				AttachLog:        true,
+				AttachLogMaxSize: 12,
+			},
+		}
+		jlog = &JobLog{
+			jobKind: jobKindExec,
+			JobID:   `test`,
+			Name:    `test.1.failed`,
+			Status:  JobStatusFailed,
+			Counter: 1,
+			content: []byte("line 1\nline 2\nline 3\n"),
+		}
+		msg = email.Message{}
+
+		data []byte
+		err  error
+	)
+
+	err = msg.SetFrom(`karajo@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = msg.AddTo(`ops@example.com`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.SetSubject(`test`)
+
+	data, err = cl.packAttachLog(&msg, jlog, jlog.content)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var mailmsg *mail.Message
+
+	mailmsg, err = mail.ReadMessage(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		mediaType string
+		params    map[string]string
+	)
+	mediaType, params, err = mime.ParseMediaType(mailmsg.Header.Get(`Content-Type`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `Content-Type`, `multipart/mixed`, mediaType)
+
+	var (
+		mr   = multipart.NewReader(mailmsg.Body, params[`boundary`])
+		part *multipart.Part
+		got  []byte
+	)
+
+	part, err = mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = io.ReadAll(quotedprintable.NewReader(part))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got = bytes.ReplaceAll(got, []byte("\r\n"), []byte("\n"))
+
+	var exp = "Job: job test\n" +
+		"Run: #1\n" +
+		"Status: failed\n" +
+		"Log: test.1.failed.log.gz, 21 bytes, truncated\n\n" +
+		"The last 20 lines of log:\n\n" +
+		"line 1\nline 2\nline 3\n"
+	test.Assert(t, `body`, exp, string(got))
+
+	part, err = mr.NextPart()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `attachment`, `test.1.failed.log.gz`, part.FileName())
+
+	var gzr *gzip.Reader
+
+	gzr, err = gzip.NewReader(base64.NewDecoder(base64.StdEncoding, part))
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err = io.ReadAll(gzr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `attachment content`, "ne 2\nline 3\n", string(got))
+}
+
+func TestLastLines(t *testing.T) {
+	type testCase struct {
+		content string
+		exp     string
+		n       int
+	}
+
+	var cases = []testCase{{
+		content: "a\nb\nc\n",
+		n:       2,
+		exp:     "b\nc\n",
+	}, {
+		content: "a\nb\nc",
+		n:       1,
+		exp:     "c",
+	}, {
+		content: "a\nb\n",
+		n:       5,
+		exp:     "a\nb\n",
+	}, {
+		content: ``,
+		n:       1,
+		exp:     ``,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		test.Assert(t, c.content, c.exp, string(lastLines([]byte(c.content), c.n)))
+	}
+}
+
+// fakeSMTPConn implement smtpConn that fail the first nfail MailTx.
+type fakeSMTPConn struct {
+	sent  chan *smtp.MailTx
+	nfail *int
+}
+
+func (conn *fakeSMTPConn) MailTx(mail *smtp.MailTx) (res *smtp.Response, err error) {
+	if *conn.nfail > 0 {
+		*conn.nfail--
+		return nil, errors.New(`connection reset`)
+	}
+	conn.sent <- mail
+	return &smtp.Response{Code: smtp.StatusOK}, nil
+}
+
+func (conn *fakeSMTPConn) Quit() (res *smtp.Response, err error) {
+	return nil, nil
+}
+
+func TestClientSMTP_deliver(t *testing.T) {
+	var (
+		nfail = 2
+		ndial int
+		sent  = make(chan *smtp.MailTx, 1)
+		cl    = &clientSMTP{
+			env: EnvNotif{
+				Name: `test`,
+				From: `karajo@example.com`,
+				To:   []string{`ops@example.com`},
+			},
+			retryDelay: time.Millisecond,
+		}
+	)
+	cl.dial = func() (smtpConn, error) {
+		ndial++
+		return &fakeSMTPConn{sent: sent, nfail: &nfail}, nil
+	}
+	cl.start()
+	defer cl.Close()
+
+	cl.Send(&JobLog{
+		jobKind: jobKindExec,
+		JobID:   `test`,
+		Status:  JobStatusFailed,
+		Counter: 1,
+		content: []byte("output\n"),
+	})
+
+	var mailtx = <-sent
+
+	test.Assert(t, `recipients`, []string{`ops@example.com`}, mailtx.Recipients)
+
+	// Each failure close the connection, so the client reconnect on
+	// each retry.
+	test.Assert(t, `number of dial`, 3, ndial)
+}
@@ -0,0 +1,152 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+const (
+	// defNotifPoolQueueSize the maximum number of job logs waiting to
+	// be send by each notification.
+	defNotifPoolQueueSize = 100
+
+	// defNotifMaxConcurrent the default number of job logs send at
+	// the same time by each notification.
+	defNotifMaxConcurrent = 1
+
+	// defNotifMaxRetry the maximum number of retry before the job log
+	// is dropped.
+	defNotifMaxRetry = 3
+
+	// defNotifRetryDelay the delay before the first retry, doubled on
+	// each retry up to defNotifRetryDelayMax.
+	defNotifRetryDelay    = 5 * time.Second
+	defNotifRetryDelayMax = time.Minute
+)
+
+// notifPool send the job logs to the notification client using bounded
+// number of workers, defined by EnvNotif.MaxConcurrent, so the burst of job
+// logs does not create unbounded number of goroutines.
+// If sending failed, the job log is retried with exponential backoff.
+type notifPool struct {
+	cl NotifClient
+
+	// pending count the job logs that has been queued but not
+	// completely send, shared by all pools.
+	pending *atomic.Int64
+
+	queue chan *JobLog
+	stopq chan struct{}
+
+	name string
+
+	wg        sync.WaitGroup
+	closeOnce sync.Once
+
+	retryDelay time.Duration
+	concurrent int
+}
+
+// newNotifPool create new pool for notification client cl with maximum
+// concurrent workers.
+func newNotifPool(name string, cl NotifClient, concurrent int, pending *atomic.Int64) (pool *notifPool) {
+	if concurrent <= 0 {
+		concurrent = defNotifMaxConcurrent
+	}
+	pool = &notifPool{
+		cl:         cl,
+		pending:    pending,
+		queue:      make(chan *JobLog, defNotifPoolQueueSize),
+		stopq:      make(chan struct{}),
+		name:       name,
+		retryDelay: defNotifRetryDelay,
+		concurrent: concurrent,
+	}
+	return pool
+}
+
+// start the workers.
+func (pool *notifPool) start() {
+	var x int
+	for x = 0; x < pool.concurrent; x++ {
+		pool.wg.Add(1)
+		go pool.worker()
+	}
+}
+
+// push the jlog into queue.
+// It will return false if the queue is full.
+func (pool *notifPool) push(jlog *JobLog) bool {
+	select {
+	case pool.queue <- jlog:
+		pool.pending.Add(1)
+		return true
+	default:
+		mlog.Errf(`notif %s: queue is full, log %s #%d dropped`,
+			pool.name, jlog.JobID, jlog.Counter)
+		return false
+	}
+}
+
+// worker send the job log in the queue one by one until the pool closed.
+func (pool *notifPool) worker() {
+	defer pool.wg.Done()
+
+	var jlog *JobLog
+	for {
+		select {
+		case jlog = <-pool.queue:
+			pool.send(jlog)
+			pool.pending.Add(-1)
+
+		case <-pool.stopq:
+			return
+		}
+	}
+}
+
+// send the jlog, retrying with exponential backoff on failure.
+// The jlog is dropped after defNotifMaxRetry retries, or if the pool is
+// closed.
+func (pool *notifPool) send(jlog *JobLog) {
+	var (
+		delay = pool.retryDelay
+
+		retry int
+		err   error
+	)
+	for retry = 0; ; retry++ {
+		err = pool.cl.Send(jlog)
+		if err == nil {
+			return
+		}
+		if retry == defNotifMaxRetry {
+			mlog.Errf(`notif %s: %s, log %s #%d dropped`, pool.name,
+				err, jlog.JobID, jlog.Counter)
+			return
+		}
+		mlog.Errf(`notif %s: %s, retry in %s`, pool.name, err, delay)
+
+		select {
+		case <-time.After(delay):
+		case <-pool.stopq:
+			return
+		}
+		delay = min(delay*2, defNotifRetryDelayMax)
+	}
+}
+
+// Close stop all workers and wait until they finished.
+// The job logs that still in the queue are dropped.
+func (pool *notifPool) Close() {
+	pool.closeOnce.Do(func() {
+		close(pool.stopq)
+		pool.wg.Wait()
+	})
+}
@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+// fakeNotifClient implement NotifClient that fail the first nfail Send.
+type fakeNotifClient struct {
+	sent  chan *JobLog
+	block chan struct{}
+
+	mtx     sync.Mutex
+	nfail   int
+	nsend   int
+	running int
+	maxRun  int
+}
+
+func (cl *fakeNotifClient) Send(jlog *JobLog) (err error) {
+	cl.mtx.Lock()
+	cl.nsend++
+	cl.running++
+	if cl.running > cl.maxRun {
+		cl.maxRun = cl.running
+	}
+	if cl.nfail > 0 {
+		cl.nfail--
+		cl.running--
+		cl.mtx.Unlock()
+		return errors.New(`connection refused`)
+	}
+	cl.mtx.Unlock()
+
+	if cl.block != nil {
+		<-cl.block
+	}
+
+	cl.mtx.Lock()
+	cl.running--
+	cl.mtx.Unlock()
+
+	cl.sent <- jlog
+	return nil
+}
+
+func (cl *fakeNotifClient) Close() {}
+
+func TestNotifPool_retry(t *testing.T) {
+	type testCase struct {
+		desc      string
+		nfail     int
+		expNsend  int
+		expIsSent bool
+	}
+
+	var cases = []testCase{{
+		desc:      `success after retry`,
+		nfail:     2,
+		expNsend:  3,
+		expIsSent: true,
+	}, {
+		desc:     `dropped after max retry`,
+		nfail:    defNotifMaxRetry + 1,
+		expNsend: defNotifMaxRetry + 1,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		var (
+			cl = &fakeNotifClient{
+				sent:  make(chan *JobLog, 1),
+				nfail: c.nfail,
+			}
+			pending atomic.Int64
+			pool    = newNotifPool(`test`, cl, 1, &pending)
+			jlog    = &JobLog{JobID: `test`, Counter: 1}
+		)
+		pool.retryDelay = time.Millisecond
+
+		pool.start()
+		pool.push(jlog)
+
+		for pending.Load() != 0 {
+			time.Sleep(time.Millisecond)
+		}
+		pool.Close()
+
+		test.Assert(t, c.desc+`: number of send`, c.expNsend, cl.nsend)
+		test.Assert(t, c.desc+`: is sent`, c.expIsSent, len(cl.sent) == 1)
+	}
+}
+
+func TestNotifPool_maxConcurrent(t *testing.T) {
+	var (
+		cl = &fakeNotifClient{
+			sent:  make(chan *JobLog, 10),
+			block: make(chan struct{}),
+		}
+		pending atomic.Int64
+		pool    = newNotifPool(`test`, cl, 2, &pending)
+
+		x int
+	)
+	pool.start()
+
+	for x = 1; x <= 5; x++ {
+		pool.push(&JobLog{JobID: `test`, Counter: int64(x)})
+	}
+	// Wait until the workers blocked on Send.
+	for {
+		cl.mtx.Lock()
+		x = cl.running
+		cl.mtx.Unlock()
+		if x == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(cl.block)
+
+	for pending.Load() != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	pool.Close()
+
+	test.Assert(t, `max running`, 2, cl.maxRun)
+	test.Assert(t, `number of sent`, 5, len(cl.sent))
+}
+
+func TestNotifPool_push(t *testing.T) {
+	var (
+		cl      = &fakeNotifClient{}
+		pending atomic.Int64
+		pool    = newNotifPool(`test`, cl, 1, &pending)
+		jlog    = &JobLog{JobID: `test`, Counter: 1}
+
+		x int
+	)
+
+	// Without workers, the queue is never consumed.
+	for x = 0; x < defNotifPoolQueueSize; x++ {
+		test.Assert(t, `push`, true, pool.push(jlog))
+	}
+	test.Assert(t, `push on full queue`, false, pool.push(jlog))
+	test.Assert(t, `pending`, int64(defNotifPoolQueueSize), pending.Load())
+}
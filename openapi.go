@@ -0,0 +1,1018 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"slices"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// apiOpenAPI the HTTP API that return the OpenAPI document of all HTTP
+// APIs.
+const apiOpenAPI = `/karajo/api/openapi.json`
+
+// openAPIVersion the version of OpenAPI specification that we generate.
+const openAPIVersion = `3.0.3`
+
+// List of security scheme names in the OpenAPI document.
+const (
+	openAPISecuritySession   = `session`
+	openAPISecuritySignature = `signature`
+)
+
+// List of response content type that is not JSON.
+const (
+	contentTypeEventStream = `text/event-stream`
+	contentTypeGzip        = `application/gzip`
+	contentTypePNG         = `image/png`
+)
+
+// apiEndpoint contains the HTTP API registered by Karajo, used to
+// generate the OpenAPI document.
+type apiEndpoint struct {
+	method       string
+	path         string
+	requestType  libhttp.RequestType
+	responseType libhttp.ResponseType
+	isSSE        bool
+}
+
+// apiParam describe the parameter of HTTP API.
+type apiParam struct {
+	name     string
+	desc     string
+	required bool
+
+	// inPath set to true if the parameter is the key in the path,
+	// for example ":name" in "/karajo/api/ns/:name/job_exec".
+	inPath bool
+}
+
+// apiDoc describe the HTTP API, in addition to its registration.
+type apiDoc struct {
+	// data the zero value of response data, nil if the response does
+	// not contains data.
+	data any
+
+	summary string
+	tag     string
+
+	// security the name of security scheme required by the API, empty
+	// if the API does not require authorization.
+	security string
+
+	// contents list of response content types for non-JSON response.
+	contents []string
+
+	params []apiParam
+}
+
+// List of parameters that used by more than one API.
+var (
+	apiParamCounter = apiParam{
+		name:     paramNameCounter,
+		desc:     `The log counter.`,
+		required: true,
+	}
+	apiParamEpoch = apiParam{
+		name:     paramNameKarajoEpoch,
+		desc:     `The current Unix time in seconds, part of signed payload.`,
+		required: true,
+	}
+	apiParamFrom = apiParam{
+		name: paramNameFrom,
+		desc: `The start of time range in RFC3339.`,
+	}
+	apiParamID = apiParam{
+		name:     paramNameID,
+		desc:     `The job ID.`,
+		required: true,
+	}
+	apiParamOffset = apiParam{
+		name: paramNameOffset,
+		desc: `The offset of log content to start streaming.`,
+	}
+	apiParamPauseDuration = apiParam{
+		name: paramNameDuration,
+		desc: `Resume the job automatically after the duration, for example "1h30m".`,
+	}
+	apiParamPauseUntil = apiParam{
+		name: paramNameUntil,
+		desc: `Resume the job automatically at the time in RFC3339.`,
+	}
+	apiParamTo = apiParam{
+		name: paramNameTo,
+		desc: `The end of time range in RFC3339.`,
+	}
+)
+
+// apiDocs contains the description of each HTTP API, indexed by its path.
+var apiDocs = map[string]apiDoc{
+	apiAgentDone: {
+		summary:  `Report the agent task as finished`,
+		tag:      `agent`,
+		security: openAPISecuritySignature,
+		params: []apiParam{
+			apiParamEpoch,
+			{name: paramNameID, desc: `The task ID.`, required: true},
+			{name: paramNameError, desc: `The error message if the task fail.`},
+		},
+	},
+	apiAgentLog: {
+		summary:  `Append the output of agent task`,
+		tag:      `agent`,
+		security: openAPISecuritySignature,
+		params: []apiParam{
+			apiParamEpoch,
+			{name: paramNameID, desc: `The task ID.`, required: true},
+			{name: paramNameOutput, desc: `The output of task.`, required: true},
+		},
+	},
+	apiAgentPoll: {
+		summary:  `Poll the task for agent`,
+		tag:      `agent`,
+		security: openAPISecuritySignature,
+		data:     AgentTask{},
+		params: []apiParam{
+			apiParamEpoch,
+			{name: paramNameName, desc: `The agent name.`, required: true},
+		},
+	},
+	apiAuthLogin: {
+		summary: `Login using user name and password`,
+		tag:     `auth`,
+		params: []apiParam{
+			{name: paramNameName, desc: `The user name.`, required: true},
+			{name: paramNamePassword, desc: `The user password.`, required: true},
+			{name: paramNameTOTP, desc: `The TOTP code, if enabled for the user.`},
+		},
+	},
+	apiAuthLogout: {
+		summary:  `Logout the current session`,
+		tag:      `auth`,
+		security: openAPISecuritySession,
+	},
+	apiAuthTOTPQR: {
+		summary:  `Get the QR code of TOTP provisioning URI`,
+		tag:      `auth`,
+		security: openAPISecuritySession,
+		contents: []string{contentTypePNG},
+	},
+	apiAuthOIDCCallback: {
+		summary: `Handle the redirect from OpenID Connect provider`,
+		tag:     `auth`,
+		params: []apiParam{
+			{name: paramNameCode, desc: `The authorization code.`},
+			{name: paramNameState, desc: `The state of login request.`, required: true},
+			{name: paramNameError, desc: `The error from provider.`},
+		},
+	},
+	apiAuthOIDCLogin: {
+		summary: `Redirect to the OpenID Connect provider`,
+		tag:     `auth`,
+	},
+	apiBackup: {
+		summary:  `Download the backup of karajo state`,
+		tag:      `environment`,
+		security: openAPISecuritySignature,
+		contents: []string{contentTypeGzip},
+		params: []apiParam{
+			apiParamEpoch,
+			{name: paramNameLogMaxAge, desc: `The maximum age of job logs to be included, for example "168h".`},
+		},
+	},
+	apiChanges: {
+		summary: `Get the changes of jobs state`,
+		tag:     `environment`,
+		data:    EnvChanges{},
+		params: []apiParam{
+			{name: paramNameSince, desc: `The revision of the last changes.`},
+		},
+	},
+	apiEnv: {
+		summary: `Get the environment`,
+		tag:     `environment`,
+		data:    Env{},
+	},
+	apiEnvExport: {
+		summary:  `Export the effective configuration`,
+		tag:      `environment`,
+		contents: []string{libhttp.ContentTypePlain, libhttp.ContentTypeJSON},
+		params: []apiParam{
+			{name: paramNameFormat, desc: `The format of configuration, "ini" or "json".`},
+		},
+	},
+	apiEnvReadOnly: {
+		summary:  `Enable or disable the read-only mode`,
+		tag:      `environment`,
+		security: openAPISecuritySignature,
+		data:     readOnlyMode{},
+		params: []apiParam{
+			apiParamEpoch,
+			{name: paramNameReadOnly, desc: `Set to true to enable the read-only mode, or false to disable it.`, required: true},
+		},
+	},
+	apiNamespaceJobExec: {
+		summary: `List the JobExec in namespace`,
+		tag:     `namespace`,
+		data:    map[string]*JobExec{},
+		params: []apiParam{
+			{name: paramNameName, desc: `The namespace name.`, required: true, inPath: true},
+		},
+	},
+	apiFederation: {
+		summary: `Get the jobs status from peers`,
+		tag:     `environment`,
+		data:    []PeerStatus{},
+	},
+	apiJobExecApprove: {
+		summary:  `Approve the run that wait for approval`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobExecArtifact: {
+		summary: `List the artifacts of job log`,
+		tag:     `job_exec`,
+		data:    []JobArtifact{},
+		params:  []apiParam{apiParamID, apiParamCounter},
+	},
+	apiJobExecArtifactDownload: {
+		summary: `Download the artifact of job log`,
+		tag:     `job_exec`,
+		params: []apiParam{
+			apiParamID,
+			apiParamCounter,
+			{name: paramNameName, desc: `The artifact name.`, required: true},
+		},
+	},
+	apiJobExecCancel: {
+		summary:  `Cancel the running job`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobExecDisable: {
+		summary:  `Disable the job, persisted after restart`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobExecDryRun: {
+		summary:  `Dry-run the job`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExecDryRun{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobExecEnable: {
+		summary:  `Enable the disabled job`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobExecLog: {
+		summary: `Get the job log`,
+		tag:     `job_exec`,
+		data:    JobLog{},
+		params:  []apiParam{apiParamID, apiParamCounter},
+	},
+	apiJobExecLogDiff: {
+		summary: `Compare two job logs as unified diff`,
+		tag:     `job_exec`,
+		params: []apiParam{
+			apiParamID,
+			{name: paramNameA, desc: `The log counter to compare from.`, required: true},
+			{name: paramNameB, desc: `The log counter to compare to.`, required: true},
+		},
+	},
+	apiJobExecLogRaw: {
+		summary: `Download the job log as plain text`,
+		tag:     `job_exec`,
+		params:  []apiParam{apiParamID, apiParamCounter},
+	},
+	apiJobExecLogSearch: {
+		summary: `Search the job logs`,
+		tag:     `job_exec`,
+		data:    []LogSearchResult{},
+		params: []apiParam{
+			{name: paramNameQuery, desc: `The text to be searched.`, required: true},
+			{name: paramNameID, desc: `Limit the search to the job ID.`},
+			{name: paramNameStatus, desc: `Limit the search to the job status.`},
+		},
+	},
+	apiJobExecLogSSE: {
+		summary: `Stream the job log`,
+		tag:     `job_exec`,
+		params:  []apiParam{apiParamID, apiParamCounter, apiParamOffset},
+	},
+	apiJobExecNext: {
+		summary: `List the next runs of job`,
+		tag:     `job_exec`,
+		data:    JobNextRuns{},
+		params: []apiParam{
+			apiParamID,
+			{name: paramNameN, desc: `The number of next runs, default to 10, maximum 1000.`},
+		},
+	},
+	apiJobExecPause: {
+		summary:  `Pause the job`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+		params:   []apiParam{apiParamEpoch, apiParamID, apiParamPauseUntil, apiParamPauseDuration},
+	},
+	apiJobExecResume: {
+		summary:  `Resume the job`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobExecSecret: {
+		summary:  `List the secret status of all jobs`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     []JobExecSecret{},
+		params:   []apiParam{apiParamEpoch},
+	},
+	apiJobExecSecretRotate: {
+		summary:  `Rotate the job secret`,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExecSecretRotate{},
+		params: []apiParam{
+			apiParamEpoch,
+			apiParamID,
+			{name: paramNameGracePeriod, desc: `The duration where the previous secret still valid, default to 24h.`},
+		},
+	},
+	apiJobHTTP: {
+		summary: `Get the JobHttp`,
+		tag:     `job_http`,
+		data:    JobHTTP{},
+		params:  []apiParam{apiParamID},
+	},
+	apiJobHTTPDisable: {
+		summary:  `Disable the JobHttp, persisted after restart`,
+		tag:      `job_http`,
+		security: openAPISecuritySignature,
+		data:     JobHTTP{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobHTTPEnable: {
+		summary:  `Enable the disabled JobHttp`,
+		tag:      `job_http`,
+		security: openAPISecuritySignature,
+		data:     JobHTTP{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobHTTPLog: {
+		summary: `Get the JobHttp log`,
+		tag:     `job_http`,
+		data:    JobLog{},
+		params:  []apiParam{apiParamID, apiParamCounter},
+	},
+	apiJobHTTPLogSSE: {
+		summary: `Stream the JobHttp log`,
+		tag:     `job_http`,
+		params:  []apiParam{apiParamID, apiParamCounter, apiParamOffset},
+	},
+	apiJobHTTPPause: {
+		summary:  `Pause the JobHttp`,
+		tag:      `job_http`,
+		security: openAPISecuritySignature,
+		data:     JobHTTP{},
+		params:   []apiParam{apiParamEpoch, apiParamID, apiParamPauseUntil, apiParamPauseDuration},
+	},
+	apiJobHTTPResume: {
+		summary:  `Resume the JobHttp`,
+		tag:      `job_http`,
+		security: openAPISecuritySignature,
+		data:     JobHTTP{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobSQL: {
+		summary: `Get the JobSql`,
+		tag:     `job_sql`,
+		data:    JobSQL{},
+		params:  []apiParam{apiParamID},
+	},
+	apiJobSQLDisable: {
+		summary:  `Disable the JobSql, persisted after restart`,
+		tag:      `job_sql`,
+		security: openAPISecuritySignature,
+		data:     JobSQL{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobSQLEnable: {
+		summary:  `Enable the disabled JobSql`,
+		tag:      `job_sql`,
+		security: openAPISecuritySignature,
+		data:     JobSQL{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiJobSQLLog: {
+		summary: `Get the JobSql log`,
+		tag:     `job_sql`,
+		data:    JobLog{},
+		params:  []apiParam{apiParamID, apiParamCounter},
+	},
+	apiJobSQLLogSSE: {
+		summary: `Stream the JobSql log`,
+		tag:     `job_sql`,
+		params:  []apiParam{apiParamID, apiParamCounter, apiParamOffset},
+	},
+	apiJobSQLPause: {
+		summary:  `Pause the JobSql`,
+		tag:      `job_sql`,
+		security: openAPISecuritySignature,
+		data:     JobSQL{},
+		params:   []apiParam{apiParamEpoch, apiParamID, apiParamPauseUntil, apiParamPauseDuration},
+	},
+	apiJobSQLResume: {
+		summary:  `Resume the JobSql`,
+		tag:      `job_sql`,
+		security: openAPISecuritySignature,
+		data:     JobSQL{},
+		params:   []apiParam{apiParamEpoch, apiParamID},
+	},
+	apiOpenAPI: {
+		summary: `Get the OpenAPI document of HTTP APIs`,
+		tag:     `environment`,
+	},
+	apiSchedule: {
+		summary: `Get the schedule of jobs`,
+		tag:     `environment`,
+		data:    Schedule{},
+		params:  []apiParam{apiParamFrom, apiParamTo},
+	},
+	apiSchedulePreview: {
+		summary: `Preview the schedule of jobs`,
+		tag:     `environment`,
+		data:    Schedule{},
+		params:  []apiParam{apiParamFrom, apiParamTo},
+	},
+}
+
+// openAPIDoc the root of OpenAPI document.
+type openAPIDoc struct {
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Info       openAPIInfo                             `json:"info"`
+	OpenAPI    string                                  `json:"openapi"`
+	Servers    []openAPIServer                         `json:"servers"`
+}
+
+type openAPIInfo struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]*openAPISchema         `json:"schemas"`
+	SecuritySchemes map[string]*openAPISecurityScheme `json:"securitySchemes"`
+}
+
+type openAPISecurityScheme struct {
+	Type        string `json:"type"`
+	Name        string `json:"name"`
+	In          string `json:"in"`
+	Description string `json:"description,omitempty"`
+}
+
+type openAPIOperation struct {
+	RequestBody *openAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*openAPIResponse `json:"responses"`
+	OperationID string                      `json:"operationId"`
+	Summary     string                      `json:"summary,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Parameters  []*openAPIParameter         `json:"parameters,omitempty"`
+	Security    []map[string][]string       `json:"security,omitempty"`
+}
+
+type openAPIParameter struct {
+	Schema      *openAPISchema `json:"schema"`
+	Name        string         `json:"name"`
+	In          string         `json:"in"`
+	Description string         `json:"description,omitempty"`
+	Required    bool           `json:"required,omitempty"`
+}
+
+type openAPIRequestBody struct {
+	Content  map[string]*openAPIMediaType `json:"content"`
+	Required bool                         `json:"required,omitempty"`
+}
+
+type openAPIResponse struct {
+	Content     map[string]*openAPIMediaType `json:"content,omitempty"`
+	Description string                       `json:"description"`
+}
+
+type openAPIMediaType struct {
+	Schema *openAPISchema `json:"schema"`
+}
+
+type openAPISchema struct {
+	Properties           map[string]*openAPISchema `json:"properties,omitempty"`
+	Items                *openAPISchema            `json:"items,omitempty"`
+	AdditionalProperties *openAPISchema            `json:"additionalProperties,omitempty"`
+	Ref                  string                    `json:"$ref,omitempty"`
+	Type                 string                    `json:"type,omitempty"`
+	Format               string                    `json:"format,omitempty"`
+	Description          string                    `json:"description,omitempty"`
+	Required             []string                  `json:"required,omitempty"`
+}
+
+// openAPISchemaOverrides contains the schema of types that encoded
+// differently by their MarshalJSON.
+var openAPISchemaOverrides = map[reflect.Type]*openAPISchema{
+	reflect.TypeOf(leaderElection{}): {
+		Type:        `string`,
+		Description: `The role of instance in leader election.`,
+	},
+	reflect.TypeOf(readOnlyMode{}): {
+		Type:        `boolean`,
+		Description: `The read-only mode of instance.`,
+	},
+	reflect.TypeOf(time.Time{}): {
+		Type:   `string`,
+		Format: `date-time`,
+	},
+}
+
+// registerEndpoint register the HTTP API and record it for the OpenAPI
+// document.
+func (k *Karajo) registerEndpoint(ep libhttp.Endpoint) (err error) {
+	err = k.HTTPd.RegisterEndpoint(ep)
+	if err != nil {
+		return err
+	}
+	var method = string(ep.Method)
+	if len(method) == 0 {
+		method = http.MethodGet
+	}
+	k.apis = append(k.apis, apiEndpoint{
+		method:       method,
+		path:         ep.Path,
+		requestType:  ep.RequestType,
+		responseType: ep.ResponseType,
+	})
+	return nil
+}
+
+// registerSSE register the Server-Sent Events API and record it for the
+// OpenAPI document.
+func (k *Karajo) registerSSE(ep libhttp.SSEEndpoint) (err error) {
+	err = k.HTTPd.RegisterSSE(ep)
+	if err != nil {
+		return err
+	}
+	k.apis = append(k.apis, apiEndpoint{
+		method:      http.MethodGet,
+		path:        ep.Path,
+		requestType: libhttp.RequestTypeQuery,
+		isSSE:       true,
+	})
+	return nil
+}
+
+// apiOpenAPI return the OpenAPI document of all registered HTTP APIs.
+//
+// Request format,
+//
+//	GET /karajo/api/openapi.json
+//
+// Response format,
+//
+//	Content-Type: application/json
+//
+//	<OpenAPI document>
+func (k *Karajo) apiOpenAPI(_ *libhttp.EndpointRequest) (resbody []byte, err error) {
+	return json.Marshal(k.openAPI())
+}
+
+// openAPI generate the OpenAPI document from the registered HTTP APIs.
+func (k *Karajo) openAPI() (doc *openAPIDoc) {
+	var serverURL = k.env.BasePath
+	if len(serverURL) == 0 {
+		serverURL = `/`
+	}
+
+	doc = &openAPIDoc{
+		OpenAPI: openAPIVersion,
+		Info: openAPIInfo{
+			Title:   `karajo`,
+			Version: Version,
+		},
+		Servers: []openAPIServer{{URL: serverURL}},
+		Paths:   make(map[string]map[string]*openAPIOperation),
+		Components: openAPIComponents{
+			Schemas: map[string]*openAPISchema{},
+			SecuritySchemes: map[string]*openAPISecurityScheme{
+				openAPISecuritySession: {
+					Type:        `apiKey`,
+					In:          `cookie`,
+					Name:        cookieName,
+					Description: `The session cookie from login.`,
+				},
+				openAPISecuritySignature: {
+					Type: `apiKey`,
+					In:   `header`,
+					Name: HeaderNameXKarajoSign,
+					Description: `The hex string of HMAC-SHA256 of request` +
+						` query or body using the job or agent secret.`,
+				},
+			},
+		},
+	}
+
+	var (
+		apis = slices.Clone(k.apis)
+
+		ep      apiEndpoint
+		op      *openAPIOperation
+		byPath  map[string]*openAPIOperation
+		apiPath string
+	)
+
+	// The JobExec hooks may changes after the HTTP server started.
+	k.hookMtx.RLock()
+	for apiPath = range k.hookJobs {
+		apis = append(apis, apiEndpoint{
+			method:       http.MethodPost,
+			path:         apiPath,
+			requestType:  libhttp.RequestTypeJSON,
+			responseType: libhttp.ResponseTypeJSON,
+		})
+	}
+	k.hookMtx.RUnlock()
+
+	for _, ep = range apis {
+		op = doc.newOperation(ep, k.apiDocOf(ep.path))
+
+		apiPath = openAPIPath(ep.path)
+		byPath = doc.Paths[apiPath]
+		if byPath == nil {
+			byPath = make(map[string]*openAPIOperation)
+			doc.Paths[apiPath] = byPath
+		}
+		byPath[strings.ToLower(ep.method)] = op
+	}
+	return doc
+}
+
+// apiDocOf return the description of HTTP API by its path.
+func (k *Karajo) apiDocOf(apiPath string) (doc apiDoc) {
+	var ok bool
+
+	doc, ok = apiDocs[apiPath]
+	if ok {
+		return doc
+	}
+
+	var job = k.hookJob(apiPath)
+	if job == nil {
+		return doc
+	}
+	doc = apiDoc{
+		summary:  `Run the job ` + job.Name,
+		tag:      `job_exec`,
+		security: openAPISecuritySignature,
+		data:     JobExec{},
+	}
+	if job.AuthKind != JobAuthKindHmacSha256 && len(job.AuthKind) != 0 {
+		// Hook that authorized by Github or Sourcehut use their
+		// own signature header.
+		doc.security = ``
+	}
+	return doc
+}
+
+// openAPIPath convert the key in the path, ":name", into the OpenAPI
+// path template, "{name}".
+func openAPIPath(apiPath string) string {
+	if !strings.Contains(apiPath, `/:`) {
+		return apiPath
+	}
+	var (
+		names = strings.Split(apiPath, `/`)
+		x     int
+	)
+	for x = range names {
+		if strings.HasPrefix(names[x], `:`) {
+			names[x] = `{` + names[x][1:] + `}`
+		}
+	}
+	return strings.Join(names, `/`)
+}
+
+// newOperation create the OpenAPI operation for the HTTP API.
+func (doc *openAPIDoc) newOperation(ep apiEndpoint, adoc apiDoc) (op *openAPIOperation) {
+	op = &openAPIOperation{
+		OperationID: operationID(ep.method, ep.path),
+		Summary:     adoc.summary,
+		Responses:   make(map[string]*openAPIResponse),
+	}
+	if len(adoc.tag) != 0 {
+		op.Tags = []string{adoc.tag}
+	}
+	if len(adoc.security) != 0 {
+		op.Security = []map[string][]string{{adoc.security: {}}}
+	}
+
+	var param apiParam
+	switch ep.requestType {
+	case libhttp.RequestTypeForm:
+		var body = &openAPISchema{
+			Type:       `object`,
+			Properties: make(map[string]*openAPISchema),
+		}
+		for _, param = range adoc.params {
+			body.Properties[param.name] = &openAPISchema{
+				Type:        `string`,
+				Description: param.desc,
+			}
+			if param.required {
+				body.Required = append(body.Required, param.name)
+			}
+		}
+		op.RequestBody = &openAPIRequestBody{
+			Required: true,
+			Content: map[string]*openAPIMediaType{
+				libhttp.ContentTypeForm: {Schema: body},
+			},
+		}
+
+	case libhttp.RequestTypeJSON:
+		op.RequestBody = &openAPIRequestBody{
+			Content: map[string]*openAPIMediaType{
+				libhttp.ContentTypeJSON: {Schema: &openAPISchema{}},
+			},
+		}
+
+	default:
+		var in string
+		for _, param = range adoc.params {
+			in = `query`
+			if param.inPath {
+				in = `path`
+			}
+			op.Parameters = append(op.Parameters, &openAPIParameter{
+				Name:        param.name,
+				In:          in,
+				Description: param.desc,
+				Required:    param.required,
+				Schema:      &openAPISchema{Type: `string`},
+			})
+		}
+	}
+
+	var (
+		res = &openAPIResponse{
+			Description: `OK`,
+			Content:     make(map[string]*openAPIMediaType),
+		}
+		ctype string
+	)
+	switch {
+	case ep.isSSE:
+		res.Content[contentTypeEventStream] = &openAPIMediaType{
+			Schema: &openAPISchema{Type: `string`},
+		}
+	case len(adoc.contents) != 0:
+		for _, ctype = range adoc.contents {
+			res.Content[ctype] = &openAPIMediaType{
+				Schema: doc.contentSchema(ctype),
+			}
+		}
+	case ep.responseType == libhttp.ResponseTypeBinary:
+		res.Content[libhttp.ContentTypeBinary] = &openAPIMediaType{
+			Schema: &openAPISchema{Type: `string`, Format: `binary`},
+		}
+	case ep.responseType == libhttp.ResponseTypePlain:
+		res.Content[libhttp.ContentTypePlain] = &openAPIMediaType{
+			Schema: &openAPISchema{Type: `string`},
+		}
+	case ep.responseType == libhttp.ResponseTypeJSON && ep.path == apiOpenAPI:
+		res.Content[libhttp.ContentTypeJSON] = &openAPIMediaType{
+			Schema: &openAPISchema{Type: `object`},
+		}
+	case ep.responseType == libhttp.ResponseTypeJSON:
+		res.Content[libhttp.ContentTypeJSON] = &openAPIMediaType{
+			Schema: doc.responseSchema(adoc.data),
+		}
+	default:
+		// The API redirect the request.
+		res.Description = `Found`
+		res.Content = nil
+		op.Responses[`302`] = res
+		res = nil
+	}
+	if res != nil {
+		op.Responses[`200`] = res
+	}
+
+	if ep.responseType == libhttp.ResponseTypeJSON || len(adoc.contents) != 0 {
+		op.Responses[`default`] = &openAPIResponse{
+			Description: `Error`,
+			Content: map[string]*openAPIMediaType{
+				libhttp.ContentTypeJSON: {Schema: doc.responseSchema(nil)},
+			},
+		}
+	}
+	return op
+}
+
+// contentSchema return the schema of non-envelope response by its content
+// type.
+func (doc *openAPIDoc) contentSchema(ctype string) *openAPISchema {
+	switch ctype {
+	case libhttp.ContentTypeJSON:
+		return &openAPISchema{Type: `object`}
+	case libhttp.ContentTypePlain:
+		return &openAPISchema{Type: `string`}
+	}
+	return &openAPISchema{Type: `string`, Format: `binary`}
+}
+
+// responseSchema return the schema of JSON response that wrap the data.
+func (doc *openAPIDoc) responseSchema(data any) (schema *openAPISchema) {
+	schema = &openAPISchema{
+		Type: `object`,
+		Properties: map[string]*openAPISchema{
+			`code`: {
+				Type:        `integer`,
+				Description: `The response code, equal to HTTP status code.`,
+			},
+			`message`: {
+				Type:        `string`,
+				Description: `The message that describe the response.`,
+			},
+		},
+		Required: []string{`code`},
+	}
+	if data != nil {
+		schema.Properties[`data`] = doc.schemaOf(reflect.TypeOf(data))
+	}
+	return schema
+}
+
+// schemaOf return the schema of Go type based on how it encoded by
+// encoding/json.
+// The exported struct is stored in the components and referenced by its
+// name.
+func (doc *openAPIDoc) schemaOf(rtype reflect.Type) (schema *openAPISchema) {
+	for rtype.Kind() == reflect.Pointer {
+		rtype = rtype.Elem()
+	}
+
+	schema = openAPISchemaOverrides[rtype]
+	if schema != nil {
+		return schema
+	}
+	if rtype == reflect.TypeOf(time.Duration(0)) {
+		return &openAPISchema{
+			Type:        `integer`,
+			Description: `The duration in nanoseconds.`,
+		}
+	}
+
+	switch rtype.Kind() {
+	case reflect.Bool:
+		return &openAPISchema{Type: `boolean`}
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32,
+		reflect.Int64, reflect.Uint, reflect.Uint8, reflect.Uint16,
+		reflect.Uint32, reflect.Uint64:
+		return &openAPISchema{Type: `integer`}
+
+	case reflect.Float32, reflect.Float64:
+		return &openAPISchema{Type: `number`}
+
+	case reflect.String:
+		return &openAPISchema{Type: `string`}
+
+	case reflect.Slice, reflect.Array:
+		if rtype.Elem().Kind() == reflect.Uint8 {
+			return &openAPISchema{Type: `string`, Format: `byte`}
+		}
+		return &openAPISchema{
+			Type:  `array`,
+			Items: doc.schemaOf(rtype.Elem()),
+		}
+
+	case reflect.Map:
+		return &openAPISchema{
+			Type:                 `object`,
+			AdditionalProperties: doc.schemaOf(rtype.Elem()),
+		}
+
+	case reflect.Struct:
+		var name = rtype.Name()
+		if !isExportedName(name) {
+			return doc.structSchema(rtype)
+		}
+		var ref = &openAPISchema{
+			Ref: `#/components/schemas/` + name,
+		}
+		if _, ok := doc.Components.Schemas[name]; !ok {
+			// Reserve the name first to handle the recursive
+			// type.
+			doc.Components.Schemas[name] = &openAPISchema{}
+			*doc.Components.Schemas[name] = *doc.structSchema(rtype)
+		}
+		return ref
+	}
+
+	// Interface or any other types.
+	return &openAPISchema{}
+}
+
+// structSchema return the schema of struct, including the fields from its
+// embedded structs.
+func (doc *openAPIDoc) structSchema(rtype reflect.Type) (schema *openAPISchema) {
+	schema = &openAPISchema{
+		Type:       `object`,
+		Properties: make(map[string]*openAPISchema),
+	}
+
+	var (
+		field reflect.StructField
+		x     int
+	)
+	for x = 0; x < rtype.NumField(); x++ {
+		field = rtype.Field(x)
+
+		var (
+			tag  = field.Tag.Get(`json`)
+			name string
+		)
+		if tag == `-` {
+			continue
+		}
+		name, _, _ = strings.Cut(tag, `,`)
+
+		if field.Anonymous && len(name) == 0 {
+			var ftype = field.Type
+			for ftype.Kind() == reflect.Pointer {
+				ftype = ftype.Elem()
+			}
+			if ftype.Kind() == reflect.Struct {
+				var (
+					embedded = doc.structSchema(ftype)
+					key      string
+					prop     *openAPISchema
+				)
+				for key, prop = range embedded.Properties {
+					if _, ok := schema.Properties[key]; !ok {
+						schema.Properties[key] = prop
+					}
+				}
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = field.Name
+		}
+		schema.Properties[name] = doc.schemaOf(field.Type)
+	}
+	return schema
+}
+
+// operationID generate the unique operation ID from the HTTP method and
+// path, for example "GET /karajo/api/job_exec/log" become
+// "get_job_exec_log".
+func operationID(method, apiPath string) string {
+	var id = strings.TrimPrefix(apiPath, pathKarajoAPI)
+	id = strings.Map(func(r rune) rune {
+		if r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' {
+			return r
+		}
+		return '_'
+	}, id)
+	return strings.ToLower(method) + `_` + id
+}
+
+// isExportedName return true if the type name is exported.
+func isExportedName(name string) bool {
+	return len(name) != 0 && name[0] >= 'A' && name[0] <= 'Z'
+}
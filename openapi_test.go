@@ -0,0 +1,105 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_apiOpenAPI(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddressAdmin: `127.0.0.1:0`,
+			DirBase:            t.TempDir(),
+			Secret:             `s3cret`,
+			ExecJobs: map[string]*JobExec{
+				`Deploy`: &JobExec{
+					Path:     `/deploy`,
+					Commands: []string{`echo deploy`},
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	// Each registered API must be documented.
+	var ep apiEndpoint
+	for _, ep = range k.apis {
+		test.Assert(t, ep.path+`: has summary`, true,
+			len(k.apiDocOf(ep.path).summary) != 0)
+	}
+
+	var srv = httptest.NewServer(k.HTTPd.Server.Handler)
+	t.Cleanup(srv.Close)
+
+	var res *http.Response
+
+	res, err = http.Get(srv.URL + apiOpenAPI)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body []byte
+
+	body, err = io.ReadAll(res.Body)
+	_ = res.Body.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `status code`, http.StatusOK, res.StatusCode)
+
+	var doc openAPIDoc
+
+	err = json.Unmarshal(body, &doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, `openapi`, openAPIVersion, doc.OpenAPI)
+	test.Assert(t, `info.version`, Version, doc.Info.Version)
+	test.Assert(t, `number of paths`, len(k.apis)+len(k.hookJobs), len(doc.Paths))
+
+	var op = doc.Paths[apiJobExecPause][`post`]
+	test.Assert(t, `pause: operationId`, `post_job_exec_pause`, op.OperationID)
+	test.Assert(t, `pause: security`,
+		[]map[string][]string{{openAPISecuritySignature: {}}}, op.Security)
+	test.Assert(t, `pause: form required`,
+		[]string{paramNameKarajoEpoch, paramNameID},
+		op.RequestBody.Content[`application/x-www-form-urlencoded`].Schema.Required)
+	test.Assert(t, `pause: data`, `#/components/schemas/JobExec`,
+		op.Responses[`200`].Content[`application/json`].Schema.Properties[`data`].Ref)
+
+	op = doc.Paths[apiJobExecRun+`/deploy`][`post`]
+	test.Assert(t, `hook: summary`, `Run the job Deploy`, op.Summary)
+
+	op = doc.Paths[apiJobExecLogSSE][`get`]
+	test.Assert(t, `SSE: parameters`, 3, len(op.Parameters))
+	test.Assert(t, `SSE: content`, true,
+		op.Responses[`200`].Content[`text/event-stream`] != nil)
+
+	var schemaEnv = doc.Components.Schemas[`Env`]
+	test.Assert(t, `Env.ha_role`, `string`, schemaEnv.Properties[`ha_role`].Type)
+	test.Assert(t, `Env.jobs`, `#/components/schemas/JobExec`,
+		schemaEnv.Properties[`jobs`].AdditionalProperties.Ref)
+
+	var schemaJob = doc.Components.Schemas[`JobExec`]
+	test.Assert(t, `JobExec.last_run`, `date-time`,
+		schemaJob.Properties[`last_run`].Format)
+}
@@ -0,0 +1,200 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// defPeerTimeout the timeout for fetching the environment from peer.
+const defPeerTimeout = 5 * time.Second
+
+// defPeerCacheTTL define how long the peers status is cached before its
+// fetched again.
+const defPeerCacheTTL = 10 * time.Second
+
+// Peer define other karajo instance whose jobs are displayed, read-only, in
+// this instance.
+type Peer struct {
+	client *Client
+
+	// Name of the peer, set from the sub-section name.
+	Name string `json:"name"`
+
+	// URL of the peer karajo server, for example
+	// "http://10.0.0.2:31937".
+	// The URL may contains credentials, so its not exported to JSON.
+	URL string `ini:"::url" json:"-"`
+
+	// publicURL the URL without user info, query, and fragment, that
+	// can be displayed in the WUI.
+	publicURL string
+
+	// Token define the bearer token that is sent on header
+	// "Authorization" when fetching the peer environment.
+	// This is useful when the peer is behind a proxy that require
+	// authorization.
+	// This field is optional.
+	Token string `ini:"::token" json:"-"`
+}
+
+// PeerStatus contains the environment of peer, including its jobs, at
+// specific time.
+type PeerStatus struct {
+	Env *Env `json:"env,omitempty"`
+
+	Name string `json:"name"`
+	URL  string `json:"url"`
+
+	// Error contains the error message if fetching the peer
+	// environment failed.
+	Error string `json:"error,omitempty"`
+
+	LastFetch time.Time `json:"last_fetch"`
+}
+
+// federation fetch and cache the status of all peers.
+type federation struct {
+	peers map[string]*Peer
+
+	lastFetch time.Time
+	status    []*PeerStatus
+
+	sync.Mutex
+
+	// isFetching true if the status is being fetched from peers.
+	isFetching bool
+}
+
+// init initialize the peer client.
+func (peer *Peer) init(name string) (err error) {
+	peer.Name = name
+	peer.URL = strings.TrimRight(strings.TrimSpace(expandEnv(peer.URL)), `/`)
+	if len(peer.URL) == 0 {
+		return fmt.Errorf(`peer %q: empty url`, name)
+	}
+
+	var publicURL *url.URL
+
+	publicURL, err = url.Parse(peer.URL)
+	if err != nil {
+		return fmt.Errorf(`peer %q: invalid url: %w`, name, err)
+	}
+	if publicURL.Scheme != `http` && publicURL.Scheme != `https` {
+		return fmt.Errorf(`peer %q: invalid url scheme %q`, name, publicURL.Scheme)
+	}
+	publicURL.User = nil
+	publicURL.RawQuery = ``
+	publicURL.Fragment = ``
+	peer.publicURL = publicURL.String()
+
+	var clientOpts = ClientOptions{
+		ClientOptions: libhttp.ClientOptions{
+			ServerURL: peer.URL,
+		},
+		RequestTimeout: defPeerTimeout,
+	}
+
+	var token = expandEnv(peer.Token)
+	if len(token) != 0 {
+		clientOpts.Headers = http.Header{
+			`Authorization`: []string{`Bearer ` + token},
+		}
+	}
+
+	peer.client, err = NewClient(clientOpts)
+	if err != nil {
+		return fmt.Errorf(`peer %q: %w`, name, err)
+	}
+	return nil
+}
+
+// fetch the status of peer.
+func (peer *Peer) fetch() (status *PeerStatus) {
+	status = &PeerStatus{
+		Name:      peer.Name,
+		URL:       peer.publicURL,
+		LastFetch: timeNow(),
+	}
+
+	var err error
+
+	status.Env, err = peer.client.Env()
+	if err != nil {
+		status.Error = err.Error()
+	}
+	return status
+}
+
+// newFederation create new federation for list of peers.
+func newFederation(peers map[string]*Peer) (fed *federation) {
+	fed = &federation{
+		peers: peers,
+	}
+	return fed
+}
+
+// get return the status of all peers sorted by name.
+// The status is fetched concurrently from all peers and cached for
+// defPeerCacheTTL.
+// While the status is being fetched, the previous status is returned.
+func (fed *federation) get() (listStatus []*PeerStatus) {
+	fed.Lock()
+	var now = timeNow()
+	if fed.status != nil {
+		if fed.isFetching || now.Sub(fed.lastFetch) < defPeerCacheTTL {
+			listStatus = fed.status
+			fed.Unlock()
+			return listStatus
+		}
+	}
+	fed.isFetching = true
+	fed.Unlock()
+
+	listStatus = fed.fetch()
+
+	fed.Lock()
+	fed.status = listStatus
+	fed.lastFetch = now
+	fed.isFetching = false
+	fed.Unlock()
+
+	return listStatus
+}
+
+// fetch the status of all peers concurrently.
+func (fed *federation) fetch() (listStatus []*PeerStatus) {
+	var (
+		names = make([]string, 0, len(fed.peers))
+		wg    sync.WaitGroup
+
+		name string
+	)
+	for name = range fed.peers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	listStatus = make([]*PeerStatus, len(names))
+
+	var x int
+	for x, name = range names {
+		wg.Add(1)
+		go func(x int, peer *Peer) {
+			listStatus[x] = peer.fetch()
+			wg.Done()
+		}(x, fed.peers[name])
+	}
+	wg.Wait()
+
+	return listStatus
+}
@@ -0,0 +1,125 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestFederation_get(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get(`Authorization`) != `Bearer t0ken` {
+			w.WriteHeader(http.StatusUnauthorized)
+			_, _ = w.Write([]byte(`{"code":401,"message":"unauthorized"}`))
+			return
+		}
+		if req.URL.Path != apiEnv {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write([]byte(`{"code":200,"data":{"name":"peer A","jobs":{"Test":{"id":"test","name":"Test","status":"success"}}}}`))
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv(`KARAJO_PEER_TOKEN`, `t0ken`)
+
+	var (
+		peers = map[string]*Peer{
+			`a`: {
+				URL:   srv.URL + `/`,
+				Token: `${KARAJO_PEER_TOKEN}`,
+			},
+			`b`: {
+				URL: strings.Replace(srv.URL, `://`, `://user:${KARAJO_PEER_TOKEN}@`, 1) + `/?token=${KARAJO_PEER_TOKEN}`,
+			},
+		}
+
+		name string
+		peer *Peer
+		err  error
+	)
+	for name, peer = range peers {
+		err = peer.init(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	var (
+		fed        = newFederation(peers)
+		listStatus = fed.get()
+	)
+
+	test.Assert(t, `len`, 2, len(listStatus))
+
+	var status = listStatus[0]
+	test.Assert(t, `a: name`, `a`, status.Name)
+	test.Assert(t, `a: url`, srv.URL, status.URL)
+	test.Assert(t, `a: error`, ``, status.Error)
+	test.Assert(t, `a: env.Name`, `peer A`, status.Env.Name)
+	test.Assert(t, `a: job status`, JobStatusSuccess,
+		status.Env.ExecJobs[`Test`].Status)
+
+	status = listStatus[1]
+	test.Assert(t, `b: name`, `b`, status.Name)
+	test.Assert(t, `b: url`, srv.URL+`/`, status.URL)
+	test.Assert(t, `b: error`, `unauthorized`, status.Error)
+
+	var got []byte
+
+	got, err = json.Marshal(peers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `peers JSON contains token`, false,
+		bytes.Contains(got, []byte(`t0ken`)))
+
+	got, err = json.Marshal(listStatus)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `status JSON contains token`, false,
+		bytes.Contains(got, []byte(`t0ken`)))
+
+	// The second call should return the cached status.
+	var cached = fed.get()
+	test.Assert(t, `cached`, true, &cached[0] == &listStatus[0])
+}
+
+func TestPeer_init(t *testing.T) {
+	type testCase struct {
+		url    string
+		expErr string
+	}
+
+	var cases = []testCase{{
+		expErr: `peer "test": empty url`,
+	}, {
+		url:    `javascript:alert(1)`,
+		expErr: `peer "test": invalid url scheme "javascript"`,
+	}, {
+		url:    `10.0.0.2:31937`,
+		expErr: `peer "test": invalid url: parse "10.0.0.2:31937": first path segment in URL cannot contain colon`,
+	}}
+
+	var (
+		c    testCase
+		peer *Peer
+		err  error
+	)
+	for _, c = range cases {
+		peer = &Peer{
+			URL: c.url,
+		}
+		err = peer.init(`test`)
+		test.Assert(t, c.url, c.expErr, err.Error())
+	}
+}
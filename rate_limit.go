@@ -0,0 +1,280 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// rateLimitPruneInterval define how often the idle buckets are removed.
+const rateLimitPruneInterval = time.Minute
+
+// ctxKeyResponseWriter the request context key for the
+// [http.ResponseWriter].
+// The evaluator does not receive the response writer, so evalRateLimit
+// get it from the request context to set the Retry-After header.
+type ctxKeyResponseWriter struct{}
+
+// RateLimit define the maximum number of HTTP requests per client IP
+// address, using token bucket algorithm.
+// The global rate limit is defined in the karajo section, while the
+// per path rate limit is defined in section "[rate_limit \"<path>\"]".
+type RateLimit struct {
+	// buckets contains the token bucket indexed by client IP address.
+	buckets map[string]*tokenBucket
+
+	lastPrune time.Time
+
+	// path of the request where the limit applied.
+	// If the path end with "/", the limit applied to all requests with
+	// the same path prefix.
+	path string
+
+	// Limit define the number of requests per unit of time, in the
+	// format "<number>/<unit>", where unit is "s" for second, "m" for
+	// minute, or "h" for hour.
+	// For example, "10/s" allow 10 requests per second.
+	Limit string `ini:"::limit" json:"limit"`
+
+	// rate the number of tokens added per second.
+	rate float64
+
+	// Burst define the maximum number of requests that can be made at
+	// once.
+	// This field is optional, default to the number in Limit.
+	Burst int `ini:"::burst" json:"burst,omitempty"`
+
+	mtx sync.Mutex
+}
+
+// tokenBucket contains the remaining tokens for single client.
+type tokenBucket struct {
+	last   time.Time
+	tokens float64
+}
+
+// init parse and validate the Limit.
+func (rl *RateLimit) init(path string) (err error) {
+	var (
+		logp = `rate_limit`
+
+		n    float64
+		unit time.Duration
+	)
+
+	rl.path = path
+
+	n, unit, err = parseRateLimit(rl.Limit)
+	if err != nil {
+		return fmt.Errorf(`%s %q: %w`, logp, path, err)
+	}
+	if rl.Burst <= 0 {
+		rl.Burst = int(n)
+		if rl.Burst <= 0 {
+			rl.Burst = 1
+		}
+	}
+
+	rl.rate = n / unit.Seconds()
+	rl.buckets = make(map[string]*tokenBucket)
+	return nil
+}
+
+// isMatch return true if the request path match with rate limit path.
+func (rl *RateLimit) isMatch(path string) bool {
+	if strings.HasSuffix(rl.path, `/`) {
+		return strings.HasPrefix(path, rl.path)
+	}
+	return path == rl.path
+}
+
+// allow return true if the client ip can make a request.
+// If its false, the wait contains the duration until the next request
+// is allowed.
+func (rl *RateLimit) allow(ip string, now time.Time) (ok bool, wait time.Duration) {
+	rl.mtx.Lock()
+	defer rl.mtx.Unlock()
+
+	if now.Sub(rl.lastPrune) >= rateLimitPruneInterval {
+		rl.prune(now)
+		rl.lastPrune = now
+	}
+
+	var bucket = rl.buckets[ip]
+	if bucket == nil {
+		bucket = &tokenBucket{
+			tokens: float64(rl.Burst),
+			last:   now,
+		}
+		rl.buckets[ip] = bucket
+	}
+
+	var elapsed = now.Sub(bucket.last).Seconds()
+	if elapsed > 0 {
+		bucket.tokens = min(float64(rl.Burst), bucket.tokens+elapsed*rl.rate)
+		bucket.last = now
+	}
+	if bucket.tokens < 1 {
+		wait = time.Duration((1 - bucket.tokens) / rl.rate * float64(time.Second))
+		return false, wait
+	}
+	bucket.tokens--
+	return true, 0
+}
+
+// prune remove the bucket that has been refilled fully, since its
+// equal to the new bucket.
+// The caller must hold the lock.
+func (rl *RateLimit) prune(now time.Time) {
+	var (
+		ip     string
+		bucket *tokenBucket
+	)
+	for ip, bucket = range rl.buckets {
+		if bucket.tokens+now.Sub(bucket.last).Seconds()*rl.rate >= float64(rl.Burst) {
+			delete(rl.buckets, ip)
+		}
+	}
+}
+
+// parseRateLimit parse the rate limit in the format "<number>/<unit>".
+func parseRateLimit(limit string) (n float64, unit time.Duration, err error) {
+	var (
+		num, unitStr string
+		ok           bool
+	)
+
+	num, unitStr, ok = strings.Cut(strings.TrimSpace(limit), `/`)
+	if !ok {
+		return 0, 0, fmt.Errorf(`invalid limit %q`, limit)
+	}
+
+	n, err = strconv.ParseFloat(strings.TrimSpace(num), 64)
+	if err != nil || n <= 0 {
+		return 0, 0, fmt.Errorf(`invalid limit %q`, limit)
+	}
+
+	switch strings.TrimSpace(unitStr) {
+	case `s`:
+		unit = time.Second
+	case `m`:
+		unit = time.Minute
+	case `h`:
+		unit = time.Hour
+	default:
+		return 0, 0, fmt.Errorf(`invalid limit %q: unknown unit %q`, limit, unitStr)
+	}
+	return n, unit, nil
+}
+
+// initRateLimits initialize the global and per path rate limit.
+func (env *Env) initRateLimits() (err error) {
+	var logp = `initRateLimits`
+
+	env.rateLimit = nil
+	if len(env.RateLimit) != 0 {
+		env.rateLimit = &RateLimit{
+			Limit: env.RateLimit,
+			Burst: env.RateLimitBurst,
+		}
+		err = env.rateLimit.init(`/`)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+	}
+
+	env.rateLimits = env.rateLimits[:0]
+
+	var (
+		path string
+		rl   *RateLimit
+	)
+	for path, rl = range env.RateLimits {
+		err = rl.init(path)
+		if err != nil {
+			return fmt.Errorf(`%s: %w`, logp, err)
+		}
+		env.rateLimits = append(env.rateLimits, rl)
+	}
+
+	// Sort by the longest path first, so the most specific path is
+	// matched first.
+	sort.Slice(env.rateLimits, func(x, y int) bool {
+		if len(env.rateLimits[x].path) == len(env.rateLimits[y].path) {
+			return env.rateLimits[x].path < env.rateLimits[y].path
+		}
+		return len(env.rateLimits[x].path) > len(env.rateLimits[y].path)
+	})
+	return nil
+}
+
+// isRateLimited return true if rate limit is configured.
+func (env *Env) isRateLimited() bool {
+	return env.rateLimit != nil || len(env.rateLimits) != 0
+}
+
+// withResponseWriter wrap the HTTP handler to store the response writer
+// in the request context, see ctxKeyResponseWriter.
+func withResponseWriter(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ctx = context.WithValue(req.Context(), ctxKeyResponseWriter{}, w)
+		next.ServeHTTP(w, req.WithContext(ctx))
+	})
+}
+
+// evalRateLimit reject the HTTP request if the client IP address exceed
+// the global rate limit or the rate limit for the request path.
+// The client IP address is derived using [Env.TrustedProxy], so clients
+// behind the same reverse proxy does not share the same limit.
+func (k *Karajo) evalRateLimit(req *http.Request, _ []byte) error {
+	var (
+		ip  = k.env.clientIP(req)
+		now = timeNow()
+
+		rl   *RateLimit
+		ok   bool
+		wait time.Duration
+	)
+	for _, rl = range k.env.rateLimits {
+		if !rl.isMatch(req.URL.Path) {
+			continue
+		}
+		ok, wait = rl.allow(ip, now)
+		if !ok {
+			setRetryAfter(req, wait)
+			return &errRateLimited
+		}
+		break
+	}
+	if k.env.rateLimit != nil {
+		ok, wait = k.env.rateLimit.allow(ip, now)
+		if !ok {
+			setRetryAfter(req, wait)
+			return &errRateLimited
+		}
+	}
+	return nil
+}
+
+// setRetryAfter set the Retry-After header, in seconds rounded up, into
+// the response writer stored in the request context.
+func setRetryAfter(req *http.Request, wait time.Duration) {
+	var w, ok = req.Context().Value(ctxKeyResponseWriter{}).(http.ResponseWriter)
+	if !ok {
+		return
+	}
+	var seconds = int64(math.Ceil(wait.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	w.Header().Set(headerRetryAfter, strconv.FormatInt(seconds, 10))
+}
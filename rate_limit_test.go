@@ -0,0 +1,232 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestParseRateLimit(t *testing.T) {
+	type testCase struct {
+		limit    string
+		expError string
+		expN     float64
+		expUnit  time.Duration
+	}
+
+	var cases = []testCase{{
+		limit:   `10/s`,
+		expN:    10,
+		expUnit: time.Second,
+	}, {
+		limit:   ` 0.5 / m `,
+		expN:    0.5,
+		expUnit: time.Minute,
+	}, {
+		limit:   `100/h`,
+		expN:    100,
+		expUnit: time.Hour,
+	}, {
+		limit:    `10`,
+		expError: `invalid limit "10"`,
+	}, {
+		limit:    `0/s`,
+		expError: `invalid limit "0/s"`,
+	}, {
+		limit:    `10/d`,
+		expError: `invalid limit "10/d": unknown unit "d"`,
+	}}
+
+	var (
+		c    testCase
+		n    float64
+		unit time.Duration
+		err  error
+	)
+	for _, c = range cases {
+		n, unit, err = parseRateLimit(c.limit)
+		if err != nil {
+			test.Assert(t, c.limit, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.limit+`: n`, c.expN, n)
+		test.Assert(t, c.limit+`: unit`, c.expUnit, unit)
+	}
+}
+
+func TestRateLimit_allow(t *testing.T) {
+	var (
+		rl = &RateLimit{
+			Limit: `2/s`,
+		}
+		now = timeNow()
+		err error
+	)
+
+	err = rl.init(`/`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `default burst`, 2, rl.Burst)
+
+	var (
+		ok   bool
+		wait time.Duration
+	)
+
+	ok, _ = rl.allow(`10.0.0.1`, now)
+	test.Assert(t, `first`, true, ok)
+	ok, _ = rl.allow(`10.0.0.1`, now)
+	test.Assert(t, `second`, true, ok)
+	ok, wait = rl.allow(`10.0.0.1`, now)
+	test.Assert(t, `exceed`, false, ok)
+	test.Assert(t, `exceed wait`, 500*time.Millisecond, wait)
+	ok, _ = rl.allow(`10.0.0.2`, now)
+	test.Assert(t, `other client`, true, ok)
+
+	// After half second, one token is added.
+	now = now.Add(500 * time.Millisecond)
+	ok, _ = rl.allow(`10.0.0.1`, now)
+	test.Assert(t, `refill`, true, ok)
+	ok, _ = rl.allow(`10.0.0.1`, now)
+	test.Assert(t, `refill exceed`, false, ok)
+
+	// The idle buckets are removed after refilled.
+	now = now.Add(rateLimitPruneInterval)
+	rl.allow(`10.0.0.3`, now)
+	test.Assert(t, `prune`, 1, len(rl.buckets))
+}
+
+func TestKarajo_evalRateLimit(t *testing.T) {
+	var (
+		env = &Env{
+			RateLimit: `3/m`,
+			RateLimits: map[string]*RateLimit{
+				apiEnv: {
+					Limit: `1/m`,
+				},
+				`/karajo/api/job_exec/`: {
+					Limit: `2/m`,
+				},
+			},
+		}
+		k   = &Karajo{env: env}
+		err error
+	)
+
+	err = env.initRateLimits()
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `sorted`, apiEnv, env.rateLimits[0].path)
+
+	type testCase struct {
+		path          string
+		expError      string
+		expRetryAfter string
+	}
+
+	var cases = []testCase{{
+		path: apiEnv,
+	}, {
+		path:          apiEnv,
+		expError:      errRateLimited.Message,
+		expRetryAfter: `60`,
+	}, {
+		path: apiJobExecRun,
+	}, {
+		path: apiJobExecPause,
+	}, {
+		// Exceed the job_exec limit.
+		path:          apiJobExecResume,
+		expError:      errRateLimited.Message,
+		expRetryAfter: `30`,
+	}}
+
+	var (
+		c   testCase
+		rec *httptest.ResponseRecorder
+		req *http.Request
+	)
+	for _, c = range cases {
+		rec = httptest.NewRecorder()
+		req = httptest.NewRequest(`GET`, c.path, nil)
+		req = req.WithContext(context.WithValue(req.Context(), ctxKeyResponseWriter{}, rec))
+
+		err = k.evalRateLimit(req, nil)
+		test.Assert(t, c.path+`: Retry-After`, c.expRetryAfter,
+			rec.Header().Get(headerRetryAfter))
+		if err != nil {
+			test.Assert(t, c.path, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.path, c.expError, ``)
+	}
+}
+
+func TestKarajo_evalRateLimit_sse(t *testing.T) {
+	var (
+		env = &Env{
+			ListenAddress: `127.0.0.1:0`,
+			DirBase:       t.TempDir(),
+			Secret:        `s3cret`,
+			RateLimits: map[string]*RateLimit{
+				apiJobExecLogSSE: {
+					Limit: `1/m`,
+				},
+				apiJobHTTPLogSSE: {
+					Limit: `1/m`,
+				},
+			},
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = New(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		_ = k.Stop()
+	})
+
+	var (
+		listPath = []string{apiJobExecLogSSE, apiJobHTTPLogSSE}
+
+		path string
+		rec  *httptest.ResponseRecorder
+		req  *http.Request
+	)
+	for _, path = range listPath {
+		req = httptest.NewRequest(`GET`, path+`?id=test`, nil)
+		req.RemoteAddr = `10.0.0.1:1234`
+		rec = httptest.NewRecorder()
+		k.HTTPd.Server.Handler.ServeHTTP(rec, req)
+
+		// The first request pass the evaluator and fail on hijack,
+		// since the recorder is not http.Hijacker.
+		test.Assert(t, path+`: first`, http.StatusInternalServerError, rec.Code)
+
+		req = httptest.NewRequest(`GET`, path+`?id=test`, nil)
+		req.RemoteAddr = `10.0.0.2:1234`
+		rec = httptest.NewRecorder()
+		k.HTTPd.Server.Handler.ServeHTTP(rec, req)
+		test.Assert(t, path+`: other client`, http.StatusInternalServerError, rec.Code)
+
+		req = httptest.NewRequest(`GET`, path+`?id=test`, nil)
+		req.RemoteAddr = `10.0.0.1:1234`
+		rec = httptest.NewRecorder()
+		k.HTTPd.Server.Handler.ServeHTTP(rec, req)
+		test.Assert(t, path+`: exceed`, http.StatusTooManyRequests, rec.Code)
+		test.Assert(t, path+`: Retry-After`, `60`, rec.Header().Get(headerRetryAfter))
+	}
+}
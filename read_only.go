@@ -0,0 +1,99 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"strconv"
+	"sync/atomic"
+)
+
+// readOnlyMode the state of read-only mode, that can be toggled at
+// runtime.
+//
+// In read-only mode, all of the mutating HTTP APIs are rejected and the
+// jobs are not executed, while the status pages and logs are still
+// served, for example when running as reporting replica or during
+// incident freeze.
+type readOnlyMode struct {
+	// revision bumped when the mode changes.
+	revision *envRevision
+
+	v atomic.Bool
+}
+
+// newReadOnlyMode create new read-only mode with initial value.
+func newReadOnlyMode(v bool, revision *envRevision) (ro *readOnlyMode) {
+	ro = &readOnlyMode{
+		revision: revision,
+	}
+	ro.v.Store(v)
+	return ro
+}
+
+// is return true if the read-only mode is enabled.
+func (ro *readOnlyMode) is() bool {
+	if ro == nil {
+		return false
+	}
+	return ro.v.Load()
+}
+
+// set enable or disable the read-only mode.
+// It return true if the mode changes.
+func (ro *readOnlyMode) set(v bool) (changed bool) {
+	changed = ro.v.Swap(v) != v
+	if changed && ro.revision != nil {
+		ro.revision.bump()
+	}
+	return changed
+}
+
+// MarshalJSON encode the current mode as JSON boolean.
+func (ro *readOnlyMode) MarshalJSON() ([]byte, error) {
+	return []byte(strconv.FormatBool(ro.is())), nil
+}
+
+// UnmarshalJSON decode the mode from JSON boolean.
+func (ro *readOnlyMode) UnmarshalJSON(b []byte) (err error) {
+	var v bool
+
+	v, err = strconv.ParseBool(string(b))
+	if err != nil {
+		return err
+	}
+	ro.v.Store(v)
+	return nil
+}
+
+// isAllowedInReadOnly return true if the request can be served in
+// read-only mode.
+// The request that does not change the state, the authentication, and
+// the API to toggle the read-only mode itself are allowed.
+// The agent APIs are allowed so the commands that already dispatched can
+// report their result.
+func isAllowedInReadOnly(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	}
+	switch req.URL.Path {
+	case apiAuthLogin, apiAuthLogout, apiEnvReadOnly,
+		apiAgentDone, apiAgentLog, apiAgentPoll:
+		return true
+	}
+	return false
+}
+
+// evalReadOnly reject the request that change the state when the
+// read-only mode is enabled.
+func (k *Karajo) evalReadOnly(req *http.Request, _ []byte) error {
+	if !k.env.ReadOnlyMode.is() {
+		return nil
+	}
+	if isAllowedInReadOnly(req) {
+		return nil
+	}
+	return &errReadOnly
+}
@@ -0,0 +1,131 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_evalReadOnly(t *testing.T) {
+	var (
+		env = &Env{
+			ReadOnlyMode: newReadOnlyMode(true, nil),
+		}
+		k = &Karajo{env: env}
+	)
+
+	type testCase struct {
+		method   string
+		path     string
+		expError string
+	}
+
+	var cases = []testCase{{
+		method: http.MethodGet,
+		path:   apiEnv,
+	}, {
+		method: http.MethodGet,
+		path:   apiJobExecLog,
+	}, {
+		method: http.MethodPost,
+		path:   apiAuthLogin,
+	}, {
+		method: http.MethodPost,
+		path:   apiEnvReadOnly,
+	}, {
+		method: http.MethodPost,
+		path:   apiAgentDone,
+	}, {
+		method:   http.MethodPost,
+		path:     apiJobExecRun + `/deploy`,
+		expError: errReadOnly.Message,
+	}, {
+		method:   http.MethodPost,
+		path:     apiJobExecPause,
+		expError: errReadOnly.Message,
+	}}
+
+	var (
+		c   testCase
+		req *http.Request
+		err error
+	)
+	for _, c = range cases {
+		req = httptest.NewRequest(c.method, c.path, nil)
+		err = k.evalReadOnly(req, nil)
+		if err != nil {
+			test.Assert(t, c.path, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.path, c.expError, ``)
+	}
+
+	// All of the requests are allowed once the read-only mode disabled.
+	test.Assert(t, `set`, true, env.ReadOnlyMode.set(false))
+	test.Assert(t, `set twice`, false, env.ReadOnlyMode.set(false))
+
+	req = httptest.NewRequest(http.MethodPost, apiJobExecPause, nil)
+	err = k.evalReadOnly(req, nil)
+	test.Assert(t, `disabled`, nil, err)
+}
+
+func TestReadOnlyMode_JSON(t *testing.T) {
+	var (
+		env = Env{
+			ReadOnlyMode: newReadOnlyMode(true, nil),
+		}
+		got struct {
+			ReadOnly *readOnlyMode `json:"read_only"`
+		}
+		raw []byte
+		err error
+	)
+
+	raw, err = json.Marshal(env.ReadOnlyMode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `MarshalJSON`, `true`, string(raw))
+
+	err = json.Unmarshal([]byte(`{"read_only":true}`), &got)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `UnmarshalJSON`, true, got.ReadOnly.is())
+}
+
+func TestJobExec_run_readOnly(t *testing.T) {
+	var (
+		job = &JobExec{
+			JobBase: JobBase{
+				kind:     jobKindExec,
+				ID:       `job`,
+				dirLog:   t.TempDir(),
+				readOnly: newReadOnlyMode(true, nil),
+			},
+		}
+		isAcked bool
+		ack     = func() { isAcked = true }
+		req     = httptest.NewRequest(http.MethodPost, apiJobExecRun+`/job`, nil)
+		ctx     = context.WithValue(req.Context(), ctxKeyJobQueueAck{}, ack)
+		epr     = &libhttp.EndpointRequest{
+			HTTPRequest: req.WithContext(ctx),
+		}
+	)
+
+	// The queued request is acknowledged and recorded as skipped.
+	job.run(epr)
+
+	test.Assert(t, `acked`, true, isAcked)
+	test.Assert(t, `logs`, 1, len(job.Logs))
+	test.Assert(t, `log status`, JobStatusSkipped, job.Logs[0].Status)
+	test.Assert(t, `log reason`, jobSkipReadOnly, job.Logs[0].Reason)
+}
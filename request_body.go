@@ -0,0 +1,140 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+// defMaxRequestBody default maximum size of HTTP request body, 4 MiB.
+const defMaxRequestBody = 4 << 20
+
+// maxRequestBody return the maximum size of request body for path.
+// The JobExec hook can override the global limit using its
+// MaxRequestBody.
+func (k *Karajo) maxRequestBody(path string) int64 {
+	k.hookMtx.RLock()
+	var limit, ok = k.bodyLimits[path]
+	k.hookMtx.RUnlock()
+	if ok {
+		return limit
+	}
+	return k.env.MaxRequestBody
+}
+
+// limitRequestBody wrap the HTTP handler to reject request with body
+// larger than the maximum size, before the body is read fully into
+// memory by the next handler.
+//
+// The Content-Length may be unknown, for example on chunked transfer
+// encoding, so the body is wrapped with [http.MaxBytesReader].
+// Once the limit reached, the next handler fail to read the body and
+// response with 400, which is replaced with 413 by limitedResponse.
+func (k *Karajo) limitRequestBody(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Body == nil || req.Body == http.NoBody || req.ContentLength == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+
+		var limit = k.maxRequestBody(req.URL.Path)
+		if req.ContentLength > limit {
+			writeErrRequestTooLarge(w, req)
+			return
+		}
+
+		var (
+			body = &limitedBody{
+				ReadCloser: http.MaxBytesReader(w, req.Body, limit),
+			}
+			res = &limitedResponse{
+				ResponseWriter: w,
+				body:           body,
+				req:            req,
+			}
+		)
+		req.Body = body
+		next.ServeHTTP(res, req)
+	})
+}
+
+// limitedBody wrap the [http.MaxBytesReader] to record whether the limit
+// has been reached.
+type limitedBody struct {
+	io.ReadCloser
+	isExceeded bool
+}
+
+// Read the body and mark it as exceeded if the read return
+// [http.MaxBytesError].
+func (body *limitedBody) Read(p []byte) (n int, err error) {
+	n, err = body.ReadCloser.Read(p)
+	if err != nil {
+		var errMax *http.MaxBytesError
+		if errors.As(err, &errMax) {
+			body.isExceeded = true
+		}
+	}
+	return n, err
+}
+
+// limitedResponse replace the response with errRequestTooLarge if the
+// request body exceed the limit.
+type limitedResponse struct {
+	http.ResponseWriter
+	body *limitedBody
+	req  *http.Request
+
+	isReplaced bool
+}
+
+// WriteHeader write errRequestTooLarge if the body limit has been reached,
+// otherwise write the code as is.
+func (res *limitedResponse) WriteHeader(code int) {
+	if res.isReplaced {
+		return
+	}
+	if res.body.isExceeded {
+		res.isReplaced = true
+		writeErrRequestTooLarge(res.ResponseWriter, res.req)
+		return
+	}
+	res.ResponseWriter.WriteHeader(code)
+}
+
+// Write the response body, unless the response has been replaced.
+func (res *limitedResponse) Write(b []byte) (int, error) {
+	if res.body.isExceeded && !res.isReplaced {
+		res.WriteHeader(http.StatusRequestEntityTooLarge)
+	}
+	if res.isReplaced {
+		return len(b), nil
+	}
+	return res.ResponseWriter.Write(b)
+}
+
+// Unwrap return the original ResponseWriter, used by
+// [http.ResponseController].
+func (res *limitedResponse) Unwrap() http.ResponseWriter {
+	return res.ResponseWriter
+}
+
+// writeErrRequestTooLarge write the errRequestTooLarge as JSON response.
+func writeErrRequestTooLarge(w http.ResponseWriter, req *http.Request) {
+	var body, _ = json.Marshal(&errRequestTooLarge)
+
+	w.Header().Set(libhttp.HeaderContentType, libhttp.ContentTypeJSON)
+	w.WriteHeader(errRequestTooLarge.Code)
+
+	var _, err = w.Write(body)
+	if err != nil {
+		mlog.Errf(`writeErrRequestTooLarge: %s %s: %s`, req.Method, req.URL.Path, err)
+	}
+}
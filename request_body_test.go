@@ -0,0 +1,155 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_limitRequestBody(t *testing.T) {
+	var (
+		hookPath = apiJobExecRun + `/large`
+		k        = &Karajo{
+			env: &Env{
+				MaxRequestBody: 8,
+			},
+			bodyLimits: map[string]int64{
+				hookPath: 16,
+			},
+		}
+
+		gotBody string
+		// The handler read the body like libhttp, response with 400
+		// if reading the body failed.
+		handler = k.limitRequestBody(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			var body, err = io.ReadAll(req.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			gotBody = string(body)
+		}))
+	)
+
+	type testCase struct {
+		desc      string
+		path      string
+		body      string
+		expBody   string
+		expCode   int
+		isChunked bool
+	}
+
+	var cases = []testCase{{
+		desc:    `with body under limit`,
+		path:    apiAuthLogin,
+		body:    `12345678`,
+		expBody: `12345678`,
+		expCode: http.StatusOK,
+	}, {
+		desc:    `with body over limit`,
+		path:    apiAuthLogin,
+		body:    `123456789`,
+		expCode: http.StatusRequestEntityTooLarge,
+	}, {
+		desc:      `with chunked body over limit`,
+		path:      apiAuthLogin,
+		body:      `123456789`,
+		isChunked: true,
+		expCode:   http.StatusRequestEntityTooLarge,
+	}, {
+		desc:    `with hook limit`,
+		path:    hookPath,
+		body:    `123456789`,
+		expBody: `123456789`,
+		expCode: http.StatusOK,
+	}, {
+		desc:    `with body over hook limit`,
+		path:    hookPath,
+		body:    `12345678901234567`,
+		expCode: http.StatusRequestEntityTooLarge,
+	}}
+
+	var (
+		c   testCase
+		req *http.Request
+		rec *httptest.ResponseRecorder
+	)
+	for _, c = range cases {
+		gotBody = ``
+		req = httptest.NewRequest(http.MethodPost, c.path, strings.NewReader(c.body))
+		if c.isChunked {
+			req.ContentLength = -1
+		}
+		rec = httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, req)
+
+		test.Assert(t, c.desc+`: code`, c.expCode, rec.Code)
+		test.Assert(t, c.desc+`: body`, c.expBody, gotBody)
+		if c.expCode == http.StatusRequestEntityTooLarge {
+			test.Assert(t, c.desc+`: response`,
+				`{"message":"request body too large","name":"ERR_REQUEST_TOO_LARGE","code":413}`,
+				rec.Body.String())
+		}
+	}
+}
+
+func TestKarajo_limitRequestBody_libhttp(t *testing.T) {
+	var (
+		k = &Karajo{
+			env: &Env{
+				MaxRequestBody: 8,
+			},
+		}
+		srv *libhttp.Server
+		err error
+	)
+
+	srv, err = libhttp.NewServer(libhttp.ServerOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = srv.RegisterEndpoint(libhttp.Endpoint{
+		Method:       libhttp.RequestMethodPost,
+		Path:         `/echo`,
+		RequestType:  libhttp.RequestTypeNone,
+		ResponseType: libhttp.ResponseTypePlain,
+		Call: func(epr *libhttp.EndpointRequest) ([]byte, error) {
+			return epr.RequestBody, nil
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		handler = k.limitRequestBody(srv.Server.Handler)
+
+		rec *httptest.ResponseRecorder
+		req *http.Request
+	)
+
+	// Unknown Content-Length, the limit is detected while libhttp
+	// reading the body.
+	req = httptest.NewRequest(http.MethodPost, `/echo`, strings.NewReader(`123456789`))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	test.Assert(t, `over limit`, http.StatusRequestEntityTooLarge, rec.Code)
+
+	req = httptest.NewRequest(http.MethodPost, `/echo`, strings.NewReader(`12345678`))
+	req.ContentLength = -1
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	test.Assert(t, `under limit`, http.StatusOK, rec.Code)
+	test.Assert(t, `under limit: body`, `12345678`, rec.Body.String())
+}
@@ -0,0 +1,490 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	libhtml "git.sr.ht/~shulhan/pakakeh.go/lib/html"
+	libtime "git.sr.ht/~shulhan/pakakeh.go/lib/time"
+)
+
+const (
+	// defScheduleRange the default range of schedule, before and after
+	// the current time, if the "from" or "to" parameter is empty.
+	defScheduleRange = 24 * time.Hour
+
+	// defScheduleRangeMax the maximum range between "from" and "to"
+	// in the schedule.
+	defScheduleRangeMax = 31 * 24 * time.Hour
+
+	// defScheduleMaxNextRuns the maximum number of next runs for each
+	// job.
+	defScheduleMaxNextRuns = 1000
+
+	// defJobNextRuns the default number of next runs returned by
+	// the API job next.
+	defJobNextRuns = 10
+
+	// defJobNextRange the maximum range, from now, to search the next
+	// runs of job.
+	defJobNextRange = 366 * 24 * time.Hour
+)
+
+// Schedule contains the past runs and the next runs of all jobs between
+// From and To.
+type Schedule struct {
+	From time.Time `json:"from"`
+	To   time.Time `json:"to"`
+
+	Jobs []*ScheduleJob `json:"jobs"`
+}
+
+// ScheduleJob contains the past runs and the next runs of single job.
+type ScheduleJob struct {
+	Kind jobKind `json:"kind"`
+	ID   string  `json:"id"`
+	Name string  `json:"name"`
+
+	Runs     []ScheduleRun `json:"runs"`
+	NextRuns []time.Time   `json:"next_runs"`
+}
+
+// ScheduleRun contains the time and status of past job execution, from the
+// job log.
+// The End is zero if the job is still running.
+type ScheduleRun struct {
+	Begin   time.Time `json:"begin"`
+	End     time.Time `json:"end,omitempty"`
+	Status  string    `json:"status"`
+	Counter int64     `json:"counter"`
+}
+
+// JobNextRuns contains the next runs of single job, as computed from its
+// Schedule or Interval.
+type JobNextRuns struct {
+	// NextRun the time of next run as set by the scheduler.
+	NextRun time.Time `json:"next_run,omitempty"`
+
+	ID       string `json:"id"`
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Schedule string `json:"schedule,omitempty"`
+
+	// NextRuns list of the next runs, up to one year from now.
+	// It is empty if the job is paused or does not have Schedule or
+	// Interval.
+	NextRuns []time.Time `json:"next_runs"`
+
+	Interval time.Duration `json:"interval,omitempty"`
+
+	// NextIn the duration from now until the first of NextRuns.
+	NextIn time.Duration `json:"next_in,omitempty"`
+}
+
+// scheduleMatcher match the time with the job schedule.
+// It use the same format as [libtime.NewScheduler].
+//
+// The [libtime.Scheduler] only provide the next run after the current
+// time and start a goroutine for each instance, so it cannot be used to
+// list the runs between arbitrary time.
+// The result of matcher is tested against it.
+type scheduleMatcher struct {
+	minutes map[int]bool
+	tod     map[int]bool
+	dow     map[time.Weekday]bool
+	dom     map[int]bool
+
+	kind string
+}
+
+// newSchedule create the Schedule of all jobs in env between from and to.
+func newSchedule(env *Env, from, to, now time.Time) (sch *Schedule) {
+	sch = &Schedule{
+		From: from,
+		To:   to,
+		Jobs: []*ScheduleJob{},
+	}
+
+	var (
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+	)
+	env.jobsMtx.RLock()
+	for _, job = range env.ExecJobs {
+		sch.Jobs = append(sch.Jobs, newScheduleJob(&job.JobBase, from, to, now))
+	}
+	env.jobsMtx.RUnlock()
+	for _, jobHTTP = range env.HTTPJobs {
+		sch.Jobs = append(sch.Jobs, newScheduleJob(&jobHTTP.JobBase, from, to, now))
+	}
+	for _, jobSQL = range env.SQLJobs {
+		sch.Jobs = append(sch.Jobs, newScheduleJob(&jobSQL.JobBase, from, to, now))
+	}
+
+	sort.Slice(sch.Jobs, func(x, y int) bool {
+		if sch.Jobs[x].Kind == sch.Jobs[y].Kind {
+			return sch.Jobs[x].ID < sch.Jobs[y].ID
+		}
+		return sch.Jobs[x].Kind < sch.Jobs[y].Kind
+	})
+	return sch
+}
+
+// PreviewSchedule return the next runs of all jobs in the configuration,
+// including the jobs in the job.d, job_http.d, and job_sql.d directories,
+// between from and to, without running the server and reading the job
+// states.
+// The paused job is included, and the job with Interval is assumed to be
+// started at from.
+//
+// It will return an error if one of the job Schedule is invalid.
+// This method should be called on Env that has not been initialized, for
+// example the one returned from [LoadEnv].
+func (env *Env) PreviewSchedule(from, to time.Time) (sch *Schedule, err error) {
+	var logp = `PreviewSchedule`
+
+	if to.Before(from) {
+		return nil, fmt.Errorf(`%s: to is before from`, logp)
+	}
+
+	env.initDirsConfig()
+
+	err = env.loadJobd()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = env.loadJobHTTPd()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	err = env.loadJobSQLd()
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	sch, err = env.previewSchedule(from, to)
+	if err != nil {
+		return nil, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	return sch, nil
+}
+
+// previewSchedule return the next runs of all jobs between from and to,
+// ignoring the job states.
+func (env *Env) previewSchedule(from, to time.Time) (sch *Schedule, err error) {
+	sch = &Schedule{
+		From: from.UTC(),
+		To:   to.UTC(),
+		Jobs: []*ScheduleJob{},
+	}
+
+	var (
+		name    string
+		job     *JobExec
+		jobHTTP *JobHTTP
+		jobSQL  *JobSQL
+		sj      *ScheduleJob
+	)
+	for name, job = range env.ExecJobs {
+		sj, err = previewScheduleJob(jobKindExec, name, &job.JobBase, sch.From, sch.To)
+		if err != nil {
+			return nil, err
+		}
+		sch.Jobs = append(sch.Jobs, sj)
+	}
+	for name, jobHTTP = range env.HTTPJobs {
+		sj, err = previewScheduleJob(jobKindHTTP, name, &jobHTTP.JobBase, sch.From, sch.To)
+		if err != nil {
+			return nil, err
+		}
+		sch.Jobs = append(sch.Jobs, sj)
+	}
+	for name, jobSQL = range env.SQLJobs {
+		sj, err = previewScheduleJob(jobKindSQL, name, &jobSQL.JobBase, sch.From, sch.To)
+		if err != nil {
+			return nil, err
+		}
+		sch.Jobs = append(sch.Jobs, sj)
+	}
+
+	sort.Slice(sch.Jobs, func(x, y int) bool {
+		if sch.Jobs[x].Kind == sch.Jobs[y].Kind {
+			return sch.Jobs[x].ID < sch.Jobs[y].ID
+		}
+		return sch.Jobs[x].Kind < sch.Jobs[y].Kind
+	})
+	return sch, nil
+}
+
+// previewScheduleJob return the next runs of job that has not been
+// initialized between from and to.
+func previewScheduleJob(kind jobKind, name string, job *JobBase, from, to time.Time) (sj *ScheduleJob, err error) {
+	var preview = JobBase{
+		kind:     kind,
+		ID:       libhtml.NormalizeForID(name),
+		Name:     name,
+		Schedule: strings.TrimSpace(job.Schedule),
+		Interval: job.Interval,
+		NextRun:  from,
+		Disabled: job.Disabled,
+	}
+
+	if len(preview.Schedule) != 0 {
+		_, err = newScheduleMatcher(preview.Schedule)
+		if err != nil {
+			return nil, fmt.Errorf(`%s %q: schedule %q: %w`,
+				kind, name, preview.Schedule, err)
+		}
+	}
+
+	sj = &ScheduleJob{
+		Kind:     kind,
+		ID:       preview.ID,
+		Name:     name,
+		Runs:     []ScheduleRun{},
+		NextRuns: preview.nextRuns(from, to, from.Add(-time.Nanosecond), defScheduleMaxNextRuns),
+	}
+	return sj, nil
+}
+
+// newScheduleJob collect the runs and next runs of job between from and
+// to.
+func newScheduleJob(job *JobBase, from, to, now time.Time) (sj *ScheduleJob) {
+	var logs []*JobLog
+
+	job.Lock()
+	sj = &ScheduleJob{
+		Kind:     job.kind,
+		ID:       job.ID,
+		Name:     job.Name,
+		Runs:     []ScheduleRun{},
+		NextRuns: job.nextRuns(from, to, now, defScheduleMaxNextRuns),
+	}
+	logs = append(logs, job.Logs...)
+	job.Unlock()
+
+	var (
+		jlog *JobLog
+		run  ScheduleRun
+	)
+	for _, jlog = range logs {
+		jlog.Lock()
+		run = ScheduleRun{
+			Begin:   jlog.timeBegin,
+			End:     jlog.timeEnd,
+			Status:  jlog.Status,
+			Counter: jlog.Counter,
+		}
+		jlog.Unlock()
+
+		if run.Status == JobStatusPaused || run.Status == JobStatusSkipped {
+			continue
+		}
+		if !run.End.IsZero() {
+			run.Begin = run.End.Add(-jlog.duration())
+		}
+		if run.Begin.IsZero() {
+			continue
+		}
+
+		var end = run.End
+		if end.IsZero() {
+			end = now
+		}
+		if end.Before(from) || run.Begin.After(to) {
+			continue
+		}
+		sj.Runs = append(sj.Runs, run)
+	}
+	return sj
+}
+
+// listNextRuns return the next n runs of job after now.
+func (job *JobBase) listNextRuns(now time.Time, n int) (jnr *JobNextRuns) {
+	job.Lock()
+	jnr = &JobNextRuns{
+		NextRun:  job.NextRun,
+		ID:       job.ID,
+		Name:     job.Name,
+		Status:   job.Status,
+		Schedule: job.Schedule,
+		NextRuns: job.nextRuns(now, now.Add(defJobNextRange), now, n),
+		Interval: job.Interval,
+	}
+	job.Unlock()
+
+	if len(jnr.NextRuns) != 0 {
+		jnr.NextIn = jnr.NextRuns[0].Sub(now)
+	}
+	return jnr
+}
+
+// nextRuns return the time when the job will be executed between from and
+// to, but not before now, limited to max items.
+// The disabled job, paused job, or job without Schedule or Interval does
+// not have next runs.
+// The caller must lock the job.
+func (job *JobBase) nextRuns(from, to, now time.Time, max int) (list []time.Time) {
+	list = []time.Time{}
+
+	if job.Disabled || job.Status == JobStatusPaused {
+		return list
+	}
+	if from.Before(now) {
+		from = now
+	}
+
+	if len(job.Schedule) != 0 {
+		var sm, err = newScheduleMatcher(job.Schedule)
+		if err != nil {
+			return list
+		}
+
+		// The schedule run on the next minute after now.
+		var t = from.UTC().Truncate(time.Minute)
+		if !t.After(now) {
+			t = t.Add(time.Minute)
+		}
+		for ; !t.After(to) && len(list) < max; t = t.Add(time.Minute) {
+			if sm.match(t) {
+				list = append(list, t)
+			}
+		}
+		return list
+	}
+
+	if job.Interval <= 0 || job.NextRun.IsZero() {
+		return list
+	}
+
+	var t = job.NextRun
+	if t.Before(from) {
+		var n = from.Sub(t) / job.Interval
+		t = t.Add(n * job.Interval)
+		if t.Before(from) {
+			t = t.Add(job.Interval)
+		}
+	}
+	for ; !t.After(to) && len(list) < max; t = t.Add(job.Interval) {
+		list = append(list, t)
+	}
+	return list
+}
+
+// newScheduleMatcher parse the schedule into scheduleMatcher.
+// The invalid values are ignored, as in [libtime.NewScheduler], but the
+// unknown schedule kind return [libtime.ErrScheduleUnknown].
+func newScheduleMatcher(schedule string) (sm *scheduleMatcher, err error) {
+	var list = strings.Split(strings.ToLower(schedule), `@`)
+
+	sm = &scheduleMatcher{
+		kind:    list[0],
+		minutes: map[int]bool{},
+		tod:     map[int]bool{},
+		dow:     map[time.Weekday]bool{},
+		dom:     map[int]bool{},
+	}
+
+	var arg = func(x int) string {
+		if len(list) > x {
+			return list[x]
+		}
+		return ``
+	}
+
+	switch sm.kind {
+	case libtime.ScheduleKindHourly:
+		sm.parseInts(sm.minutes, arg(1), 0, 59, 0)
+	case libtime.ScheduleKindDaily:
+		sm.parseTimeOfDay(arg(1))
+	case libtime.ScheduleKindWeekly:
+		sm.parseDayOfWeek(arg(1))
+		sm.parseTimeOfDay(arg(2))
+	case libtime.ScheduleKindMonthly:
+		sm.parseInts(sm.dom, arg(1), 0, 31, 1)
+		sm.parseTimeOfDay(arg(2))
+	case ``, libtime.ScheduleKindMinutely:
+		sm.kind = libtime.ScheduleKindMinutely
+	default:
+		return nil, fmt.Errorf(`%s: %w`, schedule, libtime.ErrScheduleUnknown)
+	}
+	return sm, nil
+}
+
+// match return true if the job is scheduled to run at minute t.
+func (sm *scheduleMatcher) match(t time.Time) bool {
+	var tod = t.Hour()*60 + t.Minute()
+
+	switch sm.kind {
+	case libtime.ScheduleKindHourly:
+		return sm.minutes[t.Minute()]
+	case libtime.ScheduleKindDaily:
+		return sm.tod[tod]
+	case libtime.ScheduleKindWeekly:
+		return sm.dow[t.Weekday()] && sm.tod[tod]
+	case libtime.ScheduleKindMonthly:
+		return sm.dom[t.Day()] && sm.tod[tod]
+	}
+	return true
+}
+
+// parseInts parse comma separated integers in v into set.
+// If no valid integer found, the set is filled with def.
+func (sm *scheduleMatcher) parseInts(set map[int]bool, v string, minv, maxv, def int) {
+	var (
+		str string
+		n   int
+		err error
+	)
+	for _, str = range strings.Split(v, `,`) {
+		n, err = strconv.Atoi(strings.TrimSpace(str))
+		if err != nil || n < minv || n > maxv {
+			continue
+		}
+		set[n] = true
+	}
+	if len(set) == 0 {
+		set[def] = true
+	}
+}
+
+// parseTimeOfDay parse comma separated "HH:MM" in v into set of minutes
+// of the day.
+func (sm *scheduleMatcher) parseTimeOfDay(v string) {
+	var (
+		str   string
+		clock libtime.Clock
+	)
+	for _, str = range strings.Split(v, `,`) {
+		clock = libtime.ParseClock(strings.TrimSpace(str))
+		sm.tod[clock.Hour()*60+clock.Minute()] = true
+	}
+}
+
+// parseDayOfWeek parse comma separated day names in v into set.
+// If no valid day found, the set is filled with Sunday.
+func (sm *scheduleMatcher) parseDayOfWeek(v string) {
+	var (
+		str string
+		day time.Weekday
+	)
+	for _, str = range strings.Split(v, `,`) {
+		str = strings.TrimSpace(str)
+		for day = time.Sunday; day <= time.Saturday; day++ {
+			var name = strings.ToLower(day.String())
+			if str == name || str == name[:3] {
+				sm.dow[day] = true
+			}
+		}
+	}
+	if len(sm.dow) == 0 {
+		sm.dow[time.Sunday] = true
+	}
+}
@@ -0,0 +1,289 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+	libtime "git.sr.ht/~shulhan/pakakeh.go/lib/time"
+)
+
+func TestJobBase_nextRuns(t *testing.T) {
+	type testCase struct {
+		desc string
+		job  *JobBase
+		exp  []time.Time
+	}
+
+	var (
+		// Monday, 2023-01-09 10:20:30.
+		now  = time.Date(2023, time.January, 9, 10, 20, 30, 0, time.UTC)
+		from = now.Add(-time.Hour)
+		to   = time.Date(2023, time.January, 10, 10, 20, 0, 0, time.UTC)
+	)
+
+	var cases = []testCase{{
+		desc: `hourly`,
+		job: &JobBase{
+			Schedule: `hourly@15,45`,
+		},
+		exp: []time.Time{
+			time.Date(2023, time.January, 9, 10, 45, 0, 0, time.UTC),
+			time.Date(2023, time.January, 9, 11, 15, 0, 0, time.UTC),
+		},
+	}, {
+		desc: `daily`,
+		job: &JobBase{
+			Schedule: `daily@06:00,12:30`,
+		},
+		exp: []time.Time{
+			time.Date(2023, time.January, 9, 12, 30, 0, 0, time.UTC),
+			time.Date(2023, time.January, 10, 6, 0, 0, 0, time.UTC),
+		},
+	}, {
+		desc: `weekly`,
+		job: &JobBase{
+			Schedule: `weekly@Mon,tuesday@10:00,11:00`,
+		},
+		exp: []time.Time{
+			time.Date(2023, time.January, 9, 11, 0, 0, 0, time.UTC),
+			time.Date(2023, time.January, 10, 10, 0, 0, 0, time.UTC),
+		},
+	}, {
+		desc: `monthly`,
+		job: &JobBase{
+			Schedule: `monthly@10`,
+		},
+		exp: []time.Time{
+			time.Date(2023, time.January, 10, 0, 0, 0, 0, time.UTC),
+		},
+	}, {
+		desc: `interval`,
+		job: &JobBase{
+			Interval: 5 * time.Hour,
+			NextRun:  time.Date(2023, time.January, 9, 2, 0, 0, 0, time.UTC),
+		},
+		exp: []time.Time{
+			time.Date(2023, time.January, 9, 12, 0, 0, 0, time.UTC),
+			time.Date(2023, time.January, 9, 17, 0, 0, 0, time.UTC),
+			time.Date(2023, time.January, 9, 22, 0, 0, 0, time.UTC),
+			time.Date(2023, time.January, 10, 3, 0, 0, 0, time.UTC),
+			time.Date(2023, time.January, 10, 8, 0, 0, 0, time.UTC),
+		},
+	}, {
+		desc: `paused`,
+		job: &JobBase{
+			Schedule: `hourly`,
+			Status:   JobStatusPaused,
+		},
+		exp: []time.Time{},
+	}, {
+		desc: `without schedule and interval`,
+		job:  &JobBase{},
+		exp:  []time.Time{},
+	}}
+
+	var (
+		c   testCase
+		got []time.Time
+	)
+	for _, c = range cases {
+		got = c.job.nextRuns(from, to, now, defScheduleMaxNextRuns)
+		if c.desc == `hourly` {
+			// Only check the first two runs.
+			test.Assert(t, `hourly: len`, 48, len(got))
+			got = got[:2]
+		}
+		test.Assert(t, c.desc, c.exp, got)
+	}
+}
+
+// TestJobBase_nextRuns_libtime check the first next run with the
+// [libtime.Scheduler].
+func TestJobBase_nextRuns_libtime(t *testing.T) {
+	var listSchedule = []string{
+		`minutely`,
+		`hourly`,
+		`hourly@5,35`,
+		`daily`,
+		`daily@01:30,13:45`,
+		`weekly`,
+		`weekly@Wednesday,sat@06:15`,
+		`monthly`,
+		`monthly@15,31@23:59`,
+	}
+
+	var (
+		schedule string
+		sch      *libtime.Scheduler
+		job      *JobBase
+		before   time.Time
+		after    time.Time
+		got      []time.Time
+		err      error
+	)
+	for _, schedule = range listSchedule {
+		for {
+			before = time.Now().UTC()
+			sch, err = libtime.NewScheduler(schedule)
+			if err != nil {
+				t.Fatal(err)
+			}
+			sch.Stop()
+			after = time.Now().UTC()
+
+			// Make sure the scheduler calculate the next run
+			// on the same minute.
+			if before.Truncate(time.Minute).Equal(after.Truncate(time.Minute)) {
+				break
+			}
+		}
+
+		job = &JobBase{
+			Schedule: schedule,
+		}
+		got = job.nextRuns(before, before.Add(defScheduleRangeMax), before, 1)
+		test.Assert(t, schedule, []time.Time{sch.Next()}, got)
+	}
+}
+
+func TestNewScheduleJob(t *testing.T) {
+	var (
+		now  = time.Date(2023, time.January, 9, 10, 0, 0, 0, time.UTC)
+		from = now.Add(-2 * time.Hour)
+		to   = now.Add(time.Hour)
+
+		job = &JobBase{
+			kind: jobKindExec,
+			ID:   `test`,
+			Name: `Test`,
+			Logs: []*JobLog{{
+				// Finished before from.
+				Counter:   1,
+				Status:    JobStatusSuccess,
+				timeBegin: now.Add(-4 * time.Hour),
+				timeEnd:   now.Add(-3 * time.Hour),
+			}, {
+				Counter:   2,
+				Status:    JobStatusFailed,
+				timeBegin: now.Add(-150 * time.Minute),
+				timeEnd:   now.Add(-90 * time.Minute),
+			}, {
+				Counter:   3,
+				Status:    JobStatusPaused,
+				timeBegin: now.Add(-time.Hour),
+				timeEnd:   now.Add(-time.Hour),
+			}, {
+				Counter:   4,
+				Status:    JobStatusRunning,
+				timeBegin: now.Add(-time.Minute),
+			}},
+		}
+	)
+
+	var exp = &ScheduleJob{
+		Kind: jobKindExec,
+		ID:   `test`,
+		Name: `Test`,
+		Runs: []ScheduleRun{{
+			Begin:   now.Add(-150 * time.Minute),
+			End:     now.Add(-90 * time.Minute),
+			Status:  JobStatusFailed,
+			Counter: 2,
+		}, {
+			Begin:   now.Add(-time.Minute),
+			Status:  JobStatusRunning,
+			Counter: 4,
+		}},
+		NextRuns: []time.Time{},
+	}
+
+	var got = newScheduleJob(job, from, to, now)
+	test.Assert(t, `newScheduleJob`, exp, got)
+
+	// The log content should not be loaded to compute the run
+	// duration.
+	var jlog *JobLog
+	for _, jlog = range job.Logs {
+		test.Assert(t, `log content loaded`, 0, len(jlog.content))
+	}
+}
+
+func TestEnv_PreviewSchedule(t *testing.T) {
+	var (
+		dirBase = t.TempDir()
+		dirJobd = filepath.Join(dirBase, `etc`, `karajo`, `job.d`)
+		from    = time.Date(2024, time.January, 1, 0, 0, 0, 0, time.UTC)
+		to      = from.Add(3 * time.Hour)
+
+		err error
+	)
+
+	err = os.MkdirAll(dirJobd, 0700)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(filepath.Join(dirJobd, `backup.conf`),
+		[]byte("[job \"Backup\"]\nschedule = hourly@30\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var env = &Env{
+		DirBase: dirBase,
+		HTTPJobs: map[string]*JobHTTP{
+			`Ping`: {
+				JobBase: JobBase{
+					Interval: 90 * time.Minute,
+					Status:   JobStatusPaused,
+				},
+			},
+		},
+	}
+
+	var sch *Schedule
+
+	sch, err = env.PreviewSchedule(from, to)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var exp = &Schedule{
+		From: from,
+		To:   to,
+		Jobs: []*ScheduleJob{{
+			Kind: jobKindExec,
+			ID:   `backup`,
+			Name: `Backup`,
+			Runs: []ScheduleRun{},
+			NextRuns: []time.Time{
+				from.Add(30 * time.Minute),
+				from.Add(90 * time.Minute),
+				from.Add(150 * time.Minute),
+			},
+		}, {
+			Kind: jobKindHTTP,
+			ID:   `ping`,
+			Name: `Ping`,
+			Runs: []ScheduleRun{},
+			NextRuns: []time.Time{
+				from,
+				from.Add(90 * time.Minute),
+				from.Add(180 * time.Minute),
+			},
+		}},
+	}
+	test.Assert(t, `PreviewSchedule`, exp, sch)
+
+	env.ExecJobs[`Backup`].Schedule = `hourly@61`
+
+	_, err = env.PreviewSchedule(from, to)
+	if err == nil {
+		t.Fatal(`expecting error on invalid schedule`)
+	}
+}
@@ -0,0 +1,244 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+)
+
+// List of built-in secret provider scheme.
+const (
+	secretSchemeFile  = `file`
+	secretSchemeVault = `vault`
+)
+
+// List of system environment variables to configure the Vault secret
+// provider.
+const (
+	envVaultAddr  = `VAULT_ADDR`
+	envVaultToken = `VAULT_TOKEN`
+)
+
+// defVaultKey the default key to read from Vault secret data if the
+// reference does not contains "#key".
+const defVaultKey = `value`
+
+// defVaultTimeout the timeout for fetching secret from Vault.
+const defVaultTimeout = 10 * time.Second
+
+// defSecretRefreshInterval the minimum interval between refreshing the
+// external secret, so request with invalid signature cannot be used to
+// flood the provider.
+const defSecretRefreshInterval = 30 * time.Second
+
+// SecretProvider fetch the secret from external source.
+//
+// The secret in configuration can reference the external secret using
+// "<scheme>:<path>" format, where scheme is the name of registered
+// provider and path is passed to FetchSecret.
+type SecretProvider interface {
+	FetchSecret(path string) (secret string, err error)
+}
+
+// secretProviderFile read the secret from file.
+// The trailing white spaces in the file content are removed.
+type secretProviderFile struct{}
+
+// FetchSecret read the secret from file in path.
+func (secretProviderFile) FetchSecret(path string) (secret string, err error) {
+	var content []byte
+
+	content, err = os.ReadFile(path)
+	if err != nil {
+		return ``, err
+	}
+	return string(bytes.TrimRight(content, " \t\r\n")), nil
+}
+
+// secretProviderVault read the secret from HashiCorp Vault using the HTTP
+// API.
+// The Vault address and token are read from system environment VAULT_ADDR
+// and VAULT_TOKEN.
+//
+// The path has the format "<api-path>[#key]", for example
+// "kv/karajo#webhook" read the key "webhook" from "/v1/kv/karajo".
+// If the key is empty it will default to "value".
+// Both KV version 1 and version 2 responses are supported.
+type secretProviderVault struct{}
+
+// FetchSecret read the secret from Vault.
+func (secretProviderVault) FetchSecret(path string) (secret string, err error) {
+	var (
+		logp = `FetchSecret`
+		addr = os.Getenv(envVaultAddr)
+	)
+	if len(addr) == 0 {
+		return ``, fmt.Errorf(`%s: empty %s`, logp, envVaultAddr)
+	}
+
+	var apiPath, key, _ = strings.Cut(path, `#`)
+	if len(key) == 0 {
+		key = defVaultKey
+	}
+
+	var (
+		clientOpts = libhttp.ClientOptions{
+			ServerURL: strings.TrimRight(addr, `/`),
+			Headers: http.Header{
+				`X-Vault-Token`: []string{os.Getenv(envVaultToken)},
+			},
+		}
+		httpc     = libhttp.NewClient(clientOpts)
+		clientReq = libhttp.ClientRequest{
+			Path: `/v1/` + strings.TrimLeft(apiPath, `/`),
+		}
+		clientResp *libhttp.ClientResponse
+	)
+
+	httpc.Client.Timeout = defVaultTimeout
+
+	clientResp, err = httpc.Get(clientReq)
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, logp, err)
+	}
+	if clientResp.HTTPResponse.StatusCode != http.StatusOK {
+		return ``, fmt.Errorf(`%s: %s: %s`, logp, apiPath,
+			clientResp.HTTPResponse.Status)
+	}
+
+	var res struct {
+		Data map[string]any `json:"data"`
+	}
+
+	err = json.Unmarshal(clientResp.Body, &res)
+	if err != nil {
+		return ``, fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	// KV version 2 store the secret inside data.data.
+	var data, ok = res.Data[`data`].(map[string]any)
+	if !ok {
+		data = res.Data
+	}
+
+	secret, ok = data[key].(string)
+	if !ok {
+		return ``, fmt.Errorf(`%s: %s: key %q not found`, logp, apiPath, key)
+	}
+	return secret, nil
+}
+
+// secretValue contains the secret from configuration.
+// If the secret reference an external provider, the secret is fetched
+// from provider and cached.
+type secretValue struct {
+	provider SecretProvider
+
+	// ref is the original value from configuration, while path is
+	// the value without scheme.
+	ref  string
+	path string
+
+	value []byte
+
+	// lastRefresh the time when the secret refreshed.
+	lastRefresh time.Time
+
+	sync.Mutex
+}
+
+// newSecretValue create new secretValue from the value in configuration.
+// If the value has prefix "<scheme>:" where scheme is registered in
+// providers, the secret will be fetched from provider.
+// Otherwise the value is used as secret.
+func newSecretValue(value string, providers map[string]SecretProvider) (sv *secretValue) {
+	sv = &secretValue{
+		ref: value,
+	}
+
+	var scheme, path, found = strings.Cut(value, `:`)
+	if found {
+		sv.provider = providers[scheme]
+		sv.path = path
+	}
+	if sv.provider == nil {
+		sv.value = []byte(value)
+	}
+	return sv
+}
+
+// isExternal return true if the secret is fetched from provider.
+func (sv *secretValue) isExternal() bool {
+	return sv != nil && sv.provider != nil
+}
+
+// get return the cached secret.
+// If the secret has not been fetched, it will fetch it from provider.
+func (sv *secretValue) get() (secret []byte, err error) {
+	sv.Lock()
+	defer sv.Unlock()
+
+	if sv.value != nil {
+		return sv.value, nil
+	}
+	return sv.fetch()
+}
+
+// refresh fetch the secret from provider again.
+// It return true if the new secret is different with the cached one.
+// The secret is refreshed at most once per defSecretRefreshInterval.
+func (sv *secretValue) refresh() (changed bool, err error) {
+	if !sv.isExternal() {
+		return false, nil
+	}
+
+	sv.Lock()
+	defer sv.Unlock()
+
+	var now = timeNow()
+	if now.Sub(sv.lastRefresh) < defSecretRefreshInterval {
+		return false, nil
+	}
+	sv.lastRefresh = now
+
+	var old = sv.value
+
+	_, err = sv.fetch()
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(old, sv.value), nil
+}
+
+// invalidate remove the cached secret, so the next get will fetch the
+// secret from provider.
+func (sv *secretValue) invalidate() {
+	if !sv.isExternal() {
+		return
+	}
+	sv.Lock()
+	sv.value = nil
+	sv.Unlock()
+}
+
+// fetch the secret from provider and cache it.
+func (sv *secretValue) fetch() (secret []byte, err error) {
+	var str string
+
+	str, err = sv.provider.FetchSecret(sv.path)
+	if err != nil {
+		return nil, fmt.Errorf(`fetch secret %q: %w`, sv.ref, err)
+	}
+	sv.value = []byte(str)
+	return sv.value, nil
+}
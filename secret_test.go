@@ -0,0 +1,195 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+// secretProviderMock return the secret from map and count the number of
+// fetch.
+type secretProviderMock struct {
+	secrets map[string]string
+	nfetch  int
+}
+
+func (mock *secretProviderMock) FetchSecret(path string) (string, error) {
+	mock.nfetch++
+	return mock.secrets[path], nil
+}
+
+func TestSecretProviderFile(t *testing.T) {
+	var (
+		file = filepath.Join(t.TempDir(), `secret`)
+
+		secret string
+		err    error
+	)
+
+	err = os.WriteFile(file, []byte("s3cret\n"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	secret, err = secretProviderFile{}.FetchSecret(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `FetchSecret`, `s3cret`, secret)
+}
+
+func TestSecretProviderVault(t *testing.T) {
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if req.Header.Get(`X-Vault-Token`) != `t0ken` {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch req.URL.Path {
+		case `/v1/kv/karajo`:
+			_, _ = w.Write([]byte(`{"data":{"value":"v1-value","webhook":"v1-webhook"}}`))
+		case `/v1/secret/data/karajo`:
+			_, _ = w.Write([]byte(`{"data":{"data":{"webhook":"v2-webhook"}}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+
+	t.Setenv(envVaultAddr, srv.URL)
+	t.Setenv(envVaultToken, `t0ken`)
+
+	type testCase struct {
+		path     string
+		exp      string
+		expError string
+	}
+
+	var cases = []testCase{{
+		path: `kv/karajo`,
+		exp:  `v1-value`,
+	}, {
+		path: `kv/karajo#webhook`,
+		exp:  `v1-webhook`,
+	}, {
+		path: `secret/data/karajo#webhook`,
+		exp:  `v2-webhook`,
+	}, {
+		path:     `secret/data/karajo#notexist`,
+		expError: `FetchSecret: secret/data/karajo: key "notexist" not found`,
+	}, {
+		path:     `kv/notexist`,
+		expError: `FetchSecret: kv/notexist: 404 Not Found`,
+	}}
+
+	var (
+		provider secretProviderVault
+
+		c      testCase
+		secret string
+		err    error
+	)
+	for _, c = range cases {
+		secret, err = provider.FetchSecret(c.path)
+		if err != nil {
+			test.Assert(t, c.path+`: error`, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.path, c.exp, secret)
+	}
+}
+
+func TestSecretValue(t *testing.T) {
+	var (
+		mock = &secretProviderMock{
+			secrets: map[string]string{
+				`webhook`: `s3cret`,
+			},
+		}
+		providers = map[string]SecretProvider{
+			`mock`: mock,
+		}
+
+		sv     *secretValue
+		secret []byte
+		err    error
+	)
+
+	sv = newSecretValue(`plain:secret`, providers)
+	test.Assert(t, `unknown scheme is not external`, false, sv.isExternal())
+	secret, _ = sv.get()
+	test.Assert(t, `unknown scheme`, `plain:secret`, string(secret))
+
+	sv = newSecretValue(`mock:webhook`, providers)
+	test.Assert(t, `isExternal`, true, sv.isExternal())
+
+	secret, err = sv.get()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = sv.get()
+	test.Assert(t, `get`, `s3cret`, string(secret))
+	test.Assert(t, `get is cached`, 1, mock.nfetch)
+
+	var changed bool
+
+	changed, _ = sv.refresh()
+	test.Assert(t, `refresh not changed`, false, changed)
+
+	mock.secrets[`webhook`] = `n3w`
+	changed, _ = sv.refresh()
+	test.Assert(t, `refresh before interval`, false, changed)
+	test.Assert(t, `refresh before interval nfetch`, 2, mock.nfetch)
+
+	sv.lastRefresh = sv.lastRefresh.Add(-defSecretRefreshInterval)
+	changed, _ = sv.refresh()
+	test.Assert(t, `refresh changed`, true, changed)
+
+	mock.secrets[`webhook`] = `n3wer`
+	sv.invalidate()
+	secret, _ = sv.get()
+	test.Assert(t, `get after invalidate`, `n3wer`, string(secret))
+	test.Assert(t, `nfetch`, 4, mock.nfetch)
+}
+
+func TestJobExec_authorize_secretRotated(t *testing.T) {
+	var (
+		mock = &secretProviderMock{
+			secrets: map[string]string{
+				`webhook`: `old`,
+			},
+		}
+		env = &Env{}
+	)
+
+	env.RegisterSecretProvider(`mock`, mock)
+
+	var job = &JobExec{
+		Secret:     `mock:webhook`,
+		HeaderSign: HeaderNameXKarajoSign,
+	}
+	job.secret = env.newSecretValue(job.Secret)
+
+	// Rotate the secret after its cached.
+	mock.secrets[`webhook`] = `new`
+
+	var (
+		payload = []byte(`{}`)
+		headers = http.Header{
+			HeaderNameXKarajoSign: []string{Sign(payload, []byte(`new`))},
+		}
+		err error
+	)
+
+	err = job.authorize(headers, payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `nfetch`, 2, mock.nfetch)
+}
@@ -3,34 +3,192 @@
 
 package karajo
 
-import "git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/ascii"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/mlog"
+)
+
+const (
+	defSessionFile = `sessions`
+	defSessionTTL  = 24 * time.Hour
+
+	// defSessionSweepInterval the interval to remove the expired
+	// sessions.
+	defSessionSweepInterval = 10 * time.Minute
+)
+
+// session contains the authenticated user and the time when the session
+// expired.
+type session struct {
+	user *User
+
+	// ExpiredAt the time when session is no longer valid.
+	ExpiredAt time.Time `json:"expired_at"`
+
+	// UserName the name of user, used to map the session to User when
+	// loaded from file.
+	UserName string `json:"user"`
+
+	// CSRF the token that must be sent by WUI in the HTTP header
+	// "X-Karajo-CSRF" on state-changing request.
+	CSRF string `json:"csrf"`
+
+	// Groups the OpenID Connect groups of user, used to check the
+	// allowed groups when the session loaded from file.
+	Groups []string `json:"groups,omitempty"`
+
+	// IsOIDC true if the user is authenticated using OpenID Connect,
+	// where the user does not exist in user.conf.
+	IsOIDC bool `json:"oidc,omitempty"`
+}
 
 // sessionManager manage the active session that map to authenticated user.
 type sessionManager struct {
-	value map[string]*User
+	// value contains the session indexed by hash of session key, so
+	// the session key is not stored in the file.
+	value map[string]*session
+
+	// stopq stop the sweeper.
+	stopq chan struct{}
+
+	// file where the sessions stored, so user does not need to login
+	// again after karajo restarted.
+	// If its empty, the session only stored in memory.
+	file string
+
+	// ttl define how long the session is valid since its created.
+	ttl time.Duration
+
+	sync.Mutex
 }
 
 // newSessionManager create new session manager.
 func newSessionManager() (sm *sessionManager) {
 	sm = &sessionManager{
-		value: make(map[string]*User),
+		value: make(map[string]*session),
+		stopq: make(chan struct{}, 1),
+		ttl:   defSessionTTL,
 	}
 	return sm
 }
 
+// sessionKeyHash return the hex encoded SHA-256 of session key.
+func sessionKeyHash(key string) string {
+	var sum = sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// load the sessions from file.
+// Session that has been expired or its user does not exist in users is
+// ignored.
+// The session from OpenID Connect is loaded only if oidc is not nil and
+// the user groups is still allowed.
+func (sm *sessionManager) load(file string, users map[string]*User, oidc *AuthOIDC) (err error) {
+	var (
+		logp = `load`
+
+		raw []byte
+	)
+
+	sm.Lock()
+	defer sm.Unlock()
+
+	sm.file = file
+
+	raw, err = os.ReadFile(file)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf(`%s: %w`, logp, err)
+	}
+
+	var stored = map[string]*session{}
+
+	err = json.Unmarshal(raw, &stored)
+	if err != nil {
+		return fmt.Errorf(`%s: %s: %w`, logp, file, err)
+	}
+
+	var (
+		now = timeNow()
+
+		key  string
+		sess *session
+	)
+	for key, sess = range stored {
+		if !sess.ExpiredAt.After(now) {
+			continue
+		}
+		if sess.IsOIDC {
+			if oidc == nil || !oidc.isAllowed(sess.UserName, sess.Groups) {
+				continue
+			}
+			sess.user = &User{
+				Name:   sess.UserName,
+				groups: sess.Groups,
+				isOIDC: true,
+			}
+		} else {
+			sess.user = users[sess.UserName]
+		}
+		if sess.user == nil {
+			continue
+		}
+		if len(sess.CSRF) == 0 {
+			// Session stored by older version.
+			sess.CSRF = newCSRFToken()
+		}
+		if len(key) != sha256.Size*2 {
+			// Session key stored by older version.
+			key = sessionKeyHash(key)
+		}
+		sm.value[key] = sess
+	}
+	return nil
+}
+
 // new create new session for user u.
 func (sm *sessionManager) new(u *User) (key string) {
 	var (
+		now = timeNow()
+
 		sessb []byte
+		hash  string
 		n     int
 		ok    bool
 	)
+
+	sm.Lock()
+	defer sm.Unlock()
+
+	sm.sweep(now)
+
 	for n < 5 {
 		sessb = ascii.Random([]byte(ascii.LettersNumber), 32)
 		key = string(sessb)
-		_, ok = sm.value[key]
+		hash = sessionKeyHash(key)
+		_, ok = sm.value[hash]
 		if !ok {
-			sm.value[key] = u
+			sm.value[hash] = &session{
+				user:      u,
+				UserName:  u.Name,
+				CSRF:      newCSRFToken(),
+				Groups:    u.groups,
+				IsOIDC:    u.isOIDC,
+				ExpiredAt: now.Add(sm.ttl),
+			}
+			sm.save()
 			return key
 		}
 		n++
@@ -40,13 +198,125 @@ func (sm *sessionManager) new(u *User) (key string) {
 }
 
 // get the user related to session key.
-// It will return nil if user is not exist.
+// It will return nil if user is not exist or the session has been
+// expired.
 func (sm *sessionManager) get(key string) (u *User) {
-	u = sm.value[key]
-	return u
+	var hash = sessionKeyHash(key)
+
+	sm.Lock()
+	defer sm.Unlock()
+
+	var sess = sm.value[hash]
+	if sess == nil {
+		return nil
+	}
+	if !sess.ExpiredAt.After(timeNow()) {
+		delete(sm.value, hash)
+		return nil
+	}
+	return sess.user
+}
+
+// csrf return the CSRF token for session key.
+// It will return empty string if the session is not exist or has been
+// expired.
+func (sm *sessionManager) csrf(key string) string {
+	var hash = sessionKeyHash(key)
+
+	sm.Lock()
+	defer sm.Unlock()
+
+	var sess = sm.value[hash]
+	if sess == nil || !sess.ExpiredAt.After(timeNow()) {
+		return ``
+	}
+	return sess.CSRF
 }
 
 // delete the session from storage.
 func (sm *sessionManager) delete(key string) {
-	delete(sm.value, key)
+	var hash = sessionKeyHash(key)
+
+	sm.Lock()
+	defer sm.Unlock()
+
+	var _, ok = sm.value[hash]
+	if !ok {
+		return
+	}
+	delete(sm.value, hash)
+	sm.save()
+}
+
+// runSweeper remove the expired sessions periodically until
+// stopSweeper called.
+func (sm *sessionManager) runSweeper(interval time.Duration) {
+	var ticker = time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			sm.Lock()
+			if sm.sweep(timeNow()) {
+				sm.save()
+			}
+			sm.Unlock()
+
+		case <-sm.stopq:
+			return
+		}
+	}
+}
+
+// stopSweeper stop the runSweeper.
+func (sm *sessionManager) stopSweeper() {
+	select {
+	case sm.stopq <- struct{}{}:
+	default:
+	}
+}
+
+// sweep remove the expired sessions.
+// It return true if one or more sessions has been removed.
+// The caller must hold the lock.
+func (sm *sessionManager) sweep(now time.Time) (isRemoved bool) {
+	var (
+		key  string
+		sess *session
+	)
+	for key, sess = range sm.value {
+		if !sess.ExpiredAt.After(now) {
+			delete(sm.value, key)
+			isRemoved = true
+		}
+	}
+	return isRemoved
+}
+
+// save the sessions into file.
+// The caller must hold the lock.
+// Any error is logged, since the sessions are still valid in memory.
+func (sm *sessionManager) save() {
+	if len(sm.file) == 0 {
+		return
+	}
+
+	var (
+		logp = `sessionManager.save`
+
+		raw []byte
+		err error
+	)
+
+	raw, err = json.Marshal(sm.value)
+	if err != nil {
+		mlog.Errf(`%s: %s`, logp, err)
+		return
+	}
+
+	err = os.WriteFile(sm.file, raw, 0600)
+	if err != nil {
+		mlog.Errf(`%s: %s`, logp, err)
+	}
 }
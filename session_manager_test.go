@@ -4,7 +4,11 @@
 package karajo
 
 import (
+	"bytes"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
 )
@@ -36,4 +40,160 @@ func TestSessionManager(t *testing.T) {
 	key = sm.new(expUser)
 
 	test.Assert(t, `sessionManager.new:`, 32, len(key))
+	test.Assert(t, `sessionManager.csrf:`, 32, len(sm.csrf(key)))
+
+	// Test expired session.
+
+	sm.value[sessionKeyHash(key)].ExpiredAt = timeNow()
+	gotUser = sm.get(key)
+	test.Assert(t, `sessionManager.get: expired`, nilUser, gotUser)
+}
+
+func TestSessionManager_load(t *testing.T) {
+	var (
+		file  = filepath.Join(t.TempDir(), defSessionFile)
+		user  = &User{Name: `test`}
+		users = map[string]*User{
+			user.Name: user,
+		}
+
+		sm  = newSessionManager()
+		key string
+		err error
+	)
+
+	err = sm.load(file, users, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key = sm.new(user)
+
+	var (
+		keyExpired = sm.new(user)
+		keyRemoved = sm.new(&User{Name: `removed`})
+	)
+	sm.value[sessionKeyHash(keyExpired)].ExpiredAt = timeNow().Add(-time.Second)
+	sm.save()
+
+	var raw []byte
+
+	raw, err = os.ReadFile(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `file contains session key`, false,
+		bytes.Contains(raw, []byte(key)))
+
+	// Load the sessions into new manager, like after restart.
+	var smNew = newSessionManager()
+
+	err = smNew.load(file, users, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	test.Assert(t, `loaded session`, user, smNew.get(key))
+	test.Assert(t, `loaded csrf`, sm.csrf(key), smNew.csrf(key))
+	test.Assert(t, `expired session`, 1, len(smNew.value))
+
+	var nilUser *User
+	test.Assert(t, `removed user`, nilUser, smNew.get(keyRemoved))
+
+	smNew.delete(key)
+
+	var smEmpty = newSessionManager()
+
+	err = smEmpty.load(file, users, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `after delete`, 0, len(smEmpty.value))
+}
+
+func TestSessionManager_load_oidc(t *testing.T) {
+	var (
+		file = filepath.Join(t.TempDir(), defSessionFile)
+		sm   = newSessionManager()
+		oidc = &AuthOIDC{
+			AllowedGroups: []string{`ops`},
+		}
+
+		err error
+	)
+
+	err = sm.load(file, nil, oidc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		keyOps = sm.new(&User{
+			Name:   `alice`,
+			groups: []string{`ops`},
+			isOIDC: true,
+		})
+		keyDev = sm.new(&User{
+			Name:   `bob`,
+			groups: []string{`dev`},
+			isOIDC: true,
+		})
+	)
+
+	// The allowed groups changed after restart.
+	oidc.AllowedGroups = []string{`dev`}
+
+	var smNew = newSessionManager()
+
+	err = smNew.load(file, nil, oidc)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var nilUser *User
+	test.Assert(t, `not allowed anymore`, nilUser, smNew.get(keyOps))
+	test.Assert(t, `allowed`, `bob`, smNew.get(keyDev).Name)
+
+	var smNoOIDC = newSessionManager()
+
+	err = smNoOIDC.load(file, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `without OIDC`, 0, len(smNoOIDC.value))
+}
+
+func TestSessionManager_load_plainKey(t *testing.T) {
+	var (
+		file = filepath.Join(t.TempDir(), defSessionFile)
+		user = &User{Name: `test`}
+		raw  = `{"plainkey":{"expired_at":"2023-01-10T00:00:00Z","user":"test","csrf":"token"}}`
+		sm   = newSessionManager()
+	)
+
+	var err = os.WriteFile(file, []byte(raw), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = sm.load(file, map[string]*User{user.Name: user}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	test.Assert(t, `session stored by older version`, user, sm.get(`plainkey`))
+}
+
+func TestSessionManager_sweep(t *testing.T) {
+	var (
+		sm   = newSessionManager()
+		user = &User{Name: `test`}
+		key  = sm.new(user)
+		now  = timeNow()
+	)
+
+	test.Assert(t, `sweep nothing`, false, sm.sweep(now))
+
+	sm.value[sessionKeyHash(key)].ExpiredAt = now
+	test.Assert(t, `sweep expired`, true, sm.sweep(now))
+	test.Assert(t, `after sweep`, 0, len(sm.value))
 }
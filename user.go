@@ -4,19 +4,50 @@
 package karajo
 
 import (
+	"encoding/base32"
 	"fmt"
+	"net/url"
 	"os"
+	"strings"
+	"sync"
+	"time"
 
 	"git.sr.ht/~shulhan/pakakeh.go/lib/ini"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/totp"
 	"golang.org/x/crypto/bcrypt"
 )
 
+// totpEncoding the encoding of TOTP secret, the base32 without padding as
+// used by most authenticator applications.
+var totpEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
 // User represent the account that can access Karajo user interface using
 // name and password.
 // The Password field store the bcrypt hash of plain password.
 type User struct {
 	Name     string
 	Password string `ini:"::password"`
+
+	// TOTPSecret define the base32 encoded secret for time-based one
+	// time password (TOTP).
+	// If its set, the user must input the 6 digits code from
+	// authenticator application on login.
+	TOTPSecret string `ini:"::totp_secret"`
+
+	// totpKey the decoded TOTPSecret.
+	totpKey []byte
+
+	// totpLastStep the time step of the last accepted TOTP code.
+	// The code at or before this step is rejected, so the same code
+	// cannot be used twice.
+	totpLastStep int64
+	totpLock     sync.Mutex
+
+	// groups the OpenID Connect groups of user.
+	groups []string
+
+	// isOIDC true if the user is authenticated using OpenID Connect.
+	isOIDC bool
 }
 
 // loadUsers load user from file, return the map with user's name as key.
@@ -68,3 +99,73 @@ func (u *User) authenticate(plain string) bool {
 	var err = bcrypt.CompareHashAndPassword([]byte(u.Password), []byte(plain))
 	return err == nil
 }
+
+// initTOTP decode the TOTPSecret.
+func (u *User) initTOTP() (err error) {
+	var secret = strings.ToUpper(strings.TrimSpace(u.TOTPSecret))
+	if len(secret) == 0 {
+		return nil
+	}
+	secret = strings.ReplaceAll(secret, ` `, ``)
+	secret = strings.TrimRight(secret, `=`)
+
+	u.totpKey, err = totpEncoding.DecodeString(secret)
+	if err != nil {
+		return fmt.Errorf(`user %q: invalid totp_secret: %w`, u.Name, err)
+	}
+	u.TOTPSecret = secret
+	return nil
+}
+
+// verifyTOTP return true if the code match with TOTP generated from
+// user's secret at the current, previous, or next time step, to allow
+// small clock drift between server and authenticator.
+// The code at or before the time step of the last accepted code is
+// rejected, to prevent the same code used twice.
+func (u *User) verifyTOTP(code string) bool {
+	var (
+		proto = totp.New(totp.DefHash, totp.DefCodeDigits, totp.DefTimeStep)
+		ts    = timeNow().Add(totp.DefTimeStep * time.Second)
+
+		// step the time step of the first code in listOTP.
+		step = ts.Unix() / totp.DefTimeStep
+
+		listOTP []string
+		otp     string
+		x       int
+		err     error
+	)
+
+	listOTP, err = proto.GenerateNWithTime(ts, u.totpKey, 3)
+	if err != nil {
+		return false
+	}
+
+	u.totpLock.Lock()
+	defer u.totpLock.Unlock()
+
+	code = strings.TrimSpace(code)
+	for x, otp = range listOTP {
+		if otp != code {
+			continue
+		}
+		if step-int64(x) <= u.totpLastStep {
+			return false
+		}
+		u.totpLastStep = step - int64(x)
+		return true
+	}
+	return false
+}
+
+// totpURI return the key URI format for provisioning the TOTP secret in
+// authenticator application.
+func (u *User) totpURI(issuer string) string {
+	var (
+		label = url.PathEscape(issuer) + `:` + url.PathEscape(u.Name)
+		q     = url.Values{}
+	)
+	q.Set(`secret`, u.TOTPSecret)
+	q.Set(`issuer`, issuer)
+	return `otpauth://totp/` + label + `?` + q.Encode()
+}
@@ -5,8 +5,10 @@ package karajo
 
 import (
 	"testing"
+	"time"
 
 	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/totp"
 )
 
 func TestLoadUsers(t *testing.T) {
@@ -44,3 +46,109 @@ func TestUser_authenticate(t *testing.T) {
 	got = u.authenticate(`s3cret`)
 	test.Assert(t, `authenticate: valid`, true, got)
 }
+
+func TestUser_initTOTP(t *testing.T) {
+	type testCase struct {
+		secret    string
+		expSecret string
+		expError  string
+	}
+
+	var cases = []testCase{{
+		secret: ``,
+	}, {
+		secret:    `jbsw y3dp ehpk 3pxp`,
+		expSecret: `JBSWY3DPEHPK3PXP`,
+	}, {
+		secret:    `JBSWY3DPEHPK3PXP====`,
+		expSecret: `JBSWY3DPEHPK3PXP`,
+	}, {
+		secret:   `1234`,
+		expError: `user "test": invalid totp_secret: illegal base32 data at input byte 0`,
+	}}
+
+	var (
+		c   testCase
+		u   *User
+		err error
+	)
+	for _, c = range cases {
+		u = &User{
+			Name:       `test`,
+			TOTPSecret: c.secret,
+		}
+		err = u.initTOTP()
+		if err != nil {
+			test.Assert(t, c.secret, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.secret, c.expSecret, u.TOTPSecret)
+		test.Assert(t, c.secret+`: key`, len(c.expSecret) != 0, len(u.totpKey) != 0)
+	}
+}
+
+func TestUser_verifyTOTP(t *testing.T) {
+	var u = &User{
+		Name:       `test`,
+		TOTPSecret: `JBSWY3DPEHPK3PXP`,
+	}
+
+	var err = u.initTOTP()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		proto = totp.New(totp.DefHash, totp.DefCodeDigits, totp.DefTimeStep)
+		now   = timeNow()
+
+		code string
+	)
+
+	type testCase struct {
+		desc string
+		ts   time.Time
+		exp  bool
+	}
+
+	var cases = []testCase{{
+		desc: `expired`,
+		ts:   now.Add(-90 * time.Second),
+	}, {
+		desc: `previous step`,
+		ts:   now.Add(-30 * time.Second),
+		exp:  true,
+	}, {
+		desc: `previous step reused`,
+		ts:   now.Add(-30 * time.Second),
+	}, {
+		desc: `current`,
+		ts:   now,
+		exp:  true,
+	}, {
+		desc: `next step`,
+		ts:   now.Add(30 * time.Second),
+		exp:  true,
+	}, {
+		desc: `current after next step`,
+		ts:   now,
+	}}
+
+	var c testCase
+	for _, c = range cases {
+		code, err = proto.GenerateWithTime(c.ts, u.totpKey)
+		if err != nil {
+			t.Fatal(err)
+		}
+		test.Assert(t, c.desc, c.exp, u.verifyTOTP(code))
+	}
+}
+
+func TestUser_totpURI(t *testing.T) {
+	var u = &User{
+		Name:       `john doe`,
+		TOTPSecret: `JBSWY3DPEHPK3PXP`,
+	}
+	var exp = `otpauth://totp/My%20karajo:john%20doe?issuer=My+karajo&secret=JBSWY3DPEHPK3PXP`
+	test.Assert(t, `totpURI`, exp, u.totpURI(`My karajo`))
+}
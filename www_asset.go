@@ -0,0 +1,134 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+)
+
+// List of Cache-Control header values for WUI.
+const (
+	// cacheControlImmutable is used for versioned asset, where its URL
+	// changes everytime its content changes.
+	cacheControlImmutable = `public, max-age=31536000, immutable`
+
+	// cacheControlNoCache force the browser to revalidate the
+	// content on each request.
+	cacheControlNoCache = `no-cache`
+)
+
+// paramNameVersion the query parameter that contains the asset version.
+const paramNameVersion = `v`
+
+// pathKarajo the path prefix of WUI.
+const pathKarajo = `/karajo/`
+
+// initAssetVersion compute the version of each WUI asset, from the hash of
+// its content, and rewrite the asset URLs in the HTML files to include
+// the version as query parameter.
+//
+// In development mode the content of files are read directly from disk,
+// so the assets are not versioned.
+//
+// Only the asset URLs inside the HTML files are versioned.
+// The URL of module imported from JavaScript files, using "import", is
+// not versioned, so the browser may use the cached module after upgrade.
+// Currently, the WUI does not use JavaScript module.
+func (k *Karajo) initAssetVersion(mfs *memfs.MemFS) {
+	k.assetVersion = make(map[string]string)
+
+	if k.env.IsDevelopment || mfs.PathNodes == nil {
+		return
+	}
+
+	var (
+		nodes = mfs.PathNodes.Nodes()
+
+		node *memfs.Node
+		hash [sha256.Size]byte
+	)
+	for _, node = range nodes {
+		if !isAsset(node) {
+			continue
+		}
+		hash = sha256.Sum256(node.Content)
+		k.assetVersion[node.Path] = hex.EncodeToString(hash[:8])
+	}
+
+	for _, node = range nodes {
+		if node.IsDir() || !isHTML(node) ||
+			!strings.HasPrefix(node.Path, pathKarajo) {
+			continue
+		}
+		k.versionAssetURL(node)
+	}
+}
+
+// versionAssetURL replace each asset URL, prefixed with BasePath, inside
+// the HTML node with its versioned URL.
+// The URL that has been versioned is not replaced again, since it does not
+// end with double quote.
+func (k *Karajo) versionAssetURL(node *memfs.Node) {
+	var (
+		content = node.Content
+
+		assetURL  string
+		assetPath string
+		version   string
+	)
+	for assetPath, version = range k.assetVersion {
+		assetURL = k.urlPath(assetPath)
+		content = bytes.ReplaceAll(content,
+			[]byte(`"`+assetURL+`"`),
+			[]byte(`"`+assetURL+`?`+paramNameVersion+`=`+version+`"`))
+	}
+	node.Content = content
+	node.SetSize(int64(len(content)))
+}
+
+// setCacheControl set the Cache-Control header for the WUI file.
+// The versioned asset is cached for one year, while the HTML and other
+// files must be revalidated on each request.
+// In development mode the cache is disabled, so the changes on files are
+// always loaded.
+// The files outside of WUI, in the DirPublic, are not modified.
+func (k *Karajo) setCacheControl(w http.ResponseWriter, req *http.Request, node *memfs.Node) {
+	if !strings.HasPrefix(node.Path, pathKarajo) {
+		return
+	}
+
+	var (
+		version = req.URL.Query().Get(paramNameVersion)
+		hdr     = w.Header()
+	)
+	if k.env.IsDevelopment {
+		hdr.Set(`Cache-Control`, cacheControlNoStore)
+		return
+	}
+	if len(version) != 0 && version == k.assetVersion[node.Path] {
+		hdr.Set(`Cache-Control`, cacheControlImmutable)
+		return
+	}
+	hdr.Set(`Cache-Control`, cacheControlNoCache)
+}
+
+// isAsset return true if the node is a file that can be versioned, which is
+// any WUI file except HTML.
+func isAsset(node *memfs.Node) bool {
+	if node.IsDir() || isHTML(node) {
+		return false
+	}
+	return strings.HasPrefix(node.Path, pathKarajo)
+}
+
+// isHTML return true if the node content type is HTML.
+func isHTML(node *memfs.Node) bool {
+	return strings.HasPrefix(node.ContentType, `text/html`)
+}
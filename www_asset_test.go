@@ -0,0 +1,90 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_initAssetVersion(t *testing.T) {
+	var (
+		opts = memfs.Options{
+			Root:     `_www`,
+			Includes: []string{`.*\.(html|js)$`},
+		}
+
+		mfs *memfs.MemFS
+		err error
+	)
+
+	mfs, err = memfs.New(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var k = &Karajo{
+		env: &Env{},
+	}
+
+	k.initAssetVersion(mfs)
+
+	var version = k.assetVersion[`/karajo/app/index.js`]
+	test.Assert(t, `version length`, 16, len(version))
+
+	var (
+		nodeHTML = mfs.MustGet(`/karajo/app/index.html`)
+		expURL   = []byte(`"/karajo/app/index.js?v=` + version + `"`)
+	)
+	test.Assert(t, `versioned URL`, true, bytes.Contains(nodeHTML.Content, expURL))
+	test.Assert(t, `size`, int64(len(nodeHTML.Content)), nodeHTML.Size())
+
+	// Calling it twice should not version the URL twice.
+	k.initAssetVersion(mfs)
+	test.Assert(t, `versioned once`, 1, bytes.Count(nodeHTML.Content, expURL))
+	test.Assert(t, `no double version`, false,
+		bytes.Contains(nodeHTML.Content, []byte(`?v=`+version+`?v=`)))
+
+	type testCase struct {
+		path string
+		url  string
+		exp  string
+	}
+
+	var cases = []testCase{{
+		path: `/karajo/app/index.js`,
+		url:  `/karajo/app/index.js?v=` + version,
+		exp:  cacheControlImmutable,
+	}, {
+		path: `/karajo/app/index.js`,
+		url:  `/karajo/app/index.js?v=outdated`,
+		exp:  cacheControlNoCache,
+	}, {
+		path: `/karajo/app/index.js`,
+		url:  `/karajo/app/index.js`,
+		exp:  cacheControlNoCache,
+	}, {
+		path: `/karajo/app/index.html`,
+		url:  `/karajo/app/`,
+		exp:  cacheControlNoCache,
+	}}
+
+	var (
+		c    testCase
+		node *memfs.Node
+		rec  *httptest.ResponseRecorder
+	)
+	for _, c = range cases {
+		node = mfs.MustGet(c.path)
+		rec = httptest.NewRecorder()
+
+		k.setCacheControl(rec, httptest.NewRequest(`GET`, c.url, nil), node)
+
+		test.Assert(t, c.url, c.exp, rec.Header().Get(`Cache-Control`))
+	}
+}
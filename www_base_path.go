@@ -0,0 +1,115 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"path"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+)
+
+// initBasePath normalize the BasePath into "/<path>" without trailing
+// slash, or empty if its not set.
+func (env *Env) initBasePath() (err error) {
+	var basePath = strings.TrimSpace(env.BasePath)
+	if len(basePath) == 0 {
+		env.BasePath = ``
+		return nil
+	}
+	if strings.ContainsAny(basePath, "\"'`?#\\ ") {
+		return fmt.Errorf(`initBasePath: invalid base_path %q`, env.BasePath)
+	}
+
+	basePath = path.Clean(`/` + basePath)
+	if basePath == `/` {
+		basePath = ``
+	}
+	env.BasePath = basePath
+	return nil
+}
+
+// initBasePath prefix each WUI URL inside the HTML, JavaScript, and CSS
+// files with the BasePath.
+// Only URL that start with quote or backtick are replaced, so the URL to
+// other host, like peer, is not changed.
+//
+// In development mode the content of files are read directly from disk,
+// so the URL are not prefixed.
+func (k *Karajo) initBasePath(mfs *memfs.MemFS) {
+	if len(k.env.BasePath) == 0 || k.env.IsDevelopment || mfs.PathNodes == nil {
+		return
+	}
+
+	var (
+		nodes = mfs.PathNodes.Nodes()
+
+		node *memfs.Node
+	)
+	for _, node = range nodes {
+		if node.IsDir() || !strings.HasPrefix(node.Path, pathKarajo) {
+			continue
+		}
+		if !isHTML(node) && !isScriptOrStyle(node) {
+			continue
+		}
+		node.Content = prefixWUIPath(node.Content, k.env.BasePath)
+		node.SetSize(int64(len(node.Content)))
+	}
+}
+
+// prefixWUIPath replace the WUI path "/karajo/" inside the content with
+// "<basePath>/karajo/".
+func prefixWUIPath(content []byte, basePath string) []byte {
+	var quote string
+	for _, quote = range []string{`"`, `'`, "`"} {
+		content = bytes.ReplaceAll(content,
+			[]byte(quote+pathKarajo),
+			[]byte(quote+basePath+pathKarajo))
+	}
+	return content
+}
+
+// isScriptOrStyle return true if the node is JavaScript or CSS file.
+func isScriptOrStyle(node *memfs.Node) bool {
+	return strings.HasSuffix(node.Path, `.js`) ||
+		strings.HasSuffix(node.Path, `.css`)
+}
+
+// stripBasePath wrap the HTTP handler to remove the BasePath from the
+// request path, so the next handler can use the path without prefix.
+// The request without BasePath is passed as is, in case the reverse proxy
+// already remove the prefix.
+func (k *Karajo) stripBasePath(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var basePath = k.env.BasePath
+		if len(basePath) == 0 {
+			next.ServeHTTP(w, req)
+			return
+		}
+		if req.URL.Path == basePath {
+			http.Redirect(w, req, basePath+pathKarajo, http.StatusFound)
+			return
+		}
+		if strings.HasPrefix(req.URL.Path, basePath+`/`) {
+			req.URL.Path = strings.TrimPrefix(req.URL.Path, basePath)
+			req.URL.RawPath = strings.TrimPrefix(req.URL.RawPath, basePath)
+		}
+		next.ServeHTTP(w, req)
+	})
+}
+
+// urlPath return the public URL path of WUI or API path p, prefixed
+// with BasePath.
+func (k *Karajo) urlPath(p string) string {
+	return k.env.BasePath + p
+}
+
+// cookiePath return the path for session cookies.
+func (k *Karajo) cookiePath() string {
+	return k.env.BasePath + `/`
+}
@@ -0,0 +1,151 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_initBasePath(t *testing.T) {
+	type testCase struct {
+		basePath string
+		exp      string
+		expError string
+	}
+
+	var cases = []testCase{{
+		basePath: ``,
+		exp:      ``,
+	}, {
+		basePath: `/`,
+		exp:      ``,
+	}, {
+		basePath: `ops/scheduler/`,
+		exp:      `/ops/scheduler`,
+	}, {
+		basePath: ` /ops//scheduler `,
+		exp:      `/ops/scheduler`,
+	}, {
+		basePath: `/ops"`,
+		expError: `initBasePath: invalid base_path "/ops\""`,
+	}}
+
+	var (
+		env = &Env{}
+
+		c   testCase
+		err error
+	)
+	for _, c = range cases {
+		env.BasePath = c.basePath
+		err = env.initBasePath()
+		if err != nil {
+			test.Assert(t, c.basePath, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.basePath, c.exp, env.BasePath)
+	}
+}
+
+func TestKarajo_initBasePath(t *testing.T) {
+	var (
+		opts = memfs.Options{
+			Root:     `_www`,
+			Includes: []string{`.*\.(html|js)$`},
+		}
+
+		mfs *memfs.MemFS
+		err error
+	)
+
+	mfs, err = memfs.New(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var k = &Karajo{
+		env: &Env{
+			BasePath: `/ops`,
+		},
+	}
+
+	k.initBasePath(mfs)
+	k.initAssetVersion(mfs)
+
+	var (
+		version  = k.assetVersion[`/karajo/app/index.js`]
+		nodeHTML = mfs.MustGet(`/karajo/app/index.html`)
+		nodeJS   = mfs.MustGet(`/karajo/app/index.js`)
+		expURL   = []byte(`"/ops/karajo/app/index.js?v=` + version + `"`)
+	)
+	test.Assert(t, `versioned URL`, true, bytes.Contains(nodeHTML.Content, expURL))
+	test.Assert(t, `HTML without prefix`, false,
+		bytes.Contains(nodeHTML.Content, []byte(`"/karajo/`)))
+	test.Assert(t, `JS without prefix`, false,
+		bytes.Contains(nodeJS.Content, []byte(`"/karajo/`)))
+	test.Assert(t, `JS peer URL`, true,
+		bytes.Contains(nodeJS.Content, []byte(`${peer.url}/karajo/`)))
+	test.Assert(t, `size`, int64(len(nodeJS.Content)), nodeJS.Size())
+}
+
+func TestKarajo_stripBasePath(t *testing.T) {
+	var (
+		k = &Karajo{
+			env: &Env{
+				BasePath: `/ops`,
+			},
+		}
+
+		gotPath string
+		handler = k.stripBasePath(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+			gotPath = req.URL.Path
+		}))
+	)
+
+	type testCase struct {
+		url         string
+		expPath     string
+		expLocation string
+		expCode     int
+	}
+
+	var cases = []testCase{{
+		url:     `/ops/karajo/api/environment`,
+		expPath: `/karajo/api/environment`,
+		expCode: http.StatusOK,
+	}, {
+		url:     `/karajo/api/environment`,
+		expPath: `/karajo/api/environment`,
+		expCode: http.StatusOK,
+	}, {
+		url:     `/opsx/karajo/`,
+		expPath: `/opsx/karajo/`,
+		expCode: http.StatusOK,
+	}, {
+		url:         `/ops`,
+		expLocation: `/ops/karajo/`,
+		expCode:     http.StatusFound,
+	}}
+
+	var (
+		c   testCase
+		rec *httptest.ResponseRecorder
+	)
+	for _, c = range cases {
+		gotPath = ``
+		rec = httptest.NewRecorder()
+
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, c.url, nil))
+
+		test.Assert(t, c.url+`: code`, c.expCode, rec.Code)
+		test.Assert(t, c.url+`: path`, c.expPath, gotPath)
+		test.Assert(t, c.url+`: Location`, c.expLocation, rec.Header().Get(`Location`))
+	}
+}
@@ -0,0 +1,136 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"time"
+
+	libhttp "git.sr.ht/~shulhan/pakakeh.go/lib/http"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+)
+
+// pathDevJS the script that listen to apiDevReload, injected into each
+// WUI HTML file in development mode.
+const pathDevJS = `/karajo/dev.js`
+
+// sseEventReload the event sent by apiDevReload to reload the page.
+const sseEventReload = `reload`
+
+// defDevReloadInterval the interval to check the changes on WUI files.
+const defDevReloadInterval = time.Second
+
+// cacheControlNoStore disable the browser cache, used for WUI files in
+// development mode.
+const cacheControlNoStore = `no-store`
+
+// apiDevReload send the event "reload" once the files under the WUI root
+// directory, "_www", changes, or the server restarted.
+//
+// On connected, it send the current state of files and server as event
+// ID, so the browser send it back as "Last-Event-ID" header when
+// reconnecting.
+// If the Last-Event-ID is different with the current state, for example
+// after the server restarted, the event "reload" is sent immediately.
+func (k *Karajo) apiDevReload(sse *libhttp.SSEConn) {
+	var (
+		state  = k.devState()
+		lastID = sse.HTTPRequest.Header.Get(`Last-Event-ID`)
+		err    error
+	)
+	if len(lastID) != 0 && lastID != state {
+		_ = sse.WriteEvent(sseEventReload, state, &state)
+		return
+	}
+
+	err = sse.WriteRaw([]byte(`id:` + state + "\n\n"))
+	if err != nil {
+		return
+	}
+
+	var (
+		ticker   = time.NewTicker(defDevReloadInterval)
+		lastSend = time.Now()
+		current  string
+	)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		current = k.devState()
+		if current != state {
+			_ = sse.WriteEvent(sseEventReload, current, &current)
+			return
+		}
+		if time.Since(lastSend) >= defSSEPingInterval {
+			err = sse.WriteRaw([]byte(":\n\n"))
+			if err != nil {
+				return
+			}
+			lastSend = time.Now()
+		}
+	}
+}
+
+// devState return the state of WUI files and the server, from the epoch
+// of server, the number of files, their total size, and the latest
+// modification time.
+func (k *Karajo) devState() string {
+	var (
+		epoch   int64
+		nfile   int
+		size    int64
+		modTime time.Time
+	)
+	if k.env.revision != nil {
+		epoch, _ = k.env.revision.state()
+	}
+
+	_ = filepath.WalkDir(k.www.Opts.Root, func(_ string, de fs.DirEntry, err error) error {
+		if err != nil || de.IsDir() {
+			return nil
+		}
+		var fi, errInfo = de.Info()
+		if errInfo != nil {
+			return nil
+		}
+		nfile++
+		size += fi.Size()
+		if fi.ModTime().After(modTime) {
+			modTime = fi.ModTime()
+		}
+		return nil
+	})
+
+	return fmt.Sprintf(`%x-%x-%x-%x`, epoch, nfile, size, modTime.UnixNano())
+}
+
+// injectDevJS return the copy of HTML node with the script pathDevJS
+// inserted before the end of "head" element.
+func (k *Karajo) injectDevJS(node *memfs.Node) *memfs.Node {
+	if node.IsDir() || !isHTML(node) || !strings.HasPrefix(node.Path, pathKarajo) {
+		return node
+	}
+
+	var x = bytes.Index(node.Content, []byte(`</head>`))
+	if x < 0 {
+		return node
+	}
+
+	var (
+		script = []byte(`<script type="text/javascript" src="` + k.urlPath(pathDevJS) + `"></script>` + "\n  ")
+		clone  = *node
+	)
+
+	clone.Content = make([]byte, 0, len(node.Content)+len(script))
+	clone.Content = append(clone.Content, node.Content[:x]...)
+	clone.Content = append(clone.Content, script...)
+	clone.Content = append(clone.Content, node.Content[x:]...)
+	clone.SetSize(int64(len(clone.Content)))
+
+	return &clone
+}
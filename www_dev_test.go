@@ -0,0 +1,112 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_development(t *testing.T) {
+	var (
+		env = &Env{
+			DirBase:       t.TempDir(),
+			Secret:        `s3cret`,
+			IsDevelopment: true,
+		}
+
+		k   *Karajo
+		err error
+	)
+
+	k, err = NewEmbedded(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var srv = httptest.NewServer(k.Handler())
+	t.Cleanup(srv.Close)
+
+	t.Run(`inject dev.js`, func(tt *testing.T) {
+		var res *http.Response
+
+		res, err = http.Get(srv.URL + `/karajo/app/`)
+		if err != nil {
+			tt.Fatal(err)
+		}
+		var body strings.Builder
+		_, _ = bufio.NewReader(res.Body).WriteTo(&body)
+		_ = res.Body.Close()
+
+		test.Assert(tt, `Cache-Control`, cacheControlNoStore, res.Header.Get(`Cache-Control`))
+		test.Assert(tt, `script injected`, true, strings.Contains(body.String(),
+			`<script type="text/javascript" src="/karajo/dev.js"></script>`+"\n  </head>"))
+	})
+
+	// Watch the temporary directory instead of _www.
+	var dirWww = t.TempDir()
+	k.www.Opts.Root = dirWww
+
+	var openReload = func(tt *testing.T, lastID string) (rd *bufio.Reader, closeFn func()) {
+		var req, errReq = http.NewRequest(http.MethodGet, srv.URL+apiDevReload, nil)
+		if errReq != nil {
+			tt.Fatal(errReq)
+		}
+		if len(lastID) != 0 {
+			req.Header.Set(`Last-Event-ID`, lastID)
+		}
+		var res, errDo = http.DefaultClient.Do(req)
+		if errDo != nil {
+			tt.Fatal(errDo)
+		}
+		return bufio.NewReader(res.Body), func() { _ = res.Body.Close() }
+	}
+
+	var readEvent = func(tt *testing.T, rd *bufio.Reader) (ev string) {
+		var line string
+		for {
+			line, err = rd.ReadString('\n')
+			if err != nil {
+				tt.Fatal(err)
+			}
+			if line == "\n" {
+				return ev
+			}
+			ev += line
+		}
+	}
+
+	t.Run(`changes`, func(tt *testing.T) {
+		var rd, closeFn = openReload(tt, ``)
+		defer closeFn()
+
+		var state = k.devState()
+		test.Assert(tt, `initial id`, `id:`+state+"\n", readEvent(tt, rd))
+
+		err = os.WriteFile(filepath.Join(dirWww, `index.html`), []byte(`<html>`), 0600)
+		if err != nil {
+			tt.Fatal(err)
+		}
+
+		state = k.devState()
+		test.Assert(tt, `reload`, "event:reload\ndata:"+state+"\nid:"+state+"\n",
+			readEvent(tt, rd))
+	})
+
+	t.Run(`reconnect after restart`, func(tt *testing.T) {
+		var rd, closeFn = openReload(tt, `outdated`)
+		defer closeFn()
+
+		var state = k.devState()
+		test.Assert(tt, `reload`, "event:reload\ndata:"+state+"\nid:"+state+"\n",
+			readEvent(tt, rd))
+	})
+}
@@ -0,0 +1,46 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+)
+
+// cloneMemfs create a copy of the embedded memfs so each Karajo instance
+// can modify the WUI files, for example to prefix the BasePath, without
+// changing the embedded memfs or other instances.
+//
+// The file content is shared with the original, since the WUI files are
+// modified by replacing the node Content, not by changing the content in
+// place.
+func cloneMemfs(orig *memfs.MemFS) (mfs *memfs.MemFS) {
+	var opts = *orig.Opts
+
+	mfs = &memfs.MemFS{
+		PathNodes: memfs.NewPathNode(),
+		Opts:      &opts,
+	}
+	if orig.Root != nil {
+		mfs.Root = cloneMemfsNode(mfs, orig.Root, nil)
+	}
+	return mfs
+}
+
+// cloneMemfsNode copy the node and its childs recursively, and store
+// each of them in the mfs PathNodes.
+func cloneMemfsNode(mfs *memfs.MemFS, orig, parent *memfs.Node) (node *memfs.Node) {
+	var clone = *orig
+
+	node = &clone
+	node.Parent = parent
+	node.Childs = nil
+
+	var child *memfs.Node
+	for _, child = range orig.Childs {
+		node.Childs = append(node.Childs, cloneMemfsNode(mfs, child, node))
+	}
+
+	mfs.PathNodes.Set(node.Path, node)
+	return node
+}
@@ -0,0 +1,67 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestKarajo_initMemfs_perInstance(t *testing.T) {
+	const indexPath = `/karajo/app/index.html`
+
+	var (
+		origIndex = bytes.Clone(memfsWww.MustGet(indexPath).Content)
+
+		kOps = &Karajo{
+			env: &Env{
+				BasePath: `/ops`,
+			},
+		}
+		kDev = &Karajo{
+			env: &Env{
+				BasePath: `/dev`,
+			},
+		}
+		err error
+	)
+
+	err = kOps.initMemfs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = kDev.initMemfs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Initialize the same instance again should not double the
+	// prefix.
+	err = kOps.initMemfs()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		opsIndex = kOps.www.MustGet(indexPath).Content
+		devIndex = kDev.www.MustGet(indexPath).Content
+	)
+
+	test.Assert(t, `ops prefixed`, true, bytes.Contains(opsIndex, []byte(`"/ops/karajo/`)))
+	test.Assert(t, `ops not double prefixed`, false,
+		bytes.Contains(opsIndex, []byte(`/ops/ops/karajo/`)))
+	test.Assert(t, `ops without dev`, false, bytes.Contains(opsIndex, []byte(`/dev/karajo/`)))
+
+	test.Assert(t, `dev prefixed`, true, bytes.Contains(devIndex, []byte(`"/dev/karajo/`)))
+	test.Assert(t, `dev without ops`, false, bytes.Contains(devIndex, []byte(`/ops/karajo/`)))
+
+	test.Assert(t, `embedded unchanged`, string(origIndex),
+		string(memfsWww.MustGet(indexPath).Content))
+
+	var nodeOps = kOps.www.MustGet(indexPath)
+	test.Assert(t, `size`, int64(len(nodeOps.Content)), nodeOps.Size())
+	test.Assert(t, `parent`, kOps.www.MustGet(`/karajo/app`), nodeOps.Parent)
+}
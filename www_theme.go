@@ -0,0 +1,84 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+)
+
+// List of WUI theme.
+const (
+	// wuiThemeAuto follow the theme from browser or operating system
+	// setting.
+	wuiThemeAuto  = `auto`
+	wuiThemeDark  = `dark`
+	wuiThemeLight = `light`
+)
+
+// initWUITheme validate and set the default WUI theme.
+func (env *Env) initWUITheme() (err error) {
+	env.WUITheme = strings.ToLower(strings.TrimSpace(env.WUITheme))
+
+	switch env.WUITheme {
+	case ``:
+		env.WUITheme = wuiThemeAuto
+	case wuiThemeAuto, wuiThemeDark, wuiThemeLight:
+	default:
+		return fmt.Errorf(`initWUITheme: unknown theme %q`, env.WUITheme)
+	}
+	return nil
+}
+
+// initTheme set the default theme in each WUI HTML file, by setting the
+// attribute "data-theme" in the "html" element.
+// The user can override the default theme from the WUI, where its stored
+// in the browser local storage.
+//
+// In development mode the content of files are read directly from disk,
+// so the default theme is always "auto".
+func (k *Karajo) initTheme(mfs *memfs.MemFS) {
+	if k.env.IsDevelopment || mfs.PathNodes == nil {
+		return
+	}
+
+	var (
+		nodes   = mfs.PathNodes.Nodes()
+		htmlTag = []byte(`<html data-theme="` + k.env.WUITheme + `">`)
+
+		node *memfs.Node
+	)
+	for _, node = range nodes {
+		if node.IsDir() || !isHTML(node) ||
+			!strings.HasPrefix(node.Path, pathKarajo) {
+			continue
+		}
+		node.Content = replaceHTMLTag(node.Content, htmlTag)
+		node.SetSize(int64(len(node.Content)))
+	}
+}
+
+// replaceHTMLTag replace the first "html" start tag in content with
+// htmlTag.
+func replaceHTMLTag(content, htmlTag []byte) []byte {
+	var start = bytes.Index(content, []byte(`<html`))
+	if start < 0 {
+		return content
+	}
+
+	var end = bytes.IndexByte(content[start:], '>')
+	if end < 0 {
+		return content
+	}
+	end += start + 1
+
+	var out = make([]byte, 0, len(content)+len(htmlTag))
+	out = append(out, content[:start]...)
+	out = append(out, htmlTag...)
+	out = append(out, content[end:]...)
+	return out
+}
@@ -0,0 +1,121 @@
+// SPDX-FileCopyrightText: 2026 M. Shulhan <ms@kilabit.info>
+// SPDX-License-Identifier: GPL-3.0-or-later
+
+package karajo
+
+import (
+	"bytes"
+	"testing"
+
+	"git.sr.ht/~shulhan/pakakeh.go/lib/memfs"
+	"git.sr.ht/~shulhan/pakakeh.go/lib/test"
+)
+
+func TestEnv_initWUITheme(t *testing.T) {
+	type testCase struct {
+		theme    string
+		exp      string
+		expError string
+	}
+
+	var cases = []testCase{{
+		exp: wuiThemeAuto,
+	}, {
+		theme: ` Dark `,
+		exp:   wuiThemeDark,
+	}, {
+		theme: `light`,
+		exp:   wuiThemeLight,
+	}, {
+		theme:    `blue`,
+		expError: `initWUITheme: unknown theme "blue"`,
+	}}
+
+	var (
+		c   testCase
+		env Env
+		err error
+	)
+	for _, c = range cases {
+		env = Env{
+			WUITheme: c.theme,
+		}
+		err = env.initWUITheme()
+		if err != nil {
+			test.Assert(t, c.theme, c.expError, err.Error())
+			continue
+		}
+		test.Assert(t, c.theme, c.exp, env.WUITheme)
+	}
+}
+
+func TestKarajo_initTheme(t *testing.T) {
+	var (
+		opts = memfs.Options{
+			Root:     `_www`,
+			Includes: []string{`.*\.html$`},
+		}
+
+		mfs *memfs.MemFS
+		err error
+	)
+
+	mfs, err = memfs.New(&opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var k = &Karajo{
+		env: &Env{
+			WUITheme: wuiThemeDark,
+		},
+	}
+
+	k.initTheme(mfs)
+
+	var (
+		node   = mfs.MustGet(`/karajo/app/index.html`)
+		expTag = []byte(`<html data-theme="dark">`)
+	)
+	test.Assert(t, `data-theme`, true, bytes.Contains(node.Content, expTag))
+	test.Assert(t, `size`, int64(len(node.Content)), node.Size())
+
+	// Calling it twice should replace the previous tag.
+	k.env.WUITheme = wuiThemeLight
+	k.initTheme(mfs)
+	test.Assert(t, `replaced`, 1,
+		bytes.Count(node.Content, []byte(`<html data-theme="light">`)))
+	test.Assert(t, `no previous tag`, false, bytes.Contains(node.Content, expTag))
+}
+
+func TestReplaceHTMLTag(t *testing.T) {
+	type testCase struct {
+		content string
+		exp     string
+	}
+
+	var cases = []testCase{{
+		content: `<!DOCTYPE html><html><body></body></html>`,
+		exp:     `<!DOCTYPE html><html data-theme="dark"><body></body></html>`,
+	}, {
+		content: `<html lang="en"><head></head></html>`,
+		exp:     `<html data-theme="dark"><head></head></html>`,
+	}, {
+		content: `<body></body>`,
+		exp:     `<body></body>`,
+	}, {
+		content: `<html`,
+		exp:     `<html`,
+	}}
+
+	var (
+		htmlTag = []byte(`<html data-theme="dark">`)
+
+		c   testCase
+		got []byte
+	)
+	for _, c = range cases {
+		got = replaceHTMLTag([]byte(c.content), htmlTag)
+		test.Assert(t, c.content, c.exp, string(got))
+	}
+}